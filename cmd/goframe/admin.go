@@ -0,0 +1,713 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// adminOptions configures `goframe gen admin`
+type adminOptions struct {
+	Model  string // Go type name of the model
+	Dir    string // Package declaring the model
+	Path   string // URL segment of the pages, e.g. posts
+	Roles  stringList
+	Output string
+	Force  bool
+	Module string
+}
+
+// parseAdminOptions reads `goframe gen admin <Model>` flags
+func parseAdminOptions(model string, args []string) (adminOptions, error) {
+	opts := adminOptions{Model: model}
+
+	fs := flag.NewFlagSet("gen admin", flag.ContinueOnError)
+	fs.StringVar(&opts.Dir, "dir", filepath.Join("internal", "models"), "package declaring the model")
+	fs.StringVar(&opts.Path, "path", "", "URL segment of the pages (default plural of the model)")
+	fs.Var(&opts.Roles, "role", "role allowed to use the pages, repeatable (default admin)")
+	fs.StringVar(&opts.Output, "o", filepath.Join("internal", "admin"), "output directory")
+	fs.BoolVar(&opts.Force, "force", false, "overwrite the pages of this model if they exist")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if model == "" || !token.IsExported(model) {
+		return opts, fmt.Errorf("model must be an exported Go type name, got %q", model)
+	}
+	if len(opts.Roles) == 0 {
+		opts.Roles = stringList{"admin"}
+	}
+	if opts.Path == "" {
+		opts.Path = plural(strings.ToLower(model))
+	}
+	opts.Path = strings.Trim(opts.Path, "/")
+	return opts, nil
+}
+
+// adminField is a model field as the admin pages display it
+type adminField struct {
+	Name     string // Go field name
+	Label    string
+	FormName string // Name the form binding reads
+	Input    string // text, email, url, number, checkbox or textarea
+	Step     string // Number input step
+	Required bool
+	Editable bool
+}
+
+// adminModel holds the field metadata of a model
+type adminModel struct {
+	Name   string
+	Fields []adminField
+}
+
+// List returns the columns of the list page: the ID, then up to four
+// editable fields
+func (m adminModel) List() []adminField {
+	var cols []adminField
+	for _, f := range m.Fields {
+		if f.Name == "ID" || (f.Editable && len(cols) < 5) {
+			cols = append(cols, f)
+		}
+	}
+	return cols
+}
+
+// Form returns the fields of the create and edit forms
+func (m adminModel) Form() []adminField {
+	var fields []adminField
+	for _, f := range m.Fields {
+		if f.Editable {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// readOnlyFields are managed by the database or GORM, never by forms
+var readOnlyFields = map[string]bool{"ID": true, "CreatedAt": true, "UpdatedAt": true, "DeletedAt": true}
+
+// parseAdminModel reads the fields of the model struct declared in dir
+func parseAdminModel(dir, name string) (adminModel, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return adminModel{}, err
+	}
+	sort.Strings(files)
+
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return adminModel{}, err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok && ts.Name.Name == name {
+					return newAdminModel(name, st)
+				}
+			}
+		}
+	}
+	return adminModel{}, fmt.Errorf("struct %s not found in %s (run goframe gen model %s first, or pass --dir)", name, dir, name)
+}
+
+func newAdminModel(name string, st *ast.StructType) (adminModel, error) {
+	model := adminModel{Name: name}
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			raw, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(raw)
+		}
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+
+		// gorm.Model brings ID and timestamps
+		if len(field.Names) == 0 {
+			if sel, ok := field.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "Model" {
+				model.Fields = append(model.Fields,
+					adminField{Name: "ID", Label: "ID"},
+					adminField{Name: "CreatedAt", Label: "Created At"},
+					adminField{Name: "UpdatedAt", Label: "Updated At"})
+			}
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() || jsonName == "-" && !readOnlyFields[ident.Name] || ident.Name == "DeletedAt" {
+				continue
+			}
+			f := adminField{Name: ident.Name, Label: fieldLabel(ident.Name)}
+			if !readOnlyFields[ident.Name] {
+				f.Editable = setAdminInput(&f, field.Type, tag.Get("validate"))
+				f.FormName = tag.Get("form")
+				if f.FormName == "" {
+					f.FormName = strings.ToLower(ident.Name) // What binding.Form reads without a form tag
+				}
+				if f.FormName == "-" {
+					f.Editable = false
+				}
+			}
+			model.Fields = append(model.Fields, f)
+		}
+	}
+
+	hasID := false
+	for _, f := range model.Fields {
+		hasID = hasID || f.Name == "ID"
+	}
+	if !hasID {
+		return model, errors.New("the model needs an ID field, as generated by goframe gen model")
+	}
+	return model, nil
+}
+
+// setAdminInput picks the input for a field type, and reports whether forms
+// can bind it: pointers, slices, structs and time.Time are shown only
+func setAdminInput(f *adminField, expr ast.Expr, validate string) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+
+	rules := strings.Split(validate, ",")
+	f.Required = contains(rules, "required")
+	switch ident.Name {
+	case "string":
+		f.Input = "text"
+		switch {
+		case contains(rules, "email"):
+			f.Input = "email"
+		case contains(rules, "url"):
+			f.Input = "url"
+		case longText(rules):
+			f.Input = "textarea"
+		}
+	case "bool":
+		f.Input, f.Required = "checkbox", false // Unchecked is a valid answer
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		f.Input, f.Step = "number", "1"
+	case "float32", "float64":
+		f.Input, f.Step = "number", "any"
+	default:
+		return false
+	}
+	return true
+}
+
+// longText reports whether a max length rule allows more than one line
+func longText(rules []string) bool {
+	for _, rule := range rules {
+		if v, ok := strings.CutPrefix(rule, "max="); ok {
+			n, err := strconv.Atoi(v)
+			return err == nil && n > 255
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldLabel splits a Go field name into words: CreatedAt is "Created At",
+// HTTPStatus is "HTTP Status"
+func fieldLabel(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "sh"), strings.HasSuffix(s, "ch"):
+		return s + "es"
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
+
+// generateAdmin writes the pages of a model, and the shared admin package
+// files if they do not exist yet
+func generateAdmin(opts adminOptions) ([]string, error) {
+	model, err := parseAdminModel(opts.Dir, opts.Model)
+	if err != nil {
+		return nil, err
+	}
+	if len(model.Form()) == 0 {
+		return nil, fmt.Errorf("%s has no fields forms can edit", opts.Model)
+	}
+
+	roles := make([]string, len(opts.Roles))
+	for i, role := range opts.Roles {
+		roles[i] = strconv.Quote(role)
+	}
+	lower := strings.ToLower(opts.Model)
+	data := map[string]interface{}{
+		"Module": opts.Module,
+		"Name":   opts.Model,
+		"Lower":  lower,
+		"Path":   opts.Path,
+		"Title":  fieldLabel(plural(opts.Model)),
+		"Roles":  strings.Join(roles, ", "),
+		"Model":  model,
+		"Cols":   len(model.List()) + 1,
+	}
+
+	files := []struct {
+		path   string
+		tmpl   string
+		shared bool
+	}{
+		{filepath.Join(opts.Output, "admin.go"), adminSharedGo, true},
+		{filepath.Join(opts.Output, "templates", "layout.html"), adminLayoutHTML, true},
+		{filepath.Join(opts.Output, lower+".go"), adminModelGo, false},
+		{filepath.Join(opts.Output, "templates", lower, "list.html"), adminListHTML, false},
+		{filepath.Join(opts.Output, "templates", lower, "show.html"), adminShowHTML, false},
+		{filepath.Join(opts.Output, "templates", lower, "form.html"), adminFormHTML, false},
+	}
+
+	var written []string
+	for _, f := range files {
+		if _, err := os.Stat(f.path); err == nil {
+			if f.shared {
+				continue
+			}
+			if !opts.Force {
+				return written, fmt.Errorf("%s exists (use --force to overwrite)", f.path)
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil { // #nosec G703 -- pages are written under the user-chosen output directory by design
+			return written, err
+		}
+
+		// [[ ]] delimiters leave the {{ }} of the generated html/template pages alone
+		tmpl, err := template.New(filepath.Base(f.path)).Delims("[[", "]]").Parse(f.tmpl)
+		if err != nil {
+			return written, err
+		}
+		out, err := os.Create(f.path) // #nosec G304 -- generated file path is derived from the model name by design
+		if err != nil {
+			return written, err
+		}
+		err = tmpl.Execute(out, data)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return written, err
+		}
+		written = append(written, f.path)
+	}
+	return written, nil
+}
+
+const adminSharedGo = `// Package admin serves the server-rendered CRUD pages generated by
+// goframe gen admin
+package admin
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"[[.Module]]/pkg/app"
+	"[[.Module]]/pkg/auth"
+	"[[.Module]]/pkg/binding"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html", "templates/*/*.html"))
+
+// page is the data of every admin page
+type page struct {
+	Title  string
+	Base   string // Path of the list page
+	Data   interface{}
+	Errors map[string]string // Messages by Go field name; "_form" for the whole form
+}
+
+func render(w http.ResponseWriter, status int, name string, p page) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, p); err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
+
+// basePath returns the path of the list page from the path of any page of
+// the resource
+func basePath(r *http.Request, id string) string {
+	p := strings.TrimSuffix(r.URL.Path, "/new")
+	if id != "" {
+		if i := strings.LastIndex(p, "/"+id); i >= 0 {
+			p = p[:i]
+		}
+	}
+	return p
+}
+
+// RequireRole allows users whose token carries one of roles. Put the
+// authentication middleware, which stores the claims, in front of it.
+func RequireRole(roles ...string) app.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := auth.GetClaims(r.Context())
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			for _, role := range roles {
+				if claims.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
+
+// SameOrigin rejects form posts sent from other sites, which would otherwise
+// ride on the admin's cookies
+func SameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				origin = r.Header.Get("Referer")
+			}
+			if origin != "" {
+				u, err := url.Parse(origin)
+				if err != nil || u.Host != r.Host {
+					http.Error(w, "Cross-origin request rejected", http.StatusForbidden)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bindForm binds the posted form to item and validates it, returning the
+// messages to display next to the fields
+func bindForm(r *http.Request, item interface{}) map[string]string {
+	if err := binding.Form(r, item); err != nil {
+		return map[string]string{"_form": "Invalid value: " + err.Error()}
+	}
+	if err := binding.Validate(item); err != nil {
+		return fieldErrors(err)
+	}
+	return nil
+}
+
+func fieldErrors(err error) map[string]string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return map[string]string{"_form": err.Error()}
+	}
+
+	errs := make(map[string]string, len(verrs))
+	for _, fe := range verrs {
+		errs[fe.StructField()] = fieldMessage(fe)
+	}
+	return errs
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+	switch fe.Tag() {
+	case "required":
+		return "This field is required"
+	case "email":
+		return "Enter a valid email address"
+	case "url":
+		return "Enter a valid URL"
+	case "min", "gte":
+		return fmt.Sprintf("Must be at least %s%s", fe.Param(), unit)
+	case "max", "lte":
+		return fmt.Sprintf("Must be at most %s%s", fe.Param(), unit)
+	case "len":
+		return fmt.Sprintf("Must be exactly %s%s", fe.Param(), unit)
+	case "oneof":
+		return "Must be one of: " + fe.Param()
+	}
+	return fmt.Sprintf("Failed the %s check", fe.Tag())
+}
+`
+
+const adminModelGo = `package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+	"[[.Module]]/internal/models"
+	"[[.Module]]/pkg/app"
+)
+
+// [[.Name]]Admin serves the [[.Name]] list, detail and edit pages
+type [[.Name]]Admin struct {
+	service *models.[[.Name]]Service
+}
+
+// New[[.Name]]Admin creates the [[.Name]] pages
+func New[[.Name]]Admin(service *models.[[.Name]]Service) *[[.Name]]Admin {
+	return &[[.Name]]Admin{service: service}
+}
+
+// RegisterRoutes mounts the pages under /[[.Path]] of the admin group, for
+// users with the role [[.Roles]]
+func (h *[[.Name]]Admin) RegisterRoutes(admin *app.RouteGroup) {
+	g := admin.Group("/[[.Path]]", RequireRole([[.Roles]]), SameOrigin)
+	g.GET("", h.List)
+	g.POST("", h.Create)
+	g.GET("/new", h.New)
+	g.GET("/{id:[0-9]+}", h.Show)
+	g.POST("/{id:[0-9]+}", h.Update)
+	g.GET("/{id:[0-9]+}/edit", h.Edit)
+	g.POST("/{id:[0-9]+}/delete", h.Delete)
+}
+
+func (h *[[.Name]]Admin) List(w http.ResponseWriter, r *http.Request) {
+	items, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, "Could not load [[.Lower]] records", http.StatusInternalServerError)
+		return
+	}
+	render(w, http.StatusOK, "[[.Lower]]/list", page{Title: "[[.Title]]", Base: basePath(r, ""), Data: items})
+}
+
+func (h *[[.Name]]Admin) Show(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.find(w, r)
+	if ok {
+		render(w, http.StatusOK, "[[.Lower]]/show", page{Title: fmt.Sprintf("[[.Name]] #%d", item.ID), Base: basePath(r, app.NewContext(w, r).Param("id")), Data: item})
+	}
+}
+
+func (h *[[.Name]]Admin) New(w http.ResponseWriter, r *http.Request) {
+	render(w, http.StatusOK, "[[.Lower]]/form", page{Title: "New [[.Name]]", Base: basePath(r, ""), Data: &models.[[.Name]]{}})
+}
+
+func (h *[[.Name]]Admin) Create(w http.ResponseWriter, r *http.Request) {
+	item := &models.[[.Name]]{}
+	base := basePath(r, "")
+	if errs := bindForm(r, item); errs != nil {
+		render(w, http.StatusUnprocessableEntity, "[[.Lower]]/form", page{Title: "New [[.Name]]", Base: base, Data: item, Errors: errs})
+		return
+	}
+	if err := h.service.Create(item); err != nil {
+		render(w, http.StatusInternalServerError, "[[.Lower]]/form", page{Title: "New [[.Name]]", Base: base, Data: item, Errors: map[string]string{"_form": "Could not save: " + err.Error()}})
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("%s/%d", base, item.ID), http.StatusSeeOther)
+}
+
+func (h *[[.Name]]Admin) Edit(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.find(w, r)
+	if ok {
+		render(w, http.StatusOK, "[[.Lower]]/form", page{Title: fmt.Sprintf("Edit [[.Name]] #%d", item.ID), Base: basePath(r, app.NewContext(w, r).Param("id")), Data: item})
+	}
+}
+
+func (h *[[.Name]]Admin) Update(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+	title := fmt.Sprintf("Edit [[.Name]] #%d", item.ID)
+	base := basePath(r, app.NewContext(w, r).Param("id"))
+	if errs := bindForm(r, item); errs != nil {
+		render(w, http.StatusUnprocessableEntity, "[[.Lower]]/form", page{Title: title, Base: base, Data: item, Errors: errs})
+		return
+	}
+	if err := h.service.Update(item); err != nil {
+		render(w, http.StatusInternalServerError, "[[.Lower]]/form", page{Title: title, Base: base, Data: item, Errors: map[string]string{"_form": "Could not save: " + err.Error()}})
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("%s/%d", base, item.ID), http.StatusSeeOther)
+}
+
+func (h *[[.Name]]Admin) Delete(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(item.ID); err != nil {
+		http.Error(w, "Could not delete the [[.Lower]]", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, basePath(r, app.NewContext(w, r).Param("id")), http.StatusSeeOther)
+}
+
+// find loads the record of the id route parameter, answering 404 when it
+// does not exist
+func (h *[[.Name]]Admin) find(w http.ResponseWriter, r *http.Request) (*models.[[.Name]], bool) {
+	id, err := strconv.ParseUint(app.NewContext(w, r).Param("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	item, err := h.service.GetByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Could not load the [[.Lower]]", http.StatusInternalServerError)
+		return nil, false
+	}
+	return item, true
+}
+`
+
+const adminLayoutHTML = `{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · Admin</title>
+<style>
+body { font: 15px/1.5 system-ui, sans-serif; margin: 0; color: #1f2328; background: #f6f8fa; }
+main { max-width: 960px; margin: 2rem auto; padding: 0 1rem; }
+.bar { display: flex; align-items: center; justify-content: space-between; gap: 1rem; }
+table { width: 100%; border-collapse: collapse; background: #fff; }
+th, td { padding: .5rem .75rem; border-bottom: 1px solid #d0d7de; text-align: left; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: .5rem 1.5rem; background: #fff; padding: 1rem; }
+dt { font-weight: 600; }
+form.edit { display: grid; gap: 1rem; background: #fff; padding: 1rem; }
+label { display: block; font-weight: 600; margin-bottom: .25rem; }
+input[type=text], input[type=email], input[type=url], input[type=number], textarea { width: 100%; box-sizing: border-box; padding: .4rem; font: inherit; }
+textarea { min-height: 8rem; }
+.button, button { display: inline-block; padding: .4rem .9rem; border: 1px solid #1f6feb; border-radius: 4px; background: #1f6feb; color: #fff; font: inherit; text-decoration: none; cursor: pointer; }
+.danger { background: #cf222e; border-color: #cf222e; }
+.error { color: #cf222e; margin: .25rem 0 0; }
+.invalid { border: 1px solid #cf222e; }
+</style>
+</head>
+<body>
+<main>
+{{end}}
+
+{{define "footer"}}</main>
+</body>
+</html>
+{{end}}
+`
+
+const adminListHTML = `{{define "[[.Lower]]/list"}}{{template "header" .}}
+<div class="bar">
+  <h1>{{.Title}}</h1>
+  <a class="button" href="{{.Base}}/new">New [[.Name]]</a>
+</div>
+<table>
+  <thead>
+    <tr>[[range .Model.List]]<th>[[.Label]]</th>[[end]]<th></th></tr>
+  </thead>
+  <tbody>
+  {{range .Data}}
+    <tr>[[range .Model.List]]<td>{{.[[.Name]]}}</td>[[end]]<td><a href="{{$.Base}}/{{.ID}}">View</a> · <a href="{{$.Base}}/{{.ID}}/edit">Edit</a></td></tr>
+  {{else}}
+    <tr><td colspan="[[.Cols]]">No records yet.</td></tr>
+  {{end}}
+  </tbody>
+</table>
+{{template "footer" .}}{{end}}
+`
+
+const adminShowHTML = `{{define "[[.Lower]]/show"}}{{template "header" .}}
+<div class="bar">
+  <h1>{{.Title}}</h1>
+  <a href="{{.Base}}">All [[.Title]]</a>
+</div>
+<dl>
+[[- range .Model.Fields]]
+  <dt>[[.Label]]</dt><dd>{{.Data.[[.Name]]}}</dd>
+[[- end]]
+</dl>
+<div class="bar">
+  <a class="button" href="{{.Base}}/{{.Data.ID}}/edit">Edit</a>
+  <form method="post" action="{{.Base}}/{{.Data.ID}}/delete" onsubmit="return confirm('Delete this record?')">
+    <button class="danger" type="submit">Delete</button>
+  </form>
+</div>
+{{template "footer" .}}{{end}}
+`
+
+const adminFormHTML = `{{define "[[.Lower]]/form"}}{{template "header" .}}
+<div class="bar">
+  <h1>{{.Title}}</h1>
+  <a href="{{.Base}}">All [[.Title]]</a>
+</div>
+{{with index .Errors "_form"}}<p class="error">{{.}}</p>{{end}}
+<form class="edit" method="post" action="{{.Base}}{{if .Data.ID}}/{{.Data.ID}}{{end}}" novalidate>
+[[- range .Model.Form]]
+  <div>
+  [[- if eq .Input "checkbox"]]
+    <label><input type="checkbox" name="[[.FormName]]" value="true"{{if .Data.[[.Name]]}} checked{{end}}> [[.Label]]</label>
+    <input type="hidden" name="[[.FormName]]" value="false">
+  [[- else]]
+    <label for="f-[[.FormName]]">[[.Label]]</label>
+    [[- if eq .Input "textarea"]]
+    <textarea id="f-[[.FormName]]" name="[[.FormName]]"{{if index .Errors "[[.Name]]"}} class="invalid"{{end}}[[if .Required]] required[[end]]>{{.Data.[[.Name]]}}</textarea>
+    [[- else]]
+    <input id="f-[[.FormName]]" type="[[.Input]]" name="[[.FormName]]" value="{{.Data.[[.Name]]}}"[[if .Step]] step="[[.Step]]"[[end]]{{if index .Errors "[[.Name]]"}} class="invalid"{{end}}[[if .Required]] required[[end]]>
+    [[- end]]
+  [[- end]]
+    {{with index .Errors "[[.Name]]"}}<p class="error">{{.}}</p>{{end}}
+  </div>
+[[- end]]
+  <div><button type="submit">Save</button></div>
+</form>
+{{template "footer" .}}{{end}}
+`
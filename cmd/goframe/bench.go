@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// benchOptions configures `goframe bench`
+type benchOptions struct {
+	Method      string
+	Path        string
+	BaseURL     string
+	AdminURL    string // Admin listener serving /debug/routes, used to validate Path
+	RPS         int
+	Duration    time.Duration
+	Concurrency int
+	Token       string
+	Data        string // Request body template, or @file
+	Headers     stringList
+	Timeout     time.Duration
+}
+
+func handleBench() {
+	var opts benchOptions
+
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	fs.StringVar(&opts.Method, "X", "", "HTTP method (default GET, or POST with --data)")
+	fs.StringVar(&opts.BaseURL, "base-url", "http://localhost:8080", "application URL")
+	fs.StringVar(&opts.AdminURL, "admin", os.Getenv("GOFRAME_ADMIN_URL"), "admin listener URL used to validate the route")
+	fs.IntVar(&opts.RPS, "rps", 50, "requests per second")
+	fs.DurationVar(&opts.Duration, "duration", 10*time.Second, "test duration")
+	fs.IntVar(&opts.Concurrency, "concurrency", 100, "maximum requests in flight")
+	fs.StringVar(&opts.Token, "token", os.Getenv("GOFRAME_BENCH_TOKEN"), "bearer token sent in Authorization")
+	fs.StringVar(&opts.Data, "data", "", "request body template, or @file")
+	fs.Var(&opts.Headers, "H", "request header \"Name: value\", repeatable")
+	fs.DurationVar(&opts.Timeout, "timeout", 30*time.Second, "request timeout")
+
+	// The route may come before or after the flags
+	args := os.Args[2:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		opts.Path, args = args[0], args[1:]
+	}
+	_ = fs.Parse(args)
+	if opts.Path == "" && fs.NArg() > 0 {
+		opts.Path = fs.Arg(0)
+	}
+	if opts.Path == "" {
+		fmt.Println("Usage: goframe bench <route> [--rps 50] [--duration 10s] [-X POST] [--data '{...}'] [--token ...]")
+		os.Exit(1)
+	}
+
+	result, err := runBench(opts, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	result.print(os.Stdout)
+	if result.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// benchResult aggregates the responses of a run
+type benchResult struct {
+	Sent      int
+	Dropped   int // Ticks skipped because Concurrency requests were in flight
+	Errors    int // Transport errors and 5xx responses
+	Statuses  map[int]int
+	Latencies []time.Duration
+	Elapsed   time.Duration
+}
+
+// benchVars are available to path and body templates
+type benchVars struct {
+	N int64 // Request sequence number, from 1
+}
+
+var benchFuncs = template.FuncMap{
+	"uuid": func() string {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		h := hex.EncodeToString(b)
+		return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	},
+	"randInt": func(min, max int64) int64 {
+		if max <= min {
+			return min
+		}
+		n, _ := rand.Int(rand.Reader, big.NewInt(max-min+1))
+		return min + n.Int64()
+	},
+	"now": func() string { return time.Now().UTC().Format(time.RFC3339) },
+}
+
+// runBench sends Method Path at a constant rate for Duration. Path and body
+// are templates rendered per request, e.g. /users/{{randInt 1 1000}}.
+func runBench(opts benchOptions, out io.Writer) (*benchResult, error) {
+	if opts.RPS <= 0 || opts.Duration <= 0 || opts.Concurrency <= 0 {
+		return nil, fmt.Errorf("--rps, --duration and --concurrency must be positive")
+	}
+
+	body := opts.Data
+	if strings.HasPrefix(body, "@") {
+		content, err := os.ReadFile(body[1:]) // #nosec G304 -- the payload file is chosen by the developer
+		if err != nil {
+			return nil, err
+		}
+		body = string(content)
+	}
+	if opts.Method == "" {
+		opts.Method = http.MethodGet
+		if body != "" {
+			opts.Method = http.MethodPost
+		}
+	}
+	opts.Method = strings.ToUpper(opts.Method)
+
+	pathTmpl, err := template.New("path").Funcs(benchFuncs).Parse(opts.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid route template: %w", err)
+	}
+	bodyTmpl, err := template.New("body").Funcs(benchFuncs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+
+	headers := http.Header{}
+	for _, h := range opts.Headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q", h)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	if body != "" && headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", "application/json")
+	}
+	if opts.Token != "" {
+		headers.Set("Authorization", "Bearer "+opts.Token)
+	}
+
+	if opts.AdminURL != "" {
+		sample, err := render(pathTmpl, benchVars{N: 1})
+		if err != nil {
+			return nil, err
+		}
+		if err := validateBenchRoute(opts.AdminURL, opts.Method, sample); err != nil {
+			return nil, err
+		}
+	} else {
+		fmt.Fprintln(out, "⚠ Route not validated: pass --admin with the admin listener URL")
+	}
+
+	fmt.Fprintf(out, "Benchmarking %s %s%s at %d req/s for %s\n", opts.Method, strings.TrimRight(opts.BaseURL, "/"), opts.Path, opts.RPS, opts.Duration)
+
+	client := &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        opts.Concurrency,
+			MaxIdleConnsPerHost: opts.Concurrency,
+		},
+	}
+
+	result := &benchResult{Statuses: make(map[int]int)}
+	var mu sync.Mutex
+	var seq atomic.Int64
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+
+	send := func() {
+		defer func() { <-slots; wg.Done() }()
+
+		vars := benchVars{N: seq.Add(1)}
+		path, err := render(pathTmpl, vars)
+		var payload string
+		if err == nil {
+			payload, err = render(bodyTmpl, vars)
+		}
+		var status int
+		start := time.Now()
+		if err == nil {
+			status, err = benchRequest(client, opts.Method, strings.TrimRight(opts.BaseURL, "/")+path, payload, headers)
+		}
+		latency := time.Since(start)
+
+		mu.Lock()
+		defer mu.Unlock()
+		result.Sent++
+		if err != nil {
+			result.Errors++
+			result.Statuses[0]++
+			return
+		}
+		result.Statuses[status]++
+		if status >= 500 {
+			result.Errors++
+		}
+		result.Latencies = append(result.Latencies, latency)
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(opts.RPS))
+	defer ticker.Stop()
+	deadline := time.After(opts.Duration)
+	began := time.Now()
+
+loop:
+	for {
+		select {
+		case <-deadline:
+			break loop
+		case <-ticker.C:
+			select {
+			case slots <- struct{}{}:
+				wg.Add(1)
+				go send()
+			default:
+				mu.Lock()
+				result.Dropped++
+				mu.Unlock()
+			}
+		}
+	}
+	wg.Wait()
+	result.Elapsed = time.Since(began)
+	return result, nil
+}
+
+func render(tmpl *template.Template, vars benchVars) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func benchRequest(client *http.Client, method, url, body string, headers http.Header) (int, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header = headers.Clone()
+
+	resp, err := client.Do(req) // #nosec G107 -- the target is the developer's own application
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// routeVar matches a mux path variable, with an optional pattern
+var routeVar = regexp.MustCompile(`\{([^{}:]+)(?::((?:[^{}]|\{[^{}]*\})*))?\}`)
+
+// validateBenchRoute checks method and path against the routes listed by
+// the admin listener at /debug/routes
+func validateBenchRoute(adminURL, method, path string) error {
+	resp, err := http.Get(strings.TrimRight(adminURL, "/") + "/debug/routes") // #nosec G107 -- the admin URL is chosen by the developer
+	if err != nil {
+		return fmt.Errorf("cannot list routes: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot list routes: %s returned %s", adminURL, resp.Status)
+	}
+
+	var routes []struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&routes); err != nil {
+		return fmt.Errorf("cannot list routes: %w", err)
+	}
+
+	path, _, _ = strings.Cut(path, "?")
+	var methods []string
+	for _, route := range routes {
+		if !matchRoute(route.Path, path) {
+			continue
+		}
+		if route.Method == method || route.Method == "ANY" || method == http.MethodHead && route.Method == http.MethodGet {
+			return nil
+		}
+		methods = append(methods, route.Method)
+	}
+	if len(methods) > 0 {
+		sort.Strings(methods)
+		return fmt.Errorf("%s is not allowed on %s (allowed: %s)", method, path, strings.Join(methods, ", "))
+	}
+	return fmt.Errorf("no route matches %s", path)
+}
+
+// matchRoute reports whether path matches a mux path template
+func matchRoute(template, path string) bool {
+	var pattern bytes.Buffer
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range routeVar.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		if loc[4] >= 0 {
+			pattern.WriteString("(?:" + template[loc[4]:loc[5]] + ")")
+		} else {
+			pattern.WriteString("[^/]+")
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]) + "$")
+
+	re, err := regexp.Compile(pattern.String())
+	return err == nil && re.MatchString(path)
+}
+
+// percentile returns the p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func (r *benchResult) print(w io.Writer) {
+	sort.Slice(r.Latencies, func(i, j int) bool { return r.Latencies[i] < r.Latencies[j] })
+
+	fmt.Fprintf(w, "\nRequests:   %d sent, %.1f req/s achieved", r.Sent, float64(r.Sent)/r.Elapsed.Seconds())
+	if r.Dropped > 0 {
+		fmt.Fprintf(w, ", %d not sent (concurrency limit reached)", r.Dropped)
+	}
+	fmt.Fprintln(w)
+
+	rate := 0.0
+	if r.Sent > 0 {
+		rate = float64(r.Errors) / float64(r.Sent) * 100
+	}
+	fmt.Fprintf(w, "Errors:     %d (%.2f%%)\n", r.Errors, rate)
+
+	if len(r.Latencies) > 0 {
+		fmt.Fprintf(w, "Latency:    p50 %s  p90 %s  p95 %s  p99 %s  max %s\n",
+			percentile(r.Latencies, 50).Round(time.Microsecond),
+			percentile(r.Latencies, 90).Round(time.Microsecond),
+			percentile(r.Latencies, 95).Round(time.Microsecond),
+			percentile(r.Latencies, 99).Round(time.Microsecond),
+			r.Latencies[len(r.Latencies)-1].Round(time.Microsecond))
+	}
+
+	codes := make([]int, 0, len(r.Statuses))
+	for code := range r.Statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	fmt.Fprint(w, "Status:    ")
+	for _, code := range codes {
+		label := fmt.Sprint(code)
+		if code == 0 {
+			label = "failed"
+		}
+		fmt.Fprintf(w, " %s×%d", label, r.Statuses[code])
+	}
+	fmt.Fprintln(w)
+}
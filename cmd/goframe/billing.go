@@ -0,0 +1,495 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// billingOptions configures `goframe gen billing`
+type billingOptions struct {
+	Module string
+	Output string
+	Force  bool
+}
+
+// parseBillingOptions reads `goframe gen billing` flags
+func parseBillingOptions(args []string) (billingOptions, error) {
+	var opts billingOptions
+
+	fs := flag.NewFlagSet("gen billing", flag.ContinueOnError)
+	fs.StringVar(&opts.Output, "o", filepath.Join("internal", "billing"), "output directory")
+	fs.BoolVar(&opts.Force, "force", false, "overwrite existing files")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// generateBilling writes plans, subscriptions, their lifecycle and the HTTP
+// endpoints into the project, mirroring pkg/billing of the framework
+func generateBilling(opts billingOptions) ([]string, error) {
+	files := []struct {
+		name string
+		tmpl string
+	}{
+		{"billing.go", billingGo},
+		{"service.go", billingServiceGo},
+		{"handlers.go", billingHandlersGo},
+	}
+
+	var written []string
+	for _, f := range files {
+		path := filepath.Join(opts.Output, f.name)
+		if _, err := os.Stat(path); err == nil && !opts.Force {
+			return written, fmt.Errorf("%s exists (use --force to overwrite)", path)
+		}
+		if err := os.MkdirAll(opts.Output, 0755); err != nil { // #nosec G703 -- files are written under the user-chosen output directory by design
+			return written, err
+		}
+
+		tmpl, err := template.New(f.name).Parse(f.tmpl)
+		if err != nil {
+			return written, err
+		}
+		out, err := os.Create(path) // #nosec G304 -- files are written under the user-chosen output directory by design
+		if err != nil {
+			return written, err
+		}
+		err = tmpl.Execute(out, opts)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+const billingGo = `// Package billing provides plans, subscriptions and their lifecycle for SaaS
+// products. It is gateway-agnostic: payment providers report what happened
+// through webhook events, which drive subscription state transitions.
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Interval is a billing period length
+type Interval string
+
+const (
+	Monthly Interval = "month"
+	Yearly  Interval = "year"
+)
+
+// Status is the state of a subscription
+type Status string
+
+const (
+	StatusTrialing Status = "trialing"
+	StatusActive   Status = "active"
+	StatusPastDue  Status = "past_due"
+	StatusCanceled Status = "canceled"
+)
+
+// EventType identifies a billing event reported by the payment gateway
+type EventType string
+
+const (
+	EventPaymentSucceeded EventType = "payment.succeeded"
+	EventPaymentFailed    EventType = "payment.failed"
+	EventTrialEnded       EventType = "trial.ended"
+	EventCanceled         EventType = "subscription.canceled"
+	EventPeriodEnded      EventType = "period.ended"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid subscription state transition")
+	ErrPlanNotFound      = errors.New("plan not found")
+)
+
+// Plan is a purchasable price point
+type Plan struct {
+	ID         uint     ` + "`" + `json:"id" gorm:"primarykey"` + "`" + `
+	Code       string   ` + "`" + `json:"code" gorm:"size:64;uniqueIndex"` + "`" + `
+	Name       string   ` + "`" + `json:"name" gorm:"size:128"` + "`" + `
+	PriceCents int64    ` + "`" + `json:"price_cents"` + "`" + `
+	Currency   string   ` + "`" + `json:"currency" gorm:"size:3"` + "`" + `
+	Interval   Interval ` + "`" + `json:"interval" gorm:"size:16"` + "`" + `
+	TrialDays  int      ` + "`" + `json:"trial_days"` + "`" + `
+	Active     bool     ` + "`" + `json:"active"` + "`" + `
+
+	CreatedAt time.Time ` + "`" + `json:"created_at"` + "`" + `
+	UpdatedAt time.Time ` + "`" + `json:"updated_at"` + "`" + `
+}
+
+// Subscription links a customer to a plan
+type Subscription struct {
+	ID                 uint       ` + "`" + `json:"id" gorm:"primarykey"` + "`" + `
+	CustomerID         string     ` + "`" + `json:"customer_id" gorm:"size:64;index"` + "`" + `
+	PlanID             uint       ` + "`" + `json:"plan_id"` + "`" + `
+	Plan               Plan       ` + "`" + `json:"plan"` + "`" + `
+	Status             Status     ` + "`" + `json:"status" gorm:"size:16;index"` + "`" + `
+	ExternalID         string     ` + "`" + `json:"external_id" gorm:"size:128;index"` + "`" + ` // Gateway subscription ID
+	TrialEndsAt        *time.Time ` + "`" + `json:"trial_ends_at"` + "`" + `
+	CurrentPeriodStart time.Time  ` + "`" + `json:"current_period_start"` + "`" + `
+	CurrentPeriodEnd   time.Time  ` + "`" + `json:"current_period_end"` + "`" + `
+	CancelAtPeriodEnd  bool       ` + "`" + `json:"cancel_at_period_end"` + "`" + `
+	CanceledAt         *time.Time ` + "`" + `json:"canceled_at"` + "`" + `
+
+	CreatedAt time.Time ` + "`" + `json:"created_at"` + "`" + `
+	UpdatedAt time.Time ` + "`" + `json:"updated_at"` + "`" + `
+}
+
+// Event is a gateway notification, typically parsed from a webhook
+type Event struct {
+	Type       EventType ` + "`" + `json:"type"` + "`" + `
+	ExternalID string    ` + "`" + `json:"external_id"` + "`" + `
+	OccurredAt time.Time ` + "`" + `json:"occurred_at"` + "`" + `
+}
+
+// NewSubscription starts a subscription at now, in trial if the plan has one
+func NewSubscription(customerID string, plan Plan, now time.Time) *Subscription {
+	s := &Subscription{
+		CustomerID:         customerID,
+		PlanID:             plan.ID,
+		Plan:               plan,
+		Status:             StatusActive,
+		CurrentPeriodStart: now,
+		CurrentPeriodEnd:   AddInterval(now, plan.Interval),
+	}
+
+	if plan.TrialDays > 0 {
+		trialEnd := now.AddDate(0, 0, plan.TrialDays)
+		s.Status = StatusTrialing
+		s.TrialEndsAt = &trialEnd
+		s.CurrentPeriodEnd = trialEnd
+	}
+
+	return s
+}
+
+// Apply transitions the subscription according to the event:
+//
+//	trialing --payment.succeeded--> active (new period)
+//	trialing --trial.ended--------> past_due
+//	active   --payment.succeeded--> active (renewed period)
+//	active   --payment.failed-----> past_due
+//	past_due --payment.succeeded--> active (new period)
+//	any      --subscription.canceled--> canceled
+//	active   --period.ended-------> canceled, when CancelAtPeriodEnd is set
+func (s *Subscription) Apply(e Event) error {
+	at := e.OccurredAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	if s.Status == StatusCanceled {
+		return fmt.Errorf("%w: %s on %s subscription", ErrInvalidTransition, e.Type, s.Status)
+	}
+
+	switch e.Type {
+	case EventPaymentSucceeded:
+		s.Status = StatusActive
+		s.TrialEndsAt = nil
+		s.startPeriod(at)
+
+	case EventPaymentFailed:
+		if s.Status != StatusActive && s.Status != StatusPastDue {
+			return fmt.Errorf("%w: %s on %s subscription", ErrInvalidTransition, e.Type, s.Status)
+		}
+		s.Status = StatusPastDue
+
+	case EventTrialEnded:
+		if s.Status != StatusTrialing {
+			return fmt.Errorf("%w: %s on %s subscription", ErrInvalidTransition, e.Type, s.Status)
+		}
+		s.Status = StatusPastDue
+
+	case EventCanceled:
+		s.cancel(at)
+
+	case EventPeriodEnded:
+		if s.CancelAtPeriodEnd {
+			s.cancel(at)
+		}
+
+	default:
+		return fmt.Errorf("unknown billing event: %s", e.Type)
+	}
+
+	return nil
+}
+
+// Active reports whether the customer should have access
+func (s *Subscription) Active() bool {
+	return s.Status == StatusTrialing || s.Status == StatusActive
+}
+
+func (s *Subscription) startPeriod(at time.Time) {
+	// Renewals continue from the previous period end so periods stay aligned
+	start := at
+	if d := at.Sub(s.CurrentPeriodEnd); !s.CurrentPeriodEnd.IsZero() && d > -24*time.Hour && d < 24*time.Hour {
+		start = s.CurrentPeriodEnd
+	}
+	s.CurrentPeriodStart = start
+	s.CurrentPeriodEnd = AddInterval(start, s.Plan.Interval)
+}
+
+func (s *Subscription) cancel(at time.Time) {
+	s.Status = StatusCanceled
+	s.CanceledAt = &at
+}
+
+// AddInterval returns t advanced by one billing interval
+func AddInterval(t time.Time, interval Interval) time.Time {
+	if interval == Yearly {
+		return t.AddDate(1, 0, 0)
+	}
+	return t.AddDate(0, 1, 0)
+}
+
+// Prorate returns the amount in cents to charge (positive) or credit
+// (negative) when switching from one plan to another at the given time
+// within the current period. Unused time on the old plan is credited and
+// the remaining time on the new plan is charged, both to the second.
+// Plans must share a currency.
+func Prorate(from, to Plan, periodStart, periodEnd, at time.Time) int64 {
+	total := periodEnd.Sub(periodStart)
+	if total <= 0 || !at.Before(periodEnd) {
+		return 0
+	}
+	if at.Before(periodStart) {
+		at = periodStart
+	}
+
+	remaining := periodEnd.Sub(at)
+	fraction := float64(remaining) / float64(total)
+
+	credit := roundCents(float64(from.PriceCents) * fraction)
+	charge := roundCents(float64(to.PriceCents) * fraction)
+	return charge - credit
+}
+
+func roundCents(v float64) int64 {
+	if v < 0 {
+		return -int64(-v + 0.5)
+	}
+	return int64(v + 0.5)
+}
+`
+
+const billingServiceGo = `package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Service persists plans and subscriptions with GORM
+type Service struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewService creates a billing service on the given database
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// Migrate creates the billing tables
+func (s *Service) Migrate() error {
+	return s.db.AutoMigrate(&Plan{}, &Subscription{})
+}
+
+// CreatePlan stores a new plan
+func (s *Service) CreatePlan(ctx context.Context, plan *Plan) error {
+	return s.db.WithContext(ctx).Create(plan).Error
+}
+
+// Plans returns the active plans, cheapest first
+func (s *Service) Plans(ctx context.Context) ([]Plan, error) {
+	var plans []Plan
+	err := s.db.WithContext(ctx).Where("active = ?", true).Order("price_cents").Find(&plans).Error
+	return plans, err
+}
+
+// PlanByCode returns an active plan by code
+func (s *Service) PlanByCode(ctx context.Context, code string) (*Plan, error) {
+	var plan Plan
+	err := s.db.WithContext(ctx).Where("code = ? AND active = ?", code, true).First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlanNotFound
+	}
+	return &plan, err
+}
+
+// Subscribe starts a subscription to the plan for the customer. externalID
+// is the gateway's subscription ID, used to match later webhook events.
+func (s *Service) Subscribe(ctx context.Context, customerID, planCode, externalID string) (*Subscription, error) {
+	plan, err := s.PlanByCode(ctx, planCode)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := NewSubscription(customerID, *plan, s.now())
+	sub.ExternalID = externalID
+	if err := s.db.WithContext(ctx).Create(sub).Error; err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ChangePlan moves the subscription to another plan and returns the prorated
+// amount in cents to charge (positive) or credit (negative) through the gateway
+func (s *Service) ChangePlan(ctx context.Context, subscriptionID uint, planCode string) (int64, error) {
+	plan, err := s.PlanByCode(ctx, planCode)
+	if err != nil {
+		return 0, err
+	}
+
+	var amount int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sub Subscription
+		if err := tx.Preload("Plan").First(&sub, subscriptionID).Error; err != nil {
+			return err
+		}
+		if sub.Plan.Currency != plan.Currency {
+			return fmt.Errorf("cannot change from %s to %s plan", sub.Plan.Currency, plan.Currency)
+		}
+
+		if sub.Status == StatusActive {
+			amount = Prorate(sub.Plan, *plan, sub.CurrentPeriodStart, sub.CurrentPeriodEnd, s.now())
+		}
+		sub.PlanID = plan.ID
+		sub.Plan = *plan
+		return tx.Omit("Plan").Save(&sub).Error
+	})
+
+	return amount, err
+}
+
+// HandleEvent applies a gateway event to the matching subscription
+func (s *Service) HandleEvent(ctx context.Context, e Event) (*Subscription, error) {
+	var sub Subscription
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Plan").Where("external_id = ?", e.ExternalID).First(&sub).Error; err != nil {
+			return err
+		}
+		if err := sub.Apply(e); err != nil {
+			return err
+		}
+		return tx.Omit("Plan").Save(&sub).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// WebhookHandler returns a handler applying gateway webhooks. parse verifies
+// the gateway signature and maps the payload to an Event; when nil, the body
+// is decoded as an Event directly, which is only suitable for trusted callers.
+func WebhookHandler(s *Service, parse func(r *http.Request) (Event, error)) http.HandlerFunc {
+	if parse == nil {
+		parse = func(r *http.Request) (Event, error) {
+			var e Event
+			err := json.NewDecoder(r.Body).Decode(&e)
+			return e, err
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := parse(r)
+		if err != nil {
+			http.Error(w, "Invalid webhook", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := s.HandleEvent(r.Context(), e); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				// Acknowledge events for unknown subscriptions so the gateway stops retrying
+				w.WriteHeader(http.StatusOK)
+			case errors.Is(err, ErrInvalidTransition):
+				log.Printf("Ignoring billing event: %v", err)
+				w.WriteHeader(http.StatusOK)
+			default:
+				log.Printf("Failed to handle billing event: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+`
+
+const billingHandlersGo = `package billing
+
+import (
+	"errors"
+	"net/http"
+
+	"{{.Module}}/pkg/app"
+)
+
+// subscribeRequest is the body of POST /subscriptions
+type subscribeRequest struct {
+	CustomerID string ` + "`" + `json:"customer_id"` + "`" + `
+	Plan       string ` + "`" + `json:"plan"` + "`" + `
+	ExternalID string ` + "`" + `json:"external_id"` + "`" + `
+}
+
+// RegisterRoutes mounts the plan listing, subscription and webhook endpoints.
+// parse verifies the gateway signature of webhooks, see WebhookHandler.
+func RegisterRoutes(g *app.RouteGroup, s *Service, parse func(r *http.Request) (Event, error)) {
+	g.GET("/plans", func(w http.ResponseWriter, r *http.Request) {
+		ctx := app.NewContext(w, r)
+		plans, err := s.Plans(r.Context())
+		if err != nil {
+			ctx.JSONError(http.StatusInternalServerError, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, plans)
+	})
+
+	g.POST("/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		ctx := app.NewContext(w, r)
+		var req subscribeRequest
+		if err := ctx.Bind(&req); err != nil || req.CustomerID == "" || req.Plan == "" {
+			ctx.JSONError(http.StatusBadRequest, errors.New("customer_id and plan are required"))
+			return
+		}
+
+		sub, err := s.Subscribe(r.Context(), req.CustomerID, req.Plan, req.ExternalID)
+		if errors.Is(err, ErrPlanNotFound) {
+			ctx.JSONError(http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			ctx.JSONError(http.StatusInternalServerError, err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, sub)
+	})
+
+	g.POST("/webhooks/billing", WebhookHandler(s, parse))
+}
+`
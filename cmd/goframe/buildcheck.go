@@ -0,0 +1,710 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/database"
+	"go.yaml.in/yaml/v3"
+	"gorm.io/gorm/logger"
+)
+
+// buildOptions configures `goframe build`
+type buildOptions struct {
+	Output        string
+	NoCheck       bool
+	Base          string // Git ref of the last release (default the latest tag)
+	Spec          string // OpenAPI document
+	MigrationsDir string
+	ShadowURL     string // Empty database the migrations are applied to
+	AllowBreaking bool   // Report breaking API changes as warnings
+}
+
+func parseBuildOptions(args []string) (buildOptions, error) {
+	opts := buildOptions{Output: "bin/app"}
+	// The output path may precede the flags: goframe build bin/api --base v1.2.0
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		opts.Output, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.BoolVar(&opts.NoCheck, "no-check", false, "skip the pre-build checks")
+	fs.StringVar(&opts.Base, "base", "", "git ref of the last release (default the latest tag)")
+	fs.StringVar(&opts.Spec, "spec", "openapi.yaml", "OpenAPI document compared with the last release")
+	fs.StringVar(&opts.MigrationsDir, "migrations", "migrations", "migrations directory")
+	fs.StringVar(&opts.ShadowURL, "shadow-database-url", os.Getenv("SHADOW_DATABASE_URL"), "empty database to apply migrations to (default $SHADOW_DATABASE_URL)")
+	fs.BoolVar(&opts.AllowBreaking, "allow-breaking", false, "report breaking API and proto changes without failing")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		opts.Output = fs.Arg(0)
+	}
+	return opts, nil
+}
+
+// runBuildChecks prints the report of the pre-build checks and reports
+// whether they passed
+func runBuildChecks(opts buildOptions) bool {
+	if opts.Base == "" {
+		if out, err := exec.Command("git", "describe", "--tags", "--abbrev=0").Output(); err == nil {
+			opts.Base = strings.TrimSpace(string(out))
+		}
+	}
+
+	checks := []struct {
+		name string
+		run  func(buildOptions) []finding
+	}{
+		{"Migrations", checkMigrations},
+		{"OpenAPI compatibility", checkOpenAPICompat},
+		{"Proto compatibility", checkProtoCompat},
+	}
+
+	failed := false
+	for _, check := range checks {
+		fmt.Printf("\n%s\n", check.name)
+		for _, f := range check.run(opts) {
+			fmt.Printf("  %s %s\n", f.level.symbol(), f.message)
+			if f.fix != "" && f.level != levelOK {
+				fmt.Printf("      → %s\n", f.fix)
+			}
+			failed = failed || f.level == levelFail
+		}
+	}
+	fmt.Println()
+	return !failed
+}
+
+// breakingLevel is the level of breaking changes: failures unless allowed
+func (opts buildOptions) breakingLevel() findingLevel {
+	if opts.AllowBreaking {
+		return levelWarn
+	}
+	return levelFail
+}
+
+// checkMigrations applies the migrations in name order, after schema.sql,
+// to the shadow database
+func checkMigrations(opts buildOptions) []finding {
+	schemaFile := filepath.Join(opts.MigrationsDir, "schema.sql")
+	files, err := migrationFiles(opts.MigrationsDir, schemaFile)
+	if err != nil {
+		return []finding{{levelFail, err.Error(), ""}}
+	}
+	if _, err := os.Stat(schemaFile); err == nil {
+		files = append([]string{schemaFile}, files...)
+	}
+	if len(files) == 0 {
+		return []finding{{levelOK, "no SQL migrations in " + opts.MigrationsDir, ""}}
+	}
+
+	shadowURL := opts.ShadowURL
+	if shadowURL == "" {
+		// SQLite projects get a throwaway database
+		if !strings.HasPrefix(os.Getenv("DATABASE_URL"), "sqlite") {
+			return []finding{{levelWarn, fmt.Sprintf("%d migrations not applied: no shadow database", len(files)),
+				"set SHADOW_DATABASE_URL or pass --shadow-database-url with an empty database"}}
+		}
+		dir, err := os.MkdirTemp("", "goframe-shadow-*")
+		if err != nil {
+			return []finding{{levelFail, err.Error(), ""}}
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+		shadowURL = "sqlite://" + filepath.ToSlash(filepath.Join(dir, "shadow.db"))
+	}
+
+	applied, err := applyMigrations(context.Background(), shadowURL, files)
+	if err != nil {
+		return []finding{{levelFail, err.Error(), "fix the migration, then reset the shadow database"}}
+	}
+	return []finding{{levelOK, fmt.Sprintf("%d migrations applied cleanly to the shadow database", applied), ""}}
+}
+
+func applyMigrations(ctx context.Context, shadowURL string, files []string) (int, error) {
+	// A migration file holds several statements
+	if u, err := url.Parse(shadowURL); err == nil && u.Scheme == "mysql" && !u.Query().Has("multiStatements") {
+		query := u.Query()
+		query.Set("multiStatements", "true")
+		u.RawQuery = query.Encode()
+		shadowURL = u.String()
+	}
+
+	config, err := database.ConfigFromURL("shadow", shadowURL)
+	if err != nil {
+		return 0, err
+	}
+	config.LogLevel = logger.Silent
+	if err := database.Register(config); err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	if err := database.Initialize(ctx); err != nil {
+		return 0, err
+	}
+	defer func() { _ = database.Close() }()
+
+	conn, err := database.Get(config.Name)
+	if err != nil {
+		return 0, err
+	}
+	db := conn.WithContext(ctx)
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return 0, err
+	}
+	if len(tables) > 0 {
+		return 0, fmt.Errorf("shadow database is not empty (%d tables); migrations must apply to an empty database", len(tables))
+	}
+
+	for i, file := range files {
+		content, err := os.ReadFile(file) // #nosec G304 -- reads the project's own migrations
+		if err != nil {
+			return i, err
+		}
+		if strings.TrimSpace(string(content)) == "" {
+			continue
+		}
+		if err := db.Exec(string(content)).Error; err != nil {
+			return i, fmt.Errorf("%s failed: %w", filepath.Base(file), err)
+		}
+	}
+	return len(files), nil
+}
+
+// gitShow returns the content of path, relative to the current directory,
+// at ref
+func gitShow(ref, path string) ([]byte, error) {
+	out, err := exec.Command("git", "show", ref+":./"+filepath.ToSlash(path)).Output() // #nosec G204 -- ref and path are supplied by the developer
+	if err != nil {
+		return nil, fmt.Errorf("%s not found at %s", path, ref)
+	}
+	return out, nil
+}
+
+func checkOpenAPICompat(opts buildOptions) []finding {
+	current, err := os.ReadFile(opts.Spec) // #nosec G304 -- the spec is chosen by the developer
+	if err != nil {
+		return []finding{{levelOK, "no OpenAPI document (" + opts.Spec + ")", ""}}
+	}
+	if opts.Base == "" {
+		return []finding{{levelWarn, "no release to compare " + opts.Spec + " with", "tag releases or pass --base <ref>"}}
+	}
+	previous, err := gitShow(opts.Base, opts.Spec)
+	if err != nil {
+		return []finding{{levelOK, opts.Spec + " is new since " + opts.Base, ""}}
+	}
+
+	changes, err := diffOpenAPI(previous, current)
+	if err != nil {
+		return []finding{{levelFail, err.Error(), ""}}
+	}
+	if len(changes) == 0 {
+		return []finding{{levelOK, "no breaking changes since " + opts.Base, ""}}
+	}
+	findings := make([]finding, 0, len(changes))
+	for _, change := range changes {
+		findings = append(findings, finding{opts.breakingLevel(), change, ""})
+	}
+	findings[len(findings)-1].fix = "restore compatibility, version the API, or pass --allow-breaking"
+	return findings
+}
+
+// openAPIDoc holds a parsed OpenAPI document for comparison
+type openAPIDoc struct {
+	root map[string]interface{}
+}
+
+// diffOpenAPI lists the changes of current that break clients of previous:
+// removed operations and success responses, new or newly required request
+// parameters, bodies and properties, removed or newly optional response
+// properties, and changed types
+func diffOpenAPI(previous, current []byte) ([]string, error) {
+	var oldRoot, newRoot map[string]interface{}
+	if err := yaml.Unmarshal(previous, &oldRoot); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document at release: %w", err)
+	}
+	if err := yaml.Unmarshal(current, &newRoot); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	oldDoc, newDoc := &openAPIDoc{oldRoot}, &openAPIDoc{newRoot}
+
+	// Paths match by template, so renaming {id} to {userId} is no change
+	newPaths := make(map[string]map[string]interface{})
+	for path, item := range mapValue(newRoot["paths"]) {
+		newPaths[pathTemplate(path)] = mapValue(item)
+	}
+
+	var changes []string
+	oldPaths := mapValue(oldRoot["paths"])
+	for _, path := range sortedKeys(oldPaths) {
+		oldItem := mapValue(oldPaths[path])
+		newItem, ok := newPaths[pathTemplate(path)]
+		if !ok {
+			changes = append(changes, "removed path "+path)
+			continue
+		}
+		for _, method := range mockMethods {
+			oldOp, ok := oldItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op := strings.ToUpper(method) + " " + path
+			newOp, ok := newItem[method].(map[string]interface{})
+			if !ok {
+				changes = append(changes, "removed operation "+op)
+				continue
+			}
+			changes = append(changes, diffOperation(op, oldDoc, newDoc, oldItem, newItem, oldOp, newOp)...)
+		}
+	}
+	return changes, nil
+}
+
+func diffOperation(op string, oldDoc, newDoc *openAPIDoc, oldItem, newItem, oldOp, newOp map[string]interface{}) []string {
+	var changes []string
+
+	oldParams := oldDoc.parameters(oldItem, oldOp)
+	newParams := newDoc.parameters(newItem, newOp)
+	for _, key := range sortedKeys(newParams) {
+		param := newParams[key]
+		required, _ := param["required"].(bool)
+		previous, existed := oldParams[key]
+		wasRequired, _ := previous["required"].(bool)
+		switch {
+		case !existed && required:
+			changes = append(changes, fmt.Sprintf("%s: new required %s parameter", op, key))
+		case existed && required && !wasRequired:
+			changes = append(changes, fmt.Sprintf("%s: %s parameter is now required", op, key))
+		case existed:
+			changes = diffSchema(changes, op+": "+key+" parameter", oldDoc, newDoc,
+				mapValue(previous["schema"]), mapValue(param["schema"]), true, 0)
+		}
+	}
+
+	oldBody, newBody := oldDoc.resolve(mapValue(oldOp["requestBody"])), newDoc.resolve(mapValue(newOp["requestBody"]))
+	if required, _ := newBody["required"].(bool); required {
+		if wasRequired, _ := oldBody["required"].(bool); !wasRequired {
+			changes = append(changes, op+": request body is now required")
+		}
+	}
+	changes = diffSchema(changes, op+": request body", oldDoc, newDoc, jsonSchema(oldBody), jsonSchema(newBody), true, 0)
+
+	oldResponses, newResponses := mapValue(oldOp["responses"]), mapValue(newOp["responses"])
+	for _, code := range sortedKeys(oldResponses) {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		newResponse, ok := newResponses[code]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("%s: removed %s response", op, code))
+			continue
+		}
+		changes = diffSchema(changes, fmt.Sprintf("%s: %s response", op, code), oldDoc, newDoc,
+			jsonSchema(oldDoc.resolve(mapValue(oldResponses[code]))), jsonSchema(newDoc.resolve(mapValue(newResponse))), false, 0)
+	}
+	return changes
+}
+
+// diffSchema appends the breaking changes from previous to current of a
+// request (clients send it) or response (clients read it) schema
+func diffSchema(changes []string, at string, oldDoc, newDoc *openAPIDoc, previous, current map[string]interface{}, request bool, depth int) []string {
+	previous, current = oldDoc.resolve(previous), newDoc.resolve(current)
+	if len(previous) == 0 || len(current) == 0 || depth > 16 {
+		return changes
+	}
+
+	oldType, _ := previous["type"].(string)
+	newType, _ := current["type"].(string)
+	if oldType != "" && newType != "" && oldType != newType {
+		return append(changes, fmt.Sprintf("%s: type changed from %s to %s", at, oldType, newType))
+	}
+
+	if request {
+		oldEnum, newEnum := previous["enum"], current["enum"]
+		if oldEnum != nil && newEnum != nil {
+			for _, value := range sliceValue(oldEnum) {
+				if !containsValue(sliceValue(newEnum), value) {
+					changes = append(changes, fmt.Sprintf("%s: value %v no longer accepted", at, value))
+				}
+			}
+		}
+	}
+
+	oldRequired, newRequired := stringSet(previous["required"]), stringSet(current["required"])
+	oldProps, newProps := mapValue(previous["properties"]), mapValue(current["properties"])
+	if request {
+		for _, name := range sortedKeys(newRequired) {
+			if !oldRequired[name] {
+				changes = append(changes, fmt.Sprintf("%s: property %s is now required", at, name))
+			}
+		}
+	} else {
+		for _, name := range sortedKeys(oldProps) {
+			if _, ok := newProps[name]; !ok {
+				changes = append(changes, fmt.Sprintf("%s: removed property %s", at, name))
+			} else if oldRequired[name] && !newRequired[name] {
+				changes = append(changes, fmt.Sprintf("%s: property %s is no longer always present", at, name))
+			}
+		}
+	}
+	for _, name := range sortedKeys(oldProps) {
+		if prop, ok := newProps[name]; ok {
+			changes = diffSchema(changes, at+"."+name, oldDoc, newDoc, mapValue(oldProps[name]), mapValue(prop), request, depth+1)
+		}
+	}
+	return diffSchema(changes, at+"[]", oldDoc, newDoc, mapValue(previous["items"]), mapValue(current["items"]), request, depth+1)
+}
+
+// parameters returns the parameters of an operation and its path item,
+// keyed by "<in> <name>"
+func (d *openAPIDoc) parameters(item, op map[string]interface{}) map[string]map[string]interface{} {
+	params := make(map[string]map[string]interface{})
+	for _, list := range []interface{}{item["parameters"], op["parameters"]} {
+		for _, p := range sliceValue(list) {
+			param := d.resolve(mapValue(p))
+			name, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			if in == "path" {
+				// Path parameters are positional; the template comparison covers them
+				continue
+			}
+			params[in+" "+name] = param
+		}
+	}
+	return params
+}
+
+// resolve follows local $refs such as #/components/schemas/User
+func (d *openAPIDoc) resolve(node map[string]interface{}) map[string]interface{} {
+	for i := 0; i < 8; i++ {
+		ref, ok := node["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return node
+		}
+		var target interface{} = d.root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			target = mapValue(target)[part]
+		}
+		node = mapValue(target)
+	}
+	return node
+}
+
+// jsonSchema returns the JSON schema of a request body or response
+func jsonSchema(node map[string]interface{}) map[string]interface{} {
+	content := mapValue(node["content"])
+	for _, mediaType := range sortedKeys(content) {
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return mapValue(mapValue(content[mediaType])["schema"])
+		}
+	}
+	return nil
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+func pathTemplate(path string) string {
+	return pathParam.ReplaceAllString(path, "{}")
+}
+
+func mapValue(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func sliceValue(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func stringSet(v interface{}) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range sliceValue(v) {
+		if s, ok := item.(string); ok {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if fmt.Sprint(value) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func checkProtoCompat(opts buildOptions) []finding {
+	var files []string
+	_ = filepath.WalkDir(".", func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && path != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".proto") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if len(files) == 0 {
+		return []finding{{levelOK, "no .proto files", ""}}
+	}
+	if opts.Base == "" {
+		return []finding{{levelWarn, "no release to compare .proto files with", "tag releases or pass --base <ref>"}}
+	}
+
+	// buf implements the full rule set; the built-in check covers wire
+	// compatibility of field numbers and removed RPCs
+	if _, err := os.Stat("buf.yaml"); err == nil {
+		if _, err := exec.LookPath("buf"); err == nil {
+			return bufBreaking(opts)
+		}
+	}
+
+	oldFiles, err := protoFilesAt(opts.Base)
+	if err != nil {
+		return []finding{{levelWarn, err.Error(), ""}}
+	}
+	previous, current := make(protoSchema), make(protoSchema)
+	for _, file := range oldFiles {
+		content, err := gitShow(opts.Base, file)
+		if err != nil {
+			return []finding{{levelWarn, err.Error(), ""}}
+		}
+		previous.parse(string(content))
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file) // #nosec G304 -- reads the project's own proto files
+		if err != nil {
+			return []finding{{levelFail, err.Error(), ""}}
+		}
+		current.parse(string(content))
+	}
+
+	changes := previous.breakingChanges(current)
+	if len(changes) == 0 {
+		return []finding{{levelOK, fmt.Sprintf("%d .proto files compatible with %s", len(files), opts.Base), ""}}
+	}
+	findings := make([]finding, 0, len(changes))
+	for _, change := range changes {
+		findings = append(findings, finding{opts.breakingLevel(), change, ""})
+	}
+	findings[len(findings)-1].fix = "keep field numbers and types, reserve removed fields, or pass --allow-breaking"
+	return findings
+}
+
+func bufBreaking(opts buildOptions) []finding {
+	cmd := exec.Command("buf", "breaking", "--against", ".git#ref="+opts.Base) // #nosec G204 -- the ref is supplied by the developer
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return []finding{{levelOK, "buf breaking: compatible with " + opts.Base, ""}}
+	}
+	var findings []finding
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			findings = append(findings, finding{opts.breakingLevel(), line, ""})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, finding{levelFail, "buf breaking failed: " + err.Error(), ""})
+	}
+	return findings
+}
+
+func protoFilesAt(ref string) ([]string, error) {
+	out, err := exec.Command("git", "ls-tree", "-r", "--name-only", ref).Output() // #nosec G204 -- the ref is supplied by the developer
+	if err != nil {
+		return nil, fmt.Errorf("cannot list files at %s", ref)
+	}
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasSuffix(line, ".proto") {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// protoSchema maps qualified message names to their fields by number, and
+// service names to their RPCs
+type protoSchema map[string]map[string]string
+
+var (
+	protoComment  = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	protoPackage  = regexp.MustCompile(`^package\s+([\w.]+)\s*;`)
+	protoBlock    = regexp.MustCompile(`^(message|service|enum|oneof)\s+(\w+)\s*\{`)
+	protoField    = regexp.MustCompile(`^(?:(?:optional|repeated|required)\s+)?([\w.]+(?:\s*<[^>]*>)?)\s+(\w+)\s*=\s*(\d+)`)
+	protoRPC      = regexp.MustCompile(`^rpc\s+(\w+)\s*\(\s*(stream\s+)?([\w.]+)\s*\)\s*returns\s*\(\s*(stream\s+)?([\w.]+)\s*\)`)
+	protoReserved = regexp.MustCompile(`^reserved\s+`)
+)
+
+// protoReservedKey holds the reserved field numbers and names of a message
+const protoReservedKey = "reserved"
+
+// protoReservedNumber reports whether number is in a reserved list such as
+// 2, 15, 9 to 11, 40 to max
+func protoReservedNumber(reserved, number string) bool {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return false
+	}
+	for _, part := range strings.Split(reserved, ",") {
+		low, high, isRange := strings.Cut(strings.TrimSpace(part), " to ")
+		from, err := strconv.Atoi(strings.TrimSpace(low))
+		if err != nil {
+			continue
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(high)); err != nil {
+				to = math.MaxInt32 // max
+			}
+		}
+		if n >= from && n <= to {
+			return true
+		}
+	}
+	return false
+}
+
+// parse adds the messages and services of a .proto file. Fields are
+// recorded as "<number>" → "<type> <name>", RPCs as "rpc <name>" →
+// "<request> <response>".
+func (s protoSchema) parse(content string) {
+	content = protoComment.ReplaceAllString(content, "")
+	pkg := ""
+	var scopes []string // Enclosing blocks; "" for blocks that are not messages or services
+	for _, stmt := range splitProto(content) {
+		if m := protoPackage.FindStringSubmatch(stmt); m != nil {
+			pkg = m[1] + "."
+			continue
+		}
+		if stmt == "}" {
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+			continue
+		}
+		if m := protoBlock.FindStringSubmatch(stmt); m != nil {
+			parent := pkg
+			if len(scopes) > 0 && scopes[len(scopes)-1] != "" {
+				parent = scopes[len(scopes)-1] + "."
+			}
+			switch m[1] {
+			case "message", "service":
+				name := parent + m[2]
+				if s[name] == nil {
+					s[name] = make(map[string]string)
+				}
+				scopes = append(scopes, name)
+			case "oneof":
+				// Oneof fields belong to the enclosing message
+				scopes = append(scopes, strings.TrimSuffix(parent, "."))
+			default:
+				scopes = append(scopes, "")
+			}
+			continue
+		}
+		if strings.HasSuffix(stmt, "{") {
+			scopes = append(scopes, "")
+			continue
+		}
+		if len(scopes) == 0 || scopes[len(scopes)-1] == "" {
+			continue
+		}
+		scope := s[scopes[len(scopes)-1]]
+		if protoReserved.MatchString(stmt) {
+			scope[protoReservedKey] += " " + strings.TrimSuffix(protoReserved.ReplaceAllString(stmt, ""), ";")
+			continue
+		}
+		if m := protoRPC.FindStringSubmatch(stmt); m != nil {
+			scope["rpc "+m[1]] = m[2] + m[3] + " " + m[4] + m[5]
+		} else if m := protoField.FindStringSubmatch(stmt); m != nil {
+			scope[m[3]] = strings.Join(strings.Fields(m[1]), "") + " " + m[2]
+		}
+	}
+}
+
+// splitProto splits proto source into statements ending with ";", "{" or
+// "}"
+func splitProto(content string) []string {
+	var stmts []string
+	start := 0
+	for i, c := range content {
+		if c == ';' || c == '{' || c == '}' {
+			stmt := strings.TrimSpace(content[start:i])
+			if c == '}' {
+				if stmt != "" {
+					stmts = append(stmts, stmt)
+				}
+				stmts = append(stmts, "}")
+			} else {
+				stmts = append(stmts, strings.Join(strings.Fields(stmt), " ")+string(c))
+			}
+			start = i + 1
+		}
+	}
+	return stmts
+}
+
+// breakingChanges lists the changes of current that break the wire format
+// or generated clients of s
+func (s protoSchema) breakingChanges(current protoSchema) []string {
+	var changes []string
+	for _, name := range sortedKeys(s) {
+		now, ok := current[name]
+		if !ok {
+			changes = append(changes, "removed "+name)
+			continue
+		}
+		for _, key := range sortedKeys(s[name]) {
+			if key == protoReservedKey {
+				continue
+			}
+			was := s[name][key]
+			is, ok := now[key]
+			rpc := strings.HasPrefix(key, "rpc ")
+			switch {
+			case !ok && !rpc && protoReservedNumber(now[protoReservedKey], key):
+				// Removed and reserved, so the number cannot be reused
+			case !ok && rpc:
+				changes = append(changes, fmt.Sprintf("%s: removed %s", name, key))
+			case !ok:
+				changes = append(changes, fmt.Sprintf("%s: removed field %s (%s) without reserving it", name, key, was))
+			case rpc && is != was:
+				changes = append(changes, fmt.Sprintf("%s: %s changed from (%s) to (%s)", name, key, was, is))
+			case !rpc && strings.Fields(is)[0] != strings.Fields(was)[0]:
+				changes = append(changes, fmt.Sprintf("%s: field %s changed from %s to %s", name, key, was, is))
+			}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// k8sOptions parameterizes the generated manifests and Helm chart
+type k8sOptions struct {
+	Name        string
+	Image       string
+	Port        int
+	Replicas    int
+	MaxReplicas int
+	HealthPath  string
+	Ingress     string // Ingress host; no Ingress when empty
+	Namespace   string
+	Helm        bool
+	Output      string
+}
+
+// parseK8sOptions reads `goframe gen k8s <name>` flags
+func parseK8sOptions(name string, args []string) (k8sOptions, error) {
+	opts := k8sOptions{Name: name}
+
+	fs := flag.NewFlagSet("gen k8s", flag.ContinueOnError)
+	fs.StringVar(&opts.Image, "image", name+":latest", "container image")
+	fs.IntVar(&opts.Port, "port", 8080, "container port")
+	fs.IntVar(&opts.Replicas, "replicas", 2, "minimum replicas")
+	fs.IntVar(&opts.MaxReplicas, "max-replicas", 10, "maximum replicas for the HPA")
+	fs.StringVar(&opts.HealthPath, "health", "/healthz", "health endpoint used by the probes")
+	fs.StringVar(&opts.Ingress, "ingress", "", "create an Ingress for this host")
+	fs.StringVar(&opts.Namespace, "namespace", "", "namespace for the manifests")
+	fs.BoolVar(&opts.Helm, "helm", false, "generate a Helm chart instead of plain manifests")
+	fs.StringVar(&opts.Output, "o", "", "output directory (default deploy/k8s or deploy/helm/<name>)")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if opts.Replicas < 1 || opts.MaxReplicas < opts.Replicas {
+		return opts, fmt.Errorf("invalid replicas: min %d, max %d", opts.Replicas, opts.MaxReplicas)
+	}
+	if !strings.HasPrefix(opts.HealthPath, "/") {
+		opts.HealthPath = "/" + opts.HealthPath
+	}
+	if opts.Output == "" {
+		opts.Output = filepath.Join("deploy", "k8s")
+		if opts.Helm {
+			opts.Output = filepath.Join("deploy", "helm", name)
+		}
+	}
+
+	return opts, nil
+}
+
+// generateK8s writes Deployment, Service, HPA, ConfigMap, Secret and an
+// optional Ingress, either as plain manifests or as a Helm chart
+func generateK8s(opts k8sOptions) ([]string, error) {
+	files := k8sManifests
+	if opts.Helm {
+		files = helmChart
+	}
+
+	var written []string
+	for _, f := range files {
+		if f.name == "ingress.yaml" && opts.Ingress == "" && !opts.Helm {
+			continue
+		}
+
+		path := filepath.Join(opts.Output, f.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil { // #nosec G703 -- manifests are written under the user-chosen output directory by design
+			return nil, err
+		}
+
+		// Helm templates are copied verbatim; their {{ }} belong to Helm
+		if f.verbatim {
+			if err := os.WriteFile(path, []byte(f.content), 0644); err != nil { // #nosec G703 -- manifests are written under the user-chosen output directory by design
+				return nil, err
+			}
+		} else if err := writeK8sTemplate(path, f.content, opts); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+func writeK8sTemplate(path, tmplStr string, opts k8sOptions) error {
+	tmpl, err := template.New(filepath.Base(path)).Parse(tmplStr)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path) // #nosec G703 -- manifests are written under the user-chosen output directory by design
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return tmpl.Execute(f, opts)
+}
+
+type k8sFile struct {
+	name     string
+	content  string
+	verbatim bool
+}
+
+const k8sMetadata = `  name: {{.Name}}{{if .Namespace}}
+  namespace: {{.Namespace}}{{end}}
+  labels:
+    app.kubernetes.io/name: {{.Name}}`
+
+var k8sManifests = []k8sFile{
+	{name: "deployment.yaml", content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+` + k8sMetadata + `
+spec:
+  replicas: {{.Replicas}}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{.Name}}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{.Name}}
+    spec:
+      terminationGracePeriodSeconds: 30
+      containers:
+        - name: {{.Name}}
+          image: {{.Image}}
+          ports:
+            - name: http
+              containerPort: {{.Port}}
+          envFrom:
+            - configMapRef:
+                name: {{.Name}}
+            - secretRef:
+                name: {{.Name}}
+          resources:
+            requests:
+              cpu: 100m
+              memory: 128Mi
+            limits:
+              cpu: 500m
+              memory: 256Mi
+          startupProbe:
+            httpGet:
+              path: {{.HealthPath}}
+              port: http
+            periodSeconds: 2
+            failureThreshold: 30
+          readinessProbe:
+            httpGet:
+              path: {{.HealthPath}}
+              port: http
+            periodSeconds: 5
+          livenessProbe:
+            httpGet:
+              path: {{.HealthPath}}
+              port: http
+            periodSeconds: 10
+            failureThreshold: 3
+          securityContext:
+            runAsNonRoot: true
+            allowPrivilegeEscalation: false
+            readOnlyRootFilesystem: true
+`},
+	{name: "service.yaml", content: `apiVersion: v1
+kind: Service
+metadata:
+` + k8sMetadata + `
+spec:
+  selector:
+    app.kubernetes.io/name: {{.Name}}
+  ports:
+    - name: http
+      port: 80
+      targetPort: http
+`},
+	{name: "hpa.yaml", content: `apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+` + k8sMetadata + `
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{.Name}}
+  minReplicas: {{.Replicas}}
+  maxReplicas: {{.MaxReplicas}}
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: 70
+`},
+	{name: "configmap.yaml", content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+` + k8sMetadata + `
+data:
+  PORT: "{{.Port}}"
+`},
+	{name: "secret.yaml", content: `apiVersion: v1
+kind: Secret
+metadata:
+` + k8sMetadata + `
+type: Opaque
+stringData: {}
+`},
+	{name: "ingress.yaml", content: `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+` + k8sMetadata + `
+spec:
+  rules:
+    - host: {{.Ingress}}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: {{.Name}}
+                port:
+                  name: http
+`},
+}
+
+const helmLabels = `  labels:
+    app.kubernetes.io/name: {{ .Chart.Name }}
+    app.kubernetes.io/instance: {{ .Release.Name }}`
+
+const helmSelector = `app.kubernetes.io/name: {{ .Chart.Name }}
+      app.kubernetes.io/instance: {{ .Release.Name }}`
+
+var helmChart = []k8sFile{
+	{name: "Chart.yaml", content: `apiVersion: v2
+name: {{.Name}}
+description: Helm chart for {{.Name}}
+type: application
+version: 0.1.0
+appVersion: "1.0.0"
+`},
+	{name: "values.yaml", content: `replicaCount: {{.Replicas}}
+
+image:
+  repository: {{.Image}}
+  pullPolicy: IfNotPresent
+
+containerPort: {{.Port}}
+healthPath: {{.HealthPath}}
+
+service:
+  port: 80
+
+resources:
+  requests:
+    cpu: 100m
+    memory: 128Mi
+  limits:
+    cpu: 500m
+    memory: 256Mi
+
+autoscaling:
+  enabled: true
+  maxReplicas: {{.MaxReplicas}}
+  targetCPUUtilizationPercentage: 70
+
+# Plain environment variables (ConfigMap)
+config: {}
+
+# Sensitive environment variables (Secret)
+secrets: {}
+
+ingress:
+  enabled: {{if .Ingress}}true{{else}}false{{end}}
+  className: ""
+  host: {{if .Ingress}}{{.Ingress}}{{else}}chart-example.local{{end}}
+`},
+	{name: "templates/deployment.yaml", verbatim: true, content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+` + helmLabels + `
+spec:
+  {{- if not .Values.autoscaling.enabled }}
+  replicas: {{ .Values.replicaCount }}
+  {{- end }}
+  selector:
+    matchLabels:
+      ` + helmSelector + `
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ .Chart.Name }}
+        app.kubernetes.io/instance: {{ .Release.Name }}
+      annotations:
+        checksum/config: {{ include (print $.Template.BasePath "/configmap.yaml") . | sha256sum }}
+        checksum/secret: {{ include (print $.Template.BasePath "/secret.yaml") . | sha256sum }}
+    spec:
+      terminationGracePeriodSeconds: 30
+      containers:
+        - name: {{ .Chart.Name }}
+          image: {{ .Values.image.repository }}
+          imagePullPolicy: {{ .Values.image.pullPolicy }}
+          ports:
+            - name: http
+              containerPort: {{ .Values.containerPort }}
+          envFrom:
+            - configMapRef:
+                name: {{ .Release.Name }}
+            - secretRef:
+                name: {{ .Release.Name }}
+          resources:
+            {{- toYaml .Values.resources | nindent 12 }}
+          startupProbe:
+            httpGet:
+              path: {{ .Values.healthPath }}
+              port: http
+            periodSeconds: 2
+            failureThreshold: 30
+          readinessProbe:
+            httpGet:
+              path: {{ .Values.healthPath }}
+              port: http
+            periodSeconds: 5
+          livenessProbe:
+            httpGet:
+              path: {{ .Values.healthPath }}
+              port: http
+            periodSeconds: 10
+            failureThreshold: 3
+          securityContext:
+            runAsNonRoot: true
+            allowPrivilegeEscalation: false
+            readOnlyRootFilesystem: true
+`},
+	{name: "templates/service.yaml", verbatim: true, content: `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Release.Name }}
+` + helmLabels + `
+spec:
+  selector:
+    ` + strings.ReplaceAll(helmSelector, "      ", "    ") + `
+  ports:
+    - name: http
+      port: {{ .Values.service.port }}
+      targetPort: http
+`},
+	{name: "templates/hpa.yaml", verbatim: true, content: `{{- if .Values.autoscaling.enabled }}
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{ .Release.Name }}
+` + helmLabels + `
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{ .Release.Name }}
+  minReplicas: {{ .Values.replicaCount }}
+  maxReplicas: {{ .Values.autoscaling.maxReplicas }}
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: {{ .Values.autoscaling.targetCPUUtilizationPercentage }}
+{{- end }}
+`},
+	{name: "templates/configmap.yaml", verbatim: true, content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+` + helmLabels + `
+data:
+  PORT: {{ .Values.containerPort | quote }}
+  {{- range $key, $value := .Values.config }}
+  {{ $key }}: {{ $value | quote }}
+  {{- end }}
+`},
+	{name: "templates/secret.yaml", verbatim: true, content: `apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Release.Name }}
+` + helmLabels + `
+type: Opaque
+stringData:
+  {{- range $key, $value := .Values.secrets }}
+  {{ $key }}: {{ $value | quote }}
+  {{- end }}
+`},
+	{name: "templates/ingress.yaml", verbatim: true, content: `{{- if .Values.ingress.enabled }}
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ .Release.Name }}
+` + helmLabels + `
+spec:
+  {{- with .Values.ingress.className }}
+  ingressClassName: {{ . }}
+  {{- end }}
+  rules:
+    - host: {{ .Values.ingress.host }}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: {{ .Release.Name }}
+                port:
+                  name: http
+{{- end }}
+`},
+}
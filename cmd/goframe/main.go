@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"fmt"
 	"io/fs"
@@ -31,6 +32,16 @@ func main() {
 		handleGen()
 	case "migrate":
 		handleMigrate()
+	case "schema":
+		handleSchema()
+	case "db":
+		handleDB()
+	case "doctor":
+		handleDoctor()
+	case "mock":
+		handleMock()
+	case "bench":
+		handleBench()
 	case "serve":
 		handleServe()
 	case "build":
@@ -54,33 +65,84 @@ Usage:
 
 Commands:
   new <name>           Create new project with embedded framework packages
+                         --lite        single binary backed by embedded SQLite
+                         --serverless  Lambda and Cloud Run entrypoint
   gen model <name>     Generate model
   gen handler <name>   Generate handler
   gen crud <name>      Generate full CRUD (model + handler)
+  gen admin <Model>    Generate admin list, detail and edit pages for a model
+                       (--role admin, repeatable; --path, --dir, --force)
   gen middleware <name> Generate middleware
+  gen ts               Generate TypeScript interfaces from models and DTOs
+                       (--dir <package dir>, -o web/src/api/types.ts)
+  gen billing          Generate plans, subscriptions, webhook-driven lifecycle
+                       and endpoints in internal/billing (-o <dir>, --force)
+  gen k8s <name>       Generate Kubernetes manifests (--helm for a Helm chart,
+                       --image, --port, --ingress <host>, --health <path>)
   migrate              Run database migrations
+  migrate squash       Replace migrations/*.sql by a schema.sql dumped from the
+                       migrated database (--database-url, --dir, --keep)
+  schema dump          Write the database schema to migrations/schema.sql
+                       (--database-url, default $DATABASE_URL; -o <file>)
+  db anonymize         Anonymize a copy of production data with a profile from
+                       anonymize.yaml (--profile, --database-url, --salt)
+  doctor               Check toolchain, env vars, dependencies, framework copy,
+                       routes and configuration, with suggested fixes
+  mock                 Serve example responses for every route of an OpenAPI
+                       spec (--spec openapi.yaml, --addr, --latency 50ms-300ms,
+                       --error-rate 0.1)
+  bench <route>        Load test a route and report latency percentiles and errors
+                       (--rps, --duration, -X, --data <template|@file>, --token,
+                       -H, --base-url, --admin <admin URL> to validate the route)
   serve                Start development server with hot reload
-  build [output]       Build production binary
+  build [output]       Build production binary after checking that migrations
+                       apply to an empty shadow database and that openapi.yaml
+                       and .proto files stay compatible with the last tag
+                       (--shadow-database-url, --base <ref>, --spec,
+                       --allow-breaking, --no-check)
   version              Show version
   help                 Show this help
 
 Examples:
   goframe new myapp
+  goframe new myapp --lite
+  goframe new myapp --serverless
   goframe gen model User
   goframe gen handler user
   goframe gen crud Product
+  goframe gen admin Product --role admin --role editor
+  goframe gen k8s myapp --image registry.example.com/myapp:1.0 --ingress api.example.com
+  goframe mock --spec openapi.yaml --latency 100ms-500ms
+  goframe bench /users/{{randInt 1 1000}} --rps 200 --duration 30s
   goframe serve
   goframe build`)
 }
 
 func handleNew() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: goframe new <project-name>")
+		fmt.Println("Usage: goframe new <project-name> [--lite|--serverless]")
 		os.Exit(1)
 	}
 
 	name := os.Args[2]
-	if err := createProject(name); err != nil {
+	var opts projectOptions
+	for _, arg := range os.Args[3:] {
+		switch arg {
+		case "--lite":
+			opts.Lite = true
+		case "--serverless":
+			opts.Serverless = true
+		default:
+			fmt.Printf("Unknown option: %s\n", arg)
+			os.Exit(1)
+		}
+	}
+	if opts.Lite && opts.Serverless {
+		fmt.Println("--lite and --serverless cannot be combined")
+		os.Exit(1)
+	}
+
+	if err := createProject(name, opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -90,9 +152,20 @@ func handleNew() {
 	fmt.Printf("  cd %s\n", name)
 	fmt.Printf("  go mod tidy\n")
 	fmt.Printf("  go run cmd/server/main.go\n")
+	if opts.Serverless {
+		fmt.Printf("\nDeploy:\n")
+		fmt.Printf("  Cloud Run: gcloud run deploy %s --source .\n", name)
+		fmt.Printf("  Lambda:    GOOS=linux GOARCH=arm64 go build -tags lambda.norpc -o bootstrap ./cmd/server\n")
+	}
 }
 
-func createProject(name string) error {
+// projectOptions selects the variant scaffolded by `goframe new`
+type projectOptions struct {
+	Lite       bool // Embedded SQLite database under ./data
+	Serverless bool // Lambda and Cloud Run entrypoint
+}
+
+func createProject(name string, opts projectOptions) error {
 	// Create directory structure
 	dirs := []string{
 		name,
@@ -103,6 +176,9 @@ func createProject(name string) error {
 		filepath.Join(name, "pkg"),
 		filepath.Join(name, "config"),
 	}
+	if opts.Lite {
+		dirs = append(dirs, filepath.Join(name, "internal", "store"))
+	}
 
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0755); err != nil { // #nosec G703 -- scaffold dirs are created under the user-supplied project name by design
@@ -144,6 +220,24 @@ func main() {
 	a.StartWithGracefulShutdown()
 }
 `
+	if opts.Lite {
+		mainGo = liteMainGo
+		for file, content := range map[string]string{
+			"store.go": liteStoreGo,
+			"cache.go": liteCacheGo,
+			"jobs.go":  liteJobsGo,
+		} {
+			if err := writeTemplate(filepath.Join(name, "internal", "store", file), content, nil); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.Serverless {
+		mainGo = serverlessMainGo
+		if err := writeTemplate(filepath.Join(name, "Dockerfile"), serverlessDockerfile, nil); err != nil {
+			return err
+		}
+	}
 
 	if err := writeTemplate(filepath.Join(name, "cmd", "server", "main.go"), mainGo, map[string]string{
 		"Name":   name,
@@ -225,6 +319,12 @@ require (
 	gorm.io/driver/sqlite v1.5.4
 )
 `, name)
+	if opts.Lite {
+		goMod += "\nrequire go.etcd.io/bbolt v1.3.8\n"
+	}
+	if opts.Serverless {
+		goMod += "\nrequire github.com/aws/aws-lambda-go v1.47.0\n"
+	}
 	if err := os.WriteFile(filepath.Join(name, "go.mod"), []byte(goMod), 0644); err != nil { // #nosec G703 -- scaffolding writes into the user-supplied project directory by design
 		return err
 	}
@@ -240,6 +340,9 @@ bin/
 coverage.out
 coverage.html
 `
+	if opts.Lite {
+		gitignore += "data/\n"
+	}
 	if err := os.WriteFile(filepath.Join(name, ".gitignore"), []byte(gitignore), 0644); err != nil { // #nosec G703 -- scaffolding writes into the user-supplied project directory by design
 		return err
 	}
@@ -259,6 +362,340 @@ coverage.html
 	return nil
 }
 
+// liteMainGo is the server entrypoint of `goframe new --lite`: a single binary
+// backed by an embedded SQLite database and job queue under ./data, with an
+// in-memory cache instead of Redis
+const liteMainGo = `package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"{{.Module}}/internal/handlers"
+	"{{.Module}}/internal/store"
+	"{{.Module}}/pkg/app"
+	"{{.Module}}/pkg/middleware"
+)
+
+func main() {
+	path := os.Getenv("DATABASE_PATH")
+	if path == "" {
+		path = "data/{{.Name}}.db"
+	}
+	kvPath := os.Getenv("KVSTORE_PATH")
+	if kvPath == "" {
+		kvPath = "data/kv.db"
+	}
+
+	db, err := store.Open(path)
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+	defer store.Close(db)
+
+	jobs, err := store.OpenJobs(kvPath)
+	if err != nil {
+		log.Fatalf("failed to open job queue: %v", err)
+	}
+	defer jobs.Close()
+
+	// In-memory cache in place of Redis; hand it and jobs to your services
+	cache := store.NewCache()
+	defer cache.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go jobs.Work(ctx, "default", time.Second, func(payload []byte) error {
+		log.Printf("job: %s", payload)
+		return nil
+	})
+
+	a := app.New(&app.Config{
+		Name: "{{.Name}}",
+		Port: ":8080",
+	})
+
+	a.Use(middleware.Recovery())
+	a.Use(middleware.Logger())
+	a.Use(middleware.DefaultCORS())
+
+	// Register root routes
+	handlers.RegisterRootRoutes(a)
+
+	// Register API routes
+	api := a.Group("/api/v1")
+	handlers.RegisterRoutes(api)
+
+	a.StartWithGracefulShutdown()
+}
+`
+
+// liteStoreGo opens SQLite with the same WAL tuning as database.Lite
+const liteStoreGo = `package store
+
+import (
+	"os"
+	"path/filepath"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Open opens the SQLite database at path in WAL mode, creating its directory
+func Open(path string) (*gorm.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+
+	dsn := path + "?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000&_foreign_keys=1&_txlock=immediate"
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+}
+
+// Close closes the underlying connection pool
+func Close(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+`
+
+// liteCacheGo is the in-memory cache of lite projects, standing in for Redis
+const liteCacheGo = `package store
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache is an in-memory cache with per-entry expiry
+type Cache struct {
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	stop    chan struct{}
+}
+
+type cacheEntry struct {
+	value     []byte
+	expiresAt time.Time // Zero for entries without expiry
+}
+
+// NewCache creates a cache purging expired entries every minute
+func NewCache() *Cache {
+	c := &Cache{entries: make(map[string]cacheEntry), stop: make(chan struct{})}
+	go c.sweep(time.Minute)
+	return c
+}
+
+// Get returns the value of key, false when it is missing or expired
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || (!e.expiresAt.IsZero() && time.Now().After(e.expiresAt)) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key; a ttl of 0 keeps it until deleted
+func (c *Cache) Set(key string, value []byte, ttl time.Duration) {
+	e := cacheEntry{value: value}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = e
+}
+
+// Delete removes key
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Close stops the sweeper
+func (c *Cache) Close() {
+	close(c.stop)
+}
+
+func (c *Cache) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for key, e := range c.entries {
+				if !e.expiresAt.IsZero() && now.After(e.expiresAt) {
+					delete(c.entries, key)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+`
+
+// liteJobsGo is the job queue of lite projects, kept in an embedded bbolt
+// key-value file so queued jobs survive restarts without a broker
+const liteJobsGo = `package store
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Jobs is a persistent FIFO job queue per queue name
+type Jobs struct {
+	db *bolt.DB
+}
+
+// OpenJobs opens the key-value file at path, creating its directory
+func OpenJobs(path string) (*Jobs, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &Jobs{db: db}, nil
+}
+
+// Close closes the file
+func (j *Jobs) Close() error {
+	return j.db.Close()
+}
+
+// Enqueue appends a job to queue
+func (j *Jobs) Enqueue(queue string, payload []byte) error {
+	return j.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(queue))
+		if err != nil {
+			return err
+		}
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		return b.Put(key, payload)
+	})
+}
+
+// Dequeue removes and returns the oldest job of queue, false when it is
+// empty
+func (j *Jobs) Dequeue(queue string) ([]byte, bool, error) {
+	var payload []byte
+	err := j.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(queue))
+		if b == nil {
+			return nil
+		}
+		key, value := b.Cursor().First()
+		if key == nil {
+			return nil
+		}
+		payload = append([]byte(nil), value...)
+		return b.Delete(key)
+	})
+	return payload, payload != nil, err
+}
+
+// Work hands the jobs of queue to handle until ctx is done, polling every
+// interval while the queue is empty. Failed jobs are logged and dropped.
+func (j *Jobs) Work(ctx context.Context, queue string, interval time.Duration, handle func(payload []byte) error) {
+	for {
+		payload, ok, err := j.Dequeue(queue)
+		if err != nil {
+			log.Printf("dequeue %s: %v", queue, err)
+		}
+		if ok {
+			if err := handle(payload); err != nil {
+				log.Printf("job of %s failed: %v", queue, err)
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+`
+
+// serverlessMainGo is the entrypoint of `goframe new --serverless`: the same
+// routes run on Lambda behind API Gateway or as a Cloud Run container
+const serverlessMainGo = `package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"{{.Module}}/internal/handlers"
+	"{{.Module}}/pkg/app"
+	"{{.Module}}/pkg/middleware"
+)
+
+func main() {
+	a := app.New(&app.Config{
+		Name: "{{.Name}}",
+	})
+
+	a.Use(middleware.Recovery())
+	a.Use(middleware.Logger())
+	a.Use(middleware.DefaultCORS())
+
+	// Register root routes
+	handlers.RegisterRootRoutes(a)
+
+	// Register API routes
+	api := a.Group("/api/v1")
+	handlers.RegisterRoutes(api)
+
+	// The Lambda runtime sets AWS_LAMBDA_FUNCTION_NAME; anywhere else serve
+	// HTTP on $PORT
+	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+		lambda.Start(a.LambdaHandler())
+		return
+	}
+
+	if err := a.StartCloudRun(); err != nil {
+		log.Fatal(err)
+	}
+}
+`
+
+// serverlessDockerfile builds a minimal image for Cloud Run
+const serverlessDockerfile = `FROM golang:1.23 AS build
+WORKDIR /src
+COPY go.mod go.sum ./
+RUN go mod download
+COPY . .
+RUN CGO_ENABLED=0 go build -trimpath -ldflags="-s -w" -o /server ./cmd/server
+
+FROM gcr.io/distroless/static-debian12
+COPY --from=build /server /server
+USER nonroot:nonroot
+ENTRYPOINT ["/server"]
+`
+
 // copyEmbeddedPkg copies embedded pkg files to the new project
 func copyEmbeddedPkg(projectName string) error {
 	return fs.WalkDir(embeddedPkg, "embedded/pkg", func(path string, d fs.DirEntry, err error) error {
@@ -286,13 +723,16 @@ func copyEmbeddedPkg(projectName string) error {
 }
 
 func handleGen() {
-	if len(os.Args) < 4 {
-		fmt.Println("Usage: goframe gen <model|handler|crud|middleware> <name>")
+	if len(os.Args) < 4 && !(len(os.Args) == 3 && (os.Args[2] == "ts" || os.Args[2] == "billing")) {
+		fmt.Println("Usage: goframe gen <model|handler|crud|admin|middleware|k8s|ts|billing> <name>")
 		os.Exit(1)
 	}
 
 	genType := os.Args[2]
-	name := os.Args[3]
+	name := ""
+	if len(os.Args) > 3 {
+		name = os.Args[3]
+	}
 
 	// Detect module name from go.mod
 	moduleName := detectModuleName()
@@ -320,12 +760,74 @@ func handleGen() {
 			os.Exit(1)
 		}
 		fmt.Printf("✓ CRUD '%s' generated\n", name)
+	case "admin":
+		opts, err := parseAdminOptions(name, os.Args[4:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Module = moduleName
+		files, err := generateAdmin(opts)
+		for _, f := range files {
+			fmt.Printf("✓ %s\n", f)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nMount the pages behind your authentication middleware:\n\n")
+		fmt.Printf("  adminGroup := a.Group(\"/admin\", sessionAuth)\n")
+		fmt.Printf("  admin.New%sAdmin(models.New%sService(db)).RegisterRoutes(adminGroup)\n", name, name)
 	case "middleware":
 		if err := generateMiddleware(name); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("✓ Middleware '%s' generated: internal/middleware/%s.go\n", name, strings.ToLower(name))
+	case "ts":
+		opts, err := parseTSOptions(os.Args[3:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := generateTSFile(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ TypeScript types generated: %s\n", opts.Output)
+	case "billing":
+		opts, err := parseBillingOptions(os.Args[3:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Module = moduleName
+		files, err := generateBilling(opts)
+		for _, f := range files {
+			fmt.Printf("✓ %s\n", f)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nMigrate the tables and mount the endpoints, verifying webhook signatures in parse:\n\n")
+		fmt.Printf("  svc := billing.NewService(db)\n")
+		fmt.Printf("  svc.Migrate()\n")
+		fmt.Printf("  billing.RegisterRoutes(a.Group(\"/billing\"), svc, parseGatewayEvent)\n")
+	case "k8s":
+		opts, err := parseK8sOptions(name, os.Args[4:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		files, err := generateK8s(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, f := range files {
+			fmt.Printf("✓ %s\n", f)
+		}
 	default:
 		fmt.Printf("Unknown type: %s\n", genType)
 		os.Exit(1)
@@ -495,6 +997,21 @@ func {{.Name}}() func(http.Handler) http.Handler {
 }
 
 func handleMigrate() {
+	if len(os.Args) > 2 && os.Args[2] == "squash" {
+		opts, err := parseSchemaOptions("migrate squash", os.Args[3:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		squashed, err := squashMigrations(context.Background(), opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ %d migrations squashed into %s\n", len(squashed), opts.Output)
+		return
+	}
+
 	// Check if migrations directory exists
 	if _, err := os.Stat("migrations"); os.IsNotExist(err) {
 		fmt.Println("No migrations directory found. Create 'migrations/' directory with SQL files.")
@@ -525,10 +1042,21 @@ func handleServe() {
 }
 
 func handleBuild() {
-	output := "bin/app"
-	if len(os.Args) > 2 {
-		output = os.Args[2]
+	opts, err := parseBuildOptions(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+	output := opts.Output
+
+	if !opts.NoCheck {
+		fmt.Println("Checking...")
+		if !runBuildChecks(opts) {
+			fmt.Fprintln(os.Stderr, "Pre-build checks failed; fix the issues above or pass --no-check")
+			os.Exit(1)
+		}
+	}
+
 	fmt.Println("Building...")
 	if err := os.MkdirAll("bin", 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating bin directory: %v\n", err)
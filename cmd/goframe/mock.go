@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.yaml.in/yaml/v3"
+)
+
+// mockOptions configures `goframe mock`
+type mockOptions struct {
+	Spec       string
+	Addr       string
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	ErrorRate  float64 // Share of requests answered with a documented error, or 500
+}
+
+func handleMock() {
+	var opts mockOptions
+	var latency string
+
+	fs := flag.NewFlagSet("mock", flag.ExitOnError)
+	fs.StringVar(&opts.Spec, "spec", "openapi.yaml", "OpenAPI 3 document (YAML or JSON)")
+	fs.StringVar(&opts.Addr, "addr", ":4010", "listen address")
+	fs.StringVar(&latency, "latency", "0", "added latency, fixed (200ms) or a range (50ms-400ms)")
+	fs.Float64Var(&opts.ErrorRate, "error-rate", 0, "share of requests failing, from 0 to 1")
+	_ = fs.Parse(os.Args[2:])
+
+	var err error
+	if opts.MinLatency, opts.MaxLatency, err = parseLatency(latency); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	handler, routes, err := newMockServer(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, route := range routes {
+		fmt.Printf("  %s\n", route)
+	}
+	fmt.Printf("✓ Mock server for %s on %s (Prefer: code=404 selects a response)\n", opts.Spec, opts.Addr)
+
+	server := &http.Server{Addr: opts.Addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func parseLatency(s string) (time.Duration, time.Duration, error) {
+	low, high, isRange := strings.Cut(s, "-")
+	min, err := time.ParseDuration(strings.TrimSpace(low))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latency %q", s)
+	}
+	if !isRange {
+		return min, min, nil
+	}
+	max, err := time.ParseDuration(strings.TrimSpace(high))
+	if err != nil || max < min {
+		return 0, 0, fmt.Errorf("invalid latency range %q", s)
+	}
+	return min, max, nil
+}
+
+// mockSpec holds the parts of an OpenAPI document the mock server reads
+type mockSpec struct {
+	root map[string]interface{}
+}
+
+var mockMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// newMockServer registers a route per documented operation and returns the
+// handler with the routes, for display
+func newMockServer(opts mockOptions) (http.Handler, []string, error) {
+	content, err := os.ReadFile(opts.Spec) // #nosec G304 -- the spec is chosen by the developer
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	spec := &mockSpec{root: root}
+
+	paths, _ := root["paths"].(map[string]interface{})
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("%s documents no paths", opts.Spec)
+	}
+
+	// Static paths first, so /users/me is not shadowed by /users/{id}
+	names := make([]string, 0, len(paths))
+	for path := range paths {
+		names = append(names, path)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		si, sj := strings.Count(names[i], "{"), strings.Count(names[j], "{")
+		if si != sj {
+			return si < sj
+		}
+		return names[i] < names[j]
+	})
+
+	router := mux.NewRouter()
+	var routes []string
+	for _, path := range names {
+		item, _ := paths[path].(map[string]interface{})
+		for _, method := range mockMethods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			responses, _ := op["responses"].(map[string]interface{})
+			router.Handle(path, spec.operationHandler(responses, opts)).Methods(strings.ToUpper(method))
+			routes = append(routes, fmt.Sprintf("%-7s %s", strings.ToUpper(method), path))
+		}
+	}
+
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, http.StatusNotFound, map[string]string{"error": "Route not documented in " + opts.Spec})
+	})
+	return router, routes, nil
+}
+
+func (s *mockSpec) operationHandler(responses map[string]interface{}, opts mockOptions) http.Handler {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if opts.MaxLatency > 0 {
+			delay := opts.MinLatency
+			if opts.MaxLatency > opts.MinLatency {
+				delay += time.Duration(rand.Int64N(int64(opts.MaxLatency - opts.MinLatency))) // #nosec G404 -- latency jitter, not security
+			}
+			time.Sleep(delay)
+		}
+
+		code := ""
+		if prefer := r.Header.Get("Prefer"); strings.HasPrefix(prefer, "code=") {
+			code = strings.TrimPrefix(prefer, "code=")
+		} else if opts.ErrorRate > 0 && rand.Float64() < opts.ErrorRate { // #nosec G404 -- fault injection, not security
+			code = firstCode(codes, "5", "4")
+			if code == "" {
+				writeMockJSON(w, http.StatusInternalServerError, map[string]string{"error": "Injected failure"})
+				return
+			}
+		} else {
+			code = firstCode(codes, "2", "3")
+		}
+
+		response, ok := responses[code].(map[string]interface{})
+		if !ok {
+			response, _ = responses["default"].(map[string]interface{})
+		}
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			status = http.StatusOK
+		}
+
+		body, hasBody := s.example(s.resolve(response))
+		if !hasBody {
+			w.WriteHeader(status)
+			return
+		}
+		writeMockJSON(w, status, body)
+	})
+}
+
+// firstCode returns the first documented status code with one of the
+// class prefixes, in order of preference
+func firstCode(codes []string, classes ...string) string {
+	for _, class := range classes {
+		for _, code := range codes {
+			if strings.HasPrefix(code, class) {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+// example returns the JSON example of a response: an explicit example if
+// the spec has one, otherwise a value generated from the schema
+func (s *mockSpec) example(response map[string]interface{}) (interface{}, bool) {
+	content, _ := response["content"].(map[string]interface{})
+	media, ok := content["application/json"].(map[string]interface{})
+	if !ok {
+		for contentType, m := range content {
+			if strings.HasSuffix(contentType, "+json") {
+				media, ok = m.(map[string]interface{})
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+
+	if example, ok := media["example"]; ok {
+		return example, true
+	}
+	if examples, ok := media["examples"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if value, ok := s.resolve(examples[name])["value"]; ok {
+				return value, true
+			}
+		}
+	}
+	schema, _ := media["schema"].(map[string]interface{})
+	return s.fake(schema, 0), true
+}
+
+// resolve follows a local $ref such as #/components/schemas/User
+func (s *mockSpec) resolve(node interface{}) map[string]interface{} {
+	m, _ := node.(map[string]interface{})
+	for i := 0; i < 16; i++ {
+		ref, ok := m["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return m
+		}
+		var cur interface{} = s.root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			next, _ := cur.(map[string]interface{})
+			cur = next[part]
+		}
+		m, _ = cur.(map[string]interface{})
+	}
+	return m
+}
+
+// fake generates a value matching a schema: examples, defaults and enums
+// first, then a placeholder by type and format
+func (s *mockSpec) fake(node interface{}, depth int) interface{} {
+	schema := s.resolve(node)
+	if schema == nil || depth > 8 {
+		return nil
+	}
+	if v, ok := schema["example"]; ok {
+		return v
+	}
+	if v, ok := schema["default"]; ok {
+		return v
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		if parts, ok := schema[key].([]interface{}); ok && len(parts) > 0 {
+			if key != "allOf" {
+				return s.fake(parts[0], depth+1)
+			}
+			merged := make(map[string]interface{})
+			for _, part := range parts {
+				if obj, ok := s.fake(part, depth+1).(map[string]interface{}); ok {
+					for k, v := range obj {
+						merged[k] = v
+					}
+				}
+			}
+			return merged
+		}
+	}
+
+	typ, _ := schema["type"].(string)
+	if types, ok := schema["type"].([]interface{}); ok && len(types) > 0 {
+		typ, _ = types[0].(string)
+	}
+	if typ == "" {
+		if _, ok := schema["properties"]; ok {
+			typ = "object"
+		}
+	}
+
+	switch typ {
+	case "object":
+		obj := make(map[string]interface{})
+		props, _ := schema["properties"].(map[string]interface{})
+		for name, prop := range props {
+			obj[name] = s.fake(prop, depth+1)
+		}
+		return obj
+	case "array":
+		return []interface{}{s.fake(schema["items"], depth+1)}
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	case "string":
+		switch schema["format"] {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// schemaOptions configures `goframe schema dump` and `goframe migrate squash`
+type schemaOptions struct {
+	DatabaseURL string
+	Dir         string // Migrations directory
+	Output      string
+	Keep        bool // Squash only: keep the squashed migration files
+}
+
+func parseSchemaOptions(name string, args []string) (schemaOptions, error) {
+	var opts schemaOptions
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.DatabaseURL, "database-url", os.Getenv("DATABASE_URL"), "database URL (default $DATABASE_URL)")
+	fs.StringVar(&opts.Dir, "dir", "migrations", "migrations directory")
+	fs.StringVar(&opts.Output, "o", "", "output file (default <dir>/schema.sql)")
+	fs.BoolVar(&opts.Keep, "keep", false, "keep the squashed migration files")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if opts.DatabaseURL == "" {
+		return opts, fmt.Errorf("no database: set DATABASE_URL or pass --database-url")
+	}
+	if opts.Output == "" {
+		opts.Output = filepath.Join(opts.Dir, "schema.sql")
+	}
+	return opts, nil
+}
+
+func handleSchema() {
+	if len(os.Args) < 3 || os.Args[2] != "dump" {
+		fmt.Println("Usage: goframe schema dump [--database-url <url>] [-o migrations/schema.sql]")
+		os.Exit(1)
+	}
+
+	opts, err := parseSchemaOptions("schema dump", os.Args[3:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	schema, err := dumpSchema(context.Background(), opts.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writeSchema(opts.Output, schemaHeader+schema); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Schema dumped: %s\n", opts.Output)
+}
+
+// squashMigrations replaces the SQL migrations of opts.Dir by a dump of the
+// database schema. The database must be migrated to the latest version.
+func squashMigrations(ctx context.Context, opts schemaOptions) ([]string, error) {
+	migrations, err := migrationFiles(opts.Dir, opts.Output)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations to squash in %s", opts.Dir)
+	}
+
+	schema, err := dumpSchema(ctx, opts.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var header strings.Builder
+	header.WriteString(schemaHeader)
+	header.WriteString("-- Squashed migrations:\n")
+	for _, m := range migrations {
+		header.WriteString("--   " + filepath.Base(m) + "\n")
+	}
+	header.WriteString("\n")
+
+	if err := writeSchema(opts.Output, header.String()+schema); err != nil {
+		return nil, err
+	}
+
+	if !opts.Keep {
+		for _, m := range migrations {
+			if err := os.Remove(m); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return migrations, nil
+}
+
+// migrationFiles lists the SQL migrations of dir in name order, skipping the
+// schema file itself
+func migrationFiles(dir, schemaFile string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, m := range matches {
+		if filepath.Clean(m) != filepath.Clean(schemaFile) {
+			files = append(files, m)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+const schemaHeader = "-- Schema dump generated by goframe; load it into an empty database\n-- before applying newer migrations.\n\n"
+
+func writeSchema(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644) // #nosec G306 -- schema files are committed to the project
+}
+
+// dumpSchema returns the DDL of the database tables, indexes, views and
+// triggers in a stable order, so dumps of identical schemas are identical
+func dumpSchema(ctx context.Context, databaseURL string) (string, error) {
+	config, err := database.ConfigFromURL("schema", databaseURL)
+	if err != nil {
+		return "", err
+	}
+
+	// pg_dump is the only complete source of PostgreSQL DDL
+	if config.Driver == database.PostgreSQL {
+		return pgDump(ctx, databaseURL)
+	}
+
+	config.LogLevel = logger.Silent
+	if err := database.Register(config); err != nil {
+		return "", err
+	}
+	if err := database.Initialize(ctx); err != nil {
+		return "", err
+	}
+	defer func() { _ = database.Close() }()
+
+	conn, err := database.Get(config.Name)
+	if err != nil {
+		return "", err
+	}
+	db := conn.WithContext(ctx)
+
+	var statements []string
+	switch config.Driver {
+	case database.SQLite:
+		statements, err = sqliteSchema(db)
+	case database.MySQL:
+		statements, err = mysqlSchema(db)
+	default:
+		err = fmt.Errorf("schema dump is not supported for %s", config.Driver)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(statements, ";\n\n") + ";\n", nil
+}
+
+func sqliteSchema(db *gorm.DB) ([]string, error) {
+	var statements []string
+	err := db.Raw(`SELECT sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+		ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, name`).
+		Scan(&statements).Error
+	return statements, err
+}
+
+// Table options that change with the data, not the schema
+var mysqlAutoIncrement = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+func mysqlSchema(db *gorm.DB) ([]string, error) {
+	rows, err := db.Raw("SHOW FULL TABLES").Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables, views []string
+	for rows.Next() {
+		var name, kind string
+		if err := rows.Scan(&name, &kind); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		if kind == "VIEW" {
+			views = append(views, name)
+		} else {
+			tables = append(tables, name)
+		}
+	}
+	_ = rows.Close()
+	sort.Strings(tables)
+	sort.Strings(views)
+
+	var statements []string
+	for _, kind := range []struct {
+		keyword string
+		names   []string
+	}{{"TABLE", tables}, {"VIEW", views}} {
+		for _, name := range kind.names {
+			var object, ddl string
+			row := db.Raw("SHOW CREATE " + kind.keyword + " `" + strings.ReplaceAll(name, "`", "``") + "`").Row()
+			if kind.keyword == "VIEW" {
+				var charset, collation string
+				err = row.Scan(&object, &ddl, &charset, &collation)
+			} else {
+				err = row.Scan(&object, &ddl)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to dump %s: %w", name, err)
+			}
+			statements = append(statements, mysqlAutoIncrement.ReplaceAllString(ddl, ""))
+		}
+	}
+	return statements, nil
+}
+
+func pgDump(ctx context.Context, databaseURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "pg_dump", "--schema-only", "--no-owner", "--no-privileges", "--no-comments", databaseURL) // #nosec G204 -- the database URL is supplied by the developer
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("pg_dump failed (is it installed?): %w", err)
+	}
+
+	// Drop the version banner and SET preamble so dumps only change with the schema
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "-- Dumped ") || strings.HasPrefix(line, "SET ") ||
+			strings.HasPrefix(line, "SELECT pg_catalog.set_config") || strings.HasPrefix(line, "\\restrict") ||
+			strings.HasPrefix(line, "\\unrestrict") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimLeft(strings.Join(lines, "\n"), "\n"), nil
+}
@@ -0,0 +1,325 @@
+// Package ai provides provider-agnostic clients for chat completions and
+// embeddings. Providers speak to a model API, such as an OpenAI-compatible
+// endpoint or a local server; Client adds request logging with redaction,
+// token accounting and quotas on top of any provider.
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+// Message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is a chat completion request
+type ChatRequest struct {
+	Model       string // Defaults to Config.ChatModel
+	Messages    []Message
+	Temperature *float64
+	MaxTokens   int
+	Stop        []string
+	User        string // End-user ID forwarded to the provider for abuse monitoring
+}
+
+// Usage counts the tokens of a request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add returns the sum of two usages
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+	}
+}
+
+// ChatResponse is a chat completion
+type ChatResponse struct {
+	ID           string
+	Model        string
+	Content      string
+	FinishReason string
+	Usage        Usage
+}
+
+// Chunk is a part of a streamed chat completion. The last chunk carries the
+// usage when the provider reports it.
+type Chunk struct {
+	Content      string
+	FinishReason string
+	Usage        *Usage
+}
+
+// Stream is a streamed chat completion. Recv returns io.EOF after the last
+// chunk.
+type Stream interface {
+	Recv() (Chunk, error)
+	Close() error
+}
+
+// EmbeddingRequest is an embedding request
+type EmbeddingRequest struct {
+	Model string // Defaults to Config.EmbeddingModel
+	Input []string
+	Dims  int // Output size, for models that can shorten embeddings
+	User  string
+}
+
+// EmbeddingResponse holds one embedding per input, in input order
+type EmbeddingResponse struct {
+	Model      string
+	Embeddings [][]float32
+	Usage      Usage
+}
+
+// Provider talks to a model API
+type Provider interface {
+	Name() string
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	ChatStream(ctx context.Context, req ChatRequest) (Stream, error)
+	Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error)
+}
+
+// ErrNoModel is returned when neither the request nor the config names a
+// model
+var ErrNoModel = errors.New("ai: no model")
+
+var tokensTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ai_tokens_total",
+		Help: "Total number of model tokens used",
+	},
+	[]string{"provider", "model", "type"},
+)
+
+// Config holds client configuration
+type Config struct {
+	ChatModel      string
+	EmbeddingModel string
+
+	// Quota is checked before every request and charged with its usage
+	Quota Quota
+
+	// LogBodies logs prompts and completions at debug level, passed through
+	// Redact first. Requests are always logged without their bodies.
+	LogBodies bool
+	Redact    func(string) string // Default RedactSecrets
+}
+
+// Client wraps a provider with logging and token accounting
+type Client struct {
+	provider Provider
+	config   Config
+}
+
+// New creates a client on provider
+func New(provider Provider, config Config) *Client {
+	if config.Redact == nil {
+		config.Redact = RedactSecrets
+	}
+	return &Client{provider: provider, config: config}
+}
+
+// Provider returns the underlying provider
+func (c *Client) Provider() Provider {
+	return c.provider
+}
+
+// Chat sends a chat completion request
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if err := c.prepareChat(ctx, &req); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	res, err := c.provider.Chat(ctx, req)
+	if err != nil {
+		c.logFailure(ctx, "chat", req.Model, start, err)
+		return nil, err
+	}
+	c.account(ctx, "chat", req.Model, start, res.Usage)
+	c.logBody(ctx, "completion", res.Content)
+	return res, nil
+}
+
+// Complete is Chat with a single user message, returning the text
+func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
+	res, err := c.Chat(ctx, ChatRequest{Messages: []Message{{Role: RoleUser, Content: prompt}}})
+	if err != nil {
+		return "", err
+	}
+	return res.Content, nil
+}
+
+// ChatStream sends a chat completion request and streams the answer. The
+// usage is accounted when the stream ends.
+func (c *Client) ChatStream(ctx context.Context, req ChatRequest) (Stream, error) {
+	if err := c.prepareChat(ctx, &req); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	stream, err := c.provider.ChatStream(ctx, req)
+	if err != nil {
+		c.logFailure(ctx, "chat", req.Model, start, err)
+		return nil, err
+	}
+	return &accountedStream{Stream: stream, client: c, ctx: ctx, model: req.Model, start: start}, nil
+}
+
+// Embed computes embeddings for the inputs
+func (c *Client) Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	if req.Model == "" {
+		req.Model = c.config.EmbeddingModel
+	}
+	if req.Model == "" {
+		return nil, ErrNoModel
+	}
+	if err := c.allow(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	res, err := c.provider.Embed(ctx, req)
+	if err != nil {
+		c.logFailure(ctx, "embed", req.Model, start, err)
+		return nil, err
+	}
+	c.account(ctx, "embed", req.Model, start, res.Usage)
+	return res, nil
+}
+
+// EmbedOne computes the embedding of a single text
+func (c *Client) EmbedOne(ctx context.Context, text string) ([]float32, error) {
+	res, err := c.Embed(ctx, EmbeddingRequest{Input: []string{text}})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Embeddings) != 1 {
+		return nil, errors.New("ai: provider returned no embedding")
+	}
+	return res.Embeddings[0], nil
+}
+
+func (c *Client) prepareChat(ctx context.Context, req *ChatRequest) error {
+	if req.Model == "" {
+		req.Model = c.config.ChatModel
+	}
+	if req.Model == "" {
+		return ErrNoModel
+	}
+	if err := c.allow(ctx); err != nil {
+		return err
+	}
+	for _, m := range req.Messages {
+		c.logBody(ctx, m.Role, m.Content)
+	}
+	return nil
+}
+
+func (c *Client) allow(ctx context.Context) error {
+	if c.config.Quota == nil {
+		return nil
+	}
+	return c.config.Quota.Allow(ctx)
+}
+
+// account records the usage of a successful request
+func (c *Client) account(ctx context.Context, kind, model string, start time.Time, usage Usage) {
+	name := c.provider.Name()
+	tokensTotal.WithLabelValues(name, model, "prompt").Add(float64(usage.PromptTokens))
+	tokensTotal.WithLabelValues(name, model, "completion").Add(float64(usage.CompletionTokens))
+
+	if c.config.Quota != nil {
+		c.config.Quota.Consume(ctx, model, usage)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"provider":          name,
+		"model":             model,
+		"duration":          time.Since(start).String(),
+		"prompt_tokens":     usage.PromptTokens,
+		"completion_tokens": usage.CompletionTokens,
+	}).Infof("ai %s request", kind)
+}
+
+func (c *Client) logFailure(ctx context.Context, kind, model string, start time.Time, err error) {
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"provider": c.provider.Name(),
+		"model":    model,
+		"duration": time.Since(start).String(),
+	}).Warnf("ai %s request failed: %s", kind, c.config.Redact(err.Error()))
+}
+
+func (c *Client) logBody(ctx context.Context, role, content string) {
+	if c.config.LogBodies {
+		logrus.WithContext(ctx).WithField("role", role).Debug(c.config.Redact(content))
+	}
+}
+
+// accountedStream accounts the usage of a stream once it ends
+type accountedStream struct {
+	Stream
+	client *Client
+	ctx    context.Context
+	model  string
+	start  time.Time
+
+	content []byte
+	usage   Usage
+	done    bool
+}
+
+func (s *accountedStream) Recv() (Chunk, error) {
+	chunk, err := s.Stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			s.finish()
+		}
+		return chunk, err
+	}
+	if chunk.Usage != nil {
+		s.usage = *chunk.Usage
+	}
+	if s.client.config.LogBodies {
+		s.content = append(s.content, chunk.Content...)
+	}
+	return chunk, nil
+}
+
+// Close accounts a stream abandoned before its end, e.g. when the client
+// disconnected, with the usage reported so far
+func (s *accountedStream) Close() error {
+	s.finish()
+	return s.Stream.Close()
+}
+
+func (s *accountedStream) finish() {
+	if s.done {
+		return
+	}
+	s.done = true
+	s.client.account(s.ctx, "chat stream", s.model, s.start, s.usage)
+	s.client.logBody(s.ctx, "completion", string(s.content))
+}
@@ -0,0 +1,170 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/sse"
+)
+
+// fakeOpenAI serves canned chat, streaming and embedding responses
+func fakeOpenAI(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"message":"Invalid API key","type":"invalid_request_error","code":"invalid_api_key"}}`)
+			return
+		}
+
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		switch {
+		case r.URL.Path == "/v1/embeddings":
+			fmt.Fprint(w, `{"model":"embed-1","data":[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}],"usage":{"prompt_tokens":4,"total_tokens":4}}`)
+		case body["stream"] == true:
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, part := range []string{"Hel", "lo"} {
+				fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", part)
+			}
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":2,\"total_tokens\":7}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		default:
+			fmt.Fprintf(w, `{"id":"c1","model":%q,"choices":[{"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`, body["model"])
+		}
+	}))
+}
+
+func TestClient_ChatAndEmbed(t *testing.T) {
+	server := fakeOpenAI(t)
+	defer server.Close()
+
+	quota := NewMemoryQuota(10, time.Hour, nil)
+	client := New(NewOpenAI(OpenAIConfig{BaseURL: server.URL + "/v1", APIKey: "sk-test"}), Config{
+		ChatModel:      "chat-1",
+		EmbeddingModel: "embed-1",
+		Quota:          quota,
+	})
+	ctx := context.Background()
+
+	res, err := client.Chat(ctx, ChatRequest{Messages: []Message{{Role: RoleUser, Content: "Hello"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Content != "Hi" || res.Model != "chat-1" || res.Usage.TotalTokens != 6 {
+		t.Errorf("unexpected response %+v", res)
+	}
+
+	emb, err := client.Embed(ctx, EmbeddingRequest{Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emb.Embeddings) != 2 || emb.Embeddings[0][0] != 0.1 || emb.Embeddings[1][0] != 0.3 {
+		t.Errorf("expected embeddings in input order, got %v", emb.Embeddings)
+	}
+
+	if got := quota.Remaining(ctx); got != 0 {
+		t.Errorf("expected 10 tokens charged, %d remaining", got)
+	}
+	if _, err := client.Complete(ctx, "Again"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+}
+
+func TestOpenAI_APIError(t *testing.T) {
+	server := fakeOpenAI(t)
+	defer server.Close()
+
+	client := New(NewOpenAI(OpenAIConfig{BaseURL: server.URL + "/v1", APIKey: "wrong"}), Config{ChatModel: "chat-1"})
+	_, err := client.Complete(context.Background(), "Hello")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != "invalid_api_key" {
+		t.Errorf("expected an APIError, got %v", err)
+	}
+	if _, err := New(NewOpenAI(OpenAIConfig{}), Config{}).Complete(context.Background(), "Hello"); !errors.Is(err, ErrNoModel) {
+		t.Errorf("expected ErrNoModel, got %v", err)
+	}
+}
+
+func TestPipeSSE(t *testing.T) {
+	server := fakeOpenAI(t)
+	defer server.Close()
+
+	quota := NewMemoryQuota(100, time.Hour, nil)
+	client := New(NewOpenAI(OpenAIConfig{BaseURL: server.URL + "/v1", APIKey: "sk-test"}), Config{ChatModel: "chat-1", Quota: quota})
+
+	var text string
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := client.ChatStream(r.Context(), ChatRequest{Messages: []Message{{Role: RoleUser, Content: "Hello"}}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		out, err := sse.NewStream(w, r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if text, err = PipeSSE(out, stream); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer app.Close()
+
+	res, err := http.Get(app.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	want := "event: delta\ndata: Hel\n\nevent: delta\ndata: lo\n\n" +
+		"event: done\ndata: {\"finish_reason\":\"stop\",\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":2,\"total_tokens\":7}}\n\n"
+	if string(body) != want {
+		t.Errorf("unexpected event stream:\n%s", body)
+	}
+	if text != "Hello" {
+		t.Errorf("expected the full completion, got %q", text)
+	}
+	if got := quota.Remaining(context.Background()); got != 93 {
+		t.Errorf("expected the stream usage to be charged, %d remaining", got)
+	}
+}
+
+func TestMemoryQuota_Keys(t *testing.T) {
+	type userKey struct{}
+	quota := NewMemoryQuota(5, time.Hour, func(ctx context.Context) string {
+		user, _ := ctx.Value(userKey{}).(string)
+		return user
+	})
+	alice := context.WithValue(context.Background(), userKey{}, "alice")
+	bob := context.WithValue(context.Background(), userKey{}, "bob")
+
+	quota.Consume(alice, "chat-1", Usage{TotalTokens: 5})
+	if err := quota.Allow(alice); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected alice to be over quota, got %v", err)
+	}
+	if err := quota.Allow(bob); err != nil {
+		t.Errorf("expected bob to have a separate budget, got %v", err)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	in := "key sk-abcdefghijklmnopqrstuv, Authorization: Bearer abc.def, mail ada@example.com, card 4111 1111 1111 1111"
+	out := RedactSecrets(in)
+	for _, secret := range []string{"sk-abc", "abc.def", "ada@example.com", "4111"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %q to be redacted: %s", secret, out)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultOpenAIURL is the base URL of the OpenAI API
+const DefaultOpenAIURL = "https://api.openai.com/v1"
+
+// OpenAIConfig configures an OpenAI-compatible provider
+type OpenAIConfig struct {
+	// BaseURL of the API, e.g. http://localhost:11434/v1 for Ollama or the
+	// URL of a vLLM or LiteLLM server (default DefaultOpenAIURL)
+	BaseURL string
+	APIKey  string // Sent as a bearer token when set
+	Name    string // Provider name in logs and metrics (default "openai")
+
+	Headers    map[string]string // Extra headers, e.g. OpenAI-Organization
+	HTTPClient *http.Client
+	Timeout    time.Duration // Timeout of non-streaming requests (default 60s)
+}
+
+// OpenAI is a provider for the OpenAI chat completions and embeddings API,
+// which most hosted and local model servers implement
+type OpenAI struct {
+	config OpenAIConfig
+}
+
+// NewOpenAI creates an OpenAI-compatible provider
+func NewOpenAI(config OpenAIConfig) *OpenAI {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultOpenAIURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Name == "" {
+		config.Name = "openai"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 60 * time.Second
+	}
+	return &OpenAI{config: config}
+}
+
+// APIError is an error response of the API
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ai: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// Name implements Provider
+func (p *OpenAI) Name() string {
+	return p.config.Name
+}
+
+type openAIChatRequest struct {
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Stop          []string       `json:"stop,omitempty"`
+	User          string         `json:"user,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIChoice struct {
+	Message      Message `json:"message"`
+	Delta        Message `json:"delta"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+type openAIChatResponse struct {
+	ID      string         `json:"id"`
+	Model   string         `json:"model"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   *Usage         `json:"usage"`
+}
+
+func newOpenAIChatRequest(req ChatRequest) openAIChatRequest {
+	return openAIChatRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stop:        req.Stop,
+		User:        req.User,
+	}
+}
+
+// Chat implements Provider
+func (p *OpenAI) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	var res openAIChatResponse
+	if err := p.do(ctx, "/chat/completions", newOpenAIChatRequest(req), &res); err != nil {
+		return nil, err
+	}
+	if len(res.Choices) == 0 {
+		return nil, fmt.Errorf("ai: %s returned no choices", p.config.Name)
+	}
+
+	out := &ChatResponse{
+		ID:           res.ID,
+		Model:        res.Model,
+		Content:      res.Choices[0].Message.Content,
+		FinishReason: res.Choices[0].FinishReason,
+	}
+	if res.Usage != nil {
+		out.Usage = *res.Usage
+	}
+	return out, nil
+}
+
+// ChatStream implements Provider. The stream is bound to ctx rather than to
+// the request timeout, since answers can take long to generate.
+func (p *OpenAI) ChatStream(ctx context.Context, req ChatRequest) (Stream, error) {
+	body := newOpenAIChatRequest(req)
+	body.Stream = true
+	body.StreamOptions = &streamOptions{IncludeUsage: true}
+
+	res, err := p.send(ctx, "/chat/completions", body)
+	if err != nil {
+		return nil, err
+	}
+	return &openAIStream{body: res.Body, reader: bufio.NewReader(res.Body)}, nil
+}
+
+type openAIEmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+	User       string   `json:"user,omitempty"`
+}
+
+type openAIEmbeddingResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage *Usage `json:"usage"`
+}
+
+// Embed implements Provider
+func (p *OpenAI) Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	var res openAIEmbeddingResponse
+	err := p.do(ctx, "/embeddings", openAIEmbeddingRequest{
+		Model:      req.Model,
+		Input:      req.Input,
+		Dimensions: req.Dims,
+		User:       req.User,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Data) != len(req.Input) {
+		return nil, fmt.Errorf("ai: %s returned %d embeddings for %d inputs", p.config.Name, len(res.Data), len(req.Input))
+	}
+
+	sort.Slice(res.Data, func(i, j int) bool { return res.Data[i].Index < res.Data[j].Index })
+	out := &EmbeddingResponse{Model: res.Model, Embeddings: make([][]float32, len(res.Data))}
+	for i, d := range res.Data {
+		out.Embeddings[i] = d.Embedding
+	}
+	if res.Usage != nil {
+		out.Usage = *res.Usage
+	}
+	return out, nil
+}
+
+// do sends a request and decodes the JSON response into out
+func (p *OpenAI) do(ctx context.Context, path string, body, out interface{}) error {
+	res, err := p.send(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// send posts body and returns the response, or an APIError for error
+// statuses
+func (p *OpenAI) send(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+	for k, v := range p.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 300 {
+		defer res.Body.Close()
+		return nil, readAPIError(res)
+	}
+	return res, nil
+}
+
+func readAPIError(res *http.Response) error {
+	apiErr := &APIError{StatusCode: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+
+	var body struct {
+		Error struct {
+			Message string      `json:"message"`
+			Type    string      `json:"type"`
+			Code    interface{} `json:"code"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		apiErr.Message, apiErr.Type = body.Error.Message, body.Error.Type
+		if body.Error.Code != nil {
+			apiErr.Code = fmt.Sprint(body.Error.Code)
+		}
+	}
+	return apiErr
+}
+
+// openAIStream reads chat completion chunks from an event stream
+type openAIStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	done   bool
+}
+
+func (s *openAIStream) Recv() (Chunk, error) {
+	if s.done {
+		return Chunk{}, io.EOF
+	}
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) == "" {
+				return Chunk{}, io.ErrUnexpectedEOF // Ended without [DONE]
+			}
+			if err != io.EOF {
+				return Chunk{}, err
+			}
+		}
+
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+		if !ok {
+			continue // Blank lines, comments and other fields
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			s.done = true
+			return Chunk{}, io.EOF
+		}
+
+		var res openAIChatResponse
+		if err := json.Unmarshal([]byte(data), &res); err != nil {
+			return Chunk{}, fmt.Errorf("ai: invalid stream chunk: %w", err)
+		}
+		chunk := Chunk{Usage: res.Usage}
+		if len(res.Choices) > 0 {
+			chunk.Content = res.Choices[0].Delta.Content
+			chunk.FinishReason = res.Choices[0].FinishReason
+		}
+		return chunk, nil
+	}
+}
+
+func (s *openAIStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,85 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a quota has no tokens left
+var ErrQuotaExceeded = errors.New("ai: token quota exceeded")
+
+// Quota limits token usage. Allow is checked before a request, whose size
+// is unknown until it completes; Consume charges the usage afterwards, so
+// a quota can be overrun by one request.
+type Quota interface {
+	Allow(ctx context.Context) error
+	Consume(ctx context.Context, model string, usage Usage)
+}
+
+// MemoryQuota allows a number of tokens per key and window, e.g. per user
+// per day, in memory
+type MemoryQuota struct {
+	limit  int
+	window time.Duration
+	key    func(ctx context.Context) string
+
+	mu      sync.Mutex
+	windows map[string]*quotaWindow
+}
+
+type quotaWindow struct {
+	start time.Time
+	used  int
+}
+
+// NewMemoryQuota creates a quota of limit tokens per window. key identifies
+// who is charged, e.g. the authenticated user; nil charges one shared
+// budget.
+func NewMemoryQuota(limit int, window time.Duration, key func(ctx context.Context) string) *MemoryQuota {
+	if key == nil {
+		key = func(context.Context) string { return "" }
+	}
+	return &MemoryQuota{limit: limit, window: window, key: key, windows: make(map[string]*quotaWindow)}
+}
+
+// Allow implements Quota
+func (q *MemoryQuota) Allow(ctx context.Context) error {
+	if q.Remaining(ctx) <= 0 {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Consume implements Quota
+func (q *MemoryQuota) Consume(ctx context.Context, _ string, usage Usage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.current(q.key(ctx)).used += usage.TotalTokens
+}
+
+// Remaining returns the tokens left in the current window of the caller
+func (q *MemoryQuota) Remaining(ctx context.Context) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.limit - q.current(q.key(ctx)).used
+}
+
+// current returns the window of key, starting a new one once it expired;
+// q.mu must be held
+func (q *MemoryQuota) current(key string) *quotaWindow {
+	now := time.Now()
+	w, ok := q.windows[key]
+	if !ok || now.Sub(w.start) >= q.window {
+		// Drop expired windows of other keys while here
+		for k, other := range q.windows {
+			if now.Sub(other.start) >= q.window {
+				delete(q.windows, k)
+			}
+		}
+		w = &quotaWindow{start: now}
+		q.windows[key] = w
+	}
+	return w
+}
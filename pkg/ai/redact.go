@@ -0,0 +1,20 @@
+package ai
+
+import "regexp"
+
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(sk|pk|rk)-[A-Za-z0-9_-]{16,}`),                     // API keys
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`),                  // Bearer tokens
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), // JWTs
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),      // Email addresses
+	regexp.MustCompile(`\b(?:\d[ -]?){13,19}\b`),                              // Card numbers
+}
+
+// RedactSecrets masks API keys, bearer tokens, JWTs, email addresses and
+// card numbers in text before it is logged
+func RedactSecrets(text string) string {
+	for _, p := range secretPatterns {
+		text = p.ReplaceAllString(text, "[REDACTED]")
+	}
+	return text
+}
@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/polymatx/goframe/pkg/sse"
+)
+
+// PipeSSE forwards a chat stream to a server-sent event stream, such as the
+// one returned by Context.SSE. Each chunk is sent as a "delta" event; a
+// final "done" event carries the finish reason and usage as JSON, or an
+// "error" event if the provider failed mid-stream. It returns the full
+// completion and closes stream.
+//
+//	s, err := ctx.SSE()
+//	if err != nil {
+//		return err
+//	}
+//	text, err := ai.PipeSSE(s, stream)
+func PipeSSE(out *sse.Stream, stream Stream) (string, error) {
+	defer stream.Close()
+
+	var content strings.Builder
+	var done struct {
+		FinishReason string `json:"finish_reason,omitempty"`
+		Usage        *Usage `json:"usage,omitempty"`
+	}
+	for {
+		select {
+		case <-out.Done():
+			return content.String(), errors.New("ai: client disconnected")
+		default:
+		}
+
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = out.Send(sse.Event{Event: "error", Data: "stream interrupted"})
+			return content.String(), err
+		}
+
+		if chunk.FinishReason != "" {
+			done.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			done.Usage = chunk.Usage
+		}
+		if chunk.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Content)
+		if err := out.Send(sse.Event{Event: "delta", Data: chunk.Content}); err != nil {
+			return content.String(), err
+		}
+	}
+
+	data, err := json.Marshal(done)
+	if err != nil {
+		return content.String(), err
+	}
+	return content.String(), out.Send(sse.Event{Event: "done", Data: string(data)})
+}
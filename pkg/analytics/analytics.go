@@ -0,0 +1,298 @@
+// Package analytics tracks product events and delivers them in batches to a
+// sink such as a Segment-compatible HTTP API or ClickHouse. Delivery is
+// asynchronous: Track never blocks on the network, and events are dropped
+// rather than slowing requests when the queue is full.
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	mathrand "math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/sirupsen/logrus"
+)
+
+// Event types
+const (
+	TypeTrack    = "track"
+	TypeIdentify = "identify"
+)
+
+// ErrClosed is returned when tracking on a closed client
+var ErrClosed = errors.New("analytics client closed")
+
+// Event is a tracked event, using Segment field names
+type Event struct {
+	Type        string                 `json:"type"`
+	Event       string                 `json:"event,omitempty"`
+	UserID      string                 `json:"userId,omitempty"`
+	AnonymousID string                 `json:"anonymousId,omitempty"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Traits      map[string]interface{} `json:"traits,omitempty"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
+	MessageID   string                 `json:"messageId"`
+}
+
+// Sink delivers a batch of events
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+}
+
+// SinkFunc adapts a function to Sink, e.g. to publish to Kafka
+type SinkFunc func(ctx context.Context, events []Event) error
+
+// Send implements Sink
+func (f SinkFunc) Send(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// Config holds analytics client configuration
+type Config struct {
+	Sink Sink
+
+	BatchSize     int           // Events per delivery (default 100)
+	FlushInterval time.Duration // Maximum time an event waits for a batch (default 5s)
+	QueueSize     int           // Buffered events before new ones are dropped (default 10000)
+	SendTimeout   time.Duration // Timeout per delivery (default 10s)
+
+	// SampleRate is the fraction of track events kept, between 0 and 1.
+	// Zero means keep everything; EventSampleRates overrides it per event.
+	// Identify calls are never sampled.
+	SampleRate       float64
+	EventSampleRates map[string]float64
+
+	// Enrich can add fields to every event, e.g. tenant or app version
+	Enrich func(ctx context.Context, e *Event)
+}
+
+// Client batches events and delivers them in the background
+type Client struct {
+	config  Config
+	queue   chan Event
+	flushCh chan chan struct{}
+	done    chan struct{}
+
+	mu      sync.RWMutex // Guards sends on queue against Close
+	closed  bool
+	dropped atomic.Int64
+}
+
+// NewClient creates a client and starts its delivery loop. Call Close on
+// shutdown to deliver queued events.
+func NewClient(config Config) *Client {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = 10 * time.Second
+	}
+
+	c := &Client{
+		config:  config,
+		queue:   make(chan Event, config.QueueSize),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go c.loop()
+	return c
+}
+
+// Track records an event with properties. The user is taken from the JWT
+// claims in ctx and request details from Middleware.
+func (c *Client) Track(ctx context.Context, event string, properties map[string]interface{}) error {
+	if !c.sampled(event) {
+		return nil
+	}
+	return c.enqueue(ctx, Event{Type: TypeTrack, Event: event, Properties: properties})
+}
+
+// Identify associates traits with a user. An empty userID uses the user
+// from the JWT claims in ctx.
+func (c *Client) Identify(ctx context.Context, userID string, traits map[string]interface{}) error {
+	return c.enqueue(ctx, Event{Type: TypeIdentify, UserID: userID, Traits: traits})
+}
+
+// Dropped returns the number of events dropped because the queue was full
+// or delivery failed
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Flush delivers all queued events and waits until done or ctx expires
+func (c *Client) Flush(ctx context.Context) error {
+	c.mu.RLock()
+	closed := c.closed
+	c.mu.RUnlock()
+	if closed {
+		return ErrClosed
+	}
+
+	ack := make(chan struct{})
+	select {
+	case c.flushCh <- ack:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events and delivers the queued ones. It fits
+// App.OnShutdown.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.queue)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) enqueue(ctx context.Context, e Event) error {
+	c.enrich(ctx, &e)
+
+	// The send never blocks, so holding the read lock cannot stall Close
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return ErrClosed
+	}
+
+	select {
+	case c.queue <- e:
+	default:
+		if c.dropped.Add(1)%1000 == 1 {
+			logrus.Warnf("Analytics queue full, dropping events (%d dropped so far)", c.dropped.Load())
+		}
+	}
+	return nil
+}
+
+func (c *Client) enrich(ctx context.Context, e *Event) {
+	e.Timestamp = time.Now().UTC()
+	e.MessageID = newMessageID()
+
+	if e.UserID == "" {
+		if claims, ok := auth.GetClaims(ctx); ok {
+			e.UserID = claims.UserID
+		}
+	}
+
+	if info, ok := requestInfoFromContext(ctx); ok {
+		if e.AnonymousID == "" {
+			e.AnonymousID = info.AnonymousID
+		}
+		e.Context = info.context()
+	} else {
+		e.Context = make(map[string]interface{})
+	}
+
+	if c.config.Enrich != nil {
+		c.config.Enrich(ctx, e)
+	}
+}
+
+func (c *Client) sampled(event string) bool {
+	rate, ok := c.config.EventSampleRates[event]
+	if !ok {
+		rate = c.config.SampleRate
+		if rate == 0 {
+			return true
+		}
+	}
+	return rate >= 1 || mathrand.Float64() < rate
+}
+
+func (c *Client) loop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, c.config.BatchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		c.send(batch)
+		batch = make([]Event, 0, c.config.BatchSize)
+	}
+
+	for {
+		select {
+		case e, ok := <-c.queue:
+			if !ok {
+				send()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= c.config.BatchSize {
+				send()
+			}
+
+		case ack := <-c.flushCh:
+			// Drain what is already queued before acknowledging
+			for drained := false; !drained; {
+				select {
+				case e, ok := <-c.queue:
+					if !ok {
+						drained = true
+						break
+					}
+					batch = append(batch, e)
+					if len(batch) >= c.config.BatchSize {
+						send()
+					}
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(ack)
+
+		case <-ticker.C:
+			send()
+		}
+	}
+}
+
+func (c *Client) send(batch []Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.SendTimeout)
+	defer cancel()
+
+	if err := c.config.Sink.Send(ctx, batch); err != nil {
+		c.dropped.Add(int64(len(batch)))
+		logrus.Errorf("Failed to deliver %d analytics events: %v", len(batch), err)
+	}
+}
+
+func newMessageID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,270 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logrus.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// recordingSink collects delivered batches
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]Event
+	err     error
+}
+
+func (s *recordingSink) Send(ctx context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]Event(nil), events...))
+	return s.err
+}
+
+func (s *recordingSink) events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []Event
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+func TestClient_Batching(t *testing.T) {
+	sink := &recordingSink{}
+	c := NewClient(Config{Sink: sink, BatchSize: 2, FlushInterval: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		if err := c.Track(context.Background(), "Page Viewed", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.batches) != 3 {
+		t.Errorf("expected batches of 2, 2 and 1, got %d batches", len(sink.batches))
+	}
+	if got := len(sink.events()); got != 5 {
+		t.Errorf("expected 5 events, got %d", got)
+	}
+
+	if err := c.Track(context.Background(), "After Close", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestClient_Flush(t *testing.T) {
+	sink := &recordingSink{}
+	c := NewClient(Config{Sink: sink, FlushInterval: time.Hour})
+	defer c.Close(context.Background())
+
+	_ = c.Identify(context.Background(), "user-1", map[string]interface{}{"plan": "pro"})
+	if err := c.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	events := sink.events()
+	if len(events) != 1 || events[0].Type != TypeIdentify || events[0].UserID != "user-1" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if events[0].MessageID == "" || events[0].Timestamp.IsZero() {
+		t.Error("expected message ID and timestamp")
+	}
+}
+
+func TestClient_Enrichment(t *testing.T) {
+	sink := &recordingSink{}
+	c := NewClient(Config{
+		Sink: sink,
+		Enrich: func(ctx context.Context, e *Event) {
+			e.Context["tenant"] = "acme"
+		},
+	})
+
+	handler := Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := auth.WithClaims(r.Context(), &auth.Claims{UserID: "user-7"})
+		_ = c.Track(ctx, "Order Completed", map[string]interface{}{"total": 42})
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/checkout", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.AddCookie(&http.Cookie{Name: AnonymousIDCookie, Value: "anon-1"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	_ = c.Close(context.Background())
+
+	events := sink.events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	e := events[0]
+	if e.UserID != "user-7" || e.AnonymousID != "anon-1" {
+		t.Errorf("unexpected identity %q %q", e.UserID, e.AnonymousID)
+	}
+	if e.Context["ip"] != "203.0.113.9" || e.Context["userAgent"] != "test-agent" {
+		t.Errorf("unexpected context %v", e.Context)
+	}
+	if e.Context["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" || e.Context["tenant"] != "acme" {
+		t.Errorf("unexpected context %v", e.Context)
+	}
+}
+
+func TestClient_Sampling(t *testing.T) {
+	sink := &recordingSink{}
+	c := NewClient(Config{
+		Sink:             sink,
+		SampleRate:       0.000001,
+		EventSampleRates: map[string]float64{"Signup": 1},
+	})
+
+	for i := 0; i < 100; i++ {
+		_ = c.Track(context.Background(), "Heartbeat", nil)
+	}
+	_ = c.Track(context.Background(), "Signup", nil)
+	_ = c.Identify(context.Background(), "user-1", nil)
+	_ = c.Close(context.Background())
+
+	events := sink.events()
+	if len(events) != 2 {
+		t.Errorf("expected only Signup and Identify to be kept, got %d events", len(events))
+	}
+}
+
+func TestClient_DropsWhenQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	sink := SinkFunc(func(ctx context.Context, events []Event) error {
+		<-block
+		return nil
+	})
+	c := NewClient(Config{Sink: sink, BatchSize: 1, QueueSize: 1})
+
+	for i := 0; i < 10; i++ {
+		_ = c.Track(context.Background(), "Spam", nil)
+	}
+	if c.Dropped() == 0 {
+		t.Error("expected events to be dropped when the queue is full")
+	}
+
+	close(block)
+	_ = c.Close(context.Background())
+}
+
+func TestClient_TrackDuringClose(t *testing.T) {
+	sink := &recordingSink{}
+	c := NewClient(Config{Sink: sink, FlushInterval: time.Hour})
+
+	var wg sync.WaitGroup
+	var accepted sync.Map
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				err := c.Track(context.Background(), "Concurrent", nil)
+				if errors.Is(err, ErrClosed) {
+					return
+				}
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				accepted.Store([2]int{i, j}, true)
+			}
+		}(i)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	n := 0
+	accepted.Range(func(_, _ interface{}) bool { n++; return true })
+	if got := len(sink.events()); got != n {
+		t.Errorf("expected the %d accepted events to be delivered, got %d", n, got)
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var got struct {
+		Batch []Event `json:"batch"`
+	}
+	var user string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, _ = r.BasicAuth()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	sink := &HTTPSink{URL: server.URL, WriteKey: "write-key"}
+	if err := sink.Send(context.Background(), []Event{{Type: TypeTrack, Event: "Signup"}}); err != nil {
+		t.Fatal(err)
+	}
+	if user != "write-key" {
+		t.Errorf("expected write key as basic auth user, got %q", user)
+	}
+	if len(got.Batch) != 1 || got.Batch[0].Event != "Signup" {
+		t.Errorf("unexpected batch %+v", got.Batch)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer failing.Close()
+
+	if err := (&HTTPSink{URL: failing.URL}).Send(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
+
+func TestClickHouseSink(t *testing.T) {
+	var query, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer server.Close()
+
+	sink := &ClickHouseSink{URL: server.URL, Table: "events"}
+	err := sink.Send(context.Background(), []Event{
+		{Type: TypeTrack, Event: "Signup", Properties: map[string]interface{}{"plan": "pro"}, Timestamp: time.Unix(0, 0)},
+		{Type: TypeIdentify, UserID: "u1", Traits: map[string]interface{}{"name": "Ann"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if query != "INSERT INTO events FORMAT JSONEachRow" {
+		t.Errorf("unexpected query %q", query)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 rows, got %d: %s", len(lines), body)
+	}
+	if !strings.Contains(lines[0], `"properties":"{\"plan\":\"pro\"}"`) || !strings.Contains(lines[0], `"timestamp":"1970-01-01 00:00:00.000"`) {
+		t.Errorf("unexpected first row %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"properties":"{\"name\":\"Ann\"}"`) {
+		t.Errorf("expected traits in properties for identify, got %s", lines[1])
+	}
+}
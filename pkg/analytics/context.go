@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// AnonymousIDCookie identifies visitors before they log in
+const AnonymousIDCookie = "ajs_anonymous_id"
+
+type requestInfoKey struct{}
+
+type requestInfo struct {
+	IP          string
+	UserAgent   string
+	Path        string
+	Referrer    string
+	TraceID     string
+	AnonymousID string
+}
+
+func (i requestInfo) context() map[string]interface{} {
+	ctx := map[string]interface{}{
+		"ip":        i.IP,
+		"userAgent": i.UserAgent,
+		"page": map[string]interface{}{
+			"path":     i.Path,
+			"referrer": i.Referrer,
+		},
+	}
+	if i.TraceID != "" {
+		ctx["traceId"] = i.TraceID
+	}
+	return ctx
+}
+
+// Middleware stores request details in the context so tracked events are
+// enriched with the client IP, user agent, page, trace ID and the
+// anonymous ID cookie
+func Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := requestInfo{
+				IP:        clientIP(r),
+				UserAgent: r.UserAgent(),
+				Path:      r.URL.Path,
+				Referrer:  r.Referer(),
+				TraceID:   traceID(r.Header.Get("traceparent")),
+			}
+			if cookie, err := r.Cookie(AnonymousIDCookie); err == nil {
+				info.AnonymousID = cookie.Value
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+		})
+	}
+}
+
+func requestInfoFromContext(ctx context.Context) (requestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(requestInfo)
+	return info, ok
+}
+
+// traceID extracts the trace ID from a W3C traceparent header
+func traceID(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	return parts[1]
+}
+
+func clientIP(r *http.Request) string {
+	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
+		return strings.TrimSpace(strings.Split(ip, ",")[0])
+	}
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPSink posts batches to a Segment-compatible batch endpoint such as
+// https://api.segment.io/v1/batch or a RudderStack data plane
+type HTTPSink struct {
+	URL        string
+	WriteKey   string // Sent as the basic auth username
+	HTTPClient *http.Client
+}
+
+// Send implements Sink
+func (s *HTTPSink) Send(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(struct {
+		Batch  []Event   `json:"batch"`
+		SentAt time.Time `json:"sentAt"`
+	}{Batch: events, SentAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.WriteKey != "" {
+		req.SetBasicAuth(s.WriteKey, "")
+	}
+
+	return doRequest(s.HTTPClient, req)
+}
+
+// ClickHouseSink inserts batches through the ClickHouse HTTP interface as
+// JSONEachRow. The table is expected to have the columns:
+//
+//	type String, event String, user_id String, anonymous_id String,
+//	properties String, context String, timestamp DateTime64(3), message_id String
+//
+// with properties and context holding JSON (traits are stored in properties
+// for identify events).
+type ClickHouseSink struct {
+	URL        string // e.g. http://clickhouse:8123
+	Table      string
+	User       string
+	Password   string
+	HTTPClient *http.Client
+}
+
+type clickHouseRow struct {
+	Type        string `json:"type"`
+	Event       string `json:"event"`
+	UserID      string `json:"user_id"`
+	AnonymousID string `json:"anonymous_id"`
+	Properties  string `json:"properties"`
+	Context     string `json:"context"`
+	Timestamp   string `json:"timestamp"`
+	MessageID   string `json:"message_id"`
+}
+
+// Send implements Sink
+func (s *ClickHouseSink) Send(ctx context.Context, events []Event) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, e := range events {
+		props := e.Properties
+		if e.Type == TypeIdentify {
+			props = e.Traits
+		}
+		propsJSON, err := marshalObject(props)
+		if err != nil {
+			return err
+		}
+		contextJSON, err := marshalObject(e.Context)
+		if err != nil {
+			return err
+		}
+
+		if err := enc.Encode(clickHouseRow{
+			Type:        e.Type,
+			Event:       e.Event,
+			UserID:      e.UserID,
+			AnonymousID: e.AnonymousID,
+			Properties:  propsJSON,
+			Context:     contextJSON,
+			Timestamp:   e.Timestamp.UTC().Format("2006-01-02 15:04:05.000"),
+			MessageID:   e.MessageID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	query := url.Values{"query": {fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", s.Table)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+"/?"+query.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	if s.User != "" {
+		req.Header.Set("X-ClickHouse-User", s.User)
+		req.Header.Set("X-ClickHouse-Key", s.Password)
+	}
+
+	return doRequest(s.HTTPClient, req)
+}
+
+func marshalObject(v map[string]interface{}) (string, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func doRequest(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("analytics sink returned status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
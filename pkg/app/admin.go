@@ -0,0 +1,212 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminServer is an internal listener for operational endpoints, kept apart
+// from public traffic. It has its own router and middleware chain; the app
+// middleware does not apply.
+type AdminServer struct {
+	app        *App
+	name       string
+	addr       string
+	router     *mux.Router
+	middleware []MiddlewareFunc
+	debug      bool // Serves pprof and expvar
+
+	mu       sync.Mutex
+	server   *http.Server
+	listener net.Listener
+}
+
+// Admin attaches an internal listener on addr serving /metrics, /healthz,
+// /debug/info, /debug/routes, /debug/middleware, /debug/pprof and
+// /debug/vars. It starts after the start hooks and shuts down with the app.
+// Bind it to a private interface or keep the port out of public ingress:
+//
+//	admin := a.Admin(":9090")
+//	admin.Handle("/flags", flagsHandler)
+func (a *App) Admin(addr string, middleware ...MiddlewareFunc) *AdminServer {
+	s := a.attach("admin", addr, middleware)
+	s.debug = true
+	s.router.Handle("/metrics", a.MetricsHandler()).Methods(http.MethodGet)
+	s.router.Handle("/healthz", a.HealthHandler()).Methods(http.MethodGet, http.MethodHead)
+	s.router.Handle("/debug/info", a.InfoHandler()).Methods(http.MethodGet)
+	s.router.Handle("/debug/routes", a.RoutesHandler()).Methods(http.MethodGet)
+	s.router.Handle("/debug/middleware", a.MiddlewareHandler()).Methods(http.MethodGet)
+
+	mountDebug(s.router, a.config.PprofToken)
+	return s
+}
+
+// MetricsServer attaches an internal listener on addr serving /metrics
+// alone, for scrapers kept apart from the admin endpoints
+func (a *App) MetricsServer(addr string, middleware ...MiddlewareFunc) *AdminServer {
+	s := a.attach("metrics", addr, middleware)
+	s.router.Handle("/metrics", a.MetricsHandler()).Methods(http.MethodGet)
+	return s
+}
+
+// AdminListener returns the admin listener of Config.AdminPort, or nil
+func (a *App) AdminListener() *AdminServer {
+	return a.adminPort
+}
+
+// MetricsListener returns the metrics listener of Config.MetricsPort, or nil
+func (a *App) MetricsListener() *AdminServer {
+	return a.metricsPort
+}
+
+func (a *App) attach(name, addr string, middleware []MiddlewareFunc) *AdminServer {
+	s := &AdminServer{
+		app:        a,
+		name:       name,
+		addr:       addr,
+		router:     mux.NewRouter(),
+		middleware: middleware,
+	}
+
+	a.hooksMu.Lock()
+	a.admin = append(a.admin, s)
+	a.hooksMu.Unlock()
+	return s
+}
+
+// Use adds middleware to the admin listener
+func (s *AdminServer) Use(middleware ...MiddlewareFunc) {
+	s.middleware = append(s.middleware, middleware...)
+}
+
+// Handle registers an additional admin endpoint
+func (s *AdminServer) Handle(path string, handler http.Handler) *mux.Route {
+	return s.router.Handle(path, handler)
+}
+
+// Router returns the admin router
+func (s *AdminServer) Router() *mux.Router {
+	return s.router
+}
+
+// Addr returns the address the admin listener is bound to, which resolves a
+// ":0" port once started
+func (s *AdminServer) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.addr
+}
+
+func (s *AdminServer) handler() http.Handler {
+	handler := http.Handler(s.router)
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		handler = s.middleware[i](handler)
+	}
+
+	next := handler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(withApp(r.Context(), s.app)))
+	})
+}
+
+// start binds the listener and serves in the background; bind errors are
+// returned, later serve errors are passed to onError
+func (s *AdminServer) start(onError func(error)) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:     s.handler(),
+		ReadTimeout: s.app.config.ReadTimeout,
+		// No WriteTimeout: CPU profiles and traces stream for their duration
+	}
+
+	s.mu.Lock()
+	s.listener, s.server = listener, server
+	s.mu.Unlock()
+
+	logrus.Infof("Starting %s server on %s", s.name, listener.Addr())
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			onError(err)
+		}
+	}()
+	return nil
+}
+
+func (s *AdminServer) shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	server := s.server
+	s.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+// startAdmin starts every admin listener, stopping those already started if
+// one fails to bind
+func (a *App) startAdmin(onError func(error)) error {
+	a.hooksMu.Lock()
+	servers := append([]*AdminServer(nil), a.admin...)
+	a.hooksMu.Unlock()
+
+	for i, s := range servers {
+		if err := s.start(onError); err != nil {
+			for _, started := range servers[:i] {
+				_ = started.shutdown(context.Background())
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *App) shutdownAdmin(ctx context.Context) {
+	a.hooksMu.Lock()
+	servers := append([]*AdminServer(nil), a.admin...)
+	a.hooksMu.Unlock()
+
+	for _, s := range servers {
+		if err := s.shutdown(ctx); err != nil {
+			logrus.Errorf("%s server shutdown error: %v", s.name, err)
+		}
+	}
+}
+
+// HealthHandler reports the dependencies registered with AddDependency: 200
+// when all are healthy, 503 otherwise or while a shutdown drains requests
+func (a *App) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statuses := a.checkDependencies(r.Context())
+
+		status, code := "ok", http.StatusOK
+		for _, s := range statuses {
+			if !s.Healthy {
+				status, code = "unavailable", http.StatusServiceUnavailable
+				break
+			}
+		}
+		if a.Draining() {
+			status, code = "draining", http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(struct {
+			Status       string             `json:"status"`
+			Dependencies []DependencyStatus `json:"dependencies"`
+		}{status, statuses})
+	})
+}
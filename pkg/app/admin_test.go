@@ -0,0 +1,227 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApp_Admin(t *testing.T) {
+	a := New(nil)
+	a.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Public", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	a.Group("").GET("/ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	})
+	a.AddDependency("cache", func(ctx context.Context) error { return nil })
+	a.AddDependency("database", func(ctx context.Context) error { return errors.New("down") })
+
+	admin := a.Admin("127.0.0.1:0", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Admin", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	admin.Handle("/flags", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("flags"))
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ln) }()
+
+	// Serve starts the admin listener after the start hooks
+	deadline := time.Now().Add(2 * time.Second)
+	for strings.HasSuffix(admin.Addr(), ":0") {
+		if time.Now().After(deadline) {
+			t.Fatal("admin server did not start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	adminURL := "http://" + admin.Addr()
+
+	get := func(url string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	resp := get(adminURL + "/healthz")
+	var health struct {
+		Status       string             `json:"status"`
+		Dependencies []DependencyStatus `json:"dependencies"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&health)
+	if resp.StatusCode != http.StatusServiceUnavailable || health.Status != "unavailable" || len(health.Dependencies) != 2 {
+		t.Errorf("unexpected health: %d %+v", resp.StatusCode, health)
+	}
+	if resp.Header.Get("X-Admin") != "1" || resp.Header.Get("X-Public") != "" {
+		t.Errorf("expected only the admin middleware, got %v", resp.Header)
+	}
+
+	for _, path := range []string{"/metrics", "/debug/info", "/debug/routes", "/debug/pprof/", "/flags"} {
+		if resp := get(adminURL + path); resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, resp.StatusCode)
+		}
+	}
+
+	if resp := get("http://" + ln.Addr().String() + "/debug/pprof/"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected admin endpoints to stay off the public listener, got %d", resp.StatusCode)
+	}
+	if body, _ := io.ReadAll(get("http://" + ln.Addr().String() + "/ping").Body); string(body) != "pong" {
+		t.Errorf("unexpected public response %q", body)
+	}
+
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	<-done
+
+	if _, err := http.Get(adminURL + "/healthz"); err == nil {
+		t.Error("expected the admin listener to be closed with the app")
+	}
+}
+
+func TestApp_AdminPorts(t *testing.T) {
+	a := New(&Config{AdminPort: "127.0.0.1:0", MetricsPort: "127.0.0.1:0", EnablePprof: true})
+	a.Group("").GET("/ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	})
+	a.MetricsListener().Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Scrape", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ln) }()
+	t.Cleanup(func() {
+		_ = a.Shutdown(context.Background())
+		<-done
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for strings.HasSuffix(a.AdminListener().Addr(), ":0") || strings.HasSuffix(a.MetricsListener().Addr(), ":0") {
+		if time.Now().After(deadline) {
+			t.Fatal("internal listeners did not start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	status := func(url string) (int, http.Header) {
+		t.Helper()
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode, resp.Header
+	}
+
+	tests := []struct {
+		addr, path string
+		want       int
+	}{
+		{a.AdminListener().Addr(), "/healthz", http.StatusOK},
+		{a.AdminListener().Addr(), "/debug/pprof/", http.StatusOK},
+		{a.MetricsListener().Addr(), "/metrics", http.StatusOK},
+		{a.MetricsListener().Addr(), "/healthz", http.StatusNotFound},
+		{a.MetricsListener().Addr(), "/debug/pprof/", http.StatusNotFound},
+		// EnablePprof stays off the public port with an admin port
+		{ln.Addr().String(), "/debug/pprof/", http.StatusNotFound},
+		{ln.Addr().String(), "/ping", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got, _ := status("http://" + tt.addr + tt.path); got != tt.want {
+			t.Errorf("%s%s: expected %d, got %d", tt.addr, tt.path, tt.want, got)
+		}
+	}
+
+	if _, header := status("http://" + a.MetricsListener().Addr() + "/metrics"); header.Get("X-Scrape") != "1" {
+		t.Error("expected the metrics listener middleware")
+	}
+	if _, header := status("http://" + a.AdminListener().Addr() + "/healthz"); header.Get("X-Scrape") != "" {
+		t.Error("expected the admin listener to have its own middleware")
+	}
+}
+
+func TestApp_Admin_BindError(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	a := New(nil)
+	a.Admin(taken.Addr().String())
+	stopped := false
+	a.OnShutdown(func(ctx context.Context) error { stopped = true; return nil })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	if err := a.Serve(ln); err == nil {
+		t.Fatal("expected the admin bind error")
+	}
+	if !stopped {
+		t.Error("expected shutdown hooks to run after a failed start")
+	}
+}
+
+func TestApp_Admin_StoppedWhenServerFails(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	a := New(&Config{Port: taken.Addr().String(), AdminPort: "127.0.0.1:0", MetricsPort: "127.0.0.1:0"})
+	stopped := false
+	a.OnShutdown(func(ctx context.Context) error { stopped = true; return nil })
+
+	done := make(chan error, 1)
+	go func() { done <- a.Start(context.Background()) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected the bind error of the main server")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after the main server failed")
+	}
+
+	if !stopped {
+		t.Error("expected shutdown hooks to run after a failed start")
+	}
+	for _, addr := range []string{a.AdminListener().Addr(), a.MetricsListener().Addr()} {
+		if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+			_ = conn.Close()
+			t.Errorf("expected %s to be stopped", addr)
+		}
+	}
+}
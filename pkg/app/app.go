@@ -6,11 +6,16 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/polymatx/goframe/pkg/container"
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/polymatx/goframe/pkg/render"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,6 +26,32 @@ type App struct {
 	middleware []MiddlewareFunc
 	config     *Config
 	container  *container.Container
+	routes     map[*mux.Route]*Route
+
+	errorHandler    ErrorHandlerFunc
+	challengeServer *http.Server
+	admin           []*AdminServer
+	adminPort       *AdminServer
+	metricsPort     *AdminServer
+
+	hooksMu       sync.Mutex
+	startHooks    []HookFunc
+	shutdownHooks []HookFunc
+	drainHooks    []HookFunc
+	panicHooks    []PanicHookFunc
+	dependencies  []dependency
+	startedAt     time.Time
+
+	inFlight  atomic.Int64
+	draining  atomic.Bool
+	rejected  atomic.Int64
+	interrupt context.Context // Canceled at the end of the drain grace period
+	stopAll   context.CancelFunc
+	dynamic   dynamicRoutes
+
+	preflights      map[preflightKey]*preflight // OPTIONS routes of groups with CORS
+	preflightRoutes map[*mux.Route]bool
+	policiesApplied bool
 }
 
 // Config holds application configuration
@@ -30,6 +61,62 @@ type Config struct {
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
+
+	// DrainDelay keeps the listeners open for this long once shutdown
+	// begins, answering new requests with 503 and Retry-After while load
+	// balancers see the health check fail and stop routing to the instance
+	DrainDelay time.Duration
+	// DrainGrace is how long in-flight requests may finish before the
+	// contexts of those left, such as SSE streams, are canceled and the
+	// OnDrain hooks close WebSockets (default half of the shutdown deadline)
+	DrainGrace time.Duration
+
+	// DependencyWait, when set, makes the server wait up to this long for the
+	// dependencies registered with AddDependency before running start hooks
+	DependencyWait time.Duration
+
+	// EnablePprof serves net/http/pprof and expvar under /debug/ on the public
+	// listener when no Admin listener is attached; admin listeners always
+	// serve them. PprofToken, when set, guards them on both.
+	EnablePprof bool
+	PprofToken  string
+
+	// AdminPort serves health, metrics, pprof and the admin endpoints on an
+	// internal address, e.g. "127.0.0.1:9090", with middleware of its own
+	// (see Admin and AdminListener). MetricsPort serves /metrics alone, for
+	// scrapers (see MetricsServer and MetricsListener).
+	AdminPort   string
+	MetricsPort string
+
+	// Network is "tcp" (default, listening on Port), "unix" (listening on
+	// Socket), or "systemd" (using the socket-activated listener)
+	Network string
+	Socket  string
+
+	// TLS with static certificates
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// Automatic HTTPS via Let's Encrypt, used when no certificate files are set
+	AutoTLSHosts    []string // Hosts allowed to obtain certificates
+	AutoTLSCacheDir string   // Certificate cache directory (default "./certs")
+	AutoTLSEmail    string   // ACME account contact email
+	AutoTLSHTTPAddr string   // Optional address (e.g. ":80") for HTTP-01 challenges and HTTPS redirects
+
+	// JSON encodes Context.JSON responses and decodes Context.Bind bodies,
+	// e.g. jsoniter.New(render.JSONOptions{DisableHTMLEscape: true}). It also
+	// becomes the codec of render.JSON. Defaults to encoding/json.
+	JSON render.JSONCodec
+
+	// MetricsRegistry holds the metrics of RegisterMetrics, served on the
+	// admin /metrics (default the global Prometheus registry). Pass it to
+	// middleware.MetricsWith for the HTTP metrics.
+	MetricsRegistry *prometheus.Registry
+
+	// Policies set route metadata from the config file when the server
+	// starts, e.g. app.LoadPolicies("route_policies"); invalid rules fail
+	// startup
+	Policies *RoutePolicies
 }
 
 // MiddlewareFunc is a middleware function type
@@ -56,12 +143,24 @@ func New(cfg *Config) *App {
 	if cfg.ShutdownTimeout == 0 {
 		cfg.ShutdownTimeout = 10 * time.Second
 	}
+	if cfg.JSON != nil {
+		render.SetJSONCodec(cfg.JSON)
+	}
 
 	app := &App{
 		router:     mux.NewRouter(),
 		middleware: make([]MiddlewareFunc, 0),
 		config:     cfg,
 		container:  container.New(),
+		routes:     make(map[*mux.Route]*Route),
+	}
+	app.interrupt, app.stopAll = context.WithCancel(context.Background())
+
+	if cfg.AdminPort != "" {
+		app.adminPort = app.Admin(cfg.AdminPort)
+	}
+	if cfg.MetricsPort != "" {
+		app.metricsPort = app.MetricsServer(cfg.MetricsPort)
 	}
 
 	// Bind app to container
@@ -91,50 +190,90 @@ func (a *App) Group(prefix string, middleware ...MiddlewareFunc) *RouteGroup {
 		router:     a.router.PathPrefix(prefix).Subrouter(),
 		middleware: middleware,
 		container:  a.container,
+		app:        a,
 	}
 }
 
+// Host creates a route group matching the request host. The host may
+// contain variables, read with Context.Param like path variables:
+//
+//	tenant := a.Host("{tenant}.example.com")
+//	tenant.GET("/dashboard", dashboard) // ctx.Param("tenant")
+//
+// Hosts without a port match requests on any port.
+func (a *App) Host(host string, middleware ...MiddlewareFunc) *RouteGroup {
+	return a.rootGroup().Host(host, middleware...)
+}
+
 // Start starts the HTTP server
 func (a *App) Start(ctx context.Context) error {
-	handler := a.buildHandler()
+	return a.run(ctx, false)
+}
 
-	a.server = &http.Server{
-		Addr:         a.config.Port,
-		Handler:      handler,
-		ReadTimeout:  a.config.ReadTimeout,
-		WriteTimeout: a.config.WriteTimeout,
+// StartTLS starts the HTTPS server using TLSCertFile/TLSKeyFile or AutoTLSHosts
+func (a *App) StartTLS(ctx context.Context) error {
+	if !a.config.TLSEnabled() {
+		return ErrTLSNotConfigured
+	}
+	return a.run(ctx, true)
+}
+
+func (a *App) run(ctx context.Context, useTLS bool) error {
+	if err := a.runStartHooks(ctx); err != nil {
+		return err
 	}
+	a.logStartupReport(ctx, "")
+	a.server = a.newServer()
 
+	// The first failure of the main or an admin server stops them all;
+	// later ones are dropped instead of blocking their goroutines
 	errCh := make(chan error, 1)
+	fail := func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	}
+	if err := a.startAdmin(fail); err != nil {
+		_ = a.runShutdownHooks(context.Background())
+		return err
+	}
 	go func() {
-		logrus.Infof("Starting %s on %s", a.config.Name, a.config.Port)
-		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errCh <- err
+		logrus.Infof("Starting %s on %s", a.config.Name, a.address())
+		if err := a.listenAndServe(useTLS); err != nil && err != http.ErrServerClosed {
+			fail(err)
 		}
 	}()
 
 	select {
 	case err := <-errCh:
+		_ = a.server.Close()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), a.config.ShutdownTimeout)
+		defer cancel()
+		a.shutdownAdmin(shutdownCtx)
+		_ = a.runShutdownHooks(shutdownCtx)
 		return err
 	case <-ctx.Done():
 		return a.Shutdown(context.Background())
 	}
 }
 
-// StartWithGracefulShutdown starts the server and handles graceful shutdown
+// StartWithGracefulShutdown starts the server and handles graceful shutdown.
+// It serves HTTPS when TLS is configured.
 func (a *App) StartWithGracefulShutdown() error {
-	handler := a.buildHandler()
-
-	a.server = &http.Server{
-		Addr:         a.config.Port,
-		Handler:      handler,
-		ReadTimeout:  a.config.ReadTimeout,
-		WriteTimeout: a.config.WriteTimeout,
+	if err := a.runStartHooks(context.Background()); err != nil {
+		return err
 	}
+	a.logStartupReport(context.Background(), "")
+	a.server = a.newServer()
 
+	if err := a.startAdmin(func(err error) { logrus.Fatalf("Admin server error: %v", err) }); err != nil {
+		_ = a.runShutdownHooks(context.Background())
+		return err
+	}
 	go func() {
-		logrus.Infof("Starting %s on %s", a.config.Name, a.config.Port)
-		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logrus.Infof("Starting %s on %s", a.config.Name, a.address())
+		if err := a.listenAndServe(a.config.TLSEnabled()); err != nil && err != http.ErrServerClosed {
 			logrus.Fatalf("Server error: %v", err)
 		}
 	}()
@@ -148,8 +287,12 @@ func (a *App) StartWithGracefulShutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), a.config.ShutdownTimeout)
 	defer cancel()
 
-	if err := a.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("server forced to shutdown: %w", err)
+	if report, err := a.Drain(ctx); err != nil {
+		_ = a.runShutdownHooks(ctx)
+		return fmt.Errorf("server forced to shutdown, %d requests aborted: %w", report.Aborted, err)
+	}
+	if err := a.runShutdownHooks(ctx); err != nil {
+		return fmt.Errorf("shutdown hooks failed: %w", err)
 	}
 
 	logrus.Info("Server exited")
@@ -163,30 +306,70 @@ func (a *App) Shutdown(ctx context.Context) error {
 	}
 
 	logrus.Info("Shutting down server...")
-	if err := a.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("server shutdown error: %w", err)
+	if report, err := a.Drain(ctx); err != nil {
+		_ = a.runShutdownHooks(ctx)
+		return fmt.Errorf("server shutdown error, %d requests aborted: %w", report.Aborted, err)
+	}
+	if err := a.runShutdownHooks(ctx); err != nil {
+		return fmt.Errorf("shutdown hooks failed: %w", err)
 	}
 
 	logrus.Info("Server stopped")
 	return nil
 }
 
+func (a *App) newServer() *http.Server {
+	return &http.Server{
+		Addr:         a.config.Port,
+		Handler:      a.buildHandler(),
+		ReadTimeout:  a.config.ReadTimeout,
+		WriteTimeout: a.config.WriteTimeout,
+	}
+}
+
+func (a *App) shutdownServers(ctx context.Context) error {
+	if a.challengeServer != nil {
+		_ = a.challengeServer.Shutdown(ctx)
+	}
+	a.shutdownAdmin(ctx)
+	return a.server.Shutdown(ctx)
+}
+
 // buildHandler builds the final handler with all middleware
 func (a *App) buildHandler() http.Handler {
-	handler := http.Handler(a.router)
+	handler := a.withPprof(a.withDynamic(a.router))
 
 	for i := len(a.middleware) - 1; i >= 0; i-- {
 		handler = a.middleware[i](handler)
 	}
 
-	return handler
+	next := handler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.draining.Load() {
+			a.rejectDraining(w)
+			return
+		}
+		a.inFlight.Add(1)
+		defer a.inFlight.Add(-1)
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		stop := context.AfterFunc(a.interrupt, cancel)
+		defer stop()
+
+		ctx = middleware.WithPanicHandler(withApp(ctx, a), a.reportPanic)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
 
 // RouteGroup represents a group of routes with shared middleware
 type RouteGroup struct {
 	router     *mux.Router
 	middleware []MiddlewareFunc
+	meta       middleware.RouteMeta // Copied to each route
+	cors       MiddlewareFunc       // Set by CORS
 	container  *container.Container
+	app        *App
 }
 
 // Use adds middleware to the group
@@ -200,45 +383,103 @@ func (g *RouteGroup) Group(prefix string, middleware ...MiddlewareFunc) *RouteGr
 	return &RouteGroup{
 		router:     g.router.PathPrefix(prefix).Subrouter(),
 		middleware: allMiddleware,
+		meta:       g.meta.Clone(),
+		cors:       g.cors,
 		container:  g.container,
+		app:        g.app,
+	}
+}
+
+// Host creates a sub-group matching the request host, e.g. an admin
+// subdomain under the group prefix
+func (g *RouteGroup) Host(host string, middleware ...MiddlewareFunc) *RouteGroup {
+	allMiddleware := append(append([]MiddlewareFunc(nil), g.middleware...), middleware...)
+	return &RouteGroup{
+		router:     g.router.Host(host).Subrouter(),
+		middleware: allMiddleware,
+		meta:       g.meta.Clone(),
+		cors:       g.cors,
+		container:  g.container,
+		app:        g.app,
+	}
+}
+
+// WithTimeout returns a group on the same prefix whose handlers are canceled
+// after d, responding 503 if they have not started writing. It is
+// independent of the server Read/WriteTimeout and can be applied to a
+// single route: api.WithTimeout(time.Second).GET("/search", search)
+func (g *RouteGroup) WithTimeout(d time.Duration) *RouteGroup {
+	allMiddleware := append(append([]MiddlewareFunc(nil), g.middleware...), middleware.Timeout(d))
+	return &RouteGroup{
+		router:     g.router,
+		middleware: allMiddleware,
+		meta:       g.meta.Clone(),
+		cors:       g.cors,
+		container:  g.container,
+		app:        g.app,
 	}
 }
 
 // GET registers a GET route
-func (g *RouteGroup) GET(path string, handler http.HandlerFunc) {
-	g.handle("GET", path, handler)
+func (g *RouteGroup) GET(path string, handler http.HandlerFunc) *Route {
+	return g.handle("GET", path, handler)
 }
 
 // POST registers a POST route
-func (g *RouteGroup) POST(path string, handler http.HandlerFunc) {
-	g.handle("POST", path, handler)
+func (g *RouteGroup) POST(path string, handler http.HandlerFunc) *Route {
+	return g.handle("POST", path, handler)
 }
 
 // PUT registers a PUT route
-func (g *RouteGroup) PUT(path string, handler http.HandlerFunc) {
-	g.handle("PUT", path, handler)
+func (g *RouteGroup) PUT(path string, handler http.HandlerFunc) *Route {
+	return g.handle("PUT", path, handler)
 }
 
 // DELETE registers a DELETE route
-func (g *RouteGroup) DELETE(path string, handler http.HandlerFunc) {
-	g.handle("DELETE", path, handler)
+func (g *RouteGroup) DELETE(path string, handler http.HandlerFunc) *Route {
+	return g.handle("DELETE", path, handler)
 }
 
 // PATCH registers a PATCH route
-func (g *RouteGroup) PATCH(path string, handler http.HandlerFunc) {
-	g.handle("PATCH", path, handler)
+func (g *RouteGroup) PATCH(path string, handler http.HandlerFunc) *Route {
+	return g.handle("PATCH", path, handler)
 }
 
 // Handle registers a route with specific method
-func (g *RouteGroup) Handle(method, path string, handler http.HandlerFunc) {
-	g.handle(method, path, handler)
+func (g *RouteGroup) Handle(method, path string, handler http.HandlerFunc) *Route {
+	return g.handle(method, path, handler)
 }
 
-func (g *RouteGroup) handle(method, path string, handler http.HandlerFunc) {
-	var h http.Handler = handler
+func (g *RouteGroup) handle(method, path string, handler http.HandlerFunc) *Route {
+	h := recordRequest(handler)
 	for i := len(g.middleware) - 1; i >= 0; i-- {
 		h = g.middleware[i](h)
 	}
 
-	g.router.Handle(path, h).Methods(method)
+	// GET routes answer HEAD too; net/http drops the body of HEAD responses
+	methods := []string{method}
+	if method == http.MethodGet {
+		methods = append(methods, http.MethodHead)
+	}
+
+	chain := append([]MiddlewareFunc(nil), g.middleware...)
+	if g.cors != nil {
+		h = g.cors(h)
+		chain = append([]MiddlewareFunc{g.cors}, chain...)
+		g.addPreflight(path, methods)
+	}
+
+	h, meta := g.withMeta(h)
+	route := &Route{
+		middleware: chain,
+		meta:       meta,
+		autoHead:   method == http.MethodGet,
+		group:      g,
+		path:       path,
+		handler:    h,
+	}
+	route.route = g.router.Handle(path, http.HandlerFunc(route.serve)).Methods(methods...)
+	g.app.routes[route.route] = route
+
+	return route
 }
@@ -1,10 +1,33 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/binding"
+	"github.com/polymatx/goframe/pkg/i18n"
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/polymatx/goframe/pkg/money"
+	"github.com/polymatx/goframe/pkg/render"
+	"github.com/polymatx/goframe/pkg/session"
+	"github.com/polymatx/goframe/pkg/sse"
+	"github.com/polymatx/goframe/pkg/storage"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/viper"
 )
 
 func TestNewApp(t *testing.T) {
@@ -209,3 +232,1195 @@ func TestContext(t *testing.T) {
 		}
 	})
 }
+
+func TestApp_Routes(t *testing.T) {
+	app := New(nil)
+
+	appMiddleware := func(next http.Handler) http.Handler { return next }
+	groupMiddleware := func(next http.Handler) http.Handler { return next }
+	app.Use(appMiddleware)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	api := app.Group("/api")
+	api.GET("/users", handler).Name("users.list")
+	v1 := api.Group("/v1", groupMiddleware)
+	v1.POST("/users/{id}", handler)
+	app.Router().HandleFunc("/raw", handler).Methods("GET", "HEAD")
+
+	routes := app.Routes()
+	if len(routes) != 4 {
+		t.Fatalf("expected 4 routes, got %d: %+v", len(routes), routes)
+	}
+
+	if routes[0].Method != "GET" || routes[0].Path != "/api/users" || routes[0].Name != "users.list" {
+		t.Errorf("unexpected first route: %+v", routes[0])
+	}
+	if len(routes[0].Middleware) != 1 {
+		t.Errorf("expected 1 middleware on first route, got %v", routes[0].Middleware)
+	}
+
+	if routes[1].Method != "POST" || routes[1].Path != "/api/v1/users/{id}" {
+		t.Errorf("unexpected second route: %+v", routes[1])
+	}
+	if len(routes[1].Middleware) != 2 {
+		t.Errorf("expected 2 middleware on grouped route, got %v", routes[1].Middleware)
+	}
+
+	if routes[2].Path != "/raw" || routes[3].Method != "HEAD" {
+		t.Errorf("expected raw router routes to be listed per method, got %+v", routes[2:])
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	if got := funcName(New); got != "app.New" {
+		t.Errorf("expected 'app.New', got '%s'", got)
+	}
+
+	closure := func(next http.Handler) http.Handler { return next }
+	if got := funcName(closure); got != "app.TestFuncName" {
+		t.Errorf("expected closure to resolve to 'app.TestFuncName', got '%s'", got)
+	}
+}
+
+func TestContext_Format(t *testing.T) {
+	saved := i18n.Default
+	t.Cleanup(func() { i18n.Default = saved })
+	i18n.Default = i18n.NewCatalog("en")
+	i18n.Default.Add("en", i18n.English)
+	i18n.Default.Add("de", map[string]string{i18n.CodeNotFound: "Nicht gefunden"})
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "order.html"), []byte(`{{t "not_found"}}|{{formatDate .Date}}|{{formatMoney .Total}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := render.NewTemplateRendererFuncs(filepath.Join(dir, "*.html"), i18n.TemplateFuncs("en"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no timezone database:", err)
+	}
+	date := time.Date(2026, 3, 14, 23, 30, 0, 0, time.UTC)
+	total := money.New(123450, "EUR")
+
+	tests := []struct {
+		language string
+		date     string
+		number   string
+		money    string
+		html     string
+	}{
+		{"de-DE,de;q=0.9", "15.03.2026", "1.234,5", "1.234,50\u00a0€", "Nicht gefunden|15.03.2026|1.234,50\u00a0€"},
+		{"en-US", "3/15/2026", "1,234.5", "€1,234.50", "Not Found|3/15/2026|€1,234.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", tt.language)
+			req = req.WithContext(middleware.WithLocation(req.Context(), berlin))
+			rec := httptest.NewRecorder()
+			c := NewContext(rec, req)
+
+			if got := c.FormatDate(date); got != tt.date {
+				t.Errorf("FormatDate = %q, want %q", got, tt.date)
+			}
+			if got := c.FormatNumber(1234.5, 1); got != tt.number {
+				t.Errorf("FormatNumber = %q, want %q", got, tt.number)
+			}
+			if got := c.FormatMoney(total); got != tt.money {
+				t.Errorf("FormatMoney = %q, want %q", got, tt.money)
+			}
+			if err := c.HTML(http.StatusOK, tr, "order.html", map[string]interface{}{"Date": date, "Total": total}); err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.Body.String(); got != tt.html {
+				t.Errorf("HTML = %q, want %q", got, tt.html)
+			}
+		})
+	}
+}
+
+func TestContext_Error(t *testing.T) {
+	t.Run("HTTPError uses its code and message", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/test", nil)
+		w := httptest.NewRecorder()
+
+		NewContext(w, req).Error(NewHTTPError(http.StatusNotFound, "user not found"))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+		if body := w.Body.String(); body != "{\"error\":\"user not found\"}\n" {
+			t.Errorf("unexpected body: %s", body)
+		}
+	})
+
+	t.Run("plain error hides internals", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/test", nil)
+		w := httptest.NewRecorder()
+
+		NewContext(w, req).Error(errors.New("db password leaked"))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "leaked") {
+			t.Errorf("internal error exposed: %s", w.Body.String())
+		}
+	})
+
+	t.Run("develop mode includes internal error", func(t *testing.T) {
+		viper.Set("develop_mode", true)
+		defer viper.Set("develop_mode", false)
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		w := httptest.NewRecorder()
+
+		NewContext(w, req).Error(NewHTTPError(http.StatusBadGateway).WithInternal(errors.New("upstream down")))
+
+		if w.Code != http.StatusBadGateway {
+			t.Errorf("expected status 502, got %d", w.Code)
+		}
+		var body struct{ Internal, Stack string }
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if body.Internal != "upstream down" {
+			t.Errorf("expected internal error in body: %s", w.Body.String())
+		}
+		// The stack is where the error was created, not the error handler
+		if !strings.Contains(body.Stack, "TestContext_Error") || strings.Contains(body.Stack, "DefaultErrorHandler") ||
+			strings.Contains(body.Stack, "developStack") {
+			t.Errorf("expected the stack of the caller: %s", body.Stack)
+		}
+	})
+
+	t.Run("develop mode has no stack for plain errors", func(t *testing.T) {
+		viper.Set("develop_mode", true)
+		defer viper.Set("develop_mode", false)
+
+		w := httptest.NewRecorder()
+		NewContext(w, httptest.NewRequest("GET", "/test", nil)).Error(errors.New("boom"))
+
+		if strings.Contains(w.Body.String(), "stack") || !strings.Contains(w.Body.String(), "boom") {
+			t.Errorf("unexpected body: %s", w.Body.String())
+		}
+	})
+
+	t.Run("custom handler set on app", func(t *testing.T) {
+		a := New(nil)
+		var handled error
+		a.SetErrorHandler(func(c *Context, err error) {
+			handled = err
+			c.Status(http.StatusTeapot)
+		})
+
+		want := errors.New("boom")
+		a.Group("/api").GET("/fail", Wrap(func(c *Context) error {
+			return want
+		}))
+
+		w := httptest.NewRecorder()
+		a.buildHandler().ServeHTTP(w, httptest.NewRequest("GET", "/api/fail", nil))
+
+		if w.Code != http.StatusTeapot {
+			t.Errorf("expected status 418, got %d", w.Code)
+		}
+		if handled != want {
+			t.Errorf("expected handler to receive %v, got %v", want, handled)
+		}
+	})
+}
+
+func TestConfig_TLSEnabled(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{"no tls", Config{}, false},
+		{"cert only", Config{TLSCertFile: "cert.pem"}, false},
+		{"cert and key", Config{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}, true},
+		{"autocert hosts", Config{AutoTLSHosts: []string{"example.com"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.TLSEnabled(); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApp_StartTLSNotConfigured(t *testing.T) {
+	app := New(nil)
+	if err := app.StartTLS(context.Background()); !errors.Is(err, ErrTLSNotConfigured) {
+		t.Errorf("expected ErrTLSNotConfigured, got %v", err)
+	}
+}
+
+func TestApp_AutocertHostWhitelist(t *testing.T) {
+	app := New(&Config{AutoTLSHosts: []string{"example.com"}})
+	manager := app.autocertManager()
+
+	if err := manager.HostPolicy(context.Background(), "example.com"); err != nil {
+		t.Errorf("expected whitelisted host to be allowed, got %v", err)
+	}
+	if err := manager.HostPolicy(context.Background(), "evil.com"); err == nil {
+		t.Error("expected non-whitelisted host to be rejected")
+	}
+}
+
+func TestApp_Serve(t *testing.T) {
+	app := New(nil)
+	app.Group("").GET("/ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- app.Serve(ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Errorf("expected 'pong', got '%s'", body)
+	}
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("expected Serve to return nil after shutdown, got %v", err)
+	}
+}
+
+func TestApp_StartUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	app := New(&Config{Network: NetworkUnix, Socket: socket})
+	app.Group("").GET("/ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("http://unix/ping"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Errorf("expected 'pong', got '%s'", body)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error from Start: %v", err)
+	}
+}
+
+func TestApp_ListenUnixReplacesOnlySockets(t *testing.T) {
+	dir := t.TempDir()
+
+	// A socket left behind by a previous run is replaced
+	stale := filepath.Join(dir, "stale.sock")
+	l, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	app := New(&Config{Network: NetworkUnix, Socket: stale})
+	l, err = app.listen()
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced, got %v", err)
+	}
+	l.Close()
+
+	// Anything else at the path is left alone
+	file := filepath.Join(dir, "data.db")
+	if err := os.WriteFile(file, []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	app = New(&Config{Network: NetworkUnix, Socket: file})
+	if _, err := app.listen(); err == nil {
+		t.Fatal("expected error for a path that is not a socket")
+	}
+	if data, err := os.ReadFile(file); err != nil || string(data) != "keep" {
+		t.Errorf("expected file to be kept, got %q, %v", data, err)
+	}
+}
+
+func TestApp_ListenUnsupportedNetwork(t *testing.T) {
+	app := New(&Config{Network: "udp"})
+	if _, err := app.listen(); err == nil {
+		t.Error("expected error for unsupported network")
+	}
+
+	app = New(&Config{Network: NetworkUnix})
+	if _, err := app.listen(); err == nil {
+		t.Error("expected error for unix network without socket path")
+	}
+}
+
+func TestApp_LifecycleHooks(t *testing.T) {
+	app := New(nil)
+	app.Group("").GET("/ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	})
+
+	var calls []string
+	app.OnStart(func(ctx context.Context) error { calls = append(calls, "start1"); return nil })
+	app.OnStart(func(ctx context.Context) error { calls = append(calls, "start2"); return nil })
+	app.OnShutdown(func(ctx context.Context) error { calls = append(calls, "stop1"); return nil })
+	app.OnShutdown(func(ctx context.Context) error { calls = append(calls, "stop2"); return errors.New("close failed") })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- app.Serve(ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	err = app.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "close failed") {
+		t.Errorf("expected shutdown hook error, got %v", err)
+	}
+	<-done
+
+	want := "start1,start2,stop2,stop1"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("expected hooks %s, got %s", want, got)
+	}
+
+	// Shutdown hooks run only once
+	_ = app.Shutdown(context.Background())
+	if len(calls) != 4 {
+		t.Errorf("expected shutdown hooks to run once, got %v", calls)
+	}
+}
+
+func TestApp_StartHookError(t *testing.T) {
+	app := New(&Config{Port: "127.0.0.1:0"})
+	app.OnStart(func(ctx context.Context) error { return errors.New("db unreachable") })
+
+	err := app.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "db unreachable") {
+		t.Fatalf("expected start hook error, got %v", err)
+	}
+	if app.server != nil {
+		t.Error("expected server not to be created when a start hook fails")
+	}
+}
+
+func TestContext_Location(t *testing.T) {
+	var loc *time.Location
+	var local time.Time
+	handler := middleware.Timezone(middleware.TimezoneConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(w, r)
+		loc = ctx.Location()
+		local = ctx.LocalTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/?tz=Etc/GMT-3", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if loc == nil || loc.String() != "Etc/GMT-3" {
+		t.Fatalf("expected Etc/GMT-3, got %v", loc)
+	}
+	if local.Hour() != 15 {
+		t.Errorf("expected 15h local time, got %v", local)
+	}
+
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if ctx.Location() != time.UTC {
+		t.Errorf("expected UTC without middleware, got %v", ctx.Location())
+	}
+}
+
+func TestContext_RequestID(t *testing.T) {
+	var id string
+	handler := middleware.RequestID(middleware.RequestIDConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = NewContext(w, r).RequestID()
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if id == "" || rec.Header().Get("X-Request-ID") != id {
+		t.Errorf("expected the generated ID %q in the response, got %q", id, rec.Header().Get("X-Request-ID"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "from-proxy")
+	if got := NewContext(httptest.NewRecorder(), req).RequestID(); got != "from-proxy" {
+		t.Errorf("expected the header without middleware, got %q", got)
+	}
+}
+
+func TestRouteGroup_WithTimeout(t *testing.T) {
+	app := New(nil)
+	api := app.Group("/api")
+	api.WithTimeout(20*time.Millisecond).GET("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	api.GET("/fast", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("expected no deadline outside the timeout group")
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	handler := app.buildHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/slow", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for slow route, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/fast", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for fast route, got %d", rec.Code)
+	}
+
+	for _, route := range app.Routes() {
+		if route.Path == "/api/slow" && (len(route.Middleware) != 1 || route.Middleware[0] != "middleware.Timeout") {
+			t.Errorf("expected Timeout middleware on slow route, got %v", route.Middleware)
+		}
+	}
+}
+
+func TestRouteGroup_AccessLog(t *testing.T) {
+	hook := logrustest.NewGlobal()
+	defer hook.Reset()
+
+	app := New(nil)
+	app.Use(middleware.LoggerWith(middleware.LoggerConfig{Fields: []string{middleware.LogRoute, middleware.LogUserID}}))
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{UserID: "alice"})))
+		})
+	}
+	app.Group("/api", authenticate).GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	app.buildHandler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/42", nil))
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("expected an access log entry")
+	}
+	if entry.Data[middleware.LogRoute] != "/api/users/{id}" || entry.Data[middleware.LogUserID] != "alice" {
+		t.Errorf("expected route and user from inside the group, got %v", entry.Data)
+	}
+}
+
+func TestRouteGroup_With(t *testing.T) {
+	app := New(nil)
+	api := app.Group("/api", middleware.Scopes()).With(Scopes("orders:read"), Tags("orders"))
+
+	var got middleware.RouteMeta
+	handler := func(w http.ResponseWriter, r *http.Request) { got = middleware.GetRouteMeta(r.Context()) }
+	api.GET("/orders", handler)
+	api.With(Scopes("orders:write"), RateLimitClass("writes")).POST("/orders", handler).
+		With(Summary("Create an order"), Meta("audit", true))
+	h := app.buildHandler()
+
+	serve := func(method string, scope string) int {
+		r := httptest.NewRequest(method, "/api/orders", nil)
+		r = r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{Extra: map[string]interface{}{"scope": scope}}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	if code := serve(http.MethodPost, "orders:read"); code != http.StatusForbidden {
+		t.Errorf("POST without orders:write = %d, want 403", code)
+	}
+	if code := serve(http.MethodPost, "orders:read orders:write"); code != http.StatusOK {
+		t.Fatalf("POST with scopes = %d, want 200", code)
+	}
+	if got.RateLimit != "writes" || got.Summary != "Create an order" || got.Extra["audit"] != true ||
+		len(got.Scopes) != 2 || len(got.Tags) != 1 {
+		t.Errorf("POST metadata = %+v", got)
+	}
+	if code := serve(http.MethodGet, "orders:read"); code != http.StatusOK {
+		t.Errorf("GET with orders:read = %d, want 200", code)
+	}
+	if len(got.Scopes) != 1 || got.RateLimit != "" || got.Summary != "" {
+		t.Errorf("GET metadata = %+v, want only the group metadata", got)
+	}
+
+	for _, route := range app.Routes() {
+		if route.Method == http.MethodPost && (route.Meta == nil || route.Meta.Summary != "Create an order") {
+			t.Errorf("Routes meta = %+v, want the summary", route.Meta)
+		}
+	}
+}
+
+func TestApp_InfoHandler(t *testing.T) {
+	app := New(&Config{Name: "info-app", Port: ":9999"})
+	app.Use(middleware.Timezone(middleware.TimezoneConfig{}))
+	app.Group("/api").GET("/users", func(w http.ResponseWriter, r *http.Request) {})
+	app.AddDependency("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+	app.AddDependency("database", func(ctx context.Context) error { return nil })
+	app.Router().Handle("/debug/info", app.InfoHandler()).Methods("GET")
+
+	rec := httptest.NewRecorder()
+	app.buildHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/info", nil))
+
+	var info Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if info.Name != "info-app" || info.Address != ":9999" {
+		t.Errorf("unexpected name/address %q %q", info.Name, info.Address)
+	}
+	if info.Routes != 2 {
+		t.Errorf("expected 2 routes, got %d", info.Routes)
+	}
+	if len(info.Middleware) != 1 || info.Middleware[0] != "middleware.Timezone" {
+		t.Errorf("unexpected middleware %v", info.Middleware)
+	}
+	if info.Build.GoVersion == "" {
+		t.Error("expected Go version in build info")
+	}
+	if len(info.Dependencies) != 2 {
+		t.Fatalf("expected 2 dependencies, got %v", info.Dependencies)
+	}
+	if db := info.Dependencies[0]; db.Name != "database" || !db.Healthy {
+		t.Errorf("expected healthy database first, got %+v", db)
+	}
+	if redis := info.Dependencies[1]; redis.Healthy || redis.Error != "connection refused" {
+		t.Errorf("expected failing redis, got %+v", redis)
+	}
+}
+
+func TestContext_Cookies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	rec := httptest.NewRecorder()
+	ctx := NewContext(rec, req)
+
+	if v, err := ctx.Cookie("theme"); err != nil || v != "dark" {
+		t.Errorf("Cookie(theme) = %q, %v", v, err)
+	}
+	if _, err := ctx.Cookie("missing"); !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("expected ErrNoCookie, got %v", err)
+	}
+
+	ctx.SetCookie(&http.Cookie{Name: "lang", Value: "en"})
+	ctx.ClearCookie("theme")
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("expected 2 cookies, got %v", cookies)
+	}
+	if c := cookies[0]; c.Path != "/" || c.SameSite != http.SameSiteLaxMode {
+		t.Errorf("expected defaults on set cookie, got %+v", c)
+	}
+	if c := cookies[1]; c.Name != "theme" || c.MaxAge >= 0 {
+		t.Errorf("expected expired theme cookie, got %+v", c)
+	}
+}
+
+// flushCounter records how many times the response was flushed
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushCounter) Flush() {
+	f.flushes++
+	f.ResponseRecorder.Flush()
+}
+
+func TestContext_Stream(t *testing.T) {
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+	}{
+		{"direct", func(h http.Handler) http.Handler { return h }},
+		{"through logger", middleware.Logger()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+			handler := tt.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := NewContext(w, r)
+				i := 0
+				if err := ctx.Stream(func(w io.Writer) bool {
+					i++
+					_, _ = io.WriteString(w, strings.Repeat("x", i))
+					return i < 3
+				}); err != nil {
+					t.Errorf("Stream: %v", err)
+				}
+			}))
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if body := rec.Body.String(); body != "xxxxxx" {
+				t.Errorf("body = %q", body)
+			}
+			if rec.flushes != 3 {
+				t.Errorf("expected 3 flushes, got %d", rec.flushes)
+			}
+		})
+	}
+
+	t.Run("stops when client disconnects", func(t *testing.T) {
+		reqCtx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx)
+		ctx := NewContext(httptest.NewRecorder(), req)
+
+		calls := 0
+		err := ctx.Stream(func(w io.Writer) bool {
+			calls++
+			cancel()
+			return true
+		})
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Errorf("expected cancellation after 1 call, got %v after %d", err, calls)
+		}
+	})
+}
+
+func TestContext_SSE(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := NewContext(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	stream, err := ctx.SSE()
+	if err != nil {
+		t.Fatalf("SSE: %v", err)
+	}
+	if err := stream.Send(sse.Event{Event: "tick", Data: "1"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if body := rec.Body.String(); body != "event: tick\ndata: 1\n\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestApp_Host(t *testing.T) {
+	a := New(nil)
+
+	var order []string
+	tag := func(name string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	a.Host("admin.example.com", tag("admin")).GET("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("admin"))
+	})
+	tenants := a.Host("{tenant}.example.com", tag("tenant"))
+	tenants.Group("/api").GET("/whoami", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(NewContext(w, r).Param("tenant")))
+	})
+
+	tests := []struct {
+		name     string
+		host     string
+		path     string
+		wantCode int
+		wantBody string
+		wantMW   string
+	}{
+		{"static host", "admin.example.com", "/", http.StatusOK, "admin", "admin"},
+		{"host with port", "admin.example.com:8080", "/", http.StatusOK, "admin", "admin"},
+		{"tenant captured", "acme.example.com", "/api/whoami", http.StatusOK, "acme", "tenant"},
+		{"other domain", "acme.example.org", "/api/whoami", http.StatusNotFound, "", ""},
+	}
+	handler := a.buildHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order = nil
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantMW != "" && (len(order) != 1 || order[0] != tt.wantMW) {
+				t.Errorf("middleware = %v, want [%s]", order, tt.wantMW)
+			}
+		})
+	}
+}
+
+func TestApp_HeadAndMethodOverride(t *testing.T) {
+	a := New(nil)
+	a.Use(middleware.MethodOverride())
+	api := a.Group("")
+	api.GET("/items/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		_, _ = w.Write([]byte("item"))
+	})
+	api.DELETE("/items/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	srv := httptest.NewServer(a.buildHandler())
+	defer srv.Close()
+
+	resp, err := http.Head(srv.URL + "/items/1")
+	if err != nil {
+		t.Fatalf("HEAD: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Method") != http.MethodHead || resp.ContentLength != 4 {
+		t.Errorf("unexpected HEAD response: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	resp, err = http.PostForm(srv.URL+"/items/1", url.Values{"_method": {"DELETE"}})
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected the form to reach the DELETE route, got %d", resp.StatusCode)
+	}
+
+	for _, route := range a.Routes() {
+		if route.Method == http.MethodHead {
+			t.Errorf("implicit HEAD should not be listed: %+v", route)
+		}
+	}
+}
+
+func TestApp_OnPanic(t *testing.T) {
+	a := New(nil)
+	a.Use(middleware.Recovery())
+
+	var gotPath string
+	var gotRecovered interface{}
+	a.OnPanic(func(c *Context, recovered interface{}, stack []byte) {
+		gotPath, gotRecovered = c.Path(), recovered
+	})
+	a.Group("").GET("/boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	a.buildHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+	if gotPath != "/boom" || gotRecovered != "boom" {
+		t.Errorf("expected the hook to see /boom and boom, got %q and %v", gotPath, gotRecovered)
+	}
+}
+
+func TestContext_Redirects(t *testing.T) {
+	a := New(nil)
+	a.Group("").GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {}).Name("users.show")
+
+	if u, err := a.URL("users.show", "id", "42"); err != nil || u != "/users/42" {
+		t.Errorf("URL = %q, %v", u, err)
+	}
+	if _, err := a.URL("missing"); err == nil {
+		t.Error("expected an error for an unknown route")
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		referer  string
+		redirect func(c *Context) error
+		wantCode int
+		wantLoc  string
+	}{
+		{"back to same host", http.MethodPost, "http://example.com/form?step=2", func(c *Context) error { return c.RedirectBack("/") }, http.StatusSeeOther, "/form?step=2"},
+		{"back ignores other hosts", http.MethodGet, "https://evil.test/phish", func(c *Context) error { return c.RedirectBack("/home") }, http.StatusFound, "/home"},
+		{"back without referer", http.MethodGet, "", func(c *Context) error { return c.RedirectBack("") }, http.StatusFound, "/"},
+		{"to route", http.MethodPost, "", func(c *Context) error { return c.RedirectToRoute("users.show", "id", "7") }, http.StatusSeeOther, "/users/7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://example.com/submit", nil)
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			rec := httptest.NewRecorder()
+			c := NewContext(rec, req.WithContext(withApp(req.Context(), a)))
+
+			if err := tt.redirect(c); err != nil {
+				t.Fatalf("redirect: %v", err)
+			}
+			if rec.Code != tt.wantCode || rec.Header().Get("Location") != tt.wantLoc {
+				t.Errorf("got %d %q, expected %d %q", rec.Code, rec.Header().Get("Location"), tt.wantCode, tt.wantLoc)
+			}
+		})
+	}
+
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if err := c.RedirectToRoute("users.show", "id", "1"); err == nil {
+		t.Error("expected an error outside an App")
+	}
+	if err := c.Flash("notice", "saved"); !errors.Is(err, session.ErrNoSession) {
+		t.Errorf("expected ErrNoSession, got %v", err)
+	}
+}
+
+func TestContext_FlashAfterRedirect(t *testing.T) {
+	store, err := session.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New(nil)
+	a.Use(session.Middleware(store, session.Config{}))
+	g := a.Group("")
+	g.GET("/form", func(w http.ResponseWriter, r *http.Request) {
+		c := NewContext(w, r)
+		_ = c.String(http.StatusOK, "%v", c.Flashes("notice"))
+	}).Name("form")
+	g.POST("/form", func(w http.ResponseWriter, r *http.Request) {
+		c := NewContext(w, r)
+		_ = c.Flash("notice", "saved")
+		_ = c.RedirectToRoute("form")
+	})
+
+	srv := httptest.NewServer(a.buildHandler())
+	defer srv.Close()
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+
+	resp, err := client.Post(srv.URL+"/form", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "[saved]" {
+		t.Errorf("expected the flash after the redirect, got %q", body)
+	}
+
+	resp, err = client.Get(srv.URL + "/form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "[]" {
+		t.Errorf("expected the flash to be read once, got %q", body)
+	}
+}
+
+func TestContext_BindValidated(t *testing.T) {
+	type signup struct {
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"min=8"`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"valid", `{"email":"ada@example.com","password":"correct horse"}`, http.StatusOK, ""},
+		{"invalid fields", `{"email":"nope","password":"short"}`, http.StatusUnprocessableEntity,
+			`{"error":"Validation failed","errors":{"email":"must be a valid email","password":"must contain at least 8 characters"}}`},
+		{"malformed", `{`, http.StatusBadRequest, `{"error":"invalid JSON: unexpected EOF"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			c := NewContext(rec, req)
+
+			var v signup
+			if err := c.BindValidated(&v); err != nil {
+				c.Error(err)
+			}
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); tt.wantBody != "" && got != tt.wantBody {
+				t.Errorf("unexpected body %s", got)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	c := NewContext(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	_ = c.JSONError(http.StatusBadRequest, binding.FieldErrors{"name": "is required"})
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"errors":{"name":"is required"}}` {
+		t.Errorf("unexpected JSONError body %s", got)
+	}
+}
+
+func TestContext_JSONCodec(t *testing.T) {
+	codec, err := render.StdJSON(render.JSONOptions{DisableHTMLEscape: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := New(&Config{JSON: codec})
+	t.Cleanup(func() { render.SetJSONCodec(nil) })
+
+	app.Group("").POST("/echo", Wrap(func(c *Context) error {
+		var v map[string]string
+		if err := c.Bind(&v); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, v)
+	}))
+
+	rec := httptest.NewRecorder()
+	app.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"q":"a<b & c>d"}`)))
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"q":"a<b & c>d"}` {
+		t.Errorf("expected unescaped HTML, got %s", got)
+	}
+
+	rec = httptest.NewRecorder()
+	_ = render.JSON(rec, http.StatusOK, "<b>")
+	if got := strings.TrimSpace(rec.Body.String()); got != `"<b>"` {
+		t.Errorf("expected render.JSON to use the app codec, got %s", got)
+	}
+}
+
+// signingDir is a storage.Dir that also signs URLs, like S3
+type signingDir struct {
+	*storage.Dir
+}
+
+func (signingDir) SignedURL(ctx context.Context, key string, opts storage.SignOptions) (string, error) {
+	return "https://bucket.example.com/" + key + "?expires=" + opts.Expires.String() + "&filename=" + opts.Attachment, nil
+}
+
+func TestContext_FileFromStorage(t *testing.T) {
+	store := storage.NewDir(t.TempDir())
+	if err := store.Put(context.Background(), "media/intro.txt", strings.NewReader("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	obj, _ := store.Stat(context.Background(), "media/intro.txt")
+
+	tests := []struct {
+		name    string
+		store   storage.Store
+		key     string
+		opts    []FileOptions
+		header  []string
+		status  int
+		body    string
+		check   string // Response header name=value
+		wantErr int
+	}{
+		{"full", store, "media/intro.txt", nil, nil, 200, "0123456789", "Content-Type=text/plain; charset=utf-8", 0},
+		{"range", store, "media/intro.txt", nil, []string{"Range", "bytes=2-4"}, 206, "234", "Content-Range=bytes 2-4/10", 0},
+		{"resume with If-Range", store, "media/intro.txt", nil, []string{"Range", "bytes=8-", "If-Range", obj.ETag}, 206, "89", "ETag=" + obj.ETag, 0},
+		{"stale If-Range", store, "media/intro.txt", nil, []string{"Range", "bytes=8-", "If-Range", `"old"`}, 200, "0123456789", "", 0},
+		{"not modified", store, "media/intro.txt", nil, []string{"If-None-Match", obj.ETag}, 304, "", "", 0},
+		{"attachment", store, "media/intro.txt", []FileOptions{{Attachment: "intro notes.txt"}}, nil, 200, "0123456789",
+			`Content-Disposition=attachment; filename="intro notes.txt"`, 0},
+		{"missing", store, "media/outro.txt", nil, nil, 0, "", "", http.StatusNotFound},
+		{"signed redirect", signingDir{store}, "media/intro.txt", []FileOptions{{Redirect: time.Minute, Attachment: "a.txt"}}, nil, 302, "",
+			"Location=https://bucket.example.com/media/intro.txt?expires=1m0s&filename=a.txt", 0},
+		{"redirect unsupported", store, "media/intro.txt", []FileOptions{{Redirect: time.Minute}}, nil, 200, "0123456789", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/files", nil)
+			for i := 0; i+1 < len(tt.header); i += 2 {
+				req.Header.Set(tt.header[i], tt.header[i+1])
+			}
+			rec := httptest.NewRecorder()
+			err := NewContext(rec, req).FileFromStorage(tt.store, tt.key, tt.opts...)
+
+			if tt.wantErr != 0 {
+				var he *HTTPError
+				if !errors.As(err, &he) || he.Code != tt.wantErr || !errors.Is(err, storage.ErrNotFound) {
+					t.Fatalf("error = %v, want HTTPError %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != 302 && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+			if name, value, ok := strings.Cut(tt.check, "="); ok && rec.Header().Get(name) != value {
+				t.Errorf("%s = %q, want %q", name, rec.Header().Get(name), value)
+			}
+		})
+	}
+}
+
+func TestContext_Localized(t *testing.T) {
+	saved := i18n.Default
+	t.Cleanup(func() { i18n.Default = saved })
+	i18n.Default = i18n.NewCatalog("en")
+	i18n.Default.Add("en", i18n.English)
+	i18n.Default.Add("de", map[string]string{
+		i18n.CodeValidationFailed: "Validierung fehlgeschlagen",
+		i18n.CodeRateLimited:      "Zu viele Anfragen",
+		"validation.required":     "ist erforderlich",
+		"validation.min":          "muss mindestens {param} Zeichen haben",
+	})
+
+	type signup struct {
+		Email    string `json:"email" validate:"required"`
+		Password string `json:"password" validate:"min=8"`
+	}
+	tests := []struct {
+		name     string
+		language string
+		handle   func(c *Context)
+		wantBody string
+	}{
+		{"validation", "de-DE,de;q=0.9", func(c *Context) {
+			var v signup
+			c.Error(c.BindValidated(&v))
+		}, `{"error":"Validierung fehlgeschlagen","errors":{"email":"ist erforderlich","password":"muss mindestens 8 Zeichen haben"}}`},
+		{"status text", "de", func(c *Context) { c.Error(NewHTTPError(http.StatusTooManyRequests, "Rate limit exceeded")) },
+			`{"error":"Zu viele Anfragen"}`},
+		{"JSONError", "de", func(c *Context) { _ = c.JSONError(http.StatusTooManyRequests, errors.New("Rate limit exceeded")) },
+			`{"error":"Zu viele Anfragen"}`},
+		{"custom message", "de", func(c *Context) { c.Error(NewHTTPError(http.StatusConflict, "order is closed")) },
+			`{"error":"order is closed"}`},
+		{"English", "en-US", func(c *Context) { c.Error(NewHTTPError(http.StatusTooManyRequests, "Rate limit exceeded")) },
+			`{"error":"Rate limit exceeded"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"password":"short"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.language)
+			rec := httptest.NewRecorder()
+			tt.handle(NewContext(rec, req))
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestContext_JSONEncodeFailure(t *testing.T) {
+	hook := logrustest.NewGlobal()
+	defer hook.Reset()
+
+	app := New(nil)
+	app.Group("").GET("/broken", Wrap(func(c *Context) error {
+		return c.JSON(http.StatusOK, map[string]interface{}{"items": []string{"a"}, "done": make(chan bool)})
+	}))
+
+	rec := httptest.NewRecorder()
+	app.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("expected problem details, got %q", ct)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected a well-formed body, got %q: %v", rec.Body.String(), err)
+	}
+	if body["status"] != float64(http.StatusInternalServerError) || body["title"] != "Internal Server Error" {
+		t.Errorf("unexpected body %v", body)
+	}
+	if entry := hook.LastEntry(); entry == nil || entry.Level != logrus.ErrorLevel {
+		t.Errorf("expected the failure to be logged as an error, got %v", entry)
+	}
+}
+
+func TestContext_BindQuery(t *testing.T) {
+	type listParams struct {
+		Page   int    `form:"page" validate:"min=1"`
+		Status string `form:"status" validate:"omitempty,oneof=open closed"`
+	}
+
+	a := New(nil)
+	a.Group("/api").GET("/orders", Wrap(func(c *Context) error {
+		var params listParams
+		if err := c.BindQuery(&params); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, params)
+	})).With(QueryParams(listParams{}))
+
+	tests := []struct {
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"/api/orders?page=2&status=open", http.StatusOK, `{"Page":2,"Status":"open"}`},
+		{"/api/orders?page=abc", http.StatusBadRequest,
+			`{"error":"invalid query parameter \"page\": must be an integer","expected":"integer","parameter":"page"}`},
+		{"/api/orders?page=0", http.StatusUnprocessableEntity,
+			`{"error":"Validation failed","errors":{"page":"must be at least 1"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("unexpected body %s", got)
+			}
+		})
+	}
+
+	routes := a.Routes()
+	if len(routes) != 1 || routes[0].Meta == nil || len(routes[0].Meta.Parameters) != 2 {
+		t.Fatalf("expected the query parameters in the route listing, got %+v", routes)
+	}
+	if p := routes[0].Meta.Parameters[1]; p.Name != "status" || len(p.Schema.Enum) != 2 {
+		t.Errorf("unexpected parameter %+v", p)
+	}
+}
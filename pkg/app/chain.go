@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+// Named gives middleware a name, listed by Routes and Middleware and used to
+// insert, replace or remove it: a.Use(app.Named("cors", middleware.CORS(cfg))).
+// What the descriptor of m tells App.MiddlewareChains is kept.
+func Named(name string, m MiddlewareFunc) MiddlewareFunc {
+	d, _ := middleware.DescriptorOf(m)
+	d.Name = name
+	return middleware.Describe(d, m)
+}
+
+// middlewareName returns the described name of m, or its function name
+// such as "middleware.CORS"
+func middlewareName(m MiddlewareFunc) string {
+	if d, ok := middleware.DescriptorOf(m); ok && d.Name != "" {
+		return d.Name
+	}
+	return funcName(m)
+}
+
+// Middleware returns the names of the application middleware, outermost first
+func (a *App) Middleware() []string {
+	return middlewareNames(a.middleware)
+}
+
+// UseBefore inserts middleware before the one named name, so it runs
+// first. Application middleware changes apply when the server starts.
+func (a *App) UseBefore(name string, middleware ...MiddlewareFunc) error {
+	chain, err := insertMiddleware(a.middleware, name, 0, middleware)
+	if err == nil {
+		a.middleware = chain
+	}
+	return err
+}
+
+// UseAfter inserts middleware after the one named name
+func (a *App) UseAfter(name string, middleware ...MiddlewareFunc) error {
+	chain, err := insertMiddleware(a.middleware, name, 1, middleware)
+	if err == nil {
+		a.middleware = chain
+	}
+	return err
+}
+
+// ReplaceMiddleware swaps the middleware named name, e.g. to use another
+// logger than the one a module installed
+func (a *App) ReplaceMiddleware(name string, middleware MiddlewareFunc) error {
+	chain, err := replaceMiddleware(a.middleware, name, middleware)
+	if err == nil {
+		a.middleware = chain
+	}
+	return err
+}
+
+// RemoveMiddleware removes the middleware named name
+func (a *App) RemoveMiddleware(name string) error {
+	chain, err := replaceMiddleware(a.middleware, name, nil)
+	if err == nil {
+		a.middleware = chain
+	}
+	return err
+}
+
+// Middleware returns the names of the group middleware, including those
+// inherited from parent groups, outermost first. Application middleware
+// runs before them.
+func (g *RouteGroup) Middleware() []string {
+	return middlewareNames(g.middleware)
+}
+
+// UseBefore inserts middleware before the one named name. Group changes
+// apply to routes registered afterwards.
+func (g *RouteGroup) UseBefore(name string, middleware ...MiddlewareFunc) error {
+	chain, err := insertMiddleware(g.middleware, name, 0, middleware)
+	if err == nil {
+		g.middleware = chain
+	}
+	return err
+}
+
+// UseAfter inserts middleware after the one named name
+func (g *RouteGroup) UseAfter(name string, middleware ...MiddlewareFunc) error {
+	chain, err := insertMiddleware(g.middleware, name, 1, middleware)
+	if err == nil {
+		g.middleware = chain
+	}
+	return err
+}
+
+// ReplaceMiddleware swaps the middleware named name
+func (g *RouteGroup) ReplaceMiddleware(name string, middleware MiddlewareFunc) error {
+	chain, err := replaceMiddleware(g.middleware, name, middleware)
+	if err == nil {
+		g.middleware = chain
+	}
+	return err
+}
+
+// RemoveMiddleware removes the middleware named name
+func (g *RouteGroup) RemoveMiddleware(name string) error {
+	chain, err := replaceMiddleware(g.middleware, name, nil)
+	if err == nil {
+		g.middleware = chain
+	}
+	return err
+}
+
+func indexMiddleware(chain []MiddlewareFunc, name string) (int, error) {
+	for i, m := range chain {
+		if middlewareName(m) == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no middleware named %q in %v", name, middlewareNames(chain))
+}
+
+// insertMiddleware returns a copy of chain with middleware inserted at the
+// position of name plus offset; the copy keeps groups sharing a parent
+// chain independent
+func insertMiddleware(chain []MiddlewareFunc, name string, offset int, middleware []MiddlewareFunc) ([]MiddlewareFunc, error) {
+	i, err := indexMiddleware(chain, name)
+	if err != nil {
+		return nil, err
+	}
+	i += offset
+
+	out := make([]MiddlewareFunc, 0, len(chain)+len(middleware))
+	out = append(out, chain[:i]...)
+	out = append(out, middleware...)
+	return append(out, chain[i:]...), nil
+}
+
+// replaceMiddleware returns a copy of chain with name replaced by
+// middleware, or removed when middleware is nil
+func replaceMiddleware(chain []MiddlewareFunc, name string, middleware MiddlewareFunc) ([]MiddlewareFunc, error) {
+	i, err := indexMiddleware(chain, name)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]MiddlewareFunc, 0, len(chain))
+	out = append(out, chain[:i]...)
+	if middleware != nil {
+		out = append(out, middleware)
+	}
+	return append(out, chain[i+1:]...), nil
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// trace appends its tag to the X-Trace response header
+func trace(tag string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", tag)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestNamed(t *testing.T) {
+	m := Named("cors", trace("cors"))
+	if got := middlewareName(m); got != "cors" {
+		t.Errorf("expected cors, got %s", got)
+	}
+	if got := middlewareName(trace("x")); got != "app.trace" {
+		t.Errorf("expected the function name for unnamed middleware, got %s", got)
+	}
+
+	rec := httptest.NewRecorder()
+	m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Header().Get("X-Trace") != "cors" {
+		t.Error("expected the named middleware to run")
+	}
+}
+
+func TestApp_EditMiddleware(t *testing.T) {
+	a := New(nil)
+	a.Use(Named("recovery", trace("recovery")), Named("logger", trace("logger")), Named("auth", trace("auth")))
+
+	if err := a.UseBefore("auth", Named("ratelimit", trace("ratelimit"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.UseAfter("recovery", Named("requestid", trace("requestid"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ReplaceMiddleware("logger", Named("json-logger", trace("json-logger"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RemoveMiddleware("auth"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RemoveMiddleware("missing"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected an error naming the missing middleware, got %v", err)
+	}
+
+	want := []string{"recovery", "requestid", "json-logger", "ratelimit"}
+	if got := a.Middleware(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	a.Group("").GET("/", func(w http.ResponseWriter, r *http.Request) {})
+	rec := httptest.NewRecorder()
+	a.buildHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Values("X-Trace"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the chain to run in order %v, got %v", want, got)
+	}
+}
+
+func TestRouteGroup_EditMiddleware(t *testing.T) {
+	a := New(nil)
+	api := a.Group("/api", Named("auth", trace("auth")))
+	admin := api.Group("/admin", Named("audit", trace("audit")))
+	public := api.Group("/public")
+
+	if err := admin.ReplaceMiddleware("auth", Named("admin-auth", trace("admin-auth"))); err != nil {
+		t.Fatal(err)
+	}
+	if got := admin.Middleware(); !reflect.DeepEqual(got, []string{"admin-auth", "audit"}) {
+		t.Errorf("unexpected admin chain %v", got)
+	}
+	if got := public.Middleware(); !reflect.DeepEqual(got, []string{"auth"}) {
+		t.Errorf("editing a group should not change its siblings, got %v", got)
+	}
+
+	admin.GET("/users", func(w http.ResponseWriter, r *http.Request) {})
+	for _, route := range a.Routes() {
+		if route.Path == "/api/admin/users" && !reflect.DeepEqual(route.Middleware, []string{"admin-auth", "audit"}) {
+			t.Errorf("unexpected route middleware %v", route.Middleware)
+		}
+	}
+}
@@ -2,12 +2,28 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/binding"
+	"github.com/polymatx/goframe/pkg/i18n"
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/polymatx/goframe/pkg/money"
+	"github.com/polymatx/goframe/pkg/render"
+	"github.com/polymatx/goframe/pkg/sse"
+	"github.com/polymatx/goframe/pkg/storage"
+	"github.com/polymatx/goframe/pkg/xlog"
+	"github.com/spf13/viper"
 )
 
 // Context wraps http.Request and http.ResponseWriter with additional functionality
@@ -16,18 +32,46 @@ type Context struct {
 	Response http.ResponseWriter
 	params   map[string]string
 	query    url.Values
+	app      *App
 }
 
 // NewContext creates a new Context
 func NewContext(w http.ResponseWriter, r *http.Request) *Context {
+	a, _ := FromContext(r.Context())
 	return &Context{
 		Request:  r,
 		Response: w,
 		params:   mux.Vars(r),
 		query:    r.URL.Query(),
+		app:      a,
 	}
 }
 
+// App returns the application serving the request, or nil outside an App
+func (c *Context) App() *App {
+	return c.app
+}
+
+// Location returns the request timezone resolved by middleware.Timezone,
+// or UTC when the middleware is not installed
+func (c *Context) Location() *time.Location {
+	return middleware.GetLocation(c.Request.Context())
+}
+
+// RequestID returns the ID assigned by middleware.RequestID, or the
+// X-Request-ID request header when the middleware is not installed
+func (c *Context) RequestID() string {
+	if id := middleware.GetRequestID(c.Request.Context()); id != "" {
+		return id
+	}
+	return c.Request.Header.Get("X-Request-ID")
+}
+
+// LocalTime converts t to the request timezone for presentation
+func (c *Context) LocalTime(t time.Time) time.Time {
+	return t.In(c.Location())
+}
+
 // Param returns URL parameter by name
 func (c *Context) Param(name string) string {
 	return c.params[name]
@@ -57,16 +101,122 @@ func (c *Context) SetHeader(name, value string) {
 	c.Response.Header().Set(name, value)
 }
 
-// JSON sends JSON response
+// JSON sends JSON response. When data cannot be encoded, e.g. a marshaler
+// panics or a field is a channel or func, nothing of it is written: the
+// failure is logged and answered with a 500 problem response instead.
 func (c *Context) JSON(code int, data interface{}) error {
 	c.SetHeader("Content-Type", "application/json;charset=UTF-8")
-	c.Response.WriteHeader(code)
-	return json.NewEncoder(c.Response).Encode(data)
+	err := render.WriteJSON(c.Response, code, c.jsonCodec(), data)
+	var encodeErr *render.EncodeError
+	if errors.As(err, &encodeErr) {
+		c.encodeFailed(encodeErr)
+		return nil
+	}
+	return err
+}
+
+// encodeFailed answers a response that could not be encoded with RFC 9457
+// problem details, including the error in develop_mode
+func (c *Context) encodeFailed(err error) {
+	xlog.GetWithError(c.Request.Context(), err).Errorf("%s %s: JSON response could not be encoded", c.Method(), c.Path())
+
+	body := map[string]interface{}{
+		"type":   "about:blank",
+		"title":  http.StatusText(http.StatusInternalServerError),
+		"status": http.StatusInternalServerError,
+	}
+	if viper.GetBool("develop_mode") {
+		body["detail"] = err.Error()
+	}
+	data, _ := json.Marshal(body)
+	c.Response.Header().Set("Content-Type", "application/problem+json")
+	c.Response.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	c.Response.WriteHeader(http.StatusInternalServerError)
+	_, _ = c.Response.Write(data)
 }
 
-// JSONError sends JSON error response
+// jsonCodec returns the codec of the App, or render's default
+func (c *Context) jsonCodec() render.JSONCodec {
+	if c.app != nil && c.app.config.JSON != nil {
+		return c.app.config.JSON
+	}
+	return render.DefaultJSONCodec()
+}
+
+// JSONError sends JSON error response, {"errors": {"field": "message"}} for
+// binding.FieldErrors. The message is translated to the request locale
+// when it is an i18n code or the English message of one.
 func (c *Context) JSONError(code int, err error) error {
-	return c.JSON(code, map[string]string{"error": err.Error()})
+	var fields binding.FieldErrors
+	if errors.As(err, &fields) {
+		return c.JSON(code, map[string]binding.FieldErrors{"errors": fields})
+	}
+	return c.JSON(code, map[string]string{"error": c.Translate(err.Error())})
+}
+
+// Locale returns the request locale resolved by i18n.Middleware, or the
+// best match of Accept-Language among the i18n catalog locales
+func (c *Context) Locale() string {
+	return i18n.RequestLocale(c.Request)
+}
+
+// T returns the message of an i18n code in the request locale, with args
+// as name, value pairs filling its placeholders
+func (c *Context) T(code string, args ...string) string {
+	return i18n.Default.Message(c.Locale(), code, args...)
+}
+
+// Translate returns text in the request locale when it is an i18n code or
+// the English message of one, and text as is otherwise
+func (c *Context) Translate(text string) string {
+	return i18n.Default.Translate(c.Locale(), text)
+}
+
+// FormatNumber formats v with decimals places in the request locale
+func (c *Context) FormatNumber(v float64, decimals int) string {
+	return i18n.FormatNumber(c.Locale(), v, decimals)
+}
+
+// FormatDate formats the date of t in the request locale and timezone
+func (c *Context) FormatDate(t time.Time) string {
+	return i18n.FormatDate(c.Locale(), c.LocalTime(t))
+}
+
+// FormatDateTime formats the date and time of t in the request locale and
+// timezone
+func (c *Context) FormatDateTime(t time.Time) string {
+	return i18n.FormatDateTime(c.Locale(), c.LocalTime(t))
+}
+
+// FormatMoney formats m in the request locale, e.g. "1.234,50 €" for "de"
+func (c *Context) FormatMoney(m money.Money) string {
+	return i18n.FormatMoney(c.Locale(), m)
+}
+
+// TemplateFuncs returns i18n.TemplateFuncs for the request locale, with
+// dates in the request timezone
+func (c *Context) TemplateFuncs() template.FuncMap {
+	funcs := i18n.TemplateFuncs(c.Locale())
+	funcs["formatDate"] = c.FormatDate
+	funcs["formatDateTime"] = c.FormatDateTime
+	return funcs
+}
+
+// HTML renders a template of tr with TemplateFuncs, so it formats for the
+// request locale. Create tr with render.NewTemplateRendererFuncs and
+// i18n.TemplateFuncs.
+func (c *Context) HTML(code int, tr *render.TemplateRenderer, name string, data interface{}) error {
+	return tr.RenderFuncs(c.Response, code, name, data, c.TemplateFuncs())
+}
+
+// Error hands err to the application's error handler, falling back to
+// DefaultErrorHandler when none is set
+func (c *Context) Error(err error) {
+	if c.app != nil && c.app.errorHandler != nil {
+		c.app.errorHandler(c, err)
+		return
+	}
+	DefaultErrorHandler(c, err)
 }
 
 // String sends string response
@@ -77,10 +227,149 @@ func (c *Context) String(code int, format string, values ...interface{}) error {
 	return err
 }
 
+// Flush sends any buffered response data to the client. It returns
+// http.ErrNotSupported when the underlying writer cannot flush.
+func (c *Context) Flush() error {
+	return http.NewResponseController(c.Response).Flush()
+}
+
+// Stream writes an incremental response: step is called repeatedly with the
+// response writer, and the output is flushed after each call, until step
+// returns false or the client goes away. Set headers (e.g. Content-Type)
+// before calling Stream; the status defaults to 200.
+//
+//	ctx.Stream(func(w io.Writer) bool {
+//		line, ok := <-lines
+//		if ok {
+//			fmt.Fprintln(w, line)
+//		}
+//		return ok
+//	})
+func (c *Context) Stream(step func(w io.Writer) bool) error {
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return c.Request.Context().Err()
+		default:
+		}
+
+		keepOpen := step(c.Response)
+		if err := c.Flush(); err != nil {
+			return err
+		}
+		if !keepOpen {
+			return nil
+		}
+	}
+}
+
+// SSE starts a server-sent event stream on the response. Use it to push
+// events to this client directly; for fan-out to many clients use
+// sse.Broker.Serve.
+func (c *Context) SSE() (*sse.Stream, error) {
+	return sse.NewStream(c.Response, c.Request)
+}
+
+// FileOptions are the options of FileFromStorage
+type FileOptions struct {
+	// Attachment sends the object as a download with this filename
+	Attachment string
+	// Redirect, for stores implementing storage.Signer such as S3, answers
+	// with a 302 to a signed URL valid this long instead of streaming the
+	// object through the app
+	Redirect time.Duration
+}
+
+// FileFromStorage serves the object of key from store with Range,
+// If-Range and conditional request handling, so downloads resume and
+// media players seek. The content type comes from the object, else the key
+// extension or the content; the ETag from the object metadata. A missing
+// object is a 404 HTTPError.
+func (c *Context) FileFromStorage(store storage.Store, key string, opts ...FileOptions) error {
+	var o FileOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	ctx := c.Request.Context()
+
+	if signer, ok := store.(storage.Signer); ok && o.Redirect > 0 {
+		url, err := signer.SignedURL(ctx, key, storage.SignOptions{Expires: o.Redirect, Attachment: o.Attachment})
+		if err != nil {
+			return storageError(err)
+		}
+		c.SetHeader("Cache-Control", "no-store")
+		http.Redirect(c.Response, c.Request, url, http.StatusFound)
+		return nil
+	}
+
+	body, obj, err := store.Open(ctx, key)
+	if err != nil {
+		return storageError(err)
+	}
+	defer body.Close()
+
+	if obj.ContentType != "" {
+		c.SetHeader("Content-Type", obj.ContentType)
+	}
+	if o.Attachment != "" {
+		c.SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": o.Attachment}))
+	}
+	render.ServeContent(c.Response, c.Request, path.Base(key), obj.ModTime, quoteETag(obj.ETag), body)
+	return nil
+}
+
+func storageError(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return NewHTTPError(http.StatusNotFound).WithInternal(err)
+	}
+	return err
+}
+
+// quoteETag quotes a bare ETag, as some stores return them
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
 // Bind decodes request body into provided struct
 func (c *Context) Bind(v interface{}) error {
 	defer c.Request.Body.Close()
-	return json.NewDecoder(c.Request.Body).Decode(v)
+	return c.jsonCodec().Decode(c.Request.Body, v)
+}
+
+// BindValidated binds the request by Content-Type (JSON, XML or form) and
+// validates the validate struct tags. Validation failures are returned as
+// binding.FieldErrors, which Error renders as 422; form values of the wrong
+// type as binding.ParamError and malformed bodies as a 400 HTTPError.
+func (c *Context) BindValidated(v interface{}) error {
+	err := binding.BindValidated(c.Request, v)
+	var fields binding.FieldErrors
+	var param *binding.ParamError
+	if err == nil || errors.As(err, &fields) || errors.As(err, &param) {
+		return err
+	}
+	return NewHTTPError(http.StatusBadRequest, err.Error()).WithInternal(err)
+}
+
+// BindQuery binds the query parameters by form tags and validates the
+// validate struct tags. A value of the wrong type, e.g. page=abc, is
+// returned as binding.ParamError, which Error renders as 400; validation
+// failures as binding.FieldErrors. Declare the same struct with
+// QueryParams to document the parameters.
+func (c *Context) BindQuery(v interface{}) error {
+	if err := binding.Query(c.Request, v); err != nil {
+		return err
+	}
+	if err := binding.Validate(v); err != nil {
+		if fields, ok := binding.ToFieldErrorsLocale(v, err, i18n.RequestLocale(c.Request)); ok {
+			return fields
+		}
+		return err
+	}
+	return nil
 }
 
 // BindJSON is alias for Bind
@@ -113,6 +402,32 @@ func (c *Context) Redirect(code int, url string) error {
 	return nil
 }
 
+// Cookie returns the value of the named request cookie
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+// SetCookie adds a Set-Cookie header to the response. Path defaults to "/"
+// and SameSite to Lax.
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	http.SetCookie(c.Response, cookie)
+}
+
+// ClearCookie expires the named cookie on the client
+func (c *Context) ClearCookie(name string) {
+	http.SetCookie(c.Response, &http.Cookie{Name: name, Value: "", Path: "/", MaxAge: -1})
+}
+
 // ClientIP returns client IP address
 func (c *Context) ClientIP() string {
 	// Check CF-Connecting-IP
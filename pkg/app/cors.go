@@ -0,0 +1,73 @@
+package app
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+// preflightKey identifies a path of a group router
+type preflightKey struct {
+	router *mux.Router
+	path   string
+}
+
+// preflight answers OPTIONS requests for a path of a group with a CORS
+// policy, which mux would reject with 405 before any group middleware runs
+type preflight struct {
+	route   *mux.Route
+	methods []string
+}
+
+// CORS applies a CORS policy to the routes the group registers from now on,
+// and to those of its sub-groups unless they set their own. Unlike
+// g.Use(middleware.CORS(config)), preflight requests are answered: an
+// OPTIONS route is added for each path, running the CORS middleware alone,
+// so authentication does not reject preflights.
+//
+//	public := a.Group("/public")
+//	public.CORS(middleware.CORSConfig{AllowedOrigins: []string{"*"}})
+//
+//	partner := a.Group("/partner", auth)
+//	partner.CORS(middleware.CORSConfig{AllowedOrigins: []string{"https://partner.example.com"}, AllowCredentials: true})
+func (g *RouteGroup) CORS(config middleware.CORSConfig) {
+	g.cors = Named("cors", middleware.CORS(config))
+}
+
+// addPreflight registers the OPTIONS route of path on the group router,
+// once per path, answered by the CORS policy of the group
+func (g *RouteGroup) addPreflight(path string, methods []string) {
+	g.addPreflightWith(g.cors, path, methods)
+}
+
+// addPreflightWith is addPreflight with the given CORS middleware
+func (g *RouteGroup) addPreflightWith(cors MiddlewareFunc, path string, methods []string) {
+	a := g.app
+	if a.preflights == nil {
+		a.preflights = make(map[preflightKey]*preflight)
+		a.preflightRoutes = make(map[*mux.Route]bool)
+	}
+	key := preflightKey{router: g.router, path: path}
+	if p, ok := a.preflights[key]; ok {
+		for _, method := range methods {
+			if !slices.Contains(p.methods, method) {
+				p.methods = append(p.methods, method)
+			}
+		}
+		return
+	}
+
+	p := &preflight{methods: append([]string(nil), methods...)}
+	// Plain OPTIONS requests, without Access-Control-Request-Method, list
+	// the methods of the path
+	allow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(slices.Concat(p.methods, []string{http.MethodOptions}), ", "))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	p.route = g.router.Handle(path, cors(allow)).Methods(http.MethodOptions)
+	a.preflights[key] = p
+	a.preflightRoutes[p.route] = true
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+func TestRouteGroup_CORS(t *testing.T) {
+	a := New(nil)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	denyAll := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+
+	public := a.Group("/public")
+	public.CORS(middleware.CORSConfig{AllowedOrigins: []string{"*"}})
+	public.GET("/items", ok)
+	public.POST("/items", ok)
+
+	partner := a.Group("/partner", denyAll)
+	partner.CORS(middleware.CORSConfig{
+		AllowedOrigins:   []string{"https://partner.example.com"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPut},
+		AllowCredentials: true,
+	})
+	partner.PUT("/orders/{id}", ok)
+	partner.Group("/v2").GET("/orders", ok) // Inherits the policy
+
+	a.Group("/internal").GET("/stats", ok)
+
+	handler := a.buildHandler()
+	serve := func(method, path, origin, requestMethod string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if requestMethod != "" {
+			r.Header.Set("Access-Control-Request-Method", requestMethod)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		origin        string
+		requestMethod string
+		status        int
+		allowOrigin   string
+		credentials   string
+	}{
+		{"public preflight", "OPTIONS", "/public/items", "https://any.example.com", "POST", 204, "*", ""},
+		{"public request", "GET", "/public/items", "https://any.example.com", "", 200, "*", ""},
+		// Preflights skip the group middleware that rejects the request itself
+		{"partner preflight", "OPTIONS", "/partner/orders/1", "https://partner.example.com", "PUT", 204, "https://partner.example.com", "true"},
+		{"partner rejected request keeps CORS headers", "PUT", "/partner/orders/1", "https://partner.example.com", "", 401, "https://partner.example.com", "true"},
+		{"partner other origin", "OPTIONS", "/partner/orders/1", "https://evil.example.com", "PUT", 204, "", ""},
+		{"sub-group preflight", "OPTIONS", "/partner/v2/orders", "https://partner.example.com", "GET", 204, "https://partner.example.com", "true"},
+		{"group without CORS", "OPTIONS", "/internal/stats", "https://any.example.com", "GET", 405, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.method, tt.path, tt.origin, tt.requestMethod)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.credentials)
+			}
+		})
+	}
+
+	w := serve("OPTIONS", "/public/items", "", "")
+	if got := w.Header().Get("Allow"); w.Code != 204 || got != "GET, HEAD, POST, OPTIONS" {
+		t.Errorf("plain OPTIONS: %d, Allow %q", w.Code, got)
+	}
+
+	for _, route := range a.Routes() {
+		if route.Method == http.MethodOptions {
+			t.Errorf("expected preflight routes hidden, got %+v", route)
+		}
+		if route.Path == "/public/items" && (len(route.Middleware) == 0 || route.Middleware[len(route.Middleware)-1] != "cors") {
+			t.Errorf("expected cors listed for %s %s, got %v", route.Method, route.Path, route.Middleware)
+		}
+	}
+}
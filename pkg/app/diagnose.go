@@ -0,0 +1,108 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// MiddlewareChain is an effective middleware chain, application middleware
+// followed by group middleware, and the routes it serves
+type MiddlewareChain struct {
+	Middleware []string `json:"middleware"` // Outermost first
+	Routes     []string `json:"routes"`     // e.g. "GET /users/{id}"
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// MiddlewareChains returns the distinct middleware chains of the routes, in
+// registration order, with warnings for common ordering mistakes:
+//
+//   - middleware.Recovery not outermost, so panics in the middleware before
+//     it are not recovered
+//   - middleware.Scopes, middleware.RateLimitByUser or authz running before
+//     the auth middleware, so they see no claims
+//   - the same middleware more than once, e.g. added again by a nested
+//     group
+//
+// What middleware does is read from its middleware.Descriptor; middleware
+// without one is only checked for repeats.
+func (a *App) MiddlewareChains() []MiddlewareChain {
+	var chains []MiddlewareChain
+	index := make(map[string]int)
+	for _, route := range a.Routes() {
+		key := strings.Join(route.Middleware, "\x00")
+		i, ok := index[key]
+		if !ok {
+			i = len(chains)
+			index[key] = i
+			chains = append(chains, MiddlewareChain{
+				Middleware: route.Middleware,
+				Warnings:   checkMiddlewareOrder(route.descriptors),
+			})
+		}
+		chains[i].Routes = append(chains[i].Routes, route.Method+" "+route.Path)
+	}
+	return chains
+}
+
+// MiddlewareHandler serves MiddlewareChains as JSON, on the admin listener
+// under /debug/middleware
+func (a *App) MiddlewareHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		_ = json.NewEncoder(w).Encode(a.MiddlewareChains())
+	})
+}
+
+// logMiddlewareWarnings logs the warnings of MiddlewareChains at startup
+func (a *App) logMiddlewareWarnings() {
+	for _, chain := range a.MiddlewareChains() {
+		for _, warning := range chain.Warnings {
+			logrus.WithFields(logrus.Fields{
+				"middleware": chain.Middleware,
+				"routes":     chain.Routes,
+			}).Warn("Middleware order: " + warning)
+		}
+	}
+}
+
+// checkMiddlewareOrder returns the ordering mistakes of a chain
+func checkMiddlewareOrder(chain []middleware.Descriptor) []string {
+	var warnings []string
+
+	recovers := slices.IndexFunc(chain, func(d middleware.Descriptor) bool { return d.Recovers })
+	if recovers > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s is not outermost, panics in %s are not recovered",
+			chain[recovers].Name, strings.Join(descriptorNames(chain[:recovers]), ", ")))
+	}
+
+	if auth := lastIndexFunc(chain, func(d middleware.Descriptor) bool { return d.Authenticates }); auth >= 0 {
+		for _, d := range chain[:auth] {
+			if d.NeedsClaims {
+				warnings = append(warnings, fmt.Sprintf("%s runs before %s and sees no claims", d.Name, chain[auth].Name))
+			}
+		}
+	}
+
+	seen := make(map[string]int)
+	for _, d := range chain {
+		if seen[d.Name]++; seen[d.Name] == 2 {
+			warnings = append(warnings, d.Name+" runs more than once")
+		}
+	}
+	return warnings
+}
+
+func lastIndexFunc(s []middleware.Descriptor, f func(middleware.Descriptor) bool) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if f(s[i]) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/authz"
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+func TestMiddlewareChains(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	jwt := auth.NewJWTManager("secret", time.Hour)
+	limits := middleware.RateLimitConfig{}
+
+	a := New(nil)
+	a.Use(middleware.Logger(), middleware.Recovery())
+	a.Group("/public").GET("/items", ok)
+
+	api := a.Group("/api", middleware.RateLimitByUser(limits), auth.BearerAuth(jwt), middleware.Scopes())
+	api.GET("/orders", ok)
+	api.POST("/orders", ok)
+	api.Group("/admin", authz.Require("admin"), middleware.Logger()).GET("/users", ok)
+
+	chains := a.MiddlewareChains()
+	if len(chains) != 3 {
+		t.Fatalf("chains = %+v, want 3", chains)
+	}
+
+	recovery := "middleware.Recovery is not outermost, panics in middleware.Logger are not recovered"
+	tests := []struct {
+		routes   []string
+		warnings []string
+	}{
+		{[]string{"GET /public/items"}, []string{recovery}},
+		{[]string{"GET /api/orders", "POST /api/orders"}, []string{
+			recovery,
+			"middleware.RateLimitByUser runs before auth.BearerAuth and sees no claims",
+		}},
+		{[]string{"GET /api/admin/users"}, []string{
+			recovery,
+			"middleware.RateLimitByUser runs before auth.BearerAuth and sees no claims",
+			"middleware.Logger runs more than once",
+		}},
+	}
+	for i, tt := range tests {
+		if !slices.Equal(chains[i].Routes, tt.routes) {
+			t.Errorf("chain %d routes = %v, want %v", i, chains[i].Routes, tt.routes)
+		}
+		if !slices.Equal(chains[i].Warnings, tt.warnings) {
+			t.Errorf("chain %d warnings = %q, want %q", i, chains[i].Warnings, tt.warnings)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	a.MiddlewareHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/middleware", nil))
+	var served []MiddlewareChain
+	if err := json.Unmarshal(w.Body.Bytes(), &served); err != nil || len(served) != 3 {
+		t.Errorf("served %s, %v", w.Body.String(), err)
+	}
+}
+
+func TestCheckMiddlewareOrder(t *testing.T) {
+	var (
+		recovery = middleware.Descriptor{Name: "middleware.Recovery", Recovers: true}
+		logger   = middleware.Descriptor{Name: "middleware.Logger"}
+		bearer   = middleware.Descriptor{Name: "auth.BearerAuth", Authenticates: true}
+		sso      = middleware.Descriptor{Name: "sso", Authenticates: true}
+		scopes   = middleware.Descriptor{Name: "middleware.Scopes", NeedsClaims: true}
+		limit    = middleware.Descriptor{Name: "middleware.RateLimitBy"}
+		cors     = middleware.Descriptor{Name: "cors"}
+	)
+	tests := []struct {
+		name  string
+		chain []middleware.Descriptor
+		want  []string
+	}{
+		{"good", []middleware.Descriptor{recovery, logger, bearer, scopes}, nil},
+		{"no recovery", []middleware.Descriptor{logger}, nil},
+		{"scopes before auth", []middleware.Descriptor{scopes, sso},
+			[]string{"middleware.Scopes runs before sso and sees no claims"}},
+		{"IP rate limit before auth", []middleware.Descriptor{limit, bearer}, nil},
+		{"repeated", []middleware.Descriptor{cors, cors, cors}, []string{"cors runs more than once"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkMiddlewareOrder(tt.chain); !slices.Equal(got, tt.want) {
+				t.Errorf("warnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareChains_Named(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	a := New(nil)
+	a.Use(middleware.Logger(), Named("panics", middleware.Recovery()))
+	a.Group("/api", Named("limits", middleware.RateLimitByUser(middleware.RateLimitConfig{})),
+		auth.BearerAuth(auth.NewJWTManager("secret", time.Hour))).GET("/orders", ok)
+
+	chains := a.MiddlewareChains()
+	want := []string{
+		"panics is not outermost, panics in middleware.Logger are not recovered",
+		"limits runs before auth.BearerAuth and sees no claims",
+	}
+	if len(chains) != 1 || !slices.Equal(chains[0].Warnings, want) {
+		t.Errorf("chains = %+v, want warnings %q", chains, want)
+	}
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DrainReport describes how in-flight requests ended during a shutdown
+type DrainReport struct {
+	InFlight    int64         // Requests being served when draining began
+	Completed   int64         // Requests that finished within the grace period
+	Interrupted int64         // Requests whose context was canceled after the grace period, such as SSE streams, that returned before the deadline
+	Aborted     int64         // Requests still running at the deadline, whose connections were closed
+	Rejected    int64         // New requests answered 503 while draining
+	Duration    time.Duration // Time spent draining
+}
+
+// InFlight returns the number of requests being served, including hijacked
+// connections such as WebSockets until their handler returns
+func (a *App) InFlight() int64 {
+	return a.inFlight.Load()
+}
+
+// Draining reports whether a shutdown is draining requests
+func (a *App) Draining() bool {
+	return a.draining.Load()
+}
+
+// OnDrain registers a hook run when the drain grace period ends, e.g. to
+// close WebSockets, which net/http does not track:
+//
+//	a.OnDrain(func(ctx context.Context) error { hub.CloseAll(); return nil })
+//
+// Errors are logged and do not stop the shutdown.
+func (a *App) OnDrain(hook HookFunc) {
+	a.hooksMu.Lock()
+	defer a.hooksMu.Unlock()
+	a.drainHooks = append(a.drainHooks, hook)
+}
+
+// Drain shuts the server down in phases, until ctx is done
+// (ShutdownTimeout when ctx has no deadline):
+//
+//  1. HealthHandler reports 503 and new requests get 503 with Retry-After
+//     for DrainDelay, so load balancers move traffic away
+//  2. The listeners close and in-flight requests get DrainGrace to finish
+//  3. The contexts of the requests left are canceled, ending SSE streams,
+//     and the OnDrain hooks run to close WebSockets
+//  4. At the deadline the connections left are closed
+//
+// The report counts how requests ended. Shutdown hooks are not run;
+// Shutdown drains and then runs them.
+func (a *App) Drain(ctx context.Context) (DrainReport, error) {
+	if a.server == nil {
+		return DrainReport{}, nil
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.ShutdownTimeout)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+
+	started := time.Now()
+	a.draining.Store(true)
+	report := DrainReport{InFlight: a.InFlight()}
+	logrus.WithFields(logrus.Fields{
+		"in_flight": report.InFlight,
+		"delay":     a.config.DrainDelay.String(),
+	}).Info("Draining: health check failing, rejecting new requests")
+
+	if a.config.DrainDelay > 0 {
+		wait(ctx, a.config.DrainDelay)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- a.shutdownServers(ctx) }()
+
+	grace := a.config.DrainGrace
+	if grace <= 0 {
+		grace = time.Until(deadline) / 2
+	}
+	graceCtx, cancel := context.WithTimeout(ctx, grace)
+	for a.InFlight() > 0 && wait(graceCtx, 10*time.Millisecond) {
+	}
+	cancel()
+	remaining := a.InFlight()
+
+	a.stopAll()
+	a.runDrainHooks(ctx)
+
+	err := <-shutdown
+	if err != nil {
+		_ = a.server.Close()
+	}
+
+	report.Aborted = a.InFlight()
+	report.Interrupted = max(remaining-report.Aborted, 0)
+	report.Completed = max(report.InFlight-remaining, 0)
+	report.Rejected = a.rejected.Load()
+	report.Duration = time.Since(started)
+
+	entry := logrus.WithFields(logrus.Fields{
+		"completed":   report.Completed,
+		"interrupted": report.Interrupted,
+		"aborted":     report.Aborted,
+		"rejected":    report.Rejected,
+		"duration":    report.Duration.Round(time.Millisecond).String(),
+	})
+	if report.Aborted > 0 {
+		entry.Warn("Drain deadline reached, aborted in-flight requests")
+	} else {
+		entry.Info("Drained in-flight requests")
+	}
+	return report, err
+}
+
+// rejectDraining answers a request arriving while draining
+func (a *App) rejectDraining(w http.ResponseWriter) {
+	a.rejected.Add(1)
+	h := w.Header()
+	h.Set("Connection", "close")
+	h.Set("Retry-After", "1")
+	h.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write([]byte(`{"error":"Server is shutting down"}`))
+}
+
+func (a *App) runDrainHooks(ctx context.Context) {
+	a.hooksMu.Lock()
+	hooks := a.drainHooks
+	a.drainHooks = nil
+	a.hooksMu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			logrus.WithError(err).Warnf("Drain hook %s failed", funcName(hook))
+		}
+	}
+}
+
+// wait sleeps for d and reports whether ctx is still live
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
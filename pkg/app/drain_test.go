@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveSlow serves /slow, which signals entered and blocks until release is
+// closed, and returns the server address
+func serveSlow(t *testing.T, a *App, entered chan<- struct{}, release <-chan struct{}) string {
+	t.Helper()
+	a.Group("").GET("/slow", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = a.Serve(ln) }()
+	return "http://" + ln.Addr().String()
+}
+
+func TestApp_Drain(t *testing.T) {
+	t.Run("waits for in-flight requests", func(t *testing.T) {
+		a := New(nil)
+		entered, release := make(chan struct{}), make(chan struct{})
+		url := serveSlow(t, a, entered, release)
+
+		status := make(chan int, 1)
+		go func() {
+			resp, err := http.Get(url + "/slow")
+			if err != nil {
+				status <- 0
+				return
+			}
+			_ = resp.Body.Close()
+			status <- resp.StatusCode
+		}()
+		<-entered
+
+		if got := a.InFlight(); got != 1 {
+			t.Errorf("expected 1 request in flight, got %d", got)
+		}
+		time.AfterFunc(100*time.Millisecond, func() { close(release) })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		report, err := a.Drain(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.InFlight != 1 || report.Completed != 1 || report.Aborted != 0 {
+			t.Errorf("unexpected report %+v", report)
+		}
+		if code := <-status; code != http.StatusOK {
+			t.Errorf("expected the request to complete, got status %d", code)
+		}
+	})
+
+	t.Run("aborts at the deadline", func(t *testing.T) {
+		a := New(&Config{ShutdownTimeout: 100 * time.Millisecond})
+		entered, release := make(chan struct{}), make(chan struct{})
+		defer close(release)
+		url := serveSlow(t, a, entered, release)
+
+		failed := make(chan bool, 1)
+		go func() {
+			resp, err := http.Get(url + "/slow")
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+			failed <- err != nil
+		}()
+		<-entered
+
+		report, err := a.Drain(context.Background())
+		if err == nil {
+			t.Fatal("expected a deadline error")
+		}
+		if report.InFlight != 1 || report.Aborted != 1 || report.Completed != 0 {
+			t.Errorf("unexpected report %+v", report)
+		}
+		if !<-failed {
+			t.Error("expected the client connection to be closed")
+		}
+	})
+
+	t.Run("rejects new requests and interrupts streams", func(t *testing.T) {
+		a := New(&Config{ShutdownTimeout: 5 * time.Second, DrainDelay: 200 * time.Millisecond, DrainGrace: 50 * time.Millisecond})
+		entered := make(chan struct{})
+		a.Group("").GET("/stream", func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-r.Context().Done() // Like an SSE stream
+		})
+		var hooked atomic.Bool
+		a.OnDrain(func(ctx context.Context) error {
+			hooked.Store(true)
+			return nil
+		})
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() { _ = a.Serve(ln) }()
+		url := "http://" + ln.Addr().String()
+
+		go func() {
+			if resp, err := http.Get(url + "/stream"); err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+		<-entered
+
+		done := make(chan DrainReport, 1)
+		go func() {
+			report, _ := a.Drain(context.Background())
+			done <- report
+		}()
+		for !a.Draining() {
+			time.Sleep(time.Millisecond)
+		}
+
+		resp, err := http.Get(url + "/stream")
+		if err != nil {
+			t.Fatalf("request during the drain delay: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+			t.Errorf("new request got %d, Retry-After %q; want 503 with Retry-After", resp.StatusCode, resp.Header.Get("Retry-After"))
+		}
+		rec := httptest.NewRecorder()
+		a.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "draining") {
+			t.Errorf("health = %d %s, want 503 draining", rec.Code, rec.Body.String())
+		}
+
+		report := <-done
+		if report.InFlight != 1 || report.Interrupted != 1 || report.Aborted != 0 || report.Rejected != 1 {
+			t.Errorf("unexpected report %+v", report)
+		}
+		if !hooked.Load() {
+			t.Error("expected the drain hook to run")
+		}
+	})
+}
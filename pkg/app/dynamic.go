@@ -0,0 +1,142 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+// dynamicRoute is a route added at runtime with AddRoute
+type dynamicRoute struct {
+	method     string
+	path       string
+	handler    http.Handler
+	middleware []MiddlewareFunc
+}
+
+// dynamicRoutes holds the routes added at runtime. Changes rebuild the
+// router and swap it atomically, so requests never see a router being
+// modified.
+type dynamicRoutes struct {
+	mu     sync.Mutex
+	routes []dynamicRoute
+	router atomic.Pointer[mux.Router]
+}
+
+// AddRoute registers a route while the server may be running, e.g. a webhook
+// configured from an admin UI. Routes registered through groups take
+// precedence; a method and path can only be added once.
+func (a *App) AddRoute(method, path string, handler http.HandlerFunc, middleware ...MiddlewareFunc) error {
+	method = strings.ToUpper(method)
+
+	d := &a.dynamic
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, r := range d.routes {
+		if r.method == method && r.path == path {
+			return fmt.Errorf("route %s %s already added", method, path)
+		}
+	}
+
+	var h http.Handler = handler
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	routes := append(append([]dynamicRoute(nil), d.routes...), dynamicRoute{
+		method:     method,
+		path:       path,
+		handler:    h,
+		middleware: append([]MiddlewareFunc(nil), middleware...),
+	})
+
+	router, err := newDynamicRouter(routes)
+	if err != nil {
+		return fmt.Errorf("route %s %s: %w", method, path, err)
+	}
+	d.routes = routes
+	d.router.Store(router)
+	return nil
+}
+
+// RemoveRoute removes a route added with AddRoute. In-flight requests to it
+// complete; it reports whether the route existed.
+func (a *App) RemoveRoute(method, path string) bool {
+	method = strings.ToUpper(method)
+
+	d := &a.dynamic
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	routes := make([]dynamicRoute, 0, len(d.routes))
+	for _, r := range d.routes {
+		if r.method != method || r.path != path {
+			routes = append(routes, r)
+		}
+	}
+	if len(routes) == len(d.routes) {
+		return false
+	}
+
+	router, _ := newDynamicRouter(routes) // Every route was valid when added
+	d.routes = routes
+	d.router.Store(router)
+	return true
+}
+
+// newDynamicRouter builds a router for routes; GET routes answer HEAD too,
+// like the routes of groups
+func newDynamicRouter(routes []dynamicRoute) (*mux.Router, error) {
+	router := mux.NewRouter()
+	for _, r := range routes {
+		methods := []string{r.method}
+		if r.method == http.MethodGet {
+			methods = append(methods, http.MethodHead)
+		}
+		if err := router.Handle(r.path, r.handler).Methods(methods...).GetError(); err != nil {
+			return nil, err
+		}
+	}
+	return router, nil
+}
+
+// withDynamic serves the runtime routes a request matches when the static
+// router has no route for it
+func (a *App) withDynamic(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if dynamic := a.dynamic.router.Load(); dynamic != nil {
+			var static, match mux.RouteMatch
+			if !router.Match(r, &static) || static.MatchErr != nil {
+				if dynamic.Match(r, &match) && match.MatchErr == nil {
+					dynamic.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+		router.ServeHTTP(w, r)
+	})
+}
+
+// dynamicRouteInfos lists the runtime routes for Routes
+func (a *App) dynamicRouteInfos(appMiddleware []middleware.Descriptor) []RouteInfo {
+	a.dynamic.mu.Lock()
+	defer a.dynamic.mu.Unlock()
+
+	infos := make([]RouteInfo, 0, len(a.dynamic.routes))
+	for _, r := range a.dynamic.routes {
+		chain := append(append([]middleware.Descriptor{}, appMiddleware...), middlewareDescriptors(r.middleware)...)
+		infos = append(infos, RouteInfo{
+			Method:     r.method,
+			Path:       r.path,
+			Middleware: descriptorNames(chain),
+
+			descriptors: chain,
+		})
+	}
+	return infos
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestApp_AddRoute(t *testing.T) {
+	a := New(nil)
+	a.Group("").GET("/static", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("static"))
+	})
+	handler := a.buildHandler()
+
+	do := func(method, path string) (int, string) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		body, _ := io.ReadAll(w.Body)
+		return w.Code, string(body)
+	}
+
+	// Added after the handler was built, as with a running server
+	err := a.AddRoute("post", "/hooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hook " + mux.Vars(r)["id"]))
+	})
+	if err != nil {
+		t.Fatalf("AddRoute: %v", err)
+	}
+	if code, body := do(http.MethodPost, "/hooks/42"); code != http.StatusOK || body != "hook 42" {
+		t.Errorf("expected the added route, got %d %q", code, body)
+	}
+	if err := a.AddRoute(http.MethodPost, "/hooks/{id}", func(w http.ResponseWriter, r *http.Request) {}); err == nil {
+		t.Error("expected an error adding the route twice")
+	}
+	if err := a.AddRoute(http.MethodGet, "/bad/{id:[}", func(w http.ResponseWriter, r *http.Request) {}); err == nil {
+		t.Error("expected an error for an invalid path")
+	}
+
+	// Static routes take precedence
+	_ = a.AddRoute(http.MethodGet, "/static", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("dynamic"))
+	})
+	if _, body := do(http.MethodGet, "/static"); body != "static" {
+		t.Errorf("expected the static route to win, got %q", body)
+	}
+
+	found := false
+	for _, route := range a.Routes() {
+		if route.Method == http.MethodPost && route.Path == "/hooks/{id}" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected Routes to list the added route")
+	}
+
+	if !a.RemoveRoute(http.MethodPost, "/hooks/{id}") {
+		t.Error("expected RemoveRoute to find the route")
+	}
+	if a.RemoveRoute(http.MethodPost, "/hooks/{id}") {
+		t.Error("expected the route to be gone")
+	}
+	if code, _ := do(http.MethodPost, "/hooks/42"); code != http.StatusNotFound {
+		t.Errorf("expected 404 after removal, got %d", code)
+	}
+}
+
+func TestApp_AddRouteConcurrent(t *testing.T) {
+	a := New(nil)
+	handler := a.buildHandler()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			path := fmt.Sprintf("/plugins/%d", i)
+			for j := 0; j < 50; j++ {
+				_ = a.AddRoute(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {})
+				a.RemoveRoute(http.MethodGet, path)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/plugins/%d", i), nil))
+				if w.Code != http.StatusOK && w.Code != http.StatusNotFound {
+					t.Errorf("unexpected status %d", w.Code)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
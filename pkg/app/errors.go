@@ -0,0 +1,148 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/polymatx/goframe/pkg/binding"
+	"github.com/polymatx/goframe/pkg/i18n"
+	"github.com/polymatx/goframe/pkg/xlog"
+	"github.com/spf13/viper"
+)
+
+// HTTPError is an error carrying the HTTP status and client-facing message.
+// Internal is logged and only exposed to clients in develop mode.
+type HTTPError struct {
+	Code     int
+	Message  string
+	Internal error
+
+	stack []byte // Where it was created, in develop mode
+}
+
+// NewHTTPError creates an HTTPError, using the status text when no message is given
+func NewHTTPError(code int, message ...string) *HTTPError {
+	he := &HTTPError{Code: code, Message: http.StatusText(code), stack: developStack()}
+	if len(message) > 0 {
+		he.Message = message[0]
+	}
+	return he
+}
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+	if e.Internal != nil {
+		return fmt.Sprintf("code=%d, message=%s, internal=%v", e.Code, e.Message, e.Internal)
+	}
+	return fmt.Sprintf("code=%d, message=%s", e.Code, e.Message)
+}
+
+// Unwrap returns the internal error
+func (e *HTTPError) Unwrap() error {
+	return e.Internal
+}
+
+// WithInternal returns a copy of the error with the internal error set
+func (e *HTTPError) WithInternal(err error) *HTTPError {
+	return &HTTPError{Code: e.Code, Message: e.Message, Internal: err, stack: developStack()}
+}
+
+// developStack returns the stack of the caller of the HTTPError
+// constructor in develop mode, nil otherwise
+func developStack() []byte {
+	if !viper.GetBool("develop_mode") {
+		return nil
+	}
+	// Drop debug.Stack, developStack and the constructor, two lines each
+	stack := debug.Stack()
+	goroutine, frames, _ := bytes.Cut(stack, []byte("\n"))
+	lines := bytes.SplitAfterN(frames, []byte("\n"), 7)
+	if len(lines) < 7 {
+		return stack
+	}
+	return append(append(goroutine, '\n'), lines[6]...)
+}
+
+// ErrorHandlerFunc handles errors surfaced by handlers and middleware
+type ErrorHandlerFunc func(*Context, error)
+
+// HandlerFunc is a handler that reports failures by returning an error
+type HandlerFunc func(*Context) error
+
+// Wrap adapts a HandlerFunc to http.HandlerFunc, routing returned errors
+// through the application's error handler
+func Wrap(fn HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := NewContext(w, r)
+		if err := fn(c); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// SetErrorHandler replaces the error handler used by Context.Error
+func (a *App) SetErrorHandler(handler ErrorHandlerFunc) {
+	a.errorHandler = handler
+}
+
+// DefaultErrorHandler writes {"error": message} with the status of an
+// HTTPError, or 500 for any other error. binding.FieldErrors are written as
+// 422 with an "errors" object, and binding.ParamError as 400 naming the
+// "parameter" and the "expected" type and "format". Messages are translated to the
+// request locale, see Context.Translate. Server errors are logged. In
+// develop_mode the internal error, and the stack where the HTTPError was
+// created, are included in the body.
+func DefaultErrorHandler(c *Context, err error) {
+	var fields binding.FieldErrors
+	if errors.As(err, &fields) {
+		_ = c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{"error": c.T(i18n.CodeValidationFailed), "errors": fields})
+		return
+	}
+	var param *binding.ParamError
+	if errors.As(err, &param) {
+		body := map[string]interface{}{"error": param.Error(), "parameter": param.Name, "expected": param.Type}
+		if param.Format != "" {
+			body["format"] = param.Format
+		}
+		_ = c.JSON(http.StatusBadRequest, body)
+		return
+	}
+
+	he := &HTTPError{Code: http.StatusInternalServerError, Message: http.StatusText(http.StatusInternalServerError), Internal: err}
+	var target *HTTPError
+	if errors.As(err, &target) {
+		he = target
+	}
+
+	if he.Code >= http.StatusInternalServerError {
+		xlog.GetWithError(c.Request.Context(), err).Errorf("%s %s failed", c.Method(), c.Path())
+	}
+
+	body := map[string]interface{}{"error": c.Translate(he.Message)}
+	if viper.GetBool("develop_mode") {
+		if he.Internal != nil {
+			body["internal"] = he.Internal.Error()
+		}
+		if he.stack != nil {
+			body["stack"] = string(he.stack)
+		}
+	}
+
+	_ = c.JSON(he.Code, body)
+}
+
+type appContextKey struct{}
+
+func withApp(ctx context.Context, a *App) context.Context {
+	return context.WithValue(ctx, appContextKey{}, a)
+}
+
+// FromContext returns the App serving the request, if any
+func FromContext(ctx context.Context) (*App, bool) {
+	a, ok := ctx.Value(appContextKey{}).(*App)
+	return a, ok
+}
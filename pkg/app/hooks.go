@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HookFunc is a lifecycle hook
+type HookFunc func(ctx context.Context) error
+
+// PanicHookFunc is notified of a panic recovered from a handler
+type PanicHookFunc func(c *Context, recovered interface{}, stack []byte)
+
+// OnStart registers a hook run before the server starts listening. Hooks run
+// in registration order; the first error aborts startup.
+func (a *App) OnStart(hook HookFunc) {
+	a.hooksMu.Lock()
+	defer a.hooksMu.Unlock()
+	a.startHooks = append(a.startHooks, hook)
+}
+
+// OnShutdown registers a hook run after the server has stopped accepting
+// requests during graceful shutdown, e.g. to close database or cache
+// connections. Hooks run in reverse registration order and share the
+// shutdown timeout.
+func (a *App) OnShutdown(hook HookFunc) {
+	a.hooksMu.Lock()
+	defer a.hooksMu.Unlock()
+	a.shutdownHooks = append(a.shutdownHooks, hook)
+}
+
+// OnPanic registers a callback for panics recovered by middleware.Recovery,
+// e.g. to report them to Sentry. Callbacks run in registration order before
+// the 500 response is written; Recovery must be in the middleware chain.
+func (a *App) OnPanic(hook PanicHookFunc) {
+	a.hooksMu.Lock()
+	defer a.hooksMu.Unlock()
+	a.panicHooks = append(a.panicHooks, hook)
+}
+
+// reportPanic is the middleware.PanicHandler of requests served by the app
+func (a *App) reportPanic(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) {
+	a.hooksMu.Lock()
+	hooks := append([]PanicHookFunc(nil), a.panicHooks...)
+	a.hooksMu.Unlock()
+
+	c := NewContext(w, r)
+	for _, hook := range hooks {
+		hook(c, recovered, stack)
+	}
+}
+
+func (a *App) runStartHooks(ctx context.Context) error {
+	if err := a.applyPolicies(); err != nil {
+		return err
+	}
+	if a.config.DependencyWait > 0 {
+		if err := a.WaitForDependencies(ctx, a.config.DependencyWait); err != nil {
+			return err
+		}
+	}
+
+	a.hooksMu.Lock()
+	hooks := append([]HookFunc(nil), a.startHooks...)
+	a.hooksMu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("start hook %s failed: %w", funcName(hook), err)
+		}
+	}
+	return nil
+}
+
+// runShutdownHooks runs every shutdown hook once, even if some fail
+func (a *App) runShutdownHooks(ctx context.Context) error {
+	a.hooksMu.Lock()
+	hooks := a.shutdownHooks
+	a.shutdownHooks = nil
+	a.hooksMu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			logrus.Errorf("Shutdown hook %s failed: %v", funcName(hooks[i]), err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// dependencyTimeout bounds each dependency check in the startup report
+const dependencyTimeout = 5 * time.Second
+
+// Info describes the running application
+type Info struct {
+	Name         string             `json:"name"`
+	Address      string             `json:"address"`
+	StartedAt    time.Time          `json:"started_at,omitempty"`
+	ConfigFile   string             `json:"config_file,omitempty"`
+	DevelopMode  bool               `json:"develop_mode"`
+	Build        BuildInfo          `json:"build"`
+	Routes       int                `json:"routes"`
+	Middleware   []string           `json:"middleware"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// BuildInfo is read from the binary's embedded module and VCS information
+type BuildInfo struct {
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// DependencyStatus is the result of checking a dependency
+type DependencyStatus struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"latency_ns"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type dependency struct {
+	name  string
+	check HookFunc
+}
+
+// AddDependency registers a connection checked in the startup report and
+// the info endpoint, e.g.
+//
+//	a.AddDependency("database", database.MustGet("main").Health)
+//	a.AddDependency("redis", cache.MustGet("default").Ping)
+func (a *App) AddDependency(name string, check HookFunc) {
+	a.hooksMu.Lock()
+	defer a.hooksMu.Unlock()
+	a.dependencies = append(a.dependencies, dependency{name: name, check: check})
+}
+
+// Info collects the application report, checking every dependency
+func (a *App) Info(ctx context.Context) Info {
+	info := Info{
+		Name:         a.config.Name,
+		Address:      a.address(),
+		StartedAt:    a.startedAt,
+		ConfigFile:   viper.ConfigFileUsed(),
+		DevelopMode:  viper.GetBool("develop_mode"),
+		Build:        readBuildInfo(),
+		Routes:       len(a.Routes()),
+		Middleware:   middlewareNames(a.middleware),
+		Dependencies: a.checkDependencies(ctx),
+	}
+	return info
+}
+
+// InfoHandler serves the application report as JSON. It reveals build and
+// configuration details, so mount it on an internal route:
+//
+//	a.Router().Handle("/debug/info", a.InfoHandler()).Methods("GET")
+func (a *App) InfoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		_ = json.NewEncoder(w).Encode(a.Info(r.Context()))
+	})
+}
+
+// checkDependencies runs all checks concurrently, sorted by name
+func (a *App) checkDependencies(ctx context.Context) []DependencyStatus {
+	a.hooksMu.Lock()
+	deps := append([]dependency(nil), a.dependencies...)
+	a.hooksMu.Unlock()
+
+	return checkAll(ctx, deps)
+}
+
+func checkAll(ctx context.Context, deps []dependency) []DependencyStatus {
+	statuses := make([]DependencyStatus, len(deps))
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, dependencyTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := dep.check(checkCtx)
+			statuses[i] = DependencyStatus{Name: dep.name, Healthy: err == nil, Latency: time.Since(start)}
+			if err != nil {
+				statuses[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// logStartupReport logs the application report once the start hooks ran
+func (a *App) logStartupReport(ctx context.Context, address string) {
+	a.startedAt = time.Now()
+	info := a.Info(ctx)
+	if address != "" {
+		info.Address = address
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"app":          info.Name,
+		"address":      info.Address,
+		"config_file":  info.ConfigFile,
+		"develop_mode": info.DevelopMode,
+		"go_version":   info.Build.GoVersion,
+		"version":      info.Build.Version,
+		"revision":     info.Build.Revision,
+		"routes":       info.Routes,
+		"middleware":   info.Middleware,
+	}).Info("Startup report")
+	a.logMiddlewareWarnings()
+
+	for _, dep := range info.Dependencies {
+		entry := logrus.WithFields(logrus.Fields{"dependency": dep.Name, "latency": dep.Latency.String()})
+		if dep.Healthy {
+			entry.Info("Dependency ready")
+		} else {
+			entry.WithField("error", dep.Error).Warn("Dependency unavailable")
+		}
+	}
+}
+
+func readBuildInfo() BuildInfo {
+	info := BuildInfo{GoVersion: runtime.Version()}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = bi.Main.Path
+	info.Version = bi.Main.Version
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.Time = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
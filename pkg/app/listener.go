@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Network types for Config.Network
+const (
+	NetworkTCP     = "tcp"
+	NetworkUnix    = "unix"
+	NetworkSystemd = "systemd"
+)
+
+// systemd passes activated sockets starting at file descriptor 3
+const systemdListenFDStart = 3
+
+// Serve serves the application on an existing listener, e.g. one inherited
+// from a process manager. It blocks until the server is shut down.
+func (a *App) Serve(listener net.Listener) error {
+	if err := a.runStartHooks(context.Background()); err != nil {
+		return err
+	}
+	a.logStartupReport(context.Background(), listener.Addr().String())
+	a.server = a.newServer()
+	if err := a.startAdmin(func(err error) { logrus.Errorf("Admin server error: %v", err) }); err != nil {
+		_ = a.runShutdownHooks(context.Background())
+		return err
+	}
+
+	logrus.Infof("Starting %s on %s", a.config.Name, listener.Addr())
+	if err := a.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		ctx, cancel := context.WithTimeout(context.Background(), a.config.ShutdownTimeout)
+		defer cancel()
+		a.shutdownAdmin(ctx)
+		return err
+	}
+	return nil
+}
+
+// listen opens the listener described by Network, Socket and Port
+func (a *App) listen() (net.Listener, error) {
+	switch a.config.Network {
+	case "", NetworkTCP:
+		return net.Listen("tcp", a.config.Port)
+
+	case NetworkUnix:
+		if a.config.Socket == "" {
+			return nil, errors.New("unix network requires Config.Socket")
+		}
+		// Remove a socket left behind by a previous run, but never a
+		// regular file or directory the path points at by mistake
+		if info, err := os.Lstat(a.config.Socket); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, fmt.Errorf("%s exists and is not a socket", a.config.Socket)
+			}
+			if err := os.Remove(a.config.Socket); err != nil {
+				return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		return net.Listen("unix", a.config.Socket)
+
+	case NetworkSystemd:
+		return systemdListener()
+
+	default:
+		return nil, fmt.Errorf("unsupported network: %s", a.config.Network)
+	}
+}
+
+// address describes where the server listens, for logging
+func (a *App) address() string {
+	switch a.config.Network {
+	case NetworkUnix:
+		return "unix:" + a.config.Socket
+	case NetworkSystemd:
+		return "systemd socket"
+	default:
+		return a.config.Port
+	}
+}
+
+func systemdListener() (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("no systemd socket passed to this process")
+	}
+	if n, err := strconv.Atoi(os.Getenv("LISTEN_FDS")); err != nil || n < 1 {
+		return nil, errors.New("no systemd socket passed to this process")
+	}
+
+	f := os.NewFile(systemdListenFDStart, "systemd-socket")
+	defer f.Close()
+	return net.FileListener(f)
+}
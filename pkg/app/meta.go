@@ -0,0 +1,127 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/binding"
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+// RouteOption sets route metadata, read by middleware such as
+// middleware.Scopes, middleware.RateLimitBy and middleware.FeatureWith
+type RouteOption func(meta *middleware.RouteMeta)
+
+// Scopes requires OAuth scopes, added to those of the parent group
+func Scopes(scopes ...string) RouteOption {
+	return func(meta *middleware.RouteMeta) {
+		meta.Scopes = append(meta.Scopes, scopes...)
+	}
+}
+
+// RateLimitClass sets the rate limit class, e.g. "search" or "auth"
+func RateLimitClass(class string) RouteOption {
+	return func(meta *middleware.RouteMeta) {
+		meta.RateLimit = class
+	}
+}
+
+// FeatureFlag gates the route on a feature flag
+func FeatureFlag(flag string) RouteOption {
+	return func(meta *middleware.RouteMeta) {
+		meta.Feature = flag
+	}
+}
+
+// CacheTTL sets how long middleware.ResponseCache keeps the responses of
+// the route, negative for not at all
+func CacheTTL(ttl time.Duration) RouteOption {
+	return func(meta *middleware.RouteMeta) {
+		meta.CacheTTL = ttl
+	}
+}
+
+// Summary sets the one-line description listed by Routes
+func Summary(summary string) RouteOption {
+	return func(meta *middleware.RouteMeta) {
+		meta.Summary = summary
+	}
+}
+
+// Tags adds docs tags
+func Tags(tags ...string) RouteOption {
+	return func(meta *middleware.RouteMeta) {
+		meta.Tags = append(meta.Tags, tags...)
+	}
+}
+
+// QueryParams documents the query parameters of the route from the form,
+// validate and doc tags of params, the struct its handler passes to
+// Context.BindQuery
+func QueryParams(params interface{}) RouteOption {
+	return func(meta *middleware.RouteMeta) {
+		meta.Parameters = append(meta.Parameters, binding.QueryParameters(params)...)
+	}
+}
+
+// Meta sets a custom metadata value for application middleware
+func Meta(key string, value interface{}) RouteOption {
+	return func(meta *middleware.RouteMeta) {
+		if meta.Extra == nil {
+			meta.Extra = make(map[string]interface{})
+		}
+		meta.Extra[key] = value
+	}
+}
+
+// With returns a group on the same prefix whose routes carry the metadata
+// of options, in addition to that of the group:
+//
+//	api.With(app.Scopes("orders:write"), app.RateLimitClass("writes")).POST("/orders", create)
+func (g *RouteGroup) With(options ...RouteOption) *RouteGroup {
+	meta := g.meta.Clone()
+	for _, option := range options {
+		option(&meta)
+	}
+	return &RouteGroup{
+		router:     g.router,
+		middleware: append([]MiddlewareFunc(nil), g.middleware...),
+		meta:       meta,
+		cors:       g.cors,
+		container:  g.container,
+		app:        g.app,
+	}
+}
+
+// With adds metadata to the route. Call it before the server starts.
+func (r *Route) With(options ...RouteOption) *Route {
+	for _, option := range options {
+		option(r.meta)
+	}
+	return r
+}
+
+// Meta returns the route metadata
+func (r *Route) Meta() middleware.RouteMeta {
+	return r.meta.Clone()
+}
+
+// withMeta wraps the route handler h so the route metadata reaches the
+// group middleware through the request context. The returned metadata is
+// read per request, so Route.With applies after registration.
+func (g *RouteGroup) withMeta(h http.Handler) (http.Handler, *middleware.RouteMeta) {
+	meta := g.meta.Clone()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(middleware.WithRouteMeta(r.Context(), &meta)))
+	}), &meta
+}
+
+// recordRequest wraps the route handler h, inside the group middleware, so
+// middleware.Logger sees the request as h does, with the route and the
+// claims set by authentication middleware
+func recordRequest(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.RecordRequest(r)
+		h.ServeHTTP(w, r)
+	})
+}
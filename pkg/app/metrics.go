@@ -0,0 +1,32 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterMetrics registers app-specific collectors, e.g. queue depth or
+// business counters, in Config.MetricsRegistry
+func (a *App) RegisterMetrics(collectors ...prometheus.Collector) error {
+	registerer := prometheus.DefaultRegisterer
+	if a.config.MetricsRegistry != nil {
+		registerer = a.config.MetricsRegistry
+	}
+	for _, c := range collectors {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MetricsHandler serves the metrics of Config.MetricsRegistry in the
+// Prometheus text format
+func (a *App) MetricsHandler() http.Handler {
+	if a.config.MetricsRegistry != nil {
+		return middleware.MetricsHandlerFor(a.config.MetricsRegistry)
+	}
+	return middleware.MetricsHandler()
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestApp_Metrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	a := New(&Config{MetricsRegistry: registry})
+	a.Use(middleware.MetricsWith(middleware.MetricsConfig{Registry: registry}))
+	a.Group("/api").GET("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("order"))
+	})
+
+	queued := prometheus.NewGauge(prometheus.GaugeOpts{Name: "orders_queued", Help: "Orders waiting"})
+	if err := a.RegisterMetrics(queued); err != nil {
+		t.Fatal(err)
+	}
+	queued.Set(3)
+	if err := a.RegisterMetrics(queued); err == nil {
+		t.Error("expected an error registering a collector twice")
+	}
+
+	handler := a.buildHandler()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/orders/7", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/missing", nil))
+
+	rec := httptest.NewRecorder()
+	a.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",path="/api/orders/{id}",status="2xx"} 1`,
+		`http_requests_total{method="GET",path="unmatched",status="4xx"} 1`,
+		"orders_queued 3",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics output to contain %s, got:\n%s", want, body)
+		}
+	}
+}
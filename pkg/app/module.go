@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/polymatx/goframe/pkg/container"
+)
+
+// Module is a self-contained feature (billing, auth, admin) mounted under a
+// prefix with App.Mount. Besides routes, a module may implement
+// ModuleMiddleware, ModuleBinder, ModuleStarter and ModuleStopper.
+type Module interface {
+	Routes(g *RouteGroup)
+}
+
+// ModuleMiddleware is implemented by modules whose routes share middleware
+type ModuleMiddleware interface {
+	Middleware() []MiddlewareFunc
+}
+
+// ModuleBinder is implemented by modules providing services to the container
+type ModuleBinder interface {
+	Bind(c *container.Container) error
+}
+
+// ModuleStarter is implemented by modules with a start hook, e.g. to warm
+// caches or start workers
+type ModuleStarter interface {
+	Start(ctx context.Context) error
+}
+
+// ModuleStopper is implemented by modules with a shutdown hook
+type ModuleStopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Mount registers a module under prefix: container bindings first, then its
+// routes in a group with the module middleware, then its lifecycle hooks.
+//
+//	a.Mount("/billing", billing.NewModule(db))
+func (a *App) Mount(prefix string, module Module) error {
+	if b, ok := module.(ModuleBinder); ok {
+		if err := b.Bind(a.container); err != nil {
+			return fmt.Errorf("module %T: bind failed: %w", module, err)
+		}
+	}
+
+	var middleware []MiddlewareFunc
+	if m, ok := module.(ModuleMiddleware); ok {
+		middleware = m.Middleware()
+	}
+	module.Routes(a.Group(prefix, middleware...))
+
+	if s, ok := module.(ModuleStarter); ok {
+		a.OnStart(s.Start)
+	}
+	if s, ok := module.(ModuleStopper); ok {
+		a.OnShutdown(s.Stop)
+	}
+
+	return nil
+}
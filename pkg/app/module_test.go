@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/container"
+)
+
+type testModule struct {
+	events  []string
+	bindErr error
+}
+
+func (m *testModule) Routes(g *RouteGroup) {
+	g.GET("/invoices", func(w http.ResponseWriter, r *http.Request) {
+		svc := g.container.MustResolve("billing").(string)
+		_, _ = w.Write([]byte(svc))
+	})
+}
+
+func (m *testModule) Middleware() []MiddlewareFunc {
+	return []MiddlewareFunc{func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Module", "billing")
+			next.ServeHTTP(w, r)
+		})
+	}}
+}
+
+func (m *testModule) Bind(c *container.Container) error {
+	if m.bindErr != nil {
+		return m.bindErr
+	}
+	return c.Bind("billing", "billing service")
+}
+
+func (m *testModule) Start(ctx context.Context) error {
+	m.events = append(m.events, "start")
+	return nil
+}
+
+func (m *testModule) Stop(ctx context.Context) error {
+	m.events = append(m.events, "stop")
+	return nil
+}
+
+// routesOnly implements only the required Module method
+type routesOnly struct{}
+
+func (routesOnly) Routes(g *RouteGroup) {
+	g.GET("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+func TestApp_Mount(t *testing.T) {
+	a := New(nil)
+	m := &testModule{}
+	if err := a.Mount("/billing", m); err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+	if err := a.Mount("/status", routesOnly{}); err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	a.buildHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/billing/invoices", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "billing service" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Module") != "billing" {
+		t.Error("expected module middleware to run")
+	}
+
+	rec = httptest.NewRecorder()
+	a.buildHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/", nil))
+	if rec.Body.String() != "ok" || rec.Header().Get("X-Module") != "" {
+		t.Errorf("unexpected response for routes-only module: %q %v", rec.Body.String(), rec.Header())
+	}
+
+	if err := a.runStartHooks(context.Background()); err != nil {
+		t.Fatalf("start hooks: %v", err)
+	}
+	if err := a.runShutdownHooks(context.Background()); err != nil {
+		t.Fatalf("shutdown hooks: %v", err)
+	}
+	if len(m.events) != 2 || m.events[0] != "start" || m.events[1] != "stop" {
+		t.Errorf("unexpected lifecycle events %v", m.events)
+	}
+}
+
+func TestApp_Mount_BindError(t *testing.T) {
+	a := New(nil)
+	err := a.Mount("/billing", &testModule{bindErr: errors.New("no db")})
+	if err == nil {
+		t.Fatal("expected bind error")
+	}
+	if len(a.Routes()) != 0 {
+		t.Errorf("expected no routes after a failed mount, got %v", a.Routes())
+	}
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/binding"
+)
+
+// Typed accessors return an *HTTPError with status 400 when the value is
+// missing or malformed, so handlers can return it as is. Malformed query
+// values are a *binding.ParamError instead, which Error renders as a 400
+// naming the parameter and the expected type, like BindQuery:
+//
+//	id, err := c.ParamInt("id")
+//	if err != nil {
+//		return err
+//	}
+
+// ParamInt returns URL parameter as int
+func (c *Context) ParamInt(name string) (int, error) {
+	v, err := c.param(name)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt("path parameter", name, v)
+}
+
+// ParamIntDefault returns URL parameter as int, or def when missing or invalid
+func (c *Context) ParamIntDefault(name string, def int) int {
+	if n, err := c.ParamInt(name); err == nil {
+		return n
+	}
+	return def
+}
+
+// ParamUint returns URL parameter as uint, e.g. for database IDs
+func (c *Context) ParamUint(name string) (uint, error) {
+	v, err := c.param(name)
+	if err != nil {
+		return 0, err
+	}
+	return parseUint("path parameter", name, v)
+}
+
+// ParamUintDefault returns URL parameter as uint, or def when missing or invalid
+func (c *Context) ParamUintDefault(name string, def uint) uint {
+	if n, err := c.ParamUint(name); err == nil {
+		return n
+	}
+	return def
+}
+
+// ParamUUID returns URL parameter as a canonical lowercase UUID string
+func (c *Context) ParamUUID(name string) (string, error) {
+	v, err := c.param(name)
+	if err != nil {
+		return "", err
+	}
+	if !isUUID(v) {
+		return "", invalidValue("path parameter", name, "a UUID")
+	}
+	return strings.ToLower(v), nil
+}
+
+// QueryInt returns query parameter as int
+func (c *Context) QueryInt(name string) (int, error) {
+	v, err := c.queryValue(name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, queryError(name, v, "integer", "", err)
+	}
+	return n, nil
+}
+
+// QueryIntDefault returns query parameter as int, or def when missing. An
+// invalid value is still an error so typos do not silently fall back.
+func (c *Context) QueryIntDefault(name string, def int) (int, error) {
+	if c.query.Get(name) == "" {
+		return def, nil
+	}
+	return c.QueryInt(name)
+}
+
+// QueryBool returns query parameter as bool. Besides strconv.ParseBool
+// values it accepts on/off and yes/no.
+func (c *Context) QueryBool(name string) (bool, error) {
+	v, err := c.queryValue(name)
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(v) {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, queryError(name, v, "boolean", "", err)
+	}
+	return b, nil
+}
+
+// QueryBoolDefault returns query parameter as bool, or def when missing
+func (c *Context) QueryBoolDefault(name string, def bool) (bool, error) {
+	if c.query.Get(name) == "" {
+		return def, nil
+	}
+	return c.QueryBool(name)
+}
+
+// QueryTime returns query parameter as time. RFC 3339 timestamps and
+// 2006-01-02 dates are accepted; dates are midnight in the request timezone.
+func (c *Context) QueryTime(name string) (time.Time, error) {
+	v, err := c.queryValue(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, v, c.Location())
+	if err != nil {
+		return time.Time{}, queryError(name, v, "string", "date-time", err)
+	}
+	return t, nil
+}
+
+// QueryTimeDefault returns query parameter as time, or def when missing
+func (c *Context) QueryTimeDefault(name string, def time.Time) (time.Time, error) {
+	if c.query.Get(name) == "" {
+		return def, nil
+	}
+	return c.QueryTime(name)
+}
+
+func (c *Context) param(name string) (string, error) {
+	v, ok := c.params[name]
+	if !ok || v == "" {
+		return "", NewHTTPError(http.StatusBadRequest, fmt.Sprintf("missing path parameter %q", name))
+	}
+	return v, nil
+}
+
+func (c *Context) queryValue(name string) (string, error) {
+	v := c.query.Get(name)
+	if v == "" {
+		return "", NewHTTPError(http.StatusBadRequest, fmt.Sprintf("missing query parameter %q", name))
+	}
+	return v, nil
+}
+
+func parseInt(kind, name, v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, invalidValue(kind, name, "an integer")
+	}
+	return n, nil
+}
+
+func parseUint(kind, name, v string) (uint, error) {
+	n, err := strconv.ParseUint(v, 10, strconv.IntSize)
+	if err != nil {
+		return 0, invalidValue(kind, name, "a non-negative integer")
+	}
+	return uint(n), nil
+}
+
+func invalidValue(kind, name, want string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s %q: must be %s", kind, name, want))
+}
+
+func queryError(name, value, typ, format string, err error) *binding.ParamError {
+	return &binding.ParamError{In: "query", Name: name, Type: typ, Format: format, Value: value, Err: err}
+}
+
+// isUUID reports whether s has the 8-4-4-4-12 hexadecimal UUID form
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			c := s[i]
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
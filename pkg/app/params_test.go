@@ -0,0 +1,137 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/binding"
+)
+
+func newParamsContext(target string, vars map[string]string) *Context {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if vars != nil {
+		r = mux.SetURLVars(r, vars)
+	}
+	return NewContext(httptest.NewRecorder(), r)
+}
+
+func assertBadRequest(t *testing.T, err error) {
+	t.Helper()
+	var he *HTTPError
+	if !errors.As(err, &he) || he.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 HTTPError, got %v", err)
+	}
+}
+
+func assertQueryError(t *testing.T, err error, name, typ string) {
+	t.Helper()
+	var pe *binding.ParamError
+	if !errors.As(err, &pe) || pe.In != "query" || pe.Name != name || pe.Type != typ {
+		t.Errorf("expected ParamError for %s %s, got %v", typ, name, err)
+	}
+}
+
+func TestContext_ParamAccessors(t *testing.T) {
+	c := newParamsContext("/", map[string]string{
+		"id":   "42",
+		"neg":  "-7",
+		"name": "abc",
+		"uuid": "3F2504E0-4F89-11D3-9A0C-0305E82C3301",
+	})
+
+	if n, err := c.ParamInt("id"); err != nil || n != 42 {
+		t.Errorf("ParamInt(id) = %d, %v", n, err)
+	}
+	if n, err := c.ParamInt("neg"); err != nil || n != -7 {
+		t.Errorf("ParamInt(neg) = %d, %v", n, err)
+	}
+	_, err := c.ParamInt("name")
+	assertBadRequest(t, err)
+	_, err = c.ParamInt("missing")
+	assertBadRequest(t, err)
+	if n := c.ParamIntDefault("name", 5); n != 5 {
+		t.Errorf("ParamIntDefault = %d, want 5", n)
+	}
+
+	if n, err := c.ParamUint("id"); err != nil || n != 42 {
+		t.Errorf("ParamUint(id) = %d, %v", n, err)
+	}
+	_, err = c.ParamUint("neg")
+	assertBadRequest(t, err)
+	if n := c.ParamUintDefault("neg", 1); n != 1 {
+		t.Errorf("ParamUintDefault = %d, want 1", n)
+	}
+
+	if id, err := c.ParamUUID("uuid"); err != nil || id != "3f2504e0-4f89-11d3-9a0c-0305e82c3301" {
+		t.Errorf("ParamUUID = %q, %v", id, err)
+	}
+	_, err = c.ParamUUID("name")
+	assertBadRequest(t, err)
+}
+
+func TestContext_QueryAccessors(t *testing.T) {
+	c := newParamsContext("/?page=3&bad=x&on=on&flag=false&from=2024-05-01T10:00:00Z&day=2024-05-01", nil)
+
+	if n, err := c.QueryInt("page"); err != nil || n != 3 {
+		t.Errorf("QueryInt(page) = %d, %v", n, err)
+	}
+	_, err := c.QueryInt("bad")
+	assertQueryError(t, err, "bad", "integer")
+	_, err = c.QueryInt("missing")
+	assertBadRequest(t, err)
+
+	if n, err := c.QueryIntDefault("limit", 20); err != nil || n != 20 {
+		t.Errorf("QueryIntDefault(limit) = %d, %v", n, err)
+	}
+	_, err = c.QueryIntDefault("bad", 20)
+	assertQueryError(t, err, "bad", "integer")
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"on", true},
+		{"flag", false},
+	}
+	for _, tt := range tests {
+		if b, err := c.QueryBool(tt.name); err != nil || b != tt.want {
+			t.Errorf("QueryBool(%s) = %v, %v", tt.name, b, err)
+		}
+	}
+	_, err = c.QueryBool("bad")
+	assertQueryError(t, err, "bad", "boolean")
+	if b, err := c.QueryBoolDefault("missing", true); err != nil || !b {
+		t.Errorf("QueryBoolDefault = %v, %v", b, err)
+	}
+
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if got, err := c.QueryTime("from"); err != nil || !got.Equal(want) {
+		t.Errorf("QueryTime(from) = %v, %v", got, err)
+	}
+	if got, err := c.QueryTime("day"); err != nil || !got.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("QueryTime(day) = %v, %v", got, err)
+	}
+	_, err = c.QueryTime("bad")
+	assertQueryError(t, err, "bad", "string")
+	if got, err := c.QueryTimeDefault("until", want); err != nil || !got.Equal(want) {
+		t.Errorf("QueryTimeDefault = %v, %v", got, err)
+	}
+}
+
+func TestContext_QueryErrorResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := NewContext(rec, httptest.NewRequest(http.MethodGet, "/?from=yesterday", nil))
+
+	_, err := c.QueryTime("from")
+	DefaultErrorHandler(c, err)
+
+	want := `{"error":"invalid query parameter \"from\": must be an RFC 3339 time or YYYY-MM-DD date","expected":"string","format":"date-time","parameter":"from"}`
+	if rec.Code != http.StatusBadRequest || strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("got %d %s", rec.Code, rec.Body.String())
+	}
+}
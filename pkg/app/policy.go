@@ -0,0 +1,203 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/spf13/viper"
+)
+
+// RoutePolicies declare route metadata in the config file, so operators
+// tune scopes, rate limit classes, cache TTLs and CORS without code changes:
+//
+//	route_policies:
+//	  cors_profiles:
+//	    public:
+//	      allowed_origins: ["*"]
+//	  routes:
+//	    - path: /api/orders/**
+//	      methods: [POST, PUT]
+//	      scopes: [orders:write]
+//	      rate_limit: writes
+//	    - path: /api/catalog/*
+//	      cache_ttl: 5m
+//	      cors: public
+//
+// They are applied when the server starts, on top of the metadata set in
+// code: scopes are added, the other values replaced, later rules winning.
+type RoutePolicies struct {
+	CORSProfiles map[string]middleware.CORSConfig `mapstructure:"cors_profiles"`
+	Rules        []RoutePolicy                    `mapstructure:"routes"`
+}
+
+// RoutePolicy sets the metadata of the routes matching Path and Methods.
+// The metadata is enforced by the middleware of the route groups:
+// middleware.Scopes, RateLimitBy classes and ResponseCache. CORS profiles
+// apply to the route and its preflight requests.
+type RoutePolicy struct {
+	// Path matches route templates, e.g. "/api/orders/{id}", with * for a
+	// segment and a trailing /** for any below
+	Path    string   `mapstructure:"path"`
+	Methods []string `mapstructure:"methods"` // Any method when empty
+
+	Scopes    []string      `mapstructure:"scopes"`
+	RateLimit string        `mapstructure:"rate_limit"`
+	CacheTTL  time.Duration `mapstructure:"cache_ttl"`
+	CORS      string        `mapstructure:"cors"` // Name of a CORS profile
+}
+
+// LoadPolicies reads RoutePolicies from the config key, e.g.
+// "route_policies"; nil when the key is not set
+func LoadPolicies(key string) (*RoutePolicies, error) {
+	if !viper.IsSet(key) {
+		return nil, nil
+	}
+	var policies RoutePolicies
+	if err := viper.UnmarshalKey(key, &policies); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return &policies, nil
+}
+
+// applyPolicies applies Config.Policies to the registered routes, once. A
+// rule matching no route, naming an unknown CORS profile, or requiring
+// scopes of routes without middleware.Scopes fails startup.
+func (a *App) applyPolicies() error {
+	policies := a.config.Policies
+	if policies == nil || a.policiesApplied {
+		return nil
+	}
+
+	type target struct {
+		route    *Route
+		template string
+		methods  []string
+	}
+	var targets []target
+	for muxRoute, route := range a.routes {
+		template, err := muxRoute.GetPathTemplate()
+		if err != nil {
+			continue
+		}
+		methods, _ := muxRoute.GetMethods()
+		targets = append(targets, target{route, template, methods})
+	}
+	// Registration order does not matter, but errors should be stable
+	slices.SortFunc(targets, func(x, y target) int { return strings.Compare(x.template, y.template) })
+
+	var errs []error
+	for i, rule := range policies.Rules {
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, fmt.Errorf("route policy %d (%s): %s", i, rule.Path, fmt.Sprintf(format, args...)))
+		}
+		if !strings.HasPrefix(rule.Path, "/") {
+			fail("path must start with /")
+			continue
+		}
+		if _, err := path.Match(strings.TrimSuffix(rule.Path, "/**"), ""); err != nil {
+			fail("%v", err)
+			continue
+		}
+		if _, ok := policies.CORSProfiles[rule.CORS]; rule.CORS != "" && !ok {
+			fail("unknown CORS profile %q", rule.CORS)
+			continue
+		}
+
+		matched := 0
+		for _, t := range targets {
+			if !matchPolicyPath(rule.Path, t.template) || !matchPolicyMethods(rule.Methods, t.methods) {
+				continue
+			}
+			matched++
+			meta := t.route.meta
+			for _, scope := range rule.Scopes {
+				if !slices.Contains(meta.Scopes, scope) {
+					meta.Scopes = append(meta.Scopes, scope)
+				}
+			}
+			if rule.RateLimit != "" {
+				meta.RateLimit = rule.RateLimit
+			}
+			if rule.CacheTTL != 0 {
+				meta.CacheTTL = rule.CacheTTL
+			}
+			if rule.CORS != "" {
+				meta.CORS = rule.CORS
+			}
+
+			if len(rule.Scopes) > 0 && !slices.Contains(middlewareNames(t.route.middleware), "middleware.Scopes") {
+				fail("%s sets scopes but its group lacks middleware.Scopes", t.template)
+			}
+			if rule.CORS != "" && t.route.group.cors != nil {
+				fail("%s has the CORS policy of its group", t.template)
+			}
+		}
+		if matched == 0 {
+			fail("matches no route")
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	profiles := make(map[string]MiddlewareFunc)
+	for _, t := range targets {
+		name := t.route.meta.CORS
+		if name == "" {
+			continue
+		}
+		cors, ok := profiles[name]
+		if !ok {
+			cors = Named("cors", middleware.CORS(policies.CORSProfiles[name]))
+			profiles[name] = cors
+		}
+		t.route.cors = cors(t.route.handler)
+		t.route.middleware = append([]MiddlewareFunc{cors}, t.route.middleware...)
+		t.route.group.addPreflightWith(cors, t.route.path, t.methods)
+	}
+
+	a.policiesApplied = true
+	return nil
+}
+
+// matchPolicyPath reports whether a RoutePolicy path matches a route template
+func matchPolicyPath(pattern, template string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		if template == prefix || prefix == "" {
+			return true
+		}
+		ok, _ := path.Match(prefix, template)
+		return ok || matchPolicyPrefix(prefix, template)
+	}
+	ok, _ := path.Match(pattern, template)
+	return ok
+}
+
+// matchPolicyPrefix reports whether a leading part of template's segments
+// matches pattern
+func matchPolicyPrefix(pattern, template string) bool {
+	segments := strings.Split(template, "/")
+	n := strings.Count(pattern, "/") + 1
+	if len(segments) <= n {
+		return false
+	}
+	ok, _ := path.Match(pattern, strings.Join(segments[:n], "/"))
+	return ok
+}
+
+func matchPolicyMethods(rule, methods []string) bool {
+	if len(rule) == 0 {
+		return true
+	}
+	for _, m := range rule {
+		if slices.Contains(methods, strings.ToUpper(m)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/spf13/viper"
+)
+
+func TestLoadPolicies(t *testing.T) {
+	if p, err := LoadPolicies("route_policies"); p != nil || err != nil {
+		t.Fatalf("unset key = %v, %v, want nil", p, err)
+	}
+
+	viper.Set("route_policies", map[string]interface{}{
+		"cors_profiles": map[string]interface{}{
+			"public": map[string]interface{}{"allowed_origins": []string{"*"}, "max_age": 600},
+		},
+		"routes": []interface{}{
+			map[string]interface{}{"path": "/api/orders/**", "methods": []string{"POST"}, "scopes": []string{"orders:write"}, "rate_limit": "writes"},
+			map[string]interface{}{"path": "/api/catalog/*", "cache_ttl": "5m", "cors": "public"},
+		},
+	})
+	t.Cleanup(func() { viper.Set("route_policies", nil) })
+
+	p, err := LoadPolicies("route_policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.CORSProfiles["public"]; len(got.AllowedOrigins) != 1 || got.MaxAge != 600 {
+		t.Errorf("profile = %+v", got)
+	}
+	if len(p.Rules) != 2 {
+		t.Fatalf("rules = %+v", p.Rules)
+	}
+	if r := p.Rules[0]; r.Scopes[0] != "orders:write" || r.RateLimit != "writes" || r.Methods[0] != "POST" {
+		t.Errorf("rule 0 = %+v", r)
+	}
+	if r := p.Rules[1]; r.CacheTTL != 5*time.Minute || r.CORS != "public" {
+		t.Errorf("rule 1 = %+v", r)
+	}
+}
+
+func TestApplyPolicies(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(middleware.GetRouteMeta(r.Context()).RateLimit))
+	}
+	a := New(&Config{Policies: &RoutePolicies{
+		CORSProfiles: map[string]middleware.CORSConfig{
+			"public": {AllowedOrigins: []string{"https://shop.example.com"}},
+		},
+		Rules: []RoutePolicy{
+			{Path: "/api/orders/**", Methods: []string{"post"}, Scopes: []string{"orders:write"}, RateLimit: "writes"},
+			{Path: "/api/catalog/*", CORS: "public", CacheTTL: time.Minute},
+		},
+	}})
+	api := a.Group("/api", middleware.Scopes())
+	api.GET("/orders/{id}", ok)
+	api.POST("/orders/{id}/items", ok)
+	api.GET("/catalog/{sku}", ok)
+
+	if err := a.runStartHooks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	handler := a.buildHandler()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		origin      string
+		status      int
+		body        string
+		allowOrigin string
+	}{
+		{"method not covered", "GET", "/api/orders/1", "", 200, "", ""},
+		{"scopes required", "POST", "/api/orders/1/items", "", 401, "", ""},
+		{"CORS profile", "GET", "/api/catalog/1", "https://shop.example.com", 200, "", "https://shop.example.com"},
+		{"CORS preflight", "OPTIONS", "/api/catalog/1", "https://shop.example.com", 204, "", "https://shop.example.com"},
+		{"CORS other origin", "GET", "/api/catalog/1", "https://evil.example.com", 200, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+				r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == 200 && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+		})
+	}
+
+	for _, info := range a.Routes() {
+		switch info.Path {
+		case "/api/orders/{id}/items":
+			if info.Meta == nil || info.Meta.RateLimit != "writes" || info.Meta.Scopes[0] != "orders:write" {
+				t.Errorf("orders meta = %+v", info.Meta)
+			}
+		case "/api/catalog/{sku}":
+			if info.Meta == nil || info.Meta.CORS != "public" || info.Meta.CacheTTL != time.Minute {
+				t.Errorf("catalog meta = %+v", info.Meta)
+			}
+			if info.Middleware[0] != "cors" {
+				t.Errorf("catalog middleware = %v", info.Middleware)
+			}
+		}
+	}
+}
+
+func TestApplyPolicies_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule RoutePolicy
+		want string
+	}{
+		{"no match", RoutePolicy{Path: "/api/users/**"}, "matches no route"},
+		{"relative path", RoutePolicy{Path: "api/**"}, "must start with /"},
+		{"bad pattern", RoutePolicy{Path: "/api/[a"}, "syntax error"},
+		{"unknown profile", RoutePolicy{Path: "/api/**", CORS: "partner"}, `unknown CORS profile "partner"`},
+		{"scopes without middleware", RoutePolicy{Path: "/public/items", Scopes: []string{"items:read"}}, "lacks middleware.Scopes"},
+		{"group CORS", RoutePolicy{Path: "/public/items", CORS: "public"}, "CORS policy of its group"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(&Config{Policies: &RoutePolicies{
+				CORSProfiles: map[string]middleware.CORSConfig{"public": {}},
+				Rules:        []RoutePolicy{tt.rule},
+			}})
+			a.Group("/api", middleware.Scopes()).GET("/orders", func(w http.ResponseWriter, r *http.Request) {})
+			public := a.Group("/public")
+			public.CORS(middleware.CORSConfig{})
+			public.GET("/items", func(w http.ResponseWriter, r *http.Request) {})
+
+			err := a.runStartHooks(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPolicyPath(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		template string
+		want     bool
+	}{
+		{"/api/orders", "/api/orders", true},
+		{"/api/orders", "/api/orders/{id}", false},
+		{"/api/orders/*", "/api/orders/{id}", true},
+		{"/api/orders/*", "/api/orders/{id}/items", false},
+		{"/api/orders/**", "/api/orders", true},
+		{"/api/orders/**", "/api/orders/{id}/items", true},
+		{"/api/orders/**", "/api/ordersx", false},
+		{"/api/*/items", "/api/orders/items", true},
+		{"/api/*/**", "/api/orders/{id}/items", true},
+		{"/**", "/anything/at/all", true},
+	}
+	for _, tt := range tests {
+		if got := matchPolicyPath(tt.pattern, tt.template); got != tt.want {
+			t.Errorf("matchPolicyPath(%q, %q) = %v, want %v", tt.pattern, tt.template, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// debugTokenCookie keeps the pprof index links working after a ?token= login
+const debugTokenCookie = "goframe_debug_token"
+
+// mountDebug registers net/http/pprof under /debug/pprof/ and expvar under
+// /debug/vars, guarded by token when it is set
+func mountDebug(router *mux.Router, token string) {
+	guard := func(h http.HandlerFunc) http.Handler {
+		if token == "" {
+			return h
+		}
+		return debugTokenGuard(token, h)
+	}
+
+	router.Handle("/debug/pprof/cmdline", guard(pprof.Cmdline))
+	router.Handle("/debug/pprof/profile", guard(pprof.Profile))
+	router.Handle("/debug/pprof/symbol", guard(pprof.Symbol))
+	router.Handle("/debug/pprof/trace", guard(pprof.Trace))
+	router.PathPrefix("/debug/pprof/").Handler(guard(pprof.Index))
+	router.Handle("/debug/vars", guard(expvar.Handler().ServeHTTP))
+}
+
+// debugTokenGuard accepts the token as a bearer token or a token query
+// parameter, which also sets a cookie scoped to /debug so the pprof index
+// links work from a browser
+func debugTokenGuard(token string, next http.Handler) http.Handler {
+	valid := func(candidate string) bool {
+		return candidate != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && valid(bearer) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if cookie, err := r.Cookie(debugTokenCookie); err == nil && valid(cookie.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if query := r.URL.Query().Get("token"); valid(query) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     debugTokenCookie,
+				Value:    query,
+				Path:     "/debug",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteStrictMode,
+			})
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"Unauthorized"}`))
+	})
+}
+
+// withPprof serves the debug endpoints in front of the public router when
+// EnablePprof is set and no admin listener carries them
+func (a *App) withPprof(router http.Handler) http.Handler {
+	a.hooksMu.Lock()
+	hasAdmin := false
+	for _, s := range a.admin {
+		hasAdmin = hasAdmin || s.debug
+	}
+	a.hooksMu.Unlock()
+	if !a.config.EnablePprof || hasAdmin {
+		return router
+	}
+
+	debug := mux.NewRouter()
+	mountDebug(debug, a.config.PprofToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/debug/pprof/") || r.URL.Path == "/debug/vars" {
+			debug.ServeHTTP(w, r)
+			return
+		}
+		router.ServeHTTP(w, r)
+	})
+}
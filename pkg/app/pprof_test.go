@@ -0,0 +1,73 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfig_EnablePprof(t *testing.T) {
+	serve := func(a *App, path string, prepare func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if prepare != nil {
+			prepare(req)
+		}
+		rec := httptest.NewRecorder()
+		a.buildHandler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		if rec := serve(New(nil), "/debug/pprof/", nil); rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("public without token", func(t *testing.T) {
+		a := New(&Config{EnablePprof: true})
+		for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/vars"} {
+			if rec := serve(a, path, nil); rec.Code != http.StatusOK {
+				t.Errorf("%s: expected 200, got %d", path, rec.Code)
+			}
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		a := New(&Config{EnablePprof: true, PprofToken: "s3cret"})
+
+		if rec := serve(a, "/debug/vars", nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 without token, got %d", rec.Code)
+		}
+		if rec := serve(a, "/debug/vars?token=wrong", nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 with a wrong token, got %d", rec.Code)
+		}
+		bearer := func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") }
+		if rec := serve(a, "/debug/vars", bearer); rec.Code != http.StatusOK {
+			t.Errorf("expected 200 with bearer token, got %d", rec.Code)
+		}
+
+		rec := serve(a, "/debug/pprof/?token=s3cret", nil)
+		cookies := rec.Result().Cookies()
+		if rec.Code != http.StatusOK || len(cookies) != 1 || cookies[0].Path != "/debug" {
+			t.Fatalf("expected 200 and a debug cookie, got %d %v", rec.Code, cookies)
+		}
+		withCookie := func(r *http.Request) { r.AddCookie(cookies[0]) }
+		if rec := serve(a, "/debug/pprof/heap?debug=1", withCookie); rec.Code != http.StatusOK {
+			t.Errorf("expected the cookie to authorize index links, got %d", rec.Code)
+		}
+	})
+
+	t.Run("moved to admin listener", func(t *testing.T) {
+		a := New(&Config{EnablePprof: true})
+		admin := a.Admin("127.0.0.1:0")
+
+		if rec := serve(a, "/debug/pprof/", nil); rec.Code != http.StatusNotFound {
+			t.Errorf("expected no public pprof with an admin listener, got %d", rec.Code)
+		}
+		rec := httptest.NewRecorder()
+		admin.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected expvar on the admin listener, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/polymatx/goframe/pkg/session"
+)
+
+// URL builds the path of a named route, with params given as name and value
+// pairs:
+//
+//	a.URL("users.show", "id", "42") // /users/42
+func (a *App) URL(name string, params ...string) (string, error) {
+	route := a.router.Get(name)
+	if route == nil {
+		return "", fmt.Errorf("no route named %q", name)
+	}
+	u, err := route.URL(params...)
+	if err != nil {
+		return "", fmt.Errorf("route %q: %w", name, err)
+	}
+	return u.String(), nil
+}
+
+// redirectCode is 302 after GET and HEAD, and 303 otherwise so browsers
+// follow form posts with a GET
+func (c *Context) redirectCode() int {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		return http.StatusFound
+	}
+	return http.StatusSeeOther
+}
+
+// RedirectBack redirects to the Referer when it is on the same host, or to
+// fallback ("/" when empty). Other hosts are ignored to avoid open redirects.
+func (c *Context) RedirectBack(fallback string) error {
+	target := fallback
+	if target == "" {
+		target = "/"
+	}
+	if ref, err := url.Parse(c.Request.Referer()); err == nil && ref.Host == c.Request.Host && ref.Path != "" {
+		target = ref.RequestURI()
+	}
+	return c.Redirect(c.redirectCode(), target)
+}
+
+// RedirectToRoute redirects to a named route, with params given as name and
+// value pairs: c.RedirectToRoute("users.show", "id", "42")
+func (c *Context) RedirectToRoute(name string, params ...string) error {
+	if c.app == nil {
+		return errors.New("route redirects require an App")
+	}
+	target, err := c.app.URL(name, params...)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(c.redirectCode(), target)
+}
+
+// Flash stores a message in the session, read once with Flashes after a
+// redirect. It requires session.Middleware.
+func (c *Context) Flash(key string, value interface{}) error {
+	s, err := session.FromContext(c.Request.Context())
+	if err != nil {
+		return err
+	}
+	s.Flash(key, value)
+	return nil
+}
+
+// Flashes returns and clears the flash messages for key, or nil without a
+// session
+func (c *Context) Flashes(key string) []interface{} {
+	s, err := session.FromContext(c.Request.Context())
+	if err != nil {
+		return nil
+	}
+	return s.Flashes(key)
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+// Route is a route registered through a RouteGroup
+type Route struct {
+	route      *mux.Route
+	middleware []MiddlewareFunc
+	meta       *middleware.RouteMeta
+	autoHead   bool // HEAD was added to a GET route and is not listed
+
+	group   *RouteGroup
+	path    string       // As registered on the group router
+	handler http.Handler // Group middleware and handler
+	cors    http.Handler // handler behind the CORS profile of the route policies
+}
+
+// serve runs the route handler, behind the CORS profile when the route
+// policies set one
+func (r *Route) serve(w http.ResponseWriter, req *http.Request) {
+	if r.cors != nil {
+		r.cors.ServeHTTP(w, req)
+		return
+	}
+	r.handler.ServeHTTP(w, req)
+}
+
+// Name sets the route name used by Routes and URL building
+func (r *Route) Name(name string) *Route {
+	r.route.Name(name)
+	return r
+}
+
+// GetName returns the route name
+func (r *Route) GetName() string {
+	return r.route.GetName()
+}
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Name       string   `json:"name,omitempty"`
+	Middleware []string `json:"middleware"`
+
+	Meta *middleware.RouteMeta `json:"meta,omitempty"`
+
+	descriptors []middleware.Descriptor // Of Middleware, for MiddlewareChains
+}
+
+// Routes returns every route registered on the router in registration order,
+// followed by the routes added with AddRoute. Middleware lists the
+// application middleware followed by the group middleware, outermost first.
+// Routes added directly through Router() only report the application
+// middleware.
+func (a *App) Routes() []RouteInfo {
+	appMiddleware := middlewareDescriptors(a.middleware)
+
+	var infos []RouteInfo
+	_ = a.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil || a.preflightRoutes[route] {
+			return nil
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		chain := append([]middleware.Descriptor{}, appMiddleware...)
+		r, registered := a.routes[route]
+		if registered {
+			chain = append(chain, middlewareDescriptors(r.middleware)...)
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil || len(methods) == 0 {
+			methods = []string{"ANY"}
+		}
+
+		var meta *middleware.RouteMeta
+		if registered && !r.meta.IsZero() {
+			m := r.meta.Clone()
+			meta = &m
+		}
+
+		for _, method := range methods {
+			if registered && r.autoHead && method == http.MethodHead {
+				continue
+			}
+			infos = append(infos, RouteInfo{
+				Method:     method,
+				Path:       path,
+				Name:       route.GetName(),
+				Middleware: descriptorNames(chain),
+				Meta:       meta,
+
+				descriptors: chain,
+			})
+		}
+		return nil
+	})
+
+	return append(infos, a.dynamicRouteInfos(appMiddleware)...)
+}
+
+// RoutesHandler serves Routes as JSON, e.g. for `goframe bench --admin`
+func (a *App) RoutesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		_ = json.NewEncoder(w).Encode(a.Routes())
+	})
+}
+
+func middlewareNames(middleware []MiddlewareFunc) []string {
+	names := make([]string, 0, len(middleware))
+	for _, m := range middleware {
+		names = append(names, middlewareName(m))
+	}
+	return names
+}
+
+// middlewareDescriptors returns the descriptors of middleware, undescribed
+// middleware named by its function
+func middlewareDescriptors(chain []MiddlewareFunc) []middleware.Descriptor {
+	descriptors := make([]middleware.Descriptor, 0, len(chain))
+	for _, m := range chain {
+		d, _ := middleware.DescriptorOf(m)
+		if d.Name == "" {
+			d.Name = funcName(m)
+		}
+		descriptors = append(descriptors, d)
+	}
+	return descriptors
+}
+
+func descriptorNames(descriptors []middleware.Descriptor) []string {
+	names := make([]string, 0, len(descriptors))
+	for _, d := range descriptors {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// funcName returns a short name for a function, e.g. "middleware.Logger"
+// for the closure returned by middleware.Logger()
+func funcName(fn interface{}) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	// Strip closure suffixes like ".func1" or ".func1.2"
+	parts := strings.Split(name, ".")
+	for len(parts) > 2 {
+		last := parts[len(parts)-1]
+		if !strings.HasPrefix(last, "func") && strings.Trim(last, "0123456789") != "" {
+			break
+		}
+		parts = parts[:len(parts)-1]
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cloud Run sends SIGTERM and kills the instance 10 seconds later
+const cloudRunGracePeriod = 10 * time.Second
+
+// StartCloudRun serves the application the way Cloud Run and similar
+// container platforms expect: on $PORT (default 8080) on all interfaces,
+// finishing shutdown within the SIGTERM grace period. The port only opens
+// once start hooks have succeeded, so the default TCP startup probe doubles
+// as a readiness check.
+func (a *App) StartCloudRun() error {
+	a.applyCloudRunEnv()
+	return a.StartWithGracefulShutdown()
+}
+
+func (a *App) applyCloudRunEnv() {
+	a.config.Network = NetworkTCP
+	if port := os.Getenv("PORT"); port != "" {
+		a.config.Port = ":" + port
+	} else if a.config.Port == "" {
+		a.config.Port = ":8080"
+	}
+	if a.config.ShutdownTimeout <= 0 || a.config.ShutdownTimeout >= cloudRunGracePeriod {
+		a.config.ShutdownTimeout = cloudRunGracePeriod - time.Second
+	}
+}
+
+// LambdaRequest is an API Gateway proxy integration event. Both REST API
+// (payload 1.0) and HTTP API (payload 2.0) events decode into it.
+type LambdaRequest struct {
+	Version         string               `json:"version,omitempty"`
+	Resource        string               `json:"resource,omitempty"`
+	Headers         map[string]string    `json:"headers,omitempty"`
+	Body            string               `json:"body,omitempty"`
+	IsBase64Encoded bool                 `json:"isBase64Encoded,omitempty"`
+	RequestContext  LambdaRequestContext `json:"requestContext"`
+
+	// Payload 1.0
+	HTTPMethod                      string              `json:"httpMethod,omitempty"`
+	Path                            string              `json:"path,omitempty"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters,omitempty"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters,omitempty"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders,omitempty"`
+
+	// Payload 2.0
+	RawPath        string   `json:"rawPath,omitempty"`
+	RawQueryString string   `json:"rawQueryString,omitempty"`
+	Cookies        []string `json:"cookies,omitempty"`
+}
+
+// LambdaRequestContext holds the request metadata added by API Gateway
+type LambdaRequestContext struct {
+	RequestID string `json:"requestId,omitempty"`
+	Stage     string `json:"stage,omitempty"`
+	Identity  struct {
+		SourceIP string `json:"sourceIp,omitempty"`
+	} `json:"identity"`
+	HTTP struct {
+		Method   string `json:"method,omitempty"`
+		Path     string `json:"path,omitempty"`
+		SourceIP string `json:"sourceIp,omitempty"`
+	} `json:"http"`
+}
+
+// LambdaResponse is an API Gateway proxy integration response
+type LambdaResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// LambdaHandler returns a function serving API Gateway proxy events through
+// the application's routes and middleware. Pass it to lambda.Start from
+// github.com/aws/aws-lambda-go:
+//
+//	lambda.Start(a.LambdaHandler())
+//
+// Start hooks run on the first invocation (the cold start); if they fail,
+// every invocation returns the error.
+func (a *App) LambdaHandler() func(ctx context.Context, req LambdaRequest) (LambdaResponse, error) {
+	var (
+		once    sync.Once
+		handler http.Handler
+		initErr error
+	)
+
+	return func(ctx context.Context, req LambdaRequest) (LambdaResponse, error) {
+		once.Do(func() {
+			if initErr = a.runStartHooks(ctx); initErr == nil {
+				handler = a.buildHandler()
+			}
+		})
+		if initErr != nil {
+			return LambdaResponse{}, initErr
+		}
+
+		r, err := req.httpRequest(ctx)
+		if err != nil {
+			return LambdaResponse{}, err
+		}
+
+		w := &lambdaResponseWriter{header: make(http.Header)}
+		handler.ServeHTTP(w, r)
+		return w.response(req.Version == "2.0"), nil
+	}
+}
+
+func (req LambdaRequest) httpRequest(ctx context.Context) (*http.Request, error) {
+	method, path, sourceIP := req.HTTPMethod, req.Path, req.RequestContext.Identity.SourceIP
+	query := req.RawQueryString
+	if req.Version == "2.0" {
+		method, path, sourceIP = req.RequestContext.HTTP.Method, req.RawPath, req.RequestContext.HTTP.SourceIP
+	} else {
+		values := url.Values{}
+		for k, v := range req.MultiValueQueryStringParameters {
+			values[k] = v
+		}
+		for k, v := range req.QueryStringParameters {
+			if _, ok := values[k]; !ok {
+				values.Set(k, v)
+			}
+		}
+		query = values.Encode()
+	}
+
+	if path == "" {
+		path = "/"
+	}
+	u := &url.URL{Path: path, RawQuery: query}
+
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode request body: %w", err)
+		}
+		body = decoded
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for k, values := range req.MultiValueHeaders {
+		for _, v := range values {
+			r.Header.Add(k, v)
+		}
+	}
+	for k, v := range req.Headers {
+		if r.Header.Get(k) == "" {
+			r.Header.Set(k, v)
+		}
+	}
+	if len(req.Cookies) > 0 {
+		r.Header.Set("Cookie", strings.Join(req.Cookies, "; "))
+	}
+	if req.RequestContext.RequestID != "" && r.Header.Get("X-Request-ID") == "" {
+		r.Header.Set("X-Request-ID", req.RequestContext.RequestID)
+	}
+
+	r.Host = r.Header.Get("Host")
+	r.RequestURI = u.RequestURI()
+	r.ContentLength = int64(len(body))
+	if sourceIP != "" {
+		r.RemoteAddr = net.JoinHostPort(sourceIP, "0")
+	}
+
+	return r, nil
+}
+
+// lambdaResponseWriter buffers the response, since API Gateway expects it in
+// a single event
+type lambdaResponseWriter struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (w *lambdaResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *lambdaResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+}
+
+func (w *lambdaResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(b)
+}
+
+// Flush implements http.Flusher; buffered output is sent when the handler
+// returns
+func (w *lambdaResponseWriter) Flush() {}
+
+func (w *lambdaResponseWriter) response(v2 bool) LambdaResponse {
+	if !w.wroteHeader {
+		w.status = http.StatusOK
+	}
+	if w.header.Get("Content-Type") == "" && w.body.Len() > 0 {
+		w.header.Set("Content-Type", http.DetectContentType(w.body.Bytes()))
+	}
+
+	resp := LambdaResponse{
+		StatusCode: w.status,
+		Headers:    make(map[string]string, len(w.header)),
+	}
+
+	for k, values := range w.header {
+		if v2 && k == "Set-Cookie" {
+			resp.Cookies = values
+			continue
+		}
+		resp.Headers[k] = strings.Join(values, ",")
+		if !v2 && len(values) > 1 {
+			if resp.MultiValueHeaders == nil {
+				resp.MultiValueHeaders = make(map[string][]string)
+			}
+			resp.MultiValueHeaders[k] = values
+		}
+	}
+
+	if isTextual(w.header) {
+		resp.Body = w.body.String()
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		resp.IsBase64Encoded = true
+	}
+
+	return resp
+}
+
+// isTextual reports whether a response body can be passed to API Gateway
+// as a plain string
+func isTextual(header http.Header) bool {
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType == "" || strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+	for _, textual := range []string{"json", "xml", "javascript", "x-www-form-urlencoded", "yaml"} {
+		if strings.Contains(contentType, textual) {
+			return true
+		}
+	}
+	return false
+}
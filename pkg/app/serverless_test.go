@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newLambdaTestApp() *App {
+	a := New(nil)
+	api := a.Group("/api")
+	api.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(w, r)
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "1"})
+		http.SetCookie(w, &http.Cookie{Name: "b", Value: "2"})
+		_ = ctx.JSON(http.StatusOK, map[string]string{
+			"id":     ctx.Param("id"),
+			"q":      ctx.Query("q"),
+			"cookie": ctx.Header("Cookie"),
+			"ip":     ctx.Request.RemoteAddr,
+		})
+	})
+	api.POST("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := NewContext(w, r).Body()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	})
+	return a
+}
+
+func TestApp_LambdaHandler(t *testing.T) {
+	handler := newLambdaTestApp().LambdaHandler()
+
+	t.Run("payload 1.0", func(t *testing.T) {
+		req := LambdaRequest{
+			HTTPMethod:            http.MethodGet,
+			Path:                  "/api/users/42",
+			QueryStringParameters: map[string]string{"q": "go"},
+			Headers:               map[string]string{"Cookie": "session=abc"},
+		}
+		req.RequestContext.Identity.SourceIP = "203.0.113.7"
+
+		resp, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || resp.IsBase64Encoded {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+		want := `{"cookie":"session=abc","id":"42","ip":"203.0.113.7:0","q":"go"}` + "\n"
+		if resp.Body != want {
+			t.Errorf("body = %q, want %q", resp.Body, want)
+		}
+		if got := resp.MultiValueHeaders["Set-Cookie"]; len(got) != 2 {
+			t.Errorf("expected both cookies in multiValueHeaders, got %v", got)
+		}
+	})
+
+	t.Run("payload 2.0", func(t *testing.T) {
+		req := LambdaRequest{
+			Version:        "2.0",
+			RawPath:        "/api/users/7",
+			RawQueryString: "q=lambda",
+			Cookies:        []string{"session=abc", "theme=dark"},
+		}
+		req.RequestContext.HTTP.Method = http.MethodGet
+
+		resp, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := `{"cookie":"session=abc; theme=dark","id":"7","ip":"","q":"lambda"}` + "\n"
+		if resp.Body != want {
+			t.Errorf("body = %q, want %q", resp.Body, want)
+		}
+		if len(resp.Cookies) != 2 || resp.Headers["Set-Cookie"] != "" {
+			t.Errorf("expected cookies in the cookies field, got %v / %v", resp.Cookies, resp.Headers)
+		}
+	})
+
+	t.Run("binary bodies are base64 encoded", func(t *testing.T) {
+		payload := []byte{0x00, 0xff, 0x10}
+		resp, err := handler(context.Background(), LambdaRequest{
+			HTTPMethod:      http.MethodPost,
+			Path:            "/api/echo",
+			Body:            base64.StdEncoding.EncodeToString(payload),
+			IsBase64Encoded: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.StatusCode != http.StatusCreated || !resp.IsBase64Encoded {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+		if resp.Body != base64.StdEncoding.EncodeToString(payload) {
+			t.Errorf("body = %q", resp.Body)
+		}
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		resp, err := handler(context.Background(), LambdaRequest{HTTPMethod: http.MethodGet, Path: "/missing"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestApp_LambdaHandler_StartHooks(t *testing.T) {
+	a := New(nil)
+	calls := 0
+	a.OnStart(func(ctx context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+	handler := a.LambdaHandler()
+
+	for i := 0; i < 2; i++ {
+		if _, err := handler(context.Background(), LambdaRequest{HTTPMethod: http.MethodGet, Path: "/"}); err == nil {
+			t.Fatal("expected start hook error")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected start hooks to run once, ran %d times", calls)
+	}
+}
+
+func TestApp_ApplyCloudRunEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		port         string
+		config       Config
+		wantPort     string
+		wantShutdown time.Duration
+	}{
+		{"port from env", "9000", Config{Port: ":8080", ShutdownTimeout: 5 * time.Second}, ":9000", 5 * time.Second},
+		{"configured port without env", "", Config{Port: ":3000", ShutdownTimeout: 5 * time.Second}, ":3000", 5 * time.Second},
+		{"shutdown capped to grace period", "", Config{Port: ":8080", ShutdownTimeout: 30 * time.Second}, ":8080", 9 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PORT", tt.port)
+			cfg := tt.config
+			a := New(&cfg)
+			a.applyCloudRunEnv()
+
+			if a.config.Port != tt.wantPort {
+				t.Errorf("port = %q, want %q", a.config.Port, tt.wantPort)
+			}
+			if a.config.ShutdownTimeout != tt.wantShutdown {
+				t.Errorf("shutdown timeout = %v, want %v", a.config.ShutdownTimeout, tt.wantShutdown)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticConfig configures static file serving
+type StaticConfig struct {
+	// Index is served for directory requests (default "index.html")
+	Index string
+
+	// Browse enables directory listings when a directory has no index file
+	Browse bool
+
+	// MaxAge sets Cache-Control max-age for files (default 1h). HTML files
+	// are always sent with "no-cache" so new deployments are picked up, and
+	// a negative MaxAge disables caching entirely.
+	MaxAge time.Duration
+}
+
+// Static serves files from dir under prefix, e.g. Static("/assets", "./public")
+func (a *App) Static(prefix, dir string, config ...StaticConfig) *Route {
+	return a.rootGroup().Static(prefix, dir, config...)
+}
+
+// StaticFS serves files from fsys, such as an embed.FS, under prefix
+func (a *App) StaticFS(prefix string, fsys fs.FS, config ...StaticConfig) *Route {
+	return a.rootGroup().StaticFS(prefix, fsys, config...)
+}
+
+// Static serves files from dir under prefix within the group
+func (g *RouteGroup) Static(prefix, dir string, config ...StaticConfig) *Route {
+	return g.StaticFS(prefix, os.DirFS(dir), config...)
+}
+
+// StaticFS serves files from fsys under prefix within the group. Requests go
+// through the group middleware and support Range and conditional requests.
+func (g *RouteGroup) StaticFS(prefix string, fsys fs.FS, config ...StaticConfig) *Route {
+	cfg := StaticConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Index == "" {
+		cfg.Index = "index.html"
+	}
+	if cfg.MaxAge == 0 {
+		cfg.MaxAge = time.Hour
+	}
+
+	route := &Route{
+		route:      g.router.PathPrefix("/"+strings.Trim(prefix, "/")).Methods(http.MethodGet, http.MethodHead),
+		middleware: append([]MiddlewareFunc(nil), g.middleware...),
+	}
+
+	// The group prefix is only known once the route is registered
+	fullPrefix, _ := route.route.GetPathTemplate()
+	h := recordRequest(&staticHandler{fsys: fsys, config: cfg, prefix: fullPrefix})
+	for i := len(g.middleware) - 1; i >= 0; i-- {
+		h = g.middleware[i](h)
+	}
+	h, route.meta = g.withMeta(h)
+	route.route.Handler(h)
+	g.app.routes[route.route] = route
+
+	return route
+}
+
+// rootGroup returns a group registering routes directly on the router
+func (a *App) rootGroup() *RouteGroup {
+	return &RouteGroup{router: a.router, container: a.container, app: a}
+}
+
+type staticHandler struct {
+	fsys   fs.FS
+	config StaticConfig
+	prefix string
+	etags  sync.Map
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rel := strings.TrimPrefix(r.URL.Path, h.prefix)
+	if rel != "" && rel[0] != '/' {
+		if !strings.HasSuffix(h.prefix, "/") {
+			// "/assetsfoo" matched the "/assets" prefix
+			http.NotFound(w, r)
+			return
+		}
+		rel = "/" + rel
+	}
+	name := strings.TrimPrefix(path.Clean(rel), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if info.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		index := path.Join(name, h.config.Index)
+		if indexInfo, err := fs.Stat(h.fsys, index); err == nil && !indexInfo.IsDir() {
+			h.serveFile(w, r, index, indexInfo)
+			return
+		}
+
+		if !h.config.Browse {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		http.StripPrefix(strings.TrimSuffix(h.prefix, "/"), http.FileServerFS(h.fsys)).ServeHTTP(w, r)
+		return
+	}
+
+	h.serveFile(w, r, name, info)
+}
+
+func (h *staticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo) {
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	switch {
+	case h.config.MaxAge < 0:
+		w.Header().Set("Cache-Control", "no-store")
+	case strings.HasSuffix(name, ".html"):
+		w.Header().Set("Cache-Control", "no-cache")
+	default:
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.config.MaxAge.Seconds())))
+	}
+
+	// Embedded files have no modification time, so validate with an ETag instead
+	modTime := info.ModTime()
+	if modTime.IsZero() && w.Header().Get("ETag") == "" {
+		if etag, err := h.etag(name, content); err == nil {
+			w.Header().Set("ETag", etag)
+		}
+	}
+
+	http.ServeContent(w, r, name, modTime, content)
+}
+
+// etag returns a content hash, cached since files without a modification
+// time come from immutable filesystems like embed.FS
+func (h *staticHandler) etag(name string, content io.ReadSeeker) (string, error) {
+	if etag, ok := h.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+
+	hash := fnv.New64a()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := fmt.Sprintf(`"%x"`, hash.Sum64())
+	h.etags.Store(name, etag)
+	return etag, nil
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestApp_Static(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docs", "index.html"), []byte("<h1>docs</h1>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	app := New(nil)
+	app.Static("/assets", dir)
+	handler := app.buildHandler()
+
+	tests := []struct {
+		name         string
+		path         string
+		wantCode     int
+		wantBody     string
+		wantCache    string
+		wantLocation string
+	}{
+		{"file", "/assets/app.css", http.StatusOK, "body{}", "public, max-age=3600", ""},
+		{"index", "/assets/docs/", http.StatusOK, "<h1>docs</h1>", "no-cache", ""},
+		{"directory redirect", "/assets/docs", http.StatusMovedPermanently, "", "", "/assets/docs/"},
+		{"no listing", "/assets/empty/", http.StatusNotFound, "", "", ""},
+		{"missing", "/assets/nope.js", http.StatusNotFound, "", "", ""},
+		{"traversal cleaned by router", "/assets/../app_test.go", http.StatusMovedPermanently, "", "", "/app_test.go"},
+		{"prefix boundary", "/assetsapp.css", http.StatusNotFound, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+			if tt.wantCache != "" && rec.Header().Get("Cache-Control") != tt.wantCache {
+				t.Errorf("expected Cache-Control %q, got %q", tt.wantCache, rec.Header().Get("Cache-Control"))
+			}
+			if tt.wantLocation != "" && rec.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("expected Location %q, got %q", tt.wantLocation, rec.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestRouteGroup_StaticFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":        {Data: []byte("console.log(1)")},
+		"img/logo.svg":  {Data: []byte("<svg/>")},
+		"home/main.htm": {Data: []byte("home")},
+	}
+
+	var groupCalled bool
+	app := New(nil)
+	api := app.Group("/ui", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			groupCalled = true
+			next.ServeHTTP(w, r)
+		})
+	})
+	api.StaticFS("/static", fsys, StaticConfig{Browse: true, Index: "main.htm", MaxAge: 24 * time.Hour})
+	handler := app.buildHandler()
+
+	get := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/ui/static/app.js", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "console.log(1)" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+	if !groupCalled {
+		t.Error("expected group middleware to run")
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=86400" {
+		t.Errorf("unexpected Cache-Control %q", got)
+	}
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag for file without modification time")
+	}
+	if rec := get("/ui/static/app.js", http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for matching ETag, got %d", rec.Code)
+	}
+
+	if rec := get("/ui/static/home/", nil); rec.Body.String() != "home" {
+		t.Errorf("expected custom index, got %q", rec.Body.String())
+	}
+
+	rec = get("/ui/static/img/", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "logo.svg") {
+		t.Errorf("expected directory listing, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/ui/static/app.js", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
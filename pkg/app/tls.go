@@ -0,0 +1,71 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ErrTLSNotConfigured is returned by StartTLS when neither certificate files
+// nor AutoTLSHosts are set
+var ErrTLSNotConfigured = errors.New("tls is not configured: set TLSCertFile/TLSKeyFile or AutoTLSHosts")
+
+// TLSEnabled reports whether certificate files or autocert hosts are configured
+func (c *Config) TLSEnabled() bool {
+	return (c.TLSCertFile != "" && c.TLSKeyFile != "") || len(c.AutoTLSHosts) > 0
+}
+
+func (a *App) listenAndServe(useTLS bool) error {
+	if useTLS && !a.config.TLSEnabled() {
+		return ErrTLSNotConfigured
+	}
+
+	ln, err := a.listen()
+	if err != nil {
+		return err
+	}
+
+	if !useTLS {
+		return a.server.Serve(ln)
+	}
+
+	if a.config.TLSCertFile != "" && a.config.TLSKeyFile != "" {
+		return a.server.ServeTLS(ln, a.config.TLSCertFile, a.config.TLSKeyFile)
+	}
+
+	manager := a.autocertManager()
+	a.server.TLSConfig = manager.TLSConfig()
+
+	if a.config.AutoTLSHTTPAddr != "" {
+		a.challengeServer = &http.Server{
+			Addr:              a.config.AutoTLSHTTPAddr,
+			Handler:           manager.HTTPHandler(nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			logrus.Infof("Serving ACME challenges and HTTPS redirects on %s", a.config.AutoTLSHTTPAddr)
+			if err := a.challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logrus.Errorf("ACME challenge server error: %v", err)
+			}
+		}()
+	}
+
+	return a.server.ServeTLS(ln, "", "")
+}
+
+func (a *App) autocertManager() *autocert.Manager {
+	cacheDir := a.config.AutoTLSCacheDir
+	if cacheDir == "" {
+		cacheDir = "./certs"
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(a.config.AutoTLSHosts...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      a.config.AutoTLSEmail,
+	}
+}
@@ -0,0 +1,258 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// VersionConfig selects how requests are routed to an API version. With no
+// Header and no MediaType the version is a URL prefix (/v1/users).
+type VersionConfig struct {
+	// Header selects the version from a request header, e.g. "API-Version"
+	Header string
+	// MediaType selects the version from the Accept header, e.g.
+	// "application/vnd.acme" matches "application/vnd.acme.v2+json" and
+	// "application/vnd.acme+json; version=v2"
+	MediaType string
+	// Default is served when a header or Accept request names no version
+	// (default: the latest version)
+	Default string
+}
+
+// Deprecation describes a deprecated version or route
+type Deprecation struct {
+	At     time.Time // When it was deprecated; "Deprecation: true" is sent when zero
+	Sunset time.Time // When it stops working, sent as the Sunset header
+	Link   string    // Migration guide, sent as a Link with rel="deprecation"
+}
+
+// Versions holds one route group per API version
+type Versions struct {
+	names  []string
+	groups map[string]*RouteGroup
+
+	mu           *sync.RWMutex
+	deprecations map[string]Deprecation
+}
+
+type versionContextKey struct{}
+
+// APIVersion returns the API version that served the request, or "" outside
+// a versioned group
+func APIVersion(r *http.Request) string {
+	v, _ := r.Context().Value(versionContextKey{}).(string)
+	return v
+}
+
+// Version creates a route group per version under URL prefixes:
+//
+//	v := a.Version("v1", "v2")
+//	v.GET("/users", listUsers)              // /v1/users and /v2/users
+//	v.From("v2").GET("/teams", listTeams)   // /v2/teams only
+//	v.Deprecate("v1", app.Deprecation{Sunset: sunset})
+func (a *App) Version(versions ...string) *Versions {
+	return a.rootGroup().VersionWith(VersionConfig{}, versions...)
+}
+
+// VersionWith creates a route group per version selected as described by
+// config
+func (a *App) VersionWith(config VersionConfig, versions ...string) *Versions {
+	return a.rootGroup().VersionWith(config, versions...)
+}
+
+// Version creates a route group per version under the group prefix, e.g.
+// /api/v1 and /api/v2
+func (g *RouteGroup) Version(versions ...string) *Versions {
+	return g.VersionWith(VersionConfig{}, versions...)
+}
+
+// VersionWith creates a route group per version within the group, selected
+// as described by config
+func (g *RouteGroup) VersionWith(config VersionConfig, versions ...string) *Versions {
+	v := &Versions{
+		names:        versions,
+		groups:       make(map[string]*RouteGroup, len(versions)),
+		mu:           &sync.RWMutex{},
+		deprecations: make(map[string]Deprecation),
+	}
+
+	if config.Default == "" && len(versions) > 0 {
+		config.Default = versions[len(versions)-1]
+	}
+
+	for _, name := range versions {
+		var router *mux.Router
+		if config.Header != "" || config.MediaType != "" {
+			router = g.router.MatcherFunc(versionMatcher(config, name)).Subrouter()
+		} else {
+			router = g.router.PathPrefix("/" + name).Subrouter()
+		}
+
+		middleware := append(append([]MiddlewareFunc(nil), g.middleware...), v.versionMiddleware(name))
+		v.groups[name] = &RouteGroup{
+			router:     router,
+			middleware: middleware,
+			meta:       g.meta.Clone(),
+			cors:       g.cors,
+			container:  g.container,
+			app:        g.app,
+		}
+	}
+
+	return v
+}
+
+func versionMatcher(config VersionConfig, name string) func(*http.Request, *mux.RouteMatch) bool {
+	return func(r *http.Request, _ *mux.RouteMatch) bool {
+		requested := requestedVersion(r, config)
+		if requested == "" {
+			return name == config.Default
+		}
+		return requested == name
+	}
+}
+
+// requestedVersion reads the version named by the request, if any
+func requestedVersion(r *http.Request, config VersionConfig) string {
+	if config.Header != "" {
+		if v := strings.TrimSpace(r.Header.Get(config.Header)); v != "" {
+			return v
+		}
+	}
+	if config.MediaType == "" {
+		return ""
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(accept), ";")
+		mediaType = strings.TrimSpace(mediaType)
+		if !strings.HasPrefix(mediaType, config.MediaType) {
+			continue
+		}
+
+		// application/vnd.acme.v2+json
+		rest := strings.TrimPrefix(mediaType, config.MediaType)
+		if strings.HasPrefix(rest, ".") {
+			version, _, _ := strings.Cut(rest[1:], "+")
+			return version
+		}
+
+		// application/vnd.acme+json; version=v2
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "version") {
+				return strings.Trim(value, `"`)
+			}
+		}
+	}
+	return ""
+}
+
+// versionMiddleware records the version in the request context and adds
+// deprecation headers. Deprecations are looked up per request, so Deprecate
+// may be called before or after routes are registered.
+func (v *Versions) versionMiddleware(name string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v.mu.RLock()
+			d, deprecated := v.deprecations[name]
+			v.mu.RUnlock()
+			if deprecated {
+				setDeprecationHeaders(w.Header(), d)
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionContextKey{}, name)))
+		})
+	}
+}
+
+// V returns the route group of a version, or nil if it was not declared
+func (v *Versions) V(version string) *RouteGroup {
+	return v.groups[version]
+}
+
+// From returns the versions starting at version, in declaration order, so
+// routes added in a later version are not registered on earlier ones
+func (v *Versions) From(version string) *Versions {
+	for i, name := range v.names {
+		if name == version {
+			return &Versions{names: v.names[i:], groups: v.groups, mu: v.mu, deprecations: v.deprecations}
+		}
+	}
+	return &Versions{groups: v.groups, mu: v.mu, deprecations: v.deprecations}
+}
+
+// Deprecate marks a version deprecated; its responses carry Deprecation,
+// Sunset and Link headers
+func (v *Versions) Deprecate(version string, d Deprecation) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.deprecations[version] = d
+}
+
+// GET registers a GET route on every version
+func (v *Versions) GET(path string, handler http.HandlerFunc) []*Route {
+	return v.Handle("GET", path, handler)
+}
+
+// POST registers a POST route on every version
+func (v *Versions) POST(path string, handler http.HandlerFunc) []*Route {
+	return v.Handle("POST", path, handler)
+}
+
+// PUT registers a PUT route on every version
+func (v *Versions) PUT(path string, handler http.HandlerFunc) []*Route {
+	return v.Handle("PUT", path, handler)
+}
+
+// DELETE registers a DELETE route on every version
+func (v *Versions) DELETE(path string, handler http.HandlerFunc) []*Route {
+	return v.Handle("DELETE", path, handler)
+}
+
+// PATCH registers a PATCH route on every version
+func (v *Versions) PATCH(path string, handler http.HandlerFunc) []*Route {
+	return v.Handle("PATCH", path, handler)
+}
+
+// Handle registers a route on every version. Register version-specific
+// overrides on V(version) first: the first matching route wins.
+func (v *Versions) Handle(method, path string, handler http.HandlerFunc) []*Route {
+	routes := make([]*Route, 0, len(v.names))
+	for _, name := range v.names {
+		routes = append(routes, v.groups[name].Handle(method, path, handler))
+	}
+	return routes
+}
+
+// Deprecate marks the route deprecated; its responses carry Deprecation,
+// Sunset and Link headers
+func (r *Route) Deprecate(d Deprecation) *Route {
+	next := r.route.GetHandler()
+	r.route.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		setDeprecationHeaders(w.Header(), d)
+		next.ServeHTTP(w, req)
+	}))
+	return r
+}
+
+// setDeprecationHeaders follows RFC 9745 (Deprecation) and RFC 8594 (Sunset)
+func setDeprecationHeaders(h http.Header, d Deprecation) {
+	if d.At.IsZero() {
+		h.Set("Deprecation", "true")
+	} else {
+		h.Set("Deprecation", "@"+strconv.FormatInt(d.At.Unix(), 10))
+	}
+	if !d.Sunset.IsZero() {
+		h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		h.Add("Link", "<"+d.Link+`>; rel="deprecation"`)
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(APIVersion(r)))
+}
+
+func TestApp_Version_Prefix(t *testing.T) {
+	a := New(nil)
+	api := a.Group("/api")
+	v := api.Version("v1", "v2")
+	v.V("v2").GET("/users", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("users v2"))
+	})
+	v.GET("/users", versionHandler)
+	v.From("v2").GET("/teams", versionHandler)
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/api/v1/users", http.StatusOK, "v1"},
+		{"/api/v2/users", http.StatusOK, "users v2"},
+		{"/api/v2/teams", http.StatusOK, "v2"},
+		{"/api/v1/teams", http.StatusNotFound, ""},
+		{"/api/v3/users", http.StatusNotFound, ""},
+	}
+	handler := a.buildHandler()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestApp_VersionWith_Headers(t *testing.T) {
+	a := New(nil)
+	v := a.VersionWith(VersionConfig{Header: "API-Version", MediaType: "application/vnd.acme"}, "v1", "v2")
+	v.GET("/users", versionHandler)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   string
+	}{
+		{"default is latest", "", "", "v2"},
+		{"custom header", "API-Version", "v1", "v1"},
+		{"vendor media type", "Accept", "application/vnd.acme.v1+json", "v1"},
+		{"media type parameter", "Accept", "application/vnd.acme+json; version=v1", "v1"},
+		{"other media types ignored", "Accept", "text/html, application/json", "v2"},
+	}
+	handler := a.buildHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Body.String() != tt.want {
+				t.Errorf("served by %q, want %q", rec.Body.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown version", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("API-Version", "v9")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rec.Code)
+		}
+	})
+}
+
+func TestDeprecation(t *testing.T) {
+	deprecatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	a := New(nil)
+	v := a.Version("v1", "v2")
+	v.GET("/users", versionHandler)
+	v.Deprecate("v1", Deprecation{At: deprecatedAt, Sunset: sunset, Link: "https://example.com/migrate"})
+	a.Group("/legacy").GET("/ping", versionHandler).Deprecate(Deprecation{})
+
+	handler := a.buildHandler()
+	serve := func(path string) http.Header {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Header()
+	}
+
+	h := serve("/v1/users")
+	if got := h.Get("Deprecation"); got != "@1767225600" {
+		t.Errorf("Deprecation = %q", got)
+	}
+	if got := h.Get("Sunset"); got != "Thu, 31 Dec 2026 23:59:59 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := h.Get("Link"); got != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Errorf("Link = %q", got)
+	}
+
+	if h := serve("/v2/users"); h.Get("Deprecation") != "" {
+		t.Errorf("expected v2 not to be deprecated, got %v", h)
+	}
+
+	h = serve("/legacy/ping")
+	if h.Get("Deprecation") != "true" || h.Get("Sunset") != "" {
+		t.Errorf("unexpected route deprecation headers %v", h)
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/healthz"
+	"github.com/sirupsen/logrus"
+)
+
+// Backoff between dependency probes in WaitForDependencies
+const (
+	waitInitialBackoff = 250 * time.Millisecond
+	waitMaxBackoff     = 5 * time.Second
+)
+
+// WaitForDependencies probes the dependencies registered with AddDependency
+// and the configured database, cache, mongodb and rabbit servers until all
+// of them are healthy, retrying failed ones with exponential backoff. The
+// servers are probed by connecting to their address, unless a dependency
+// of the same name, e.g. "database:main", replaces the probe. After timeout
+// it fails with the dependencies that never came up and their last error.
+// Config.DependencyWait runs it before the start hooks.
+func (a *App) WaitForDependencies(ctx context.Context, timeout time.Duration) error {
+	a.hooksMu.Lock()
+	pending := append([]dependency(nil), a.dependencies...)
+	a.hooksMu.Unlock()
+	pending = withEndpoints(pending, healthz.Endpoints())
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	backoff := waitInitialBackoff
+	var last []DependencyStatus
+
+	for attempt := 1; ; attempt++ {
+		last = checkAll(ctx, pending)
+
+		var down []dependency
+		for i, status := range last {
+			entry := logrus.WithFields(logrus.Fields{"dependency": status.Name, "attempt": attempt})
+			if status.Healthy {
+				entry.WithField("waited", time.Since(start).Round(time.Millisecond).String()).Info("Dependency ready")
+				continue
+			}
+			entry.WithFields(logrus.Fields{"error": status.Error, "retry_in": backoff.String()}).Warn("Waiting for dependency")
+			down = append(down, dependencyNamed(pending, last[i].Name))
+		}
+		if len(down) == 0 {
+			return nil
+		}
+		pending = down
+
+		select {
+		case <-ctx.Done():
+			return dependencyReport(last, timeout)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > waitMaxBackoff {
+			backoff = waitMaxBackoff
+		}
+	}
+}
+
+// withEndpoints adds a connect probe for each endpoint not already among deps
+func withEndpoints(deps []dependency, endpoints []healthz.Endpoint) []dependency {
+	for _, endpoint := range endpoints {
+		if dependencyNamed(deps, endpoint.Name).check != nil {
+			continue
+		}
+		deps = append(deps, dependency{name: endpoint.Name, check: dialProbe(endpoint.Addrs)})
+	}
+	return deps
+}
+
+// dialProbe succeeds when any of addrs accepts a TCP connection
+func dialProbe(addrs []string) HookFunc {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		var err error
+		for _, addr := range addrs {
+			var conn net.Conn
+			if conn, err = dialer.DialContext(ctx, "tcp", addr); err == nil {
+				return conn.Close()
+			}
+		}
+		return err
+	}
+}
+
+// checkAll sorts its results by name, so map them back to the dependency
+func dependencyNamed(deps []dependency, name string) dependency {
+	for _, dep := range deps {
+		if dep.name == name {
+			return dep
+		}
+	}
+	return dependency{}
+}
+
+func dependencyReport(statuses []DependencyStatus, timeout time.Duration) error {
+	var failed []string
+	for _, status := range statuses {
+		if !status.Healthy {
+			failed = append(failed, fmt.Sprintf("%s (%s)", status.Name, status.Error))
+		}
+	}
+	return fmt.Errorf("dependencies not ready after %s: %s", timeout, strings.Join(failed, ", "))
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/healthz"
+)
+
+func TestApp_WaitForDependencies(t *testing.T) {
+	t.Run("no dependencies", func(t *testing.T) {
+		if err := New(nil).WaitForDependencies(context.Background(), time.Second); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("retries until ready", func(t *testing.T) {
+		a := New(nil)
+		var dbCalls, cacheCalls atomic.Int32
+		a.AddDependency("database", func(ctx context.Context) error {
+			if dbCalls.Add(1) < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		})
+		a.AddDependency("cache", func(ctx context.Context) error {
+			cacheCalls.Add(1)
+			return nil
+		})
+
+		if err := a.WaitForDependencies(context.Background(), 5*time.Second); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if dbCalls.Load() != 3 {
+			t.Errorf("expected 3 database probes, got %d", dbCalls.Load())
+		}
+		if cacheCalls.Load() != 1 {
+			t.Errorf("expected a healthy dependency to be probed once, got %d", cacheCalls.Load())
+		}
+	})
+
+	t.Run("reports what never came up", func(t *testing.T) {
+		a := New(nil)
+		a.AddDependency("broker", func(ctx context.Context) error { return errors.New("no route to host") })
+		a.AddDependency("cache", func(ctx context.Context) error { return nil })
+
+		err := a.WaitForDependencies(context.Background(), 300*time.Millisecond)
+		if err == nil {
+			t.Fatal("expected timeout error")
+		}
+		if msg := err.Error(); !strings.Contains(msg, "broker (no route to host)") || strings.Contains(msg, "cache") {
+			t.Errorf("unexpected report %q", msg)
+		}
+	})
+
+	t.Run("config runs it before start hooks", func(t *testing.T) {
+		a := New(&Config{DependencyWait: 100 * time.Millisecond})
+		a.AddDependency("database", func(ctx context.Context) error { return errors.New("down") })
+		started := false
+		a.OnStart(func(ctx context.Context) error {
+			started = true
+			return nil
+		})
+
+		if err := a.runStartHooks(context.Background()); err == nil {
+			t.Fatal("expected dependency error")
+		}
+		if started {
+			t.Error("start hooks must not run when dependencies are down")
+		}
+	})
+	t.Run("probes configured servers", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		closed, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		down := closed.Addr().String()
+		closed.Close()
+
+		deps := withEndpoints(
+			[]dependency{{name: "database:main", check: func(ctx context.Context) error { return nil }}},
+			[]healthz.Endpoint{
+				{Name: "database:main", Addrs: []string{down}},
+				{Name: "redis:default", Addrs: []string{down, ln.Addr().String()}},
+				{Name: "rabbit:main", Addrs: []string{down}},
+			},
+		)
+		if len(deps) != 3 {
+			t.Fatalf("expected the database probe to be replaced, got %d dependencies", len(deps))
+		}
+		statuses := checkAll(context.Background(), deps)
+		for _, status := range statuses {
+			if healthy := status.Name != "rabbit:main"; status.Healthy != healthy {
+				t.Errorf("%s: expected healthy %v, got %+v", status.Name, healthy, status)
+			}
+		}
+	})
+}
@@ -0,0 +1,304 @@
+// Package audit records HTTP requests for compliance audit trails: who
+// called which endpoint, with the outcome and selected request and response
+// bodies, size-capped and redacted. Entries are delivered in batches to a
+// sink such as a file, a database table or Elasticsearch.
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrClosed is returned when recording to a closed logger
+var ErrClosed = errors.New("audit logger closed")
+
+// Entry is an audited request
+type Entry struct {
+	ID           string                 `json:"id"`
+	Time         time.Time              `json:"time"`
+	Method       string                 `json:"method"`
+	Path         string                 `json:"path"`
+	Route        string                 `json:"route,omitempty"` // Route template, e.g. /users/{id}
+	Query        string                 `json:"query,omitempty"`
+	Status       int                    `json:"status"`
+	Duration     float64                `json:"duration_ms"`
+	UserID       string                 `json:"user_id,omitempty"`
+	Username     string                 `json:"username,omitempty"`
+	Role         string                 `json:"role,omitempty"`
+	IP           string                 `json:"ip"`
+	UserAgent    string                 `json:"user_agent,omitempty"`
+	RequestID    string                 `json:"request_id,omitempty"`
+	RequestBody  string                 `json:"request_body,omitempty"`
+	ResponseBody string                 `json:"response_body,omitempty"`
+	Truncated    bool                   `json:"truncated,omitempty"` // A body exceeded MaxBodySize
+	Extra        map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Sink stores a batch of entries
+type Sink interface {
+	Write(ctx context.Context, entries []Entry) error
+}
+
+// SinkFunc adapts a function to Sink, e.g. to publish to Kafka
+type SinkFunc func(ctx context.Context, entries []Entry) error
+
+// Write implements Sink
+func (f SinkFunc) Write(ctx context.Context, entries []Entry) error {
+	return f(ctx, entries)
+}
+
+// Config holds audit logger configuration
+type Config struct {
+	Sink Sink
+
+	BatchSize     int           // Entries per write (default 100)
+	FlushInterval time.Duration // Maximum time an entry waits for a batch (default 1s)
+	QueueSize     int           // Buffered entries (default 10000)
+	WriteTimeout  time.Duration // Timeout per write (default 10s)
+
+	// Block makes requests wait for room in a full queue instead of
+	// dropping their entries
+	Block bool
+
+	// RequestBody and ResponseBody record the bodies of BodyTypes
+	RequestBody  bool
+	ResponseBody bool
+
+	// MaxBodySize caps each recorded body (default 16KB); longer bodies are
+	// cut and the entry marked Truncated
+	MaxBodySize int
+
+	// BodyTypes are the content types whose bodies are recorded (default
+	// JSON, form and plain text)
+	BodyTypes []string
+
+	// Redact names the fields whose values are replaced by "[REDACTED]" in
+	// JSON and form bodies and in the query, compared case-insensitively
+	// (default DefaultRedact)
+	Redact []string
+
+	// Skip leaves requests unaudited, e.g. health checks or reads
+	Skip func(r *http.Request) bool
+
+	// Enrich can add fields to every entry, e.g. the tenant
+	Enrich func(ctx context.Context, e *Entry)
+}
+
+// DefaultRedact are the fields redacted unless Config.Redact is set
+var DefaultRedact = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token", "id_token",
+	"authorization", "api_key", "apikey", "client_secret", "credit_card", "card_number", "cvv", "ssn",
+}
+
+var defaultBodyTypes = []string{"application/json", "+json", "application/x-www-form-urlencoded", "text/plain"}
+
+// Logger batches audit entries and writes them in the background
+type Logger struct {
+	config  Config
+	redact  map[string]bool
+	queue   chan Entry
+	flushCh chan chan struct{}
+	done    chan struct{}
+
+	patternOnce sync.Once
+	pattern     *regexp.Regexp
+
+	closeOnce sync.Once
+	closed    atomic.Bool
+	dropped   atomic.Int64
+}
+
+// NewLogger creates an audit logger and starts its write loop. Call Close
+// on shutdown to write queued entries.
+func NewLogger(config Config) *Logger {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = 10 * time.Second
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 16 << 10
+	}
+	if len(config.BodyTypes) == 0 {
+		config.BodyTypes = defaultBodyTypes
+	}
+	if len(config.Redact) == 0 {
+		config.Redact = DefaultRedact
+	}
+
+	l := &Logger{
+		config:  config,
+		redact:  redactSet(config.Redact),
+		queue:   make(chan Entry, config.QueueSize),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.loop()
+	return l
+}
+
+// Record queues an entry, e.g. for an action taken outside a request. ID
+// and Time are set when empty.
+func (l *Logger) Record(ctx context.Context, e Entry) (err error) {
+	if l.closed.Load() {
+		return ErrClosed
+	}
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if l.config.Enrich != nil {
+		l.config.Enrich(ctx, &e)
+	}
+
+	defer func() {
+		// The queue may be closed concurrently by Close
+		if recover() != nil {
+			err = ErrClosed
+		}
+	}()
+
+	if l.config.Block {
+		select {
+		case l.queue <- e:
+			return nil
+		case <-ctx.Done():
+			l.drop()
+			return ctx.Err()
+		}
+	}
+	select {
+	case l.queue <- e:
+	default:
+		l.drop()
+	}
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the queue was full
+// or writing failed
+func (l *Logger) Dropped() int64 {
+	return l.dropped.Load()
+}
+
+// Flush writes all queued entries and waits until done or ctx expires
+func (l *Logger) Flush(ctx context.Context) error {
+	if l.closed.Load() {
+		return ErrClosed
+	}
+
+	ack := make(chan struct{})
+	select {
+	case l.flushCh <- ack:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting entries and writes the queued ones. It fits
+// App.OnShutdown.
+func (l *Logger) Close(ctx context.Context) error {
+	l.closeOnce.Do(func() {
+		l.closed.Store(true)
+		close(l.queue)
+	})
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *Logger) drop() {
+	if l.dropped.Add(1)%1000 == 1 {
+		logrus.Warnf("Audit queue full, dropping entries (%d dropped so far)", l.dropped.Load())
+	}
+}
+
+func (l *Logger) loop() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, l.config.BatchSize)
+	write := func() {
+		if len(batch) == 0 {
+			return
+		}
+		l.write(batch)
+		batch = make([]Entry, 0, l.config.BatchSize)
+	}
+
+	for {
+		select {
+		case e, ok := <-l.queue:
+			if !ok {
+				write()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= l.config.BatchSize {
+				write()
+			}
+
+		case ack := <-l.flushCh:
+			// Drain what is already queued before acknowledging
+			for drained := false; !drained; {
+				select {
+				case e, ok := <-l.queue:
+					if !ok {
+						drained = true
+						break
+					}
+					batch = append(batch, e)
+					if len(batch) >= l.config.BatchSize {
+						write()
+					}
+				default:
+					drained = true
+				}
+			}
+			write()
+			close(ack)
+
+		case <-ticker.C:
+			write()
+		}
+	}
+}
+
+func (l *Logger) write(batch []Entry) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.WriteTimeout)
+	defer cancel()
+
+	if err := l.config.Sink.Write(ctx, batch); err != nil {
+		l.dropped.Add(int64(len(batch)))
+		logrus.Errorf("Failed to write %d audit entries: %v", len(batch), err)
+	}
+}
@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logrus.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// recordingSink collects written batches
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]Entry
+}
+
+func (s *recordingSink) Write(ctx context.Context, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]Entry(nil), entries...))
+	return nil
+}
+
+func (s *recordingSink) entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []Entry
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+// audited serves one request through the logger middleware and returns the
+// entry written
+func audited(t *testing.T, config Config, h http.Handler, r *http.Request) (Entry, *httptest.ResponseRecorder) {
+	t.Helper()
+	sink := &recordingSink{}
+	config.Sink = sink
+	l := NewLogger(config)
+
+	w := httptest.NewRecorder()
+	l.Middleware()(h).ServeHTTP(w, r)
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := sink.entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	return entries[0], w
+}
+
+func TestLogger_Batching(t *testing.T) {
+	sink := &recordingSink{}
+	l := NewLogger(Config{Sink: sink, BatchSize: 2, FlushInterval: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		if err := l.Record(context.Background(), Entry{Method: http.MethodPost}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(sink.entries()); got != 5 {
+		t.Errorf("expected 5 entries after flush, got %d", got)
+	}
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record(context.Background(), Entry{}); err != ErrClosed {
+		t.Errorf("expected ErrClosed after Close, got %v", err)
+	}
+	for _, e := range sink.entries() {
+		if e.ID == "" || e.Time.IsZero() {
+			t.Errorf("expected ID and time set, got %+v", e)
+		}
+	}
+}
+
+func TestLogger_DropsWhenQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	l := NewLogger(Config{
+		Sink: SinkFunc(func(ctx context.Context, entries []Entry) error {
+			<-block
+			return nil
+		}),
+		BatchSize: 1,
+		QueueSize: 1,
+	})
+
+	for i := 0; i < 10; i++ {
+		_ = l.Record(context.Background(), Entry{})
+	}
+	if l.Dropped() == 0 {
+		t.Error("expected dropped entries")
+	}
+
+	close(block)
+	_ = l.Close(context.Background())
+}
+
+func TestMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{UserID: "42", Username: "ada", Role: "admin"}))
+		middleware.RecordRequest(r)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"7","token":"t0k3n","echo":` + string(body) + `}`))
+	})
+
+	r := httptest.NewRequest(http.MethodPut, "/users/7?api_key=k&view=full", strings.NewReader(`{"name":"Ada","password":"hunter2"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+	r = r.WithContext(middleware.WithRequestID(r.Context(), "req-1"))
+	e, w := audited(t, Config{RequestBody: true, ResponseBody: true}, router, r)
+
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"password":"hunter2"`) {
+		t.Errorf("expected handler to get the whole body, got %d %q", w.Code, w.Body.String())
+	}
+	if e.Method != http.MethodPut || e.Path != "/users/7" || e.Route != "/users/{id}" || e.Status != http.StatusCreated {
+		t.Errorf("unexpected request fields %+v", e)
+	}
+	if e.UserID != "42" || e.Username != "ada" || e.Role != "admin" {
+		t.Errorf("expected user from claims, got %q %q %q", e.UserID, e.Username, e.Role)
+	}
+	if e.IP != "203.0.113.9" || e.RequestID != "req-1" {
+		t.Errorf("unexpected IP %q or request ID %q", e.IP, e.RequestID)
+	}
+	if e.Query != "api_key=%5BREDACTED%5D&view=full" {
+		t.Errorf("unexpected query %q", e.Query)
+	}
+	if e.RequestBody != `{"name":"Ada","password":"[REDACTED]"}` {
+		t.Errorf("unexpected request body %q", e.RequestBody)
+	}
+	if e.ResponseBody != `{"echo":{"name":"Ada","password":"[REDACTED]"},"id":"7","token":"[REDACTED]"}` {
+		t.Errorf("unexpected response body %q", e.ResponseBody)
+	}
+}
+
+func TestMiddleware_Bodies(t *testing.T) {
+	echo := func(contentType string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", contentType)
+			_, _ = w.Write(body)
+		})
+	}
+
+	tests := []struct {
+		name          string
+		config        Config
+		contentType   string
+		body          string
+		wantRequest   string
+		wantResponse  string
+		wantTruncated bool
+	}{
+		{"bodies off", Config{}, "application/json", `{"a":1}`, "", "", false},
+		{"form", Config{RequestBody: true}, "application/x-www-form-urlencoded", "user=ada&password=x", "password=%5BREDACTED%5D&user=ada", "", false},
+		{"unrecorded type", Config{RequestBody: true, ResponseBody: true}, "application/octet-stream", "raw", "", "", false},
+		{"custom redact", Config{RequestBody: true, Redact: []string{"Name"}}, "application/json", `{"name":"Ada","password":"x"}`, `{"name":"[REDACTED]","password":"x"}`, "", false},
+		{"nested", Config{RequestBody: true}, "application/json", `{"users":[{"secret":1,"n":2}]}`, `{"users":[{"n":2,"secret":"[REDACTED]"}]}`, "", false},
+		{
+			"truncated", Config{RequestBody: true, ResponseBody: true, MaxBodySize: 27}, "application/json",
+			`{"user":"ada","password":"hunter2","x":1}`,
+			`{"user":"ada","password":"[REDACTED]"`, `{"user":"ada","password":"[REDACTED]"`, true,
+		},
+		{
+			"cut in value", Config{RequestBody: true, MaxBodySize: 20}, "application/json",
+			`{"password":"hunter2"}`, `{"password":"[REDACTED]"`, "", true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			e, w := audited(t, tt.config, echo(tt.contentType), r)
+
+			if w.Body.String() != tt.body {
+				t.Errorf("expected response %q, got %q", tt.body, w.Body.String())
+			}
+			if e.RequestBody != tt.wantRequest {
+				t.Errorf("expected request body %q, got %q", tt.wantRequest, e.RequestBody)
+			}
+			if e.ResponseBody != tt.wantResponse {
+				t.Errorf("expected response body %q, got %q", tt.wantResponse, e.ResponseBody)
+			}
+			if e.Truncated != tt.wantTruncated {
+				t.Errorf("expected truncated %v, got %v", tt.wantTruncated, e.Truncated)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Skip(t *testing.T) {
+	sink := &recordingSink{}
+	l := NewLogger(Config{Sink: sink, Skip: func(r *http.Request) bool { return r.Method == http.MethodGet }})
+
+	h := l.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/", nil))
+	_ = l.Close(context.Background())
+
+	entries := sink.entries()
+	if len(entries) != 1 || entries[0].Method != http.MethodDelete || entries[0].Status != http.StatusOK {
+		t.Errorf("expected only the DELETE audited with status 200, got %+v", entries)
+	}
+}
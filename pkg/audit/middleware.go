@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+// Middleware records an entry for every request once its response is
+// written. Installed before routing, as with App.Use, entries still carry
+// the route template and the user set by authentication middleware on the
+// route.
+func (l *Logger) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l.config.Skip != nil && l.config.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			e := Entry{
+				ID:        newID(),
+				Time:      start.UTC(),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Query:     l.redactQuery(r.URL.RawQuery),
+				IP:        clientIP(r),
+				UserAgent: r.UserAgent(),
+			}
+
+			var reqBody []byte
+			if l.config.RequestBody && l.recorded(r.Header.Get("Content-Type")) {
+				var truncated bool
+				reqBody, truncated = l.captureRequest(r)
+				e.Truncated = truncated
+			}
+
+			r, routed := middleware.TrackRouting(r)
+			aw := &auditWriter{ResponseWriter: w, logger: l, status: http.StatusOK}
+			next.ServeHTTP(aw, r)
+
+			routedReq := routed()
+			e.Status = aw.status
+			e.Duration = float64(time.Since(start).Microseconds()) / 1000
+			e.RequestID = middleware.GetRequestID(routedReq.Context())
+			e.Route = middleware.RouteTemplate(routedReq)
+			if claims, ok := auth.GetClaims(routedReq.Context()); ok {
+				e.UserID, e.Username, e.Role = claims.UserID, claims.Username, claims.Role
+			}
+			if reqBody != nil {
+				e.RequestBody = l.redactBody(r.Header.Get("Content-Type"), reqBody)
+			}
+			if aw.capture {
+				e.ResponseBody = l.redactBody(w.Header().Get("Content-Type"), aw.body.Bytes())
+				e.Truncated = e.Truncated || aw.truncated
+			}
+
+			_ = l.Record(routedReq.Context(), e)
+		})
+	}
+}
+
+// captureRequest reads up to MaxBodySize bytes of the body, leaving it
+// whole for the handler
+func (l *Logger) captureRequest(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(l.config.MaxBodySize)+1))
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return nil, false
+	}
+	if len(body) > l.config.MaxBodySize {
+		return body[:l.config.MaxBodySize], true
+	}
+	return body, false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// recorded reports whether bodies of contentType are recorded
+func (l *Logger) recorded(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range l.config.BodyTypes {
+		if mediaType == t || (strings.HasPrefix(t, "+") && strings.HasSuffix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditWriter captures the status and, with ResponseBody, up to
+// MaxBodySize bytes of the body
+type auditWriter struct {
+	http.ResponseWriter
+	logger *Logger
+
+	status      int
+	wroteHeader bool
+	capture     bool
+	truncated   bool
+	body        bytes.Buffer
+}
+
+func (w *auditWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= 200 {
+		w.wroteHeader = true
+		w.status = code
+		w.capture = w.logger.config.ResponseBody && w.logger.recorded(w.Header().Get("Content-Type"))
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.capture {
+		if room := w.logger.config.MaxBodySize - w.body.Len(); room < len(b) {
+			w.body.Write(b[:max(room, 0)])
+			w.truncated = true
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (w *auditWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap supports http.ResponseController
+func (w *auditWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func clientIP(r *http.Request) string {
+	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
+		return strings.TrimSpace(strings.Split(ip, ",")[0])
+	}
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of redacted fields
+const Redacted = "[REDACTED]"
+
+func redactSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
+
+// redactQuery redacts a raw query, returned unchanged when it has no
+// redacted parameter
+func (l *Logger) redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil || !l.redactValues(values) {
+		return raw
+	}
+	return values.Encode()
+}
+
+// redactValues redacts form values, reporting whether any was
+func (l *Logger) redactValues(values url.Values) bool {
+	redacted := false
+	for key, vals := range values {
+		if l.redact[strings.ToLower(key)] {
+			for i := range vals {
+				vals[i] = Redacted
+			}
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// redactBody redacts a recorded body by its content type
+func (l *Logger) redactBody(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return l.redactJSON(body)
+	case mediaType == "application/x-www-form-urlencoded":
+		return l.redactQuery(string(body))
+	}
+	return string(body)
+}
+
+// redactJSON redacts fields at any depth. Bodies cut at MaxBodySize do not
+// parse, and have their redacted string and scalar values replaced in place.
+func (l *Logger) redactJSON(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return l.redactPattern().ReplaceAllString(string(body), `"$1":"`+Redacted+`"`)
+	}
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(l.redactTree(v)); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func (l *Logger) redactTree(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if l.redact[strings.ToLower(key)] {
+				v[key] = Redacted
+			} else {
+				v[key] = l.redactTree(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = l.redactTree(val)
+		}
+	}
+	return v
+}
+
+// redactPattern matches a redacted key and its string or scalar value,
+// which may be cut off by the end of the body
+func (l *Logger) redactPattern() *regexp.Regexp {
+	l.patternOnce.Do(func() {
+		keys := make([]string, 0, len(l.redact))
+		for key := range l.redact {
+			keys = append(keys, regexp.QuoteMeta(key))
+		}
+		l.pattern = regexp.MustCompile(`(?i)"(` + strings.Join(keys, "|") + `)"\s*:\s*(?:"(?:[^"\\]|\\.)*(?:"|\\?$)|[^,}\]\s]+)`)
+	})
+	return l.pattern
+}
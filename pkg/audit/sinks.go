@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/elasticsearch"
+	"gorm.io/gorm"
+)
+
+// WriterSink writes entries as JSON lines, e.g. to os.Stdout for a log
+// shipper to collect
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a sink writing to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewFileSink returns a sink appending to the file at path, created with
+// mode 0600 when missing. Close it after the logger.
+func NewFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSink(f), nil
+}
+
+// Write implements Sink. A batch is written at once, so lines of
+// concurrent writers do not interleave.
+func (s *WriterSink) Write(ctx context.Context, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf := bufio.NewWriter(s.w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if f, ok := s.w.(*os.File); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+// Close closes the underlying writer when it is an io.Closer
+func (s *WriterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Row is the database row of an entry, with Extra as JSON
+type Row struct {
+	ID           string    `gorm:"primaryKey;size:32"`
+	Time         time.Time `gorm:"index"`
+	Method       string    `gorm:"size:16"`
+	Path         string    `gorm:"size:2048"`
+	Route        string    `gorm:"size:512;index"`
+	Query        string    `gorm:"type:text"`
+	Status       int
+	Duration     float64
+	UserID       string `gorm:"size:255;index"`
+	Username     string `gorm:"size:255"`
+	Role         string `gorm:"size:255"`
+	IP           string `gorm:"size:64"`
+	UserAgent    string `gorm:"size:512"`
+	RequestID    string `gorm:"size:128;index"`
+	RequestBody  string `gorm:"type:text"`
+	ResponseBody string `gorm:"type:text"`
+	Truncated    bool
+	Extra        string `gorm:"type:text"`
+}
+
+// TableSink inserts entries into a database table, by default audit_logs
+type TableSink struct {
+	DB    *gorm.DB
+	Table string
+}
+
+func (s *TableSink) table() string {
+	if s.Table == "" {
+		return "audit_logs"
+	}
+	return s.Table
+}
+
+// Migrate creates or updates the table
+func (s *TableSink) Migrate(ctx context.Context) error {
+	return s.DB.WithContext(ctx).Table(s.table()).AutoMigrate(&Row{})
+}
+
+// Write implements Sink
+func (s *TableSink) Write(ctx context.Context, entries []Entry) error {
+	rows := make([]Row, len(entries))
+	for i, e := range entries {
+		var extra string
+		if len(e.Extra) > 0 {
+			b, err := json.Marshal(e.Extra)
+			if err != nil {
+				return err
+			}
+			extra = string(b)
+		}
+		rows[i] = Row{
+			ID: e.ID, Time: e.Time, Method: e.Method, Path: e.Path, Route: e.Route, Query: e.Query,
+			Status: e.Status, Duration: e.Duration, UserID: e.UserID, Username: e.Username, Role: e.Role,
+			IP: e.IP, UserAgent: e.UserAgent, RequestID: e.RequestID,
+			RequestBody: e.RequestBody, ResponseBody: e.ResponseBody, Truncated: e.Truncated, Extra: extra,
+		}
+	}
+	return s.DB.WithContext(ctx).Table(s.table()).Create(&rows).Error
+}
+
+// ElasticsearchSink bulk-indexes entries by ID, so retried batches do not
+// duplicate them
+type ElasticsearchSink struct {
+	Client *elasticsearch.Client
+
+	// Index is the index name, formatted with the entry time when it holds
+	// a Go time layout in braces, e.g. "audit-{2006.01.02}" for daily indices
+	Index string
+}
+
+// Write implements Sink
+func (s *ElasticsearchSink) Write(ctx context.Context, entries []Entry) error {
+	byIndex := make(map[string]map[string]interface{})
+	for _, e := range entries {
+		index := indexName(s.Index, e.Time)
+		if byIndex[index] == nil {
+			byIndex[index] = make(map[string]interface{})
+		}
+		byIndex[index][e.ID] = e
+	}
+	for index, docs := range byIndex {
+		if err := s.Client.BulkIndex(ctx, index, docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexName formats the time layout between braces in pattern with t
+func indexName(pattern string, t time.Time) string {
+	before, rest, ok := strings.Cut(pattern, "{")
+	if !ok {
+		return pattern
+	}
+	layout, after, ok := strings.Cut(rest, "}")
+	if !ok {
+		return pattern
+	}
+	return before + t.UTC().Format(layout) + after
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []Entry{{ID: "1", Method: "POST", Path: "/a<b>"}, {ID: "2", Method: "DELETE"}}
+	if err := sink.Write(context.Background(), entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, _ := f.Stat()
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	var got []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0].Path != "/a<b>" || got[1].Method != "DELETE" {
+		t.Errorf("unexpected entries %+v", got)
+	}
+}
+
+func TestTableSink(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &TableSink{DB: db}
+	ctx := context.Background()
+	if err := sink.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	err = sink.Write(ctx, []Entry{
+		{ID: "a", Time: at, Method: "POST", Path: "/orders", Status: 201, UserID: "42", Extra: map[string]interface{}{"tenant": "acme"}},
+		{ID: "b", Time: at, Method: "DELETE", Path: "/orders/1", Status: 204},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []Row
+	if err := db.Table("audit_logs").Order("id").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].UserID != "42" || rows[0].Extra != `{"tenant":"acme"}` || rows[1].Status != 204 {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+}
+
+func TestIndexName(t *testing.T) {
+	at := time.Date(2026, 3, 1, 23, 30, 0, 0, time.FixedZone("", -5*3600))
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"audit", "audit"},
+		{"audit-{2006.01.02}", "audit-2026.03.02"},
+		{"audit-{2006.01}-v1", "audit-2026.03-v1"},
+		{"audit-{2006", "audit-{2006"},
+	}
+	for _, tt := range tests {
+		if got := indexName(tt.pattern, at); got != tt.want {
+			t.Errorf("indexName(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
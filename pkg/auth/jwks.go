@@ -0,0 +1,414 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrKeyNotFound    = errors.New("signing key not found")
+	ErrKeySetStale    = errors.New("key set expired and refresh failed")
+	ErrNoKeySetURL    = errors.New("key set URL is empty")
+	errUnsupportedKey = errors.New("unsupported key type")
+)
+
+// KeySetStore is a shared cache for the JWKS document and its fetch time,
+// letting several instances reuse one fetch. *cache.Manager satisfies it.
+type KeySetStore interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// KeySetConfig holds JWKS cache configuration
+type KeySetConfig struct {
+	URL         string        // JWKS endpoint
+	TTL         time.Duration // How long fetched keys are fresh (default 1h)
+	StaleTTL    time.Duration // How long expired keys are served while the issuer is down (default 24h)
+	MinInterval time.Duration // Minimum time between refreshes triggered by unknown kids (default 1m)
+	Store       KeySetStore   // Optional shared cache
+	StoreKey    string        // Key in Store (default "jwks:" + URL)
+	HTTPClient  *http.Client  // Default client has a 10s timeout
+}
+
+// KeySet caches public keys from a JWKS endpoint. Keys are kept in memory,
+// optionally shared through a KeySetStore, and refreshed in the background
+// with Start. When the issuer is unreachable, expired keys keep being served
+// for StaleTTL so validation does not hard-depend on the IdP.
+type KeySet struct {
+	config KeySetConfig
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	fetches     uint64 // Completed fetches from the issuer
+	lastErr     error  // Outcome of the last of them
+
+	refreshMu sync.Mutex
+}
+
+// NewKeySet creates a KeySet; keys are loaded lazily on first use
+func NewKeySet(config KeySetConfig) *KeySet {
+	if config.TTL == 0 {
+		config.TTL = time.Hour
+	}
+	if config.StaleTTL == 0 {
+		config.StaleTTL = 24 * time.Hour
+	}
+	if config.MinInterval == 0 {
+		config.MinInterval = time.Minute
+	}
+	if config.StoreKey == "" {
+		config.StoreKey = "jwks:" + config.URL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &KeySet{
+		config: config,
+		keys:   make(map[string]crypto.PublicKey),
+	}
+}
+
+// Key returns the public key with the given kid
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k.mu.RLock()
+	key, found := k.keys[kid]
+	fetchedAt, lastAttempt, fetches := k.fetchedAt, k.lastAttempt, k.fetches
+	k.mu.RUnlock()
+
+	fresh := !fetchedAt.IsZero() && time.Since(fetchedAt) < k.config.TTL
+	stale := found && time.Since(fetchedAt) < k.config.TTL+k.config.StaleTTL
+	throttled := time.Since(lastAttempt) < k.config.MinInterval
+
+	switch {
+	case fresh && found:
+		return key, nil
+	case throttled && stale:
+		return key, nil
+	case throttled:
+		// Unknown kid, but the issuer was asked recently
+		return nil, ErrKeyNotFound
+	}
+
+	// A kid missing from fresh keys may be a rotation: ask the issuer, as
+	// the shared copy is no newer than ours
+	if !fresh && k.loadStored(ctx) {
+		k.mu.RLock()
+		key, ok := k.keys[kid]
+		k.mu.RUnlock()
+		if ok {
+			return key, nil
+		}
+	}
+
+	if err := k.fetch(ctx, fetches); err != nil {
+		if stale {
+			logrus.Warnf("JWKS refresh failed, serving stale keys from %s: %v", k.config.URL, err)
+			return key, nil
+		}
+		if found {
+			return nil, fmt.Errorf("%w: %v", ErrKeySetStale, err)
+		}
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// keyfuncTimeout bounds the key lookup of Keyfunc, which has no request
+// context to inherit a deadline from
+const keyfuncTimeout = 10 * time.Second
+
+// Keyfunc resolves the verification key for a token by its kid header. A
+// fetch from the issuer is bounded by 10s; use KeyfuncContext to tie it to a
+// request instead.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyfuncTimeout)
+	defer cancel()
+	return k.KeyfuncContext(ctx)(token)
+}
+
+// KeyfuncContext returns a jwt.Keyfunc resolving keys under ctx, so fetches
+// from the issuer are canceled with the request:
+//
+//	jwt.Parse(raw, ks.KeyfuncContext(r.Context()))
+func (k *KeySet) KeyfuncContext(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return k.Key(ctx, kid)
+	}
+}
+
+// Refresh reloads keys from the shared store when it has a fresh copy newer
+// than ours, else from the issuer
+func (k *KeySet) Refresh(ctx context.Context) error {
+	k.mu.RLock()
+	fetches := k.fetches
+	k.mu.RUnlock()
+
+	if k.loadStored(ctx) {
+		return nil
+	}
+	return k.fetch(ctx, fetches)
+}
+
+// storedKeySet is the shared copy of a JWKS document, with the time it was
+// fetched from the issuer so every instance expires it at the same time
+type storedKeySet struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	JWKS      json.RawMessage `json:"jwks"`
+}
+
+// loadStored loads the keys of the shared store, reporting whether it had a
+// fresh copy newer than ours
+func (k *KeySet) loadStored(ctx context.Context) bool {
+	if k.config.Store == nil {
+		return false
+	}
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	raw, err := k.config.Store.Get(ctx, k.config.StoreKey)
+	if err != nil || raw == "" {
+		return false
+	}
+	var stored storedKeySet
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil || time.Since(stored.FetchedAt) >= k.config.TTL {
+		return false
+	}
+	k.mu.RLock()
+	newer := stored.FetchedAt.After(k.fetchedAt)
+	k.mu.RUnlock()
+	if !newer {
+		return false
+	}
+	keys, err := ParseKeySet(stored.JWKS)
+	if err != nil {
+		return false
+	}
+	k.setKeys(keys, stored.FetchedAt)
+	return true
+}
+
+// fetch loads the keys from the issuer and shares them through the store.
+// seen is the number of fetches the caller observed before deciding to
+// fetch: when another one completed since, e.g. while this caller waited for
+// refreshMu, its outcome is returned instead of asking the issuer again.
+func (k *KeySet) fetch(ctx context.Context, seen uint64) error {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	k.mu.Lock()
+	if k.fetches != seen {
+		err := k.lastErr
+		k.mu.Unlock()
+		return err
+	}
+	k.lastAttempt = time.Now()
+	k.mu.Unlock()
+
+	err := k.load(ctx)
+	k.mu.Lock()
+	k.fetches++
+	k.lastErr = err
+	k.mu.Unlock()
+	return err
+}
+
+// load downloads and parses the keys, then shares them through the store
+func (k *KeySet) load(ctx context.Context) error {
+	raw, err := k.download(ctx)
+	if err != nil {
+		return err
+	}
+	fetchedAt := time.Now()
+
+	keys, err := ParseKeySet(raw)
+	if err != nil {
+		return err
+	}
+	k.setKeys(keys, fetchedAt)
+
+	if k.config.Store != nil {
+		stored, _ := json.Marshal(storedKeySet{FetchedAt: fetchedAt, JWKS: raw})
+		if err := k.config.Store.Set(ctx, k.config.StoreKey, string(stored), k.config.TTL); err != nil {
+			logrus.Warnf("Failed to store JWKS in shared cache: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Start refreshes keys in the background every half TTL until ctx is done
+func (k *KeySet) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(k.config.TTL / 2)
+		defer ticker.Stop()
+
+		for {
+			if err := k.Refresh(ctx); err != nil {
+				logrus.Warnf("Background JWKS refresh failed for %s: %v", k.config.URL, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (k *KeySet) setKeys(keys map[string]crypto.PublicKey, fetchedAt time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+	k.fetchedAt = fetchedAt
+}
+
+func (k *KeySet) download(ctx context.Context) ([]byte, error) {
+	if k.config.URL == "" {
+		return nil, ErrNoKeySetURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := k.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// ParseKeySet parses a JWKS document into public keys by kid. Encryption
+// keys and unsupported key types are skipped.
+func ParseKeySet(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			if !errors.Is(err, errUnsupportedKey) {
+				return nil, fmt.Errorf("invalid JWK %q: %w", k.Kid, err)
+			}
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URL(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errUnsupportedKey
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URL(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		point := append([]byte{4}, append(x, y...)...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errUnsupportedKey
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// jwksServer serves the given keys and counts requests; failing makes it return 500
+type jwksServer struct {
+	*httptest.Server
+	hits    atomic.Int32
+	failing atomic.Bool
+}
+
+func newJWKSServer(t *testing.T, keys ...map[string]string) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.hits.Add(1)
+		if s.failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+type mapStore struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (m *mapStore) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return v, nil
+}
+
+func (m *mapStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func TestKeySet_ValidatesRS256Token(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newJWKSServer(t, rsaJWK("k1", &priv.PublicKey))
+	ks := NewKeySet(KeySetConfig{URL: srv.URL})
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{Subject: "user-1"})
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		parsed, err := jwt.Parse(signed, ks.Keyfunc)
+		if err != nil || !parsed.Valid {
+			t.Fatalf("expected valid token, got %v", err)
+		}
+	}
+
+	if hits := srv.hits.Load(); hits != 1 {
+		t.Errorf("expected keys to be fetched once, got %d", hits)
+	}
+}
+
+func TestKeySet_UnknownKidIsThrottled(t *testing.T) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t, rsaJWK("k1", &priv.PublicKey))
+	ks := NewKeySet(KeySetConfig{URL: srv.URL})
+
+	if _, err := ks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := ks.Key(context.Background(), "missing"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("expected ErrKeyNotFound, got %v", err)
+		}
+	}
+	if hits := srv.hits.Load(); hits != 1 {
+		t.Errorf("expected unknown kids not to refetch within MinInterval, got %d fetches", hits)
+	}
+}
+
+func TestKeySet_ConcurrentMissesFetchOnce(t *testing.T) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{rsaJWK("k1", &priv.PublicKey)}})
+	}))
+	t.Cleanup(srv.Close)
+	ks := NewKeySet(KeySetConfig{URL: srv.URL, MinInterval: time.Nanosecond})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ks.Key(context.Background(), "k1"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := hits.Load(); n != 1 {
+		t.Errorf("expected concurrent lookups to share one fetch, got %d", n)
+	}
+}
+
+func TestKeySet_KeyfuncContext(t *testing.T) {
+	srv := newJWKSServer(t)
+	ks := NewKeySet(KeySetConfig{URL: srv.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	token := &jwt.Token{Header: map[string]interface{}{"kid": "k1"}}
+	if _, err := ks.KeyfuncContext(ctx)(token); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the fetch to use the given context, got %v", err)
+	}
+}
+
+func TestKeySet_ServesStaleKeysWhenIssuerDown(t *testing.T) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t, rsaJWK("k1", &priv.PublicKey))
+	ks := NewKeySet(KeySetConfig{URL: srv.URL, TTL: 10 * time.Millisecond, MinInterval: time.Nanosecond})
+
+	if _, err := ks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	srv.failing.Store(true)
+	time.Sleep(20 * time.Millisecond)
+
+	key, err := ks.Key(context.Background(), "k1")
+	if err != nil || key == nil {
+		t.Fatalf("expected stale key to be served, got %v", err)
+	}
+	if hits := srv.hits.Load(); hits != 2 {
+		t.Errorf("expected a refresh attempt, got %d fetches", hits)
+	}
+}
+
+func TestKeySet_SharedStore(t *testing.T) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t, rsaJWK("k1", &priv.PublicKey))
+	store := &mapStore{data: map[string]string{}}
+
+	first := NewKeySet(KeySetConfig{URL: srv.URL, Store: store})
+	second := NewKeySet(KeySetConfig{URL: srv.URL, Store: store})
+
+	for _, ks := range []*KeySet{first, second} {
+		if _, err := ks.Key(context.Background(), "k1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if hits := srv.hits.Load(); hits != 1 {
+		t.Errorf("expected second instance to reuse the stored key set, got %d fetches", hits)
+	}
+}
+
+func TestKeySet_SharedStoreRotation(t *testing.T) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys := []map[string]string{rsaJWK("k1", &priv.PublicKey)}
+	var mu sync.Mutex
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(srv.Close)
+	store := &mapStore{data: map[string]string{}}
+	ks := NewKeySet(KeySetConfig{URL: srv.URL, Store: store, MinInterval: time.Nanosecond})
+
+	if _, err := ks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	keys = append(keys, rsaJWK("k2", &priv.PublicKey))
+	mu.Unlock()
+
+	// The stored copy lacks k2 and is fresh; the issuer must be asked anyway
+	if _, err := ks.Key(context.Background(), "k2"); err != nil {
+		t.Fatalf("expected rotated key to be fetched from the issuer, got %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("expected 2 fetches, got %d", n)
+	}
+
+	other := NewKeySet(KeySetConfig{URL: srv.URL, Store: store})
+	if _, err := other.Key(context.Background(), "k2"); err != nil {
+		t.Fatalf("expected the rotated key set to be shared, got %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("expected another instance to reuse the stored rotation, got %d fetches", n)
+	}
+}
+
+func TestKeySet_SharedStoreKeepsFetchTime(t *testing.T) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t, rsaJWK("k1", &priv.PublicKey))
+	doc, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{rsaJWK("k1", &priv.PublicKey)}})
+
+	tests := []struct {
+		name      string
+		stored    string
+		wantFetch int32
+	}{
+		{"fresh copy", storedDoc(time.Now().Add(-30*time.Minute), doc), 0},
+		{"copy older than TTL", storedDoc(time.Now().Add(-2*time.Hour), doc), 1},
+		{"raw document", string(doc), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := srv.hits.Load()
+			store := &mapStore{data: map[string]string{"jwks:" + srv.URL: tt.stored}}
+			ks := NewKeySet(KeySetConfig{URL: srv.URL, Store: store})
+			if _, err := ks.Key(context.Background(), "k1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := srv.hits.Load() - before; got != tt.wantFetch {
+				t.Errorf("fetches = %d, want %d", got, tt.wantFetch)
+			}
+		})
+	}
+
+	// Keys loaded from a copy fetched 59 minutes ago expire in a minute
+	store := &mapStore{data: map[string]string{"jwks:" + srv.URL: storedDoc(time.Now().Add(-59*time.Minute), doc)}}
+	ks := NewKeySet(KeySetConfig{URL: srv.URL, Store: store})
+	if err := ks.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if age := time.Since(ks.fetchedAt); age < 59*time.Minute {
+		t.Errorf("fetch time = %v ago, want the original fetch time", age)
+	}
+}
+
+func storedDoc(fetchedAt time.Time, doc []byte) string {
+	data, _ := json.Marshal(storedKeySet{FetchedAt: fetchedAt, JWKS: doc})
+	return string(data)
+}
+
+func TestParseKeySet(t *testing.T) {
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecdhKey, _ := ec.PublicKey.ECDH()
+	point := ecdhKey.Bytes()
+
+	doc, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "EC", "kid": "ec", "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(point[1:33]),
+			"y": base64.RawURLEncoding.EncodeToString(point[33:])},
+		{"kty": "oct", "kid": "symmetric", "k": "c2VjcmV0"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+
+	keys, err := ParseKeySet(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("expected only the EC signing key, got %d keys", len(keys))
+	}
+	if pub, ok := keys["ec"].(*ecdsa.PublicKey); !ok || !pub.Equal(&ec.PublicKey) {
+		t.Errorf("EC key did not round-trip")
+	}
+}
@@ -1,7 +1,14 @@
 package auth
 
 import (
+	"context"
+	"crypto"
+	"crypto/rand"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,6 +17,8 @@ import (
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("token expired")
+	ErrNoSigningKey = errors.New("no signing key configured")
+	ErrNoKeys       = errors.New("no signing key, verification key or key set configured")
 )
 
 // Claims represents JWT claims
@@ -21,10 +30,70 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// Scopes returns the OAuth scopes granted by the "scope" claim, a space
+// separated string, or the "scopes" claim, a list
+func (c *Claims) Scopes() []string {
+	if scope, ok := c.Extra["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	var scopes []string
+	switch list := c.Extra["scopes"].(type) {
+	case []string:
+		scopes = append(scopes, list...)
+	case []interface{}:
+		for _, s := range list {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+	}
+	return scopes
+}
+
+// HasScopes reports whether the claims grant all scopes
+func (c *Claims) HasScopes(scopes ...string) bool {
+	granted := c.Scopes()
+	for _, s := range scopes {
+		if !slices.Contains(granted, s) {
+			return false
+		}
+	}
+	return true
+}
+
 // JWTManager handles JWT token operations
 type JWTManager struct {
 	secret     []byte
 	expiration time.Duration
+
+	// Asymmetric keys, see NewJWTManagerWithConfig
+	mu         sync.RWMutex
+	signingKey *Key
+	keys       map[string]*Key
+	keySet     *KeySet
+	issuer     string
+	audience   string
+	methods    []string
+	denylist   Denylist
+}
+
+// JWTConfig configures a JWTManager with asymmetric keys
+type JWTConfig struct {
+	Expiration time.Duration // Lifetime of generated tokens
+	SigningKey *Key          // Signs generated tokens; nil to only validate
+	Keys       []*Key        // Further verification keys, e.g. retired after a rotation
+	KeySet     *KeySet       // Remote JWKS, e.g. of Keycloak, Auth0 or Cognito, for kids not in Keys
+	Issuer     string        // Set in generated tokens and required in validated ones, if not empty
+	Audience   string        // Set in generated tokens and required in validated ones, if not empty
+
+	// Methods restricts the accepted algorithms, e.g. []string{"RS256"}.
+	// Default is every RSA, ECDSA and EdDSA algorithm, each only accepted
+	// with a key of its type; HMAC is never accepted.
+	Methods []string
+
+	// Denylist holds the IDs of revoked tokens, see JWTManager.Revoke.
+	// Default is in memory; share one, e.g. in Redis, between instances.
+	Denylist Denylist
 }
 
 // NewJWTManager creates a new JWT manager
@@ -32,7 +101,100 @@ func NewJWTManager(secret string, expiration time.Duration) *JWTManager {
 	return &JWTManager{
 		secret:     []byte(secret),
 		expiration: expiration,
+		denylist:   NewMemoryDenylist(),
+	}
+}
+
+// NewJWTManagerWithConfig creates a JWT manager signing with RSA, ECDSA or
+// EdDSA keys, validating tokens by their kid header against the configured
+// keys and then the key set
+//
+//	key, _ := auth.LoadPrivateKeyFile("2024-06", "/etc/keys/jwt.pem")
+//	m, _ := auth.NewJWTManagerWithConfig(auth.JWTConfig{SigningKey: key, Expiration: time.Hour})
+func NewJWTManagerWithConfig(config JWTConfig) (*JWTManager, error) {
+	if config.SigningKey == nil && len(config.Keys) == 0 && config.KeySet == nil {
+		return nil, ErrNoKeys
+	}
+	methods := config.Methods
+	if len(methods) == 0 {
+		methods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+	}
+	m := &JWTManager{
+		expiration: config.Expiration,
+		keys:       make(map[string]*Key),
+		keySet:     config.KeySet,
+		issuer:     config.Issuer,
+		audience:   config.Audience,
+		methods:    methods,
+		denylist:   config.Denylist,
+	}
+	if m.denylist == nil {
+		m.denylist = NewMemoryDenylist()
+	}
+	for _, key := range config.Keys {
+		if err := m.AddKey(key); err != nil {
+			return nil, err
+		}
+	}
+	if config.SigningKey != nil {
+		if err := m.Rotate(config.SigningKey); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Rotate makes key sign the tokens generated from now on. The previous
+// signing key keeps validating the tokens it signed until RemoveKey.
+func (m *JWTManager) Rotate(key *Key) error {
+	if key.Private == nil {
+		return fmt.Errorf("key %q has no private key", key.ID)
+	}
+	if err := m.AddKey(key); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.signingKey = key
+	return nil
+}
+
+// AddKey adds a verification key, replacing the key with the same ID
+func (m *JWTManager) AddKey(key *Key) error {
+	if m.keys == nil {
+		return errors.New("keys need a manager from NewJWTManagerWithConfig")
 	}
+	if key.Method == nil || !methodMatches(key.Method, key.Public) {
+		return fmt.Errorf("key %q: %w", key.ID, errUnsupportedKey)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key.ID] = key
+	return nil
+}
+
+// RemoveKey stops accepting tokens signed by the key with the given ID; the
+// signing key cannot be removed
+func (m *JWTManager) RemoveKey(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.signingKey != nil && m.signingKey.ID == id {
+		return
+	}
+	delete(m.keys, id)
+}
+
+// KeySet returns the JWKS document of the public keys, to be served on e.g.
+// /.well-known/jwks.json for other services
+func (m *JWTManager) KeySet() ([]byte, error) {
+	m.mu.RLock()
+	keys := make([]*Key, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+	m.mu.RUnlock()
+	slices.SortFunc(keys, func(a, b *Key) int { return strings.Compare(a.ID, b.ID) })
+	return MarshalKeySet(keys...)
 }
 
 // GenerateToken generates a new JWT token
@@ -43,36 +205,118 @@ func (m *JWTManager) GenerateToken(userID, username, role string, extra map[stri
 		Role:     role,
 		Extra:    extra,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        rand.Text(), // jti, to revoke the token
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	if m.secret != nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(m.secret)
+	}
+
+	m.mu.RLock()
+	key := m.signingKey
+	m.mu.RUnlock()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	claims.Issuer = m.issuer
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
 // ValidateToken validates and parses JWT token
 func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
+	return m.ValidateTokenContext(context.Background(), tokenString)
+}
+
+// ValidateTokenContext validates and parses JWT token, rejecting tokens
+// revoked with Revoke; ctx bounds fetching the key set and the denylist
+func (m *JWTManager) ValidateTokenContext(ctx context.Context, tokenString string) (*Claims, error) {
+	var token *jwt.Token
+	var err error
+	if m.secret != nil {
+		token, err = jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, ErrInvalidToken
+			}
+			return m.secret, nil
+		})
+	} else {
+		opts := []jwt.ParserOption{jwt.WithValidMethods(m.methods)}
+		if m.issuer != "" {
+			opts = append(opts, jwt.WithIssuer(m.issuer))
+		}
+		if m.audience != "" {
+			opts = append(opts, jwt.WithAudience(m.audience))
 		}
-		return m.secret, nil
-	})
+		token, err = jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+			return m.verificationKey(ctx, token)
+		}, opts...)
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if err := m.checkRevoked(ctx, claims); err != nil {
+			return nil, err
+		}
+		if claims.UserID == "" {
+			// Tokens of identity providers carry the user in "sub"
+			claims.UserID = claims.Subject
+		}
 		return claims, nil
 	}
 
 	return nil, ErrInvalidToken
 }
 
+// verificationKey resolves the key of a token by its kid header, from the
+// configured keys and then the key set. A token without kid is accepted
+// when there is a single configured key.
+func (m *JWTManager) verificationKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	if !ok && kid == "" && len(m.keys) == 1 {
+		for _, only := range m.keys {
+			key, ok = only, true
+		}
+	}
+	m.mu.RUnlock()
+
+	var public crypto.PublicKey
+	switch {
+	case ok:
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		public = key.Public
+	case m.keySet != nil:
+		var err error
+		if public, err = m.keySet.Key(ctx, kid); err != nil {
+			return nil, err
+		}
+		if !methodMatches(token.Method, public) {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrKeyNotFound
+	}
+	return public, nil
+}
+
 // RefreshToken generates a new token with extended expiration
 func (m *JWTManager) RefreshToken(tokenString string) (string, error) {
 	claims, err := m.ValidateToken(tokenString)
@@ -1,8 +1,17 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func TestNewJWTManager(t *testing.T) {
@@ -120,3 +129,158 @@ func TestJWTManager_ExpiredToken(t *testing.T) {
 		t.Error("expected error for expired token")
 	}
 }
+
+func TestClaims_Scopes(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra map[string]interface{}
+		want  bool
+	}{
+		{"scope string", map[string]interface{}{"scope": "orders:read orders:write"}, true},
+		{"scopes list", map[string]interface{}{"scopes": []interface{}{"orders:read", "orders:write"}}, true},
+		{"missing one", map[string]interface{}{"scope": "orders:read"}, false},
+		{"no scopes", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{Extra: tt.extra}
+			if got := claims.HasScopes("orders:read", "orders:write"); got != tt.want {
+				t.Errorf("HasScopes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newKey(t *testing.T, id string, signer crypto.Signer) *Key {
+	t.Helper()
+	method, err := methodFor(signer.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Key{ID: id, Method: method, Private: signer, Public: signer.Public()}
+}
+
+func TestJWTManager_AsymmetricKeys(t *testing.T) {
+	for alg, signer := range generateKeys(t) {
+		t.Run(alg, func(t *testing.T) {
+			manager, err := NewJWTManagerWithConfig(JWTConfig{
+				SigningKey: newKey(t, "k1", signer),
+				Expiration: time.Hour,
+				Issuer:     "https://auth.example.com",
+				Audience:   "api",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := manager.GenerateToken("user-123", "john", "admin", nil)
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+			claims, err := manager.ValidateToken(token)
+			if err != nil {
+				t.Fatalf("failed to validate token: %v", err)
+			}
+			if claims.UserID != "user-123" || claims.Issuer != "https://auth.example.com" {
+				t.Errorf("unexpected claims %+v", claims)
+			}
+
+			// Only the public key validates
+			verifier, _ := NewJWTManagerWithConfig(JWTConfig{
+				Keys:     []*Key{{ID: "k1", Method: manager.signingKey.Method, Public: signer.Public()}},
+				Audience: "other",
+			})
+			if _, err := verifier.ValidateToken(token); err == nil {
+				t.Error("expected a token for another audience to be rejected")
+			}
+		})
+	}
+}
+
+func TestJWTManager_Rotate(t *testing.T) {
+	keys := generateKeys(t)
+	manager, err := NewJWTManagerWithConfig(JWTConfig{SigningKey: newKey(t, "old", keys["RS256"]), Expiration: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _ := manager.GenerateToken("user-1", "", "", nil)
+
+	if err := manager.Rotate(newKey(t, "new", keys["ES256"])); err != nil {
+		t.Fatal(err)
+	}
+	newToken, _ := manager.GenerateToken("user-1", "", "", nil)
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := manager.ValidateToken(token); err != nil {
+			t.Errorf("%s token: %v", name, err)
+		}
+	}
+
+	manager.RemoveKey("old")
+	if _, err := manager.ValidateToken(oldToken); err == nil {
+		t.Error("expected token of a removed key to be rejected")
+	}
+	manager.RemoveKey("new")
+	if _, err := manager.ValidateToken(newToken); err != nil {
+		t.Errorf("signing key must not be removable: %v", err)
+	}
+
+	doc, err := manager.KeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, _ := ParseKeySet(doc); len(parsed) != 1 || parsed["new"] == nil {
+		t.Errorf("expected the key set to hold the new key, got %v", parsed)
+	}
+}
+
+func TestJWTManager_KeySet(t *testing.T) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t, rsaJWK("idp", &priv.PublicKey))
+	manager, err := NewJWTManagerWithConfig(JWTConfig{KeySet: NewKeySet(KeySetConfig{URL: srv.URL})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+			Subject:   "user-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	claims, err := manager.ValidateToken(sign(jwt.SigningMethodRS256, "idp", priv))
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if claims.UserID != "user-1" {
+		t.Errorf("expected UserID from sub, got %q", claims.UserID)
+	}
+
+	// HS256 keyed with the public key must not pass for RS256
+	publicDER := x509.MarshalPKCS1PublicKey(&priv.PublicKey)
+	if _, err := manager.ValidateToken(sign(jwt.SigningMethodHS256, "idp", publicDER)); err == nil {
+		t.Error("expected HS256 token to be rejected")
+	}
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := manager.ValidateToken(sign(jwt.SigningMethodES256, "idp", ecKey)); err == nil {
+		t.Error("expected ES256 token for an RSA key to be rejected")
+	}
+
+	if _, err := manager.GenerateToken("user-1", "", "", nil); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("expected ErrNoSigningKey, got %v", err)
+	}
+}
+
+func TestNewJWTManagerWithConfig_NoKeys(t *testing.T) {
+	if _, err := NewJWTManagerWithConfig(JWTConfig{}); !errors.Is(err, ErrNoKeys) {
+		t.Errorf("expected ErrNoKeys, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var errNoPEM = errors.New("no PEM block found")
+
+// Key is an asymmetric key of a JWTManager. Private is only needed to sign;
+// verification keys, e.g. retired after a rotation, only have Public.
+type Key struct {
+	ID      string            // kid header of the tokens it signs
+	Method  jwt.SigningMethod // Inferred from the key type when loaded from PEM
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// ParsePrivateKeyPEM parses a PKCS#8, PKCS#1 (RSA) or SEC 1 (EC) private key.
+// The method is RS256 for RSA, ES256/ES384/ES512 by curve and EdDSA for
+// Ed25519; set Method to use e.g. PS256 instead.
+func ParsePrivateKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errNoPEM
+	}
+
+	var (
+		parsed interface{}
+		err    error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errUnsupportedKey
+	}
+	method, err := methodFor(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: id, Method: method, Private: signer, Public: signer.Public()}, nil
+}
+
+// ParsePublicKeyPEM parses a PKIX or PKCS#1 (RSA) public key, or the key of
+// a certificate
+func ParsePublicKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errNoPEM
+	}
+
+	var (
+		public crypto.PublicKey
+		err    error
+	)
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			public = cert.PublicKey
+		}
+	default:
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	method, err := methodFor(public)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: id, Method: method, Public: public}, nil
+}
+
+// LoadPrivateKeyFile reads a private key with ParsePrivateKeyPEM
+func LoadPrivateKeyFile(id, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKeyPEM(id, data)
+}
+
+// LoadPublicKeyFile reads a public key with ParsePublicKeyPEM
+func LoadPublicKeyFile(id, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKeyPEM(id, data)
+}
+
+// methodFor returns the default signing method of a public key
+func methodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, errUnsupportedKey
+}
+
+// methodMatches reports whether a token signed with method can be verified
+// with public, so a token cannot pick an algorithm its key was not made for
+func methodMatches(method jwt.SigningMethod, public crypto.PublicKey) bool {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		m, ok := method.(*jwt.SigningMethodECDSA)
+		return ok && m.CurveBits == key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	}
+	return false
+}
+
+// MarshalKeySet encodes the public keys as a JWKS document, to be served for
+// other services validating the tokens
+func MarshalKeySet(keys ...*Key) ([]byte, error) {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{Keys: make([]jwk, 0, len(keys))}
+
+	for _, key := range keys {
+		k := jwk{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			k.Kty = "RSA"
+			k.N = encodeBase64URL(public.N.Bytes())
+			k.E = encodeBase64URL(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			point, err := public.Bytes() // 0x04 || X || Y
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", key.ID, err)
+			}
+			size := (len(point) - 1) / 2
+			k.Kty = "EC"
+			k.Crv = public.Curve.Params().Name
+			k.X = encodeBase64URL(point[1 : 1+size])
+			k.Y = encodeBase64URL(point[1+size:])
+		case ed25519.PublicKey:
+			k.Kty = "OKP"
+			k.Crv = "Ed25519"
+			k.X = encodeBase64URL(public)
+		default:
+			return nil, fmt.Errorf("key %q: %w", key.ID, errUnsupportedKey)
+		}
+		set.Keys = append(set.Keys, k)
+	}
+
+	return json.Marshal(set)
+}
+
+func encodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func generateKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]crypto.Signer{"RS256": rsaKey, "ES256": ecKey, "EdDSA": edKey}
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	for alg, signer := range generateKeys(t) {
+		t.Run(alg, func(t *testing.T) {
+			der, err := x509.MarshalPKCS8PrivateKey(signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := ParsePrivateKeyPEM("k1", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+			if err != nil {
+				t.Fatalf("ParsePrivateKeyPEM: %v", err)
+			}
+			if key.ID != "k1" || key.Method.Alg() != alg {
+				t.Errorf("got kid %q method %s, want k1 %s", key.ID, key.Method.Alg(), alg)
+			}
+
+			der, err = x509.MarshalPKIXPublicKey(signer.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+			public, err := ParsePublicKeyPEM("k1", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+			if err != nil {
+				t.Fatalf("ParsePublicKeyPEM: %v", err)
+			}
+			if public.Private != nil || public.Method.Alg() != alg {
+				t.Errorf("got method %s, want %s without private key", public.Method.Alg(), alg)
+			}
+		})
+	}
+
+	t.Run("PKCS#1 RSA", func(t *testing.T) {
+		rsaKey := generateKeys(t)["RS256"].(*rsa.PrivateKey)
+		data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+		if _, err := ParsePrivateKeyPEM("k1", data); err != nil {
+			t.Errorf("ParsePrivateKeyPEM: %v", err)
+		}
+	})
+
+	t.Run("not PEM", func(t *testing.T) {
+		if _, err := ParsePrivateKeyPEM("k1", []byte("secret")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestMarshalKeySet(t *testing.T) {
+	var keys []*Key
+	for alg, signer := range generateKeys(t) {
+		method, err := methodFor(signer.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, &Key{ID: alg, Method: method, Public: signer.Public()})
+	}
+
+	doc, err := MarshalKeySet(keys...)
+	if err != nil {
+		t.Fatalf("MarshalKeySet: %v", err)
+	}
+	parsed, err := ParseKeySet(doc)
+	if err != nil {
+		t.Fatalf("ParseKeySet: %v", err)
+	}
+	for _, key := range keys {
+		public, ok := parsed[key.ID].(interface{ Equal(crypto.PublicKey) bool })
+		if !ok || !public.Equal(key.Public) {
+			t.Errorf("key %s did not round-trip", key.ID)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrLocked             = errors.New("account locked")
+	ErrInvalidUnlockToken = errors.New("invalid unlock token")
+)
+
+// LockedError is returned for locked accounts; errors.Is(err, ErrLocked)
+// matches it
+type LockedError struct {
+	Until time.Time // When the lock expires
+}
+
+// Error implements the error interface
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("account locked until %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+// Is matches ErrLocked
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// RetryAfter returns how long the lock lasts, e.g. for a Retry-After header
+func (e *LockedError) RetryAfter() time.Duration {
+	return max(0, time.Until(e.Until))
+}
+
+// LockoutStore keeps failed login counters and locks. *cache.Manager
+// provides a Redis implementation with LockoutStore, shared by all
+// instances.
+type LockoutStore interface {
+	// Incr adds one to the counter of key and returns it; the counter
+	// expires window after its first increment
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Get returns the value of key, "" when there is none
+	Get(ctx context.Context, key string) (string, error)
+	// SetNX sets key to value for ttl unless key is set, reporting whether
+	// it did
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Del removes keys
+	Del(ctx context.Context, keys ...string) error
+}
+
+// LockoutConfig holds login throttling configuration
+type LockoutConfig struct {
+	MaxAttempts int           // Failed logins before locking (default 5)
+	Window      time.Duration // Period failures are counted in (default 15m)
+	LockFor     time.Duration // How long a lock lasts (default 15m)
+
+	Store LockoutStore // Default in memory, for a single instance
+}
+
+// Lockout throttles logins by locking a key, such as "user:"+email or
+// "ip:"+address, after too many failures within a window. Locks expire on
+// their own or are lifted early with the unlock token handed out when
+// locking, e.g. mailed to the user.
+//
+//	if err := lockout.Check(ctx, key); err != nil {
+//		return err // 429 with Retry-After
+//	}
+//	if !util.CheckPassword(password, user.PasswordHash) {
+//		token, err := lockout.Fail(ctx, key)
+//		if errors.Is(err, auth.ErrLocked) && token != "" {
+//			sendUnlockMail(user, token)
+//		}
+//		return errInvalidLogin
+//	}
+//	lockout.Succeed(ctx, key)
+type Lockout struct {
+	config LockoutConfig
+}
+
+// NewLockout creates a Lockout
+func NewLockout(config LockoutConfig) *Lockout {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.Window <= 0 {
+		config.Window = 15 * time.Minute
+	}
+	if config.LockFor <= 0 {
+		config.LockFor = 15 * time.Minute
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryLockoutStore()
+	}
+	return &Lockout{config: config}
+}
+
+// Check returns a *LockedError while key is locked
+func (l *Lockout) Check(ctx context.Context, key string) error {
+	until, _, err := l.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !until.IsZero() {
+		return &LockedError{Until: until}
+	}
+	return nil
+}
+
+// Fail records a failed login for key. The failure locking it returns the
+// unlock token with a *LockedError; only a hash of the token is stored.
+// Concurrent failures past the limit return the *LockedError of that lock
+// without a token.
+func (l *Lockout) Fail(ctx context.Context, key string) (string, error) {
+	n, err := l.config.Store.Incr(ctx, "fail:"+key, l.config.Window)
+	if err != nil {
+		return "", err
+	}
+	if n < int64(l.config.MaxAttempts) {
+		return "", nil
+	}
+
+	token := rand.Text()
+	until := time.Now().Add(l.config.LockFor)
+	value := strconv.FormatInt(until.UnixNano(), 10) + ":" + hashUnlockToken(token)
+	locked, err := l.config.Store.SetNX(ctx, "lock:"+key, value, l.config.LockFor)
+	if err != nil {
+		return "", err
+	}
+	if !locked {
+		// Another failure locked it first and hands out the token
+		if until, _, err = l.lock(ctx, key); err != nil || until.IsZero() {
+			return "", err
+		}
+		return "", &LockedError{Until: until}
+	}
+	if err := l.config.Store.Del(ctx, "fail:"+key); err != nil {
+		return "", err
+	}
+	return token, &LockedError{Until: until}
+}
+
+// Succeed clears the failed logins of key after a successful login
+func (l *Lockout) Succeed(ctx context.Context, key string) error {
+	return l.config.Store.Del(ctx, "fail:"+key)
+}
+
+// Unlock lifts the lock of key with the token Fail returned, failing with
+// ErrInvalidUnlockToken for any other token or when key is not locked
+func (l *Lockout) Unlock(ctx context.Context, key, token string) error {
+	until, hash, err := l.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	if until.IsZero() || subtle.ConstantTimeCompare([]byte(hash), []byte(hashUnlockToken(token))) != 1 {
+		return ErrInvalidUnlockToken
+	}
+	return l.config.Store.Del(ctx, "lock:"+key, "fail:"+key)
+}
+
+// lock returns the expiry and token hash of the lock of key, a zero time
+// when it is not locked
+func (l *Lockout) lock(ctx context.Context, key string) (time.Time, string, error) {
+	value, err := l.config.Store.Get(ctx, "lock:"+key)
+	if err != nil || value == "" {
+		return time.Time{}, "", err
+	}
+	nanos, hash, _ := strings.Cut(value, ":")
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("malformed lock of %s: %w", key, err)
+	}
+	until := time.Unix(0, n)
+	if !time.Now().Before(until) {
+		return time.Time{}, "", nil // Expired, not yet dropped by the store
+	}
+	return until, hash, nil
+}
+
+func hashUnlockToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryLockoutStore is a LockoutStore for a single instance
+type MemoryLockoutStore struct {
+	mu      sync.Mutex
+	entries map[string]lockoutEntry
+	swept   time.Time
+}
+
+type lockoutEntry struct {
+	value   string
+	count   int64
+	expires time.Time
+}
+
+// NewMemoryLockoutStore creates an empty MemoryLockoutStore
+func NewMemoryLockoutStore() *MemoryLockoutStore {
+	return &MemoryLockoutStore{entries: make(map[string]lockoutEntry)}
+}
+
+// Incr implements LockoutStore
+func (s *MemoryLockoutStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		entry = lockoutEntry{expires: now.Add(window)}
+	}
+	entry.count++
+	s.entries[key] = entry
+	return entry.count, nil
+}
+
+// Get implements LockoutStore
+func (s *MemoryLockoutStore) Get(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || !time.Now().Before(entry.expires) {
+		return "", nil
+	}
+	return entry.value, nil
+}
+
+// SetNX implements LockoutStore
+func (s *MemoryLockoutStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expires) {
+		return false, nil
+	}
+	s.entries[key] = lockoutEntry{value: value, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Del implements LockoutStore
+func (s *MemoryLockoutStore) Del(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// sweep drops expired entries once a minute, so the store does not grow
+// forever
+func (s *MemoryLockoutStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	for key, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.swept = now
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	l := NewLockout(LockoutConfig{MaxAttempts: 3, Window: time.Minute, LockFor: time.Minute})
+
+	for i := 1; i < 3; i++ {
+		if token, err := l.Fail(ctx, "user:ann"); token != "" || err != nil {
+			t.Fatalf("failure %d = %q, %v, want no lock", i, token, err)
+		}
+	}
+	if err := l.Succeed(ctx, "user:ann"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < 3; i++ {
+		_, _ = l.Fail(ctx, "user:ann")
+	}
+	if err := l.Check(ctx, "user:ann"); err != nil {
+		t.Fatalf("Check after a success reset the count = %v", err)
+	}
+
+	token, err := l.Fail(ctx, "user:ann")
+	var locked *LockedError
+	if token == "" || !errors.As(err, &locked) || !errors.Is(err, ErrLocked) {
+		t.Fatalf("third failure = %q, %v, want unlock token and LockedError", token, err)
+	}
+	if d := locked.RetryAfter(); d <= 0 || d > time.Minute {
+		t.Errorf("RetryAfter = %v", d)
+	}
+	if err := l.Check(ctx, "user:ann"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Check = %v, want ErrLocked", err)
+	}
+	if err := l.Check(ctx, "user:bob"); err != nil {
+		t.Errorf("Check of another key = %v", err)
+	}
+
+	if err := l.Unlock(ctx, "user:ann", "wrong"); !errors.Is(err, ErrInvalidUnlockToken) {
+		t.Errorf("Unlock with a wrong token = %v", err)
+	}
+	if err := l.Unlock(ctx, "user:ann", token); err != nil {
+		t.Fatalf("Unlock = %v", err)
+	}
+	if err := l.Check(ctx, "user:ann"); err != nil {
+		t.Errorf("Check after Unlock = %v", err)
+	}
+	if err := l.Unlock(ctx, "user:ann", token); !errors.Is(err, ErrInvalidUnlockToken) {
+		t.Errorf("second Unlock = %v, want ErrInvalidUnlockToken", err)
+	}
+}
+
+func TestLockout_Expiry(t *testing.T) {
+	ctx := context.Background()
+	l := NewLockout(LockoutConfig{MaxAttempts: 2, Window: 20 * time.Millisecond, LockFor: 20 * time.Millisecond})
+
+	_, _ = l.Fail(ctx, "ip:10.0.0.1")
+	time.Sleep(30 * time.Millisecond)
+	if _, err := l.Fail(ctx, "ip:10.0.0.1"); err != nil {
+		t.Fatalf("failure after the window = %v, want a new count", err)
+	}
+	if _, err := l.Fail(ctx, "ip:10.0.0.1"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second failure in the window = %v, want ErrLocked", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if err := l.Check(ctx, "ip:10.0.0.1"); err != nil {
+		t.Errorf("Check after the lock expired = %v", err)
+	}
+}
+
+func TestLockout_ConcurrentFailures(t *testing.T) {
+	ctx := context.Background()
+	l := NewLockout(LockoutConfig{MaxAttempts: 2, Window: time.Minute, LockFor: time.Minute})
+	_, _ = l.Fail(ctx, "user:ann")
+
+	tokens := make(chan string, 10)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Failures after the lock start a new count
+			token, err := l.Fail(ctx, "user:ann")
+			if err != nil && !errors.Is(err, ErrLocked) {
+				t.Errorf("concurrent failure = %v", err)
+			}
+			if token != "" {
+				tokens <- token
+			}
+		}()
+	}
+	wg.Wait()
+	close(tokens)
+
+	var issued []string
+	for token := range tokens {
+		issued = append(issued, token)
+	}
+	if len(issued) != 1 {
+		t.Fatalf("issued %d unlock tokens, want 1", len(issued))
+	}
+	if err := l.Unlock(ctx, "user:ann", issued[0]); err != nil {
+		t.Errorf("Unlock with the issued token = %v", err)
+	}
+}
@@ -3,27 +3,30 @@ package auth
 import (
 	"net/http"
 	"strings"
+
+	fwmiddleware "github.com/polymatx/goframe/pkg/framework/middleware"
+	"github.com/polymatx/goframe/pkg/i18n"
 )
 
 // BearerAuth middleware validates JWT bearer token
 func BearerAuth(jwtManager *JWTManager) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
+	return fwmiddleware.Describe(fwmiddleware.Descriptor{Name: "auth.BearerAuth", Authenticates: true}, func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Missing authorization header", http.StatusUnauthorized)
+				http.Error(w, i18n.T(r, i18n.CodeMissingAuthorization), http.StatusUnauthorized)
 				return
 			}
 
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				http.Error(w, i18n.T(r, i18n.CodeInvalidAuthorization), http.StatusUnauthorized)
 				return
 			}
 
-			claims, err := jwtManager.ValidateToken(parts[1])
+			claims, err := jwtManager.ValidateTokenContext(r.Context(), parts[1])
 			if err != nil {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				http.Error(w, i18n.T(r, i18n.CodeInvalidToken), http.StatusUnauthorized)
 				return
 			}
 
@@ -32,36 +35,36 @@ func BearerAuth(jwtManager *JWTManager) func(http.Handler) http.Handler {
 			ctx = WithClaims(ctx, claims)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
-	}
+	})
 }
 
 // BasicAuth middleware validates basic authentication
 func BasicAuth(validator func(username, password string) bool) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
+	return fwmiddleware.Describe(fwmiddleware.Descriptor{Name: "auth.BasicAuth"}, func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			username, password, ok := r.BasicAuth()
 			if !ok || !validator(username, password) {
 				w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				http.Error(w, i18n.T(r, i18n.CodeUnauthorized), http.StatusUnauthorized)
 				return
 			}
 
 			next.ServeHTTP(w, r)
 		})
-	}
+	})
 }
 
 // APIKeyAuth middleware validates API key
 func APIKeyAuth(headerName string, validator func(apiKey string) bool) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
+	return fwmiddleware.Describe(fwmiddleware.Descriptor{Name: "auth.APIKeyAuth"}, func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			apiKey := r.Header.Get(headerName)
 			if apiKey == "" || !validator(apiKey) {
-				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				http.Error(w, i18n.T(r, i18n.CodeInvalidAPIKey), http.StatusUnauthorized)
 				return
 			}
 
 			next.ServeHTTP(w, r)
 		})
-	}
+	})
 }
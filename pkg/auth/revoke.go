@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrRevokedToken = errors.New("token revoked")
+	ErrNoTokenID    = errors.New("token has no jti claim")
+)
+
+// Denylist remembers revoked token IDs until the tokens would have expired.
+// *cache.Manager provides a Redis implementation with Denylist, shared by
+// all instances.
+type Denylist interface {
+	// Add revokes id for ttl
+	Add(ctx context.Context, id string, ttl time.Duration) error
+	// Contains reports whether id is revoked
+	Contains(ctx context.Context, id string) (bool, error)
+}
+
+// SetDenylist replaces the denylist of revoked token IDs, in memory by
+// default; use a shared one such as Redis when running several instances
+func (m *JWTManager) SetDenylist(d Denylist) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.denylist = d
+}
+
+func (m *JWTManager) getDenylist() Denylist {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.denylist
+}
+
+// Revoke invalidates the token with the given jti claim for ttl, which
+// should cover the rest of its lifetime
+func (m *JWTManager) Revoke(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if tokenID == "" {
+		return ErrNoTokenID
+	}
+	return m.getDenylist().Add(ctx, tokenID, ttl)
+}
+
+// RevokeClaims invalidates the token of claims until it expires, e.g. on
+// logout with the claims of the request
+func (m *JWTManager) RevokeClaims(ctx context.Context, claims *Claims) error {
+	ttl := 24 * time.Hour // Tokens without expiry
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+		if ttl <= 0 {
+			return nil // Expired already
+		}
+	}
+	return m.Revoke(ctx, claims.ID, ttl)
+}
+
+// checkRevoked fails for tokens in the denylist. A denylist that cannot be
+// read fails too, so a revoked token is never accepted.
+func (m *JWTManager) checkRevoked(ctx context.Context, claims *Claims) error {
+	if claims.ID == "" {
+		return nil
+	}
+	revoked, err := m.getDenylist().Contains(ctx, claims.ID)
+	if err != nil {
+		logrus.Warnf("Token denylist check failed: %v", err)
+		return fmt.Errorf("token denylist check failed: %w", err)
+	}
+	if revoked {
+		return ErrRevokedToken
+	}
+	return nil
+}
+
+// MemoryDenylist is a Denylist for a single instance
+type MemoryDenylist struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+	swept   time.Time
+}
+
+// NewMemoryDenylist creates an empty MemoryDenylist
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{expires: make(map[string]time.Time)}
+}
+
+// Add implements Denylist
+func (d *MemoryDenylist) Add(ctx context.Context, id string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	// Drop expired IDs once a minute, so the list does not grow forever
+	if now.Sub(d.swept) > time.Minute {
+		for id, expires := range d.expires {
+			if now.After(expires) {
+				delete(d.expires, id)
+			}
+		}
+		d.swept = now
+	}
+	d.expires[id] = now.Add(ttl)
+	return nil
+}
+
+// Contains implements Denylist
+func (d *MemoryDenylist) Contains(ctx context.Context, id string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	expires, ok := d.expires[id]
+	return ok && time.Now().Before(expires), nil
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRevoke(t *testing.T) {
+	manager := NewJWTManager("test-secret", time.Hour)
+	token, _ := manager.GenerateToken("user-1", "john", "user", nil)
+	other, _ := manager.GenerateToken("user-1", "john", "user", nil)
+
+	claims, err := manager.ValidateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ID == "" {
+		t.Fatal("expected generated tokens to have a jti claim")
+	}
+	if err := manager.RevokeClaims(context.Background(), claims); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.ValidateToken(token); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("expected ErrRevokedToken, got %v", err)
+	}
+	if _, err := manager.RefreshToken(token); err == nil {
+		t.Error("expected a revoked token not to refresh")
+	}
+	if _, err := manager.ValidateToken(other); err != nil {
+		t.Errorf("expected other tokens to stay valid, got %v", err)
+	}
+
+	handler := BearerAuth(manager)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for name, tc := range map[string]struct {
+		token string
+		want  int
+	}{
+		"revoked": {token, http.StatusUnauthorized},
+		"valid":   {other, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, tc.want)
+		}
+	}
+
+	if err := manager.Revoke(context.Background(), "", time.Hour); !errors.Is(err, ErrNoTokenID) {
+		t.Errorf("expected ErrNoTokenID, got %v", err)
+	}
+
+	// Revocations are per manager
+	if _, err := NewJWTManager("test-secret", time.Hour).ValidateToken(token); err != nil {
+		t.Errorf("expected another manager's denylist to be separate, got %v", err)
+	}
+}
+
+type failingDenylist struct{}
+
+func (failingDenylist) Add(context.Context, string, time.Duration) error { return nil }
+
+func (failingDenylist) Contains(context.Context, string) (bool, error) {
+	return false, errors.New("unavailable")
+}
+
+func TestRevoke_DenylistUnavailable(t *testing.T) {
+	manager := NewJWTManager("test-secret", time.Hour)
+	manager.SetDenylist(failingDenylist{})
+	token, _ := manager.GenerateToken("user-1", "john", "user", nil)
+	if _, err := manager.ValidateToken(token); err == nil {
+		t.Error("expected tokens to be rejected while the denylist cannot be read")
+	}
+}
+
+func TestJWTConfig_Denylist(t *testing.T) {
+	manager, err := NewJWTManagerWithConfig(JWTConfig{
+		SigningKey: newKey(t, "k1", generateKeys(t)["ES256"]),
+		Expiration: time.Hour,
+		Denylist:   failingDenylist{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := manager.GenerateToken("user-1", "john", "user", nil)
+	if _, err := manager.ValidateToken(token); err == nil {
+		t.Error("expected the configured denylist to be checked")
+	}
+}
+
+func TestMemoryDenylist(t *testing.T) {
+	ctx := context.Background()
+	d := NewMemoryDenylist()
+	_ = d.Add(ctx, "short", time.Millisecond)
+	_ = d.Add(ctx, "long", time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	for id, want := range map[string]bool{"short": false, "long": true, "unknown": false} {
+		if got, _ := d.Contains(ctx, id); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package authz checks role-based permissions of authenticated requests.
+// Roles grant permissions such as "orders:write" and inherit those of other
+// roles; they come from static configuration (StaticStore) or database
+// tables (TableStore). The roles of a request are read from the auth.Claims
+// set by auth.BearerAuth.
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	fwmiddleware "github.com/polymatx/goframe/pkg/framework/middleware"
+	"github.com/polymatx/goframe/pkg/i18n"
+	"github.com/sirupsen/logrus"
+)
+
+// Role grants permissions, and those of the roles it inherits
+type Role struct {
+	Permissions []string `mapstructure:"permissions" json:"permissions" yaml:"permissions"`
+	Inherits    []string `mapstructure:"inherits" json:"inherits,omitempty" yaml:"inherits,omitempty"`
+}
+
+// Store looks roles up by name
+type Store interface {
+	// Role returns the role named name, false when there is none
+	Role(ctx context.Context, name string) (Role, bool, error)
+}
+
+// StaticStore holds roles from configuration, e.g.
+// viper.UnmarshalKey("authz.roles", &roles)
+type StaticStore map[string]Role
+
+// Role implements Store
+func (s StaticStore) Role(ctx context.Context, name string) (Role, bool, error) {
+	role, ok := s[name]
+	return role, ok, nil
+}
+
+// Options holds Authorizer options
+type Options struct {
+	// CacheTTL is how long the resolved permissions of a role are kept
+	// (default 1m, negative for none). Invalidate drops them earlier.
+	CacheTTL time.Duration
+}
+
+// Authorizer resolves the permissions of roles from a store
+type Authorizer struct {
+	store Store
+	ttl   time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]cachedRole
+}
+
+type cachedRole struct {
+	permissions []string
+	expires     time.Time
+}
+
+// New creates an Authorizer for the roles of store
+func New(store Store, opts ...Options) *Authorizer {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.CacheTTL == 0 {
+		o.CacheTTL = time.Minute
+	}
+	return &Authorizer{store: store, ttl: o.CacheTTL, cache: make(map[string]cachedRole)}
+}
+
+// Permissions returns the permissions granted to role, including those of
+// the roles it inherits. Unknown roles have none.
+func (a *Authorizer) Permissions(ctx context.Context, role string) ([]string, error) {
+	a.mu.RLock()
+	cached, ok := a.cache[role]
+	a.mu.RUnlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.permissions, nil
+	}
+
+	var permissions []string
+	seen := map[string]bool{}
+	queue := []string{role}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue // Inherited twice, or a cycle
+		}
+		seen[name] = true
+
+		r, found, err := a.store.Role(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		for _, p := range r.Permissions {
+			if !slices.Contains(permissions, p) {
+				permissions = append(permissions, p)
+			}
+		}
+		queue = append(queue, r.Inherits...)
+	}
+
+	if a.ttl > 0 {
+		a.mu.Lock()
+		a.cache[role] = cachedRole{permissions: permissions, expires: time.Now().Add(a.ttl)}
+		a.mu.Unlock()
+	}
+	return permissions, nil
+}
+
+// Invalidate drops the cached permissions, e.g. after changing roles
+func (a *Authorizer) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache = make(map[string]cachedRole)
+}
+
+// Can reports whether the roles of claims grant all permissions
+func (a *Authorizer) Can(ctx context.Context, claims *auth.Claims, permissions ...string) (bool, error) {
+	granted, err := a.granted(ctx, claims)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range permissions {
+		if !grants(granted, p) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// CanAny reports whether the roles of claims grant any of permissions
+func (a *Authorizer) CanAny(ctx context.Context, claims *auth.Claims, permissions ...string) (bool, error) {
+	granted, err := a.granted(ctx, claims)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range permissions {
+		if grants(granted, p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// granted returns the permissions of all roles of claims
+func (a *Authorizer) granted(ctx context.Context, claims *auth.Claims) ([]string, error) {
+	var granted []string
+	for _, role := range Roles(claims) {
+		permissions, err := a.Permissions(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		granted = append(granted, permissions...)
+	}
+	return granted, nil
+}
+
+// Require returns middleware rejecting requests whose roles lack any of
+// permissions. It must run after authentication: requests without claims
+// get 401, denied requests 403, and store failures 503.
+func (a *Authorizer) Require(permissions ...string) func(http.Handler) http.Handler {
+	return check("authz.Require", func() *Authorizer { return a }, (*Authorizer).Can, permissions)
+}
+
+// RequireAny is Require with one of permissions being enough
+func (a *Authorizer) RequireAny(permissions ...string) func(http.Handler) http.Handler {
+	return check("authz.RequireAny", func() *Authorizer { return a }, (*Authorizer).CanAny, permissions)
+}
+
+var (
+	defaultLock sync.RWMutex
+	defaultAuth = New(StaticStore{})
+)
+
+// SetDefault sets the Authorizer of the package-level functions, which
+// grants nothing until set
+func SetDefault(a *Authorizer) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultAuth = a
+}
+
+// Default returns the Authorizer of the package-level functions
+func Default() *Authorizer {
+	defaultLock.RLock()
+	defer defaultLock.RUnlock()
+	return defaultAuth
+}
+
+// Require is Authorizer.Require of the default Authorizer, looked up for
+// every request so it may be set after the routes
+func Require(permissions ...string) func(http.Handler) http.Handler {
+	return check("authz.Require", Default, (*Authorizer).Can, permissions)
+}
+
+// RequireAny is Authorizer.RequireAny of the default Authorizer
+func RequireAny(permissions ...string) func(http.Handler) http.Handler {
+	return check("authz.RequireAny", Default, (*Authorizer).CanAny, permissions)
+}
+
+// Can is Authorizer.Can of the default Authorizer
+func Can(ctx context.Context, claims *auth.Claims, permissions ...string) (bool, error) {
+	return Default().Can(ctx, claims, permissions...)
+}
+
+type checkFunc func(a *Authorizer, ctx context.Context, claims *auth.Claims, permissions ...string) (bool, error)
+
+func check(name string, authorizer func() *Authorizer, can checkFunc, permissions []string) func(http.Handler) http.Handler {
+	return fwmiddleware.Describe(fwmiddleware.Descriptor{Name: name, NeedsClaims: true}, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.GetClaims(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, i18n.T(r, i18n.CodeUnauthorized))
+				return
+			}
+
+			allowed, err := can(authorizer(), r.Context(), claims, permissions...)
+			if err != nil {
+				logrus.WithError(err).Error("Permission check failed")
+				writeError(w, http.StatusServiceUnavailable, "Authorization unavailable")
+				return
+			}
+			if !allowed {
+				writeError(w, http.StatusForbidden, i18n.T(r, i18n.CodeForbidden))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+// Roles returns the roles of claims: Role, and the "roles" claim, a list
+func Roles(claims *auth.Claims) []string {
+	var roles []string
+	if claims.Role != "" {
+		roles = append(roles, claims.Role)
+	}
+	switch list := claims.Extra["roles"].(type) {
+	case []string:
+		roles = append(roles, list...)
+	case []interface{}:
+		for _, r := range list {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+	}
+	return roles
+}
+
+// Match reports whether the granted permission covers required: equal,
+// "*", or a "resource:*" wildcard such as "orders:*" for "orders:write"
+func Match(granted, required string) bool {
+	if granted == required || granted == "*" {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(granted, "*")
+	return ok && strings.HasSuffix(prefix, ":") && strings.HasPrefix(required, prefix)
+}
+
+func grants(granted []string, required string) bool {
+	for _, g := range granted {
+		if Match(g, required) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,140 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+)
+
+var roles = StaticStore{
+	"viewer": {Permissions: []string{"orders:read", "products:read"}},
+	"clerk":  {Permissions: []string{"orders:write"}, Inherits: []string{"viewer"}},
+	"admin":  {Permissions: []string{"*"}},
+	"cycle":  {Permissions: []string{"reports:*"}, Inherits: []string{"cycle", "clerk"}},
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"orders:read", "orders:read", true},
+		{"orders:read", "orders:write", false},
+		{"orders:*", "orders:write", true},
+		{"orders:*", "ordersx:write", false},
+		{"*", "anything:at:all", true},
+		{"orders*", "orders:write", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.granted, tt.required); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizer_Can(t *testing.T) {
+	a := New(roles)
+	tests := []struct {
+		name        string
+		claims      *auth.Claims
+		permissions []string
+		want        bool
+	}{
+		{"granted", &auth.Claims{Role: "viewer"}, []string{"orders:read"}, true},
+		{"not granted", &auth.Claims{Role: "viewer"}, []string{"orders:write"}, false},
+		{"inherited", &auth.Claims{Role: "clerk"}, []string{"orders:write", "products:read"}, true},
+		{"wildcard", &auth.Claims{Role: "admin"}, []string{"orders:delete"}, true},
+		{"cycle", &auth.Claims{Role: "cycle"}, []string{"reports:export", "orders:read"}, true},
+		{"roles claim", &auth.Claims{Extra: map[string]interface{}{"roles": []interface{}{"viewer", "clerk"}}}, []string{"orders:write"}, true},
+		{"unknown role", &auth.Claims{Role: "ghost"}, []string{"orders:read"}, false},
+		{"no role", &auth.Claims{}, []string{"orders:read"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Can(context.Background(), tt.claims, tt.permissions...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Can = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type countingStore struct {
+	StaticStore
+	calls int
+	err   error
+}
+
+func (s *countingStore) Role(ctx context.Context, name string) (Role, bool, error) {
+	s.calls++
+	if s.err != nil {
+		return Role{}, false, s.err
+	}
+	return s.StaticStore.Role(ctx, name)
+}
+
+func TestAuthorizer_Cache(t *testing.T) {
+	store := &countingStore{StaticStore: roles}
+	a := New(store, Options{CacheTTL: time.Hour})
+	claims := &auth.Claims{Role: "clerk"}
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := a.Can(context.Background(), claims, "orders:read"); !ok {
+			t.Fatal("expected clerk to read orders")
+		}
+	}
+	if store.calls != 2 { // clerk and viewer, once
+		t.Errorf("expected the resolved role to be cached, got %d store calls", store.calls)
+	}
+
+	a.Invalidate()
+	_, _ = a.Can(context.Background(), claims, "orders:read")
+	if store.calls != 4 {
+		t.Errorf("expected Invalidate to drop the cache, got %d store calls", store.calls)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	a := New(roles)
+	SetDefault(a)
+	t.Cleanup(func() { SetDefault(New(StaticStore{})) })
+	failing := New(&countingStore{err: errors.New("database down")})
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		claims     *auth.Claims
+		wantStatus int
+	}{
+		{"no claims", a.Require("orders:write"), nil, http.StatusUnauthorized},
+		{"allowed", a.Require("orders:write"), &auth.Claims{Role: "clerk"}, http.StatusOK},
+		{"denied", a.Require("orders:write"), &auth.Claims{Role: "viewer"}, http.StatusForbidden},
+		{"any", a.RequireAny("orders:write", "orders:read"), &auth.Claims{Role: "viewer"}, http.StatusOK},
+		{"store failure", failing.Require("orders:write"), &auth.Claims{Role: "clerk"}, http.StatusServiceUnavailable},
+		{"default", Require("orders:write"), &auth.Claims{Role: "clerk"}, http.StatusOK},
+		{"default denied", Require("orders:write"), &auth.Claims{Role: "viewer"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			if tt.claims != nil {
+				req = req.WithContext(auth.WithClaims(req.Context(), tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
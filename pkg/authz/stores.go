@@ -0,0 +1,80 @@
+package authz
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RolePermission grants a permission to a role in a TableStore
+type RolePermission struct {
+	Role       string `gorm:"primaryKey;size:100"`
+	Permission string `gorm:"primaryKey;size:191"`
+}
+
+// TableName implements gorm's tabler
+func (RolePermission) TableName() string { return "authz_role_permissions" }
+
+// RoleInherit makes a role inherit the permissions of Parent in a TableStore
+type RoleInherit struct {
+	Role   string `gorm:"primaryKey;size:100"`
+	Parent string `gorm:"primaryKey;size:100"`
+}
+
+// TableName implements gorm's tabler
+func (RoleInherit) TableName() string { return "authz_role_inherits" }
+
+// TableStore keeps roles in the authz_role_permissions and
+// authz_role_inherits tables, for roles managed at runtime. A role exists
+// while it grants or inherits anything.
+type TableStore struct {
+	DB *gorm.DB
+}
+
+// Migrate creates or updates the tables
+func (s *TableStore) Migrate(ctx context.Context) error {
+	return s.DB.WithContext(ctx).AutoMigrate(&RolePermission{}, &RoleInherit{})
+}
+
+// Role implements Store
+func (s *TableStore) Role(ctx context.Context, name string) (Role, bool, error) {
+	var role Role
+	db := s.DB.WithContext(ctx)
+	if err := db.Model(&RolePermission{}).Where("role = ?", name).Order("permission").Pluck("permission", &role.Permissions).Error; err != nil {
+		return Role{}, false, err
+	}
+	if err := db.Model(&RoleInherit{}).Where("role = ?", name).Order("parent").Pluck("parent", &role.Inherits).Error; err != nil {
+		return Role{}, false, err
+	}
+	return role, len(role.Permissions) > 0 || len(role.Inherits) > 0, nil
+}
+
+// Grant grants permissions to role
+func (s *TableStore) Grant(ctx context.Context, role string, permissions ...string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	rows := make([]RolePermission, len(permissions))
+	for i, p := range permissions {
+		rows[i] = RolePermission{Role: role, Permission: p}
+	}
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// Revoke takes permissions away from role
+func (s *TableStore) Revoke(ctx context.Context, role string, permissions ...string) error {
+	return s.DB.WithContext(ctx).Where("role = ? AND permission IN ?", role, permissions).Delete(&RolePermission{}).Error
+}
+
+// Inherit makes role inherit the permissions of parents
+func (s *TableStore) Inherit(ctx context.Context, role string, parents ...string) error {
+	if len(parents) == 0 {
+		return nil
+	}
+	rows := make([]RoleInherit, len(parents))
+	for i, p := range parents {
+		rows[i] = RoleInherit{Role: role, Parent: p}
+	}
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
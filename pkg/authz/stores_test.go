@@ -0,0 +1,57 @@
+package authz
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTableStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "authz.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &TableStore{DB: db}
+	ctx := context.Background()
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range []error{
+		store.Grant(ctx, "viewer", "orders:read", "products:read"),
+		store.Grant(ctx, "viewer", "orders:read"), // Granted twice
+		store.Grant(ctx, "clerk", "orders:write", "orders:delete"),
+		store.Inherit(ctx, "clerk", "viewer"),
+		store.Revoke(ctx, "clerk", "orders:delete"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	role, found, err := store.Role(ctx, "clerk")
+	if err != nil || !found {
+		t.Fatalf("Role = %v, %v", found, err)
+	}
+	if !slices.Equal(role.Permissions, []string{"orders:write"}) || !slices.Equal(role.Inherits, []string{"viewer"}) {
+		t.Errorf("unexpected role %+v", role)
+	}
+	if _, found, _ := store.Role(ctx, "ghost"); found {
+		t.Error("expected an unknown role not to be found")
+	}
+
+	a := New(store)
+	ok, err := a.Can(ctx, &auth.Claims{Role: "clerk"}, "orders:write", "products:read")
+	if err != nil || !ok {
+		t.Errorf("Can = %v, %v, want inherited permissions", ok, err)
+	}
+	if ok, _ := a.Can(ctx, &auth.Claims{Role: "clerk"}, "orders:delete"); ok {
+		t.Error("expected a revoked permission to be denied")
+	}
+}
@@ -0,0 +1,214 @@
+// Package billing provides plans, subscriptions and their lifecycle for SaaS
+// products. It is gateway-agnostic: payment providers report what happened
+// through webhook events, which drive subscription state transitions.
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Interval is a billing period length
+type Interval string
+
+const (
+	Monthly Interval = "month"
+	Yearly  Interval = "year"
+)
+
+// Status is the state of a subscription
+type Status string
+
+const (
+	StatusTrialing Status = "trialing"
+	StatusActive   Status = "active"
+	StatusPastDue  Status = "past_due"
+	StatusCanceled Status = "canceled"
+)
+
+// EventType identifies a billing event reported by the payment gateway
+type EventType string
+
+const (
+	EventPaymentSucceeded EventType = "payment.succeeded"
+	EventPaymentFailed    EventType = "payment.failed"
+	EventTrialEnded       EventType = "trial.ended"
+	EventCanceled         EventType = "subscription.canceled"
+	EventPeriodEnded      EventType = "period.ended"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid subscription state transition")
+	ErrPlanNotFound      = errors.New("plan not found")
+)
+
+// Plan is a purchasable price point
+type Plan struct {
+	ID         uint     `json:"id" gorm:"primarykey"`
+	Code       string   `json:"code" gorm:"size:64;uniqueIndex"`
+	Name       string   `json:"name" gorm:"size:128"`
+	PriceCents int64    `json:"price_cents"`
+	Currency   string   `json:"currency" gorm:"size:3"`
+	Interval   Interval `json:"interval" gorm:"size:16"`
+	TrialDays  int      `json:"trial_days"`
+	Active     bool     `json:"active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Subscription links a customer to a plan
+type Subscription struct {
+	ID                 uint       `json:"id" gorm:"primarykey"`
+	CustomerID         string     `json:"customer_id" gorm:"size:64;index"`
+	PlanID             uint       `json:"plan_id"`
+	Plan               Plan       `json:"plan"`
+	Status             Status     `json:"status" gorm:"size:16;index"`
+	ExternalID         string     `json:"external_id" gorm:"size:128;index"` // Gateway subscription ID
+	TrialEndsAt        *time.Time `json:"trial_ends_at"`
+	CurrentPeriodStart time.Time  `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time  `json:"current_period_end"`
+	CancelAtPeriodEnd  bool       `json:"cancel_at_period_end"`
+	CanceledAt         *time.Time `json:"canceled_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Event is a gateway notification, typically parsed from a webhook
+type Event struct {
+	Type       EventType `json:"type"`
+	ExternalID string    `json:"external_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// NewSubscription starts a subscription at now, in trial if the plan has one
+func NewSubscription(customerID string, plan Plan, now time.Time) *Subscription {
+	s := &Subscription{
+		CustomerID:         customerID,
+		PlanID:             plan.ID,
+		Plan:               plan,
+		Status:             StatusActive,
+		CurrentPeriodStart: now,
+		CurrentPeriodEnd:   AddInterval(now, plan.Interval),
+	}
+
+	if plan.TrialDays > 0 {
+		trialEnd := now.AddDate(0, 0, plan.TrialDays)
+		s.Status = StatusTrialing
+		s.TrialEndsAt = &trialEnd
+		s.CurrentPeriodEnd = trialEnd
+	}
+
+	return s
+}
+
+// Apply transitions the subscription according to the event:
+//
+//	trialing --payment.succeeded--> active (new period)
+//	trialing --trial.ended--------> past_due
+//	active   --payment.succeeded--> active (renewed period)
+//	active   --payment.failed-----> past_due
+//	past_due --payment.succeeded--> active (new period)
+//	any      --subscription.canceled--> canceled
+//	active   --period.ended-------> canceled, when CancelAtPeriodEnd is set
+func (s *Subscription) Apply(e Event) error {
+	at := e.OccurredAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	if s.Status == StatusCanceled {
+		return fmt.Errorf("%w: %s on %s subscription", ErrInvalidTransition, e.Type, s.Status)
+	}
+
+	switch e.Type {
+	case EventPaymentSucceeded:
+		s.Status = StatusActive
+		s.TrialEndsAt = nil
+		s.startPeriod(at)
+
+	case EventPaymentFailed:
+		if s.Status != StatusActive && s.Status != StatusPastDue {
+			return fmt.Errorf("%w: %s on %s subscription", ErrInvalidTransition, e.Type, s.Status)
+		}
+		s.Status = StatusPastDue
+
+	case EventTrialEnded:
+		if s.Status != StatusTrialing {
+			return fmt.Errorf("%w: %s on %s subscription", ErrInvalidTransition, e.Type, s.Status)
+		}
+		s.Status = StatusPastDue
+
+	case EventCanceled:
+		s.cancel(at)
+
+	case EventPeriodEnded:
+		if s.CancelAtPeriodEnd {
+			s.cancel(at)
+		}
+
+	default:
+		return fmt.Errorf("unknown billing event: %s", e.Type)
+	}
+
+	return nil
+}
+
+// Active reports whether the customer should have access
+func (s *Subscription) Active() bool {
+	return s.Status == StatusTrialing || s.Status == StatusActive
+}
+
+func (s *Subscription) startPeriod(at time.Time) {
+	// Renewals continue from the previous period end so periods stay aligned
+	start := at
+	if d := at.Sub(s.CurrentPeriodEnd); !s.CurrentPeriodEnd.IsZero() && d > -24*time.Hour && d < 24*time.Hour {
+		start = s.CurrentPeriodEnd
+	}
+	s.CurrentPeriodStart = start
+	s.CurrentPeriodEnd = AddInterval(start, s.Plan.Interval)
+}
+
+func (s *Subscription) cancel(at time.Time) {
+	s.Status = StatusCanceled
+	s.CanceledAt = &at
+}
+
+// AddInterval returns t advanced by one billing interval
+func AddInterval(t time.Time, interval Interval) time.Time {
+	if interval == Yearly {
+		return t.AddDate(1, 0, 0)
+	}
+	return t.AddDate(0, 1, 0)
+}
+
+// Prorate returns the amount in cents to charge (positive) or credit
+// (negative) when switching from one plan to another at the given time
+// within the current period. Unused time on the old plan is credited and
+// the remaining time on the new plan is charged, both to the second.
+// Plans must share a currency.
+func Prorate(from, to Plan, periodStart, periodEnd, at time.Time) int64 {
+	total := periodEnd.Sub(periodStart)
+	if total <= 0 || !at.Before(periodEnd) {
+		return 0
+	}
+	if at.Before(periodStart) {
+		at = periodStart
+	}
+
+	remaining := periodEnd.Sub(at)
+	fraction := float64(remaining) / float64(total)
+
+	credit := roundCents(float64(from.PriceCents) * fraction)
+	charge := roundCents(float64(to.PriceCents) * fraction)
+	return charge - credit
+}
+
+func roundCents(v float64) int64 {
+	if v < 0 {
+		return -int64(-v + 0.5)
+	}
+	return int64(v + 0.5)
+}
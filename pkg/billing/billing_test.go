@@ -0,0 +1,270 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	t0    = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	basic = Plan{ID: 1, Code: "basic", PriceCents: 1000, Currency: "USD", Interval: Monthly, Active: true}
+	pro   = Plan{ID: 2, Code: "pro", PriceCents: 3000, Currency: "USD", Interval: Monthly, TrialDays: 14, Active: true}
+)
+
+func TestNewSubscription(t *testing.T) {
+	sub := NewSubscription("c1", basic, t0)
+	if sub.Status != StatusActive || sub.TrialEndsAt != nil {
+		t.Fatalf("expected active subscription without trial, got %+v", sub)
+	}
+	if !sub.CurrentPeriodEnd.Equal(t0.AddDate(0, 1, 0)) {
+		t.Errorf("unexpected period end %v", sub.CurrentPeriodEnd)
+	}
+
+	trial := NewSubscription("c1", pro, t0)
+	if trial.Status != StatusTrialing || trial.TrialEndsAt == nil {
+		t.Fatalf("expected trialing subscription, got %+v", trial)
+	}
+	if !trial.CurrentPeriodEnd.Equal(t0.AddDate(0, 0, 14)) {
+		t.Errorf("unexpected trial end %v", trial.CurrentPeriodEnd)
+	}
+}
+
+func TestSubscription_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    Plan
+		events  []EventType
+		want    Status
+		wantErr bool
+	}{
+		{"trial converts", pro, []EventType{EventPaymentSucceeded}, StatusActive, false},
+		{"trial lapses", pro, []EventType{EventTrialEnded}, StatusPastDue, false},
+		{"payment fails", basic, []EventType{EventPaymentFailed}, StatusPastDue, false},
+		{"past due recovers", basic, []EventType{EventPaymentFailed, EventPaymentSucceeded}, StatusActive, false},
+		{"canceled", basic, []EventType{EventCanceled}, StatusCanceled, false},
+		{"period end keeps active", basic, []EventType{EventPeriodEnded}, StatusActive, false},
+		{"canceled is final", basic, []EventType{EventCanceled, EventPaymentSucceeded}, StatusCanceled, true},
+		{"trial end on active", basic, []EventType{EventTrialEnded}, StatusActive, true},
+		{"payment failed in trial", pro, []EventType{EventPaymentFailed}, StatusTrialing, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := NewSubscription("c1", tt.plan, t0)
+			var err error
+			for _, typ := range tt.events {
+				if err = sub.Apply(Event{Type: typ, OccurredAt: t0.Add(time.Hour)}); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("expected ErrInvalidTransition, got %v", err)
+			}
+			if sub.Status != tt.want {
+				t.Errorf("status = %s, want %s", sub.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscription_ApplyCancelAtPeriodEnd(t *testing.T) {
+	sub := NewSubscription("c1", basic, t0)
+	sub.CancelAtPeriodEnd = true
+	if err := sub.Apply(Event{Type: EventPeriodEnded, OccurredAt: sub.CurrentPeriodEnd}); err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status != StatusCanceled || sub.CanceledAt == nil {
+		t.Errorf("expected canceled subscription, got %+v", sub)
+	}
+}
+
+func TestSubscription_ApplyRenewalKeepsPeriodsAligned(t *testing.T) {
+	sub := NewSubscription("c1", basic, t0)
+	end := sub.CurrentPeriodEnd
+
+	// Gateway reports the renewal a few minutes after the period ended
+	if err := sub.Apply(Event{Type: EventPaymentSucceeded, OccurredAt: end.Add(5 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if !sub.CurrentPeriodStart.Equal(end) {
+		t.Errorf("period start = %v, want %v", sub.CurrentPeriodStart, end)
+	}
+	if !sub.CurrentPeriodEnd.Equal(end.AddDate(0, 1, 0)) {
+		t.Errorf("period end = %v", sub.CurrentPeriodEnd)
+	}
+}
+
+func TestProrate(t *testing.T) {
+	start := t0
+	end := t0.AddDate(0, 0, 30)
+
+	tests := []struct {
+		name string
+		from Plan
+		to   Plan
+		at   time.Time
+		want int64
+	}{
+		{"upgrade halfway", basic, pro, t0.AddDate(0, 0, 15), 1000},
+		{"downgrade halfway", pro, basic, t0.AddDate(0, 0, 15), -1000},
+		{"upgrade at start", basic, pro, start, 2000},
+		{"at period end", basic, pro, end, 0},
+		{"same plan", basic, basic, t0.AddDate(0, 0, 10), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Prorate(tt.from, tt.to, start, end, tt.at); got != tt.want {
+				t.Errorf("Prorate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "billing.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	svc := NewService(db)
+	svc.now = func() time.Time { return t0 }
+	if err := svc.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, p := range []Plan{basic, pro} {
+		p.ID = 0
+		if err := svc.CreatePlan(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return svc
+}
+
+func TestService_Lifecycle(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.Subscribe(ctx, "c1", "missing", "ext-0"); !errors.Is(err, ErrPlanNotFound) {
+		t.Fatalf("expected ErrPlanNotFound, got %v", err)
+	}
+
+	sub, err := svc.Subscribe(ctx, "c1", "pro", "ext-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status != StatusTrialing {
+		t.Fatalf("expected trialing, got %s", sub.Status)
+	}
+
+	got, err := svc.HandleEvent(ctx, Event{Type: EventPaymentSucceeded, ExternalID: "ext-1", OccurredAt: t0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusActive || got.Plan.Code != "pro" {
+		t.Errorf("unexpected subscription %+v", got)
+	}
+
+	svc.now = func() time.Time {
+		return got.CurrentPeriodStart.Add(got.CurrentPeriodEnd.Sub(got.CurrentPeriodStart) / 2)
+	}
+	amount, err := svc.ChangePlan(ctx, sub.ID, "basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount != -1000 {
+		t.Errorf("expected credit of 1000, got %d", amount)
+	}
+
+	var stored Subscription
+	if err := svc.db.Preload("Plan").First(&stored, sub.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Plan.Code != "basic" {
+		t.Errorf("expected plan basic, got %s", stored.Plan.Code)
+	}
+}
+
+func TestService_InactivePlan(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	legacy := Plan{Code: "legacy", PriceCents: 500, Currency: "USD", Interval: Monthly}
+	if err := svc.CreatePlan(ctx, &legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored Plan
+	if err := svc.db.First(&stored, legacy.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Active {
+		t.Error("expected inactive plan to be stored inactive")
+	}
+	if _, err := svc.Subscribe(ctx, "c1", "legacy", "ext-1"); !errors.Is(err, ErrPlanNotFound) {
+		t.Errorf("expected ErrPlanNotFound, got %v", err)
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	if _, err := svc.Subscribe(ctx, "c1", "basic", "ext-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := WebhookHandler(svc, nil)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"applies event", `{"type":"payment.failed","external_id":"ext-1"}`, http.StatusOK},
+		{"unknown subscription", `{"type":"payment.failed","external_id":"nope"}`, http.StatusOK},
+		{"invalid body", `{`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/billing", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	var sub Subscription
+	if err := svc.db.Where("external_id = ?", "ext-1").First(&sub).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status != StatusPastDue {
+		t.Errorf("expected past_due after webhook, got %s", sub.Status)
+	}
+}
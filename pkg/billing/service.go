@@ -0,0 +1,145 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/xlog"
+	"gorm.io/gorm"
+)
+
+// Service persists plans and subscriptions with GORM
+type Service struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewService creates a billing service on the given database
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// Migrate creates the billing tables
+func (s *Service) Migrate() error {
+	return s.db.AutoMigrate(&Plan{}, &Subscription{})
+}
+
+// CreatePlan stores a new plan
+func (s *Service) CreatePlan(ctx context.Context, plan *Plan) error {
+	return s.db.WithContext(ctx).Create(plan).Error
+}
+
+// PlanByCode returns an active plan by code
+func (s *Service) PlanByCode(ctx context.Context, code string) (*Plan, error) {
+	var plan Plan
+	err := s.db.WithContext(ctx).Where("code = ? AND active = ?", code, true).First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlanNotFound
+	}
+	return &plan, err
+}
+
+// Subscribe starts a subscription to the plan for the customer. externalID
+// is the gateway's subscription ID, used to match later webhook events.
+func (s *Service) Subscribe(ctx context.Context, customerID, planCode, externalID string) (*Subscription, error) {
+	plan, err := s.PlanByCode(ctx, planCode)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := NewSubscription(customerID, *plan, s.now())
+	sub.ExternalID = externalID
+	if err := s.db.WithContext(ctx).Create(sub).Error; err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ChangePlan moves the subscription to another plan and returns the prorated
+// amount in cents to charge (positive) or credit (negative) through the gateway
+func (s *Service) ChangePlan(ctx context.Context, subscriptionID uint, planCode string) (int64, error) {
+	plan, err := s.PlanByCode(ctx, planCode)
+	if err != nil {
+		return 0, err
+	}
+
+	var amount int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sub Subscription
+		if err := tx.Preload("Plan").First(&sub, subscriptionID).Error; err != nil {
+			return err
+		}
+		if sub.Plan.Currency != plan.Currency {
+			return fmt.Errorf("cannot change from %s to %s plan", sub.Plan.Currency, plan.Currency)
+		}
+
+		if sub.Status == StatusActive {
+			amount = Prorate(sub.Plan, *plan, sub.CurrentPeriodStart, sub.CurrentPeriodEnd, s.now())
+		}
+		sub.PlanID = plan.ID
+		sub.Plan = *plan
+		return tx.Omit("Plan").Save(&sub).Error
+	})
+
+	return amount, err
+}
+
+// HandleEvent applies a gateway event to the matching subscription
+func (s *Service) HandleEvent(ctx context.Context, e Event) (*Subscription, error) {
+	var sub Subscription
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Plan").Where("external_id = ?", e.ExternalID).First(&sub).Error; err != nil {
+			return err
+		}
+		if err := sub.Apply(e); err != nil {
+			return err
+		}
+		return tx.Omit("Plan").Save(&sub).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// WebhookHandler returns a handler applying gateway webhooks. parse verifies
+// the gateway signature and maps the payload to an Event; when nil, the body
+// is decoded as an Event directly, which is only suitable for trusted callers.
+func WebhookHandler(s *Service, parse func(r *http.Request) (Event, error)) http.HandlerFunc {
+	if parse == nil {
+		parse = func(r *http.Request) (Event, error) {
+			var e Event
+			err := json.NewDecoder(r.Body).Decode(&e)
+			return e, err
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := parse(r)
+		if err != nil {
+			http.Error(w, "Invalid webhook", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := s.HandleEvent(r.Context(), e); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				// Acknowledge events for unknown subscriptions so the gateway stops retrying
+				w.WriteHeader(http.StatusOK)
+			case errors.Is(err, ErrInvalidTransition):
+				xlog.GetWithError(r.Context(), err).Warn("Ignoring billing event")
+				w.WriteHeader(http.StatusOK)
+			default:
+				xlog.GetWithError(r.Context(), err).Error("Failed to handle billing event")
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
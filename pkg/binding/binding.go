@@ -35,6 +35,13 @@ func Bind(r *http.Request, obj interface{}) error {
 
 // JSON binds JSON request body to struct
 func JSON(r *http.Request, obj interface{}) error {
+	if err := decodeJSON(r, obj); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+func decodeJSON(r *http.Request, obj interface{}) error {
 	defer r.Body.Close()
 
 	decoder := json.NewDecoder(r.Body)
@@ -44,20 +51,25 @@ func JSON(r *http.Request, obj interface{}) error {
 		}
 		return fmt.Errorf("invalid JSON: %w", err)
 	}
-
-	return Validate(obj)
+	return nil
 }
 
 // XML binds XML request body to struct
 func XML(r *http.Request, obj interface{}) error {
+	if err := decodeXML(r, obj); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+func decodeXML(r *http.Request, obj interface{}) error {
 	defer r.Body.Close()
 
 	decoder := xml.NewDecoder(r.Body)
 	if err := decoder.Decode(obj); err != nil {
 		return fmt.Errorf("invalid XML: %w", err)
 	}
-
-	return Validate(obj)
+	return nil
 }
 
 // Form binds form data to struct
@@ -66,12 +78,13 @@ func Form(r *http.Request, obj interface{}) error {
 		return err
 	}
 
-	return mapForm(obj, r.Form)
+	return mapForm(obj, r.Form, "form")
 }
 
-// Query binds query parameters to struct
+// Query binds query parameters to struct. Values that do not convert to
+// the field type are reported as *ParamError.
 func Query(r *http.Request, obj interface{}) error {
-	return mapForm(obj, r.URL.Query())
+	return mapForm(obj, r.URL.Query(), "query")
 }
 
 // Validate validates struct using validator tags
@@ -83,7 +96,7 @@ func Validate(obj interface{}) error {
 }
 
 // mapForm maps form values to struct fields
-func mapForm(ptr interface{}, form map[string][]string) error {
+func mapForm(ptr interface{}, form map[string][]string, in string) error {
 	typ := reflect.TypeOf(ptr).Elem()
 	val := reflect.ValueOf(ptr).Elem()
 
@@ -95,9 +108,9 @@ func mapForm(ptr interface{}, form map[string][]string) error {
 			continue
 		}
 
-		inputFieldName := typeField.Tag.Get("form")
-		if inputFieldName == "" {
-			inputFieldName = strings.ToLower(typeField.Name)
+		inputFieldName, ok := formName(typeField)
+		if !ok {
+			continue
 		}
 
 		inputValue, exists := form[inputFieldName]
@@ -107,33 +120,42 @@ func mapForm(ptr interface{}, form map[string][]string) error {
 
 		numElems := len(inputValue)
 		if structField.Kind() == reflect.Slice && numElems > 0 {
-			sliceOf := structField.Type().Elem().Kind()
 			slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
 			for i := 0; i < numElems; i++ {
-				if err := setField(sliceOf, inputValue[i], slice.Index(i)); err != nil {
-					return err
+				if err := setField(inputValue[i], slice.Index(i)); err != nil {
+					return paramError(err, in, inputFieldName, inputValue[i], slice.Index(i).Type())
 				}
 			}
 			val.Field(i).Set(slice)
 		} else {
-			if err := setField(typeField.Type.Kind(), inputValue[0], structField); err != nil {
-				return err
+			if err := setField(inputValue[0], structField); err != nil {
+				return paramError(err, in, inputFieldName, inputValue[0], typeField.Type)
 			}
 		}
 	}
 	return nil
 }
 
-func setField(valueKind reflect.Kind, val string, field reflect.Value) error {
-	switch valueKind {
+// paramError wraps conversion errors; unsupported field types are
+// programming errors and returned as is
+func paramError(err error, in, name, value string, typ reflect.Type) error {
+	schema, ok := kindSchema(typ)
+	if !ok {
+		return err
+	}
+	return &ParamError{In: in, Name: name, Type: schema.Type, Value: value, Err: err}
+}
+
+func setField(val string, field reflect.Value) error {
+	switch valueKind := field.Kind(); valueKind {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		intVal, err := strconv.ParseInt(val, 10, 64)
+		intVal, err := strconv.ParseInt(val, 10, field.Type().Bits())
 		if err != nil {
 			return err
 		}
 		field.SetInt(intVal)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		uintVal, err := strconv.ParseUint(val, 10, 64)
+		uintVal, err := strconv.ParseUint(val, 10, field.Type().Bits())
 		if err != nil {
 			return err
 		}
@@ -145,7 +167,7 @@ func setField(valueKind reflect.Kind, val string, field reflect.Value) error {
 		}
 		field.SetBool(boolVal)
 	case reflect.Float32, reflect.Float64:
-		floatVal, err := strconv.ParseFloat(val, 64)
+		floatVal, err := strconv.ParseFloat(val, field.Type().Bits())
 		if err != nil {
 			return err
 		}
@@ -432,3 +432,104 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+// order has nested and slice fields for FieldErrors naming.
+type order struct {
+	Customer user       `json:"customer" validate:"required"`
+	Items    []lineItem `json:"items" validate:"required,min=1,dive"`
+	Coupon   string     `json:"coupon" validate:"omitempty,len=8" message:"is not a valid coupon"`
+}
+
+type lineItem struct {
+	SKU      string `json:"sku" validate:"required"`
+	Quantity int    `json:"qty" validate:"gte=1"`
+}
+
+func TestBindValidated(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		obj         interface{}
+		want        FieldErrors
+		errContains string
+	}{
+		{
+			name:        "valid JSON",
+			contentType: "application/json",
+			body:        `{"customer":{"name":"ada"},"items":[{"sku":"A1","qty":2}]}`,
+			obj:         &order{},
+		},
+		{
+			name:        "nested and indexed fields use json names",
+			contentType: "application/json",
+			body:        `{"customer":{"age":200,"email":"nope"},"items":[{"sku":"A1","qty":1},{"qty":0}],"coupon":"X"}`,
+			obj:         &order{},
+			want: FieldErrors{
+				"customer.name":  "is required",
+				"customer.age":   "must be at most 130",
+				"customer.email": "must be a valid email",
+				"items[1].sku":   "is required",
+				"items[1].qty":   "must be at least 1",
+				"coupon":         "is not a valid coupon",
+			},
+		},
+		{
+			name:        "empty list",
+			contentType: "application/json",
+			body:        `{"customer":{"name":"ada"},"items":[]}`,
+			obj:         &order{},
+			want:        FieldErrors{"items": "must contain at least 1 item"},
+		},
+		{
+			name:        "form data is validated",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "age=20",
+			obj:         &user{},
+			want:        FieldErrors{"name": "is required"},
+		},
+		{
+			name:        "malformed body",
+			contentType: "application/json",
+			body:        `{"customer":`,
+			obj:         &order{},
+			errContains: "invalid JSON",
+		},
+		{
+			name:        "maps are not validated",
+			contentType: "application/json",
+			body:        `{"any":"thing"}`,
+			obj:         &map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BindValidated(newRequest(t, http.MethodPost, "/orders", tt.contentType, tt.body), tt.obj)
+
+			switch {
+			case tt.errContains != "":
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+			case tt.want != nil:
+				fields, ok := err.(FieldErrors)
+				if !ok {
+					t.Fatalf("expected FieldErrors, got %T %v", err, err)
+				}
+				if !reflect.DeepEqual(fields, tt.want) {
+					t.Errorf("expected %v, got %v", tt.want, fields)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestFieldErrors_Error(t *testing.T) {
+	err := FieldErrors{"name": "is required", "age": "must be at least 0"}
+	if got := err.Error(); got != "validation failed: age must be at least 0; name is required" {
+		t.Errorf("unexpected message %q", got)
+	}
+}
@@ -0,0 +1,205 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/polymatx/goframe/pkg/i18n"
+)
+
+// FieldErrors maps request field names to validation messages, e.g.
+// {"email": "must be a valid email"}. Nested fields are joined with dots:
+// "address.zip", "items[0].sku".
+type FieldErrors map[string]string
+
+// Error lists the field errors in name order
+func (e FieldErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + " " + e[name]
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// BindValidated binds request data by Content-Type like Bind, then
+// validates structs, returning FieldErrors when validation fails. Unlike
+// Bind, form data is validated too.
+func BindValidated(r *http.Request, obj interface{}) error {
+	contentType := r.Header.Get("Content-Type")
+
+	var err error
+	switch {
+	case strings.Contains(contentType, "application/xml"):
+		err = decodeXML(r, obj)
+	case strings.Contains(contentType, "application/x-www-form-urlencoded"),
+		strings.Contains(contentType, "multipart/form-data"):
+		err = Form(r, obj)
+	default:
+		err = decodeJSON(r, obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	if v := reflect.ValueOf(obj); v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if err := validate.Struct(obj); err != nil {
+		if fields, ok := ToFieldErrorsLocale(obj, err, i18n.RequestLocale(r)); ok {
+			return fields
+		}
+		return err
+	}
+	return nil
+}
+
+// ToFieldErrors converts the validator errors of obj to FieldErrors named
+// after the json tags (then form tags, then Go names). A message struct tag
+// overrides the generated message.
+func ToFieldErrors(obj interface{}, err error) (FieldErrors, bool) {
+	return ToFieldErrorsLocale(obj, err, "")
+}
+
+// ToFieldErrorsLocale is ToFieldErrors with the messages of locale in the
+// i18n catalog, "validation.<tag>" codes, where it has them
+func ToFieldErrorsLocale(obj interface{}, err error, locale string) (FieldErrors, bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+
+	typ := reflect.TypeOf(obj)
+	fields := make(FieldErrors, len(verrs))
+	for _, fe := range verrs {
+		name, field := fieldPath(typ, fe.StructNamespace())
+		message := ""
+		if field != nil {
+			message = field.Tag.Get("message")
+		}
+		if message == "" {
+			message = fieldMessage(fe, locale)
+		}
+		if _, exists := fields[name]; !exists {
+			fields[name] = message
+		}
+	}
+	return fields, true
+}
+
+// fieldPath translates a namespace such as User.Items[0].SKU to request
+// names, returning the struct field of the last element
+func fieldPath(typ reflect.Type, namespace string) (string, *reflect.StructField) {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:] // Drop the root type name
+	}
+
+	var field *reflect.StructField
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		goName, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "[" + index
+		}
+
+		for typ != nil && (typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map) {
+			typ = typ.Elem()
+		}
+		if typ == nil || typ.Kind() != reflect.Struct {
+			names = append(names, goName+index)
+			continue
+		}
+
+		f, ok := typ.FieldByName(goName)
+		if !ok {
+			names = append(names, goName+index)
+			typ = nil
+			continue
+		}
+		field = &f
+		names = append(names, requestName(f)+index)
+		typ = f.Type
+	}
+	return strings.Join(names, "."), field
+}
+
+func requestName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "xml"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// fieldMessage describes a failed validation tag in words
+func fieldMessage(fe validator.FieldError, locale string) string {
+	param := fe.Param()
+	if _, ok := i18n.Default.Lookup(locale, "validation."+fe.Tag()); ok {
+		return i18n.Default.Message(locale, "validation."+fe.Tag(), "param", param)
+	}
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email"
+	case "url", "http_url", "uri":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "min", "gte":
+		if isSized(fe.Kind()) {
+			return fmt.Sprintf("must contain at least %s %s", param, unit(fe.Kind(), param))
+		}
+		return "must be at least " + param
+	case "max", "lte":
+		if isSized(fe.Kind()) {
+			return fmt.Sprintf("must contain at most %s %s", param, unit(fe.Kind(), param))
+		}
+		return "must be at most " + param
+	case "len":
+		if isSized(fe.Kind()) {
+			return fmt.Sprintf("must contain exactly %s %s", param, unit(fe.Kind(), param))
+		}
+		return "must be " + param
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	case "eqfield":
+		return "must match " + param
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "numeric", "number":
+		return "must be numeric"
+	}
+	return fmt.Sprintf("failed the %s validation", fe.Tag())
+}
+
+func isSized(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
+
+func unit(kind reflect.Kind, count string) string {
+	name := "item"
+	if kind == reflect.String {
+		name = "character"
+	}
+	if count != "1" {
+		name += "s"
+	}
+	return name
+}
@@ -0,0 +1,170 @@
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParamError reports a query or form value that does not convert to the
+// type of its field, e.g. page=abc for an int field
+type ParamError struct {
+	In     string // "query" or "form"
+	Name   string // Parameter name
+	Type   string // Expected OpenAPI type, e.g. "integer"
+	Format string // Expected OpenAPI format, e.g. "date-time", if any
+	Value  string
+	Err    error
+}
+
+// Error names the parameter and the expected type
+func (e *ParamError) Error() string {
+	want := e.Type
+	if e.Format != "" {
+		want = e.Format
+	}
+	return fmt.Sprintf("invalid %s parameter %q: must be %s", e.In, e.Name, describeType(want))
+}
+
+// Unwrap returns the conversion error
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// Parameter is an OpenAPI parameter object
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Schema      Schema `json:"schema"`
+}
+
+// Schema is the OpenAPI schema of a parameter
+type Schema struct {
+	Type    string   `json:"type"`
+	Format  string   `json:"format,omitempty"`
+	Items   *Schema  `json:"items,omitempty"`
+	Enum    []string `json:"enum,omitempty"`
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+}
+
+// QueryParameters describes the fields Query binds in obj, a struct or
+// struct pointer, as OpenAPI query parameters. Names and types follow the
+// form tags and field types; required, oneof, min and max come from the
+// validate tags and descriptions from doc tags:
+//
+//	type listParams struct {
+//		Page   int    `form:"page" validate:"min=1" doc:"Page number"`
+//		Status string `form:"status" validate:"omitempty,oneof=open closed"`
+//	}
+func QueryParameters(obj interface{}) []Parameter {
+	typ := reflect.TypeOf(obj)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []Parameter
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, ok := formName(field)
+		if !ok {
+			continue
+		}
+		schema, ok := kindSchema(field.Type)
+		if !ok {
+			continue
+		}
+
+		param := Parameter{Name: name, In: "query", Description: field.Tag.Get("doc")}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			tag, value, _ := strings.Cut(rule, "=")
+			switch tag {
+			case "required":
+				param.Required = true
+			case "oneof":
+				target := &schema
+				if schema.Items != nil {
+					target = schema.Items
+				}
+				target.Enum = strings.Fields(value)
+			case "min", "gte", "max", "lte":
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil || (schema.Type != "integer" && schema.Type != "number") {
+					continue
+				}
+				if tag == "min" || tag == "gte" {
+					schema.Minimum = &n
+				} else {
+					schema.Maximum = &n
+				}
+			}
+		}
+		param.Schema = schema
+		params = append(params, param)
+	}
+	return params
+}
+
+// formName returns the request name of field, false for fields not bound
+func formName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name := field.Tag.Get("form")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return strings.ToLower(field.Name), true
+	}
+	return name, true
+}
+
+// kindSchema returns the schema of the field types setField converts to
+func kindSchema(typ reflect.Type) (Schema, bool) {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int64:
+		return Schema{Type: "integer", Format: "int64"}, true
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return Schema{Type: "integer", Format: "int32"}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return Schema{Type: "integer", Minimum: &zero}, true
+	case reflect.Float32:
+		return Schema{Type: "number", Format: "float"}, true
+	case reflect.Float64:
+		return Schema{Type: "number", Format: "double"}, true
+	case reflect.Bool:
+		return Schema{Type: "boolean"}, true
+	case reflect.String:
+		return Schema{Type: "string"}, true
+	case reflect.Slice:
+		items, ok := kindSchema(typ.Elem())
+		if !ok || items.Type == "array" {
+			return Schema{}, false
+		}
+		return Schema{Type: "array", Items: &items}, true
+	}
+	return Schema{}, false
+}
+
+// describeType words an OpenAPI type or format for error messages
+func describeType(typ string) string {
+	switch typ {
+	case "date-time":
+		return "an RFC 3339 time or YYYY-MM-DD date"
+	case "integer":
+		return "an integer"
+	case "number":
+		return "a number"
+	case "boolean":
+		return "a boolean"
+	}
+	return "a " + typ
+}
@@ -0,0 +1,85 @@
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestQuery_ParamError(t *testing.T) {
+	tests := []struct {
+		target   string
+		wantName string
+		wantType string
+		wantMsg  string
+	}{
+		{"/?age=abc", "age", "integer", `invalid query parameter "age": must be an integer`},
+		{"/?active=maybe", "active", "boolean", `invalid query parameter "active": must be a boolean`},
+		{"/?score=high", "score", "number", `invalid query parameter "score": must be a number`},
+		{"/?count=-1", "count", "integer", `invalid query parameter "count": must be an integer`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			var got formPayload
+			err := Query(httptest.NewRequest(http.MethodGet, tt.target, nil), &got)
+
+			var param *ParamError
+			if !errors.As(err, &param) {
+				t.Fatalf("expected a ParamError, got %v", err)
+			}
+			if param.Name != tt.wantName || param.Type != tt.wantType || param.In != "query" {
+				t.Errorf("unexpected error %+v", param)
+			}
+			if err.Error() != tt.wantMsg {
+				t.Errorf("expected %q, got %q", tt.wantMsg, err.Error())
+			}
+			var numErr *strconv.NumError
+			if tt.wantType != "boolean" && !errors.As(err, &numErr) {
+				t.Error("expected the conversion error to be wrapped")
+			}
+		})
+	}
+
+	// Values out of range of the field size are rejected
+	var small struct {
+		Level int8 `form:"level"`
+	}
+	if err := Query(httptest.NewRequest(http.MethodGet, "/?level=300", nil), &small); err == nil {
+		t.Error("expected an error for an int8 overflow")
+	}
+}
+
+func TestQueryParameters(t *testing.T) {
+	type listParams struct {
+		Page    int      `form:"page" validate:"required,min=1" doc:"Page number"`
+		PerPage uint8    `form:"per_page" validate:"max=100"`
+		Status  string   `form:"status" validate:"omitempty,oneof=open closed"`
+		Tags    []string `form:"tag" validate:"dive,oneof=a b"`
+		Ratio   float64
+		Skip    string `form:"-"`
+		hidden  string
+		Nested  struct{ A int }
+	}
+
+	got, err := json.Marshal(QueryParameters(&listParams{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[` +
+		`{"name":"page","in":"query","description":"Page number","required":true,"schema":{"type":"integer","format":"int64","minimum":1}},` +
+		`{"name":"per_page","in":"query","schema":{"type":"integer","minimum":0,"maximum":100}},` +
+		`{"name":"status","in":"query","schema":{"type":"string","enum":["open","closed"]}},` +
+		`{"name":"tag","in":"query","schema":{"type":"array","items":{"type":"string","enum":["a","b"]}}},` +
+		`{"name":"ratio","in":"query","schema":{"type":"number","format":"double"}}` +
+		`]`
+	if string(got) != want {
+		t.Errorf("unexpected parameters\n got %s\nwant %s", got, want)
+	}
+
+	if params := QueryParameters("not a struct"); params != nil {
+		t.Errorf("expected no parameters, got %v", params)
+	}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/dedupe"
+	"github.com/redis/go-redis/v9"
+)
+
+// DedupeStore keeps the message IDs of idempotent consumers in Redis, for
+// use with dedupe.New
+type DedupeStore struct {
+	manager *Manager
+	prefix  string
+}
+
+// DedupeStore returns a store keeping its keys under prefix, e.g.
+// "dedupe:orders:"
+func (m *Manager) DedupeStore(prefix string) *DedupeStore {
+	return &DedupeStore{manager: m, prefix: prefix}
+}
+
+// Claim implements dedupe.Store
+func (s *DedupeStore) Claim(ctx context.Context, id string, ttl time.Duration) (string, error) {
+	key := s.manager.key(ctx, s.prefix+id)
+	for {
+		ok, err := s.manager.client.SetNX(ctx, key, dedupe.StateProcessing, ttl).Result()
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return dedupe.StateNew, nil
+		}
+		state, err := s.manager.client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue // Expired or released meanwhile
+		}
+		return state, err
+	}
+}
+
+// Complete implements dedupe.Store
+func (s *DedupeStore) Complete(ctx context.Context, id string, ttl time.Duration) error {
+	return s.manager.client.Set(ctx, s.manager.key(ctx, s.prefix+id), dedupe.StateDone, ttl).Err()
+}
+
+// Release implements dedupe.Store
+func (s *DedupeStore) Release(ctx context.Context, id string) error {
+	return s.manager.client.Del(ctx, s.manager.key(ctx, s.prefix+id)).Err()
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/dedupe"
+)
+
+func TestDedupeStore(t *testing.T) {
+	ctx := context.Background()
+	flushCache(t)
+
+	var store dedupe.Store = testCache.DedupeStore("dedupe:test:")
+	for _, step := range []struct {
+		do   func() (string, error)
+		want string
+	}{
+		{func() (string, error) { return store.Claim(ctx, "m1", time.Minute) }, dedupe.StateNew},
+		{func() (string, error) { return store.Claim(ctx, "m1", time.Minute) }, dedupe.StateProcessing},
+		{func() (string, error) { return "", store.Complete(ctx, "m1", time.Hour) }, ""},
+		{func() (string, error) { return store.Claim(ctx, "m1", time.Minute) }, dedupe.StateDone},
+		{func() (string, error) { return "", store.Release(ctx, "m1") }, ""},
+		{func() (string, error) { return store.Claim(ctx, "m1", time.Minute) }, dedupe.StateNew},
+	} {
+		got, err := step.do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != step.want {
+			t.Fatalf("state = %q, want %q", got, step.want)
+		}
+	}
+	if ttl, _ := testCache.TTL(ctx, "dedupe:test:m1"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL = %v, want the processing timeout", ttl)
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DelPatternOptions tunes DelPattern
+type DelPatternOptions struct {
+	BatchSize int64 // Keys per SCAN and UNLINK (default 500)
+
+	// Pause between batches, so a large delete leaves Redis time to serve
+	// other clients (default 10ms, negative for none)
+	Pause time.Duration
+}
+
+// DelPattern deletes all keys matching a pattern, e.g. "users:*", and
+// returns how many it deleted. Unlike Keys and Del it does not block
+// Redis: keys are found with SCAN in batches and removed with UNLINK,
+// which frees memory in the background. In cluster mode every master is
+// scanned. With a KeyPrefix, the pattern is relative to the prefix.
+func (m *Manager) DelPattern(ctx context.Context, pattern string, opts ...DelPatternOptions) (int64, error) {
+	var o DelPatternOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 500
+	}
+	if o.Pause == 0 {
+		o.Pause = 10 * time.Millisecond
+	}
+	match := m.key(ctx, pattern)
+
+	cluster, ok := m.client.(*redis.ClusterClient)
+	if !ok {
+		return delPattern(ctx, m.client, match, o, false)
+	}
+	var deleted atomic.Int64
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		n, err := delPattern(ctx, node, match, o, true)
+		deleted.Add(n)
+		return err
+	})
+	return deleted.Load(), err
+}
+
+// delPattern deletes the keys matching match on one node. Keys of a cluster
+// node are unlinked one by one, as they may hash to different slots.
+func delPattern(ctx context.Context, client redis.Cmdable, match string, o DelPatternOptions, cluster bool) (int64, error) {
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, o.BatchSize).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := unlink(ctx, client, keys, cluster)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return deleted, nil
+		}
+
+		if o.Pause > 0 {
+			timer := time.NewTimer(o.Pause)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return deleted, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+func unlink(ctx context.Context, client redis.Cmdable, keys []string, cluster bool) (int64, error) {
+	if !cluster {
+		return client.Unlink(ctx, keys...).Result()
+	}
+	cmds, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+		return nil
+	})
+	var n int64
+	for _, cmd := range cmds {
+		n += cmd.(*redis.IntCmd).Val()
+	}
+	return n, err
+}
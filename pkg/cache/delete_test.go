@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestManager_DelPattern(t *testing.T) {
+	ctx := context.Background()
+	flushCache(t)
+
+	for i := 0; i < 25; i++ {
+		if err := testCache.Set(ctx, "users:"+strconv.Itoa(i), "x", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = testCache.Set(ctx, "orders:1", "x", time.Minute)
+	_ = testCache.Set(ctx, "tenant:acme:users:1", "x", time.Minute)
+
+	n, err := testCache.DelPattern(ctx, "users:*", DelPatternOptions{BatchSize: 10, Pause: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 25 {
+		t.Errorf("deleted %d keys, want 25", n)
+	}
+	if left, _ := testCache.Keys(ctx, "*"); len(left) != 2 {
+		t.Errorf("expected other keys kept, got %v", left)
+	}
+
+	// With a KeyPrefix only the keys of the tenant are deleted
+	scoped := &Manager{client: testCache.client, config: Config{
+		KeyPrefix: func(context.Context) string { return "tenant:acme:" },
+	}}
+	if n, err := scoped.DelPattern(ctx, "users:*"); err != nil || n != 1 {
+		t.Errorf("scoped DelPattern = %d, %v, want 1", n, err)
+	}
+	if n, _ := testCache.Exists(ctx, "orders:1"); n != 1 {
+		t.Error("expected orders:1 kept")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := testCache.DelPattern(cancelled, "*"); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Denylist keeps the IDs of revoked tokens in Redis, shared by all
+// instances, for use as auth.JWTConfig.Denylist
+type Denylist struct {
+	manager *Manager
+	prefix  string
+}
+
+// Denylist returns a denylist keeping its keys under prefix, e.g.
+// "revoked:"
+func (m *Manager) Denylist(prefix string) *Denylist {
+	return &Denylist{manager: m, prefix: prefix}
+}
+
+// Add implements auth.Denylist
+func (d *Denylist) Add(ctx context.Context, id string, ttl time.Duration) error {
+	return d.manager.client.Set(ctx, d.manager.key(ctx, d.prefix+id), 1, ttl).Err()
+}
+
+// Contains implements auth.Denylist
+func (d *Denylist) Contains(ctx context.Context, id string) (bool, error) {
+	n, err := d.manager.client.Exists(ctx, d.manager.key(ctx, d.prefix+id)).Result()
+	return n > 0, err
+}
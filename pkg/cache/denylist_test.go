@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+)
+
+func TestDenylist(t *testing.T) {
+	ctx := context.Background()
+	flushCache(t)
+
+	var denylist auth.Denylist = testCache.Denylist("revoked:")
+	if revoked, err := denylist.Contains(ctx, "t1"); err != nil || revoked {
+		t.Fatalf("Contains = %v, %v before revoking", revoked, err)
+	}
+	if err := denylist.Add(ctx, "t1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, err := denylist.Contains(ctx, "t1"); err != nil || !revoked {
+		t.Errorf("Contains = %v, %v after revoking", revoked, err)
+	}
+	if ttl, _ := testCache.TTL(ctx, "revoked:t1"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL = %v, want the rest of the token lifetime", ttl)
+	}
+}
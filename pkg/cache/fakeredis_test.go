@@ -12,6 +12,7 @@ import (
 	"io"
 	"math"
 	"net"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -34,6 +35,28 @@ type fakeRedis struct {
 	mu     sync.Mutex
 	data   map[string]*fakeEntry
 	expiry map[string]time.Time
+	subs   map[*fakeSubscriber]struct{}
+
+	// scans maps SCAN cursors to the last key returned, so keys deleted
+	// between calls do not shift the iteration as offsets would
+	scans map[int]string
+}
+
+// fakeSubscriber is a connection in subscribed mode
+type fakeSubscriber struct {
+	mu       sync.Mutex // Guards w against concurrent publishes
+	w        *bufio.Writer
+	channels map[string]bool
+	patterns map[string]bool
+}
+
+func (c *fakeSubscriber) write(reply string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.w.WriteString(reply); err != nil {
+		return err
+	}
+	return c.w.Flush()
 }
 
 func startFakeRedis() (*fakeRedis, error) {
@@ -45,6 +68,8 @@ func startFakeRedis() (*fakeRedis, error) {
 		ln:     ln,
 		data:   make(map[string]*fakeEntry),
 		expiry: make(map[string]time.Time),
+		subs:   make(map[*fakeSubscriber]struct{}),
+		scans:  make(map[int]string),
 	}
 	go s.acceptLoop()
 	return s, nil
@@ -66,7 +91,12 @@ func (s *fakeRedis) acceptLoop() {
 func (s *fakeRedis) handleConn(conn net.Conn) {
 	defer func() { _ = conn.Close() }()
 	r := bufio.NewReader(conn)
-	w := bufio.NewWriter(conn)
+	sub := &fakeSubscriber{w: bufio.NewWriter(conn), channels: map[string]bool{}, patterns: map[string]bool{}}
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, sub)
+		s.mu.Unlock()
+	}()
 	for {
 		args, err := readCommand(r)
 		if err != nil {
@@ -75,15 +105,73 @@ func (s *fakeRedis) handleConn(conn net.Conn) {
 		if len(args) == 0 {
 			continue
 		}
-		if _, err := w.WriteString(s.exec(args)); err != nil {
-			return
+		reply := s.subscription(sub, args)
+		if reply == "" {
+			reply = s.exec(args)
 		}
-		if err := w.Flush(); err != nil {
+		if err := sub.write(reply); err != nil {
 			return
 		}
 	}
 }
 
+// subscription handles the pub/sub commands of a connection, returning ""
+// for other commands
+func (s *fakeRedis) subscription(sub *fakeSubscriber, args []string) string {
+	cmd := strings.ToUpper(args[0])
+	var set map[string]bool
+	switch cmd {
+	case "SUBSCRIBE", "UNSUBSCRIBE":
+		set = sub.channels
+	case "PSUBSCRIBE", "PUNSUBSCRIBE":
+		set = sub.patterns
+	case "PING":
+		if len(sub.channels)+len(sub.patterns) > 0 {
+			return respArray([]string{"pong", ""})
+		}
+		return ""
+	default:
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var b strings.Builder
+	for _, name := range args[1:] {
+		if strings.HasPrefix(cmd, "UN") || strings.HasPrefix(cmd, "PUN") {
+			delete(set, name)
+		} else {
+			set[name] = true
+		}
+		b.WriteString("*3\r\n" + respBulk(strings.ToLower(cmd)) + respBulk(name) +
+			respInt(int64(len(sub.channels)+len(sub.patterns))))
+	}
+	if len(sub.channels)+len(sub.patterns) > 0 {
+		s.subs[sub] = struct{}{}
+	} else {
+		delete(s.subs, sub)
+	}
+	return b.String()
+}
+
+// publish delivers a message to the subscribers of channel (s.mu held)
+func (s *fakeRedis) publish(channel, payload string) int64 {
+	var n int64
+	for sub := range s.subs {
+		if sub.channels[channel] {
+			_ = sub.write(respArray([]string{"message", channel, payload}))
+			n++
+		}
+		for pattern := range sub.patterns {
+			if ok, _ := path.Match(pattern, channel); ok {
+				_ = sub.write(respArray([]string{"pmessage", pattern, channel, payload}))
+				n++
+			}
+		}
+	}
+	return n
+}
+
 // --- RESP protocol helpers ---
 
 func readLine(r *bufio.Reader) (string, error) {
@@ -271,6 +359,62 @@ func (s *fakeRedis) exec(args []string) string {
 		return s.cmdZRangeByScore(args[1], args[2], args[3])
 	case "ZREM":
 		return s.cmdZRem(args[1], args[2:])
+	case "PUBLISH":
+		return respInt(s.publish(args[1], args[2]))
+	case "PTTL":
+		if s.live(args[1]) == nil {
+			return respInt(-2)
+		}
+		deadline, ok := s.expiry[args[1]]
+		if !ok {
+			return respInt(-1)
+		}
+		return respInt(time.Until(deadline).Milliseconds())
+	case "DUMP":
+		// Only strings are serialized, in a format private to the fake
+		e := s.live(args[1])
+		if e == nil {
+			return respNullBulk
+		}
+		if e.kind != "string" {
+			return respError("ERR fake DUMP supports strings only")
+		}
+		return respBulk("fake:" + e.str)
+	case "RESTORE":
+		return s.cmdRestore(args[1], args[2], args[3], args[4:])
+	case "KEYS":
+		var keys []string
+		for key := range s.data {
+			if ok, _ := path.Match(args[1], key); ok && s.live(key) != nil {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		return respArray(keys)
+	case "SCAN":
+		return s.cmdScan(args[1:])
+	case "UNLINK":
+		return s.cmdDel(args[1:])
+	case "EVALSHA":
+		// Scripts are not cached; go-redis falls back to EVAL
+		return respError("NOSCRIPT No matching script")
+	case "EVAL":
+		// Only the scripts of the package are known, run natively
+		if args[1] == releaseLockScript && args[2] == "1" {
+			if e := s.live(args[3]); e != nil && e.kind == "string" && e.str == args[4] {
+				return s.cmdDel(args[3:4])
+			}
+			return respInt(0)
+		}
+		if args[1] == incrWindowScript && args[2] == "1" {
+			reply := s.cmdIncrBy(args[3], 1)
+			if _, ok := s.expiry[args[3]]; !ok && !strings.HasPrefix(reply, "-") {
+				ms, _ := strconv.Atoi(args[4])
+				s.expiry[args[3]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+			return reply
+		}
+		return respError("ERR fake EVAL does not know the script")
 	default:
 		return respError("ERR unknown command '" + args[0] + "'")
 	}
@@ -326,6 +470,50 @@ func (s *fakeRedis) cmdSet(args []string) string {
 	return respSimple("OK")
 }
 
+// cmdScan pages through the sorted keys, resuming after the last key
+// returned for the cursor
+func (s *fakeRedis) cmdScan(args []string) string {
+	cursor, err := strconv.Atoi(args[0])
+	if err != nil {
+		return respError("ERR invalid cursor")
+	}
+	match, count := "*", 10
+	for i := 1; i+1 < len(args); i += 2 {
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			match = args[i+1]
+		case "COUNT":
+			count, _ = strconv.Atoi(args[i+1])
+		}
+	}
+	after := s.scans[cursor]
+	delete(s.scans, cursor)
+	var keys []string
+	for key := range s.data {
+		if (cursor == 0 || key > after) && s.live(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	next := 0
+	if len(keys) > count {
+		keys = keys[:count]
+		next = len(s.scans) + 1
+		for s.scans[next] != "" {
+			next++
+		}
+		s.scans[next] = keys[count-1]
+	}
+	var page []string
+	for _, key := range keys {
+		if ok, _ := path.Match(match, key); ok {
+			page = append(page, key)
+		}
+	}
+	return "*2\r\n" + respBulk(strconv.Itoa(next)) + respArray(page)
+}
+
 func (s *fakeRedis) cmdGet(key string) string {
 	e := s.live(key)
 	if e == nil {
@@ -689,3 +877,24 @@ func (s *fakeRedis) cmdZRem(key string, members []string) string {
 	}
 	return respInt(n)
 }
+
+func (s *fakeRedis) cmdRestore(key, ttlStr, payload string, opts []string) string {
+	ttl, err := strconv.ParseInt(ttlStr, 10, 64)
+	if err != nil {
+		return respError("ERR value is not an integer or out of range")
+	}
+	value, ok := strings.CutPrefix(payload, "fake:")
+	if !ok {
+		return respError("ERR DUMP payload version or checksum are wrong")
+	}
+	replace := len(opts) > 0 && strings.ToUpper(opts[0]) == "REPLACE"
+	if !replace && s.live(key) != nil {
+		return respError("BUSYKEY Target key name already exists.")
+	}
+	s.data[key] = &fakeEntry{kind: "string", str: value}
+	delete(s.expiry, key)
+	if ttl > 0 {
+		s.expiry[key] = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+	}
+	return respSimple("OK")
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// releaseLockScript deletes a lock only if it still holds the token of its
+// owner, so an owner whose lock expired cannot release the next owner's
+const releaseLockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+
+var releaseLock = redis.NewScript(releaseLockScript)
+
+// Locker takes locks shared by all instances through Redis, for use with
+// middleware.Lock
+type Locker struct {
+	manager *Manager
+	name    string
+}
+
+// Locker returns a locker whose keys are separated from those of other
+// lockers by name
+func (m *Manager) Locker(name string) *Locker {
+	return &Locker{manager: m, name: name}
+}
+
+// Acquire takes the lock of key until release is called or ttl passes. It
+// reports false if the lock is held.
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (func(ctx context.Context) error, bool, error) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	key = l.manager.key(ctx, "lock:"+l.name+":"+key)
+	ok, err := l.manager.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return func(ctx context.Context) error {
+		return releaseLock.Run(ctx, l.manager.client, []string{key}, token).Err()
+	}, true, nil
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+	flushCache(t)
+
+	var locker middleware.Locker = testCache.Locker("orders")
+	release, ok, err := locker.Acquire(ctx, "42", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Acquire = %v, %v, want the lock", ok, err)
+	}
+	if _, ok, _ := locker.Acquire(ctx, "42", time.Minute); ok {
+		t.Fatal("lock taken twice")
+	}
+	if _, ok, _ := locker.Acquire(ctx, "43", time.Minute); !ok {
+		t.Error("other key locked")
+	}
+	if _, ok, _ := testCache.Locker("payments").Acquire(ctx, "42", time.Minute); !ok {
+		t.Error("other locker locked")
+	}
+
+	if err := release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := locker.Acquire(ctx, "42", time.Minute); !ok {
+		t.Error("lock not released")
+	}
+
+	// A release after expiry leaves the next owner's lock alone
+	expired, _, _ := locker.Acquire(ctx, "44", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := locker.Acquire(ctx, "44", time.Minute); !ok {
+		t.Fatal("expired lock not taken")
+	}
+	if err := expired(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := locker.Acquire(ctx, "44", time.Minute); ok {
+		t.Error("expired owner released the next owner's lock")
+	}
+}
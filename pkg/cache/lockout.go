@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrWindowScript counts a failure and starts the window with the first
+// one in a single step, so a counter never outlives its window. Counters
+// left without a TTL are given one.
+const incrWindowScript = `local n = redis.call("INCR", KEYS[1])
+if redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n`
+
+var incrWindow = redis.NewScript(incrWindowScript)
+
+// LockoutStore keeps failed login counters and account locks in Redis,
+// shared by all instances, for use with auth.NewLockout
+type LockoutStore struct {
+	manager *Manager
+	prefix  string
+}
+
+// LockoutStore returns a store keeping its keys under prefix, e.g.
+// "lockout:"
+func (m *Manager) LockoutStore(prefix string) *LockoutStore {
+	return &LockoutStore{manager: m, prefix: prefix}
+}
+
+// Incr implements auth.LockoutStore
+func (s *LockoutStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	key = s.manager.key(ctx, s.prefix+key)
+	return incrWindow.Run(ctx, s.manager.client, []string{key}, window.Milliseconds()).Int64()
+}
+
+// Get implements auth.LockoutStore
+func (s *LockoutStore) Get(ctx context.Context, key string) (string, error) {
+	value, err := s.manager.client.Get(ctx, s.manager.key(ctx, s.prefix+key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetNX implements auth.LockoutStore
+func (s *LockoutStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return s.manager.client.SetNX(ctx, s.manager.key(ctx, s.prefix+key), value, ttl).Result()
+}
+
+// Del implements auth.LockoutStore. Keys are deleted one at a time: the
+// lock and counter of an account hash to different cluster slots, and a
+// multi-key DEL across slots fails with CROSSSLOT.
+func (s *LockoutStore) Del(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := s.manager.client.Del(ctx, s.manager.key(ctx, s.prefix+key)).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+)
+
+func TestLockoutStore(t *testing.T) {
+	ctx := context.Background()
+	flushCache(t)
+
+	store := testCache.LockoutStore("lockout:")
+	l := auth.NewLockout(auth.LockoutConfig{MaxAttempts: 2, Window: time.Minute, LockFor: time.Minute, Store: store})
+
+	if _, err := l.Fail(ctx, "user:ann"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := testCache.TTL(ctx, "lockout:fail:user:ann"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("counter TTL = %v, want the window", ttl)
+	}
+	token, err := l.Fail(ctx, "user:ann")
+	if !errors.Is(err, auth.ErrLocked) {
+		t.Fatalf("second failure = %v, want ErrLocked", err)
+	}
+	if err := l.Check(ctx, "user:ann"); !errors.Is(err, auth.ErrLocked) {
+		t.Errorf("Check = %v, want ErrLocked", err)
+	}
+	if err := l.Unlock(ctx, "user:ann", token); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := store.Get(ctx, "lock:user:ann"); value != "" || err != nil {
+		t.Errorf("lock after Unlock = %q, %v", value, err)
+	}
+
+	if ok, err := store.SetNX(ctx, "lock:user:bob", "1", time.Minute); !ok || err != nil {
+		t.Fatalf("first SetNX = %v, %v", ok, err)
+	}
+	if ok, err := store.SetNX(ctx, "lock:user:bob", "2", time.Minute); ok || err != nil {
+		t.Errorf("SetNX of a set key = %v, %v, want false", ok, err)
+	}
+
+	// A counter left without a TTL gets one on the next failure
+	if err := testCache.Set(ctx, "lockout:fail:user:cy", "3", 0); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := store.Incr(ctx, "fail:user:cy", time.Minute); n != 4 || err != nil {
+		t.Fatalf("Incr = %d, %v", n, err)
+	}
+	if ttl, _ := testCache.TTL(ctx, "lockout:fail:user:cy"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("counter TTL = %v, want the window", ttl)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/polymatx/goframe/pkg/xlog"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -17,62 +20,62 @@ type Serializable interface {
 
 // Set stores a key-value pair with TTL in Redis
 func (m *Manager) Set(ctx context.Context, key, value string, ttl time.Duration) error {
-	return m.client.Set(ctx, key, value, ttl).Err()
+	return m.client.Set(ctx, m.key(ctx, key), value, ttl).Err()
 }
 
 // SetNX sets a key only if it doesn't exist (atomic)
 func (m *Manager) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
-	return m.client.SetNX(ctx, key, value, ttl).Result()
+	return m.client.SetNX(ctx, m.key(ctx, key), value, ttl).Result()
 }
 
 // Get retrieves a value by key from Redis
 func (m *Manager) Get(ctx context.Context, key string) (string, error) {
-	return m.client.Get(ctx, key).Result()
+	return m.client.Get(ctx, m.key(ctx, key)).Result()
 }
 
 // GetDel atomically gets and deletes a key
 func (m *Manager) GetDel(ctx context.Context, key string) (string, error) {
-	return m.client.GetDel(ctx, key).Result()
+	return m.client.GetDel(ctx, m.key(ctx, key)).Result()
 }
 
 // Del deletes one or more keys from Redis
 func (m *Manager) Del(ctx context.Context, keys ...string) error {
-	return m.client.Del(ctx, keys...).Err()
+	return m.client.Del(ctx, m.keys(ctx, keys)...).Err()
 }
 
 // Exists checks if one or more keys exist in Redis
 func (m *Manager) Exists(ctx context.Context, keys ...string) (int64, error) {
-	return m.client.Exists(ctx, keys...).Result()
+	return m.client.Exists(ctx, m.keys(ctx, keys)...).Result()
 }
 
 // Expire sets a timeout on a key
 func (m *Manager) Expire(ctx context.Context, key string, ttl time.Duration) error {
-	return m.client.Expire(ctx, key, ttl).Err()
+	return m.client.Expire(ctx, m.key(ctx, key), ttl).Err()
 }
 
 // TTL returns the remaining time to live of a key
 func (m *Manager) TTL(ctx context.Context, key string) (time.Duration, error) {
-	return m.client.TTL(ctx, key).Result()
+	return m.client.TTL(ctx, m.key(ctx, key)).Result()
 }
 
 // Incr increments the integer value of a key by one
 func (m *Manager) Incr(ctx context.Context, key string) (int64, error) {
-	return m.client.Incr(ctx, key).Result()
+	return m.client.Incr(ctx, m.key(ctx, key)).Result()
 }
 
 // IncrBy increments the integer value of a key by the given amount
 func (m *Manager) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
-	return m.client.IncrBy(ctx, key, value).Result()
+	return m.client.IncrBy(ctx, m.key(ctx, key), value).Result()
 }
 
 // Decr decrements the integer value of a key by one
 func (m *Manager) Decr(ctx context.Context, key string) (int64, error) {
-	return m.client.Decr(ctx, key).Result()
+	return m.client.Decr(ctx, m.key(ctx, key)).Result()
 }
 
 // DecrBy decrements the integer value of a key by the given amount
 func (m *Manager) DecrBy(ctx context.Context, key string, value int64) (int64, error) {
-	return m.client.DecrBy(ctx, key, value).Result()
+	return m.client.DecrBy(ctx, m.key(ctx, key), value).Result()
 }
 
 // SetJSON serializes and stores a JSON object with TTL
@@ -114,77 +117,88 @@ func (m *Manager) GetObject(ctx context.Context, key string, obj Serializable) e
 
 // MGet retrieves multiple keys at once
 func (m *Manager) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
-	return m.client.MGet(ctx, keys...).Result()
+	return m.client.MGet(ctx, m.keys(ctx, keys)...).Result()
 }
 
-// MSet sets multiple key-value pairs atomically
+// MSet sets multiple key-value pairs atomically, given as alternating keys
+// and values
 func (m *Manager) MSet(ctx context.Context, pairs ...interface{}) error {
+	if prefix := m.prefix(ctx); prefix != "" {
+		prefixed := make([]interface{}, len(pairs))
+		copy(prefixed, pairs)
+		for i := 0; i < len(prefixed); i += 2 {
+			if key, ok := prefixed[i].(string); ok {
+				prefixed[i] = prefix + key
+			}
+		}
+		pairs = prefixed
+	}
 	return m.client.MSet(ctx, pairs...).Err()
 }
 
 // HSet sets a field in a hash
 func (m *Manager) HSet(ctx context.Context, key string, values ...interface{}) error {
-	return m.client.HSet(ctx, key, values...).Err()
+	return m.client.HSet(ctx, m.key(ctx, key), values...).Err()
 }
 
 // HGet gets a field from a hash
 func (m *Manager) HGet(ctx context.Context, key, field string) (string, error) {
-	return m.client.HGet(ctx, key, field).Result()
+	return m.client.HGet(ctx, m.key(ctx, key), field).Result()
 }
 
 // HGetAll gets all fields from a hash
 func (m *Manager) HGetAll(ctx context.Context, key string) (map[string]string, error) {
-	return m.client.HGetAll(ctx, key).Result()
+	return m.client.HGetAll(ctx, m.key(ctx, key)).Result()
 }
 
 // HDel deletes one or more fields from a hash
 func (m *Manager) HDel(ctx context.Context, key string, fields ...string) error {
-	return m.client.HDel(ctx, key, fields...).Err()
+	return m.client.HDel(ctx, m.key(ctx, key), fields...).Err()
 }
 
 // LPush prepends one or more values to a list
 func (m *Manager) LPush(ctx context.Context, key string, values ...interface{}) error {
-	return m.client.LPush(ctx, key, values...).Err()
+	return m.client.LPush(ctx, m.key(ctx, key), values...).Err()
 }
 
 // RPush appends one or more values to a list
 func (m *Manager) RPush(ctx context.Context, key string, values ...interface{}) error {
-	return m.client.RPush(ctx, key, values...).Err()
+	return m.client.RPush(ctx, m.key(ctx, key), values...).Err()
 }
 
 // LPop removes and returns the first element of a list
 func (m *Manager) LPop(ctx context.Context, key string) (string, error) {
-	return m.client.LPop(ctx, key).Result()
+	return m.client.LPop(ctx, m.key(ctx, key)).Result()
 }
 
 // RPop removes and returns the last element of a list
 func (m *Manager) RPop(ctx context.Context, key string) (string, error) {
-	return m.client.RPop(ctx, key).Result()
+	return m.client.RPop(ctx, m.key(ctx, key)).Result()
 }
 
 // LRange gets a range of elements from a list
 func (m *Manager) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	return m.client.LRange(ctx, key, start, stop).Result()
+	return m.client.LRange(ctx, m.key(ctx, key), start, stop).Result()
 }
 
 // SAdd adds one or more members to a set
 func (m *Manager) SAdd(ctx context.Context, key string, members ...interface{}) error {
-	return m.client.SAdd(ctx, key, members...).Err()
+	return m.client.SAdd(ctx, m.key(ctx, key), members...).Err()
 }
 
 // SRem removes one or more members from a set
 func (m *Manager) SRem(ctx context.Context, key string, members ...interface{}) error {
-	return m.client.SRem(ctx, key, members...).Err()
+	return m.client.SRem(ctx, m.key(ctx, key), members...).Err()
 }
 
 // SMembers gets all members of a set
 func (m *Manager) SMembers(ctx context.Context, key string) ([]string, error) {
-	return m.client.SMembers(ctx, key).Result()
+	return m.client.SMembers(ctx, m.key(ctx, key)).Result()
 }
 
 // SIsMember checks if a value is a member of a set
 func (m *Manager) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
-	return m.client.SIsMember(ctx, key, member).Result()
+	return m.client.SIsMember(ctx, m.key(ctx, key), member).Result()
 }
 
 // ZAdd adds one or more members to a sorted set
@@ -196,7 +210,7 @@ func (m *Manager) ZAdd(ctx context.Context, key string, members ...*Z) error {
 			Member: m.Member,
 		}
 	}
-	return m.client.ZAdd(ctx, key, redisMembers...).Err()
+	return m.client.ZAdd(ctx, m.key(ctx, key), redisMembers...).Err()
 }
 
 // Z represents a sorted set member
@@ -207,12 +221,12 @@ type Z struct {
 
 // ZRange gets a range of members from a sorted set by index
 func (m *Manager) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	return m.client.ZRange(ctx, key, start, stop).Result()
+	return m.client.ZRange(ctx, m.key(ctx, key), start, stop).Result()
 }
 
 // ZRangeByScore gets members from a sorted set by score range
 func (m *Manager) ZRangeByScore(ctx context.Context, key string, min, max string) ([]string, error) {
-	return m.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+	return m.client.ZRangeByScore(ctx, m.key(ctx, key), &redis.ZRangeBy{
 		Min: min,
 		Max: max,
 	}).Result()
@@ -220,7 +234,7 @@ func (m *Manager) ZRangeByScore(ctx context.Context, key string, min, max string
 
 // ZRem removes one or more members from a sorted set
 func (m *Manager) ZRem(ctx context.Context, key string, members ...interface{}) error {
-	return m.client.ZRem(ctx, key, members...).Err()
+	return m.client.ZRem(ctx, m.key(ctx, key), members...).Err()
 }
 
 // Publish publishes a message to a channel
@@ -228,14 +242,97 @@ func (m *Manager) Publish(ctx context.Context, channel string, message interface
 	return m.client.Publish(ctx, channel, message).Err()
 }
 
-// Keys finds all keys matching a pattern (use with caution in production)
+// Subscribe calls handler with the messages published to channel until ctx
+// is done. Channels containing *, ? or [ are subscribed as patterns, and
+// handler receives the channel of each message.
+func (m *Manager) Subscribe(ctx context.Context, channel string, handler func(channel string, payload []byte) error) error {
+	client, ok := m.client.(interface {
+		Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+		PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub
+	})
+	if !ok {
+		return fmt.Errorf("cache '%s' does not support subscriptions", m.config.Name)
+	}
+
+	var pubsub *redis.PubSub
+	if strings.ContainsAny(channel, "*?[") {
+		pubsub = client.PSubscribe(ctx, channel)
+	} else {
+		pubsub = client.Subscribe(ctx, channel)
+	}
+	// Wait for the confirmation so publishes after Subscribe returns are seen
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return fmt.Errorf("failed to subscribe to '%s': %w", channel, err)
+	}
+
+	go func() {
+		defer func() { _ = pubsub.Close() }()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				if err := handler(msg.Channel, []byte(msg.Payload)); err != nil {
+					xlog.GetWithError(ctx, err).Warnf("Redis subscriber of %s failed", msg.Channel)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Keys finds all keys matching a pattern (use with caution in production).
+// With a KeyPrefix, the pattern and keys are relative to the prefix.
 func (m *Manager) Keys(ctx context.Context, pattern string) ([]string, error) {
-	return m.client.Keys(ctx, pattern).Result()
+	keys, err := m.client.Keys(ctx, m.key(ctx, pattern)).Result()
+	return m.trim(ctx, keys), err
 }
 
 // Scan iterates over keys matching a pattern
 func (m *Manager) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
-	return m.client.Scan(ctx, cursor, match, count).Result()
+	keys, next, err := m.client.Scan(ctx, cursor, m.key(ctx, match), count).Result()
+	return m.trim(ctx, keys), next, err
+}
+
+// prefix returns the key prefix of ctx
+func (m *Manager) prefix(ctx context.Context) string {
+	if m.config.KeyPrefix == nil {
+		return ""
+	}
+	return m.config.KeyPrefix(ctx)
+}
+
+// key returns key with the prefix of ctx
+func (m *Manager) key(ctx context.Context, key string) string {
+	return m.prefix(ctx) + key
+}
+
+// keys returns keys with the prefix of ctx
+func (m *Manager) keys(ctx context.Context, keys []string) []string {
+	prefix := m.prefix(ctx)
+	if prefix == "" {
+		return keys
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = prefix + key
+	}
+	return prefixed
+}
+
+// trim removes the prefix of ctx from keys returned by Redis
+func (m *Manager) trim(ctx context.Context, keys []string) []string {
+	if prefix := m.prefix(ctx); prefix != "" {
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, prefix)
+		}
+	}
+	return keys
 }
 
 // FlushDB deletes all keys in the current database (use with extreme caution)
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -518,3 +519,50 @@ func TestManager_MSetMGet(t *testing.T) {
 		t.Errorf("expected nil for missing key, got %v", got[2])
 	}
 }
+
+func TestManager_KeyPrefix(t *testing.T) {
+	flushCache(t)
+	type tenantKey struct{}
+	scoped := &Manager{client: testCache.client, config: Config{
+		Name: "scoped",
+		KeyPrefix: func(ctx context.Context) string {
+			if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+				return "tenant:" + tenant + ":"
+			}
+			return ""
+		},
+	}}
+	acme := context.WithValue(context.Background(), tenantKey{}, "acme")
+	globex := context.WithValue(context.Background(), tenantKey{}, "globex")
+
+	if err := scoped.Set(acme, "plan", "pro", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := scoped.MSet(globex, "plan", "free", "seats", "3"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := scoped.Get(acme, "plan"); v != "pro" {
+		t.Errorf("acme plan = %q, want pro", v)
+	}
+	if v, _ := scoped.Get(globex, "plan"); v != "free" {
+		t.Errorf("globex plan = %q, want free", v)
+	}
+	if _, err := scoped.Get(context.Background(), "plan"); err != ErrNotFound {
+		t.Errorf("unscoped plan error = %v, want ErrNotFound", err)
+	}
+	if v, _ := testCache.Get(context.Background(), "tenant:acme:plan"); v != "pro" {
+		t.Errorf("stored key = %q, want tenant:acme:plan", v)
+	}
+
+	keys, err := scoped.Keys(globex, "*")
+	sort.Strings(keys)
+	if err != nil || strings.Join(keys, ",") != "plan,seats" {
+		t.Errorf("Keys = %v, %v, want plan and seats", keys, err)
+	}
+	if err := scoped.Del(acme, "plan"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := scoped.Exists(globex, "plan"); n != 1 {
+		t.Error("deleting acme's key deleted globex's")
+	}
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManager_Subscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type message struct{ channel, payload string }
+	received := make(chan message, 4)
+	handler := func(channel string, payload []byte) error {
+		received <- message{channel, string(payload)}
+		return errors.New("handler errors are logged, not fatal")
+	}
+	if err := testCache.Subscribe(ctx, "orders", handler); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := testCache.Subscribe(ctx, "users.*", handler); err != nil {
+		t.Fatalf("Subscribe pattern: %v", err)
+	}
+
+	_ = testCache.Publish(ctx, "orders", "created")
+	_ = testCache.Publish(ctx, "users.42", "renamed")
+	_ = testCache.Publish(ctx, "other", "ignored")
+
+	want := map[message]bool{{"orders", "created"}: true, {"users.42", "renamed"}: true}
+	for range want {
+		select {
+		case m := <-received:
+			if !want[m] {
+				t.Errorf("unexpected message %+v", m)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for messages")
+		}
+	}
+	select {
+	case m := <-received:
+		t.Errorf("unexpected message %+v", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	fwmiddleware "github.com/polymatx/goframe/pkg/framework/middleware"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimiter is a sliding window rate limiter shared by all instances
+// through Redis. The requests of the last window are estimated from the
+// counters of the current and previous fixed windows, the previous one
+// weighted by how much it still overlaps. Rejected requests count too, so
+// clients that keep retrying stay limited.
+type RateLimiter struct {
+	manager *Manager
+	name    string
+	limit   int
+	window  time.Duration
+	now     func() time.Time
+}
+
+// RateLimiter returns a limiter allowing limit requests per window and key,
+// for use with middleware.RateLimitBy. name separates the counters of
+// different limits.
+func (m *Manager) RateLimiter(name string, limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{manager: m, name: name, limit: limit, window: window, now: time.Now}
+}
+
+// Allow counts a request of key and reports whether it is within the limit
+func (l *RateLimiter) Allow(ctx context.Context, key string) (fwmiddleware.RateLimitResult, error) {
+	now := l.now().UnixNano()
+	window := int64(l.window)
+	current := now / window
+	elapsed := float64(now-current*window) / float64(window)
+
+	// The hash tag keeps both counters on one cluster slot
+	prefix := "ratelimit:{" + l.name + ":" + key + "}:"
+	pipe := l.manager.client.Pipeline()
+	incr := pipe.Incr(ctx, prefix+strconv.FormatInt(current, 10))
+	pipe.Expire(ctx, prefix+strconv.FormatInt(current, 10), 2*l.window)
+	prev := pipe.Get(ctx, prefix+strconv.FormatInt(current-1, 10))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return fwmiddleware.RateLimitResult{}, err
+	}
+	previous, _ := prev.Float64()
+
+	estimate := previous*(1-elapsed) + float64(incr.Val())
+	res := fwmiddleware.RateLimitResult{
+		Allowed:   estimate <= float64(l.limit),
+		Limit:     l.limit,
+		Remaining: max(0, l.limit-int(math.Ceil(estimate))),
+		Reset:     time.Duration((1 - elapsed) * float64(l.window)),
+	}
+	if !res.Allowed {
+		res.RetryAfter = time.Duration(retryAfter(l.limit, previous, float64(incr.Val()), elapsed) * float64(l.window))
+	}
+	return res, nil
+}
+
+// retryAfter returns the fraction of a window until the next request fits,
+// given the counts of the previous and current window at elapsed (0-1) into
+// the current one
+func retryAfter(limit int, previous, current, elapsed float64) float64 {
+	// The next request is allowed once previous*(1-t)+current+1 <= limit
+	var wait float64
+	if current+1 <= float64(limit) {
+		wait = 1 - (float64(limit)-current-1)/previous - elapsed
+	} else {
+		// Only after the current window rolls over into the previous one
+		wait = 1 - elapsed
+		if current > 0 {
+			wait += math.Max(0, 1-(float64(limit)-1)/current)
+		}
+	}
+	return math.Max(0, wait)
+}
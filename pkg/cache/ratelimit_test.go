@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	flushCache(t)
+
+	clock := time.Unix(0, 0).Add(1000 * time.Minute) // Start of a window
+	limiter := testCache.RateLimiter("api", 3, time.Minute)
+	limiter.now = func() time.Time { return clock }
+
+	for i := 1; i <= 3; i++ {
+		res, err := limiter.Allow(ctx, "ip:10.0.0.1")
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if !res.Allowed || res.Remaining != 3-i {
+			t.Fatalf("request %d: allowed %v remaining %d, want allowed with %d left", i, res.Allowed, res.Remaining, 3-i)
+		}
+	}
+
+	res, _ := limiter.Allow(ctx, "ip:10.0.0.1")
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("4th request allowed %v remaining %d, want denied", res.Allowed, res.Remaining)
+	}
+	if res.Reset != time.Minute {
+		t.Errorf("Reset = %v, want 1m", res.Reset)
+	}
+	// 4 counted: the window rolls over, then 4*(1-t)+1 <= 3 needs t >= 1/2
+	if res.RetryAfter != 90*time.Second {
+		t.Errorf("RetryAfter = %v, want 1m30s", res.RetryAfter)
+	}
+
+	if res, _ := limiter.Allow(ctx, "ip:10.0.0.2"); !res.Allowed {
+		t.Error("other key limited")
+	}
+
+	// Three quarters into the next window the previous one weighs 1/4
+	clock = clock.Add(time.Minute + 45*time.Second)
+	res, _ = limiter.Allow(ctx, "ip:10.0.0.1")
+	if !res.Allowed || res.Remaining != 1 {
+		t.Errorf("next window: allowed %v remaining %d, want allowed with 1 left", res.Allowed, res.Remaining)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name                       string
+		limit                      int
+		previous, current, elapsed float64
+		want                       float64
+	}{
+		{"previous window drains", 10, 10, 5, 0.2, 0.4},
+		{"current window full", 10, 0, 10, 0.5, 0.5 + 0.1},
+		{"already fits", 10, 10, 1, 0.9, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := retryAfter(tt.limit, tt.previous, tt.current, tt.elapsed)
+			if diff := got - tt.want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("retryAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
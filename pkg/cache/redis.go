@@ -3,10 +3,13 @@ package cache
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/polymatx/goframe/pkg/healthz"
 	"github.com/polymatx/goframe/pkg/xlog"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
@@ -31,6 +34,11 @@ type Config struct {
 	Mode     Mode          // Connection mode (standalone or cluster)
 	PoolSize int           // Connection pool size
 	Timeout  time.Duration // Connection timeout
+
+	// KeyPrefix returns the prefix of the keys of Manager operations, locks
+	// and cached responses for a context, e.g. tenant.KeyPrefix to keep
+	// tenants apart. Rate limit counters are not prefixed.
+	KeyPrefix func(ctx context.Context) string
 }
 
 // Manager provides Redis operations
@@ -76,9 +84,44 @@ func Register(config Config) error {
 	}
 
 	configs = append(configs, config)
+	healthz.RegisterEndpoint("redis:"+config.Name, config.Addrs...)
 	return nil
 }
 
+// ConfigFromURL builds a configuration from a connection URL such as
+// REDIS_URL: redis://:password@host:6379/0. Several comma-separated hosts
+// with ?mode=cluster select cluster mode.
+func ConfigFromURL(name, rawURL string) (Config, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return Config{}, fmt.Errorf("unsupported redis URL scheme: %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return Config{}, fmt.Errorf("redis URL has no host: %s", u.Redacted())
+	}
+	if user := u.User.Username(); user != "" && user != "default" {
+		return Config{}, fmt.Errorf("redis ACL users are not supported: %s", user)
+	}
+
+	config := Config{
+		Name:  name,
+		Addrs: strings.Split(u.Host, ","),
+		Mode:  Mode(u.Query().Get("mode")),
+	}
+	config.Password, _ = u.User.Password()
+
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if config.DB, err = strconv.Atoi(db); err != nil {
+			return Config{}, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+
+	return config, nil
+}
+
 // Initialize establishes all registered Redis connections
 func Initialize(ctx context.Context) error {
 	var initErr error
@@ -124,6 +167,10 @@ func connect(ctx context.Context, config Config) error {
 		})
 	}
 
+	if hooked, ok := client.(interface{ AddHook(redis.Hook) }); ok {
+		hooked.AddHook(spanHook{})
+	}
+
 	// Test connection
 	if err := client.Ping(ctx).Err(); err != nil {
 		xlog.GetWithError(ctx, err).Errorf("Failed to connect to Redis: %s", config.Name)
@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// ConflictPolicy decides how replicated keys treat values already in the
+// target
+type ConflictPolicy int
+
+const (
+	// ConflictOverwrite replaces the target value, so the source region
+	// wins
+	ConflictOverwrite ConflictPolicy = iota
+	// ConflictKeepTarget never replaces or deletes keys present in the
+	// target, which fills gaps without touching keys the target region
+	// writes itself
+	ConflictKeepTarget
+)
+
+// ReplicationConfig configures write-behind replication to a secondary
+// Redis
+type ReplicationConfig struct {
+	Namespaces []string // Key prefixes replicated, e.g. "session:"; all keys when empty
+	Conflict   ConflictPolicy
+	QueueSize  int // Keys pending replication before new writes are dropped (default 10000)
+	Workers    int // Concurrent copies (default 2)
+}
+
+var (
+	replicationLag = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "cache_replication_lag_seconds",
+			Help:    "Time from a cache write to its copy reaching the replication target",
+			Buckets: []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"source", "target"},
+	)
+	replicationPending = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cache_replication_pending",
+			Help: "Cache keys waiting to be replicated",
+		},
+		[]string{"source", "target"},
+	)
+	replicationFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_replication_failures_total",
+			Help: "Cache keys not replicated, by reason",
+		},
+		[]string{"source", "target", "reason"},
+	)
+)
+
+// writeCommands are the commands whose keys are replicated
+var writeCommands = map[string]bool{
+	"set": true, "setnx": true, "setex": true, "psetex": true, "getset": true, "getdel": true, "getex": true,
+	"mset": true, "msetnx": true, "append": true, "setrange": true,
+	"incr": true, "incrby": true, "incrbyfloat": true, "decr": true, "decrby": true,
+	"del": true, "unlink": true, "expire": true, "pexpire": true, "expireat": true, "pexpireat": true, "persist": true,
+	"hset": true, "hsetnx": true, "hmset": true, "hdel": true, "hincrby": true, "hincrbyfloat": true,
+	"lpush": true, "rpush": true, "lpop": true, "rpop": true, "lset": true, "lrem": true, "ltrim": true, "linsert": true,
+	"sadd": true, "srem": true, "spop": true, "smove": true,
+	"zadd": true, "zrem": true, "zincrby": true, "zremrangebyscore": true, "zremrangebyrank": true,
+}
+
+// Replicator copies the keys written through a manager to a target
+type Replicator struct {
+	source *Manager
+	target *Manager
+	config ReplicationConfig
+	labels prometheus.Labels
+
+	queue   chan string
+	mu      sync.Mutex
+	pending map[string]time.Time // Key to the time of its first unreplicated write
+	copying int
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// Replicate starts write-behind replication of the keys written through m
+// to target, for disaster recovery or reads close to users in another
+// region. Each written key is copied with DUMP and RESTORE shortly after,
+// with its TTL; several writes to a key before its copy are coalesced.
+// Writes are dropped when the queue is full, and lost with the process if
+// it stops before Stop drains them.
+func (m *Manager) Replicate(target *Manager, config ReplicationConfig) (*Replicator, error) {
+	hooked, ok := m.client.(interface{ AddHook(redis.Hook) })
+	if !ok {
+		return nil, fmt.Errorf("cache '%s' does not support replication", m.config.Name)
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.Workers <= 0 {
+		config.Workers = 2
+	}
+
+	r := &Replicator{
+		source:  m,
+		target:  target,
+		config:  config,
+		labels:  prometheus.Labels{"source": m.config.Name, "target": target.config.Name},
+		queue:   make(chan string, config.QueueSize),
+		pending: make(map[string]time.Time),
+	}
+	hooked.AddHook(replicationHook{r})
+
+	for i := 0; i < config.Workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+	return r, nil
+}
+
+// Pending returns the number of keys waiting to be replicated, including
+// those being copied
+func (r *Replicator) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending) + r.copying
+}
+
+// Stop stops accepting writes and waits until the pending keys are
+// replicated or ctx is done
+func (r *Replicator) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.stopped {
+		r.stopped = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Replicator) matches(key string) bool {
+	if len(r.config.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range r.config.Namespaces {
+		if strings.HasPrefix(key, ns) {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue schedules a key written at now
+func (r *Replicator) enqueue(key string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	if _, ok := r.pending[key]; ok {
+		return // Its copy will carry this write too
+	}
+	select {
+	case r.queue <- key:
+		r.pending[key] = now
+		replicationPending.With(r.labels).Inc()
+	default:
+		replicationFailures.With(r.withReason("dropped")).Inc()
+	}
+}
+
+func (r *Replicator) withReason(reason string) prometheus.Labels {
+	return prometheus.Labels{"source": r.labels["source"], "target": r.labels["target"], "reason": reason}
+}
+
+func (r *Replicator) work() {
+	defer r.wg.Done()
+	for key := range r.queue {
+		r.mu.Lock()
+		written := r.pending[key]
+		delete(r.pending, key) // Writes from now on queue the key again
+		r.copying++
+		r.mu.Unlock()
+
+		timeout := r.source.config.Timeout + r.target.config.Timeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := r.copy(ctx, key)
+		cancel()
+
+		r.mu.Lock()
+		r.copying--
+		r.mu.Unlock()
+		replicationPending.With(r.labels).Dec()
+		if err != nil {
+			replicationFailures.With(r.withReason("error")).Inc()
+			logrus.WithError(err).WithField("key", key).Warnf("cache replication to %s failed", r.target.config.Name)
+			continue
+		}
+		replicationLag.With(r.labels).Observe(time.Since(written).Seconds())
+	}
+}
+
+// copy makes the target key match the source key
+func (r *Replicator) copy(ctx context.Context, key string) error {
+	pipe := r.source.client.Pipeline()
+	dump := pipe.Dump(ctx, key)
+	pttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	target := r.target.client
+	if errors.Is(dump.Err(), redis.Nil) {
+		if r.config.Conflict == ConflictKeepTarget {
+			return nil
+		}
+		return target.Del(ctx, key).Err()
+	}
+
+	ttl := pttl.Val()
+	if ttl < 0 {
+		ttl = 0 // No expiry
+	}
+	if r.config.Conflict == ConflictKeepTarget {
+		err := target.Restore(ctx, key, ttl, dump.Val()).Err()
+		if err != nil && strings.HasPrefix(err.Error(), "BUSYKEY") {
+			return nil
+		}
+		return err
+	}
+	return target.RestoreReplace(ctx, key, ttl, dump.Val()).Err()
+}
+
+// replicationHook queues the keys of successful write commands
+type replicationHook struct {
+	r *Replicator
+}
+
+func (h replicationHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h replicationHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		h.record(cmd, time.Now())
+		return err
+	}
+}
+
+func (h replicationHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		now := time.Now()
+		for _, cmd := range cmds {
+			h.record(cmd, now)
+		}
+		return err
+	}
+}
+
+func (h replicationHook) record(cmd redis.Cmder, now time.Time) {
+	if cmd.Err() != nil && !errors.Is(cmd.Err(), redis.Nil) {
+		return
+	}
+	name := strings.ToLower(cmd.Name())
+	if !writeCommands[name] {
+		return
+	}
+	for _, key := range commandKeys(name, cmd.Args()) {
+		if h.r.matches(key) {
+			h.r.enqueue(key, now)
+		}
+	}
+}
+
+// commandKeys returns the keys a write command modifies
+func commandKeys(name string, args []interface{}) []string {
+	var keys []string
+	switch name {
+	case "del", "unlink":
+		for _, a := range args[1:] {
+			keys = append(keys, fmt.Sprint(a))
+		}
+	case "mset", "msetnx":
+		for i := 1; i < len(args); i += 2 {
+			keys = append(keys, fmt.Sprint(args[i]))
+		}
+	case "smove":
+		if len(args) > 2 {
+			keys = append(keys, fmt.Sprint(args[1]), fmt.Sprint(args[2]))
+		}
+	default:
+		if len(args) > 1 {
+			keys = append(keys, fmt.Sprint(args[1]))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newFakeManager starts a fake Redis with a manager outside the registry
+func newFakeManager(t *testing.T, name string) *Manager {
+	t.Helper()
+	srv, err := startFakeRedis()
+	if err != nil {
+		t.Fatalf("start fake redis: %v", err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+		srv.Close()
+	})
+	return &Manager{client: client, config: Config{Name: name, Timeout: time.Second}}
+}
+
+func waitReplicated(t *testing.T, r *Replicator) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for r.Pending() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d keys still pending", r.Pending())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReplicate(t *testing.T) {
+	ctx := context.Background()
+	source, target := newFakeManager(t, "eu"), newFakeManager(t, "us")
+
+	r, err := source.Replicate(target, ReplicationConfig{Namespaces: []string{"session:"}})
+	if err != nil {
+		t.Fatalf("Replicate: %v", err)
+	}
+	defer func() { _ = r.Stop(ctx) }()
+
+	_ = source.Set(ctx, "session:1", "alice", time.Minute)
+	_ = source.Set(ctx, "session:2", "bob", 0)
+	_ = source.Set(ctx, "local:1", "not replicated", 0)
+	_ = source.MSet(ctx, "session:3", "carol", "local:2", "x")
+	waitReplicated(t, r)
+
+	for key, want := range map[string]string{"session:1": "alice", "session:2": "bob", "session:3": "carol"} {
+		if got, err := target.Get(ctx, key); err != nil || got != want {
+			t.Errorf("target %s = %q, %v; want %q", key, got, err, want)
+		}
+	}
+	if ttl, _ := target.TTL(ctx, "session:1"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("target TTL = %v, want the source TTL", ttl)
+	}
+	if n, _ := target.Exists(ctx, "local:1", "local:2"); n != 0 {
+		t.Error("keys outside the namespaces were replicated")
+	}
+
+	_ = source.Del(ctx, "session:2")
+	waitReplicated(t, r)
+	if _, err := target.Get(ctx, "session:2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted key still in target: %v", err)
+	}
+}
+
+func TestReplicate_KeepTarget(t *testing.T) {
+	ctx := context.Background()
+	source, target := newFakeManager(t, "eu"), newFakeManager(t, "us")
+
+	r, err := source.Replicate(target, ReplicationConfig{Conflict: ConflictKeepTarget})
+	if err != nil {
+		t.Fatalf("Replicate: %v", err)
+	}
+
+	_ = target.Set(ctx, "owned", "by us", 0)
+	_ = source.Set(ctx, "owned", "by eu", 0)
+	_ = source.Set(ctx, "missing", "filled", 0)
+	if err := r.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if got, _ := target.Get(ctx, "owned"); got != "by us" {
+		t.Errorf("owned = %q, want target value kept", got)
+	}
+	if got, _ := target.Get(ctx, "missing"); got != "filled" {
+		t.Errorf("missing = %q, want copied", got)
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ResponseStore keeps cached HTTP responses in Redis, for use with
+// middleware.Cache
+type ResponseStore struct {
+	manager *Manager
+	prefix  string
+}
+
+// ResponseStore returns a response store keeping its keys under prefix,
+// e.g. "httpcache:"
+func (m *Manager) ResponseStore(prefix string) *ResponseStore {
+	return &ResponseStore{manager: m, prefix: prefix}
+}
+
+// Get returns the value of key, with ok false when it does not exist
+func (s *ResponseStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.manager.client.Get(ctx, s.manager.key(ctx, s.prefix+key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key for ttl
+func (s *ResponseStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.manager.client.Set(ctx, s.manager.key(ctx, s.prefix+key), value, ttl).Err()
+}
+
+// Delete removes keys
+func (s *ResponseStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.prefix + key
+	}
+	return s.manager.client.Del(ctx, s.manager.keys(ctx, prefixed)...).Err()
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestResponseStore(t *testing.T) {
+	ctx := context.Background()
+	flushCache(t)
+	store := testCache.ResponseStore("httpcache:")
+
+	if _, ok, err := store.Get(ctx, "page"); ok || err != nil {
+		t.Fatalf("Get missing = ok %v, err %v; want miss", ok, err)
+	}
+	if err := store.Set(ctx, "page", []byte("body"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if raw, err := testCache.Get(ctx, "httpcache:page"); err != nil || raw != "body" {
+		t.Errorf("raw key = %q, %v; want prefixed key holding body", raw, err)
+	}
+	value, ok, err := store.Get(ctx, "page")
+	if err != nil || !ok || string(value) != "body" {
+		t.Errorf("Get = %q, %v, %v; want body", value, ok, err)
+	}
+
+	if err := store.Delete(ctx, "page"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "page"); ok {
+		t.Error("key still present after Delete")
+	}
+}
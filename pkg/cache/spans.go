@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/reqctx"
+	"github.com/redis/go-redis/v9"
+)
+
+// spanHook records the commands of requests served by
+// middleware.SlowRequests as cache spans. connect installs it on every
+// client.
+type spanHook struct{}
+
+func (spanHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (spanHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !reqctx.RecordingSpans(ctx) {
+			return next(ctx, cmd)
+		}
+		start := time.Now()
+		err := next(ctx, cmd)
+		reqctx.RecordSpan(ctx, reqctx.SpanCache, spanName(cmd), start, spanError(err))
+		return err
+	}
+}
+
+func (spanHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !reqctx.RecordingSpans(ctx) {
+			return next(ctx, cmds)
+		}
+		start := time.Now()
+		err := next(ctx, cmds)
+		name := "pipeline(" + strconv.Itoa(len(cmds)) + ")"
+		if len(cmds) > 0 {
+			name += " " + spanName(cmds[0])
+		}
+		reqctx.RecordSpan(ctx, reqctx.SpanCache, name, start, spanError(err))
+		return err
+	}
+}
+
+// spanName is the command and its key, without values
+func spanName(cmd redis.Cmder) string {
+	name := strings.ToUpper(cmd.Name())
+	if args := cmd.Args(); len(args) > 1 {
+		if key, ok := args[1].(string); ok {
+			name += " " + key
+		}
+	}
+	return name
+}
+
+// spanError drops redis.Nil, a cache miss rather than a failure
+func spanError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/redis/go-redis/v9"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestSpanHook(t *testing.T) {
+	m := newFakeManager(t, "spans")
+	m.client.(*redis.Client).AddHook(spanHook{})
+	// Dial outside the request, whose handshake would be recorded too
+	if err := m.client.Ping(context.Background()).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	hook := logrustest.NewGlobal()
+	defer hook.Reset()
+
+	h := middleware.SlowRequests(middleware.SlowRequestConfig{Threshold: time.Nanosecond})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		m.client.Set(ctx, "user:1", "ada", 0)
+		m.client.Get(ctx, "user:2")
+		pipe := m.client.Pipeline()
+		pipe.Get(ctx, "user:1")
+		pipe.Get(ctx, "user:3")
+		_, _ = pipe.Exec(ctx)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("expected slow request log")
+	}
+	spans := entry.Data["spans"].([]middleware.Span)
+	want := []string{"SET user:1", "GET user:2", "pipeline(2) GET user:1"}
+	if len(spans) != len(want) {
+		t.Fatalf("expected %d spans, got %+v", len(want), spans)
+	}
+	for i, name := range want {
+		if spans[i].Kind != middleware.SpanCache || spans[i].Name != name || spans[i].Error != "" {
+			t.Errorf("span %d = %+v, want %q without error", i, spans[i], name)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/polymatx/goframe/pkg/cache"
+	"github.com/polymatx/goframe/pkg/database"
+	"github.com/polymatx/goframe/pkg/mongodb"
+	"github.com/polymatx/goframe/pkg/rabbit"
+	"github.com/sirupsen/logrus"
+)
+
+// EnvConnectionName is the name connections from AutoRegisterFromEnv are
+// registered under, e.g. database.Get(config.EnvConnectionName)
+const EnvConnectionName = "main"
+
+// AutoRegisterFromEnv registers a connection for each of the standard
+// DATABASE_URL, REDIS_URL, MONGODB_URI and AMQP_URL variables that is set,
+// so container platforms can configure drivers without code. Call it before
+// the drivers' Initialize. It returns the variables that were used.
+func AutoRegisterFromEnv() ([]string, error) {
+	var (
+		used []string
+		errs []error
+	)
+
+	register := func(env string, fn func(value string) error) {
+		value := os.Getenv(env)
+		if value == "" {
+			return
+		}
+		if err := fn(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", env, err))
+			return
+		}
+		used = append(used, env)
+		logrus.Infof("Registered %q connection from %s", EnvConnectionName, env)
+	}
+
+	register("DATABASE_URL", func(value string) error {
+		cfg, err := database.ConfigFromURL(EnvConnectionName, value)
+		if err != nil {
+			return err
+		}
+		return database.Register(cfg)
+	})
+	register("REDIS_URL", func(value string) error {
+		cfg, err := cache.ConfigFromURL(EnvConnectionName, value)
+		if err != nil {
+			return err
+		}
+		return cache.Register(cfg)
+	})
+	register("MONGODB_URI", func(value string) error {
+		cfg, err := mongodb.ConfigFromURI(EnvConnectionName, value)
+		if err != nil {
+			return err
+		}
+		mongodb.Register(cfg)
+		return nil
+	})
+	register("AMQP_URL", func(value string) error {
+		return rabbit.RegisterURL(EnvConnectionName, value)
+	})
+
+	return used, errors.Join(errs...)
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Anonymizer returns the replacement of a column value. Digest is a keyed
+// hash of the original value: deriving fake values from it keeps equal
+// inputs equal (and unique columns unique) without revealing them.
+type Anonymizer func(value interface{}, digest string) interface{}
+
+// AnonymizeRule anonymizes columns of a table
+type AnonymizeRule struct {
+	Table   string
+	Key     string            // Primary key used to page and update rows (default "id")
+	Columns map[string]string // Column name to anonymizer name
+}
+
+var (
+	anonymizersLock sync.RWMutex
+	anonymizers     = map[string]Anonymizer{
+		"null": func(interface{}, string) interface{} { return nil },
+		"hash": func(_ interface{}, digest string) interface{} { return digest[:16] },
+		"fake_email": func(_ interface{}, digest string) interface{} {
+			return "user_" + digest[:12] + "@example.com"
+		},
+		"fake_name":  func(_ interface{}, digest string) interface{} { return "User " + digest[:8] },
+		"fake_phone": func(_ interface{}, digest string) interface{} { return "+1555" + digitsOf(digest, 7) },
+		"redact":     func(interface{}, string) interface{} { return "REDACTED" },
+	}
+
+	anonymizeProfiles = make(map[string][]AnonymizeRule)
+)
+
+// RegisterAnonymizer adds or replaces a named anonymizer. Built in are null,
+// hash, fake_email, fake_name, fake_phone and redact.
+func RegisterAnonymizer(name string, fn Anonymizer) {
+	anonymizersLock.Lock()
+	defer anonymizersLock.Unlock()
+	anonymizers[name] = fn
+}
+
+// RegisterAnonymizeProfile registers the rules applied by AnonymizeProfile,
+// e.g. a "staging" profile
+func RegisterAnonymizeProfile(profile string, rules ...AnonymizeRule) {
+	anonymizersLock.Lock()
+	defer anonymizersLock.Unlock()
+	anonymizeProfiles[profile] = append(anonymizeProfiles[profile], rules...)
+}
+
+// AnonymizeProfile applies the rules of a registered profile
+func (c *Connection) AnonymizeProfile(ctx context.Context, profile string, salt []byte) (int64, error) {
+	anonymizersLock.RLock()
+	rules, ok := anonymizeProfiles[profile]
+	anonymizersLock.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("anonymize profile '%s' not found", profile)
+	}
+	return c.Anonymize(ctx, rules, salt)
+}
+
+// Anonymize rewrites the columns of every rule in place and returns the
+// number of rows updated. Run it on a copy of production data, never on
+// production itself. Digests are keyed with salt; with a nil salt a random
+// one is used, so hashes cannot be matched against other snapshots.
+func (c *Connection) Anonymize(ctx context.Context, rules []AnonymizeRule, salt []byte) (int64, error) {
+	if salt == nil {
+		salt = make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return 0, err
+		}
+	}
+
+	// Resolve every anonymizer first so a typo fails before any row changes
+	resolved := make([]map[string]Anonymizer, len(rules))
+	anonymizersLock.RLock()
+	for i, rule := range rules {
+		resolved[i] = make(map[string]Anonymizer, len(rule.Columns))
+		for column, name := range rule.Columns {
+			fn, ok := anonymizers[name]
+			if !ok {
+				anonymizersLock.RUnlock()
+				return 0, fmt.Errorf("unknown anonymizer '%s' for %s.%s", name, rule.Table, column)
+			}
+			resolved[i][column] = fn
+		}
+	}
+	anonymizersLock.RUnlock()
+
+	var total int64
+	for i, rule := range rules {
+		n, err := c.anonymizeTable(ctx, rule, resolved[i], salt)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("failed to anonymize %s: %w", rule.Table, err)
+		}
+	}
+	return total, nil
+}
+
+const anonymizeBatchSize = 500
+
+func (c *Connection) anonymizeTable(ctx context.Context, rule AnonymizeRule, fns map[string]Anonymizer, salt []byte) (int64, error) {
+	key := rule.Key
+	if key == "" {
+		key = "id"
+	}
+
+	columns := make([]string, 0, len(fns))
+	for column := range fns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var total int64
+	var last interface{}
+	for {
+		query := c.WithContext(ctx).Table(rule.Table).Select(append([]string{key}, columns...)).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: key}}).Limit(anonymizeBatchSize)
+		if last != nil {
+			query = query.Where(clause.Gt{Column: clause.Column{Name: key}, Value: last})
+		}
+
+		var rows []map[string]interface{}
+		if err := query.Find(&rows).Error; err != nil {
+			return total, err
+		}
+		if len(rows) == 0 {
+			return total, nil
+		}
+
+		var updated int64
+		err := c.Transaction(ctx, func(tx *gorm.DB) error {
+			for _, row := range rows {
+				updates := make(map[string]interface{}, len(columns))
+				for _, column := range columns {
+					value := row[column]
+					if value == nil {
+						continue // Nothing to hide
+					}
+					updates[column] = fns[column](value, digest(salt, value))
+				}
+				if len(updates) == 0 {
+					continue
+				}
+				err := tx.Table(rule.Table).Where(clause.Eq{Column: clause.Column{Name: key}, Value: row[key]}).
+					Updates(updates).Error
+				if err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += updated
+
+		last = rows[len(rows)-1][key]
+		if len(rows) < anonymizeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// digest does not depend on the column, so a value copied across tables
+// (an email in users and orders) still joins after anonymization
+func digest(salt []byte, value interface{}) string {
+	mac := hmac.New(sha256.New, salt)
+	switch v := value.(type) {
+	case []byte:
+		mac.Write(v)
+	default:
+		fmt.Fprint(mac, v)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func digitsOf(hexDigest string, n int) string {
+	digits := make([]byte, 0, n)
+	for i := 0; len(digits) < n && i < len(hexDigest); i++ {
+		digits = append(digits, '0'+hexDigest[i]%10)
+	}
+	return string(digits)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type anonCustomer struct {
+	ID    uint `gorm:"primaryKey"`
+	Email string
+	Phone string
+	Notes *string
+	Name  string
+}
+
+func TestConnection_Anonymize(t *testing.T) {
+	conn := mustConn(t)
+	db := conn.DB()
+	if err := db.AutoMigrate(&anonCustomer{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Migrator().DropTable(&anonCustomer{}) })
+
+	notes := "VIP, call before delivery"
+	customers := []anonCustomer{
+		{Email: "alice@corp.com", Phone: "+4915112345678", Notes: &notes, Name: "Alice"},
+		{Email: "bob@corp.com", Phone: "+4915112345678", Name: "Bob"},
+	}
+	// More rows than one batch so paging is exercised
+	for i := 0; i < anonymizeBatchSize; i++ {
+		customers = append(customers, anonCustomer{Email: "x@corp.com", Name: "X"})
+	}
+	if err := db.CreateInBatches(customers, 100).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	RegisterAnonymizer("initials", func(value interface{}, _ string) interface{} {
+		return strings.ToUpper(value.(string)[:1]) + "."
+	})
+	RegisterAnonymizeProfile("test-staging", AnonymizeRule{
+		Table:   "anon_customers",
+		Columns: map[string]string{"email": "fake_email", "phone": "hash", "notes": "null", "name": "initials"},
+	})
+
+	n, err := conn.AnonymizeProfile(context.Background(), "test-staging", []byte("salt"))
+	if err != nil {
+		t.Fatalf("anonymize: %v", err)
+	}
+	if n != int64(len(customers)) {
+		t.Errorf("expected %d rows updated, got %d", len(customers), n)
+	}
+
+	var got []anonCustomer
+	db.Order("id").Limit(2).Find(&got)
+	alice, bob := got[0], got[1]
+
+	if !strings.HasPrefix(alice.Email, "user_") || !strings.HasSuffix(alice.Email, "@example.com") || alice.Email == bob.Email {
+		t.Errorf("unexpected emails %q and %q", alice.Email, bob.Email)
+	}
+	if alice.Phone == "+4915112345678" || len(alice.Phone) != 16 || alice.Phone != bob.Phone {
+		t.Errorf("expected equal phones to hash equally, got %q and %q", alice.Phone, bob.Phone)
+	}
+	if alice.Notes != nil {
+		t.Errorf("expected notes to be nulled, got %q", *alice.Notes)
+	}
+	if alice.Name != "A." {
+		t.Errorf("expected the custom anonymizer to run, got %q", alice.Name)
+	}
+
+	var remaining int64
+	db.Model(&anonCustomer{}).Where("email LIKE ?", "%@corp.com").Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("expected every row anonymized, %d left", remaining)
+	}
+}
+
+func TestConnection_Anonymize_Errors(t *testing.T) {
+	conn := mustConn(t)
+
+	if _, err := conn.AnonymizeProfile(context.Background(), "missing", nil); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+
+	_, err := conn.Anonymize(context.Background(), []AnonymizeRule{
+		{Table: "anything", Columns: map[string]string{"email": "fake_emial"}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "fake_emial") {
+		t.Errorf("expected an unknown anonymizer error, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/polymatx/goframe/pkg/xlog"
+	"gorm.io/gorm"
+)
+
+type primaryKey struct{}
+
+type stickySessionKey struct{}
+
+// WithPrimary returns a context whose reads go to the primary, for reads
+// that must see the latest writes
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// StickyStore keeps the markers of ReadYourWrites. *cache.Manager
+// implements it, sharing the markers between instances.
+type StickyStore interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Exists(ctx context.Context, keys ...string) (int64, error)
+}
+
+// stickySession tracks the writes of a request
+type stickySession struct {
+	store  StickyStore
+	key    string
+	window time.Duration
+	sticky atomic.Bool // Read from the primary
+	mark   sync.Once
+}
+
+// ReadYourWrites middleware sends the reads of a client to the primary for
+// window after it wrote through a connection with replicas, so a page
+// loaded after a POST does not miss the change on a lagging replica. key
+// identifies the client and defaults to middleware.KeyByUser; requests
+// with an empty key are not tracked.
+func ReadYourWrites(store StickyStore, window time.Duration, key func(*http.Request) string) func(http.Handler) http.Handler {
+	if key == nil {
+		key = middleware.KeyByUser
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			session := &stickySession{store: store, key: "ryw:" + k, window: window}
+			if n, err := store.Exists(ctx, session.key); err != nil {
+				// Without the marker a replica may be stale; fail safe
+				xlog.GetWithError(ctx, err).Warn("read-your-writes lookup failed")
+				session.sticky.Store(true)
+			} else if n > 0 {
+				session.sticky.Store(true)
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, stickySessionKey{}, session)))
+		})
+	}
+}
+
+// readsFromPrimary reports whether reads with ctx must go to the primary
+func readsFromPrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return true
+	}
+	session, _ := ctx.Value(stickySessionKey{}).(*stickySession)
+	return session != nil && session.sticky.Load()
+}
+
+// markWrite makes the client of ctx read from the primary for the rest of
+// the request and the sticky window
+func markWrite(ctx context.Context) {
+	session, _ := ctx.Value(stickySessionKey{}).(*stickySession)
+	if session == nil {
+		return
+	}
+	session.sticky.Store(true)
+	session.mark.Do(func() {
+		if err := session.store.Set(ctx, session.key, "1", session.window); err != nil {
+			xlog.GetWithError(ctx, err).Warn("read-your-writes marker failed")
+		}
+	})
+}
+
+// openReplicas opens the replica pools of config and routes reads of db to
+// them
+func openReplicas(ctx context.Context, db *gorm.DB, config Config) ([]*sql.DB, error) {
+	if len(config.Replicas) == 0 {
+		return nil, nil
+	}
+
+	pools := make([]*sql.DB, 0, len(config.Replicas))
+	closeAll := func() {
+		for _, pool := range pools {
+			_ = pool.Close()
+		}
+	}
+	for i, dsn := range config.Replicas {
+		dialector, err := openDialector(config.Driver, dsn)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		replica, err := gorm.Open(dialector, &gorm.Config{Logger: db.Logger})
+		if err == nil {
+			var pool *sql.DB
+			if pool, err = replica.DB(); err == nil {
+				pool.SetMaxIdleConns(config.MaxIdleConns)
+				pool.SetMaxOpenConns(config.MaxOpenConns)
+				pool.SetConnMaxLifetime(config.ConnMaxLifetime)
+				pool.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+				pools = append(pools, pool)
+				err = pool.PingContext(ctx)
+			}
+		}
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to connect to replica %d of database '%s': %w", i, config.Name, err)
+		}
+	}
+
+	conns := make([]gorm.ConnPool, len(pools))
+	for i, pool := range pools {
+		conns[i] = pool
+	}
+	plugin := &replicaPlugin{replicas: conns}
+	if err := db.Use(plugin); err != nil {
+		closeAll()
+		return nil, fmt.Errorf("failed to use plugin '%s' on database '%s': %w", plugin.Name(), config.Name, err)
+	}
+	return pools, nil
+}
+
+// replicaPlugin sends reads outside transactions to the replicas in turn,
+// and records writes for ReadYourWrites
+type replicaPlugin struct {
+	primary  gorm.ConnPool
+	replicas []gorm.ConnPool
+	next     atomic.Uint64
+}
+
+func (p *replicaPlugin) Name() string { return "goframe:replicas" }
+
+func (p *replicaPlugin) Initialize(db *gorm.DB) error {
+	p.primary = db.ConnPool
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("goframe:replicas:query", p.route); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("goframe:replicas:row", p.route); err != nil {
+		return err
+	}
+	for _, w := range []struct {
+		after string
+		reg   func(name string, fn func(*gorm.DB)) error
+	}{
+		{"gorm:create", cb.Create().After("gorm:create").Register},
+		{"gorm:update", cb.Update().After("gorm:update").Register},
+		{"gorm:delete", cb.Delete().After("gorm:delete").Register},
+		{"gorm:raw", cb.Raw().After("gorm:raw").Register},
+	} {
+		if err := w.reg("goframe:replicas:"+strings.TrimPrefix(w.after, "gorm:"), p.recordWrite); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *replicaPlugin) route(db *gorm.DB) {
+	stmt := db.Statement
+	// Statements in a transaction run on its connection
+	if db.Error != nil || stmt.ConnPool != p.primary || readsFromPrimary(stmt.Context) {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	if sql := skipComments(stmt.SQL.String()); sql != "" {
+		// Raw SQL may write, e.g. INSERT ... RETURNING
+		upper := strings.ToUpper(sql)
+		if !strings.HasPrefix(upper, "SELECT") || strings.Contains(upper, " FOR UPDATE") || strings.Contains(upper, " FOR SHARE") {
+			return
+		}
+	}
+	stmt.ConnPool = p.replicas[(p.next.Add(1)-1)%uint64(len(p.replicas))]
+}
+
+// skipComments returns sql without its leading comments, such as the tags
+// of ForRequest sessions
+func skipComments(sql string) string {
+	for {
+		sql = strings.TrimSpace(sql)
+		switch {
+		case strings.HasPrefix(sql, "/*"):
+			end := strings.Index(sql, "*/")
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+2:]
+		case strings.HasPrefix(sql, "--"):
+			end := strings.IndexByte(sql, '\n')
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+1:]
+		default:
+			return sql
+		}
+	}
+}
+
+func (p *replicaPlugin) recordWrite(db *gorm.DB) {
+	if db.Error == nil && db.Statement.Context != nil {
+		markWrite(db.Statement.Context)
+	}
+}
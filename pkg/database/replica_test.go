@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/reqctx"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// memoryStickyStore is a StickyStore in a map
+type memoryStickyStore struct {
+	mu   sync.Mutex
+	keys map[string]time.Duration
+}
+
+func (s *memoryStickyStore) Set(_ context.Context, key, _ string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key] = ttl
+	return nil
+}
+
+func (s *memoryStickyStore) Exists(_ context.Context, keys ...string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, k := range keys {
+		if _, ok := s.keys[k]; ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+type replicatedItem struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+// openReplicated returns a SQLite primary routing reads to a separate
+// SQLite replica, each holding one row naming its database. plugins are
+// installed first, as connect does.
+func openReplicated(t *testing.T, plugins ...gorm.Plugin) *gorm.DB {
+	t.Helper()
+	dir := t.TempDir()
+	open := func(name string) *gorm.DB {
+		db, err := gorm.Open(sqlite.Open(filepath.Join(dir, name+".db")), &gorm.Config{Logger: logger.Discard})
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		if err := db.AutoMigrate(&replicatedItem{}); err != nil {
+			t.Fatalf("migrate %s: %v", name, err)
+		}
+		if err := db.Create(&replicatedItem{Name: name}).Error; err != nil {
+			t.Fatalf("seed %s: %v", name, err)
+		}
+		sqlDB, _ := db.DB()
+		t.Cleanup(func() { _ = sqlDB.Close() })
+		return db
+	}
+
+	primary, replica := open("primary"), open("replica")
+	for _, p := range plugins {
+		if err := primary.Use(p); err != nil {
+			t.Fatalf("Use: %v", err)
+		}
+	}
+	if err := primary.Use(&replicaPlugin{replicas: []gorm.ConnPool{replica.ConnPool}}); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	return primary
+}
+
+func readFrom(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var item replicatedItem
+	if err := db.First(&item).Error; err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	return item.Name
+}
+
+func TestReplicaRouting(t *testing.T) {
+	db := openReplicated(t)
+	ctx := context.Background()
+
+	if got := readFrom(t, db.WithContext(ctx)); got != "replica" {
+		t.Errorf("read went to %s, want replica", got)
+	}
+	if got := readFrom(t, db.WithContext(WithPrimary(ctx))); got != "primary" {
+		t.Errorf("WithPrimary read went to %s", got)
+	}
+
+	var raw string
+	if err := db.Raw("SELECT name FROM replicated_items LIMIT 1").Scan(&raw).Error; err != nil || raw != "replica" {
+		t.Errorf("raw SELECT went to %q (%v), want replica", raw, err)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if got := readFrom(t, tx); got != "primary" {
+			t.Errorf("read in transaction went to %s", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+}
+
+func TestReplicaRouting_TaggedStatements(t *testing.T) {
+	capture := &sqlCapture{name: "test:replica-sql"}
+	db := openReplicated(t, &requestPlugin{driver: SQLite}, capture)
+	ctx := context.WithValue(reqctx.WithRequestID(context.Background(), "req-1"), forRequestKey{}, time.Duration(0))
+
+	var raw string
+	if err := db.WithContext(ctx).Raw("SELECT name FROM replicated_items LIMIT 1").Scan(&raw).Error; err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if sql := capture.take(); len(sql) != 1 || !strings.HasPrefix(sql[0], "/*request_id='req-1'*/ SELECT") {
+		t.Fatalf("SQL = %q, want it tagged", sql)
+	}
+	if raw != "replica" {
+		t.Errorf("tagged raw SELECT went to %q, want replica", raw)
+	}
+	if got := readFrom(t, db.WithContext(ctx)); got != "replica" {
+		t.Errorf("tagged read went to %s, want replica", got)
+	}
+}
+
+func TestSkipComments(t *testing.T) {
+	tests := []struct{ sql, want string }{
+		{"SELECT 1", "SELECT 1"},
+		{"/*route='%2F'*/ SELECT 1", "SELECT 1"},
+		{" /* a */ /* b */\n-- c\nselect 1", "select 1"},
+		{"/* unterminated", ""},
+	}
+	for _, tt := range tests {
+		if got := skipComments(tt.sql); got != tt.want {
+			t.Errorf("skipComments(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	db := openReplicated(t)
+	store := &memoryStickyStore{keys: map[string]time.Duration{}}
+	key := func(r *http.Request) string { return r.Header.Get("X-User") }
+
+	var reads []string
+	handler := ReadYourWrites(store, 5*time.Second, key)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn := db.WithContext(r.Context())
+		reads = append(reads, readFrom(t, conn))
+		if r.Method == http.MethodPost {
+			if err := conn.Create(&replicatedItem{Name: "new"}).Error; err != nil {
+				t.Errorf("Create: %v", err)
+			}
+			reads = append(reads, readFrom(t, conn))
+		}
+	}))
+	serve := func(method, user string) {
+		r := httptest.NewRequest(method, "/", nil)
+		r.Header.Set("X-User", user)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	serve(http.MethodPost, "alice") // Replica, then primary after the write
+	serve(http.MethodGet, "alice")  // Primary while the marker lives
+	serve(http.MethodGet, "bob")    // Other clients keep using the replica
+
+	want := []string{"replica", "primary", "primary", "replica"}
+	if len(reads) != len(want) {
+		t.Fatalf("reads = %v, want %v", reads, want)
+	}
+	for i := range want {
+		if reads[i] != want[i] {
+			t.Errorf("reads = %v, want %v", reads, want)
+			break
+		}
+	}
+	if ttl := store.keys["ryw:alice"]; ttl != 5*time.Second {
+		t.Errorf("marker TTL = %v, want 5s", ttl)
+	}
+}
+
+func TestOpenReplicas(t *testing.T) {
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "primary.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	config := Config{Name: "replicated", Driver: SQLite, Replicas: []string{filepath.Join(dir, "replica.db")}, MaxOpenConns: 4}
+
+	pools, err := openReplicas(context.Background(), db, config)
+	if err != nil {
+		t.Fatalf("openReplicas: %v", err)
+	}
+	defer func() {
+		for _, p := range pools {
+			_ = p.Close()
+		}
+	}()
+	if len(pools) != 1 || pools[0].Stats().MaxOpenConnections != 4 {
+		t.Fatalf("pools = %d, want 1 replica with the pool settings", len(pools))
+	}
+	if db.Callback().Query().Get("goframe:replicas:query") == nil {
+		t.Error("replica routing not registered")
+	}
+}
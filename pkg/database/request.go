@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/reqctx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type queryTagsKey struct{}
+
+// forRequestKey marks the contexts of ForRequest sessions and carries the
+// statement timeout of their connection
+type forRequestKey struct{}
+
+// WithQueryTags returns a context whose queries carry the given key/value
+// pairs in their SQL comment, next to the route and request ID
+func WithQueryTags(ctx context.Context, kv ...string) context.Context {
+	tags := make(map[string]string)
+	for k, v := range queryTagsFrom(ctx) {
+		tags[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		tags[kv[i]] = kv[i+1]
+	}
+	return context.WithValue(ctx, queryTagsKey{}, tags)
+}
+
+func queryTagsFrom(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(queryTagsKey{}).(map[string]string)
+	return tags
+}
+
+// QueryTags returns the tags of queries run with ctx in a ForRequest
+// session: the route template matched by the router, the request ID and
+// the tags added with WithQueryTags
+func QueryTags(ctx context.Context) map[string]string {
+	tags := make(map[string]string)
+	if tmpl := reqctx.Route(ctx); tmpl != "" {
+		tags["route"] = tmpl
+	}
+	if id := reqctx.GetRequestID(ctx); id != "" {
+		tags["request_id"] = id
+	}
+	for k, v := range queryTagsFrom(ctx) {
+		tags[k] = v
+	}
+	return tags
+}
+
+// ForRequest returns a session bound to the request context. Queries are
+// cancelled with the request, carry the route and request ID as an SQL
+// comment for attribution in slow query logs and pg_stat_activity, and are
+// bounded on the server by a statement timeout: the time left until the
+// context deadline, capped at Config.StatementTimeout. Other sessions run
+// their SQL as written.
+//
+// PostgreSQL and Config.PrepareStmt connections leave the request ID out of
+// the comment, since a unique statement per request defeats the statement
+// cache of pgx and GORM.
+//
+// The timeout is enforced with the MAX_EXECUTION_TIME hint on MySQL SELECTs
+// and with SET LOCAL statement_timeout inside PostgreSQL transactions; other
+// statements rely on the context cancelling the query.
+func (c *Connection) ForRequest(ctx context.Context) *gorm.DB {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.db.WithContext(context.WithValue(ctx, forRequestKey{}, c.config.StatementTimeout))
+}
+
+// RequestTransaction runs fn in a transaction on a ForRequest session
+func (c *Connection) RequestTransaction(ctx context.Context, fn func(*gorm.DB) error) error {
+	return c.ForRequest(ctx).Transaction(fn)
+}
+
+// statementTimeout returns the timeout of statements run with a ForRequest
+// context, or 0 for none
+func statementTimeout(ctx context.Context) time.Duration {
+	limit, ok := ctx.Value(forRequestKey{}).(time.Duration)
+	if !ok {
+		return 0
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		if left < time.Millisecond {
+			left = time.Millisecond // Already expired; the context fails the query
+		}
+		if limit <= 0 || left < limit {
+			limit = left
+		}
+	}
+	return limit
+}
+
+// tagComment renders tags as a sqlcommenter comment. Keys and values are
+// URL-encoded, so they cannot close the comment.
+func tagComment(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = commentEscape(k) + "='" + commentEscape(tags[k]) + "'"
+	}
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+func commentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// requestPlugin tags and bounds the statements of ForRequest sessions.
+// connect installs it on every connection.
+type requestPlugin struct {
+	driver Driver
+	// Request IDs make every statement unique, which defeats the cache of
+	// prepared statements
+	skipRequestID bool
+}
+
+// forRequest reports whether ctx is the context of a ForRequest session
+func forRequest(ctx context.Context) bool {
+	_, ok := ctx.Value(forRequestKey{}).(time.Duration)
+	return ok
+}
+
+func (p *requestPlugin) Name() string { return "goframe:request" }
+
+func (p *requestPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, r := range []struct {
+		before string
+		clause string
+		reg    func(name string, fn func(*gorm.DB)) error
+	}{
+		{"gorm:query", "SELECT", cb.Query().Before("gorm:query").Register},
+		{"gorm:create", "INSERT", cb.Create().Before("gorm:create").Register},
+		{"gorm:update", "UPDATE", cb.Update().Before("gorm:update").Register},
+		{"gorm:delete", "DELETE", cb.Delete().Before("gorm:delete").Register},
+		{"gorm:row", "SELECT", cb.Row().Before("gorm:row").Register},
+		{"gorm:raw", "", cb.Raw().Before("gorm:raw").Register},
+	} {
+		if err := r.reg("goframe:request:"+strings.TrimPrefix(r.before, "gorm:"), p.annotate(r.clause)); err != nil {
+			return err
+		}
+	}
+
+	// Dialects with their own builder for a clause, like SQLite for INSERT,
+	// skip its BeforeExpression
+	for _, name := range []string{"SELECT", "INSERT", "UPDATE", "DELETE"} {
+		if build, ok := db.ClauseBuilders[name]; ok {
+			db.ClauseBuilders[name] = func(c clause.Clause, builder clause.Builder) {
+				if c.BeforeExpression != nil {
+					c.BeforeExpression.Build(builder)
+					_ = builder.WriteByte(' ')
+					c.BeforeExpression = nil
+				}
+				build(c, builder)
+			}
+		}
+	}
+	return nil
+}
+
+// annotate returns the callback run before the statement is built and
+// executed. name is the clause leading the statement, empty for raw SQL.
+func (p *requestPlugin) annotate(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		if db.Error != nil || stmt.Context == nil || !forRequest(stmt.Context) {
+			return
+		}
+
+		tags := QueryTags(stmt.Context)
+		if p.skipRequestID {
+			delete(tags, "request_id")
+		}
+		comment := tagComment(tags)
+		timeout := statementTimeout(stmt.Context)
+
+		if stmt.SQL.Len() > 0 || name == "" {
+			// Raw SQL, or a statement already built by the caller
+			if comment != "" && stmt.SQL.Len() > 0 {
+				sql := stmt.SQL.String()
+				stmt.SQL.Reset()
+				stmt.SQL.WriteString(comment + " " + sql)
+			}
+		} else {
+			// The clause is kept when gorm builds the statement, so the
+			// expressions end up around its keyword
+			c, ok := stmt.Clauses[name]
+			if !ok {
+				c = clause.Clause{Name: name}
+			}
+			changed := false
+			if comment != "" && c.BeforeExpression == nil {
+				c.BeforeExpression = clause.Expr{SQL: comment}
+				changed = true
+			}
+			if p.driver == MySQL && name == "SELECT" && timeout > 0 && c.AfterNameExpression == nil {
+				c.AfterNameExpression = clause.Expr{SQL: fmt.Sprintf("/*+ MAX_EXECUTION_TIME(%d) */", timeout.Milliseconds())}
+				changed = true
+			}
+			if changed {
+				stmt.Clauses[name] = c
+			}
+		}
+
+		if p.driver == PostgreSQL && timeout > 0 && !db.DryRun {
+			// SET LOCAL only lasts until the end of the transaction; outside
+			// one it would leak to the next user of the pooled connection
+			if _, inTx := stmt.ConnPool.(gorm.TxCommitter); inTx {
+				_, err := stmt.ConnPool.ExecContext(stmt.Context, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds()))
+				if err != nil {
+					_ = db.AddError(fmt.Errorf("failed to set statement timeout: %w", err))
+				}
+			}
+		}
+	}
+}
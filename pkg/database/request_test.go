@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/reqctx"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlCapture is a GORM plugin recording the SQL of executed statements
+type sqlCapture struct {
+	name string
+	mu   sync.Mutex
+	sql  []string
+}
+
+func (c *sqlCapture) Name() string { return c.name }
+
+func (c *sqlCapture) Initialize(db *gorm.DB) error {
+	record := func(db *gorm.DB) {
+		c.mu.Lock()
+		c.sql = append(c.sql, db.Statement.SQL.String())
+		c.mu.Unlock()
+	}
+	cb := db.Callback()
+	if err := cb.Query().After("gorm:query").Register(c.name+":query", record); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register(c.name+":create", record); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register(c.name+":row", record); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register(c.name+":raw", record)
+}
+
+func (c *sqlCapture) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := c.sql
+	c.sql = nil
+	return out
+}
+
+type taggedItem struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func TestForRequest_TagsStatements(t *testing.T) {
+	conn := mustConn(t)
+	capture := &sqlCapture{name: "test:request-sql"}
+	if err := Use(testConnName, capture); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	if err := conn.AutoMigrate(&taggedItem{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	capture.take()
+
+	var got, plain []string
+	router := mux.NewRouter()
+	router.HandleFunc("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithQueryTags(r.Context(), "job", "it's */ done")
+		db := conn.ForRequest(ctx)
+		if err := db.Create(&taggedItem{Name: "a"}).Error; err != nil {
+			t.Errorf("Create: %v", err)
+		}
+		var items []taggedItem
+		if err := db.Find(&items).Error; err != nil {
+			t.Errorf("Find: %v", err)
+		}
+		if err := db.Exec("DELETE FROM tagged_items").Error; err != nil {
+			t.Errorf("Exec: %v", err)
+		}
+		got = capture.take()
+
+		// Sessions not made with ForRequest run their SQL as written
+		if err := conn.DB().WithContext(ctx).Find(&items).Error; err != nil {
+			t.Errorf("Find: %v", err)
+		}
+		plain = capture.take()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+	req = req.WithContext(reqctx.WithRequestID(req.Context(), "req-1"))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(got) != 3 {
+		t.Fatalf("captured %d statements, want 3: %q", len(got), got)
+	}
+	want := "/*job='it%27s%20%2A%2F%20done',request_id='req-1',route='%2Fitems%2F%7Bid%7D'*/ "
+	for _, sql := range got {
+		if !strings.HasPrefix(sql, want) {
+			t.Errorf("statement %q does not start with %q", sql, want)
+		}
+	}
+	if len(plain) != 1 || strings.Contains(plain[0], "/*") {
+		t.Errorf("plain session statements = %q, want one without a comment", plain)
+	}
+}
+
+func TestTagComment(t *testing.T) {
+	if got := tagComment(nil); got != "" {
+		t.Errorf("tagComment(nil) = %q, want empty", got)
+	}
+	got := tagComment(map[string]string{"b": "x y", "a": "*/;--"})
+	if want := "/*a='%2A%2F%3B--',b='x%20y'*/"; got != want {
+		t.Errorf("tagComment = %q, want %q", got, want)
+	}
+}
+
+func TestStatementTimeout(t *testing.T) {
+	scoped := func(ctx context.Context, limit time.Duration) context.Context {
+		return context.WithValue(ctx, forRequestKey{}, limit)
+	}
+	short, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		min, max time.Duration
+	}{
+		{"not request scoped", short, 0, 0},
+		{"no deadline or limit", scoped(context.Background(), 0), 0, 0},
+		{"limit only", scoped(context.Background(), 5*time.Second), 5 * time.Second, 5 * time.Second},
+		{"deadline only", scoped(short, 0), 900 * time.Millisecond, time.Second},
+		{"deadline below limit", scoped(short, 5*time.Second), 900 * time.Millisecond, time.Second},
+		{"limit below deadline", scoped(short, 100*time.Millisecond), 100 * time.Millisecond, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statementTimeout(tt.ctx); got < tt.min || got > tt.max {
+				t.Errorf("statementTimeout = %v, want within [%v, %v]", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRequestPlugin_MySQLExecutionTime(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/app",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	if err := db.Use(&requestPlugin{driver: MySQL, skipRequestID: true}); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), forRequestKey{}, 1500*time.Millisecond)
+	ctx = reqctx.WithRequestID(WithQueryTags(ctx, "job", "sync"), "req-1")
+
+	var items []taggedItem
+	sql := db.WithContext(ctx).Find(&items).Statement.SQL.String()
+	want := "/*job='sync'*/ SELECT /*+ MAX_EXECUTION_TIME(1500) */ * FROM `tagged_items`"
+	if sql != want {
+		t.Errorf("SQL = %q, want %q", sql, want)
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/reqctx"
+	"gorm.io/gorm"
+)
+
+const spanStartKey = "goframe:span_start"
+
+// spanPlugin records the statements of requests served by
+// middleware.SlowRequests as db spans. connect installs it on every
+// connection.
+type spanPlugin struct{}
+
+func (spanPlugin) Name() string { return "goframe:spans" }
+
+func (p spanPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, r := range []struct {
+		name   string
+		before func(name string, fn func(*gorm.DB)) error
+		after  func(name string, fn func(*gorm.DB)) error
+	}{
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	} {
+		if err := r.before("goframe:spans:start_"+r.name, p.start); err != nil {
+			return err
+		}
+		if err := r.after("goframe:spans:end_"+r.name, p.end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (spanPlugin) start(db *gorm.DB) {
+	if ctx := db.Statement.Context; ctx != nil && reqctx.RecordingSpans(ctx) {
+		db.InstanceSet(spanStartKey, time.Now())
+	}
+}
+
+func (spanPlugin) end(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanStartKey)
+	if !ok {
+		return
+	}
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	reqctx.RecordSpan(db.Statement.Context, reqctx.SpanDB, strings.TrimSpace(db.Statement.SQL.String()), v.(time.Time), err)
+}
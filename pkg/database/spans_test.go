@@ -0,0 +1,58 @@
+package database
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSpanPlugin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "spans.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(spanPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&replicatedItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	hook := logrustest.NewGlobal()
+	defer hook.Reset()
+
+	h := middleware.SlowRequests(middleware.SlowRequestConfig{Threshold: time.Nanosecond})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tx := db.WithContext(r.Context())
+		tx.Create(&replicatedItem{Name: "a"})
+		var item replicatedItem
+		tx.First(&item, "name = ?", "missing")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Queries outside a slow request are not recorded
+	db.Create(&replicatedItem{Name: "b"})
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("expected slow request log")
+	}
+	spans := entry.Data["spans"].([]middleware.Span)
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %+v", spans)
+	}
+	if spans[0].Kind != middleware.SpanDB || !strings.HasPrefix(spans[0].Name, "INSERT INTO `replicated_items`") {
+		t.Errorf("unexpected insert span %+v", spans[0])
+	}
+	if !strings.HasPrefix(spans[1].Name, "SELECT") || spans[1].Error != "" {
+		t.Errorf("expected not found select without error, got %+v", spans[1])
+	}
+}
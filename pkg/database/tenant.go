@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrTenantPoolLimit is returned when a tenant database would exceed
+// TenantConfig.MaxPools and every open tenant pool is busy
+var ErrTenantPoolLimit = errors.New("tenant database pool limit reached")
+
+// TenantConfig holds per-tenant connection routing configuration
+type TenantConfig struct {
+	// Shared is the connection of tenants without a database of their own
+	Shared string
+
+	// Dedicated maps tenants to registered connections, e.g. a dedicated
+	// database for a large customer
+	Dedicated map[string]string
+
+	// Connect returns the configuration of a tenant database opened on
+	// first use. Tenants it returns false for use Shared.
+	Connect func(tenant string) (Config, bool)
+
+	MaxPools      int           // Most tenant databases opened by Connect at once (default 50)
+	IdleTimeout   time.Duration // Tenant databases unused this long are closed (default 10 minutes)
+	SweepInterval time.Duration // How often idle tenant databases are closed (default IdleTimeout / 2)
+
+	// Tenant returns the tenant of a request context, for For (default
+	// tenant.FromContext, the tenant resolved by tenant.Middleware)
+	Tenant func(ctx context.Context) string
+}
+
+// TenantRouter resolves the database connection of each tenant
+type TenantRouter struct {
+	config TenantConfig
+
+	mu    sync.Mutex
+	pools map[string]*tenantPool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type tenantPool struct {
+	ready    chan struct{} // Closed once conn or err is set
+	conn     *Connection
+	err      error
+	lastUsed time.Time
+}
+
+// NewTenantRouter returns a router for config. Close it on shutdown to
+// close the tenant databases it opened.
+func NewTenantRouter(config TenantConfig) *TenantRouter {
+	if config.MaxPools <= 0 {
+		config.MaxPools = 50
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = 10 * time.Minute
+	}
+	if config.SweepInterval <= 0 {
+		config.SweepInterval = config.IdleTimeout / 2
+	}
+	if config.Tenant == nil {
+		config.Tenant = tenant.FromContext
+	}
+
+	r := &TenantRouter{
+		config: config,
+		pools:  make(map[string]*tenantPool),
+		stop:   make(chan struct{}),
+	}
+	if config.Connect != nil {
+		r.wg.Add(1)
+		go r.sweepLoop()
+	}
+	return r
+}
+
+// For returns the connection of the tenant of ctx, resolved with
+// TenantConfig.Tenant
+func (r *TenantRouter) For(ctx context.Context) (*Connection, error) {
+	return r.Get(ctx, r.config.Tenant(ctx))
+}
+
+// Get returns the connection of tenant: its dedicated connection, its own
+// database opened with Connect, or the shared connection
+func (r *TenantRouter) Get(ctx context.Context, tenant string) (*Connection, error) {
+	if tenant == "" {
+		return r.shared()
+	}
+	if name, ok := r.config.Dedicated[tenant]; ok {
+		return Get(name)
+	}
+	if r.config.Connect == nil {
+		return r.shared()
+	}
+
+	if pool := r.pool(tenant); pool != nil {
+		return pool.wait(ctx)
+	}
+	config, ok := r.config.Connect(tenant)
+	if !ok {
+		return r.shared()
+	}
+
+	r.mu.Lock()
+	if pool, ok := r.pools[tenant]; ok {
+		pool.lastUsed = time.Now()
+		r.mu.Unlock()
+		return pool.wait(ctx)
+	}
+	if len(r.pools) >= r.config.MaxPools && !r.evictLocked(0, 1) {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("database of tenant '%s': %w", tenant, ErrTenantPoolLimit)
+	}
+	pool := &tenantPool{ready: make(chan struct{}), lastUsed: time.Now()}
+	r.pools[tenant] = pool
+	r.mu.Unlock()
+
+	if config.Name == "" {
+		config.Name = "tenant:" + tenant
+	}
+	// Opened without the request context, so a cancelled request does not
+	// fail the tenants waiting on the same pool
+	pool.conn, pool.err = open(context.WithoutCancel(ctx), withDefaults(config))
+	if pool.err != nil {
+		r.mu.Lock()
+		if r.pools[tenant] == pool {
+			delete(r.pools, tenant)
+		}
+		r.mu.Unlock()
+	} else {
+		logrus.Infof("Opened %s database of tenant %s", config.Driver, tenant)
+	}
+	close(pool.ready)
+
+	return pool.conn, pool.err
+}
+
+// Pools returns the number of open tenant databases
+func (r *TenantRouter) Pools() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pools)
+}
+
+// Evict closes the tenant databases unused for idle, except those running
+// queries, and returns how many were closed
+func (r *TenantRouter) Evict(idle time.Duration) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before := len(r.pools)
+	r.evictLocked(idle, before)
+	return before - len(r.pools)
+}
+
+// Close stops eviction and closes the tenant databases. Dedicated and
+// shared connections are closed by database.Close.
+func (r *TenantRouter) Close() error {
+	select {
+	case <-r.stop:
+		return nil
+	default:
+		close(r.stop)
+	}
+	r.wg.Wait()
+
+	r.mu.Lock()
+	pools := r.pools
+	r.pools = make(map[string]*tenantPool)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, pool := range pools {
+		<-pool.ready
+		if pool.conn != nil {
+			if err := pool.conn.close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pool returns the open or opening database of tenant, if any
+func (r *TenantRouter) pool(tenant string) *tenantPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pool, ok := r.pools[tenant]
+	if !ok {
+		return nil
+	}
+	pool.lastUsed = time.Now()
+	return pool
+}
+
+func (r *TenantRouter) shared() (*Connection, error) {
+	if r.config.Shared == "" {
+		return nil, fmt.Errorf("no shared database connection configured")
+	}
+	return Get(r.config.Shared)
+}
+
+// evictLocked closes up to n idle tenant databases, least recently used
+// first, and reports whether any was closed
+func (r *TenantRouter) evictLocked(idle time.Duration, n int) bool {
+	cutoff := time.Now().Add(-idle)
+	tenants := make([]string, 0, len(r.pools))
+	for tenant, pool := range r.pools {
+		if pool.opened() && !pool.lastUsed.After(cutoff) && pool.conn.Stats().InUse == 0 {
+			tenants = append(tenants, tenant)
+		}
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return r.pools[tenants[i]].lastUsed.Before(r.pools[tenants[j]].lastUsed)
+	})
+
+	if len(tenants) > n {
+		tenants = tenants[:n]
+	}
+	for _, tenant := range tenants {
+		if err := r.pools[tenant].conn.close(); err != nil {
+			logrus.Warnf("failed to close database of tenant %s: %v", tenant, err)
+		}
+		delete(r.pools, tenant)
+	}
+	return len(tenants) > 0
+}
+
+func (r *TenantRouter) sweepLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.Evict(r.config.IdleTimeout)
+		}
+	}
+}
+
+func (p *tenantPool) wait(ctx context.Context) (*Connection, error) {
+	select {
+	case <-p.ready:
+		return p.conn, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// opened reports whether the database is open, as opposed to still opening
+func (p *tenantPool) opened() bool {
+	select {
+	case <-p.ready:
+		return p.conn != nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/tenant"
+	"gorm.io/gorm/logger"
+)
+
+func newTestTenantRouter(t *testing.T, maxPools int) *TenantRouter {
+	t.Helper()
+	dir := t.TempDir()
+	router := NewTenantRouter(TenantConfig{
+		Shared:    testConnName,
+		Dedicated: map[string]string{"big": testDSNName},
+		Connect: func(tenant string) (Config, bool) {
+			if tenant == "small" {
+				return Config{}, false
+			}
+			return Config{Driver: SQLite, Database: filepath.Join(dir, tenant+".db"), LogLevel: logger.Silent}, true
+		},
+		MaxPools: maxPools,
+		Tenant: func(ctx context.Context) string {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			return tenant
+		},
+	})
+	t.Cleanup(func() {
+		if err := router.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+	return router
+}
+
+type tenantKey struct{}
+
+func TestTenantRouter_Get(t *testing.T) {
+	router := newTestTenantRouter(t, 0)
+	ctx := context.Background()
+
+	tests := []struct {
+		tenant string
+		want   string
+	}{
+		{"", testConnName},
+		{"big", testDSNName},
+		{"small", testConnName},
+		{"acme", "tenant:acme"},
+	}
+	for _, tt := range tests {
+		conn, err := router.Get(ctx, tt.tenant)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", tt.tenant, err)
+		}
+		if conn.config.Name != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.tenant, conn.config.Name, tt.want)
+		}
+	}
+
+	first, _ := router.Get(ctx, "acme")
+	again, err := router.For(context.WithValue(ctx, tenantKey{}, "acme"))
+	if err != nil {
+		t.Fatalf("For() error = %v", err)
+	}
+	if first != again {
+		t.Error("tenant database opened twice")
+	}
+	if err := again.Health(ctx); err != nil {
+		t.Errorf("Health() error = %v", err)
+	}
+	if n := router.Pools(); n != 1 {
+		t.Errorf("Pools() = %d, want 1", n)
+	}
+
+	// Without TenantConfig.Tenant, the tenant of tenant.Middleware is used
+	defaults := NewTenantRouter(TenantConfig{Shared: testConnName, Dedicated: map[string]string{"big": testDSNName}})
+	defer defaults.Close()
+	if conn, err := defaults.For(tenant.WithID(ctx, "big")); err != nil || conn.config.Name != testDSNName {
+		t.Errorf("For() = %v, %v, want the dedicated connection", conn, err)
+	}
+}
+
+func TestTenantRouter_Limit(t *testing.T) {
+	router := newTestTenantRouter(t, 2)
+	ctx := context.Background()
+
+	a, err := router.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("Get(a) error = %v", err)
+	}
+	tx := a.Begin(ctx)
+	defer tx.Rollback()
+	if _, err := router.Get(ctx, "b"); err != nil {
+		t.Fatalf("Get(b) error = %v", err)
+	}
+
+	// b is idle and evicted for c; a is in a transaction and kept
+	if _, err := router.Get(ctx, "c"); err != nil {
+		t.Fatalf("Get(c) error = %v", err)
+	}
+	router.mu.Lock()
+	_, hasA := router.pools["a"]
+	_, hasB := router.pools["b"]
+	router.mu.Unlock()
+	if !hasA || hasB {
+		t.Errorf("open pools a=%v b=%v, want a kept and b evicted", hasA, hasB)
+	}
+
+	conn, err := router.Get(ctx, "c")
+	if err != nil {
+		t.Fatalf("Get(c) error = %v", err)
+	}
+	txC := conn.Begin(ctx)
+	defer txC.Rollback()
+	if _, err := router.Get(ctx, "d"); !errors.Is(err, ErrTenantPoolLimit) {
+		t.Errorf("Get(d) error = %v, want ErrTenantPoolLimit", err)
+	}
+}
+
+func TestTenantRouter_Evict(t *testing.T) {
+	router := newTestTenantRouter(t, 0)
+	ctx := context.Background()
+
+	conn, err := router.Get(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if n := router.Evict(time.Hour); n != 0 {
+		t.Errorf("Evict(1h) = %d, want 0 for a pool just used", n)
+	}
+	if n := router.Evict(0); n != 1 {
+		t.Errorf("Evict(0) = %d, want 1", n)
+	}
+	if err := conn.Health(ctx); err == nil {
+		t.Error("evicted database still open")
+	}
+
+	reopened, err := router.Get(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Get() after eviction error = %v", err)
+	}
+	if reopened == conn {
+		t.Error("Get() returned the evicted connection")
+	}
+}
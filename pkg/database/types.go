@@ -418,3 +418,54 @@ func (ns *NullString) UnmarshalJSON(b []byte) error {
 	ns.Valid = true
 	return nil
 }
+
+// UTCTime is a timestamp that is always stored and scanned as UTC, whatever
+// the session timezone of the database connection. Convert it with In (or
+// app.Context.LocalTime) to present it in the user's timezone.
+type UTCTime struct {
+	time.Time
+}
+
+// NewUTCTime returns t as a UTCTime
+func NewUTCTime(t time.Time) UTCTime {
+	return UTCTime{Time: t.UTC()}
+}
+
+// Scan implements the Scanner interface.
+func (ut *UTCTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		ut.Time = time.Time{}
+	case time.Time:
+		ut.Time = v.UTC()
+	case string:
+		return ut.parse(v)
+	case []byte:
+		return ut.parse(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into UTCTime", value)
+	}
+	return nil
+}
+
+// Drivers without parseTime return timestamps as text in the session timezone,
+// which is expected to be UTC
+func (ut *UTCTime) parse(s string) error {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			ut.Time = t.UTC()
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as UTCTime", s)
+}
+
+// Value implements the driver Valuer interface.
+func (ut UTCTime) Value() (driver.Value, error) {
+	return ut.Time.UTC(), nil
+}
+
+// In returns the time in loc
+func (ut UTCTime) In(loc *time.Location) time.Time {
+	return ut.Time.In(loc)
+}
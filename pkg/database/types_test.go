@@ -680,3 +680,68 @@ func TestStringMapJSONArray(t *testing.T) {
 		}
 	})
 }
+
+func TestUTCTime(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*3600)
+	ref := time.Date(2024, 5, 1, 19, 30, 0, 0, tokyo)
+
+	t.Run("Value normalizes to UTC", func(t *testing.T) {
+		v, err := UTCTime{Time: ref}.Value()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, ok := v.(time.Time)
+		if !ok || got.Location() != time.UTC || !got.Equal(ref) {
+			t.Errorf("expected %v in UTC, got %v", ref, v)
+		}
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			value   interface{}
+			want    time.Time
+			wantErr bool
+		}{
+			{"time", ref, ref, false},
+			{"RFC3339 string", "2024-05-01T10:30:00Z", ref, false},
+			{"datetime bytes", []byte("2024-05-01 10:30:00"), ref, false},
+			{"nil", nil, time.Time{}, false},
+			{"garbage", "yesterday", time.Time{}, true},
+			{"unsupported type", 42, time.Time{}, true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var ut UTCTime
+				err := ut.Scan(tt.value)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !ut.Time.Equal(tt.want) {
+					t.Errorf("expected %v, got %v", tt.want, ut.Time)
+				}
+				if !ut.Time.IsZero() && ut.Time.Location() != time.UTC {
+					t.Errorf("expected UTC location, got %v", ut.Time.Location())
+				}
+			})
+		}
+	})
+
+	t.Run("In", func(t *testing.T) {
+		ut := NewUTCTime(ref)
+		if got := ut.In(tokyo); got.Hour() != 19 {
+			t.Errorf("expected 19h in JST, got %v", got)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		b, err := json.Marshal(NewUTCTime(ref))
+		if err != nil {
+			t.Fatalf("marshal error: %v", err)
+		}
+		if string(b) != `"2024-05-01T10:30:00Z"` {
+			t.Errorf("unexpected JSON: %s", b)
+		}
+	})
+}
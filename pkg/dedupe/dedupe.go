@@ -0,0 +1,221 @@
+// Package dedupe makes message consumers idempotent. A Deduper remembers
+// the IDs of the messages it processed for a window, so redeliveries of
+// AMQP, MQTT, Redis or Kafka messages after a retry or a lost ack do not
+// apply their effects twice:
+//
+//	d := dedupe.New(dedupe.Config{Name: "orders", Store: redis.DedupeStore("dedupe:orders:")})
+//
+//	conn.Consume(ctx, "orders", d.Handler(handleOrder))        // rabbit
+//	client.Subscribe(ctx, "sensors/#", d.TopicHandler(handle)) // mqtt, cache
+//	err := d.Do(ctx, msg.Key, func(ctx context.Context) error { ... })
+package dedupe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// States of a message ID in a Store
+const (
+	StateNew        = ""           // Not seen within the window
+	StateProcessing = "processing" // Claimed by a consumer still processing it
+	StateDone       = "done"       // Processed
+)
+
+// ErrInProgress is returned for a message another delivery is still
+// processing; redeliver it later, e.g. by nacking it with requeue
+var ErrInProgress = errors.New("message is being processed by another consumer")
+
+var messagesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "dedupe_messages_total",
+		Help: "Messages seen by idempotent consumers, by consumer and outcome",
+	},
+	[]string{"consumer", "outcome"},
+)
+
+// Store remembers message IDs, e.g. a cache.DedupeStore shared by all
+// instances through Redis, or a TableStore
+type Store interface {
+	// Claim marks id as processing for ttl unless the store knows it, and
+	// returns its state before
+	Claim(ctx context.Context, id string, ttl time.Duration) (state string, err error)
+
+	// Complete marks id as processed for ttl
+	Complete(ctx context.Context, id string, ttl time.Duration) error
+
+	// Release forgets id, so a redelivery processes it again
+	Release(ctx context.Context, id string) error
+}
+
+// Config holds idempotent consumer configuration
+type Config struct {
+	Name  string // Consumer name in metrics (default "default")
+	Store Store  // Default an in-process MemoryStore
+
+	// Window is how long processed IDs are remembered (default 24h); keep it
+	// above the longest redelivery delay of the broker
+	Window time.Duration
+
+	// ProcessingTimeout is how long a claim lasts, so a message whose
+	// consumer crashed is processed again (default 5m)
+	ProcessingTimeout time.Duration
+
+	// ID returns the ID of a message for Handler and TopicHandler (default
+	// the SHA-256 of the payload, so identical payloads are one message)
+	ID func(payload []byte) string
+}
+
+// Deduper runs each message once
+type Deduper struct {
+	config Config
+}
+
+// New returns a Deduper
+func New(config Config) *Deduper {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Window <= 0 {
+		config.Window = 24 * time.Hour
+	}
+	if config.ProcessingTimeout <= 0 {
+		config.ProcessingTimeout = 5 * time.Minute
+	}
+	if config.ID == nil {
+		config.ID = HashID
+	}
+	return &Deduper{config: config}
+}
+
+// Do runs fn unless the message id was processed within the window. It
+// returns nil for duplicates, ErrInProgress while another delivery
+// processes id, and the error of fn, after which a redelivery runs fn
+// again.
+func (d *Deduper) Do(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	state, err := d.config.Store.Claim(ctx, id, d.config.ProcessingTimeout)
+	if err != nil {
+		messagesTotal.WithLabelValues(d.config.Name, "error").Inc()
+		return err
+	}
+	switch state {
+	case StateDone:
+		messagesTotal.WithLabelValues(d.config.Name, "duplicate").Inc()
+		return nil
+	case StateProcessing:
+		messagesTotal.WithLabelValues(d.config.Name, "in_progress").Inc()
+		return ErrInProgress
+	}
+
+	// The store is updated even when ctx ends while fn runs
+	storeCtx := context.WithoutCancel(ctx)
+	if err := fn(ctx); err != nil {
+		messagesTotal.WithLabelValues(d.config.Name, "failed").Inc()
+		return errors.Join(err, d.config.Store.Release(storeCtx, id))
+	}
+	messagesTotal.WithLabelValues(d.config.Name, "processed").Inc()
+	return d.config.Store.Complete(storeCtx, id, d.config.Window)
+}
+
+// Handler wraps a handler of message bodies, such as those of
+// rabbit.Connection.Consume, which nacks failed and in progress messages
+// for redelivery
+func (d *Deduper) Handler(handler func(body []byte) error) func(body []byte) error {
+	return func(body []byte) error {
+		return d.Do(context.Background(), d.config.ID(body), func(context.Context) error {
+			return handler(body)
+		})
+	}
+}
+
+// TopicHandler wraps a handler of topic messages, such as those of
+// mqtt.Client.Subscribe and cache.Manager.Subscribe. Message IDs are
+// scoped by topic.
+func (d *Deduper) TopicHandler(handler func(topic string, payload []byte) error) func(topic string, payload []byte) error {
+	return func(topic string, payload []byte) error {
+		return d.Do(context.Background(), topic+":"+d.config.ID(payload), func(context.Context) error {
+			return handler(topic, payload)
+		})
+	}
+}
+
+// HashID returns the SHA-256 of payload in hex
+func HashID(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// JSONID returns the message ID from a top-level field of JSON payloads,
+// e.g. "event_id", falling back to HashID for payloads without it
+func JSONID(field string) func(payload []byte) string {
+	return func(payload []byte) string {
+		if id := jsoniter.Get(payload, field).ToString(); id != "" {
+			return id
+		}
+		return HashID(payload)
+	}
+}
+
+// MemoryStore is a Store for a single instance
+type MemoryStore struct {
+	mu  sync.Mutex
+	ids map[string]memoryEntry
+	now func() time.Time
+}
+
+type memoryEntry struct {
+	state   string
+	expires time.Time
+}
+
+// NewMemoryStore returns an in-process Store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{ids: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Claim implements Store
+func (s *MemoryStore) Claim(_ context.Context, id string, ttl time.Duration) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if e, ok := s.ids[id]; ok && now.Before(e.expires) {
+		return e.state, nil
+	}
+	// Drop expired IDs now and then, so the map does not grow forever
+	if len(s.ids) > 1024 && len(s.ids)%1024 == 0 {
+		for k, e := range s.ids {
+			if !now.Before(e.expires) {
+				delete(s.ids, k)
+			}
+		}
+	}
+	s.ids[id] = memoryEntry{state: StateProcessing, expires: now.Add(ttl)}
+	return StateNew, nil
+}
+
+// Complete implements Store
+func (s *MemoryStore) Complete(_ context.Context, id string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids[id] = memoryEntry{state: StateDone, expires: s.now().Add(ttl)}
+	return nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ids, id)
+	return nil
+}
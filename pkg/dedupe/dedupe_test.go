@@ -0,0 +1,89 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDeduper(t *testing.T) {
+	ctx := context.Background()
+	d := New(Config{Name: "test"})
+	runs := 0
+	count := func(context.Context) error { runs++; return nil }
+
+	if err := d.Do(ctx, "m1", count); err != nil || runs != 1 {
+		t.Fatalf("first delivery: err %v, runs %d", err, runs)
+	}
+	if err := d.Do(ctx, "m1", count); err != nil || runs != 1 {
+		t.Errorf("duplicate: err %v, runs %d, want skipped", err, runs)
+	}
+
+	// A failed message is processed again when redelivered
+	boom := errors.New("boom")
+	if err := d.Do(ctx, "m2", func(context.Context) error { return boom }); !errors.Is(err, boom) {
+		t.Errorf("expected the handler error, got %v", err)
+	}
+	if err := d.Do(ctx, "m2", count); err != nil || runs != 2 {
+		t.Errorf("redelivery after failure: err %v, runs %d", err, runs)
+	}
+
+	// A redelivery while the first is processing is retried later
+	err := d.Do(ctx, "m3", func(ctx context.Context) error {
+		return d.Do(ctx, "m3", count)
+	})
+	if !errors.Is(err, ErrInProgress) {
+		t.Errorf("expected ErrInProgress, got %v", err)
+	}
+
+	for outcome, want := range map[string]float64{"processed": 2, "duplicate": 1, "failed": 2, "in_progress": 1} {
+		if got := testutil.ToFloat64(messagesTotal.WithLabelValues("test", outcome)); got != want {
+			t.Errorf("%s = %v, want %v", outcome, got, want)
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	d := New(Config{Name: "handlers", ID: JSONID("event_id")})
+	var got []string
+	handler := d.TopicHandler(func(topic string, payload []byte) error {
+		got = append(got, topic+" "+string(payload))
+		return nil
+	})
+	_ = handler("a", []byte(`{"event_id":"1","n":1}`))
+	_ = handler("a", []byte(`{"event_id":"1","n":2}`)) // Same ID, different payload
+	_ = handler("b", []byte(`{"event_id":"1"}`))       // Other topic
+	_ = handler("a", []byte(`not json`))
+	_ = handler("a", []byte(`not json`))
+	if len(got) != 3 {
+		t.Errorf("expected 3 messages processed, got %q", got)
+	}
+
+	runs := 0
+	body := d.Handler(func([]byte) error { runs++; return nil })
+	_ = body([]byte(`{"event_id":"2"}`))
+	_ = body([]byte(`{"event_id":"2"}`))
+	if runs != 1 {
+		t.Errorf("expected 1 run, got %d", runs)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	_, _ = s.Claim(ctx, "m", time.Minute)
+	_ = s.Complete(ctx, "m", time.Hour)
+	if state, _ := s.Claim(ctx, "m", time.Minute); state != StateDone {
+		t.Errorf("state = %q, want done", state)
+	}
+	now = now.Add(2 * time.Hour)
+	if state, _ := s.Claim(ctx, "m", time.Minute); state != StateNew {
+		t.Errorf("state after the window = %q, want new", state)
+	}
+}
@@ -0,0 +1,87 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Row is a message ID in a TableStore
+type Row struct {
+	ID        string    `gorm:"primaryKey;size:191"`
+	State     string    `gorm:"size:16;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+// TableStore keeps message IDs in a database table, by default
+// processed_messages. Expired rows are reused by Claim; Purge deletes them.
+type TableStore struct {
+	DB    *gorm.DB
+	Table string
+}
+
+func (s *TableStore) table(ctx context.Context) *gorm.DB {
+	table := s.Table
+	if table == "" {
+		table = "processed_messages"
+	}
+	return s.DB.WithContext(ctx).Table(table)
+}
+
+// Migrate creates or updates the table
+func (s *TableStore) Migrate(ctx context.Context) error {
+	return s.table(ctx).AutoMigrate(&Row{})
+}
+
+// Claim implements Store
+func (s *TableStore) Claim(ctx context.Context, id string, ttl time.Duration) (string, error) {
+	now := time.Now().UTC()
+	row := Row{ID: id, State: StateProcessing, ExpiresAt: now.Add(ttl)}
+	res := s.table(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 1 {
+		return StateNew, nil
+	}
+
+	// Known: take it over if expired, in one statement so only one
+	// consumer does
+	res = s.table(ctx).Where("id = ? AND expires_at <= ?", id, now).
+		Updates(map[string]interface{}{"state": StateProcessing, "expires_at": row.ExpiresAt})
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 1 {
+		return StateNew, nil
+	}
+
+	var existing Row
+	err := s.table(ctx).Where("id = ?", id).Take(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Released meanwhile; let the redelivery claim it
+		return StateProcessing, nil
+	}
+	return existing.State, err
+}
+
+// Complete implements Store
+func (s *TableStore) Complete(ctx context.Context, id string, ttl time.Duration) error {
+	return s.table(ctx).Where("id = ?", id).
+		Updates(map[string]interface{}{"state": StateDone, "expires_at": time.Now().UTC().Add(ttl)}).Error
+}
+
+// Release implements Store
+func (s *TableStore) Release(ctx context.Context, id string) error {
+	return s.table(ctx).Where("id = ?", id).Delete(&Row{}).Error
+}
+
+// Purge deletes expired message IDs and returns how many, e.g. from a
+// periodic job
+func (s *TableStore) Purge(ctx context.Context) (int64, error) {
+	res := s.table(ctx).Where("expires_at <= ?", time.Now().UTC()).Delete(&Row{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,66 @@
+package dedupe
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTableStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "dedupe.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &TableStore{DB: db}
+	ctx := context.Background()
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	claim := func(id string, ttl time.Duration) string {
+		t.Helper()
+		state, err := store.Claim(ctx, id, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return state
+	}
+
+	if s := claim("m1", time.Minute); s != StateNew {
+		t.Fatalf("first claim = %q, want new", s)
+	}
+	if s := claim("m1", time.Minute); s != StateProcessing {
+		t.Errorf("second claim = %q, want processing", s)
+	}
+	if err := store.Complete(ctx, "m1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if s := claim("m1", time.Minute); s != StateDone {
+		t.Errorf("claim after completion = %q, want done", s)
+	}
+
+	if err := store.Release(ctx, "m1"); err != nil {
+		t.Fatal(err)
+	}
+	if s := claim("m1", time.Minute); s != StateNew {
+		t.Errorf("claim after release = %q, want new", s)
+	}
+
+	// Expired claims of crashed consumers are taken over
+	if s := claim("m2", -time.Second); s != StateNew {
+		t.Fatalf("claim = %q, want new", s)
+	}
+	if s := claim("m2", time.Minute); s != StateNew {
+		t.Errorf("claim of an expired ID = %q, want new", s)
+	}
+
+	_, _ = store.Claim(ctx, "m3", -time.Second)
+	if n, err := store.Purge(ctx); err != nil || n != 1 {
+		t.Errorf("Purge = %d, %v, want 1", n, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -18,17 +19,24 @@ var (
 	clients             = make(map[string]*Client)
 	clientLock          = &sync.RWMutex{}
 	once                = &sync.Once{}
-	elasticConnExpected = make([]elasticConfig, 0)
+	elasticConnExpected = make([]Config, 0)
 
 	all  map[string][]Initializer
 	lock sync.RWMutex
 )
 
-type elasticConfig struct {
-	name     string
-	url      string
-	username string
-	password string
+// Config holds Elasticsearch connection configuration
+type Config struct {
+	Name     string
+	URL      string
+	Username string
+	Password string
+
+	// Timeout bounds each request, so a runaway query cannot hold a request
+	// forever (default 30s)
+	Timeout time.Duration
+
+	MaxIdleConns int // Idle connections kept for reuse (default 10)
 }
 
 // Initializer interface for post-connection initialization
@@ -36,14 +44,20 @@ type Initializer interface {
 	Initialize()
 }
 
+// RegisterConfig registers an Elasticsearch connection
+func RegisterConfig(cfg Config) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxIdleConns == 0 {
+		cfg.MaxIdleConns = 10
+	}
+	elasticConnExpected = append(elasticConnExpected, cfg)
+}
+
 // RegisterElasticSearch registers Elasticsearch connection
 func RegisterElasticSearch(name, url, username, password string) {
-	elasticConnExpected = append(elasticConnExpected, elasticConfig{
-		name:     name,
-		url:      url,
-		username: username,
-		password: password,
-	})
+	RegisterConfig(Config{Name: name, URL: url, Username: username, Password: password})
 }
 
 // Initialize initializes all Elasticsearch connections
@@ -53,13 +67,17 @@ func Initialize(ctx context.Context) error {
 		_ = safe.Try(func() error {
 			for _, cfg := range elasticConnExpected {
 				opts := []elastic.ClientOptionFunc{
-					elastic.SetURL(cfg.url),
+					elastic.SetURL(cfg.URL),
+					elastic.SetHttpClient(&http.Client{
+						Transport: newPoolTransport(cfg.Name, cfg.MaxIdleConns),
+						Timeout:   cfg.Timeout,
+					}),
 					elastic.SetSniff(false),
 					elastic.SetHealthcheck(false),
 				}
 
-				if cfg.username != "" && cfg.password != "" {
-					opts = append(opts, elastic.SetBasicAuth(cfg.username, cfg.password))
+				if cfg.Username != "" && cfg.Password != "" {
+					opts = append(opts, elastic.SetBasicAuth(cfg.Username, cfg.Password))
 				}
 
 				client, err := elastic.NewClient(opts...)
@@ -69,7 +87,7 @@ func Initialize(ctx context.Context) error {
 					return err
 				}
 
-				_, _, err = client.Ping(cfg.url).Do(ctx)
+				_, _, err = client.Ping(cfg.URL).Do(ctx)
 				if err != nil {
 					xlog.GetWithError(ctx, errors.New("ping to elasticsearch failed")).Error(err)
 					initErr = err
@@ -77,10 +95,10 @@ func Initialize(ctx context.Context) error {
 				}
 
 				clientLock.Lock()
-				clients[cfg.name] = NewClient(client)
+				clients[cfg.Name] = NewClient(client)
 				clientLock.Unlock()
 
-				logrus.Infof("successfully connected to elasticsearch: %s", cfg.url)
+				logrus.Infof("successfully connected to elasticsearch: %s", cfg.URL)
 			}
 			return nil
 		}, 30*time.Second)
@@ -0,0 +1,121 @@
+package elasticsearch
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	poolConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "elasticsearch_pool_connections",
+			Help: "Connections to Elasticsearch, by client and state (in_use, idle)",
+		},
+		[]string{"client", "state"},
+	)
+	poolWait = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "elasticsearch_pool_wait_seconds",
+			Help:    "Time requests waited for an Elasticsearch connection, idle or newly dialed",
+			Buckets: []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 5},
+		},
+		[]string{"client"},
+	)
+)
+
+// poolTransport is the HTTP transport of a client, keeping its connection
+// metrics. Connections are counted from dial to close; those serving a
+// request are in use, the others idle.
+type poolTransport struct {
+	base *http.Transport
+	name string
+
+	mu    sync.Mutex
+	open  int
+	inUse int
+}
+
+func newPoolTransport(name string, maxIdle int) *poolTransport {
+	t := &poolTransport{name: name}
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	t.base = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			t.update(1, 0)
+			return &poolConn{Conn: conn, transport: t}, nil
+		},
+		MaxIdleConns:        maxIdle,
+		MaxIdleConnsPerHost: maxIdle,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *poolTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	got := false
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			got = true
+			poolWait.WithLabelValues(t.name).Observe(time.Since(start).Seconds())
+			t.update(0, 1)
+		},
+	}
+	resp, err := t.base.RoundTrip(r.WithContext(httptrace.WithClientTrace(r.Context(), trace)))
+	switch {
+	case !got:
+	case err != nil:
+		t.update(0, -1)
+	default:
+		// The connection serves the request until the body is closed
+		resp.Body = &poolBody{ReadCloser: resp.Body, transport: t}
+	}
+	return resp, err
+}
+
+func (t *poolTransport) update(open, inUse int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.open += open
+	t.inUse += inUse
+	poolConnections.WithLabelValues(t.name, "in_use").Set(float64(t.inUse))
+	poolConnections.WithLabelValues(t.name, "idle").Set(float64(max(0, t.open-t.inUse)))
+}
+
+// poolConn counts the connection closed once
+type poolConn struct {
+	net.Conn
+	transport *poolTransport
+	once      sync.Once
+}
+
+func (c *poolConn) Close() error {
+	c.once.Do(func() { c.transport.update(-1, 0) })
+	return c.Conn.Close()
+}
+
+// poolBody releases the connection of a response once closed
+type poolBody struct {
+	io.ReadCloser
+	transport *poolTransport
+	once      sync.Once
+}
+
+func (b *poolBody) Close() error {
+	b.once.Do(func() { b.transport.update(0, -1) })
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,70 @@
+package elasticsearch
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPoolTransport(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	transport := newPoolTransport("pool-test", 10)
+	client := &http.Client{Transport: transport}
+	inUse := poolConnections.WithLabelValues("pool-test", "in_use")
+	idle := poolConnections.WithLabelValues("pool-test", "idle")
+
+	resp, err := client.Get(server.URL + "/fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(inUse); got != 1 {
+		t.Errorf("in use while the body is open = %v, want 1", got)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if in, id := testutil.ToFloat64(inUse), testutil.ToFloat64(idle); in != 0 || id != 1 {
+		t.Errorf("after the response: in use %v, idle %v, want 0 and 1", in, id)
+	}
+
+	// A second concurrent request dials a new connection
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if resp, err := client.Get(server.URL + "/slow"); err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+	resp, err = client.Get(server.URL + "/fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if in, id := testutil.ToFloat64(inUse), testutil.ToFloat64(idle); in != 1 || id != 1 {
+		t.Errorf("with a slow request: in use %v, idle %v, want 1 and 1", in, id)
+	}
+	close(release)
+	<-done
+
+	transport.base.CloseIdleConnections()
+	if in, id := testutil.ToFloat64(inUse), testutil.ToFloat64(idle); in != 0 || id != 0 {
+		t.Errorf("after closing: in use %v, idle %v, want 0", in, id)
+	}
+	if n := testutil.CollectAndCount(poolWait, "elasticsearch_pool_wait_seconds"); n == 0 {
+		t.Error("expected wait times observed")
+	}
+}
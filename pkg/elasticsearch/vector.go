@@ -0,0 +1,300 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// Hit is a search result with its relevance score
+type Hit struct {
+	ID     string
+	Score  float64
+	Source json.RawMessage
+}
+
+// Decode unmarshals the document source into v
+func (h Hit) Decode(v interface{}) error {
+	return json.Unmarshal(h.Source, v)
+}
+
+// DenseVector returns the mapping of a dense_vector field indexed for kNN
+// search. similarity is "cosine" (default), "dot_product", "l2_norm" or
+// "max_inner_product".
+func DenseVector(dims int, similarity string) map[string]interface{} {
+	if similarity == "" {
+		similarity = "cosine"
+	}
+	return map[string]interface{}{
+		"type":       "dense_vector",
+		"dims":       dims,
+		"index":      true,
+		"similarity": similarity,
+	}
+}
+
+// VectorIndex describes an index holding embeddings next to regular fields
+type VectorIndex struct {
+	Field      string                 // Field holding the embeddings
+	Dims       int                    // Embedding size, e.g. 1536
+	Similarity string                 // See DenseVector
+	Properties map[string]interface{} // Mappings of the other fields
+}
+
+// Mapping returns the index body with the dense_vector field added to the
+// properties
+func (v VectorIndex) Mapping() (string, error) {
+	if v.Field == "" || v.Dims <= 0 {
+		return "", errors.New("vector index requires a field and dims")
+	}
+	properties := make(map[string]interface{}, len(v.Properties)+1)
+	for k, p := range v.Properties {
+		properties[k] = p
+	}
+	properties[v.Field] = DenseVector(v.Dims, v.Similarity)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"mappings": map[string]interface{}{"properties": properties},
+	})
+	return string(body), err
+}
+
+// CreateVectorIndex creates an index with a dense_vector field
+func (c *Client) CreateVectorIndex(ctx context.Context, index string, v VectorIndex) error {
+	mapping, err := v.Mapping()
+	if err != nil {
+		return err
+	}
+	return c.CreateIndex(ctx, index, mapping)
+}
+
+// KNNOptions configures an approximate kNN search, which needs
+// Elasticsearch 8
+type KNNOptions struct {
+	Field         string                 // dense_vector field, required
+	K             int                    // Number of nearest neighbours to return (default 10)
+	NumCandidates int                    // Candidates considered per shard (default 10 × K)
+	Filter        map[string]interface{} // Query documents must match, e.g. a term query
+	MinSimilarity float64                // Drops hits less similar than this when set
+	Boost         float64                // Weight of the kNN score in a combined query
+	Source        []string               // Source fields returned; all when empty
+}
+
+// KNNQuery returns the knn section of a search body for vector, for
+// requests built by hand
+func KNNQuery(vector []float32, opts KNNOptions) (map[string]interface{}, error) {
+	if opts.Field == "" {
+		return nil, errors.New("knn search requires a field")
+	}
+	if len(vector) == 0 {
+		return nil, errors.New("knn search requires a query vector")
+	}
+	if opts.K <= 0 {
+		opts.K = 10
+	}
+	if opts.NumCandidates < opts.K {
+		opts.NumCandidates = min(opts.K*10, 10000)
+	}
+
+	knn := map[string]interface{}{
+		"field":          opts.Field,
+		"query_vector":   vector,
+		"k":              opts.K,
+		"num_candidates": opts.NumCandidates,
+	}
+	if len(opts.Filter) > 0 {
+		knn["filter"] = opts.Filter
+	}
+	if opts.MinSimilarity != 0 {
+		knn["similarity"] = opts.MinSimilarity
+	}
+	if opts.Boost != 0 {
+		knn["boost"] = opts.Boost
+	}
+	return knn, nil
+}
+
+// KNNSearch returns the documents whose embeddings are nearest to vector,
+// most similar first
+func (c *Client) KNNSearch(ctx context.Context, index string, vector []float32, opts KNNOptions) ([]Hit, error) {
+	knn, err := KNNQuery(vector, opts)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{"knn": knn, "size": knn["k"]}
+	if len(opts.Source) > 0 {
+		body["_source"] = opts.Source
+	}
+	return c.SearchHits(ctx, index, body)
+}
+
+// SearchHits performs a search query and returns the hits with their
+// scores
+func (c *Client) SearchHits(ctx context.Context, index string, query map[string]interface{}) ([]Hit, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.client.Search().
+		Index(index).
+		Source(string(queryJSON)).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toHits(res.Hits), nil
+}
+
+func toHits(hits *elastic.SearchHits) []Hit {
+	if hits == nil {
+		return nil
+	}
+	out := make([]Hit, 0, len(hits.Hits))
+	for _, hit := range hits.Hits {
+		h := Hit{ID: hit.Id, Source: hit.Source}
+		if hit.Score != nil {
+			h.Score = *hit.Score
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// Fusion selects how HybridSearch merges the text and vector results
+type Fusion int
+
+const (
+	// FusionRRF ranks by reciprocal rank fusion, which ignores the score
+	// scales and needs no tuning
+	FusionRRF Fusion = iota
+	// FusionLinear ranks by the weighted sum of min-max normalised scores
+	FusionLinear
+)
+
+// HybridOptions configures a hybrid search combining a BM25 text query with
+// a kNN search
+type HybridOptions struct {
+	Query        map[string]interface{} // Text query, e.g. {"match": {"body": "refund policy"}}
+	KNN          KNNOptions
+	Size         int // Number of fused results (default KNN.K)
+	Fusion       Fusion
+	RankConstant int     // RRF rank constant (default 60)
+	TextWeight   float64 // FusionLinear weight of the text score (default 0.5)
+	VectorWeight float64 // FusionLinear weight of the vector score (default 0.5)
+}
+
+// HybridSearch runs the text query and the kNN search, and fuses both
+// result lists into one, best matches first. Hit.Score holds the fused
+// score.
+func (c *Client) HybridSearch(ctx context.Context, index string, vector []float32, opts HybridOptions) ([]Hit, error) {
+	if len(opts.Query) == 0 {
+		return nil, errors.New("hybrid search requires a text query")
+	}
+	knn, err := KNNQuery(vector, opts.KNN)
+	if err != nil {
+		return nil, err
+	}
+	k := knn["k"].(int)
+	if opts.Size <= 0 {
+		opts.Size = k
+	}
+
+	// Each side fetches at least Size hits so the fusion has enough to rank
+	window := max(k, opts.Size)
+	knn["k"] = window
+	knn["num_candidates"] = max(knn["num_candidates"].(int), window)
+
+	textBody := map[string]interface{}{"query": opts.Query, "size": window}
+	vectorBody := map[string]interface{}{"knn": knn, "size": window}
+	if len(opts.KNN.Source) > 0 {
+		textBody["_source"] = opts.KNN.Source
+		vectorBody["_source"] = opts.KNN.Source
+	}
+
+	text, err := c.SearchHits(ctx, index, textBody)
+	if err != nil {
+		return nil, fmt.Errorf("text search: %w", err)
+	}
+	vectors, err := c.SearchHits(ctx, index, vectorBody)
+	if err != nil {
+		return nil, fmt.Errorf("knn search: %w", err)
+	}
+
+	var fused []Hit
+	if opts.Fusion == FusionLinear {
+		tw, vw := opts.TextWeight, opts.VectorWeight
+		if tw == 0 && vw == 0 {
+			tw, vw = 0.5, 0.5
+		}
+		fused = FuseLinear([]float64{tw, vw}, text, vectors)
+	} else {
+		fused = FuseRRF(opts.RankConstant, text, vectors)
+	}
+	if len(fused) > opts.Size {
+		fused = fused[:opts.Size]
+	}
+	return fused, nil
+}
+
+// FuseRRF merges ranked result lists by reciprocal rank fusion: a document
+// scores the sum of 1/(k+rank) over the lists it appears in. k defaults to
+// 60.
+func FuseRRF(k int, lists ...[]Hit) []Hit {
+	if k <= 0 {
+		k = 60
+	}
+	return fuse(lists, func(list int, rank int, _ Hit) float64 {
+		return 1 / float64(k+rank+1)
+	})
+}
+
+// FuseLinear merges result lists by the weighted sum of their scores, each
+// list min-max normalised to [0, 1] first. Missing weights count as 1.
+func FuseLinear(weights []float64, lists ...[]Hit) []Hit {
+	bounds := make([][2]float64, len(lists))
+	for i, list := range lists {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, h := range list {
+			lo, hi = math.Min(lo, h.Score), math.Max(hi, h.Score)
+		}
+		bounds[i] = [2]float64{lo, hi}
+	}
+
+	return fuse(lists, func(list int, _ int, h Hit) float64 {
+		weight := 1.0
+		if list < len(weights) {
+			weight = weights[list]
+		}
+		lo, hi := bounds[list][0], bounds[list][1]
+		if hi == lo {
+			return weight
+		}
+		return weight * (h.Score - lo) / (hi - lo)
+	})
+}
+
+// fuse sums the contribution of every occurrence of a document, keeping the
+// first source seen, and sorts by the fused score
+func fuse(lists [][]Hit, score func(list int, rank int, h Hit) float64) []Hit {
+	index := make(map[string]int)
+	var out []Hit
+	for l, list := range lists {
+		for rank, h := range list {
+			i, ok := index[h.ID]
+			if !ok {
+				i = len(out)
+				index[h.ID] = i
+				out = append(out, Hit{ID: h.ID, Source: h.Source})
+			}
+			out[i].Score += score(l, rank, h)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
@@ -0,0 +1,91 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func ids(hits []Hit) string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = h.ID
+	}
+	return strings.Join(out, ",")
+}
+
+func TestFuseRRF(t *testing.T) {
+	text := []Hit{{ID: "a", Score: 12}, {ID: "b", Score: 9}, {ID: "c", Score: 1}}
+	vectors := []Hit{{ID: "c", Score: 0.99}, {ID: "b", Score: 0.98}, {ID: "d", Score: 0.5}}
+
+	fused := FuseRRF(0, text, vectors)
+	if got := ids(fused); got != "c,b,a,d" {
+		t.Errorf("expected c,b,a,d, got %s", got)
+	}
+	if want := 1.0/61 + 1.0/63; fused[0].Score != want {
+		t.Errorf("expected score %v, got %v", want, fused[0].Score)
+	}
+}
+
+func TestFuseLinear(t *testing.T) {
+	text := []Hit{{ID: "a", Score: 10}, {ID: "b", Score: 5}, {ID: "c", Score: 0}}
+	vectors := []Hit{{ID: "c", Score: 0.9}, {ID: "a", Score: 0.5}, {ID: "b", Score: 0.1}}
+
+	tests := []struct {
+		weights []float64
+		want    string
+	}{
+		{[]float64{0.5, 0.5}, "a,c,b"},
+		{[]float64{0.1, 0.9}, "c,a,b"},
+		{[]float64{1, 0}, "a,b,c"},
+	}
+	for _, tt := range tests {
+		if got := ids(FuseLinear(tt.weights, text, vectors)); got != tt.want {
+			t.Errorf("weights %v: expected %s, got %s", tt.weights, tt.want, got)
+		}
+	}
+}
+
+func TestKNNQuery(t *testing.T) {
+	if _, err := KNNQuery([]float32{1}, KNNOptions{}); err == nil {
+		t.Error("expected an error without a field")
+	}
+	if _, err := KNNQuery(nil, KNNOptions{Field: "embedding"}); err == nil {
+		t.Error("expected an error without a vector")
+	}
+
+	knn, err := KNNQuery([]float32{0.1, 0.2}, KNNOptions{Field: "embedding", K: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if knn["k"] != 5 || knn["num_candidates"] != 50 {
+		t.Errorf("unexpected defaults %v", knn)
+	}
+}
+
+func TestVectorIndex_Mapping(t *testing.T) {
+	body, err := VectorIndex{
+		Field:      "embedding",
+		Dims:       3,
+		Properties: map[string]interface{}{"title": map[string]interface{}{"type": "text"}},
+	}.Mapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m struct {
+		Mappings struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(body), &m); err != nil {
+		t.Fatal(err)
+	}
+	vector := m.Mappings.Properties["embedding"]
+	if vector["type"] != "dense_vector" || vector["dims"] != 3.0 || vector["similarity"] != "cosine" {
+		t.Errorf("unexpected vector mapping %v", vector)
+	}
+	if m.Mappings.Properties["title"]["type"] != "text" {
+		t.Error("expected the other properties to be kept")
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Descriptor tells route listings and App.MiddlewareChains what middleware
+// is, instead of guessing from its function name
+type Descriptor struct {
+	Name          string // Listed name, e.g. "middleware.Recovery"
+	Recovers      bool   // Recovers panics, so it belongs outermost
+	Authenticates bool   // Sets the claims of the request
+	NeedsClaims   bool   // Reads the claims, so it runs after authentication
+}
+
+// descriptorProbe is passed to described middleware to read its descriptor
+// back
+type descriptorProbe struct {
+	http.Handler
+	descriptor Descriptor
+}
+
+// Describe attaches d to middleware m
+//
+//go:noinline
+func Describe(d Descriptor, m func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if probe, ok := next.(*descriptorProbe); ok {
+			probe.descriptor = d
+			return probe
+		}
+		return m(next)
+	}
+}
+
+// describedPC identifies the closures returned by Describe; other
+// middleware is never called to find its descriptor
+var describedPC = reflect.ValueOf(Describe(Descriptor{}, nil)).Pointer()
+
+// DescriptorOf returns the descriptor attached to m with Describe, false
+// for other middleware
+func DescriptorOf(m func(http.Handler) http.Handler) (Descriptor, bool) {
+	if m == nil || reflect.ValueOf(m).Pointer() != describedPC {
+		return Descriptor{}, false
+	}
+	probe := &descriptorProbe{}
+	m(probe)
+	return probe.descriptor, true
+}
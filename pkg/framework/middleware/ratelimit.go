@@ -0,0 +1,13 @@
+package middleware
+
+import "time"
+
+// RateLimitResult is the outcome of a rate limit check. It lives here so
+// limiters such as cache.RateLimiter need not import pkg/middleware.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // Requests allowed per window
+	Remaining  int           // Requests left in the window
+	Reset      time.Duration // Until the current window ends
+	RetryAfter time.Duration // Until a request is allowed again, when denied
+}
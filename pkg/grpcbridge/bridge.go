@@ -0,0 +1,234 @@
+// Package grpcbridge exposes server-streaming gRPC methods to browsers over
+// WebSocket or Server-Sent Events.
+//
+// It does not import gRPC: any generated streaming client satisfies Stream,
+// since it has a Recv() (*Msg, error) method. Open adapts the generated call:
+//
+//	open := func(ctx context.Context, req *pb.WatchRequest) (grpcbridge.Stream[*pb.Event], error) {
+//		return client.Watch(ctx, req)
+//	}
+//	api.GET("/events", grpcbridge.SSE(grpcbridge.Config[pb.WatchRequest, *pb.Event]{Open: open}))
+//
+// Messages are pulled from the stream only after the previous one has been
+// written, so a slow browser applies backpressure to the gRPC stream through
+// HTTP/2 flow control instead of buffering in the server.
+package grpcbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/polymatx/goframe/pkg/binding"
+	"github.com/sirupsen/logrus"
+)
+
+// Stream is a server-streaming receiver such as a generated gRPC client stream
+type Stream[T any] interface {
+	Recv() (T, error)
+}
+
+// Config configures a bridge endpoint for request type Req and message type Resp
+type Config[Req any, Resp any] struct {
+	// Open starts the upstream stream. Required.
+	Open func(ctx context.Context, req *Req) (Stream[Resp], error)
+
+	// Outgoing derives the upstream context from the request, e.g. to copy
+	// the Authorization header into gRPC metadata:
+	//	metadata.AppendToOutgoingContext(ctx, "authorization", r.Header.Get("Authorization"))
+	Outgoing func(ctx context.Context, r *http.Request) context.Context
+
+	// Decode fills req from the HTTP request for SSE endpoints. Defaults to
+	// the JSON body for POST requests and query parameters otherwise.
+	// WebSocket endpoints read the request from the first client message.
+	Decode func(r *http.Request, req *Req) error
+
+	// Marshal encodes a message. Defaults to encoding/json; use protojson
+	// for proto messages.
+	Marshal func(Resp) ([]byte, error)
+
+	// WriteTimeout bounds a single write to the client (default 10s). A
+	// client that cannot keep up within it is disconnected.
+	WriteTimeout time.Duration
+
+	// Upgrader is used by WebSocket endpoints
+	Upgrader websocket.Upgrader
+}
+
+func (c *Config[Req, Resp]) setDefaults() {
+	if c.Outgoing == nil {
+		c.Outgoing = func(ctx context.Context, r *http.Request) context.Context { return ctx }
+	}
+	if c.Decode == nil {
+		c.Decode = decodeRequest[Req]
+	}
+	if c.Marshal == nil {
+		c.Marshal = func(m Resp) ([]byte, error) { return json.Marshal(m) }
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = 10 * time.Second
+	}
+}
+
+func decodeRequest[Req any](r *http.Request, req *Req) error {
+	if r.Method == http.MethodPost {
+		defer r.Body.Close()
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+		return nil
+	}
+	return binding.Query(r, req)
+}
+
+// SSE returns a handler streaming upstream messages as Server-Sent Events.
+// Each message is sent as a "message" event; a stream failure is reported
+// as an "error" event before the response ends.
+func SSE[Req any, Resp any](config Config[Req, Resp]) http.HandlerFunc {
+	config.setDefaults()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := new(Req)
+		if err := config.Decode(r, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(config.Outgoing(r.Context(), r))
+		defer cancel()
+
+		stream, err := config.Open(ctx, req)
+		if err != nil {
+			http.Error(w, "Upstream unavailable", http.StatusBadGateway)
+			logrus.Errorf("grpcbridge: open stream: %v", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		// The controller reaches the Flusher through middleware wrappers
+		// that only implement Unwrap
+		rc := http.NewResponseController(w)
+		if err := rc.Flush(); err != nil {
+			logrus.Errorf("grpcbridge: streaming unsupported: %v", err)
+			return
+		}
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					_, _ = fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+					_ = rc.Flush()
+				}
+				return
+			}
+
+			data, err := config.Marshal(msg)
+			if err != nil {
+				logrus.Errorf("grpcbridge: marshal message: %v", err)
+				return
+			}
+
+			_ = rc.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// WebSocket returns a handler that reads the request from the first client
+// message and streams upstream messages back as text frames. The socket is
+// closed with a normal closure when the stream ends, or with an internal
+// error closure carrying the stream error.
+func WebSocket[Req any, Resp any](config Config[Req, Resp]) http.HandlerFunc {
+	config.setDefaults()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := config.Upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		closeWith := func(code int, text string) {
+			// Close frame payloads are limited to 125 bytes including the code
+			text = truncate(text, 120)
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(time.Second))
+		}
+
+		req := new(Req)
+		if err := conn.ReadJSON(req); err != nil {
+			closeWith(websocket.CloseUnsupportedData, "invalid request")
+			return
+		}
+
+		ctx, cancel := context.WithCancel(config.Outgoing(r.Context(), r))
+		defer cancel()
+
+		// Cancel the upstream stream when the client goes away
+		go func() {
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+
+		stream, err := config.Open(ctx, req)
+		if err != nil {
+			logrus.Errorf("grpcbridge: open stream: %v", err)
+			closeWith(websocket.CloseTryAgainLater, "upstream unavailable")
+			return
+		}
+
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					closeWith(websocket.CloseNormalClosure, "")
+				} else if ctx.Err() == nil {
+					closeWith(websocket.CloseInternalServerErr, err.Error())
+				}
+				return
+			}
+
+			data, err := config.Marshal(msg)
+			if err != nil {
+				logrus.Errorf("grpcbridge: marshal message: %v", err)
+				closeWith(websocket.CloseInternalServerErr, "marshal error")
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8
+// sequence, which would make the close reason invalid text
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,167 @@
+package grpcbridge
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+type watchRequest struct {
+	Topic string `json:"topic" form:"topic"`
+}
+
+type event struct {
+	Topic string `json:"topic"`
+	Seq   int    `json:"seq"`
+}
+
+type sliceStream struct {
+	events []*event
+	err    error
+}
+
+func (s *sliceStream) Recv() (*event, error) {
+	if len(s.events) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	e := s.events[0]
+	s.events = s.events[1:]
+	return e, nil
+}
+
+type authKey struct{}
+
+func testConfig(t *testing.T, streamErr error) Config[watchRequest, *event] {
+	return Config[watchRequest, *event]{
+		Outgoing: func(ctx context.Context, r *http.Request) context.Context {
+			return context.WithValue(ctx, authKey{}, r.Header.Get("Authorization"))
+		},
+		Open: func(ctx context.Context, req *watchRequest) (Stream[*event], error) {
+			if ctx.Value(authKey{}) != "Bearer t" {
+				t.Errorf("expected authorization to be propagated, got %v", ctx.Value(authKey{}))
+			}
+			return &sliceStream{
+				events: []*event{{Topic: req.Topic, Seq: 1}, {Topic: req.Topic, Seq: 2}},
+				err:    streamErr,
+			}, nil
+		},
+	}
+}
+
+func TestSSE(t *testing.T) {
+	srv := httptest.NewServer(SSE(testConfig(t, errors.New("upstream reset"))))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"?topic=orders", nil)
+	req.Header.Set("Authorization", "Bearer t")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %s", ct)
+	}
+
+	body, _ := io.ReadAll(bufio.NewReader(resp.Body))
+	want := "event: message\ndata: {\"topic\":\"orders\",\"seq\":1}\n\n" +
+		"event: message\ndata: {\"topic\":\"orders\",\"seq\":2}\n\n" +
+		"event: error\ndata: \"upstream reset\"\n\n"
+	if string(body) != want {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+}
+
+// unwrapWriter hides the Flusher of the writer it wraps, like middleware
+// that only implements Unwrap
+type unwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w *unwrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestSSE_ThroughUnwrapWriter(t *testing.T) {
+	handler := SSE(testConfig(t, nil))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(&unwrapWriter{w}, r)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"?topic=orders", nil)
+	req.Header.Set("Authorization", "Bearer t")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || strings.Count(string(body), "event: message") != 2 {
+		t.Errorf("expected events through the wrapper, got %d:\n%s", resp.StatusCode, body)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	srv := httptest.NewServer(WebSocket(testConfig(t, nil)))
+	defer srv.Close()
+
+	header := http.Header{"Authorization": []string{"Bearer t"}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(watchRequest{Topic: "users"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	for seq := 1; seq <= 2; seq++ {
+		var e event
+		if err := conn.ReadJSON(&e); err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if e.Topic != "users" || e.Seq != seq {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	}
+
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("expected normal closure at end of stream, got %v", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "abc"},
+		{"aé", 2, "a"}, // é is 2 bytes
+		{"€€", 4, "€"}, // € is 3 bytes
+		{"€", 1, ""},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.in, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
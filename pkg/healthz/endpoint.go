@@ -0,0 +1,48 @@
+package healthz
+
+import (
+	"sync"
+)
+
+// Endpoint is the network address of a configured backend, e.g. a database
+// server, registered by the driver packages when a connection is configured
+type Endpoint struct {
+	Name  string   // e.g. "database:main"
+	Addrs []string // host:port, any of them reachable is enough
+}
+
+var (
+	endpoints     []Endpoint
+	endpointsLock sync.RWMutex
+)
+
+// RegisterEndpoint records the addresses of a configured backend, replacing
+// an endpoint of the same name. Addresses that are empty are skipped.
+func RegisterEndpoint(name string, addrs ...string) {
+	var kept []string
+	for _, addr := range addrs {
+		if addr != "" {
+			kept = append(kept, addr)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+
+	endpointsLock.Lock()
+	defer endpointsLock.Unlock()
+	for i := range endpoints {
+		if endpoints[i].Name == name {
+			endpoints[i].Addrs = kept
+			return
+		}
+	}
+	endpoints = append(endpoints, Endpoint{Name: name, Addrs: kept})
+}
+
+// Endpoints returns the registered endpoints in registration order
+func Endpoints() []Endpoint {
+	endpointsLock.RLock()
+	defer endpointsLock.RUnlock()
+	return append([]Endpoint(nil), endpoints...)
+}
@@ -224,3 +224,34 @@ func TestRegisterRoute(t *testing.T) {
 	// not panic and must not start anything.
 	RegisterRoute()
 }
+
+func TestRegisterEndpoint(t *testing.T) {
+	endpointsLock.Lock()
+	saved := endpoints
+	endpoints = nil
+	endpointsLock.Unlock()
+	defer func() {
+		endpointsLock.Lock()
+		endpoints = saved
+		endpointsLock.Unlock()
+	}()
+
+	RegisterEndpoint("database:main", "db:5432")
+	RegisterEndpoint("redis:default", "", "")
+	RegisterEndpoint("redis:cluster", "r1:6379", "", "r2:6379")
+	RegisterEndpoint("database:main", "db2:5432")
+
+	got := Endpoints()
+	want := []Endpoint{
+		{Name: "database:main", Addrs: []string{"db2:5432"}},
+		{Name: "redis:cluster", Addrs: []string{"r1:6379", "r2:6379"}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d endpoints, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || strings.Join(got[i].Addrs, ",") != strings.Join(want[i].Addrs, ",") {
+			t.Errorf("endpoint %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package httpclient provides HTTP clients for service-to-service calls. The
+// requests they send with the context of an inbound request carry the
+// headers middleware.PropagateHeaders captured from it, such as the tenant,
+// locale and trace context, and are recorded as spans for
+// middleware.SlowRequests:
+//
+//	client := httpclient.New(httpclient.Config{Hosts: []string{"*.svc.cluster.local"}})
+//
+//	req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, "http://orders.svc.cluster.local/orders", nil)
+//	resp, err := client.Do(req)
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+// Config holds client configuration
+type Config struct {
+	Timeout   time.Duration     // Overall request timeout (default 30s)
+	Transport http.RoundTripper // Base transport (default http.DefaultTransport)
+
+	// Hosts receive the propagated headers, e.g. "orders:8080" or
+	// "*.svc.cluster.local"; a host without a port matches any port. Empty
+	// propagates to every host, so set it when the client also calls third
+	// parties and Authorization is propagated.
+	Hosts []string
+}
+
+// New returns a client propagating the captured headers of the request
+// context to outbound requests
+func New(config Config) *http.Client {
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: middleware.SpanTransport(Propagate(config.Transport, config.Hosts...)),
+	}
+}
+
+// Propagate returns a transport setting the headers captured by
+// middleware.PropagateHeaders on requests to hosts made through next.
+// Headers the request already has are kept. A nil next uses
+// http.DefaultTransport.
+func Propagate(next http.RoundTripper, hosts ...string) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &propagator{next: next, hosts: hosts}
+}
+
+type propagator struct {
+	next  http.RoundTripper
+	hosts []string
+}
+
+func (p *propagator) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := middleware.GetPropagatedHeaders(req.Context())
+	if len(headers) == 0 || !p.allowed(req.URL.Host) {
+		return p.next.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it is given
+	var out *http.Request
+	for name, values := range headers {
+		if _, ok := req.Header[name]; ok {
+			continue
+		}
+		if out == nil {
+			out = req.Clone(req.Context())
+		}
+		out.Header[name] = values
+	}
+	if out == nil {
+		return p.next.RoundTrip(req)
+	}
+	return p.next.RoundTrip(out)
+}
+
+func (p *propagator) allowed(host string) bool {
+	if len(p.hosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	name := host
+	if i := strings.LastIndexByte(host, ':'); i > strings.LastIndexByte(host, ']') {
+		name = host[:i]
+	}
+	for _, pattern := range p.hosts {
+		pattern = strings.ToLower(pattern)
+		candidate := name
+		if i := strings.LastIndexByte(pattern, ':'); i > strings.LastIndexByte(pattern, ']') {
+			candidate = host
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(candidate, suffix) {
+				return true
+			}
+		} else if candidate == pattern {
+			return true
+		}
+	}
+	return false
+}
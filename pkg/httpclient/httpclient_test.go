@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+func TestPropagate(t *testing.T) {
+	var got http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer backend.Close()
+
+	// Headers captured from the inbound request reach the backend
+	handler := middleware.PropagateHeaders(middleware.PropagateConfig{
+		Headers: []string{"X-Tenant-ID", "Accept-Language", "Authorization"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, backend.URL, nil)
+		req.Header.Set("Accept-Language", "fr")
+		resp, err := New(Config{}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if req.Header.Get("X-Tenant-ID") != "" {
+			t.Error("outbound request modified")
+		}
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Tenant-ID", "acme")
+	r.Header.Set("Accept-Language", "de")
+	r.Header.Set("Authorization", "Bearer token")
+	r.Header.Set("Cookie", "session=1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got.Get("X-Tenant-ID") != "acme" || got.Get("Authorization") != "Bearer token" {
+		t.Errorf("expected propagated headers, got %v", got)
+	}
+	if got.Get("Accept-Language") != "fr" {
+		t.Errorf("expected request header kept, got %q", got.Get("Accept-Language"))
+	}
+	if got.Get("Cookie") != "" {
+		t.Errorf("expected unconfigured header not propagated, got %q", got.Get("Cookie"))
+	}
+
+	t.Run("hosts", func(t *testing.T) {
+		ctx := middleware.WithPropagatedHeaders(context.Background(), http.Header{"X-Tenant-Id": {"acme"}})
+		for _, tt := range []struct {
+			hosts []string
+			want  string
+		}{
+			{nil, "acme"},
+			{[]string{"127.0.0.1"}, "acme"},
+			{[]string{backend.Listener.Addr().String()}, "acme"},
+			{[]string{"127.0.0.1:1"}, ""},
+			{[]string{"*.0.0.1"}, "acme"},
+			{[]string{"api.example.com"}, ""},
+		} {
+			got = nil
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL, nil)
+			resp, err := New(Config{Hosts: tt.hosts}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if v := got.Get("X-Tenant-ID"); v != tt.want {
+				t.Errorf("hosts %v: X-Tenant-ID = %q, want %q", tt.hosts, v, tt.want)
+			}
+		}
+	})
+}
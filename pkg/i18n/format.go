@@ -0,0 +1,154 @@
+package i18n
+
+import (
+	"html/template"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/money"
+)
+
+// Format holds the conventions of a locale for numbers, dates and money
+type Format struct {
+	Decimal  string // Decimal separator, e.g. "." or ","
+	Group    string // Thousands separator, e.g. "," or "."
+	Date     string // time layout of dates, e.g. "1/2/2006"
+	DateTime string // time layout of dates with time, e.g. "1/2/2006 3:04 PM"
+
+	// Money is the pattern of amounts, with {amount} and {symbol}
+	// placeholders, e.g. "{symbol}{amount}" or "{amount} {symbol}"
+	Money string
+}
+
+// formats are the built-in conventions by locale
+var formats = map[string]Format{
+	"en":    {".", ",", "1/2/2006", "1/2/2006 3:04 PM", "{symbol}{amount}"},
+	"en-GB": {".", ",", "02/01/2006", "02/01/2006 15:04", "{symbol}{amount}"},
+	"de":    {",", ".", "02.01.2006", "02.01.2006 15:04", "{amount}\u00a0{symbol}"},
+	"de-CH": {".", "\u2019", "02.01.2006", "02.01.2006 15:04", "{symbol}\u00a0{amount}"},
+	"fr":    {",", "\u202f", "02/01/2006", "02/01/2006 15:04", "{amount}\u00a0{symbol}"},
+	"es":    {",", ".", "2/1/2006", "2/1/2006 15:04", "{amount}\u00a0{symbol}"},
+	"it":    {",", ".", "02/01/2006", "02/01/2006 15:04", "{amount}\u00a0{symbol}"},
+	"nl":    {",", ".", "2-1-2006", "2-1-2006 15:04", "{symbol}\u00a0{amount}"},
+	"pt":    {",", ".", "02/01/2006", "02/01/2006 15:04", "{symbol}\u00a0{amount}"},
+	"pl":    {",", "\u00a0", "2.01.2006", "2.01.2006 15:04", "{amount}\u00a0{symbol}"},
+	"ru":    {",", "\u00a0", "02.01.2006", "02.01.2006 15:04", "{amount}\u00a0{symbol}"},
+	"sv":    {",", "\u00a0", "2006-01-02", "2006-01-02 15:04", "{amount}\u00a0{symbol}"},
+	"tr":    {",", ".", "02.01.2006", "02.01.2006 15:04", "{symbol}{amount}"},
+	"ja":    {".", ",", "2006/01/02", "2006/01/02 15:04", "{symbol}{amount}"},
+	"zh":    {".", ",", "2006/1/2", "2006/1/2 15:04", "{symbol}{amount}"},
+}
+
+var formatsMu sync.RWMutex
+
+// SetFormat sets the conventions of locale, e.g. "de-AT", replacing the
+// built-in ones
+func SetFormat(locale string, f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[normalize(locale)] = f
+}
+
+// LookupFormat returns the conventions of locale, then of its base
+// language ("pt" for "pt-BR"), then English
+func LookupFormat(locale string) Format {
+	locale = normalize(locale)
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	if f, ok := formats[locale]; ok {
+		return f
+	}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		if f, ok := formats[base]; ok {
+			return f
+		}
+	}
+	return formats["en"]
+}
+
+// FormatNumber formats v with decimals places in locale, e.g. "1,234.50"
+// in "en" and "1.234,50" in "de"
+func FormatNumber(locale string, v float64, decimals int) string {
+	return LookupFormat(locale).number(strconv.FormatFloat(v, 'f', decimals, 64))
+}
+
+// FormatDate formats the date of t in locale, e.g. "3/14/2026" in "en" and
+// "14.03.2026" in "de"; convert t to the user's timezone first
+func FormatDate(locale string, t time.Time) string {
+	return t.Format(LookupFormat(locale).Date)
+}
+
+// FormatDateTime formats the date and time of t in locale
+func FormatDateTime(locale string, t time.Time) string {
+	return t.Format(LookupFormat(locale).DateTime)
+}
+
+// FormatMoney formats m in locale with the digits and symbol of its
+// currency, e.g. "$1,234.50" in "en" and "1.234,50 €" in "de"
+func FormatMoney(locale string, m money.Money) string {
+	f := LookupFormat(locale)
+	amount := f.number(m.Decimal())
+	sign := ""
+	if rest, ok := strings.CutPrefix(amount, "-"); ok {
+		sign, amount = "-", rest
+	}
+	return sign + strings.NewReplacer(
+		"{amount}", amount,
+		"{symbol}", money.LookupCurrency(m.Currency).Symbol,
+	).Replace(f.Money)
+}
+
+// number localizes a decimal such as "-1234.5", grouping its digits
+func (f Format) number(decimal string) string {
+	sign := ""
+	if rest, ok := strings.CutPrefix(decimal, "-"); ok {
+		sign, decimal = "-", rest
+	}
+	integer, fraction, _ := strings.Cut(decimal, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(f.Group)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(f.Decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// TemplateFuncs returns template functions formatting in locale:
+//
+//	{{t "not_found"}}
+//	{{formatNumber .Total 2}}
+//	{{formatDate .CreatedAt}}
+//	{{formatDateTime .CreatedAt}}
+//	{{formatMoney .Price}}
+//
+// Parse templates with them, e.g. with render.NewTemplateRendererFuncs,
+// then app.Context.HTML replaces them with ones for the request locale.
+func TemplateFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(code string, args ...string) string {
+			return Default.Message(locale, code, args...)
+		},
+		"formatNumber": func(v float64, decimals int) string {
+			return FormatNumber(locale, v, decimals)
+		},
+		"formatDate": func(t time.Time) string {
+			return FormatDate(locale, t)
+		},
+		"formatDateTime": func(t time.Time) string {
+			return FormatDateTime(locale, t)
+		},
+		"formatMoney": func(m money.Money) string {
+			return FormatMoney(locale, m)
+		},
+	}
+}
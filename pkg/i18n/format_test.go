@@ -0,0 +1,99 @@
+package i18n
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/money"
+)
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		locale   string
+		v        float64
+		decimals int
+		want     string
+	}{
+		{"en", 1234567.891, 2, "1,234,567.89"},
+		{"en-US", -1234.5, 1, "-1,234.5"},
+		{"de", 1234567.891, 2, "1.234.567,89"},
+		{"de-AT", 1234, 0, "1.234"},
+		{"fr", 1234.5, 2, "1\u202f234,50"},
+		{"xx", 999, 0, "999"},
+		{"en", 100, 0, "100"},
+	}
+	for _, tt := range tests {
+		if got := FormatNumber(tt.locale, tt.v, tt.decimals); got != tt.want {
+			t.Errorf("FormatNumber(%q, %v, %d) = %q, want %q", tt.locale, tt.v, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	d := time.Date(2026, 3, 14, 15, 4, 0, 0, time.UTC)
+	tests := []struct {
+		locale, date, dateTime string
+	}{
+		{"en", "3/14/2026", "3/14/2026 3:04 PM"},
+		{"en-GB", "14/03/2026", "14/03/2026 15:04"},
+		{"de-DE", "14.03.2026", "14.03.2026 15:04"},
+		{"ja", "2026/03/14", "2026/03/14 15:04"},
+	}
+	for _, tt := range tests {
+		if got := FormatDate(tt.locale, d); got != tt.date {
+			t.Errorf("FormatDate(%q) = %q, want %q", tt.locale, got, tt.date)
+		}
+		if got := FormatDateTime(tt.locale, d); got != tt.dateTime {
+			t.Errorf("FormatDateTime(%q) = %q, want %q", tt.locale, got, tt.dateTime)
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		locale string
+		m      money.Money
+		want   string
+	}{
+		{"en", money.New(123450, "USD"), "$1,234.50"},
+		{"en", money.New(-999, "EUR"), "-€9.99"},
+		{"de", money.New(123450, "EUR"), "1.234,50\u00a0€"},
+		{"pt-BR", money.New(123450, "BRL"), "R$\u00a01.234,50"},
+		{"ja", money.New(1500, "JPY"), "¥1,500"},
+		{"en", money.New(100, "XYZ"), "XYZ1.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatMoney(tt.locale, tt.m); got != tt.want {
+			t.Errorf("FormatMoney(%q, %v) = %q, want %q", tt.locale, tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	prev := LookupFormat("de-CH")
+	t.Cleanup(func() { SetFormat("de-CH", prev) })
+
+	SetFormat("de-CH", Format{Decimal: ".", Group: "'", Date: "02.01.2006", Money: "{symbol} {amount}"})
+	if got := FormatMoney("de-ch", money.New(123450, "CHF")); got != "CHF 1'234.50" {
+		t.Errorf("FormatMoney = %q", got)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	tmpl := template.Must(template.New("").Funcs(TemplateFuncs("en")).Parse(
+		`{{t "not_found"}} {{formatNumber .N 1}} {{formatDate .D}} {{formatMoney .M}}`))
+	var buf bytes.Buffer
+	err := tmpl.Funcs(TemplateFuncs("de")).Execute(&buf, map[string]interface{}{
+		"N": 1234.5,
+		"D": time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC),
+		"M": money.New(999, "EUR"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Not Found 1.234,5 14.03.2026 9,99\u00a0€"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
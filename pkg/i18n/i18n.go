@@ -0,0 +1,345 @@
+// Package i18n translates the error messages of the framework. A Catalog
+// maps message codes such as "rate_limited" to messages by locale; English
+// is built in, other locales are added with Add. Middleware negotiates the
+// request locale from Accept-Language, and the Format functions follow its
+// conventions for numbers, dates and money.
+package i18n
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Codes of the framework messages
+const (
+	CodeBadRequest           = "bad_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeRequestTimeout       = "request_timeout"
+	CodeConflict             = "conflict"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeValidationFailed     = "validation_failed"
+	CodeRateLimited          = "rate_limited"
+	CodeInternalError        = "internal_error"
+	CodeServiceUnavailable   = "service_unavailable"
+
+	CodeMissingAuthorization = "missing_authorization"
+	CodeInvalidAuthorization = "invalid_authorization"
+	CodeInvalidToken         = "invalid_token"
+	CodeInvalidAPIKey        = "invalid_api_key"
+	CodeInsufficientScope    = "insufficient_scope"
+)
+
+// English are the built-in messages, also the fallback of other locales.
+// Field validation messages have "validation.<tag>" codes, e.g.
+// "validation.required", with the {param} of the tag; binding words them
+// in English unless the request locale has them.
+var English = map[string]string{
+	CodeBadRequest:           "Bad Request",
+	CodeUnauthorized:         "Unauthorized",
+	CodeForbidden:            "Forbidden",
+	CodeNotFound:             "Not Found",
+	CodeMethodNotAllowed:     "Method Not Allowed",
+	CodeRequestTimeout:       "Request timeout",
+	CodeConflict:             "Conflict",
+	CodePayloadTooLarge:      "Request Entity Too Large",
+	CodeUnsupportedMediaType: "Unsupported Media Type",
+	CodeValidationFailed:     "Validation failed",
+	CodeRateLimited:          "Rate limit exceeded",
+	CodeInternalError:        "Internal Server Error",
+	CodeServiceUnavailable:   "Service Unavailable",
+
+	CodeMissingAuthorization: "Missing authorization header",
+	CodeInvalidAuthorization: "Invalid authorization header format",
+	CodeInvalidToken:         "Invalid token",
+	CodeInvalidAPIKey:        "Invalid API key",
+	CodeInsufficientScope:    "Insufficient scope",
+}
+
+// statusCodes are the codes of HTTP statuses
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusRequestTimeout:        CodeRequestTimeout,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeValidationFailed,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternalError,
+	http.StatusServiceUnavailable:    CodeServiceUnavailable,
+}
+
+// StatusCode returns the message code of an HTTP status, "" if none
+func StatusCode(status int) string {
+	return statusCodes[status]
+}
+
+// Catalog holds messages by locale and code. It is safe for concurrent use.
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string // Locale, code, message
+	codes    map[string]string            // Code by fallback message
+	fallback string
+}
+
+// NewCatalog creates a catalog falling back to the messages of fallback,
+// e.g. "en"
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		messages: make(map[string]map[string]string),
+		codes:    make(map[string]string),
+		fallback: normalize(fallback),
+	}
+}
+
+// Add adds messages by code to locale, e.g. "de" or "pt-BR"
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	locale = normalize(locale)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string, len(messages))
+	}
+	maps.Copy(c.messages[locale], messages)
+	if locale == c.fallback {
+		for code, message := range messages {
+			c.codes[message] = code
+		}
+	}
+}
+
+// Locales returns the locales with messages, sorted
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Sorted(maps.Keys(c.messages))
+}
+
+// Lookup returns the message of code in locale, then its base language
+// ("pt" for "pt-BR"), then the fallback locale
+func (c *Catalog) Lookup(locale, code string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, l := range c.chain(normalize(locale)) {
+		if message, ok := c.messages[l][code]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// Message returns the message of code in locale, with args as name, value
+// pairs filling its {name} placeholders. Unknown codes are returned as is.
+func (c *Catalog) Message(locale, code string, args ...string) string {
+	message, ok := c.Lookup(locale, code)
+	if !ok {
+		return code
+	}
+	return format(message, args)
+}
+
+// Translate returns text in locale, text being a code or the fallback
+// message of one, such as "Rate limit exceeded". Other text is returned
+// as is, so any error message can be passed.
+func (c *Catalog) Translate(locale, text string) string {
+	if message, ok := c.Lookup(locale, text); ok {
+		return message
+	}
+	c.mu.RLock()
+	code, ok := c.codes[text]
+	c.mu.RUnlock()
+	if !ok {
+		return text
+	}
+	message, _ := c.Lookup(locale, code)
+	return message
+}
+
+// chain returns locale, its base language and the fallback
+func (c *Catalog) chain(locale string) []string {
+	chain := []string{locale}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		chain = append(chain, base)
+	}
+	return append(chain, c.fallback)
+}
+
+func format(message string, args []string) string {
+	if len(args) < 2 || !strings.Contains(message, "{") {
+		return message
+	}
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+args[i]+"}", args[i+1])
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+// normalize turns "pt_br" or "PT-br" into "pt-BR"
+func normalize(locale string) string {
+	base, region, ok := strings.Cut(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	if !ok {
+		return strings.ToLower(base)
+	}
+	return strings.ToLower(base) + "-" + strings.ToUpper(region)
+}
+
+// Default is the catalog of the package-level functions and the framework
+var Default = NewCatalog("en")
+
+func init() {
+	Default.Add("en", English)
+}
+
+// Add adds messages to locale of the Default catalog
+func Add(locale string, messages map[string]string) {
+	Default.Add(locale, messages)
+}
+
+// Message returns the message of code in the request locale of ctx from
+// the Default catalog
+func Message(ctx context.Context, code string, args ...string) string {
+	return Default.Message(GetLocale(ctx), code, args...)
+}
+
+// Translate returns text in the request locale of ctx from the Default
+// catalog, see Catalog.Translate
+func Translate(ctx context.Context, text string) string {
+	return Default.Translate(GetLocale(ctx), text)
+}
+
+// T returns the message of code in the locale of r: the one set by
+// Middleware, else the best match of Accept-Language in the Default catalog
+func T(r *http.Request, code string, args ...string) string {
+	return Default.Message(RequestLocale(r), code, args...)
+}
+
+// Negotiate returns the first locale of an Accept-Language header, by
+// quality, that supported has, matching base languages both ways ("de-AT"
+// takes "de", "de" takes "de-DE"); "" when none matches
+func Negotiate(acceptLanguage string, supported []string) string {
+	type tag struct {
+		locale string
+		q      float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if locale != "" && q > 0 {
+			tags = append(tags, tag{normalize(locale), q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if t.locale == "*" && len(supported) > 0 {
+			return supported[0]
+		}
+		base, _, _ := strings.Cut(t.locale, "-")
+		var partial string
+		for _, s := range supported {
+			s = normalize(s)
+			if s == t.locale {
+				return s
+			}
+			if sBase, _, _ := strings.Cut(s, "-"); partial == "" && (s == base || sBase == t.locale) {
+				partial = s
+			}
+		}
+		if partial != "" {
+			return partial
+		}
+	}
+	return ""
+}
+
+type localeKey struct{}
+
+// WithLocale returns a context carrying the request locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// GetLocale returns the request locale set by Middleware, or the fallback
+// of the Default catalog
+func GetLocale(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return Default.fallback
+}
+
+// RequestLocale returns the locale set by Middleware, else the best match
+// of the Accept-Language header in the Default catalog
+func RequestLocale(r *http.Request) string {
+	if locale, ok := r.Context().Value(localeKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	if locale := Negotiate(r.Header.Get("Accept-Language"), Default.Locales()); locale != "" {
+		return locale
+	}
+	return Default.fallback
+}
+
+// Config holds locale negotiation configuration
+type Config struct {
+	QueryParam string // Query parameter overriding everything else (default "lang")
+
+	// Resolve returns the user's preferred locale, e.g. from their profile.
+	// It takes precedence over Accept-Language.
+	Resolve func(r *http.Request) string
+
+	Catalog *Catalog // Locales offered (default Default)
+}
+
+// Middleware resolves the request locale from the query parameter, the
+// user preference or Accept-Language, in that order, among the locales of
+// the catalog, and stores it in the request context
+func Middleware(config Config) func(http.Handler) http.Handler {
+	if config.QueryParam == "" {
+		config.QueryParam = "lang"
+	}
+	if config.Catalog == nil {
+		config.Catalog = Default
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			supported := config.Catalog.Locales()
+			locale := Negotiate(r.URL.Query().Get(config.QueryParam), supported)
+			if locale == "" && config.Resolve != nil {
+				locale = Negotiate(config.Resolve(r), supported)
+			}
+			if locale == "" {
+				locale = Negotiate(r.Header.Get("Accept-Language"), supported)
+			}
+			if locale == "" {
+				locale = config.Catalog.fallback
+			}
+
+			w.Header().Add("Vary", "Accept-Language")
+			w.Header().Set("Content-Language", locale)
+			next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), locale)))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	supported := []string{"en", "de", "pt-BR", "fr-FR"}
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"de", "de"},
+		{"de-AT,en;q=0.5", "de"},
+		{"fr", "fr-FR"},
+		{"pt_br", "pt-BR"},
+		{"es,de;q=0.8,en;q=0.9", "en"},
+		{"de;q=0", ""},
+		{"es", ""},
+		{"*", "en"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header, supported); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	c := NewCatalog("en")
+	c.Add("en", English)
+	c.Add("de", map[string]string{
+		CodeRateLimited:      "Zu viele Anfragen",
+		"validation.min":     "muss mindestens {param} sein",
+		CodeValidationFailed: "Validierung fehlgeschlagen",
+	})
+	c.Add("de-CH", map[string]string{CodeRateLimited: "Zu viele Anfragen, bitte warten"})
+
+	tests := []struct {
+		name, locale, text, want string
+	}{
+		{"code", "de", CodeRateLimited, "Zu viele Anfragen"},
+		{"English message", "de", "Rate limit exceeded", "Zu viele Anfragen"},
+		{"region", "de-CH", CodeRateLimited, "Zu viele Anfragen, bitte warten"},
+		{"base language", "de-AT", CodeValidationFailed, "Validierung fehlgeschlagen"},
+		{"fallback", "de", CodeNotFound, "Not Found"},
+		{"unknown locale", "ja", "Rate limit exceeded", "Rate limit exceeded"},
+		{"other text", "de", "order 42 is closed", "order 42 is closed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Translate(tt.locale, tt.text); got != tt.want {
+				t.Errorf("Translate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := c.Message("de", "validation.min", "param", "3"); got != "muss mindestens 3 sein" {
+		t.Errorf("Message = %q", got)
+	}
+	if got := c.Message("de", "unknown_code"); got != "unknown_code" {
+		t.Errorf("expected unknown codes as is, got %q", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	c := NewCatalog("en")
+	c.Add("en", English)
+	c.Add("fr", map[string]string{CodeNotFound: "Introuvable"})
+
+	var locale string
+	handler := Middleware(Config{Catalog: c})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = GetLocale(r.Context())
+	}))
+
+	tests := []struct {
+		url, header, want string
+	}{
+		{"/", "fr-CA,fr;q=0.9", "fr"},
+		{"/?lang=en", "fr", "en"},
+		{"/", "ja", "en"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		req.Header.Set("Accept-Language", tt.header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if locale != tt.want || rec.Header().Get("Content-Language") != tt.want {
+			t.Errorf("%s %q: locale %q, Content-Language %q, want %q", tt.url, tt.header, locale, rec.Header().Get("Content-Language"), tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	Add("es", map[string]string{CodeInvalidToken: "Token no válido"})
+	t.Cleanup(func() { delete(Default.messages, "es") })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "es-MX")
+	if got := T(req, CodeInvalidToken); got != "Token no válido" {
+		t.Errorf("T = %q, want the Accept-Language match without Middleware", got)
+	}
+}
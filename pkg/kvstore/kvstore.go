@@ -0,0 +1,259 @@
+// Package kvstore is an embedded, file-backed key-value store built on bbolt
+// for single-binary deployments that need persistence without Redis. Its
+// operations mirror cache.Manager (including cache.ErrNotFound for missing
+// keys), so code written against the shared interfaces can move to Redis
+// later without changes.
+package kvstore
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/cache"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrNotFound is returned when a key does not exist or has expired. It is
+// cache.ErrNotFound, so errors.Is checks work for both stores.
+var ErrNotFound = cache.ErrNotFound
+
+var dataBucket = []byte("kv")
+
+// Config holds embedded store configuration
+type Config struct {
+	Name          string        // Store name
+	Path          string        // Database file, created if missing
+	SweepInterval time.Duration // How often expired keys are purged (default 1m)
+	Timeout       time.Duration // Time to wait for the file lock (default 5s)
+}
+
+// Store is an embedded key-value store
+type Store struct {
+	db     *bolt.DB
+	config Config
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+var (
+	once       sync.Once
+	stores     = make(map[string]*Store)
+	storesLock sync.RWMutex
+	configs    []Config
+)
+
+// Register adds a store configuration to be opened later
+func Register(config Config) error {
+	if config.Name == "" {
+		return fmt.Errorf("kvstore config name cannot be empty")
+	}
+	if config.Path == "" {
+		return fmt.Errorf("kvstore config path cannot be empty")
+	}
+
+	configs = append(configs, config)
+	return nil
+}
+
+// Initialize opens all registered stores
+func Initialize(ctx context.Context) error {
+	var initErr error
+
+	once.Do(func() {
+		for _, config := range configs {
+			store, err := Open(config)
+			if err != nil {
+				initErr = fmt.Errorf("failed to open kvstore '%s': %w", config.Name, err)
+				return
+			}
+
+			storesLock.Lock()
+			stores[config.Name] = store
+			storesLock.Unlock()
+
+			logrus.Infof("Successfully opened kvstore: %s", config.Path)
+		}
+	})
+
+	return initErr
+}
+
+// Get returns a store by name
+func Get(name string) (*Store, error) {
+	storesLock.RLock()
+	defer storesLock.RUnlock()
+
+	store, exists := stores[name]
+	if !exists {
+		return nil, fmt.Errorf("kvstore '%s' not found", name)
+	}
+	return store, nil
+}
+
+// MustGet returns a store by name or panics if not found
+func MustGet(name string) *Store {
+	store, err := Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return store
+}
+
+// Close closes all registered stores
+func Close() error {
+	storesLock.Lock()
+	defer storesLock.Unlock()
+
+	var errs []error
+	for name, store := range stores {
+		if err := store.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close kvstore '%s': %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("errors closing kvstores: %v", errs)
+	}
+	return nil
+}
+
+// Open opens a store outside the registry
+func Open(config Config) (*Store, error) {
+	if config.SweepInterval == 0 {
+		config.SweepInterval = time.Minute
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	if dir := filepath.Dir(config.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := bolt.Open(config.Path, 0o600, &bolt.Options{Timeout: config.Timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(dataBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	s := &Store{db: db, config: config, stop: make(chan struct{})}
+	s.wg.Add(1)
+	go s.sweepLoop()
+	return s, nil
+}
+
+// Close stops the sweeper and closes the database file
+func (s *Store) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+		close(s.stop)
+	}
+	s.wg.Wait()
+	return s.db.Close()
+}
+
+// Ping reports whether the store is open, for health checks
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.View(func(tx *bolt.Tx) error { return nil })
+}
+
+// Health implements healthz.Healthy
+func (s *Store) Health(ctx context.Context) error {
+	return s.Ping(ctx)
+}
+
+func (s *Store) sweepLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.sweep(); err != nil {
+				logrus.Warnf("kvstore '%s': failed to purge expired keys: %v", s.config.Name, err)
+			}
+		}
+	}
+}
+
+// sweep deletes expired keys
+func (s *Store) sweep() error {
+	now := time.Now()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucket)
+
+		// Deleting under the cursor can shift the following key into its
+		// position, which Next would then skip, so collect the keys first
+		var keys [][]byte
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if expired(v, now) {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Values are stored as an 8-byte expiry (unix nanoseconds, 0 for none)
+// followed by the data
+
+func encode(value []byte, ttl time.Duration) []byte {
+	buf := make([]byte, 8+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(buf, uint64(time.Now().Add(ttl).UnixNano()))
+	}
+	copy(buf[8:], value)
+	return buf
+}
+
+func expiry(raw []byte) time.Time {
+	if len(raw) < 8 {
+		return time.Time{}
+	}
+	n := binary.BigEndian.Uint64(raw[:8])
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(n))
+}
+
+func expired(raw []byte, now time.Time) bool {
+	exp := expiry(raw)
+	return !exp.IsZero() && !now.Before(exp)
+}
+
+func payload(raw []byte) []byte {
+	if len(raw) < 8 {
+		return nil
+	}
+	return raw[8:]
+}
@@ -0,0 +1,248 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/cache"
+	"github.com/polymatx/goframe/pkg/session"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store must stay a drop-in for the Redis-backed interfaces
+var (
+	_ session.Backend  = (*Store)(nil)
+	_ auth.KeySetStore = (*Store)(nil)
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(Config{Name: "test", Path: filepath.Join(t.TempDir(), "data", "kv.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestStore_GetSet(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("expected cache.ErrNotFound, got %v", err)
+	}
+
+	if err := s.Set(ctx, "k", "v", 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Get(ctx, "k"); err != nil || v != "v" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	if ttl, _ := s.TTL(ctx, "k"); ttl != -1 {
+		t.Errorf("expected TTL -1 without expiry, got %v", ttl)
+	}
+
+	if ok, _ := s.SetNX(ctx, "k", "other", 0); ok {
+		t.Error("expected SetNX to fail on existing key")
+	}
+	if ok, _ := s.SetNX(ctx, "new", "x", 0); !ok {
+		t.Error("expected SetNX to set missing key")
+	}
+
+	if n, _ := s.Exists(ctx, "k", "new", "missing"); n != 2 {
+		t.Errorf("expected 2 existing keys, got %d", n)
+	}
+
+	if v, err := s.GetDel(ctx, "k"); err != nil || v != "v" {
+		t.Errorf("GetDel = %q, %v", v, err)
+	}
+	if err := s.Del(ctx, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := s.Exists(ctx, "k", "new"); n != 0 {
+		t.Errorf("expected keys to be deleted, %d left", n)
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	_ = s.Set(ctx, "short", "v", 20*time.Millisecond)
+	_ = s.Set(ctx, "long", "v", time.Hour)
+
+	if ttl, _ := s.TTL(ctx, "long"); ttl <= 59*time.Minute {
+		t.Errorf("unexpected TTL %v", ttl)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := s.Get(ctx, "short"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected expired key to be missing, got %v", err)
+	}
+	if ttl, _ := s.TTL(ctx, "short"); ttl != -2 {
+		t.Errorf("expected TTL -2 for expired key, got %v", ttl)
+	}
+	if ok, _ := s.SetNX(ctx, "short", "again", 0); !ok {
+		t.Error("expected SetNX to replace an expired key")
+	}
+
+	_ = s.Set(ctx, "gone", "v", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if err := s.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := s.Keys(ctx, "*"); len(keys) != 2 {
+		t.Errorf("expected expired key to be purged, got %v", keys)
+	}
+
+	if err := s.Expire(ctx, "missing", time.Second); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for Expire on missing key, got %v", err)
+	}
+}
+
+func TestStore_SweepConsecutiveExpiredKeys(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		_ = s.Set(ctx, k, "v", time.Millisecond)
+	}
+	_ = s.Set(ctx, "e", "v", 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if err := s.sweep(); err != nil {
+		t.Fatal(err)
+	}
+
+	var left []string
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(dataBucket).ForEach(func(k, _ []byte) error {
+			left = append(left, string(k))
+			return nil
+		})
+	})
+	if len(left) != 1 || left[0] != "e" {
+		t.Errorf("expected only e to survive the sweep, got %v", left)
+	}
+}
+
+func TestStore_Counters(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	if n, _ := s.Incr(ctx, "hits"); n != 1 {
+		t.Errorf("expected 1, got %d", n)
+	}
+	if n, _ := s.IncrBy(ctx, "hits", 9); n != 10 {
+		t.Errorf("expected 10, got %d", n)
+	}
+	if n, _ := s.Decr(ctx, "hits"); n != 9 {
+		t.Errorf("expected 9, got %d", n)
+	}
+
+	_ = s.Set(ctx, "text", "abc", 0)
+	if _, err := s.Incr(ctx, "text"); err == nil {
+		t.Error("expected error incrementing a non-integer")
+	}
+}
+
+func TestStore_KeysAndJSON(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	type user struct{ Name string }
+	_ = s.SetJSON(ctx, "user:1", user{Name: "ann"}, 0)
+	_ = s.SetJSON(ctx, "user:2", user{Name: "bob"}, 0)
+	_ = s.Set(ctx, "session:1", "x", 0)
+
+	keys, err := s.Keys(ctx, "user:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "user:1" || keys[1] != "user:2" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	var u user
+	if err := s.GetJSON(ctx, "user:2", &u); err != nil || u.Name != "bob" {
+		t.Errorf("GetJSON = %+v, %v", u, err)
+	}
+}
+
+func TestStore_Queue(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	_ = s.RPush(ctx, "jobs", "a", "b")
+	_ = s.RPush(ctx, "jobs", "c")
+	if n, _ := s.LLen(ctx, "jobs"); n != 3 {
+		t.Errorf("expected 3 jobs, got %d", n)
+	}
+
+	for _, want := range []string{"a", "b", "c"} {
+		if got, err := s.LPop(ctx, "jobs"); err != nil || got != want {
+			t.Errorf("LPop = %q, %v; want %q", got, err, want)
+		}
+	}
+	if _, err := s.LPop(ctx, "jobs"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound on empty queue, got %v", err)
+	}
+	if n, _ := s.Exists(ctx, "jobs"); n != 0 {
+		t.Error("expected empty list to be deleted")
+	}
+}
+
+func TestStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.db")
+	ctx := context.Background()
+
+	s, err := Open(Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Set(ctx, "k", "persisted", 0)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if v, err := s.Get(ctx, "k"); err != nil || v != "persisted" {
+		t.Errorf("Get after reopen = %q, %v", v, err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	if err := Register(Config{Name: ""}); err == nil {
+		t.Error("expected error for empty name")
+	}
+	if err := Register(Config{Name: "reg", Path: filepath.Join(t.TempDir(), "reg.db")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	s, err := Get("reg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Health(context.Background()); err != nil {
+		t.Errorf("expected healthy store, got %v", err)
+	}
+	if _, err := Get("missing"); err == nil {
+		t.Error("expected error for unknown store")
+	}
+}
@@ -0,0 +1,256 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Set stores a key-value pair; a ttl of 0 means no expiry
+func (s *Store) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(dataBucket).Put([]byte(key), encode([]byte(value), ttl))
+	})
+}
+
+// SetNX sets a key only if it doesn't exist (atomic)
+func (s *Store) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	set := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucket)
+		if raw := b.Get([]byte(key)); raw != nil && !expired(raw, time.Now()) {
+			return nil
+		}
+		set = true
+		return b.Put([]byte(key), encode([]byte(value), ttl))
+	})
+	return set, err
+}
+
+// Get retrieves a value by key, returning ErrNotFound when missing or expired
+func (s *Store) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(dataBucket).Get([]byte(key))
+		if raw == nil || expired(raw, time.Now()) {
+			return ErrNotFound
+		}
+		value = string(payload(raw))
+		return nil
+	})
+	return value, err
+}
+
+// GetDel atomically gets and deletes a key
+func (s *Store) GetDel(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucket)
+		raw := b.Get([]byte(key))
+		if raw == nil || expired(raw, time.Now()) {
+			return ErrNotFound
+		}
+		value = string(payload(raw))
+		return b.Delete([]byte(key))
+	})
+	return value, err
+}
+
+// Del deletes one or more keys
+func (s *Store) Del(ctx context.Context, keys ...string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucket)
+		for _, key := range keys {
+			if err := b.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Exists returns how many of the keys exist
+func (s *Store) Exists(ctx context.Context, keys ...string) (int64, error) {
+	var n int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucket)
+		now := time.Now()
+		for _, key := range keys {
+			if raw := b.Get([]byte(key)); raw != nil && !expired(raw, now) {
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Expire sets a timeout on an existing key
+func (s *Store) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucket)
+		raw := b.Get([]byte(key))
+		if raw == nil || expired(raw, time.Now()) {
+			return ErrNotFound
+		}
+		return b.Put([]byte(key), encode(payload(raw), ttl))
+	})
+}
+
+// TTL returns the remaining time to live of a key. Like Redis it returns -1
+// for keys without expiry and -2 for missing keys.
+func (s *Store) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl := time.Duration(-2)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(dataBucket).Get([]byte(key))
+		now := time.Now()
+		if raw == nil || expired(raw, now) {
+			return nil
+		}
+		if exp := expiry(raw); exp.IsZero() {
+			ttl = -1
+		} else {
+			ttl = exp.Sub(now)
+		}
+		return nil
+	})
+	return ttl, err
+}
+
+// Incr increments the integer value of a key by one
+func (s *Store) Incr(ctx context.Context, key string) (int64, error) {
+	return s.IncrBy(ctx, key, 1)
+}
+
+// IncrBy increments the integer value of a key, keeping its expiry
+func (s *Store) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	var n int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucket)
+		raw := b.Get([]byte(key))
+
+		var ttl time.Duration
+		if raw != nil && !expired(raw, time.Now()) {
+			current, err := strconv.ParseInt(string(payload(raw)), 10, 64)
+			if err != nil {
+				return err
+			}
+			n = current
+			if exp := expiry(raw); !exp.IsZero() {
+				ttl = time.Until(exp)
+			}
+		}
+
+		n += value
+		return b.Put([]byte(key), encode([]byte(strconv.FormatInt(n, 10)), ttl))
+	})
+	return n, err
+}
+
+// Decr decrements the integer value of a key by one
+func (s *Store) Decr(ctx context.Context, key string) (int64, error) {
+	return s.IncrBy(ctx, key, -1)
+}
+
+// SetJSON stores a JSON-encoded value
+func (s *Store) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.Set(ctx, key, string(data), ttl)
+}
+
+// GetJSON retrieves and decodes a JSON value
+func (s *Store) GetJSON(ctx context.Context, key string, dest interface{}) error {
+	data, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), dest)
+}
+
+// Keys returns keys matching a glob pattern such as "session:*"
+func (s *Store) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		now := time.Now()
+		return tx.Bucket(dataBucket).ForEach(func(k, v []byte) error {
+			if expired(v, now) {
+				return nil
+			}
+			if ok, _ := path.Match(pattern, string(k)); ok {
+				keys = append(keys, string(k))
+			}
+			return nil
+		})
+	})
+	return keys, err
+}
+
+// RPush appends values to a list, e.g. a small job queue
+func (s *Store) RPush(ctx context.Context, key string, values ...string) error {
+	return s.updateList(key, func(list []string) ([]string, error) {
+		return append(list, values...), nil
+	})
+}
+
+// LPop removes and returns the first element of a list
+func (s *Store) LPop(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.updateList(key, func(list []string) ([]string, error) {
+		if len(list) == 0 {
+			return nil, ErrNotFound
+		}
+		value = list[0]
+		return list[1:], nil
+	})
+	return value, err
+}
+
+// LLen returns the length of a list
+func (s *Store) LLen(ctx context.Context, key string) (int64, error) {
+	var list []string
+	err := s.GetJSON(ctx, key, &list)
+	if err == ErrNotFound {
+		return 0, nil
+	}
+	return int64(len(list)), err
+}
+
+// updateList stores lists as JSON arrays, which suits the small queues an
+// embedded deployment needs; empty lists are deleted like in Redis
+func (s *Store) updateList(key string, fn func([]string) ([]string, error)) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucket)
+
+		var list []string
+		var ttl time.Duration
+		if raw := b.Get([]byte(key)); raw != nil && !expired(raw, time.Now()) {
+			if err := json.Unmarshal(payload(raw), &list); err != nil {
+				return err
+			}
+			if exp := expiry(raw); !exp.IsZero() {
+				ttl = time.Until(exp)
+			}
+		}
+
+		list, err := fn(list)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return b.Delete([]byte(key))
+		}
+
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), encode(data, ttl))
+	})
+}
@@ -0,0 +1,403 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/xlog"
+	"golang.org/x/sync/singleflight"
+)
+
+// maxCachedBody is the largest response body the cache stores
+const maxCachedBody = 1 << 20
+
+// CacheStore holds cached responses. Get reports ok false for missing or
+// expired keys.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// CacheKeyFunc returns the cache key of a request, or "" to bypass the cache
+type CacheKeyFunc func(r *http.Request) string
+
+// URLCacheKey keys responses by host, path and query, with the query
+// parameters sorted
+func URLCacheKey(r *http.Request) string {
+	return r.Host + r.URL.Path + "?" + r.URL.Query().Encode()
+}
+
+// ResponseCache caches full GET responses. Responses are stored per variant
+// of the headers named in their Vary header, and concurrent misses of the
+// same variant run the handler once.
+type ResponseCache struct {
+	store CacheStore
+	ttl   time.Duration
+	key   CacheKeyFunc
+	group singleflight.Group
+}
+
+// NewResponseCache creates a response cache storing entries for ttl. keyFunc
+// defaults to URLCacheKey.
+func NewResponseCache(store CacheStore, ttl time.Duration, keyFunc CacheKeyFunc) *ResponseCache {
+	if keyFunc == nil {
+		keyFunc = URLCacheKey
+	}
+	return &ResponseCache{store: store, ttl: ttl, key: keyFunc}
+}
+
+// Cache middleware caches GET responses in store for ttl. Use
+// NewResponseCache to keep a handle for invalidation.
+func Cache(store CacheStore, ttl time.Duration, keyFunc CacheKeyFunc) func(http.Handler) http.Handler {
+	return NewResponseCache(store, ttl, keyFunc).Middleware()
+}
+
+// Invalidate drops the cached responses of the given keys, all variants
+// included
+func (c *ResponseCache) Invalidate(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.store.Delete(ctx, keys...)
+}
+
+// cachedResponse is a stored response
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Stored time.Time   `json:"stored"`
+}
+
+// cacheIndex is stored under the key itself and names the request headers
+// responses vary on. Variants live under keys carrying the generation, so
+// deleting the index invalidates all of them at once.
+type cacheIndex struct {
+	Vary []string `json:"vary"`
+	Gen  string   `json:"gen"`
+}
+
+func (i *cacheIndex) variantKey(key string, r *http.Request) string {
+	h := sha256.New()
+	for _, name := range i.Vary {
+		h.Write([]byte(strings.Join(r.Header.Values(name), ",")))
+		h.Write([]byte{0})
+	}
+	return key + "#" + i.Gen + ":" + hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// flight is the outcome of a handler run shared with concurrent misses
+type flight struct {
+	res      *cachedResponse
+	req      *http.Request
+	vary     []string
+	storable bool
+}
+
+// Middleware returns the caching middleware. Requests with an Authorization
+// or Cookie header bypass the cache, since their responses may be
+// personalized, and responses marked private, no-store or
+// no-cache, setting cookies or varying on * are not stored. The CacheTTL of
+// the route metadata replaces the TTL, a negative one disabling caching.
+func (c *ResponseCache) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.Header.Get("Authorization") != "" ||
+				r.Header.Get("Cookie") != "" || r.Header.Get("Accept") == "text/event-stream" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ttl := c.ttl
+			if routeTTL := GetRouteMeta(r.Context()).CacheTTL; routeTTL != 0 {
+				ttl = routeTTL
+			}
+			key := c.key(r)
+			if key == "" || ttl < 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			idx := c.loadIndex(ctx, key)
+			flightKey := key
+			if idx != nil {
+				flightKey = idx.variantKey(key, r)
+				if res := c.loadResponse(ctx, flightKey); res != nil {
+					writeCached(w, res, "HIT")
+					return
+				}
+			}
+
+			led := false
+			v, _, _ := c.group.Do(flightKey, func() (interface{}, error) {
+				led = true
+				rec := &cacheRecorder{header: make(http.Header)}
+				next.ServeHTTP(rec, r)
+
+				f := &flight{res: rec.response(), req: r}
+				f.vary, f.storable = storable(f.res, rec.overflow)
+				if f.storable {
+					c.save(ctx, key, idx, f, ttl)
+				}
+				return f, nil
+			})
+			f := v.(*flight)
+
+			if !led && (!f.storable || !sameVariant(f.vary, f.req, r)) {
+				// The shared response does not fit this request
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeCached(w, f.res, "MISS")
+		})
+	}
+}
+
+func (c *ResponseCache) loadIndex(ctx context.Context, key string) *cacheIndex {
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		xlog.GetWithError(ctx, err).Warn("response cache lookup failed")
+		return nil
+	}
+	var idx cacheIndex
+	if !ok || json.Unmarshal(data, &idx) != nil {
+		return nil
+	}
+	return &idx
+}
+
+func (c *ResponseCache) loadResponse(ctx context.Context, key string) *cachedResponse {
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		xlog.GetWithError(ctx, err).Warn("response cache lookup failed")
+		return nil
+	}
+	var res cachedResponse
+	if !ok || json.Unmarshal(data, &res) != nil {
+		return nil
+	}
+	return &res
+}
+
+// save stores the response under a variant of the index, replacing the
+// index when the response varies on other headers
+func (c *ResponseCache) save(ctx context.Context, key string, idx *cacheIndex, f *flight, ttl time.Duration) {
+	if idx == nil || !slices.Equal(idx.Vary, f.vary) {
+		idx = &cacheIndex{Vary: f.vary, Gen: newCacheGeneration()}
+	}
+	index, _ := json.Marshal(idx)
+	data, err := json.Marshal(f.res)
+	if err == nil {
+		err = c.store.Set(ctx, idx.variantKey(key, f.req), data, ttl)
+	}
+	if err == nil {
+		err = c.store.Set(ctx, key, index, ttl)
+	}
+	if err != nil {
+		xlog.GetWithError(ctx, err).Warn("response cache store failed")
+	}
+}
+
+func newCacheGeneration() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// storable reports whether a response may be shared, and the request
+// headers it varies on
+func storable(res *cachedResponse, overflow bool) ([]string, bool) {
+	switch res.Status {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return nil, false
+	}
+	if overflow || len(res.Header.Values("Set-Cookie")) > 0 {
+		return nil, false
+	}
+	cc := strings.ToLower(strings.Join(res.Header.Values("Cache-Control"), ","))
+	for _, directive := range []string{"private", "no-store", "no-cache"} {
+		if strings.Contains(cc, directive) {
+			return nil, false
+		}
+	}
+
+	var vary []string
+	for _, v := range res.Header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" && !slices.Contains(vary, name) {
+				vary = append(vary, name)
+			}
+		}
+	}
+	slices.Sort(vary)
+	return vary, true
+}
+
+func sameVariant(vary []string, a, b *http.Request) bool {
+	for _, name := range vary {
+		if !slices.Equal(a.Header.Values(name), b.Header.Values(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeCached(w http.ResponseWriter, res *cachedResponse, status string) {
+	header := w.Header()
+	for k, v := range res.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Set("X-Cache", status)
+	if status == "HIT" {
+		header.Set("Age", strconv.Itoa(int(time.Since(res.Stored).Seconds())))
+	}
+	w.WriteHeader(res.Status)
+	_, _ = w.Write(res.Body)
+}
+
+// cacheRecorder buffers the handler response
+type cacheRecorder struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	overflow    bool
+	wroteHeader bool
+}
+
+func (r *cacheRecorder) Header() http.Header { return r.header }
+
+func (r *cacheRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+}
+
+func (r *cacheRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	if r.body.Len()+len(b) > maxCachedBody {
+		r.overflow = true
+	}
+	return r.body.Write(b)
+}
+
+func (r *cacheRecorder) response() *cachedResponse {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return &cachedResponse{
+		Status: r.status,
+		Header: r.header.Clone(),
+		Body:   r.body.Bytes(),
+		Stored: time.Now(),
+	}
+}
+
+// MemoryCacheStore is an in-process CacheStore evicting the least recently
+// used entries beyond its capacity
+type MemoryCacheStore struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCacheStore creates an LRU store holding up to maxEntries entries
+// (default 1000)
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryCacheStore{
+		max:     maxEntries,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get implements CacheStore
+func (s *MemoryCacheStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		s.remove(el)
+		return nil, false, nil
+	}
+	s.order.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+// Set implements CacheStore
+func (s *MemoryCacheStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	if el, ok := s.entries[key]; ok {
+		el.Value = entry
+		s.order.MoveToFront(el)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.max {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+// Delete implements CacheStore
+func (s *MemoryCacheStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := s.entries[key]; ok {
+			s.remove(el)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, expired ones included until evicted
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *MemoryCacheStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryCacheEntry).key)
+}
@@ -3,48 +3,304 @@ package middleware
 import (
 	"compress/gzip"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
 )
 
-type gzipResponseWriter struct {
-	io.Writer
-	http.ResponseWriter
-	wroteHeader bool
+// Content encodings supported by Compress
+const (
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+)
+
+// CompressConfig holds compression configuration
+type CompressConfig struct {
+	// Encodings are the encodings offered, preferred first when the client
+	// accepts several equally (default br, gzip)
+	Encodings []string
+
+	GzipLevel   int // 1-9 (default gzip.DefaultCompression)
+	BrotliLevel int // 1-11 (default 4, fast enough for dynamic responses)
+
+	// MinSize is the smallest body compressed; smaller bodies are sent as
+	// is, since compression gains little on them (default 0, all bodies)
+	MinSize int
+
+	// ContentTypes are the media type prefixes compressed (default text,
+	// JSON, JavaScript, XML and SVG, including +json and +xml types)
+	ContentTypes []string
 }
 
-func (w *gzipResponseWriter) WriteHeader(code int) {
-	if !w.wroteHeader {
-		w.ResponseWriter.Header().Del("Content-Length")
-		w.wroteHeader = true
-	}
-	w.ResponseWriter.WriteHeader(code)
+// Compress middleware compresses responses with gzip or brotli with
+// default configuration
+func Compress() func(http.Handler) http.Handler {
+	return Describe(Descriptor{Name: "middleware.Compress"}, CompressWith(CompressConfig{}))
 }
 
-func (w *gzipResponseWriter) Write(b []byte) (int, error) {
-	if !w.wroteHeader {
-		w.WriteHeader(http.StatusOK)
+// CompressWith middleware compresses responses with the encoding the
+// client prefers among those of Accept-Encoding. Responses of other content
+// types, already encoded ones, and those without a body pass through.
+func CompressWith(config CompressConfig) func(http.Handler) http.Handler {
+	if len(config.Encodings) == 0 {
+		config.Encodings = []string{EncodingBrotli, EncodingGzip}
+	}
+	if config.GzipLevel < gzip.HuffmanOnly || config.GzipLevel == gzip.NoCompression || config.GzipLevel > gzip.BestCompression {
+		config.GzipLevel = gzip.DefaultCompression
+	}
+	if config.BrotliLevel <= 0 || config.BrotliLevel > brotli.BestCompression {
+		config.BrotliLevel = 4
+	}
+	if len(config.ContentTypes) == 0 {
+		config.ContentTypes = []string{
+			"text/", "application/json", "+json", "application/javascript",
+			"application/xml", "+xml", "image/svg+xml",
+		}
+	}
+
+	pools := map[string]*sync.Pool{
+		EncodingGzip: {New: func() interface{} {
+			gz, _ := gzip.NewWriterLevel(io.Discard, config.GzipLevel)
+			return gz
+		}},
+		EncodingBrotli: {New: func() interface{} {
+			return brotli.NewWriterLevel(io.Discard, config.BrotliLevel)
+		}},
 	}
-	return w.Writer.Write(b)
-}
 
-// Compress middleware compresses HTTP responses using gzip
-func Compress() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Check if client accepts gzip
-			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			// Upgraded connections such as WebSockets are not HTTP bodies
+			if r.Header.Get("Upgrade") != "" {
 				next.ServeHTTP(w, r)
 				return
 			}
+			w.Header().Add("Vary", "Accept-Encoding")
 
-			// Create gzip writer
-			w.Header().Set("Content-Encoding", "gzip")
-			gz := gzip.NewWriter(w)
-			defer gz.Close()
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), config.Encodings)
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-			gzw := &gzipResponseWriter{Writer: gz, ResponseWriter: w}
-			next.ServeHTTP(gzw, r)
+			cw := &compressWriter{ResponseWriter: w, config: &config, encoding: encoding, pool: pools[encoding]}
+			defer cw.finish()
+			next.ServeHTTP(cw, r)
 		})
 	}
 }
+
+// compressor is implemented by gzip.Writer and brotli.Writer
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type compressMode int
+
+const (
+	compressPending     compressMode = iota // Header not written yet
+	compressBuffering                       // Holding the body until MinSize
+	compressing                             // Writing through the compressor
+	compressPassthrough                     // Writing uncompressed
+)
+
+type compressWriter struct {
+	http.ResponseWriter
+	config   *CompressConfig
+	encoding string
+	pool     *sync.Pool
+
+	mode   compressMode
+	status int
+	buf    []byte
+	cw     compressor
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.mode != compressPending || w.status != 0 {
+		return
+	}
+	// Informational responses precede the final one
+	if code >= 100 && code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	// Without a Content-Type the first write is sniffed for one
+	if w.Header().Get("Content-Type") != "" {
+		w.decide()
+	}
+}
+
+// decide starts compressing, buffering or passing the response through
+func (w *compressWriter) decide() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	code := w.status
+	h := w.Header()
+	size := -1
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil {
+		size = n
+	}
+	switch {
+	case code == http.StatusNoContent || code == http.StatusNotModified || code == http.StatusPartialContent,
+		h.Get("Content-Encoding") != "",
+		!w.compressible(h.Get("Content-Type")),
+		size >= 0 && size < w.config.MinSize:
+		w.passthrough()
+	case w.config.MinSize <= 0 || size >= w.config.MinSize:
+		w.start()
+	default:
+		w.mode = compressBuffering
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.mode == compressPending {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.decide()
+	}
+
+	switch w.mode {
+	case compressBuffering:
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.config.MinSize {
+			return len(b), nil
+		}
+		buf := w.buf
+		w.buf = nil
+		w.start()
+		if _, err := w.cw.Write(buf); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	case compressing:
+		return w.cw.Write(b)
+	default:
+		return w.ResponseWriter.Write(b)
+	}
+}
+
+// Flush writes buffered compressed data to the client, so streamed responses
+// are not held back by the compressor or MinSize
+func (w *compressWriter) Flush() {
+	if w.mode == compressPending {
+		w.decide()
+	}
+	if w.mode == compressBuffering {
+		buf := w.buf
+		w.buf = nil
+		w.start()
+		_, _ = w.cw.Write(buf)
+	}
+	if w.mode == compressing {
+		_ = w.cw.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap supports http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// compressible reports whether responses of contentType are compressed
+func (w *compressWriter) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range w.config.ContentTypes {
+		if strings.HasPrefix(mediaType, t) || (strings.HasPrefix(t, "+") && strings.HasSuffix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// start writes the header and compresses the rest of the body
+func (w *compressWriter) start() {
+	w.mode = compressing
+	h := w.Header()
+	h.Set("Content-Encoding", w.encoding)
+	h.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.cw = w.pool.Get().(compressor)
+	w.cw.Reset(w.ResponseWriter)
+}
+
+func (w *compressWriter) passthrough() {
+	w.mode = compressPassthrough
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// finish completes the compressed stream, or sends a body below MinSize
+// as is
+func (w *compressWriter) finish() {
+	switch w.mode {
+	case compressPending:
+		if w.status != 0 {
+			w.passthrough()
+		}
+	case compressBuffering:
+		w.passthrough()
+		_, _ = w.ResponseWriter.Write(w.buf)
+	case compressing:
+		_ = w.cw.Close()
+		w.cw.Reset(io.Discard)
+		w.pool.Put(w.cw)
+		w.cw = nil
+	}
+}
+
+// negotiateEncoding returns the encoding of offered with the highest
+// quality in acceptEncoding, or "" when none is acceptable
+func negotiateEncoding(acceptEncoding string, offered []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			wildcard = q
+		} else {
+			qualities[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range offered {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons requests are shed, the reason label of
+// http_shed_requests_total
+const (
+	ShedQueueFull = "queue_full" // Every slot and queue place taken
+	ShedTimeout   = "timeout"    // Queued for longer than QueueTimeout
+)
+
+// ConcurrencyConfig holds concurrency limiter configuration
+type ConcurrencyConfig struct {
+	// MaxInFlight caps the requests served at once through the middleware;
+	// 0 leaves them unlimited
+	MaxInFlight int
+
+	// PerRoute caps the requests served at once by each route the
+	// middleware wraps, when installed on a group; 0 leaves them unlimited
+	PerRoute int
+
+	// QueueDepth is how many requests may wait for a slot, per limit,
+	// before more are shed (default 0, shed at once)
+	QueueDepth int
+
+	// QueueTimeout is how long a request waits for a slot (default 1s)
+	QueueTimeout time.Duration
+
+	// StatusCode of shed requests, 503 or 429 (default 503)
+	StatusCode int
+
+	// RetryAfter is sent with shed requests (default 1s)
+	RetryAfter time.Duration
+
+	// Name labels the metrics of the limiter (default "default")
+	Name string
+
+	// Registry the metrics are registered in (default the global
+	// Prometheus registry)
+	Registry *prometheus.Registry
+}
+
+// ConcurrencyLimit middleware caps in-flight requests, queueing those over
+// the limit up to QueueDepth for QueueTimeout and shedding the rest with
+// StatusCode and Retry-After. It reports http_inflight_requests,
+// http_queued_requests and http_shed_requests_total by limiter name.
+func ConcurrencyLimit(config ConcurrencyConfig) func(http.Handler) http.Handler {
+	if config.QueueTimeout <= 0 {
+		config.QueueTimeout = time.Second
+	}
+	if config.StatusCode == 0 {
+		config.StatusCode = http.StatusServiceUnavailable
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = time.Second
+	}
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	if config.Registry != nil {
+		registerer = config.Registry
+	}
+
+	inFlight := register(registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_inflight_requests",
+		Help: "HTTP requests being served under a concurrency limit",
+	}, []string{"limiter"})).WithLabelValues(config.Name)
+	queued := register(registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_queued_requests",
+		Help: "HTTP requests waiting for a concurrency limit slot",
+	}, []string{"limiter"})).WithLabelValues(config.Name)
+	shed := register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_shed_requests_total",
+		Help: "HTTP requests rejected by a concurrency limit",
+	}, []string{"limiter", "reason"}))
+
+	global := newSemaphore(config.MaxInFlight, config.QueueDepth)
+	retryAfter := strconv.Itoa(max(1, ceilSeconds(config.RetryAfter)))
+
+	return func(next http.Handler) http.Handler {
+		route := newSemaphore(config.PerRoute, config.QueueDepth)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The narrower route limit is taken first, so requests queued on
+			// a busy route do not hold global slots
+			for _, sem := range []*semaphore{route, global} {
+				if sem == nil {
+					continue
+				}
+				// A slot taken is released by its defer, also when the next
+				// limit sheds the request
+				reason, err := sem.acquire(r.Context(), config.QueueTimeout, queued)
+				if err != nil {
+					// The client is gone; there is no one to answer
+					return
+				}
+				if reason != "" {
+					shed.WithLabelValues(config.Name, reason).Inc()
+					w.Header().Set("Retry-After", retryAfter)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(config.StatusCode)
+					_, _ = w.Write([]byte(`{"error":"Server is busy, retry later"}`))
+					return
+				}
+				defer sem.release()
+			}
+
+			inFlight.Inc()
+			defer inFlight.Dec()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// semaphore bounds concurrent holders, with a bounded queue of waiters
+type semaphore struct {
+	slots   chan struct{}
+	depth   int64
+	waiting atomic.Int64
+}
+
+// newSemaphore returns nil for no limit
+func newSemaphore(limit, depth int) *semaphore {
+	if limit <= 0 {
+		return nil
+	}
+	return &semaphore{slots: make(chan struct{}, limit), depth: int64(depth)}
+}
+
+// acquire takes a slot, or returns why the request is shed. The error is
+// that of ctx when the request is canceled while queued.
+func (s *semaphore) acquire(ctx context.Context, timeout time.Duration, queued prometheus.Gauge) (string, error) {
+	select {
+	case s.slots <- struct{}{}:
+		return "", nil
+	default:
+	}
+
+	if s.waiting.Add(1) > s.depth {
+		s.waiting.Add(-1)
+		return ShedQueueFull, nil
+	}
+	queued.Inc()
+	defer func() {
+		s.waiting.Add(-1)
+		queued.Dec()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		return "", nil
+	case <-timer.C:
+		return ShedTimeout, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (s *semaphore) release() {
+	<-s.slots
+}
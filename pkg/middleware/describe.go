@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	fwmiddleware "github.com/polymatx/goframe/pkg/framework/middleware"
+)
+
+// Descriptor tells route listings and App.MiddlewareChains what middleware
+// is. Describe custom authentication middleware so the order checks know
+// it sets the claims:
+//
+//	sso := middleware.Describe(middleware.Descriptor{Name: "sso.Auth", Authenticates: true}, ssoAuth)
+type Descriptor = fwmiddleware.Descriptor
+
+// Describe attaches d to middleware m
+func Describe(d Descriptor, m func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return fwmiddleware.Describe(d, m)
+}
+
+// DescriptorOf returns the descriptor attached to m with Describe, false
+// for other middleware
+func DescriptorOf(m func(http.Handler) http.Handler) (Descriptor, bool) {
+	return fwmiddleware.DescriptorOf(m)
+}
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ETagConfig holds ETag configuration
+type ETagConfig struct {
+	Weak bool // Send weak validators, W/"...", for bodies equivalent but not byte-identical
+
+	// ContentTypes are the media type prefixes given ETags (default JSON,
+	// including +json types)
+	ContentTypes []string
+
+	// MaxSize is the largest body buffered for hashing (default 1MB).
+	// Larger responses are streamed without an ETag.
+	MaxSize int
+}
+
+// ETag middleware adds ETags to JSON responses with default configuration
+func ETag() func(http.Handler) http.Handler {
+	return ETagWith(ETagConfig{})
+}
+
+// ETagWith middleware hashes 200 responses to GET and HEAD requests as they
+// are written and sets their ETag, answering a matching If-None-Match with
+// 304 Not Modified, so polling clients skip unchanged bodies. Handlers that
+// set an ETag themselves, e.g. from a row version, are not buffered and
+// their ETag is compared instead.
+func ETagWith(config ETagConfig) func(http.Handler) http.Handler {
+	if len(config.ContentTypes) == 0 {
+		config.ContentTypes = []string{"application/json", "+json"}
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = 1 << 20
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w, config: &config, ifNoneMatch: r.Header.Get("If-None-Match")}
+			next.ServeHTTP(ew, r)
+			ew.finish()
+		})
+	}
+}
+
+type etagMode int
+
+const (
+	etagPending     etagMode = iota // Header not written yet
+	etagBuffering                   // Hashing and buffering the body
+	etagPassthrough                 // Writing through
+	etagDiscard                     // 304 sent; dropping the body
+)
+
+type etagWriter struct {
+	http.ResponseWriter
+	config      *ETagConfig
+	ifNoneMatch string
+
+	mode   etagMode
+	status int
+	buf    bytes.Buffer
+	hash   hash.Hash64
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.mode != etagPending {
+		return
+	}
+	w.status = code
+
+	h := w.Header()
+	switch {
+	case code != http.StatusOK || !w.tagged(h.Get("Content-Type")):
+		w.mode = etagPassthrough
+		w.ResponseWriter.WriteHeader(code)
+	case h.Get("ETag") != "":
+		if etagMatch(w.ifNoneMatch, h.Get("ETag")) {
+			w.notModified()
+			return
+		}
+		w.mode = etagPassthrough
+		w.ResponseWriter.WriteHeader(code)
+	default:
+		w.mode = etagBuffering
+		w.hash = fnv.New64a()
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.mode == etagPending {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	switch w.mode {
+	case etagDiscard:
+		return len(b), nil
+	case etagBuffering:
+		_, _ = w.hash.Write(b)
+		w.buf.Write(b)
+		if w.buf.Len() > w.config.MaxSize {
+			return len(b), w.stream()
+		}
+		return len(b), nil
+	default:
+		return w.ResponseWriter.Write(b)
+	}
+}
+
+// Flush gives up on the ETag, since a flushing handler streams its response
+func (w *etagWriter) Flush() {
+	switch w.mode {
+	case etagPending:
+		w.WriteHeader(http.StatusOK)
+		if w.mode == etagBuffering {
+			_ = w.stream()
+		}
+	case etagBuffering:
+		_ = w.stream()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && w.mode == etagPassthrough {
+		f.Flush()
+	}
+}
+
+// Unwrap supports http.ResponseController
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// tagged reports whether responses of contentType get an ETag
+func (w *etagWriter) tagged(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range w.config.ContentTypes {
+		if strings.HasPrefix(mediaType, t) || (strings.HasPrefix(t, "+") && strings.HasSuffix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// stream writes the buffered body and passes the rest through
+func (w *etagWriter) stream() error {
+	w.mode = etagPassthrough
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf = bytes.Buffer{}
+	return err
+}
+
+func (w *etagWriter) notModified() {
+	w.mode = etagDiscard
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(http.StatusNotModified)
+}
+
+// finish sends a buffered response, or 304 when it matches If-None-Match
+func (w *etagWriter) finish() {
+	if w.mode == etagPending {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.mode != etagBuffering {
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x"`, w.hash.Sum64())
+	if w.config.Weak {
+		etag = "W/" + etag
+	}
+	w.Header().Set("ETag", etag)
+	if etagMatch(w.ifNoneMatch, etag) {
+		w.notModified()
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+}
+
+// etagMatch reports whether an If-None-Match header matches etag, with the
+// weak comparison RFC 9110 requires for it
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/xlog"
+)
+
+// FlagTarget is who a feature flag is evaluated for
+type FlagTarget struct {
+	User   string
+	Tenant string
+}
+
+// FlagSource decides whether feature flags are on
+type FlagSource interface {
+	Enabled(ctx context.Context, flag string, target FlagTarget) (bool, error)
+}
+
+// FlagFunc adapts a function to FlagSource, e.g. to call a flag service SDK
+type FlagFunc func(ctx context.Context, flag string, target FlagTarget) (bool, error)
+
+// Enabled calls f
+func (f FlagFunc) Enabled(ctx context.Context, flag string, target FlagTarget) (bool, error) {
+	return f(ctx, flag, target)
+}
+
+// FlagRule enables a flag for everyone, listed users and tenants, or a
+// percentage of them
+type FlagRule struct {
+	Enabled bool
+	Users   []string
+	Tenants []string
+	Percent int // Share of users, or tenants for requests without a user
+}
+
+// StaticFlags is a FlagSource with fixed rules, e.g. loaded with
+// viper.UnmarshalKey("features", &flags). Unknown flags are off.
+type StaticFlags map[string]FlagRule
+
+// Enabled reports whether the rule of flag matches target
+func (s StaticFlags) Enabled(_ context.Context, flag string, target FlagTarget) (bool, error) {
+	rule, ok := s[flag]
+	if !ok {
+		return false, nil
+	}
+	if rule.Enabled ||
+		(target.User != "" && slices.Contains(rule.Users, target.User)) ||
+		(target.Tenant != "" && slices.Contains(rule.Tenants, target.Tenant)) {
+		return true, nil
+	}
+
+	key := target.User
+	if key == "" {
+		key = target.Tenant
+	}
+	if rule.Percent <= 0 || key == "" {
+		return false, nil
+	}
+	// Hashing with the flag name keeps rollouts independent across flags
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(flag + ":" + key))
+	return int(h.Sum32()%100) < rule.Percent, nil
+}
+
+var (
+	flagSourceMu sync.RWMutex
+	flagSource   FlagSource = StaticFlags{}
+)
+
+// SetFlagSource sets the source Feature uses
+func SetFlagSource(source FlagSource) {
+	flagSourceMu.Lock()
+	defer flagSourceMu.Unlock()
+	flagSource = source
+}
+
+func getFlagSource() FlagSource {
+	flagSourceMu.RLock()
+	defer flagSourceMu.RUnlock()
+	return flagSource
+}
+
+// FeatureConfig holds feature gating configuration
+type FeatureConfig struct {
+	Source FlagSource // Default the source set with SetFlagSource
+	Status int        // Response while the flag is off: 404 (default) hides the route, 403 admits it exists
+
+	// Target resolves who the flag is evaluated for. By default the user is
+	// the authenticated user and the tenant the "tenant_id" claim.
+	Target func(r *http.Request) FlagTarget
+
+	// FailOpen serves requests when the source fails; by default they get
+	// Status
+	FailOpen bool
+}
+
+// Feature middleware serves the route only while flag is on for the user
+// or tenant of the request, so unreleased endpoints can ship dark and be
+// enabled progressively
+func Feature(flag string) func(http.Handler) http.Handler {
+	return FeatureWith(flag, FeatureConfig{})
+}
+
+// FeatureWith is Feature with custom configuration. With an empty flag it
+// gates each route on the feature flag declared in its metadata, and routes
+// without one pass.
+func FeatureWith(flag string, config FeatureConfig) func(http.Handler) http.Handler {
+	if config.Status == 0 {
+		config.Status = http.StatusNotFound
+	}
+	if config.Target == nil {
+		config.Target = claimsTarget
+	}
+	body := `{"error":"Not Found"}`
+	if config.Status == http.StatusForbidden {
+		body = `{"error":"Feature not enabled"}`
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := flag
+			if name == "" {
+				if name = GetRouteMeta(r.Context()).Feature; name == "" {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			source := config.Source
+			if source == nil {
+				source = getFlagSource()
+			}
+
+			on, err := source.Enabled(r.Context(), name, config.Target(r))
+			if err != nil {
+				xlog.GetWithError(r.Context(), err).Warnf("feature flag %s failed", name)
+				on = config.FailOpen
+			}
+			if !on {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(config.Status)
+				_, _ = w.Write([]byte(body))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// claimsTarget targets the authenticated user and the tenant_id claim
+func claimsTarget(r *http.Request) FlagTarget {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		return FlagTarget{}
+	}
+	tenant, _ := claims.Extra["tenant_id"].(string)
+	return FlagTarget{User: claims.UserID, Tenant: tenant}
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/xlog"
+)
+
+// Locker takes exclusive locks on keys, e.g. a cache.Locker shared by all
+// instances through Redis
+type Locker interface {
+	// Acquire takes the lock of key, held until release is called or ttl
+	// passes. It reports false without waiting if the lock is held.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (release func(ctx context.Context) error, ok bool, err error)
+}
+
+// LockConfig configures Lock
+type LockConfig struct {
+	Locker Locker // Default a MemoryLocker shared by Lock middleware, for a single instance
+
+	// Param is the route parameter naming the locked resource, e.g. "id"
+	Param string
+
+	// Key returns the lock key of a request instead of Param; "" runs the
+	// handler without a lock
+	Key func(r *http.Request) string
+
+	// Resource prefixes keys from Param (default the route template). Set
+	// it, e.g. to "order", so the routes of one resource exclude each other:
+	// paying and canceling the same order.
+	Resource string
+
+	Wait          time.Duration // How long to wait for a held lock (default 0, fail at once)
+	RetryInterval time.Duration // Between attempts while waiting (default 50ms)
+
+	// TTL releases the lock of a crashed instance (default 30s). Keep it
+	// above the time the handler takes.
+	TTL time.Duration
+
+	// FailOpen runs the handler when the locker fails; by default requests
+	// are rejected with 503. Requests canceled while waiting never run.
+	FailOpen bool
+}
+
+// Lock middleware serializes the requests on one resource: a request whose
+// resource is locked by another waits up to Wait, then gets 409 Conflict
+// with Retry-After. It prevents double processing, e.g. of an order
+// during a state transition. It runs inside routing, as group middleware.
+func Lock(config LockConfig) func(http.Handler) http.Handler {
+	if config.Locker == nil {
+		config.Locker = defaultLocker
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 50 * time.Millisecond
+	}
+	if config.TTL <= 0 {
+		config.TTL = 30 * time.Second
+	}
+	if config.Key == nil {
+		config.Key = func(r *http.Request) string {
+			value := mux.Vars(r)[config.Param]
+			if value == "" {
+				return ""
+			}
+			resource := config.Resource
+			if resource == "" {
+				resource = RouteTemplate(r)
+			}
+			return resource + ":" + value
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := config.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			release, ok, err := acquire(r.Context(), config, key)
+			if err != nil && r.Context().Err() != nil {
+				// The client went away while waiting; the lock may still be
+				// held, so the handler must not run, even with FailOpen
+				xlog.GetWithError(r.Context(), err).Info("lock wait canceled")
+				return
+			}
+			if err != nil {
+				xlog.GetWithError(r.Context(), err).Warn("lock failed")
+				if config.FailOpen {
+					next.ServeHTTP(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"error":"Lock unavailable"}`))
+				return
+			}
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(config.Wait))))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"error":"Resource is being modified by another request"}`))
+				return
+			}
+			defer func() {
+				// The lock is released even when the client went away
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+				defer cancel()
+				if err := release(ctx); err != nil {
+					xlog.GetWithError(r.Context(), err).Warn("lock release failed")
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Locked wraps a handler with Lock:
+//
+//	api.POST("/orders/{id}/pay", middleware.Locked(middleware.LockConfig{Param: "id", Resource: "order"}, pay))
+func Locked(config LockConfig, h http.HandlerFunc) http.HandlerFunc {
+	return Lock(config)(h).ServeHTTP
+}
+
+// acquire takes the lock of key, retrying until config.Wait passes
+func acquire(ctx context.Context, config LockConfig, key string) (func(context.Context) error, bool, error) {
+	deadline := time.Now().Add(config.Wait)
+	for {
+		release, ok, err := config.Locker.Acquire(ctx, key, config.TTL)
+		if err != nil || ok {
+			return release, ok, err
+		}
+		wait := min(config.RetryInterval, time.Until(deadline))
+		if wait <= 0 {
+			return nil, false, nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, false, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// defaultLocker is shared so routes locking one resource exclude each other
+var defaultLocker = NewMemoryLocker()
+
+// MemoryLocker is a Locker for a single instance
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+	seq   uint64
+}
+
+type memoryLock struct {
+	id      uint64
+	expires time.Time
+}
+
+// NewMemoryLocker returns an in-process Locker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]memoryLock)}
+}
+
+// Acquire implements Locker
+func (l *MemoryLocker) Acquire(_ context.Context, key string, ttl time.Duration) (func(context.Context) error, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if held, ok := l.locks[key]; ok && now.Before(held.expires) {
+		return nil, false, nil
+	}
+	// Drop expired locks now and then, so the map does not grow with keys
+	if len(l.locks) > 1024 {
+		for k, held := range l.locks {
+			if !now.Before(held.expires) {
+				delete(l.locks, k)
+			}
+		}
+	}
+
+	l.seq++
+	id := l.seq
+	l.locks[key] = memoryLock{id: id, expires: now.Add(ttl)}
+	return func(context.Context) error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// After the TTL, another request may hold the lock
+		if held, ok := l.locks[key]; ok && held.id == id {
+			delete(l.locks, key)
+		}
+		return nil
+	}, true, nil
+}
@@ -1,9 +1,21 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/reqctx"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,10 +37,125 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// ReadFrom keeps sendfile for file responses when the underlying writer
+// supports it
+func (rw *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.Copy(rw.ResponseWriter, r)
+	rw.written += n
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap supports http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Access log fields
+const (
+	LogMethod    = "method"
+	LogPath      = "path"
+	LogQuery     = "query"
+	LogStatus    = "status"
+	LogLatency   = "duration" // Milliseconds
+	LogBytes     = "bytes"
+	LogIP        = "ip"
+	LogUserAgent = "user_agent"
+	LogRequestID = "request_id"
+	LogRoute     = "route"   // Route template, e.g. /users/{id}
+	LogUserID    = "user_id" // Authenticated user from the auth claims
+	LogReferer   = "referer"
+)
+
+// Access log formats
+const (
+	LogFormatJSON     = "json"     // A logrus entry with the configured fields
+	LogFormatCombined = "combined" // Apache combined log format, written to Output
+)
+
+// LoggerConfig holds access log configuration
+type LoggerConfig struct {
+	// Fields logged in the JSON format (default method, path, query, status,
+	// duration, bytes, ip, user_agent and request_id)
+	Fields []string
+
+	Format string    // LogFormatJSON (default) or LogFormatCombined
+	Output io.Writer // Destination of the combined format (default os.Stdout)
+
+	// SkipPaths are not logged, e.g. "/healthz" and "/metrics"; a trailing
+	// "*" matches a prefix, as in "/debug/*"
+	SkipPaths []string
+}
+
+var defaultLogFields = []string{
+	LogMethod, LogPath, LogQuery, LogStatus, LogLatency, LogBytes, LogIP, LogUserAgent, LogRequestID,
+}
+
+type routedRequestKey struct{}
+
+// RecordRequest makes the routed request visible to Logger and Metrics,
+// which run before routing, so they see the route template and the user
+// set by authentication middleware. The app router calls it for every route.
+func RecordRequest(r *http.Request) {
+	if routed, ok := r.Context().Value(routedRequestKey{}).(*atomic.Pointer[http.Request]); ok {
+		routed.Store(r)
+	}
+}
+
+// trackRouting returns r carrying the pointer RecordRequest stores the
+// routed request in, shared with any outer Logger or Metrics
+func trackRouting(r *http.Request) (*http.Request, *atomic.Pointer[http.Request]) {
+	if routed, ok := r.Context().Value(routedRequestKey{}).(*atomic.Pointer[http.Request]); ok {
+		return r, routed
+	}
+	routed := &atomic.Pointer[http.Request]{}
+	routed.Store(r)
+	return r.WithContext(context.WithValue(r.Context(), routedRequestKey{}, routed)), routed
+}
+
+// TrackRouting returns r carrying the routed request slot and a function
+// returning the routed request, for middleware running before routing that
+// reports the route or user as Logger does. Before the handler runs, the
+// function returns r.
+func TrackRouting(r *http.Request) (*http.Request, func() *http.Request) {
+	r, routed := trackRouting(r)
+	return r, routed.Load
+}
+
 // Logger middleware logs HTTP requests
 func Logger() func(http.Handler) http.Handler {
+	return Describe(Descriptor{Name: "middleware.Logger"}, LoggerWith(LoggerConfig{}))
+}
+
+// LoggerWith middleware logs HTTP requests with the configured fields and
+// format
+func LoggerWith(config LoggerConfig) func(http.Handler) http.Handler {
+	if len(config.Fields) == 0 {
+		config.Fields = defaultLogFields
+	}
+	if config.Format == "" {
+		config.Format = LogFormatJSON
+	}
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	// The route and user are only known once the request is routed
+	routed := config.Format == LogFormatCombined ||
+		slices.Contains(config.Fields, LogRoute) || slices.Contains(config.Fields, LogUserID)
+	var outputMu sync.Mutex
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skipPath(config.SkipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
 			start := time.Now()
 
 			// Wrap response writer
@@ -37,25 +164,114 @@ func Logger() func(http.Handler) http.Handler {
 				statusCode:     http.StatusOK,
 			}
 
+			inner := &atomic.Pointer[http.Request]{}
+			if routed {
+				r, inner = trackRouting(r)
+			}
+
 			// Call next handler
 			next.ServeHTTP(rw, r)
 
 			// Log request
 			duration := time.Since(start)
-			logrus.WithFields(logrus.Fields{
-				"method":     r.Method,
-				"path":       r.URL.Path,
-				"query":      r.URL.RawQuery,
-				"status":     rw.statusCode,
-				"duration":   duration.Milliseconds(),
-				"bytes":      rw.written,
-				"ip":         getClientIP(r),
-				"user_agent": r.UserAgent(),
-			}).Info("HTTP request")
+			if config.Format == LogFormatCombined {
+				line := combinedLogLine(r, inner.Load(), rw, start)
+				outputMu.Lock()
+				_, _ = io.WriteString(config.Output, line)
+				outputMu.Unlock()
+				return
+			}
+
+			fields := make(logrus.Fields, len(config.Fields))
+			for _, field := range config.Fields {
+				switch field {
+				case LogMethod:
+					fields[field] = r.Method
+				case LogPath:
+					fields[field] = r.URL.Path
+				case LogQuery:
+					fields[field] = r.URL.RawQuery
+				case LogStatus:
+					fields[field] = rw.statusCode
+				case LogLatency:
+					fields[field] = duration.Milliseconds()
+				case LogBytes:
+					fields[field] = rw.written
+				case LogIP:
+					fields[field] = getClientIP(r)
+				case LogUserAgent:
+					fields[field] = r.UserAgent()
+				case LogReferer:
+					fields[field] = r.Referer()
+				case LogRequestID:
+					// RequestID may run inside Logger, so also look at the response
+					if id := GetRequestID(r.Context()); id != "" {
+						fields[field] = id
+					} else if id := rw.Header().Get("X-Request-ID"); id != "" {
+						fields[field] = id
+					}
+				case LogRoute:
+					if route := RouteTemplate(inner.Load()); route != "" {
+						fields[field] = route
+					}
+				case LogUserID:
+					if user := claimsUser(inner.Load()); user != "" {
+						fields[field] = user
+					}
+				}
+			}
+			logrus.WithFields(fields).Info("HTTP request")
 		})
 	}
 }
 
+func skipPath(skip []string, path string) bool {
+	for _, p := range skip {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteTemplate returns the path template of the route matching r, e.g.
+// "/orders/{id}", or "" when r is nil or matched no route
+func RouteTemplate(r *http.Request) string {
+	return reqctx.RouteTemplate(r)
+}
+
+func claimsUser(r *http.Request) string {
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		return claims.UserID
+	}
+	return ""
+}
+
+// combinedLogLine formats a request in the Apache combined log format:
+// host ident user [time] "request" status bytes "referer" "user-agent"
+func combinedLogLine(r, routed *http.Request, rw *responseWriter, start time.Time) string {
+	host := getClientIP(r)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	user := claimsUser(routed)
+	if user == "" {
+		user = "-"
+	}
+	size := "-"
+	if rw.written > 0 {
+		size = strconv.FormatInt(rw.written, 10)
+	}
+	return fmt.Sprintf("%s - %s [%s] %s %d %s %s %s\n",
+		host, user, start.Format("02/Jan/2006:15:04:05 -0700"),
+		strconv.Quote(r.Method+" "+r.URL.RequestURI()+" "+r.Proto),
+		rw.statusCode, size, strconv.Quote(r.Referer()), strconv.Quote(r.UserAgent()))
+}
+
 func getClientIP(r *http.Request) string {
 	if ip := r.Header.Get("CF-Connecting-IP"); ip != "" {
 		return ip
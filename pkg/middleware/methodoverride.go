@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// safeMethods can never be the result of an override; turning a POST into
+// a GET or HEAD would let a link trigger a state change
+var safeMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodConnect: true,
+}
+
+// MethodOverrideConfig holds method override configuration
+type MethodOverrideConfig struct {
+	// Methods a POST may be turned into (default PUT, PATCH and DELETE).
+	// GET, HEAD, OPTIONS, TRACE and CONNECT are never allowed.
+	Methods []string
+
+	Header    string // Default X-HTTP-Method-Override, "-" to ignore headers
+	FormField string // Default _method, "-" to ignore forms
+}
+
+// MethodOverride middleware lets HTML forms and clients behind restrictive
+// proxies send PUT, PATCH and DELETE as a POST carrying the
+// X-HTTP-Method-Override header or a _method form field. It must run before
+// routing, so install it with App.Use rather than on a route group.
+func MethodOverride() func(http.Handler) http.Handler {
+	return Describe(Descriptor{Name: "middleware.MethodOverride"}, MethodOverrideWith(MethodOverrideConfig{}))
+}
+
+// MethodOverrideWith middleware is MethodOverride with an allowlist of
+// methods and custom header and form field names. Only POST requests are
+// overridden, the header winning over the form field; other methods and
+// those not in the allowlist pass through unchanged.
+func MethodOverrideWith(config MethodOverrideConfig) func(http.Handler) http.Handler {
+	if len(config.Methods) == 0 {
+		config.Methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	if config.Header == "" {
+		config.Header = "X-HTTP-Method-Override"
+	}
+	if config.FormField == "" {
+		config.FormField = "_method"
+	}
+	allowed := make(map[string]bool, len(config.Methods))
+	for _, method := range config.Methods {
+		if method = strings.ToUpper(method); !safeMethods[method] {
+			allowed[method] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				var method string
+				if config.Header != "-" {
+					method = r.Header.Get(config.Header)
+				}
+				if method == "" && config.FormField != "-" && isForm(r) {
+					method = r.PostFormValue(config.FormField)
+				}
+				if method = strings.ToUpper(strings.TrimSpace(method)); allowed[method] {
+					r.Method = method
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isForm(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	return strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(contentType, "multipart/form-data")
+}
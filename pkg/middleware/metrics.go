@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -9,27 +11,74 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var (
-	httpRequestsTotal = promauto.NewCounterVec(
+// UnmatchedRoute is the path label of requests that matched no route, so
+// scans of random URLs do not create a series each
+const UnmatchedRoute = "unmatched"
+
+var httpPanicsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_panics_total",
+		Help: "Total number of panics recovered from HTTP handlers",
+	},
+	[]string{"method", "path"},
+)
+
+// MetricsConfig holds Prometheus metrics configuration
+type MetricsConfig struct {
+	// Registry the metrics are registered in, e.g. a registry of its own
+	// per test (default the global Prometheus registry)
+	Registry *prometheus.Registry
+
+	DurationBuckets []float64 // Latency buckets in seconds (default prometheus.DefBuckets)
+	SizeBuckets     []float64 // Response size buckets in bytes (default 100 B to 10 MB)
+}
+
+// Metrics middleware collects Prometheus metrics in the global registry
+func Metrics() func(http.Handler) http.Handler {
+	return Describe(Descriptor{Name: "middleware.Metrics"}, MetricsWith(MetricsConfig{}))
+}
+
+// MetricsWith middleware counts requests and observes their latency and
+// response size, labelled by method, route template and status class (2xx,
+// 4xx, ...). Middleware sharing a registry share the metrics, with the
+// buckets of the first.
+func MetricsWith(config MetricsConfig) func(http.Handler) http.Handler {
+	if len(config.DurationBuckets) == 0 {
+		config.DurationBuckets = prometheus.DefBuckets
+	}
+	if len(config.SizeBuckets) == 0 {
+		config.SizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+	}
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	if config.Registry != nil {
+		registerer = config.Registry
+	}
+
+	labels := []string{"method", "path", "status"}
+	requests := register(registerer, prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_requests_total",
 			Help: "Total number of HTTP requests",
 		},
-		[]string{"method", "path", "status"},
-	)
-
-	httpRequestDuration = promauto.NewHistogramVec(
+		labels,
+	))
+	duration := register(registerer, prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "http_request_duration_seconds",
 			Help:    "HTTP request duration in seconds",
-			Buckets: prometheus.DefBuckets,
+			Buckets: config.DurationBuckets,
 		},
-		[]string{"method", "path", "status"},
-	)
-)
+		labels,
+	))
+	size := register(registerer, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size in bytes",
+			Buckets: config.SizeBuckets,
+		},
+		labels,
+	))
 
-// Metrics middleware collects Prometheus metrics
-func Metrics() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -38,14 +87,18 @@ func Metrics() func(http.Handler) http.Handler {
 				ResponseWriter: w,
 				statusCode:     http.StatusOK,
 			}
+			r, routed := trackRouting(r)
 
 			next.ServeHTTP(rw, r)
 
-			duration := time.Since(start).Seconds()
-			status := http.StatusText(rw.statusCode)
-
-			httpRequestsTotal.WithLabelValues(r.Method, r.URL.Path, status).Inc()
-			httpRequestDuration.WithLabelValues(r.Method, r.URL.Path, status).Observe(duration)
+			route := RouteTemplate(routed.Load())
+			if route == "" {
+				route = UnmatchedRoute
+			}
+			values := []string{r.Method, route, statusClass(rw.statusCode)}
+			requests.WithLabelValues(values...).Inc()
+			duration.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+			size.WithLabelValues(values...).Observe(float64(rw.written))
 		})
 	}
 }
@@ -54,3 +107,30 @@ func Metrics() func(http.Handler) http.Handler {
 func MetricsHandler() http.Handler {
 	return promhttp.Handler()
 }
+
+// MetricsHandlerFor returns the Prometheus metrics handler of registry
+func MetricsHandlerFor(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// register registers c, or returns the collector already registered in
+// its place
+func register[C prometheus.Collector](registerer prometheus.Registerer, c C) C {
+	if err := registerer.Register(c); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			if existing, ok := registered.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return strconv.Itoa(code)
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	serve := func(h http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("hit serves stored response", func(t *testing.T) {
+		var calls atomic.Int32
+		handler := Cache(NewMemoryCacheStore(0), time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := calls.Add(1)
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("call " + strconv.Itoa(int(n))))
+		}))
+
+		first := serve(handler, "/items?b=2&a=1")
+		second := serve(handler, "/items?a=1&b=2")
+		if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+			t.Errorf("X-Cache = %q, %q; want MISS, HIT", first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+		}
+		if second.Body.String() != "call 1" || second.Header().Get("Content-Type") != "text/plain" {
+			t.Errorf("hit = %q %q, want stored response", second.Body.String(), second.Header().Get("Content-Type"))
+		}
+		if serve(handler, "/items?a=2").Body.String() != "call 2" {
+			t.Error("other query served from cache")
+		}
+	})
+
+	t.Run("uncacheable responses and requests", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			respond func(w http.ResponseWriter)
+			header  []string
+		}{
+			{"server error", func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) }, nil},
+			{"private", func(w http.ResponseWriter) { w.Header().Set("Cache-Control", "private, max-age=60") }, nil},
+			{"set cookie", func(w http.ResponseWriter) { w.Header().Set("Set-Cookie", "session=1") }, nil},
+			{"vary star", func(w http.ResponseWriter) { w.Header().Set("Vary", "*") }, nil},
+			{"authorized request", func(w http.ResponseWriter) {}, []string{"Authorization", "Bearer token"}},
+			{"request with cookie", func(w http.ResponseWriter) {}, []string{"Cookie", "session=abc"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var calls atomic.Int32
+				handler := Cache(NewMemoryCacheStore(0), time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls.Add(1)
+					tt.respond(w)
+				}))
+				serve(handler, "/", tt.header...)
+				serve(handler, "/", tt.header...)
+				if calls.Load() != 2 {
+					t.Errorf("handler ran %d times, want 2", calls.Load())
+				}
+			})
+		}
+	})
+
+	t.Run("cookies get their own responses", func(t *testing.T) {
+		handler := Cache(NewMemoryCacheStore(0), time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, _ := r.Cookie("session")
+			_, _ = w.Write([]byte("hello " + session.Value))
+		}))
+
+		for _, user := range []string{"alice", "bob", "alice"} {
+			w := serve(handler, "/profile", "Cookie", "session="+user)
+			if w.Body.String() != "hello "+user || w.Header().Get("X-Cache") != "" {
+				t.Errorf("%s: %s %q, want uncached %q", user, w.Header().Get("X-Cache"), w.Body.String(), "hello "+user)
+			}
+		}
+	})
+
+	t.Run("vary stores variants", func(t *testing.T) {
+		handler := Cache(NewMemoryCacheStore(0), time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Vary", "Accept-Language")
+			_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+		}))
+
+		serve(handler, "/", "Accept-Language", "en")
+		serve(handler, "/", "Accept-Language", "de")
+		for _, lang := range []string{"en", "de"} {
+			w := serve(handler, "/", "Accept-Language", lang)
+			if w.Header().Get("X-Cache") != "HIT" || w.Body.String() != lang {
+				t.Errorf("%s: %s %q, want HIT %q", lang, w.Header().Get("X-Cache"), w.Body.String(), lang)
+			}
+		}
+	})
+
+	t.Run("concurrent misses run handler once", func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		handler := Cache(NewMemoryCacheStore(0), time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			<-release
+			_, _ = w.Write([]byte("slow"))
+		}))
+
+		var wg sync.WaitGroup
+		bodies := make([]string, 5)
+		for i := range bodies {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				bodies[i] = serve(handler, "/slow").Body.String()
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("handler ran %d times, want 1", calls.Load())
+		}
+		for i, body := range bodies {
+			if body != "slow" {
+				t.Errorf("request %d got %q", i, body)
+			}
+		}
+	})
+
+	t.Run("route TTL", func(t *testing.T) {
+		store := NewMemoryCacheStore(0)
+		handler := Cache(store, time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		withTTL := func(ttl time.Duration) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handler.ServeHTTP(w, r.WithContext(WithRouteMeta(r.Context(), &RouteMeta{CacheTTL: ttl})))
+			})
+		}
+
+		serve(withTTL(-1), "/none")
+		if store.Len() != 0 {
+			t.Errorf("negative TTL stored %d entries", store.Len())
+		}
+		serve(withTTL(time.Millisecond), "/short")
+		time.Sleep(5 * time.Millisecond)
+		if w := serve(withTTL(time.Millisecond), "/short"); w.Header().Get("X-Cache") != "MISS" {
+			t.Errorf("X-Cache = %q after route TTL, want MISS", w.Header().Get("X-Cache"))
+		}
+	})
+
+	t.Run("invalidate drops all variants", func(t *testing.T) {
+		var calls atomic.Int32
+		rc := NewResponseCache(NewMemoryCacheStore(0), time.Minute, func(r *http.Request) string { return r.URL.Path })
+		handler := rc.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Vary", "Accept")
+		}))
+
+		serve(handler, "/report", "Accept", "text/csv")
+		serve(handler, "/report", "Accept", "application/json")
+		if err := rc.Invalidate(context.Background(), "/report"); err != nil {
+			t.Fatalf("Invalidate: %v", err)
+		}
+		serve(handler, "/report", "Accept", "text/csv")
+		serve(handler, "/report", "Accept", "application/json")
+		if calls.Load() != 4 {
+			t.Errorf("handler ran %d times, want 4", calls.Load())
+		}
+	})
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryCacheStore(2)
+
+	_ = s.Set(ctx, "a", []byte("1"), 0)
+	_ = s.Set(ctx, "b", []byte("2"), 0)
+	_, _, _ = s.Get(ctx, "a") // b is now least recently used
+	_ = s.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := s.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if v, ok, _ := s.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("a = %q, %v; want 1", v, ok)
+	}
+
+	_ = s.Set(ctx, "short", []byte("x"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := s.Get(ctx, "short"); ok {
+		t.Error("expected expired entry to miss")
+	}
+	if s.Len() != 1 { // "short" evicted "c", then expired
+		t.Errorf("Len = %d, want 1", s.Len())
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/andybalholm/brotli"
 )
 
 func gunzip(t *testing.T, r io.Reader) string {
@@ -24,33 +27,57 @@ func gunzip(t *testing.T, r io.Reader) string {
 	return string(data)
 }
 
+func unbrotli(t *testing.T, r io.Reader) string {
+	t.Helper()
+	data, err := io.ReadAll(brotli.NewReader(r))
+	if err != nil {
+		t.Fatalf("failed to decompress body: %v", err)
+	}
+	return string(data)
+}
+
 func TestCompress(t *testing.T) {
 	const payload = "hello hello hello hello hello compression"
 
 	tests := []struct {
 		name           string
 		acceptEncoding string
-		wantGzip       bool
+		wantEncoding   string
 	}{
 		{
 			name:           "gzip when client accepts gzip",
 			acceptEncoding: "gzip",
-			wantGzip:       true,
+			wantEncoding:   "gzip",
 		},
 		{
-			name:           "gzip when client accepts multiple encodings",
+			name:           "brotli preferred when client accepts both",
 			acceptEncoding: "deflate, gzip, br",
-			wantGzip:       true,
+			wantEncoding:   "br",
+		},
+		{
+			name:           "client quality wins over preference",
+			acceptEncoding: "br;q=0.5, gzip",
+			wantEncoding:   "gzip",
+		},
+		{
+			name:           "wildcard",
+			acceptEncoding: "*",
+			wantEncoding:   "br",
+		},
+		{
+			name:           "refused encoding",
+			acceptEncoding: "br;q=0, gzip;q=0.1",
+			wantEncoding:   "gzip",
 		},
 		{
 			name:           "no gzip without Accept-Encoding",
 			acceptEncoding: "",
-			wantGzip:       false,
+			wantEncoding:   "",
 		},
 		{
 			name:           "no gzip for other encodings",
 			acceptEncoding: "deflate",
-			wantGzip:       false,
+			wantEncoding:   "",
 		},
 	}
 
@@ -68,21 +95,24 @@ func TestCompress(t *testing.T) {
 			if w.Code != http.StatusOK {
 				t.Errorf("expected status 200, got %d", w.Code)
 			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.wantEncoding, got)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("expected Vary 'Accept-Encoding', got %q", got)
+			}
 
-			if tt.wantGzip {
-				if got := w.Header().Get("Content-Encoding"); got != "gzip" {
-					t.Fatalf("expected Content-Encoding 'gzip', got %q", got)
-				}
-				if got := gunzip(t, w.Body); got != payload {
-					t.Errorf("expected decompressed body %q, got %q", payload, got)
-				}
-			} else {
-				if got := w.Header().Get("Content-Encoding"); got != "" {
-					t.Fatalf("expected no Content-Encoding, got %q", got)
-				}
-				if got := w.Body.String(); got != payload {
-					t.Errorf("expected body %q, got %q", payload, got)
-				}
+			var got string
+			switch tt.wantEncoding {
+			case "gzip":
+				got = gunzip(t, w.Body)
+			case "br":
+				got = unbrotli(t, w.Body)
+			default:
+				got = w.Body.String()
+			}
+			if got != payload {
+				t.Errorf("expected body %q, got %q", payload, got)
 			}
 		})
 	}
@@ -145,3 +175,114 @@ func TestCompress_LargeBodyIsActuallyCompressed(t *testing.T) {
 		t.Error("decompressed body does not match original payload")
 	}
 }
+
+func TestCompress_Flush(t *testing.T) {
+	var flushedLen int
+	w := httptest.NewRecorder()
+	wrapped := Compress()(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte("first chunk"))
+		if err := http.NewResponseController(rw).Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+		}
+		flushedLen = w.Body.Len()
+		_, _ = rw.Write([]byte(", second chunk"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	wrapped.ServeHTTP(w, req)
+
+	if !w.Flushed {
+		t.Error("expected the underlying writer to be flushed")
+	}
+	if flushedLen == 0 {
+		t.Error("expected compressed data to reach the client on Flush")
+	}
+	if got := gunzip(t, w.Body); got != "first chunk, second chunk" {
+		t.Errorf("unexpected decompressed body %q", got)
+	}
+}
+
+func TestCompressWith(t *testing.T) {
+	large := strings.Repeat("goframe ", 200)
+
+	tests := []struct {
+		name         string
+		config       CompressConfig
+		contentType  string
+		encoding     string
+		body         string
+		wantEncoding string
+	}{
+		{"below min size", CompressConfig{MinSize: 1024}, "text/plain", "", "small", ""},
+		{"above min size", CompressConfig{MinSize: 1024}, "text/plain", "", large, "br"},
+		{"content length above min size", CompressConfig{MinSize: 1024}, "application/json", "", large, "br"},
+		{"json suffix", CompressConfig{}, "application/problem+json", "", large, "br"},
+		{"image skipped", CompressConfig{}, "image/png", "", large, ""},
+		{"already encoded", CompressConfig{}, "text/plain", "gzip", large, "gzip"},
+		{"gzip only", CompressConfig{Encodings: []string{EncodingGzip}, GzipLevel: gzip.BestSpeed}, "text/plain", "", large, "gzip"},
+		{"custom types", CompressConfig{ContentTypes: []string{"application/x-ndjson"}}, "application/x-ndjson", "", large, "br"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := CompressWith(tt.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				if tt.name == "content length above min size" {
+					w.Header().Set("Content-Length", strconv.Itoa(len(tt.body)))
+				}
+				// Written in small pieces to cross MinSize mid-body
+				for i := 0; i < len(tt.body); i += 100 {
+					_, _ = w.Write([]byte(tt.body[i:min(i+100, len(tt.body))]))
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip, br")
+			w := httptest.NewRecorder()
+			wrapped.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.wantEncoding, got)
+			}
+			var got string
+			switch {
+			case tt.encoding != "":
+				got = w.Body.String()
+			case tt.wantEncoding == "gzip":
+				got = gunzip(t, w.Body)
+			case tt.wantEncoding == "br":
+				got = unbrotli(t, w.Body)
+			default:
+				got = w.Body.String()
+			}
+			if got != tt.body {
+				t.Errorf("body mismatch: got %d bytes, want %d", len(got), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestCompress_NoBody(t *testing.T) {
+	wrapped := Compress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	wrapped.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding, got %q", got)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %d bytes", w.Body.Len())
+	}
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// blockingHandler holds requests until release is closed, signalling each
+// arrival on entered
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     ConcurrencyConfig
+		requests   int
+		wantOK     int
+		wantShed   int
+		wantReason string
+		wantStatus int
+	}{
+		{
+			name:       "sheds over the limit without a queue",
+			config:     ConcurrencyConfig{MaxInFlight: 2},
+			requests:   4,
+			wantOK:     2,
+			wantShed:   2,
+			wantReason: ShedQueueFull,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "queues up to the depth",
+			config:     ConcurrencyConfig{MaxInFlight: 1, QueueDepth: 2, QueueTimeout: time.Minute},
+			requests:   4,
+			wantOK:     3,
+			wantShed:   1,
+			wantReason: ShedQueueFull,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "sheds queued requests after the timeout",
+			config:     ConcurrencyConfig{MaxInFlight: 1, QueueDepth: 5, QueueTimeout: 20 * time.Millisecond, StatusCode: http.StatusTooManyRequests},
+			requests:   3,
+			wantOK:     1,
+			wantShed:   2,
+			wantReason: ShedTimeout,
+			wantStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			tt.config.Registry = registry
+			tt.config.Name = "api"
+
+			entered := make(chan struct{}, tt.requests)
+			release := make(chan struct{})
+			handler := ConcurrencyLimit(tt.config)(blockingHandler(entered, release))
+
+			codes := make(chan int, tt.requests)
+			var wg sync.WaitGroup
+			for range tt.requests {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					rec := httptest.NewRecorder()
+					handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+					if rec.Code != http.StatusOK && rec.Header().Get("Retry-After") != "1" {
+						t.Errorf("expected Retry-After on shed requests, got %q", rec.Header().Get("Retry-After"))
+					}
+					codes <- rec.Code
+				}()
+			}
+
+			// Wait for the limit to fill and the rest to be shed or queued
+			<-entered
+			deadline := time.Now().Add(2 * time.Second)
+			for len(codes) < tt.wantShed && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			close(release)
+			wg.Wait()
+			close(codes)
+
+			ok, rejected := 0, 0
+			for code := range codes {
+				switch code {
+				case http.StatusOK:
+					ok++
+				case tt.wantStatus:
+					rejected++
+				default:
+					t.Errorf("unexpected status %d", code)
+				}
+			}
+			if ok != tt.wantOK || rejected != tt.wantShed {
+				t.Errorf("expected %d served and %d shed, got %d and %d", tt.wantOK, tt.wantShed, ok, rejected)
+			}
+
+			counter := register(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "http_shed_requests_total",
+				Help: "HTTP requests rejected by a concurrency limit",
+			}, []string{"limiter", "reason"}))
+			if got := testutil.ToFloat64(counter.WithLabelValues("api", tt.wantReason)); got != float64(tt.wantShed) {
+				t.Errorf("expected %d shed requests counted, got %v", tt.wantShed, got)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimit_PerRoute(t *testing.T) {
+	limit := ConcurrencyLimit(ConcurrencyConfig{PerRoute: 1, MaxInFlight: 2, Registry: prometheus.NewRegistry()})
+
+	entered := make(chan struct{}, 4)
+	release := make(chan struct{})
+	reports := limit(blockingHandler(entered, release))
+	search := limit(blockingHandler(entered, release))
+
+	serve := func(h http.Handler) <-chan int {
+		code := make(chan int, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			code <- rec.Code
+		}()
+		return code
+	}
+
+	first := serve(reports)
+	<-entered
+	// The route is at its limit while another route still has room
+	if code := <-serve(reports); code != http.StatusServiceUnavailable {
+		t.Errorf("expected the busy route to shed, got %d", code)
+	}
+	other := serve(search)
+	<-entered
+
+	close(release)
+	if <-first != http.StatusOK || <-other != http.StatusOK {
+		t.Error("expected the admitted requests to complete")
+	}
+}
+
+func TestConcurrencyLimit_CanceledWhileQueued(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handler := ConcurrencyLimit(ConcurrencyConfig{MaxInFlight: 1, QueueDepth: 1, QueueTimeout: time.Minute, Registry: registry})(blockingHandler(entered, release))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	<-entered
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	rec := httptest.NewRecorder()
+	go func() {
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		close(done)
+	}()
+	cancel()
+	<-done
+	close(release)
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected no response for a canceled request, got %q", rec.Body.String())
+	}
+}
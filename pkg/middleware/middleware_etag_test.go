@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	jsonHandler := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	serve := func(h http.Handler, method, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("conditional GET", func(t *testing.T) {
+		h := ETag()(jsonHandler(`{"items":[1,2]}`))
+		first := serve(h, http.MethodGet, "")
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || first.Body.String() != `{"items":[1,2]}` || !strings.HasPrefix(etag, `"`) {
+			t.Fatalf("first = %d %q ETag %q", first.Code, first.Body.String(), etag)
+		}
+
+		tests := []struct {
+			name        string
+			ifNoneMatch string
+			want        int
+		}{
+			{"match", etag, http.StatusNotModified},
+			{"match in list", `"other", ` + etag, http.StatusNotModified},
+			{"weak match", "W/" + etag, http.StatusNotModified},
+			{"wildcard", "*", http.StatusNotModified},
+			{"changed", `"stale"`, http.StatusOK},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := serve(h, http.MethodGet, tt.ifNoneMatch)
+				if w.Code != tt.want {
+					t.Errorf("status = %d, want %d", w.Code, tt.want)
+				}
+				if tt.want == http.StatusNotModified && (w.Body.Len() != 0 || w.Header().Get("ETag") != etag) {
+					t.Errorf("304 body %q ETag %q", w.Body.String(), w.Header().Get("ETag"))
+				}
+			})
+		}
+	})
+
+	t.Run("weak validators", func(t *testing.T) {
+		w := serve(ETagWith(ETagConfig{Weak: true})(jsonHandler(`{}`)), http.MethodGet, "")
+		if !strings.HasPrefix(w.Header().Get("ETag"), `W/"`) {
+			t.Errorf("ETag = %q, want a weak validator", w.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("untagged responses", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			method  string
+			handler http.Handler
+		}{
+			{"post", http.MethodPost, jsonHandler(`{}`)},
+			{"html", http.MethodGet, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = w.Write([]byte("<p>hi</p>"))
+			})},
+			{"error status", http.MethodGet, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"Not Found"}`))
+			})},
+			{"too large", http.MethodGet, jsonHandler(`{"data":"` + strings.Repeat("x", 64) + `"}`)},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := serve(ETagWith(ETagConfig{MaxSize: 32})(tt.handler), tt.method, "*")
+				if w.Header().Get("ETag") != "" || w.Code == http.StatusNotModified || w.Body.Len() == 0 {
+					t.Errorf("got %d ETag %q body %q, want the response unchanged", w.Code, w.Header().Get("ETag"), w.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("handler ETag", func(t *testing.T) {
+		h := ETag()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.Header().Set("ETag", `"v7"`)
+			_, _ = w.Write([]byte(`{"version":7}`))
+		}))
+		if w := serve(h, http.MethodGet, `"v7"`); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("matching = %d %q, want 304", w.Code, w.Body.String())
+		}
+		if w := serve(h, http.MethodGet, `"v6"`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"v7"` {
+			t.Errorf("stale = %d ETag %q, want 200 with the handler ETag", w.Code, w.Header().Get("ETag"))
+		}
+	})
+}
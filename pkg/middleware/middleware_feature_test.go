@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/auth"
+)
+
+func TestFeature(t *testing.T) {
+	flags := StaticFlags{
+		"new-search": {Users: []string{"alice"}, Tenants: []string{"acme"}},
+		"everyone":   {Enabled: true},
+	}
+	failing := FlagFunc(func(context.Context, string, FlagTarget) (bool, error) {
+		return false, errors.New("flag service down")
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		flag   string
+		config FeatureConfig
+		claims *auth.Claims
+		want   int
+	}{
+		{"listed user", "new-search", FeatureConfig{Source: flags}, &auth.Claims{UserID: "alice"}, http.StatusOK},
+		{"listed tenant", "new-search", FeatureConfig{Source: flags}, &auth.Claims{UserID: "bob", Extra: map[string]interface{}{"tenant_id": "acme"}}, http.StatusOK},
+		{"other user hidden", "new-search", FeatureConfig{Source: flags}, &auth.Claims{UserID: "bob"}, http.StatusNotFound},
+		{"anonymous hidden", "new-search", FeatureConfig{Source: flags}, nil, http.StatusNotFound},
+		{"forbidden status", "new-search", FeatureConfig{Source: flags, Status: http.StatusForbidden}, nil, http.StatusForbidden},
+		{"enabled for everyone", "everyone", FeatureConfig{Source: flags}, nil, http.StatusOK},
+		{"unknown flag", "missing", FeatureConfig{Source: flags}, &auth.Claims{UserID: "alice"}, http.StatusNotFound},
+		{"source fails closed", "new-search", FeatureConfig{Source: failing}, nil, http.StatusNotFound},
+		{"source fails open", "new-search", FeatureConfig{Source: failing, FailOpen: true}, nil, http.StatusOK},
+		{"custom target", "new-search", FeatureConfig{Source: flags, Target: func(r *http.Request) FlagTarget {
+			return FlagTarget{Tenant: r.Header.Get("X-Tenant")}
+		}}, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/search", nil)
+			r.Header.Set("X-Tenant", "acme")
+			if tt.claims != nil {
+				r = r.WithContext(auth.WithClaims(r.Context(), tt.claims))
+			}
+			w := httptest.NewRecorder()
+			FeatureWith(tt.flag, tt.config)(ok).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeature_DefaultSource(t *testing.T) {
+	defer SetFlagSource(StaticFlags{})
+	handler := Feature("beta")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	if code := serve(); code != http.StatusNotFound {
+		t.Errorf("status = %d before the flag is set, want 404", code)
+	}
+	SetFlagSource(StaticFlags{"beta": {Enabled: true}})
+	if code := serve(); code != http.StatusOK {
+		t.Errorf("status = %d after enabling, want 200", code)
+	}
+}
+
+func TestStaticFlags_Percent(t *testing.T) {
+	flags := StaticFlags{"rollout": {Percent: 30}}
+	ctx := context.Background()
+
+	on := 0
+	for i := 0; i < 1000; i++ {
+		target := FlagTarget{User: strconv.Itoa(i)}
+		first, _ := flags.Enabled(ctx, "rollout", target)
+		again, _ := flags.Enabled(ctx, "rollout", target)
+		if first != again {
+			t.Fatalf("user %d flipped between evaluations", i)
+		}
+		if first {
+			on++
+		}
+	}
+	if on < 250 || on > 350 {
+		t.Errorf("%d of 1000 users enabled, want about 300", on)
+	}
+	if enabled, _ := flags.Enabled(ctx, "rollout", FlagTarget{}); enabled {
+		t.Error("anonymous request enabled by percentage")
+	}
+}
+
+func TestFeature_RouteMeta(t *testing.T) {
+	gate := FeatureWith("", FeatureConfig{Source: StaticFlags{"on": {Enabled: true}}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tests := []struct {
+		name string
+		meta *RouteMeta
+		want int
+	}{
+		{"no metadata", nil, http.StatusOK},
+		{"flag on", &RouteMeta{Feature: "on"}, http.StatusOK},
+		{"flag off", &RouteMeta{Feature: "off"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.meta != nil {
+				r = r.WithContext(WithRouteMeta(r.Context(), tt.meta))
+			}
+			w := httptest.NewRecorder()
+			gate.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type failingLocker struct{}
+
+func (failingLocker) Acquire(context.Context, string, time.Duration) (func(context.Context) error, bool, error) {
+	return nil, false, errors.New("redis down")
+}
+
+func TestLock(t *testing.T) {
+	entered := make(chan struct{})
+	proceed := make(chan struct{})
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			entered <- struct{}{}
+			<-proceed
+		}
+		_, _ = w.Write([]byte("ok"))
+	}
+
+	// serve runs the request in the background when block is set
+	newRouter := func(config LockConfig) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/orders/{id}/pay", Locked(config, slow))
+		router.HandleFunc("/orders/{id}/cancel", Locked(config, slow))
+		return router
+	}
+	serve := func(router http.Handler, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		config   LockConfig
+		target   string // Requested while /orders/1/pay holds the lock
+		wantCode int
+	}{
+		{"same resource", LockConfig{Param: "id"}, "/orders/1/pay", http.StatusConflict},
+		{"other resource", LockConfig{Param: "id"}, "/orders/2/pay", http.StatusOK},
+		{"other route", LockConfig{Param: "id"}, "/orders/1/cancel", http.StatusOK},
+		{"shared resource", LockConfig{Param: "id", Resource: "order"}, "/orders/1/cancel", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRouter(tt.config)
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				serve(router, "/orders/1/pay?block=1")
+			}()
+			<-entered
+
+			w := serve(router, tt.target)
+			close(proceed)
+			wg.Wait()
+			proceed = make(chan struct{})
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+			if w.Code == http.StatusConflict && w.Header().Get("Retry-After") != "1" {
+				t.Errorf("expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+			}
+			// The lock is released after the handler
+			if w := serve(router, "/orders/1/pay"); w.Code != http.StatusOK {
+				t.Errorf("expected lock released, got %d", w.Code)
+			}
+		})
+	}
+
+	t.Run("wait", func(t *testing.T) {
+		router := newRouter(LockConfig{Param: "id", Wait: time.Second, RetryInterval: time.Millisecond})
+		go serve(router, "/orders/1/pay?block=1")
+		<-entered
+		time.AfterFunc(20*time.Millisecond, func() { close(proceed) })
+
+		if w := serve(router, "/orders/1/pay"); w.Code != http.StatusOK {
+			t.Errorf("expected the waiting request to run, got %d", w.Code)
+		}
+		proceed = make(chan struct{})
+	})
+
+	t.Run("canceled while waiting", func(t *testing.T) {
+		router := newRouter(LockConfig{Param: "id", Wait: time.Second, RetryInterval: time.Millisecond, FailOpen: true})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			serve(router, "/orders/1/pay?block=1")
+		}()
+		<-entered
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/1/pay", nil).WithContext(ctx))
+		close(proceed)
+		<-done
+		proceed = make(chan struct{})
+
+		if w.Body.Len() != 0 {
+			t.Errorf("expected the canceled request not to run, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("locker failure", func(t *testing.T) {
+		if w := serve(newRouter(LockConfig{Param: "id", Locker: failingLocker{}}), "/orders/1/pay"); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503, got %d", w.Code)
+		}
+		if w := serve(newRouter(LockConfig{Param: "id", Locker: failingLocker{}, FailOpen: true}), "/orders/1/pay"); w.Code != http.StatusOK {
+			t.Errorf("expected fail open, got %d", w.Code)
+		}
+	})
+}
+
+func TestMemoryLockerExpiry(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryLocker()
+	expired, _, _ := l.Acquire(ctx, "a", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := l.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("expired lock not taken")
+	}
+	_ = expired(ctx)
+	if _, ok, _ := l.Acquire(ctx, "a", time.Minute); ok {
+		t.Error("expired owner released the next owner's lock")
+	}
+}
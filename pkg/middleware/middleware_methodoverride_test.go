@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header string
+		form   url.Values
+		want   string
+	}{
+		{"header", http.MethodPost, "DELETE", nil, http.MethodDelete},
+		{"form field", http.MethodPost, "", url.Values{"_method": {"put"}}, http.MethodPut},
+		{"header wins over form", http.MethodPost, "PATCH", url.Values{"_method": {"DELETE"}}, http.MethodPatch},
+		{"no override", http.MethodPost, "", url.Values{"name": {"x"}}, http.MethodPost},
+		{"GET is never overridden", http.MethodGet, "DELETE", nil, http.MethodGet},
+		{"cannot become GET", http.MethodPost, "GET", nil, http.MethodPost},
+		{"unknown method ignored", http.MethodPost, "TRACE", nil, http.MethodPost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, field string
+			handler := MethodOverride()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Method
+				field = r.FormValue("name")
+			}))
+
+			var req *http.Request
+			if tt.form != nil {
+				req = httptest.NewRequest(tt.method, "/items/1", strings.NewReader(tt.form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest(tt.method, "/items/1", nil)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-HTTP-Method-Override", tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			if tt.form.Get("name") != "" && field != tt.form.Get("name") {
+				t.Errorf("expected the form to stay readable, got %q", field)
+			}
+		})
+	}
+}
+
+func TestMethodOverrideWith(t *testing.T) {
+	config := MethodOverrideConfig{
+		Methods:   []string{"delete", http.MethodGet, "PURGE"},
+		Header:    "X-Method",
+		FormField: "-",
+	}
+	tests := []struct {
+		name   string
+		method string
+		header string
+		form   url.Values
+		want   string
+	}{
+		{"allowed", http.MethodPost, "DELETE", nil, http.MethodDelete},
+		{"custom method", http.MethodPost, "purge", nil, "PURGE"},
+		{"not in allowlist", http.MethodPost, "PUT", nil, http.MethodPost},
+		{"safe methods never allowed", http.MethodPost, "GET", nil, http.MethodPost},
+		{"only POST", http.MethodPut, "DELETE", nil, http.MethodPut},
+		{"form ignored", http.MethodPost, "", url.Values{"_method": {"DELETE"}}, http.MethodPost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := MethodOverrideWith(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Method
+			}))
+
+			req := httptest.NewRequest(tt.method, "/items/1", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				req.Header.Set("X-Method", tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
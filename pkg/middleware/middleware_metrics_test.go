@@ -5,6 +5,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestMetrics(t *testing.T) {
@@ -50,11 +54,13 @@ func TestMetrics(t *testing.T) {
 
 func TestMetricsHandler(t *testing.T) {
 	// Drive a request through the Metrics middleware first so the counters
-	// have at least one observation with a unique, recognizable path.
-	const probePath = "/metrics-probe-path"
-	wrapped := Metrics()(okHandler("ok"))
-	req := httptest.NewRequest(http.MethodGet, probePath, nil)
-	wrapped.ServeHTTP(httptest.NewRecorder(), req)
+	// have at least one observation with a unique, recognizable route.
+	const probeRoute = "/metrics-probe/{id}"
+	router := mux.NewRouter()
+	router.Handle(probeRoute, okHandler("ok"))
+	router.Use(mux.MiddlewareFunc(Metrics()))
+	req := httptest.NewRequest(http.MethodGet, "/metrics-probe/1", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
 
 	handler := MetricsHandler()
 	if handler == nil {
@@ -76,7 +82,8 @@ func TestMetricsHandler(t *testing.T) {
 	for _, want := range []string{
 		"http_requests_total",
 		"http_request_duration_seconds",
-		`path="` + probePath + `"`,
+		"http_response_size_bytes",
+		`path="` + probeRoute + `"`,
 		`method="GET"`,
 	} {
 		if !strings.Contains(body, want) {
@@ -84,3 +91,67 @@ func TestMetricsHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestMetricsWith(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := MetricsWith(MetricsConfig{Registry: registry, SizeBuckets: []float64{10, 100}})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		RecordRequest(r)
+		if mux.Vars(r)["id"] == "0" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(strings.Repeat("x", 50)))
+	})
+	// Outside the router, as app.Use installs it
+	handler := Logger()(metrics(router))
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/nope/1", "/nope/2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	tests := []struct {
+		route, status string
+		want          float64
+	}{
+		{"/users/{id}", "2xx", 2},
+		{"/users/{id}", "4xx", 1},
+		{UnmatchedRoute, "4xx", 2},
+	}
+	counter := register(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests",
+	}, []string{"method", "path", "status"}))
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(counter.WithLabelValues(http.MethodGet, tt.route, tt.status)); got != tt.want {
+			t.Errorf("expected %v requests for %s %s, got %v", tt.want, tt.route, tt.status, got)
+		}
+	}
+
+	// A second middleware on the registry shares the metrics
+	MetricsWith(MetricsConfig{Registry: registry})(router).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/3", nil))
+	if got := testutil.ToFloat64(counter.WithLabelValues(http.MethodGet, "/users/{id}", "2xx")); got != 3 {
+		t.Errorf("expected the shared counter at 3, got %v", got)
+	}
+
+	rec := httptest.NewRecorder()
+	MetricsHandlerFor(registry).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`http_response_size_bytes_bucket{method="GET",path="/users/{id}",status="2xx",le="100"} 3`,
+		`http_response_size_bytes_bucket{method="GET",path="/users/{id}",status="2xx",le="10"} 0`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected metrics output to contain %s", want)
+		}
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for code, want := range map[int]string{200: "2xx", 301: "3xx", 404: "4xx", 503: "5xx", 0: "0"} {
+		if got := statusClass(code); got != want {
+			t.Errorf("statusClass(%d) = %s, want %s", code, got, want)
+		}
+	}
+}
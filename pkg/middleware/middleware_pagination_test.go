@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPaginationGuard(t *testing.T) {
+	list := func(n int) string {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = strconv.Itoa(i)
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	}
+	jsonHandler := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	serve := func(h http.Handler, method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		config   PaginationConfig
+		method   string
+		target   string
+		handler  http.Handler
+		wantCode int
+		wantBody string
+	}{
+		{"within limit", PaginationConfig{MaxItems: 3}, http.MethodGet, "/", jsonHandler(list(3)), http.StatusOK, list(3)},
+		{"over item limit", PaginationConfig{MaxItems: 3}, http.MethodGet, "/", jsonHandler(list(4)), http.StatusBadRequest, ""},
+		{"envelope over item limit", PaginationConfig{MaxItems: 3}, http.MethodGet, "/", jsonHandler(`{"data":` + list(4) + `}`), http.StatusBadRequest, ""},
+		{"unknown envelope field", PaginationConfig{MaxItems: 3}, http.MethodGet, "/", jsonHandler(`{"rows":` + list(4) + `}`), http.StatusOK, `{"rows":` + list(4) + `}`},
+		{"over byte limit", PaginationConfig{MaxBytes: 8}, http.MethodGet, "/", jsonHandler(list(10)), http.StatusBadRequest, ""},
+		{"paginated", PaginationConfig{MaxItems: 3}, http.MethodGet, "/?page=2", jsonHandler(list(4)), http.StatusOK, list(4)},
+		{"custom param", PaginationConfig{MaxItems: 3, Params: []string{"after"}}, http.MethodGet, "/?after=x", jsonHandler(list(4)), http.StatusOK, list(4)},
+		{"custom param ignores defaults", PaginationConfig{MaxItems: 3, Params: []string{"after"}}, http.MethodGet, "/?page=2", jsonHandler(list(4)), http.StatusBadRequest, ""},
+		{"not GET", PaginationConfig{MaxItems: 3}, http.MethodPost, "/", jsonHandler(list(4)), http.StatusOK, list(4)},
+		{"not JSON", PaginationConfig{MaxItems: 3}, http.MethodGet, "/", okHandler(list(4)), http.StatusOK, list(4)},
+		{"error status", PaginationConfig{MaxItems: 3}, http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(list(4)))
+		}), http.StatusNotFound, list(4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(PaginationGuard(tt.config)(tt.handler), tt.method, tt.target)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusBadRequest {
+				var body map[string]interface{}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || !strings.Contains(body["error"].(string), "paginate") {
+					t.Errorf("body = %q", w.Body.String())
+				}
+				return
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("truncate", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body string
+			want string
+		}{
+			{"array", list(5), list(3)},
+			{"envelope", `{"items":` + list(5) + `,"total":5}`, `{"items":` + list(3) + `,"total":5}`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				h := PaginationGuard(PaginationConfig{MaxItems: 3, Truncate: true})(jsonHandler(tt.body))
+				w := serve(h, http.MethodGet, "/")
+				if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != tt.want {
+					t.Fatalf("got %d %q, want %q", w.Code, w.Body.String(), tt.want)
+				}
+				if w.Header().Get("X-Total-Count") != "5" || w.Header().Get("X-Truncated") != "true" {
+					t.Errorf("headers = %v", w.Header())
+				}
+				if w.Header().Get("Content-Length") != strconv.Itoa(w.Body.Len()) {
+					t.Errorf("Content-Length = %q, body %d bytes", w.Header().Get("Content-Length"), w.Body.Len())
+				}
+			})
+		}
+	})
+
+	t.Run("flush streams", func(t *testing.T) {
+		h := PaginationGuard(PaginationConfig{MaxItems: 3})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("[0,1,"))
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte("2,3]"))
+		}))
+		w := serve(h, http.MethodGet, "/")
+		if w.Code != http.StatusOK || w.Body.String() != list(4) || !w.Flushed || w.Header().Get("Content-Length") != "" {
+			t.Errorf("got %d %q flushed %v Content-Length %q", w.Code, w.Body.String(), w.Flushed, w.Header().Get("Content-Length"))
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPropagateHeaders(t *testing.T) {
+	t.Run("default headers", func(t *testing.T) {
+		var got http.Header
+		handler := RequestID(RequestIDConfig{})(PropagateHeaders(PropagateConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = GetPropagatedHeaders(r.Context())
+		})))
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.Header.Add("Accept-Language", "de")
+		r.Header.Add("Accept-Language", "en;q=0.5")
+		r.Header.Set("Authorization", "Bearer token")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		if got.Get("Traceparent") == "" || len(got.Values("Accept-Language")) != 2 {
+			t.Errorf("expected trace and locale captured, got %v", got)
+		}
+		if got.Get("X-Request-ID") == "" {
+			t.Error("expected generated request ID captured")
+		}
+		if got.Get("Authorization") != "" {
+			t.Error("expected Authorization not captured by default")
+		}
+	})
+
+	t.Run("nothing captured", func(t *testing.T) {
+		handler := PropagateHeaders(PropagateConfig{Headers: []string{"X-Tenant-ID"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h := GetPropagatedHeaders(r.Context()); h != nil {
+				t.Errorf("expected no headers, got %v", h)
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		ctx := WithPropagatedHeaders(context.Background(), http.Header{"X-Tenant-Id": {"acme"}, "Accept-Language": {"de"}})
+		ctx = WithPropagatedHeaders(ctx, http.Header{"Accept-Language": {"fr"}})
+		h := GetPropagatedHeaders(ctx)
+		if h.Get("X-Tenant-ID") != "acme" || h.Get("Accept-Language") != "fr" {
+			t.Errorf("unexpected merge %v", h)
+		}
+	})
+}
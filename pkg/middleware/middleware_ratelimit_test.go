@@ -1,10 +1,14 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/polymatx/goframe/pkg/auth"
 	"golang.org/x/time/rate"
 )
 
@@ -115,3 +119,105 @@ func TestRateLimiter_Cleanup(t *testing.T) {
 		t.Error("expected recently seen entry to survive cleanup")
 	}
 }
+
+// countingLimiter allows limit requests per key and records the keys seen
+type countingLimiter struct {
+	limit int
+	seen  map[string]int
+	err   error
+}
+
+func (l *countingLimiter) Allow(_ context.Context, key string) (RateLimitResult, error) {
+	if l.err != nil {
+		return RateLimitResult{}, l.err
+	}
+	l.seen[key]++
+	n := l.seen[key]
+	return RateLimitResult{
+		Allowed:    n <= l.limit,
+		Limit:      l.limit,
+		Remaining:  max(0, l.limit-n),
+		Reset:      1500 * time.Millisecond,
+		RetryAfter: 200 * time.Millisecond,
+	}, nil
+}
+
+func TestRateLimitBy(t *testing.T) {
+	t.Run("headers and rejection", func(t *testing.T) {
+		limiter := &countingLimiter{limit: 1, seen: map[string]int{}}
+		handler := RateLimitBy(RateLimitConfig{Limiter: limiter})(okHandler("ok"))
+
+		w := doRequest(handler, "10.0.0.1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "1" || w.Header().Get("X-RateLimit-Remaining") != "0" ||
+			w.Header().Get("X-RateLimit-Reset") != "2" {
+			t.Errorf("unexpected rate limit headers %v", w.Header())
+		}
+
+		w = doRequest(handler, "10.0.0.1")
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status 429, got %d", w.Code)
+		}
+		if w.Header().Get("Retry-After") != "1" {
+			t.Errorf("expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+		}
+		if limiter.seen["ip:10.0.0.1"] != 2 {
+			t.Errorf("expected requests keyed by IP, got %v", limiter.seen)
+		}
+	})
+
+	t.Run("key functions", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Real-IP", "10.0.0.2")
+		if got := KeyByUser(req); got != "ip:10.0.0.2" {
+			t.Errorf("anonymous KeyByUser = %q", got)
+		}
+		if got := KeyByHeader("X-API-Key")(req); got != "ip:10.0.0.2" {
+			t.Errorf("KeyByHeader without header = %q", got)
+		}
+
+		req.Header.Set("X-API-Key", "k1")
+		if got := KeyByHeader("X-API-Key")(req); got != "key:k1" {
+			t.Errorf("KeyByHeader = %q", got)
+		}
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UserID: "42"}))
+		if got := KeyByUser(req); got != "user:42" {
+			t.Errorf("KeyByUser = %q", got)
+		}
+	})
+
+	t.Run("route classes", func(t *testing.T) {
+		general := &countingLimiter{limit: 10, seen: map[string]int{}}
+		search := &countingLimiter{limit: 1, seen: map[string]int{}}
+		limited := RateLimitBy(RateLimitConfig{Limiter: general, Classes: map[string]Limiter{"search": search}})(okHandler("ok"))
+		withClass := func(class string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				limited.ServeHTTP(w, r.WithContext(WithRouteMeta(r.Context(), &RouteMeta{RateLimit: class})))
+			})
+		}
+
+		doRequest(withClass("search"), "10.0.0.1")
+		if w := doRequest(withClass("search"), "10.0.0.1"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("search class: expected status 429, got %d", w.Code)
+		}
+		if w := doRequest(withClass("unknown"), "10.0.0.1"); w.Code != http.StatusOK || general.seen["ip:10.0.0.1"] != 1 {
+			t.Errorf("unknown class: expected the default limiter, got %d %v", w.Code, general.seen)
+		}
+		if w := doRequest(RateLimitBy(RateLimitConfig{})(okHandler("ok")), "10.0.0.1"); w.Code != http.StatusOK {
+			t.Errorf("no limiter: expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("limiter failure", func(t *testing.T) {
+		limiter := &countingLimiter{err: errors.New("redis down")}
+		if w := doRequest(RateLimitBy(RateLimitConfig{Limiter: limiter})(okHandler("ok")), "10.0.0.1"); w.Code != http.StatusOK {
+			t.Errorf("fail open: expected status 200, got %d", w.Code)
+		}
+		closed := RateLimitBy(RateLimitConfig{Limiter: limiter, FailClosed: true})(okHandler("ok"))
+		if w := doRequest(closed, "10.0.0.1"); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("fail closed: expected status 503, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/xlog"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   RequestIDConfig
+		incoming string
+		want     string // "" expects a generated ID
+	}{
+		{"generated", RequestIDConfig{}, "", ""},
+		{"incoming reused", RequestIDConfig{}, "abc-123", "abc-123"},
+		{"too long replaced", RequestIDConfig{}, strings.Repeat("a", 129), ""},
+		{"control characters replaced", RequestIDConfig{}, "abc\x00def", ""},
+		{"spaces replaced", RequestIDConfig{}, "abc def", ""},
+		{"custom generator", RequestIDConfig{Generator: func() string { return "fixed" }}, "", "fixed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromCtx, fromHeader, logged string
+			handler := RequestID(tt.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromCtx = GetRequestID(r.Context())
+				fromHeader = r.Header.Get("X-Request-ID")
+				logged, _ = xlog.Get(r.Context()).Data["request_id"].(string)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.want == "" {
+				if len(fromCtx) != 32 || fromCtx == tt.incoming {
+					t.Errorf("expected a generated ID, got %q", fromCtx)
+				}
+			} else if fromCtx != tt.want {
+				t.Errorf("expected ID %q, got %q", tt.want, fromCtx)
+			}
+			if got := rec.Header().Get("X-Request-ID"); got != fromCtx {
+				t.Errorf("response header %q, context %q", got, fromCtx)
+			}
+			if fromHeader != fromCtx || logged != fromCtx {
+				t.Errorf("request header %q and log field %q should match %q", fromHeader, logged, fromCtx)
+			}
+		})
+	}
+}
+
+func TestRequestID_CustomHeader(t *testing.T) {
+	handler := RequestID(RequestIDConfig{Header: "X-Correlation-ID", LogField: "correlation_id"})(okHandler("ok"))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", "corr-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Correlation-ID"); got != "corr-1" {
+		t.Errorf("expected corr-1, got %q", got)
+	}
+	if rec.Header().Get("X-Request-ID") != "" {
+		t.Error("expected the default header to be unused")
+	}
+}
+
+func TestRequestID_Logger(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		handler http.Handler
+	}{
+		{"logger inside", RequestID(RequestIDConfig{})(Logger()(okHandler("ok")))},
+		{"logger outside", Logger()(RequestID(RequestIDConfig{})(okHandler("ok")))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := logrustest.NewGlobal()
+			defer hook.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Request-ID", "req-42")
+			tt.handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			entry := hook.LastEntry()
+			if entry == nil || entry.Data["request_id"] != "req-42" {
+				t.Errorf("expected request_id in the access log, got %v", entry)
+			}
+		})
+	}
+}
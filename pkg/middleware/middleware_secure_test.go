@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecure(t *testing.T) {
+	serve := func(h http.Handler, https bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if https {
+			r.Header.Set("X-Forwarded-Proto", "https")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		w := serve(Secure()(okHandler("ok")), true)
+		want := map[string]string{
+			"Strict-Transport-Security": "max-age=31536000",
+			"X-Content-Type-Options":    "nosniff",
+			"X-Frame-Options":           "DENY",
+			"Referrer-Policy":           "strict-origin-when-cross-origin",
+			"Content-Security-Policy":   "default-src 'self'; base-uri 'self'; object-src 'none'",
+		}
+		for name, value := range want {
+			if got := w.Header().Get(name); got != value {
+				t.Errorf("%s = %q, want %q", name, got, value)
+			}
+		}
+		if got := serve(Secure()(okHandler("ok")), false).Header().Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("HSTS over plain HTTP = %q, want none", got)
+		}
+	})
+
+	t.Run("custom configuration", func(t *testing.T) {
+		w := serve(SecureWith(SecureConfig{
+			HSTSMaxAge:            time.Hour,
+			HSTSIncludeSubdomains: true,
+			HSTSPreload:           true,
+			FrameOptions:          "SAMEORIGIN",
+			ReferrerPolicy:        "-",
+			ContentSecurityPolicy: DefaultCSP().Add("img-src", CSPSelf, "https://cdn.example.com").Remove("base-uri"),
+			CSPReportOnly:         true,
+		})(okHandler("ok")), true)
+
+		if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains; preload" {
+			t.Errorf("HSTS = %q", got)
+		}
+		if got := w.Header().Get("X-Frame-Options"); got != "SAMEORIGIN" {
+			t.Errorf("X-Frame-Options = %q", got)
+		}
+		if _, ok := w.Header()["Referrer-Policy"]; ok {
+			t.Error("expected Referrer-Policy to be omitted")
+		}
+		if got := w.Header().Get("Content-Security-Policy-Report-Only"); got != "default-src 'self'; object-src 'none'; img-src 'self' https://cdn.example.com" {
+			t.Errorf("report-only CSP = %q", got)
+		}
+		if got := w.Header().Get("Content-Security-Policy"); got != "" {
+			t.Errorf("enforced CSP = %q, want none", got)
+		}
+	})
+
+	t.Run("route override", func(t *testing.T) {
+		embed := SecureWith(SecureConfig{HSTSMaxAge: -1, FrameOptions: "-", ContentSecurityPolicy: NewCSP()})(okHandler("ok"))
+		w := serve(Secure()(embed), true)
+		for _, name := range []string{"Strict-Transport-Security", "X-Frame-Options", "Content-Security-Policy"} {
+			if got := w.Header().Get(name); got != "" {
+				t.Errorf("%s = %q, want it removed by the route", name, got)
+			}
+		}
+		if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Error("expected nosniff to stay")
+		}
+	})
+
+	t.Run("nonce", func(t *testing.T) {
+		var nonce string
+		policy := NewCSP().Set("default-src", CSPSelf).Set("script-src", CSPSelf, CSPStrictDynamic)
+		h := SecureWith(SecureConfig{ContentSecurityPolicy: policy, Nonce: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce = GetCSPNonce(r.Context())
+		}))
+
+		w := serve(h, false)
+		if nonce == "" {
+			t.Fatal("expected a nonce in the request context")
+		}
+		want := "default-src 'self'; script-src 'self' 'strict-dynamic' 'nonce-" + nonce + "'"
+		if got := w.Header().Get("Content-Security-Policy"); got != want {
+			t.Errorf("CSP = %q, want %q", got, want)
+		}
+		first := nonce
+		serve(h, false)
+		if nonce == first {
+			t.Error("expected a new nonce per request")
+		}
+		if strings.Contains(policy.String(), "nonce") {
+			t.Error("nonce leaked into the configured policy")
+		}
+	})
+}
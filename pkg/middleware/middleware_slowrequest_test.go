@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestSlowRequests(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer backend.Close()
+	client := &http.Client{Transport: SpanTransport(nil)}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{UserID: "42"}))
+		RecordRequest(r)
+		ctx := r.Context()
+
+		end := StartSpan(ctx, SpanDB, "SELECT * FROM orders WHERE id = ?")
+		time.Sleep(5 * time.Millisecond)
+		end(nil)
+		StartSpan(ctx, SpanCache, "GET order:1")(errors.New("timeout"))
+		for _, path := range []string{"/ok", "/fail"} {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL+path+"?q=1", nil)
+			if resp, err := client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	t.Run("slow request logged with spans", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		w := httptest.NewRecorder()
+		SlowRequests(SlowRequestConfig{Threshold: time.Millisecond})(router).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+
+		entry := hook.LastEntry()
+		if entry == nil || entry.Message != "Slow request" || entry.Level != logrus.WarnLevel {
+			t.Fatalf("expected slow request warning, got %+v", entry)
+		}
+		if entry.Data[LogRoute] != "/orders/{id}" || entry.Data[LogStatus] != http.StatusAccepted || entry.Data[LogUserID] != "42" {
+			t.Errorf("unexpected fields %v", entry.Data)
+		}
+
+		spans := entry.Data["spans"].([]Span)
+		if len(spans) != 4 {
+			t.Fatalf("expected 4 spans, got %+v", spans)
+		}
+		if spans[0].Kind != SpanDB || spans[0].Duration < 5 || spans[0].Error != "" {
+			t.Errorf("unexpected db span %+v", spans[0])
+		}
+		if spans[1].Kind != SpanCache || spans[1].Error != "timeout" {
+			t.Errorf("unexpected cache span %+v", spans[1])
+		}
+		host := backend.Listener.Addr().String()
+		if spans[2].Name != "GET "+host+"/ok" || spans[2].Error != "" || spans[3].Error != "status 502" {
+			t.Errorf("unexpected http spans %+v %+v", spans[2], spans[3])
+		}
+		if entry.Data["db_calls"] != 1 || entry.Data["http_calls"] != 2 || entry.Data["spans_total"] != 4 {
+			t.Errorf("unexpected totals %v", entry.Data)
+		}
+	})
+
+	t.Run("fast request not logged", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		SlowRequests(SlowRequestConfig{Threshold: time.Minute})(router).
+			ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+		if len(hook.AllEntries()) != 0 {
+			t.Errorf("expected no log, got %v", hook.AllEntries())
+		}
+	})
+
+	t.Run("spans capped", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		h := SlowRequests(SlowRequestConfig{Threshold: time.Nanosecond, MaxSpans: 2})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i < 5; i++ {
+				StartSpan(r.Context(), SpanDB, "SELECT 1")(nil)
+			}
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		entry := hook.LastEntry()
+		if entry == nil || len(entry.Data["spans"].([]Span)) != 2 || entry.Data["spans_total"] != 5 {
+			t.Errorf("expected 2 of 5 spans kept, got %+v", entry)
+		}
+	})
+
+	t.Run("no recorder", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if RecordingSpans(r.Context()) {
+			t.Error("expected no recording outside SlowRequests")
+		}
+		StartSpan(r.Context(), SpanDB, "SELECT 1")(nil)
+	})
+
+	t.Run("trace context", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		r := httptest.NewRequest(http.MethodGet, "/orders/1?expand=items", nil)
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r = r.WithContext(WithRequestID(r.Context(), "req-1"))
+		SlowRequests(SlowRequestConfig{Threshold: time.Nanosecond})(router).ServeHTTP(httptest.NewRecorder(), r)
+
+		entry := hook.LastEntry()
+		if entry == nil {
+			t.Fatal("expected slow request log")
+		}
+		for field, want := range map[string]interface{}{
+			"trace_id":       "4bf92f3577b34da6a3ce929d0e0e4736",
+			"parent_span_id": "00f067aa0ba902b7",
+			LogRequestID:     "req-1",
+			LogQuery:         "expand=items",
+		} {
+			if got := entry.Data[field]; got != want {
+				t.Errorf("%s = %v, want %v", field, got, want)
+			}
+		}
+	})
+
+	t.Run("goroutine dump past hard threshold", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		dir := t.TempDir()
+		h := SlowRequests(SlowRequestConfig{
+			Threshold:     time.Millisecond,
+			HardThreshold: 10 * time.Millisecond,
+			ProfileDir:    dir,
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+		}))
+		for i := 0; i < 2; i++ {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stuck", nil))
+		}
+
+		// The second request is within ProfileInterval of the first dump
+		files, _ := os.ReadDir(dir)
+		if len(files) != 1 {
+			t.Fatalf("expected 1 dump, got %d", len(files))
+		}
+		dump, _ := os.ReadFile(filepath.Join(dir, files[0].Name()))
+		if !strings.Contains(string(dump), "goroutine ") || !strings.Contains(string(dump), "time.Sleep") {
+			t.Errorf("expected goroutine stacks, got %q", dump)
+		}
+
+		var dumped, slow []*logrus.Entry
+		for _, e := range hook.AllEntries() {
+			switch e.Level {
+			case logrus.ErrorLevel:
+				dumped = append(dumped, e)
+			case logrus.WarnLevel:
+				slow = append(slow, e)
+			}
+		}
+		want := filepath.Join(dir, files[0].Name())
+		if len(dumped) != 1 || dumped[0].Data["profile"] != want || dumped[0].Data[LogPath] != "/stuck" {
+			t.Errorf("expected one dump error naming the file, got %+v", dumped)
+		}
+		if len(slow) != 2 || slow[0].Data["profile"] != want || slow[1].Data["profile"] != nil {
+			t.Errorf("expected the dump named in the first slow request only, got %+v", slow)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	variantB := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("B:" + GetVariant(r.Context())))
+	})
+	control := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("A:" + GetVariant(r.Context())))
+	})
+
+	var exposures []string
+	config := SplitConfig{
+		Name: "checkout",
+		Variants: []Variant{
+			{Name: "a", Weight: 50},
+			{Name: "b", Weight: 50, Handler: variantB},
+		},
+		Key:      func(r *http.Request) string { return r.Header.Get("X-User-ID") },
+		Override: func(r *http.Request) string { return r.Header.Get("X-Variant") },
+		OnExposure: func(r *http.Request, experiment, variant string) {
+			exposures = append(exposures, experiment+"="+variant)
+		},
+	}
+	handler := Split(config)(control)
+
+	serve := func(headers map[string]string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("sticky per key", func(t *testing.T) {
+		first := serve(map[string]string{"X-User-ID": "user-1"}).Body.String()
+		for i := 0; i < 5; i++ {
+			if got := serve(map[string]string{"X-User-ID": "user-1"}).Body.String(); got != first {
+				t.Fatalf("expected sticky variant %q, got %q", first, got)
+			}
+		}
+	})
+
+	t.Run("weights distribute traffic", func(t *testing.T) {
+		counts := map[string]int{}
+		for i := 0; i < 1000; i++ {
+			body := serve(map[string]string{"X-User-ID": fmt.Sprintf("user-%d", i)}).Body.String()
+			counts[body[:1]]++
+		}
+		if counts["A"] < 400 || counts["B"] < 400 {
+			t.Errorf("expected roughly even split, got %v", counts)
+		}
+	})
+
+	t.Run("override", func(t *testing.T) {
+		if got := serve(map[string]string{"X-User-ID": "user-1", "X-Variant": "b"}).Body.String(); got != "B:b" {
+			t.Errorf("expected forced variant b, got %q", got)
+		}
+		if got := serve(map[string]string{"X-User-ID": "user-1", "X-Variant": "a"}).Body.String(); got != "A:a" {
+			t.Errorf("expected forced variant a, got %q", got)
+		}
+	})
+
+	t.Run("anonymous cookie stickiness", func(t *testing.T) {
+		w := serve(nil)
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || !strings.HasPrefix(cookies[0].Name, "goframe_split_checkout") {
+			t.Fatalf("expected anonymous ID cookie, got %v", cookies)
+		}
+		first := w.Body.String()
+		for i := 0; i < 5; i++ {
+			w := serve(nil, cookies[0])
+			if w.Body.String() != first {
+				t.Fatalf("expected sticky variant %q, got %q", first, w.Body.String())
+			}
+			if len(w.Result().Cookies()) != 0 {
+				t.Error("expected existing cookie to be reused")
+			}
+		}
+	})
+
+	t.Run("exposure events", func(t *testing.T) {
+		exposures = nil
+		serve(map[string]string{"X-Variant": "b"})
+		if len(exposures) != 1 || exposures[0] != "checkout=b" {
+			t.Errorf("unexpected exposures %v", exposures)
+		}
+	})
+}
+
+func TestCanary(t *testing.T) {
+	canary := okHandler("canary")
+	stable := okHandler("stable")
+
+	none := Canary(0, canary)(stable)
+	all := Canary(100, canary)(stable)
+
+	for i := 0; i < 20; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		none.ServeHTTP(w, req)
+		if w.Body.String() != "stable" {
+			t.Fatalf("expected stable at 0%%, got %q", w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		all.ServeHTTP(w, req)
+		if w.Body.String() != "canary" {
+			t.Fatalf("expected canary at 100%%, got %q", w.Body.String())
+		}
+	}
+}
@@ -1,12 +1,21 @@
 package middleware
 
 import (
+	"bytes"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"syscall"
 	"testing"
 
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 	logrustest "github.com/sirupsen/logrus/hooks/test"
 )
@@ -93,6 +102,146 @@ func TestRecovery(t *testing.T) {
 	}
 }
 
+func TestRecovery_PanicHandler(t *testing.T) {
+	before := testutil.ToFloat64(httpPanicsTotal.WithLabelValues(http.MethodGet, "/reported/{id}"))
+
+	var gotRecovered interface{}
+	var gotStack []byte
+	handler := func(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) {
+		gotRecovered, gotStack = recovered, stack
+		panic("reporter down")
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/reported/{id}", func(w http.ResponseWriter, r *http.Request) {
+		RecordRequest(r)
+		panic("boom")
+	})
+	// Outside the router, as app.Use installs it
+	wrapped := Recovery()(router)
+	req := httptest.NewRequest(http.MethodGet, "/reported/42", nil)
+	req = req.WithContext(WithPanicHandler(req.Context(), handler))
+	w := httptest.NewRecorder()
+	wrapped.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+	if gotRecovered != "boom" {
+		t.Errorf("expected recovered value boom, got %v", gotRecovered)
+	}
+	if len(gotStack) == 0 {
+		t.Error("expected stack trace")
+	}
+	if got := testutil.ToFloat64(httpPanicsTotal.WithLabelValues(http.MethodGet, "/reported/{id}")); got != before+1 {
+		t.Errorf("expected panic counter %v, got %v", before+1, got)
+	}
+}
+
+func TestRecoveryWith(t *testing.T) {
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		name      string
+		config    RecoveryConfig
+		accept    string
+		wantCT    string
+		wantBody  []string
+		forbidden []string
+	}{
+		{"default json", RecoveryConfig{}, "", "application/json", []string{`"error":"Internal Server Error"`}, []string{"boom"}},
+		{"problem details", RecoveryConfig{}, "application/problem+json", "application/problem+json", []string{`"status":500`, `"title":"Internal Server Error"`}, []string{"boom"}},
+		{"html for browsers", RecoveryConfig{}, "text/html,application/xhtml+xml,*/*;q=0.8", "text/html; charset=utf-8", []string{"<h1>Internal Server Error</h1>"}, []string{"boom"}},
+		{"refused html", RecoveryConfig{}, "text/html;q=0, application/json", "application/json", []string{`"error"`}, nil},
+		{"dev json", RecoveryConfig{Dev: true}, "", "application/json", []string{`"detail":"boom"`, `"stack":"goroutine`}, nil},
+		{"dev html", RecoveryConfig{Dev: true}, "text/html", "text/html; charset=utf-8", []string{"<h2>boom</h2>", "<pre>goroutine"}, nil},
+		{"custom renderer", RecoveryConfig{Render: func(w http.ResponseWriter, r *http.Request, p Panic) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("oops"))
+		}}, "", "", []string{"oops"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			RecoveryWith(tt.config)(panicking).ServeHTTP(w, req)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("expected status 500, got %d", w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantCT {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantCT, got)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("expected body to contain %q, got %q", want, w.Body.String())
+				}
+			}
+			for _, unwanted := range tt.forbidden {
+				if strings.Contains(w.Body.String(), unwanted) {
+					t.Errorf("expected body without %q, got %q", unwanted, w.Body.String())
+				}
+			}
+		})
+	}
+
+	t.Run("reporter", func(t *testing.T) {
+		var reported []interface{}
+		report := func(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) {
+			reported = append(reported, recovered)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(WithPanicHandler(req.Context(), report))
+		RecoveryWith(RecoveryConfig{Reporter: report})(panicking).ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(reported) != 2 || reported[0] != "boom" {
+			t.Errorf("expected reporter and panic handler to get boom, got %v", reported)
+		}
+	})
+
+	t.Run("broken pipe", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+		before := testutil.ToFloat64(httpPanicsTotal.WithLabelValues(http.MethodGet, UnmatchedRoute))
+		reported := false
+		h := RecoveryWith(RecoveryConfig{Reporter: func(http.ResponseWriter, *http.Request, interface{}, []byte) {
+			reported = true
+		}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gone", nil))
+
+		if w.Body.Len() != 0 || reported {
+			t.Errorf("expected no response or report, got %q reported %v", w.Body.String(), reported)
+		}
+		if got := testutil.ToFloat64(httpPanicsTotal.WithLabelValues(http.MethodGet, UnmatchedRoute)); got != before {
+			t.Errorf("expected panic counter %v, got %v", before, got)
+		}
+		for _, entry := range hook.AllEntries() {
+			if entry.Level <= logrus.ErrorLevel {
+				t.Errorf("expected no error logs, got %q", entry.Message)
+			}
+		}
+	})
+
+	t.Run("abort handler", func(t *testing.T) {
+		defer func() {
+			if got := recover(); got != http.ErrAbortHandler {
+				t.Errorf("expected ErrAbortHandler to propagate, got %v", got)
+			}
+		}()
+		RecoveryWith(RecoveryConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
 func TestLogger(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -161,6 +310,65 @@ func TestLogger(t *testing.T) {
 	}
 }
 
+func TestLoggerWith(t *testing.T) {
+	withClaims := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{UserID: "alice"}))
+			RecordRequest(r)
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		router := mux.NewRouter()
+		router.Handle("/users/{id}", withClaims(okHandler("ok")))
+		handler := LoggerWith(LoggerConfig{Fields: []string{LogStatus, LogRoute, LogUserID}})(router)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+		entry := hook.LastEntry()
+		if entry == nil {
+			t.Fatal("expected a log entry to be emitted")
+		}
+		want := logrus.Fields{LogStatus: http.StatusOK, LogRoute: "/users/{id}", LogUserID: "alice"}
+		if !reflect.DeepEqual(entry.Data, want) {
+			t.Errorf("expected fields %v, got %v", want, entry.Data)
+		}
+	})
+
+	t.Run("skip paths", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		handler := LoggerWith(LoggerConfig{SkipPaths: []string{"/healthz", "/debug/*"}})(okHandler("ok"))
+		for _, path := range []string{"/healthz", "/debug/pprof/heap", "/healthz/deep"} {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+		if len(hook.Entries) != 1 || hook.LastEntry().Data[LogPath] != "/healthz/deep" {
+			t.Errorf("expected only /healthz/deep logged, got %d entries", len(hook.Entries))
+		}
+	})
+
+	t.Run("combined format", func(t *testing.T) {
+		var out bytes.Buffer
+		handler := LoggerWith(LoggerConfig{Format: LogFormatCombined, Output: &out})(withClaims(okHandler("hello")))
+
+		req := httptest.NewRequest(http.MethodGet, "/search?q=go", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Referer", "https://example.com/")
+		req.Header.Set("User-Agent", "curl/8.0")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		line := regexp.MustCompile(`\[[^]]+\]`).ReplaceAllString(out.String(), "[time]")
+		want := `10.0.0.1 - alice [time] "GET /search?q=go HTTP/1.1" 200 5 "https://example.com/" "curl/8.0"` + "\n"
+		if line != want {
+			t.Errorf("expected line\n%q, got\n%q", want, line)
+		}
+	})
+}
+
 func TestGetClientIP(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -207,3 +415,22 @@ func TestGetClientIP(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteTemplate(t *testing.T) {
+	var got string
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		got = RouteTemplate(r)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/42", nil))
+	if got != "/orders/{id}" {
+		t.Errorf("RouteTemplate() = %q, want /orders/{id}", got)
+	}
+	if tmpl := RouteTemplate(httptest.NewRequest("GET", "/orders/42", nil)); tmpl != "" {
+		t.Errorf("RouteTemplate() without a route = %q, want empty", tmpl)
+	}
+	if tmpl := RouteTemplate(nil); tmpl != "" {
+		t.Errorf("RouteTemplate(nil) = %q, want empty", tmpl)
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Run("fast handler passes through", func(t *testing.T) {
+		handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Test", "1")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("ok"))
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Code != http.StatusCreated || w.Body.String() != "ok" {
+			t.Errorf("unexpected response %d %q", w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Test") != "1" {
+			t.Error("expected handler headers to be copied")
+		}
+	})
+
+	t.Run("slow handler times out", func(t *testing.T) {
+		canceled := make(chan error, 1)
+		handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			_, err := w.Write([]byte("late"))
+			canceled <- err
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", w.Code)
+		}
+		if w.Body.String() != `{"error":"Request timeout"}` {
+			t.Errorf("unexpected body %q", w.Body.String())
+		}
+		err := <-canceled
+		if !errors.Is(err, http.ErrHandlerTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected ErrHandlerTimeout and DeadlineExceeded for late write, got %v", err)
+		}
+	})
+
+	t.Run("panic propagates", func(t *testing.T) {
+		handler := Recovery()(Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", w.Code)
+		}
+	})
+
+	t.Run("late panic is reported", func(t *testing.T) {
+		reported := make(chan interface{}, 1)
+		handler := Recovery()(Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			panic("late boom")
+		})))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(WithPanicHandler(req.Context(), func(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) {
+			reported <- recovered
+		}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", w.Code)
+		}
+		select {
+		case p := <-reported:
+			if p != "late boom" {
+				t.Errorf("expected late panic to be reported, got %v", p)
+			}
+		case <-time.After(time.Second):
+			t.Error("late panic was not reported")
+		}
+	})
+}
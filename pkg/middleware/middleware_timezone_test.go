@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimezone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	profile := func(r *http.Request) string { return r.Header.Get("X-User-Timezone") }
+
+	tests := []struct {
+		name    string
+		config  TimezoneConfig
+		target  string
+		headers map[string]string
+		want    string
+	}{
+		{"default UTC", TimezoneConfig{}, "/", nil, "UTC"},
+		{"custom default", TimezoneConfig{Default: berlin}, "/", nil, "Europe/Berlin"},
+		{"header", TimezoneConfig{}, "/", map[string]string{"Time-Zone": "Asia/Tokyo"}, "Asia/Tokyo"},
+		{"query overrides header", TimezoneConfig{}, "/?tz=America/New_York", map[string]string{"Time-Zone": "Asia/Tokyo"}, "America/New_York"},
+		{
+			"profile overrides header",
+			TimezoneConfig{Resolve: profile},
+			"/",
+			map[string]string{"Time-Zone": "Asia/Tokyo", "X-User-Timezone": "Europe/Berlin"},
+			"Europe/Berlin",
+		},
+		{"invalid name falls through", TimezoneConfig{}, "/?tz=Mars/Olympus", map[string]string{"Time-Zone": "Asia/Tokyo"}, "Asia/Tokyo"},
+		{"local is ignored", TimezoneConfig{}, "/?tz=Local", nil, "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := Timezone(tt.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = GetLocation(r.Context()).String()
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("expected location %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetLocation_NoMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if loc := GetLocation(req.Context()); loc != time.UTC {
+		t.Errorf("expected UTC without middleware, got %v", loc)
+	}
+}
@@ -0,0 +1,267 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/polymatx/goframe/pkg/xlog"
+	"github.com/sirupsen/logrus"
+)
+
+// PaginationConfig holds pagination guard configuration
+type PaginationConfig struct {
+	// MaxItems is the longest list served to requests without pagination
+	// parameters (default 1000)
+	MaxItems int
+
+	// MaxBytes is the largest body served to them (default 1MB)
+	MaxBytes int
+
+	// Params are the query parameters marking a request paginated (default
+	// page, per_page, page_size, limit, offset and cursor)
+	Params []string
+
+	// ListFields are the fields of a JSON object holding its list, for
+	// envelopes such as {"data": [...]} (default data, items, results)
+	ListFields []string
+
+	// Truncate serves the first MaxItems items, with X-Total-Count and
+	// X-Truncated, instead of rejecting the request. Bodies over MaxBytes
+	// are always rejected.
+	Truncate bool
+}
+
+// PaginationGuard middleware rejects GET requests without pagination
+// parameters whose JSON response lists more than MaxItems items or exceeds
+// MaxBytes, with 400 asking for pagination, so an unbounded GetAll cannot
+// dump a whole table. Responses of paginated requests pass through.
+func PaginationGuard(config PaginationConfig) func(http.Handler) http.Handler {
+	if config.MaxItems <= 0 {
+		config.MaxItems = 1000
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = 1 << 20
+	}
+	if len(config.Params) == 0 {
+		config.Params = []string{"page", "per_page", "page_size", "limit", "offset", "cursor"}
+	}
+	if len(config.ListFields) == 0 {
+		config.ListFields = []string{"data", "items", "results"}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || paginated(r, config.Params) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			pw := &paginationWriter{ResponseWriter: w, config: &config, r: r}
+			next.ServeHTTP(pw, r)
+			pw.finish()
+		})
+	}
+}
+
+func paginated(r *http.Request, params []string) bool {
+	query := r.URL.Query()
+	for _, param := range params {
+		if query.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+type paginationMode int
+
+const (
+	paginationPending     paginationMode = iota // Header not written yet
+	paginationBuffering                         // Holding the body to count its items
+	paginationPassthrough                       // Writing through
+	paginationRejected                          // Over MaxBytes; dropping the body
+)
+
+type paginationWriter struct {
+	http.ResponseWriter
+	config *PaginationConfig
+	r      *http.Request
+
+	mode   paginationMode
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *paginationWriter) WriteHeader(code int) {
+	if w.mode != paginationPending {
+		return
+	}
+	if code >= 100 && code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+
+	if code != http.StatusOK || !isJSON(w.Header().Get("Content-Type")) {
+		w.mode = paginationPassthrough
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if n, err := strconv.Atoi(w.Header().Get("Content-Length")); err == nil && n > w.config.MaxBytes {
+		w.reject("bytes", n)
+		return
+	}
+	w.mode = paginationBuffering
+}
+
+func (w *paginationWriter) Write(b []byte) (int, error) {
+	if w.mode == paginationPending {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	switch w.mode {
+	case paginationRejected:
+		return len(b), nil
+	case paginationBuffering:
+		w.buf.Write(b)
+		if w.buf.Len() > w.config.MaxBytes {
+			w.reject("bytes", w.buf.Len())
+		}
+		return len(b), nil
+	default:
+		return w.ResponseWriter.Write(b)
+	}
+}
+
+// Flush streams the response unchecked, since a flushing handler sends it
+// in parts on purpose
+func (w *paginationWriter) Flush() {
+	switch w.mode {
+	case paginationPending:
+		w.WriteHeader(http.StatusOK)
+		if w.mode == paginationBuffering {
+			w.stream(w.buf.Bytes())
+		}
+	case paginationBuffering:
+		w.stream(w.buf.Bytes())
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && w.mode == paginationPassthrough {
+		f.Flush()
+	}
+}
+
+// Unwrap supports http.ResponseController
+func (w *paginationWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish counts the items of a buffered body, then sends, truncates or
+// rejects it
+func (w *paginationWriter) finish() {
+	if w.mode == paginationPending && w.status != 0 {
+		w.WriteHeader(w.status)
+	}
+	if w.mode != paginationBuffering {
+		return
+	}
+
+	body := w.buf.Bytes()
+	items, field, envelope := w.list(body)
+	if len(items) <= w.config.MaxItems {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.stream(body)
+		return
+	}
+	if !w.config.Truncate {
+		w.reject("items", len(items))
+		return
+	}
+
+	var truncated interface{} = items[:w.config.MaxItems]
+	if envelope != nil {
+		envelope[field], _ = json.Marshal(truncated)
+		truncated = envelope
+	}
+	out, err := json.Marshal(truncated)
+	if err != nil {
+		w.stream(body)
+		return
+	}
+	out = append(out, '\n')
+	h := w.Header()
+	h.Set("Content-Length", strconv.Itoa(len(out)))
+	h.Set("X-Total-Count", strconv.Itoa(len(items)))
+	h.Set("X-Truncated", "true")
+	w.stream(out)
+}
+
+// list returns the items of a top-level array, or of the list field of an
+// object and the object
+func (w *paginationWriter) list(body []byte) ([]json.RawMessage, string, map[string]json.RawMessage) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, "", nil
+	}
+	switch trimmed[0] {
+	case '[':
+		var items []json.RawMessage
+		if json.Unmarshal(trimmed, &items) == nil {
+			return items, "", nil
+		}
+	case '{':
+		var envelope map[string]json.RawMessage
+		if json.Unmarshal(trimmed, &envelope) != nil {
+			return nil, "", nil
+		}
+		for _, field := range w.config.ListFields {
+			var items []json.RawMessage
+			if raw, ok := envelope[field]; ok && json.Unmarshal(raw, &items) == nil {
+				return items, field, envelope
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// stream writes body and passes the rest through
+func (w *paginationWriter) stream(body []byte) {
+	w.mode = paginationPassthrough
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+	w.buf = bytes.Buffer{}
+}
+
+// reject answers 400 asking for pagination, and warns so the unbounded
+// endpoint gets noticed
+func (w *paginationWriter) reject(limit string, size int) {
+	w.mode = paginationRejected
+	w.buf = bytes.Buffer{}
+	xlog.GetWithFields(w.r.Context(), logrus.Fields{
+		"method": w.r.Method,
+		"path":   w.r.URL.Path,
+		limit:    size,
+	}).Warn("unpaginated response exceeds the pagination limit")
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	w.ResponseWriter.WriteHeader(http.StatusBadRequest)
+	body, _ := json.Marshal(map[string]interface{}{
+		"error":     "Response too large, paginate with one of: " + strings.Join(w.config.Params, ", "),
+		"max_items": w.config.MaxItems,
+	})
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+// isJSON reports whether contentType is JSON, including +json types
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
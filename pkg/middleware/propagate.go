@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type propagatedHeadersKey struct{}
+
+// DefaultPropagatedHeaders are the headers PropagateHeaders captures by
+// default: the request ID, tenant, locale and trace context. Authorization
+// is not among them; add it only when downstream services trust the same
+// tokens.
+var DefaultPropagatedHeaders = []string{
+	"X-Request-ID", "X-Tenant-ID", "Accept-Language", "traceparent", "tracestate", "baggage",
+}
+
+// PropagateConfig holds header propagation configuration
+type PropagateConfig struct {
+	Headers []string // Inbound headers to capture (default DefaultPropagatedHeaders)
+}
+
+// PropagateHeaders middleware captures the configured inbound headers into
+// the request context, for pkg/httpclient to re-attach to the outbound
+// requests made while serving the request. Run it after RequestID so a
+// generated request ID is captured too.
+func PropagateHeaders(config PropagateConfig) func(http.Handler) http.Handler {
+	if len(config.Headers) == 0 {
+		config.Headers = DefaultPropagatedHeaders
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			captured := make(http.Header, len(config.Headers))
+			for _, name := range config.Headers {
+				if values := r.Header.Values(name); len(values) > 0 {
+					captured[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+				}
+			}
+			if len(captured) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithPropagatedHeaders(r.Context(), captured)))
+		})
+	}
+}
+
+// WithPropagatedHeaders returns a context carrying headers to propagate,
+// e.g. for a background job continuing a request. Headers already in ctx
+// are kept unless h sets them.
+func WithPropagatedHeaders(ctx context.Context, h http.Header) context.Context {
+	if prev := GetPropagatedHeaders(ctx); len(prev) > 0 {
+		merged := prev.Clone()
+		for name, values := range h {
+			merged[name] = values
+		}
+		h = merged
+	}
+	return context.WithValue(ctx, propagatedHeadersKey{}, h)
+}
+
+// GetPropagatedHeaders returns the headers to propagate, or nil if
+// PropagateHeaders did not run. The header must not be modified.
+func GetPropagatedHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(propagatedHeadersKey{}).(http.Header)
+	return h
+}
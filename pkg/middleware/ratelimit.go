@@ -1,10 +1,17 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/polymatx/goframe/pkg/auth"
+	fwmiddleware "github.com/polymatx/goframe/pkg/framework/middleware"
+	"github.com/polymatx/goframe/pkg/i18n"
+	"github.com/polymatx/goframe/pkg/xlog"
 	"golang.org/x/time/rate"
 )
 
@@ -87,9 +94,7 @@ func RateLimit(requestsPerSecond float64, burst int) func(http.Handler) http.Han
 			limiter := rl.getLimiter(ip)
 
 			if !limiter.Allow() {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusTooManyRequests)
-				_, _ = w.Write([]byte(`{"error":"Rate limit exceeded"}`))
+				writeErrorCode(w, r, http.StatusTooManyRequests, i18n.CodeRateLimited)
 				return
 			}
 
@@ -97,3 +102,129 @@ func RateLimit(requestsPerSecond float64, burst int) func(http.Handler) http.Han
 		})
 	}
 }
+
+// RateLimitResult is the outcome of a rate limit check
+type RateLimitResult = fwmiddleware.RateLimitResult
+
+// Limiter decides whether the requests of a key may proceed, e.g. a
+// cache.RateLimiter shared by all instances through Redis
+type Limiter interface {
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc returns the key a request is limited by, or "" to skip
+// the limit
+type RateLimitKeyFunc func(r *http.Request) string
+
+// KeyByIP limits requests per client IP
+func KeyByIP(r *http.Request) string {
+	return "ip:" + getClientIP(r)
+}
+
+// KeyByUser limits requests per authenticated user, and anonymous requests
+// per client IP
+func KeyByUser(r *http.Request) string {
+	if claims, ok := auth.GetClaims(r.Context()); ok && claims.UserID != "" {
+		return "user:" + claims.UserID
+	}
+	return KeyByIP(r)
+}
+
+// KeyByHeader limits requests per value of a header such as X-API-Key, and
+// requests without it per client IP
+func KeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(name); v != "" {
+			return "key:" + v
+		}
+		return KeyByIP(r)
+	}
+}
+
+// RateLimitConfig configures RateLimitBy
+type RateLimitConfig struct {
+	Limiter Limiter          // Routes without a rate limit class; nil leaves them unlimited
+	Key     RateLimitKeyFunc // Default KeyByIP
+
+	// Classes are the limiters of the rate limit classes declared in route
+	// metadata, e.g. "search" or "auth"; unknown classes use Limiter
+	Classes map[string]Limiter
+
+	// FailClosed rejects requests with 503 when the limiter fails; by
+	// default they are let through
+	FailClosed bool
+}
+
+// RateLimitBy middleware limits requests per key with a pluggable limiter.
+// Responses carry X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (seconds until the window ends), and rejected requests
+// Retry-After.
+func RateLimitBy(config RateLimitConfig) func(http.Handler) http.Handler {
+	return Describe(Descriptor{Name: "middleware.RateLimitBy"}, rateLimitBy(config))
+}
+
+// RateLimitByUser is RateLimitBy keyed by KeyByUser. It must run after the
+// authentication middleware, or every request is limited by IP;
+// App.MiddlewareChains flags chains where it does not.
+func RateLimitByUser(config RateLimitConfig) func(http.Handler) http.Handler {
+	config.Key = KeyByUser
+	return Describe(Descriptor{Name: "middleware.RateLimitByUser", NeedsClaims: true}, rateLimitBy(config))
+}
+
+func rateLimitBy(config RateLimitConfig) func(http.Handler) http.Handler {
+	if config.Key == nil {
+		config.Key = KeyByIP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := config.Limiter
+			if class := GetRouteMeta(r.Context()).RateLimit; class != "" && config.Classes[class] != nil {
+				limiter = config.Classes[class]
+			}
+			key := config.Key(r)
+			if limiter == nil || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := limiter.Allow(r.Context(), key)
+			if err != nil {
+				xlog.GetWithError(r.Context(), err).Warn("rate limiter failed")
+				if config.FailClosed {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusServiceUnavailable)
+					_, _ = w.Write([]byte(`{"error":"Rate limiter unavailable"}`))
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(res.RetryAfter))))
+				writeErrorCode(w, r, http.StatusTooManyRequests, i18n.CodeRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// writeErrorCode writes {"error": message} with the message of an i18n code
+// in the request locale
+func writeErrorCode(w http.ResponseWriter, r *http.Request, status int, code string) {
+	body, _ := json.Marshal(map[string]string{"error": i18n.T(r, code)})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
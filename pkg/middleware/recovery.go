@@ -1,31 +1,229 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
 )
 
+// PanicHandler is notified of panics recovered by Recovery, e.g. to report
+// them to an error tracker. The 500 response is written after it returns.
+type PanicHandler func(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte)
+
+type panicHandlerKey struct{}
+
+// WithPanicHandler returns a context whose recovered panics are passed to handler
+func WithPanicHandler(ctx context.Context, handler PanicHandler) context.Context {
+	return context.WithValue(ctx, panicHandlerKey{}, handler)
+}
+
+// GetPanicHandler returns the panic handler of the request, if any
+func GetPanicHandler(ctx context.Context) PanicHandler {
+	handler, _ := ctx.Value(panicHandlerKey{}).(PanicHandler)
+	return handler
+}
+
+// Panic describes a recovered panic to a PanicRenderer
+type Panic struct {
+	Recovered interface{}
+	Stack     []byte // Nil unless RecoveryConfig.Dev is set
+}
+
+// PanicRenderer writes the response to a recovered panic
+type PanicRenderer func(w http.ResponseWriter, r *http.Request, p Panic)
+
+// RecoveryConfig holds recovery configuration
+type RecoveryConfig struct {
+	// Render writes the 500 response (default RenderPanic)
+	Render PanicRenderer
+
+	// Dev passes the panic value and stack to Render, which shows them to
+	// the client. Enable it in development only.
+	Dev bool
+
+	// Reporter is notified of recovered panics along with the PanicHandler
+	// of the request, e.g. to send them to an error tracker
+	Reporter PanicHandler
+}
+
 // Recovery middleware recovers from panics
 func Recovery() func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
+	return Describe(Descriptor{Name: "middleware.Recovery", Recovers: true}, RecoveryWith(RecoveryConfig{}))
+}
+
+// RecoveryWith middleware recovers from panics with config. Panics are
+// logged with their stack, counted in http_panics_total by route template
+// and reported before Render writes the response. Panics from clients gone
+// away, such as a broken pipe, are logged at info level without a response;
+// http.ErrAbortHandler is panicked again so the server aborts the
+// connection.
+func RecoveryWith(config RecoveryConfig) func(http.Handler) http.Handler {
+	if config.Render == nil {
+		config.Render = RenderPanic
+	}
+
+	return Describe(Descriptor{Name: "middleware.RecoveryWith", Recovers: true}, func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, routed := trackRouting(r)
 			defer func() {
-				if err := recover(); err != nil {
+				err := recover()
+				if err == nil {
+					return
+				}
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				if clientGone(err) {
 					logrus.WithFields(logrus.Fields{
 						"error": err,
-						"stack": string(debug.Stack()),
 						"path":  r.URL.Path,
-					}).Error("Panic recovered")
+					}).Info("Client connection closed")
+					return
+				}
+
+				stack := debug.Stack()
+				route := RouteTemplate(routed.Load())
+				if route == "" {
+					route = UnmatchedRoute
+				}
+				httpPanicsTotal.WithLabelValues(r.Method, route).Inc()
+
+				logrus.WithFields(logrus.Fields{
+					"error": err,
+					"stack": string(stack),
+					"path":  r.URL.Path,
+				}).Error("Panic recovered")
 
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusInternalServerError)
-					_, _ = w.Write([]byte(`{"error":"Internal Server Error"}`))
+				if config.Reporter != nil {
+					notifyPanic(config.Reporter, w, r, err, stack)
+				}
+				if handler := GetPanicHandler(r.Context()); handler != nil {
+					notifyPanic(handler, w, r, err, stack)
 				}
+
+				p := Panic{}
+				if config.Dev {
+					p = Panic{Recovered: err, Stack: stack}
+				}
+				config.Render(w, r, p)
 			}()
 
 			next.ServeHTTP(w, r)
 		})
+	})
+}
+
+// notifyPanic keeps a failing reporter from escaping the recovery
+func notifyPanic(handler PanicHandler, w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			logrus.WithField("error", err).Error("Panic handler panicked")
+		}
+	}()
+	handler(w, r, recovered, stack)
+}
+
+// clientGone reports whether recovered is a write to a connection the
+// client closed
+func clientGone(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	return ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET))
+}
+
+// RenderPanic is the default PanicRenderer. It serves an HTML page to
+// browsers, RFC 9457 problem details to clients accepting
+// application/problem+json, and {"error":"Internal Server Error"} to the
+// rest. The panic value and stack are included when set.
+func RenderPanic(w http.ResponseWriter, r *http.Request, p Panic) {
+	switch panicMediaType(r) {
+	case "text/html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = panicPage.Execute(w, p.fields())
+	case "application/problem+json":
+		body := map[string]interface{}{
+			"type":   "about:blank",
+			"title":  http.StatusText(http.StatusInternalServerError),
+			"status": http.StatusInternalServerError,
+		}
+		p.addTo(body)
+		writePanicJSON(w, "application/problem+json", body)
+	default:
+		body := map[string]interface{}{"error": "Internal Server Error"}
+		p.addTo(body)
+		writePanicJSON(w, "application/json", body)
+	}
+}
+
+// fields are the panic value and stack the HTML page shows, none unless set
+func (p Panic) fields() map[string]string {
+	if p.Stack == nil {
+		return map[string]string{}
 	}
+	return map[string]string{"Detail": fmt.Sprint(p.Recovered), "Stack": string(p.Stack)}
 }
+
+// addTo adds the panic value and stack to a JSON body, when set
+func (p Panic) addTo(body map[string]interface{}) {
+	if p.Stack != nil {
+		body["detail"], body["stack"] = fmt.Sprint(p.Recovered), string(p.Stack)
+	}
+}
+
+func writePanicJSON(w http.ResponseWriter, contentType string, body map[string]interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusInternalServerError)
+	data, _ := json.Marshal(body)
+	_, _ = w.Write(data)
+}
+
+// panicMediaType returns the first of the media types RenderPanic writes
+// that Accept lists, application/json when none is
+func panicMediaType(r *http.Request) string {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(accept), ";")
+		if refused(params) {
+			continue
+		}
+		switch mediaType = strings.TrimSpace(mediaType); mediaType {
+		case "text/html", "application/problem+json", "application/json":
+			return mediaType
+		}
+	}
+	return "application/json"
+}
+
+// refused reports whether the parameters of an Accept entry give it q=0
+func refused(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(key, "q") {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			return err == nil && q == 0
+		}
+	}
+	return false
+}
+
+var panicPage = template.Must(template.New("panic").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>500 Internal Server Error</title></head>
+<body>
+<h1>Internal Server Error</h1>
+<p>Something went wrong on our side. Please try again later.</p>
+{{- if .Detail}}
+<h2>{{.Detail}}</h2>
+<pre>{{.Stack}}</pre>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/polymatx/goframe/pkg/reqctx"
+	"github.com/polymatx/goframe/pkg/xlog"
+)
+
+// RequestIDConfig holds request ID configuration
+type RequestIDConfig struct {
+	Header    string        // Request and response header (default "X-Request-ID")
+	Generator func() string // Creates IDs for requests without one (default 32 hex chars)
+	LogField  string        // xlog field carrying the ID (default "request_id")
+}
+
+// RequestID middleware reuses the request ID sent by the client or a proxy,
+// or generates one, and stores it in the request context, the xlog fields
+// and the response header. Incoming IDs longer than 128 characters or with
+// non-printable characters are replaced.
+func RequestID(config RequestIDConfig) func(http.Handler) http.Handler {
+	if config.Header == "" {
+		config.Header = "X-Request-ID"
+	}
+	if config.Generator == nil {
+		config.Generator = NewRequestID
+	}
+	if config.LogField == "" {
+		config.LogField = "request_id"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(config.Header)
+			if !validRequestID(id) {
+				id = config.Generator()
+				r.Header.Set(config.Header, id)
+			}
+			w.Header().Set(config.Header, id)
+
+			ctx := xlog.SetField(WithRequestID(r.Context(), id), config.LogField, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// NewRequestID returns a random 128-bit ID in hex
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return reqctx.WithRequestID(ctx, id)
+}
+
+// GetRequestID returns the request ID, or "" if the RequestID middleware did
+// not run
+func GetRequestID(ctx context.Context) string {
+	return reqctx.GetRequestID(ctx)
+}
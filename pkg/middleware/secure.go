@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Content-Security-Policy source keywords
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+	CSPData          = "data:"
+)
+
+type cspDirective struct {
+	name    string
+	sources []string
+}
+
+// CSP builds a Content-Security-Policy, keeping directives in the order
+// they are added:
+//
+//	middleware.DefaultCSP().Add("img-src", "https://cdn.example.com").Set("frame-ancestors", middleware.CSPNone)
+type CSP struct {
+	directives []cspDirective
+}
+
+// NewCSP returns an empty policy
+func NewCSP() *CSP {
+	return &CSP{}
+}
+
+// DefaultCSP returns a policy allowing resources from the same origin only:
+// default-src 'self'; base-uri 'self'; object-src 'none'
+func DefaultCSP() *CSP {
+	return NewCSP().Set("default-src", CSPSelf).Set("base-uri", CSPSelf).Set("object-src", CSPNone)
+}
+
+// Add adds sources to directive, creating it if needed
+func (c *CSP) Add(directive string, sources ...string) *CSP {
+	for i := range c.directives {
+		if c.directives[i].name == directive {
+			for _, s := range sources {
+				if !slices.Contains(c.directives[i].sources, s) {
+					c.directives[i].sources = append(c.directives[i].sources, s)
+				}
+			}
+			return c
+		}
+	}
+	c.directives = append(c.directives, cspDirective{name: directive, sources: slices.Clone(sources)})
+	return c
+}
+
+// Set replaces the sources of directive. Directives without sources, such
+// as upgrade-insecure-requests, are set with none.
+func (c *CSP) Set(directive string, sources ...string) *CSP {
+	c.Remove(directive)
+	return c.Add(directive, sources...)
+}
+
+// Remove removes directive
+func (c *CSP) Remove(directive string) *CSP {
+	c.directives = slices.DeleteFunc(c.directives, func(d cspDirective) bool { return d.name == directive })
+	return c
+}
+
+// Clone returns a copy of the policy, e.g. to adjust the default for a route
+func (c *CSP) Clone() *CSP {
+	out := &CSP{directives: make([]cspDirective, len(c.directives))}
+	for i, d := range c.directives {
+		out.directives[i] = cspDirective{name: d.name, sources: slices.Clone(d.sources)}
+	}
+	return out
+}
+
+// String returns the header value
+func (c *CSP) String() string {
+	return c.render("")
+}
+
+// render returns the header value with nonce added to script-src and
+// style-src
+func (c *CSP) render(nonce string) string {
+	parts := make([]string, 0, len(c.directives))
+	for _, d := range c.directives {
+		sources := d.sources
+		if nonce != "" && (d.name == "script-src" || d.name == "style-src") {
+			sources = append(slices.Clone(sources), "'nonce-"+nonce+"'")
+		}
+		parts = append(parts, strings.TrimSpace(d.name+" "+strings.Join(sources, " ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+type cspNonceKey struct{}
+
+// GetCSPNonce returns the nonce of the request for inline scripts and
+// styles, <script nonce="...">, or "" when SecureConfig.Nonce is off
+func GetCSPNonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(cspNonceKey{}).(string)
+	return nonce
+}
+
+// SecureConfig holds security header configuration. String fields set to
+// "-" omit their header, which lets a route remove a header set by the
+// application middleware.
+type SecureConfig struct {
+	HSTSMaxAge            time.Duration // Default 1 year; negative omits Strict-Transport-Security
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+
+	FrameOptions   string // X-Frame-Options (default "DENY")
+	ReferrerPolicy string // Referrer-Policy (default "strict-origin-when-cross-origin")
+
+	// ContentSecurityPolicy defaults to DefaultCSP; an empty policy omits
+	// the header
+	ContentSecurityPolicy *CSP
+	CSPReportOnly         bool // Send Content-Security-Policy-Report-Only to trial a policy
+
+	// Nonce adds a per-request nonce to the script-src and style-src
+	// directives, read with GetCSPNonce when rendering pages
+	Nonce bool
+}
+
+// Secure middleware sets security headers with default configuration
+func Secure() func(http.Handler) http.Handler {
+	return SecureWith(SecureConfig{})
+}
+
+// SecureWith middleware sets Strict-Transport-Security on HTTPS requests,
+// X-Content-Type-Options, X-Frame-Options, Referrer-Policy and
+// Content-Security-Policy. Applied again on a group or route it replaces
+// the headers of the application middleware for those routes.
+func SecureWith(config SecureConfig) func(http.Handler) http.Handler {
+	if config.HSTSMaxAge == 0 {
+		config.HSTSMaxAge = 365 * 24 * time.Hour
+	}
+	if config.FrameOptions == "" {
+		config.FrameOptions = "DENY"
+	}
+	if config.ReferrerPolicy == "" {
+		config.ReferrerPolicy = "strict-origin-when-cross-origin"
+	}
+	if config.ContentSecurityPolicy == nil {
+		config.ContentSecurityPolicy = DefaultCSP()
+	}
+
+	hsts := ""
+	if config.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(config.HSTSMaxAge.Seconds()))
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	cspHeader, otherCSPHeader := "Content-Security-Policy", "Content-Security-Policy-Report-Only"
+	if config.CSPReportOnly {
+		cspHeader, otherCSPHeader = otherCSPHeader, cspHeader
+	}
+	// Rendered once unless a nonce changes it per request
+	policy := config.ContentSecurityPolicy.Clone()
+	csp := policy.String()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			setOrDelete(h, "X-Frame-Options", config.FrameOptions)
+			setOrDelete(h, "Referrer-Policy", config.ReferrerPolicy)
+			// Browsers ignore HSTS received over plain HTTP
+			if hsts == "" {
+				h.Del("Strict-Transport-Security")
+			} else if isHTTPS(r) {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+
+			value := csp
+			if config.Nonce && csp != "" {
+				nonce := GetCSPNonce(r.Context())
+				if nonce == "" {
+					nonce = newCSPNonce()
+					r = r.WithContext(context.WithValue(r.Context(), cspNonceKey{}, nonce))
+				}
+				value = policy.render(nonce)
+			}
+			h.Del(otherCSPHeader)
+			if value == "" {
+				h.Del(cspHeader)
+			} else {
+				h.Set(cspHeader, value)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setOrDelete(h http.Header, name, value string) {
+	if value == "-" {
+		h.Del(name)
+		return
+	}
+	h.Set(name, value)
+}
+
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
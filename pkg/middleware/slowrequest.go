@@ -0,0 +1,314 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/reqctx"
+	"github.com/sirupsen/logrus"
+)
+
+// Span kinds recorded for SlowRequests
+const (
+	SpanDB    = reqctx.SpanDB
+	SpanCache = reqctx.SpanCache
+	SpanHTTP  = reqctx.SpanHTTP
+)
+
+// maxSpanName caps span names, e.g. long SQL statements
+const maxSpanName = 256
+
+// Span is an operation made while serving a request
+type Span struct {
+	Kind     string  `json:"kind"`
+	Name     string  `json:"name"`     // e.g. the SQL, Redis command or URL
+	Start    float64 `json:"start_ms"` // Since the request started
+	Duration float64 `json:"duration_ms"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// SlowRequestConfig holds slow request detection configuration
+type SlowRequestConfig struct {
+	// Threshold is the latency budget; slower requests are logged (default 1s)
+	Threshold time.Duration
+
+	// MaxSpans caps the spans kept per request (default 100); more are
+	// counted but not kept
+	MaxSpans int
+
+	// HardThreshold, when set, dumps the stacks of all goroutines once a
+	// request has run for it, while it still runs, to show where it is
+	// stuck. The dump is logged as an error and named in the slow request
+	// warning.
+	HardThreshold time.Duration
+
+	// ProfileDir receives the goroutine dumps (default os.TempDir())
+	ProfileDir string
+
+	// ProfileInterval is the minimum time between dumps (default 1m), so
+	// a latency spike does not dump for every request
+	ProfileInterval time.Duration
+}
+
+// flightRecorder collects the spans of a request
+type flightRecorder struct {
+	start time.Time
+	max   int
+
+	mu      sync.Mutex
+	spans   []Span
+	dropped int
+	profile string // Goroutine dump taken past HardThreshold
+}
+
+// SlowRequests middleware logs requests slower than Threshold as a single
+// warning with the route, trace context, status, user and the spans
+// recorded while serving them: the database queries, cache commands and
+// outgoing HTTP calls made with the request context, through the
+// instrumentation of pkg/database, pkg/cache and SpanTransport, and any
+// added with StartSpan. With HardThreshold set, requests running past it
+// also get a goroutine dump.
+func SlowRequests(config SlowRequestConfig) func(http.Handler) http.Handler {
+	if config.Threshold <= 0 {
+		config.Threshold = time.Second
+	}
+	if config.MaxSpans <= 0 {
+		config.MaxSpans = 100
+	}
+	if config.ProfileDir == "" {
+		config.ProfileDir = os.TempDir()
+	}
+	if config.ProfileInterval <= 0 {
+		config.ProfileInterval = time.Minute
+	}
+	// Unix nanoseconds of the last dump, shared by the routes the
+	// middleware wraps
+	var lastDump atomic.Int64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &flightRecorder{start: time.Now(), max: config.MaxSpans}
+			r, routed := trackRouting(r.WithContext(reqctx.WithSpanRecorder(r.Context(), rec)))
+			rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			if config.HardThreshold > 0 {
+				timer := time.AfterFunc(config.HardThreshold, func() {
+					last := lastDump.Load()
+					now := time.Now()
+					if now.UnixNano()-last < int64(config.ProfileInterval) || !lastDump.CompareAndSwap(last, now.UnixNano()) {
+						return
+					}
+					rec.dump(r, routed.Load(), config.ProfileDir)
+				})
+				defer timer.Stop()
+			}
+
+			next.ServeHTTP(rw, r)
+
+			elapsed := time.Since(rec.start)
+			if elapsed < config.Threshold {
+				return
+			}
+			rec.log(r, routed.Load(), rw.statusCode, elapsed)
+		})
+	}
+}
+
+// fields returns the route and trace context of a request
+func (f *flightRecorder) fields(r, routed *http.Request) logrus.Fields {
+	fields := logrus.Fields{
+		LogMethod: r.Method,
+		LogPath:   r.URL.Path,
+		LogIP:     getClientIP(r),
+	}
+	if r.URL.RawQuery != "" {
+		fields[LogQuery] = r.URL.RawQuery
+	}
+	if route := mux.CurrentRoute(routed); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			fields[LogRoute] = tmpl
+		}
+		if name := route.GetName(); name != "" {
+			fields["route_name"] = name
+		}
+	}
+	if id := GetRequestID(r.Context()); id != "" {
+		fields[LogRequestID] = id
+	}
+	if traceID, spanID, ok := traceContext(r.Header.Get("traceparent")); ok {
+		fields["trace_id"], fields["parent_span_id"] = traceID, spanID
+	}
+	if user := claimsUser(routed); user != "" {
+		fields[LogUserID] = user
+	}
+	return fields
+}
+
+// dump writes the stacks of all goroutines to a file in dir
+func (f *flightRecorder) dump(r, routed *http.Request, dir string) {
+	fields := f.fields(r, routed)
+	fields[LogLatency] = milliseconds(time.Since(f.start))
+
+	file, err := os.CreateTemp(dir, "goroutines-"+time.Now().UTC().Format("20060102T150405")+"-*.txt")
+	if err != nil {
+		logrus.WithFields(fields).WithError(err).Error("Failed to dump goroutines of stuck request")
+		return
+	}
+	err = pprof.Lookup("goroutine").WriteTo(file, 2)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		logrus.WithFields(fields).WithError(err).Error("Failed to dump goroutines of stuck request")
+		return
+	}
+
+	f.mu.Lock()
+	f.profile = file.Name()
+	f.mu.Unlock()
+	fields["profile"] = file.Name()
+	logrus.WithFields(fields).Error("Request exceeded hard latency threshold, goroutines dumped")
+}
+
+// log writes the slow request record
+func (f *flightRecorder) log(r, routed *http.Request, status int, elapsed time.Duration) {
+	f.mu.Lock()
+	spans := f.spans
+	dropped := f.dropped
+	profile := f.profile
+	f.mu.Unlock()
+
+	fields := f.fields(r, routed)
+	fields[LogStatus] = status
+	fields[LogLatency] = milliseconds(elapsed)
+	fields["spans"] = spans
+	fields["spans_total"] = len(spans) + dropped
+	if profile != "" {
+		fields["profile"] = profile
+	}
+
+	// Totals per kind tell where the time went at a glance
+	type total struct {
+		count int
+		ms    float64
+	}
+	totals := make(map[string]*total)
+	for _, s := range spans {
+		t := totals[s.Kind]
+		if t == nil {
+			t = &total{}
+			totals[s.Kind] = t
+		}
+		t.count++
+		t.ms += s.Duration
+	}
+	for kind, t := range totals {
+		fields[kind+"_calls"] = t.count
+		fields[kind+"_ms"] = t.ms
+	}
+
+	logrus.WithFields(fields).Warn("Slow request")
+}
+
+// traceContext returns the trace and parent span IDs of a W3C traceparent
+// header
+func traceContext(traceparent string) (string, string, bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// RecordingSpans reports whether spans recorded with ctx are kept, so
+// instrumentation can skip timing otherwise
+func RecordingSpans(ctx context.Context) bool {
+	return reqctx.RecordingSpans(ctx)
+}
+
+// RecordSpan records an operation that started at start and just ended,
+// for SlowRequests. It does nothing for contexts of requests SlowRequests
+// does not serve.
+func RecordSpan(ctx context.Context, kind, name string, start time.Time, err error) {
+	reqctx.RecordSpan(ctx, kind, name, start, err)
+}
+
+// RecordSpan implements reqctx.SpanRecorder
+func (rec *flightRecorder) RecordSpan(kind, name string, start time.Time, err error) {
+	if len(name) > maxSpanName {
+		name = name[:maxSpanName]
+	}
+	span := Span{
+		Kind:     kind,
+		Name:     name,
+		Start:    milliseconds(start.Sub(rec.start)),
+		Duration: milliseconds(time.Since(start)),
+	}
+	if err != nil {
+		span.Error = err.Error()
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.spans) >= rec.max {
+		rec.dropped++
+		return
+	}
+	rec.spans = append(rec.spans, span)
+}
+
+// StartSpan starts recording an operation for SlowRequests, e.g. a call to
+// a service without instrumentation. Call the returned function when it
+// ends.
+func StartSpan(ctx context.Context, kind, name string) func(err error) {
+	if !RecordingSpans(ctx) {
+		return func(error) {}
+	}
+	start := time.Now()
+	return func(err error) {
+		RecordSpan(ctx, kind, name, start, err)
+	}
+}
+
+// SpanTransport records the requests made through next with a request
+// context as SpanHTTP spans. Responses with a 5xx status are recorded as
+// errors. A nil next uses http.DefaultTransport.
+func SpanTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return spanTransport{next}
+}
+
+type spanTransport struct {
+	next http.RoundTripper
+}
+
+func (t spanTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !RecordingSpans(ctx) {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	spanErr := err
+	if err == nil && resp.StatusCode >= 500 {
+		spanErr = fmt.Errorf("status %d", resp.StatusCode)
+	}
+	RecordSpan(ctx, SpanHTTP, req.Method+" "+req.URL.Host+req.URL.Path, start, spanErr)
+	return resp, err
+}
+
+// milliseconds returns d in milliseconds with microsecond precision
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+)
+
+type variantKey struct{}
+
+// Variant is one arm of a traffic split
+type Variant struct {
+	Name    string
+	Weight  int          // Relative share of traffic
+	Handler http.Handler // Alternate handler or upstream proxy; nil serves the wrapped handler
+}
+
+// SplitConfig holds traffic splitting configuration
+type SplitConfig struct {
+	// Name identifies the experiment in cookies, context and exposure events
+	Name     string
+	Variants []Variant
+
+	// Key returns a stable identifier such as the user or tenant ID. The same
+	// key always gets the same variant. When it returns "", a random ID is
+	// kept in a cookie so anonymous visitors are sticky as well.
+	Key func(r *http.Request) string
+
+	// Override forces a variant by name, e.g. from a header or feature flag.
+	// Unknown names are ignored.
+	Override func(r *http.Request) string
+
+	// OnExposure is called once per request with the assigned variant, e.g.
+	// to send an exposure event to an analytics sink
+	OnExposure func(r *http.Request, experiment, variant string)
+
+	CookieMaxAge int // Seconds the anonymous ID cookie lives (default 30 days)
+}
+
+// Split middleware routes each request to one of the weighted variants and
+// stores the variant name in the request context
+func Split(config SplitConfig) func(http.Handler) http.Handler {
+	if config.Name == "" {
+		config.Name = "experiment"
+	}
+	if config.CookieMaxAge == 0 {
+		config.CookieMaxAge = 30 * 24 * 3600
+	}
+	cookieName := "goframe_split_" + config.Name
+
+	total := 0
+	for _, v := range config.Variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if total == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			variant, ok := config.overridden(r)
+			if !ok {
+				key := ""
+				if config.Key != nil {
+					key = config.Key(r)
+				}
+				if key == "" {
+					key = anonymousID(w, r, cookieName, config.CookieMaxAge)
+				}
+				variant = config.pick(key, total)
+			}
+
+			if config.OnExposure != nil {
+				config.OnExposure(r, config.Name, variant.Name)
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), variantKey{}, variant.Name))
+			if variant.Handler != nil {
+				variant.Handler.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Canary middleware sends percent of traffic to canary, e.g. a reverse proxy
+// to the new release, and the rest to the wrapped handler. Users stay on the
+// same side between requests.
+func Canary(percent int, canary http.Handler) func(http.Handler) http.Handler {
+	return Split(SplitConfig{
+		Name: "canary",
+		Variants: []Variant{
+			{Name: "stable", Weight: 100 - percent},
+			{Name: "canary", Weight: percent, Handler: canary},
+		},
+	})
+}
+
+// GetVariant returns the variant assigned by Split, or "" outside a split
+func GetVariant(ctx context.Context) string {
+	v, _ := ctx.Value(variantKey{}).(string)
+	return v
+}
+
+func (c SplitConfig) overridden(r *http.Request) (Variant, bool) {
+	if c.Override == nil {
+		return Variant{}, false
+	}
+	name := c.Override(r)
+	if name == "" {
+		return Variant{}, false
+	}
+	for _, v := range c.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// pick maps key to a variant; hashing with the experiment name keeps
+// assignments independent across experiments
+func (c SplitConfig) pick(key string, total int) Variant {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(c.Name + ":" + key))
+	bucket := int(h.Sum32() % uint32(total))
+
+	for _, v := range c.Variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return c.Variants[len(c.Variants)-1]
+}
+
+func anonymousID(w http.ResponseWriter, r *http.Request, name string, maxAge int) string {
+	if cookie, err := r.Cookie(name); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	id := hex.EncodeToString(b)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errTimeout is returned by writes after Timeout answered the request. It
+// matches both http.ErrHandlerTimeout and context.DeadlineExceeded.
+var errTimeout error = timeoutError{}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return http.ErrHandlerTimeout.Error() }
+
+func (timeoutError) Unwrap() []error {
+	return []error{http.ErrHandlerTimeout, context.DeadlineExceeded}
+}
+
+// handlerPanic is a panic of the handler goroutine with its stack
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// Timeout middleware cancels the request context after d. If the handler has
+// not started writing by then, the client receives 503 Service Unavailable;
+// a response already in progress is cut off. Unlike http.TimeoutHandler the
+// response is not buffered, so streaming handlers keep working, and handlers
+// should watch r.Context() to stop early. Panics after the timeout, when
+// the response was already sent, are logged and passed to the PanicHandler
+// of the request.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header), ctx: ctx}
+			done := make(chan struct{})
+			panicCh := make(chan handlerPanic, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicCh <- handlerPanic{value: p, stack: debug.Stack()}
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicCh:
+				// Re-panic on the serving goroutine so Recovery can handle it
+				panic(p.value)
+			case <-done:
+			case <-ctx.Done():
+				tw.timeout()
+				// Recovery has returned by the time the handler panics, so
+				// report late panics here instead of losing them
+				go func() {
+					select {
+					case p := <-panicCh:
+						reportLatePanic(tw, r, p)
+					case <-done:
+					}
+				}()
+			}
+		})
+	}
+}
+
+// timeoutWriter guards the underlying writer so the handler goroutine cannot
+// write after the timeout response was sent. The handler gets its own header
+// map, copied on WriteHeader, to avoid racing with the timeout response.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	ctx    context.Context
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	if tw.ctx.Err() == context.DeadlineExceeded {
+		// The deadline passed before the handler started writing
+		tw.timeoutLocked()
+		return
+	}
+	tw.wroteHeader = true
+
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.writeHeaderLocked(http.StatusOK)
+	if tw.timedOut {
+		return 0, errTimeout
+	}
+	return tw.w.Write(b)
+}
+
+// Flush implements http.Flusher
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.writeHeaderLocked(http.StatusOK)
+	if tw.timedOut {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timeoutLocked()
+}
+
+func (tw *timeoutWriter) timeoutLocked() {
+	if tw.timedOut {
+		return
+	}
+	tw.timedOut = true
+	if tw.wroteHeader {
+		return
+	}
+
+	tw.w.Header().Set("Content-Type", "application/json")
+	tw.w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = tw.w.Write([]byte(`{"error":"Request timeout"}`))
+}
+
+// reportLatePanic logs and counts a panic of a handler that outlived its
+// timeout, and passes it to the PanicHandler of the request
+func reportLatePanic(w http.ResponseWriter, r *http.Request, p handlerPanic) {
+	if p.value == http.ErrAbortHandler {
+		return
+	}
+	route := RouteTemplate(r)
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	httpPanicsTotal.WithLabelValues(r.Method, route).Inc()
+
+	logrus.WithFields(logrus.Fields{
+		"error": p.value,
+		"stack": string(p.stack),
+		"path":  r.URL.Path,
+	}).Error("Panic after request timeout")
+
+	if handler := GetPanicHandler(r.Context()); handler != nil {
+		notifyPanic(handler, w, r, p.value, p.stack)
+	}
+}
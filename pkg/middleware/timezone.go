@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type locationKey struct{}
+
+// TimezoneConfig holds timezone resolution configuration
+type TimezoneConfig struct {
+	QueryParam string // Query parameter overriding everything else (default "tz")
+	Header     string // Request header sent by clients (default "Time-Zone")
+
+	// Resolve returns the user's preferred timezone, e.g. from their profile.
+	// It runs after authentication middleware and takes precedence over the
+	// header, which is only a device hint.
+	Resolve func(r *http.Request) string
+
+	Default *time.Location // Used when nothing resolves (default UTC)
+}
+
+// Timezone middleware resolves the request timezone from the query
+// parameter, the user preference or the header, in that order, and stores it
+// in the request context. Unknown zone names are ignored.
+func Timezone(config TimezoneConfig) func(http.Handler) http.Handler {
+	if config.QueryParam == "" {
+		config.QueryParam = "tz"
+	}
+	if config.Header == "" {
+		config.Header = "Time-Zone"
+	}
+	if config.Default == nil {
+		config.Default = time.UTC
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			loc := config.Default
+
+			candidates := []func() string{
+				func() string { return r.URL.Query().Get(config.QueryParam) },
+				func() string {
+					if config.Resolve == nil {
+						return ""
+					}
+					return config.Resolve(r)
+				},
+				func() string { return r.Header.Get(config.Header) },
+			}
+			for _, candidate := range candidates {
+				if l, ok := loadLocation(candidate()); ok {
+					loc = l
+					break
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithLocation(r.Context(), loc)))
+		})
+	}
+}
+
+// WithLocation returns a context carrying the request timezone
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// GetLocation returns the request timezone, or UTC if none was resolved
+func GetLocation(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok && loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Zone data is read from disk on every time.LoadLocation call
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, bool) {
+	if name == "" {
+		return nil, false
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), true
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, false
+	}
+	locations.Store(name, loc)
+	return loc, true
+}
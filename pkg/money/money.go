@@ -0,0 +1,134 @@
+// Package money holds amounts of money as integer minor units, e.g. cents,
+// with their ISO 4217 currency, so they add up without float rounding.
+// i18n formats them for a locale.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts of different
+// currencies
+var ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+// Currency describes an ISO 4217 currency
+type Currency struct {
+	Code   string // e.g. "EUR"
+	Symbol string // e.g. "€", the code when there is none
+	Digits int    // Decimal places of the minor unit, e.g. 2 for cents
+}
+
+// currencies are the common currencies; others are assumed to have two
+// digits and their code as symbol
+var currencies = map[string]Currency{
+	"USD": {"USD", "$", 2},
+	"EUR": {"EUR", "€", 2},
+	"GBP": {"GBP", "£", 2},
+	"CHF": {"CHF", "CHF", 2},
+	"JPY": {"JPY", "¥", 0},
+	"CNY": {"CNY", "¥", 2},
+	"KRW": {"KRW", "₩", 0},
+	"INR": {"INR", "₹", 2},
+	"BRL": {"BRL", "R$", 2},
+	"CAD": {"CAD", "CA$", 2},
+	"AUD": {"AUD", "A$", 2},
+	"SEK": {"SEK", "kr", 2},
+	"NOK": {"NOK", "kr", 2},
+	"DKK": {"DKK", "kr.", 2},
+	"PLN": {"PLN", "zł", 2},
+	"RUB": {"RUB", "₽", 2},
+	"TRY": {"TRY", "₺", 2},
+	"IRR": {"IRR", "IRR", 0},
+	"KWD": {"KWD", "KWD", 3},
+	"BHD": {"BHD", "BHD", 3},
+}
+
+// LookupCurrency returns the currency of an ISO 4217 code, any case
+func LookupCurrency(code string) Currency {
+	code = strings.ToUpper(code)
+	if c, ok := currencies[code]; ok {
+		return c
+	}
+	return Currency{Code: code, Symbol: code, Digits: 2}
+}
+
+// Money is an amount in the minor unit of its currency, e.g. 1999 USD for
+// $19.99
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New creates an amount of minor units, e.g. New(1999, "USD")
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Parse parses a decimal amount in major units, e.g. Parse("19.99", "USD"),
+// rejecting more decimals than the currency has
+func Parse(amount, currency string) (Money, error) {
+	digits := LookupCurrency(currency).Digits
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return Money{}, fmt.Errorf("money: invalid amount %q", amount)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return Money{}, fmt.Errorf("money: invalid amount %q for %s", amount, currency)
+	}
+	return New(r.Num().Int64(), currency), nil
+}
+
+// Add returns m plus other, ErrCurrencyMismatch for different currencies
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m minus other, ErrCurrencyMismatch for different currencies
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns m times n
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Decimal returns the amount in major units without grouping, e.g.
+// "-19.99"
+func (m Money) Decimal() string {
+	digits := LookupCurrency(m.Currency).Digits
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	units := strings.TrimPrefix(strconv.FormatInt(amount, 10), "-")
+	if digits == 0 {
+		return sign + units
+	}
+	if len(units) <= digits {
+		units = strings.Repeat("0", digits-len(units)+1) + units
+	}
+	return sign + units[:len(units)-digits] + "." + units[len(units)-digits:]
+}
+
+// String returns the amount with its code, e.g. "19.99 USD"; use
+// i18n.FormatMoney for display
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
@@ -0,0 +1,67 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount, currency string
+		want             Money
+		wantErr          bool
+	}{
+		{"19.99", "usd", New(1999, "USD"), false},
+		{"-0.5", "EUR", New(-50, "EUR"), false},
+		{"1500", "JPY", New(1500, "JPY"), false},
+		{"1.234", "KWD", New(1234, "KWD"), false},
+		{"1.999", "USD", Money{}, true},
+		{"0.5", "JPY", Money{}, true},
+		{"abc", "USD", Money{}, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.amount, tt.currency)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Parse(%q, %q) = %v, %v", tt.amount, tt.currency, got, err)
+		}
+	}
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{New(1999, "USD"), "19.99"},
+		{New(5, "USD"), "0.05"},
+		{New(-5, "EUR"), "-0.05"},
+		{New(-123456, "EUR"), "-1234.56"},
+		{New(1500, "JPY"), "1500"},
+		{New(1, "KWD"), "0.001"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Decimal(); got != tt.want {
+			t.Errorf("%#v.Decimal() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+	if got := New(1999, "USD").String(); got != "19.99 USD" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	sum, err := New(1000, "USD").Add(New(250, "USD"))
+	if err != nil || sum != New(1250, "USD") {
+		t.Errorf("Add = %v, %v", sum, err)
+	}
+	diff, err := sum.Sub(New(1250, "USD"))
+	if err != nil || !diff.IsZero() {
+		t.Errorf("Sub = %v, %v", diff, err)
+	}
+	if _, err := sum.Add(New(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add EUR error = %v", err)
+	}
+	if got := New(250, "USD").Mul(3); got != New(750, "USD") {
+		t.Errorf("Mul = %v", got)
+	}
+}
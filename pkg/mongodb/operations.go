@@ -10,21 +10,29 @@ import (
 
 // InsertOne inserts a single document
 func (c *Client) InsertOne(ctx context.Context, collection string, document interface{}) (*mongo.InsertOneResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).InsertOne(ctx, document)
 }
 
 // InsertMany inserts multiple documents
 func (c *Client) InsertMany(ctx context.Context, collection string, documents []interface{}) (*mongo.InsertManyResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).InsertMany(ctx, documents)
 }
 
 // FindOne finds a single document
 func (c *Client) FindOne(ctx context.Context, collection string, filter interface{}, result interface{}) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).FindOne(ctx, filter).Decode(result)
 }
 
 // Find finds multiple documents
 func (c *Client) Find(ctx context.Context, collection string, filter interface{}, results interface{}, opts ...*options.FindOptions) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	cursor, err := c.Collection(collection).Find(ctx, filter, opts...)
 	if err != nil {
 		return err
@@ -42,11 +50,15 @@ func (c *Client) FindByID(ctx context.Context, collection string, id interface{}
 
 // UpdateOne updates a single document
 func (c *Client) UpdateOne(ctx context.Context, collection string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).UpdateOne(ctx, filter, update)
 }
 
 // UpdateMany updates multiple documents
 func (c *Client) UpdateMany(ctx context.Context, collection string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).UpdateMany(ctx, filter, update)
 }
 
@@ -58,16 +70,22 @@ func (c *Client) UpdateByID(ctx context.Context, collection string, id interface
 
 // ReplaceOne replaces a single document
 func (c *Client) ReplaceOne(ctx context.Context, collection string, filter, replacement interface{}) (*mongo.UpdateResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).ReplaceOne(ctx, filter, replacement)
 }
 
 // DeleteOne deletes a single document
 func (c *Client) DeleteOne(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).DeleteOne(ctx, filter)
 }
 
 // DeleteMany deletes multiple documents
 func (c *Client) DeleteMany(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).DeleteMany(ctx, filter)
 }
 
@@ -79,11 +97,15 @@ func (c *Client) DeleteByID(ctx context.Context, collection string, id interface
 
 // CountDocuments counts documents matching filter
 func (c *Client) CountDocuments(ctx context.Context, collection string, filter interface{}) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).CountDocuments(ctx, filter)
 }
 
 // Aggregate performs aggregation
 func (c *Client) Aggregate(ctx context.Context, collection string, pipeline interface{}, results interface{}) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	cursor, err := c.Collection(collection).Aggregate(ctx, pipeline)
 	if err != nil {
 		return err
@@ -105,12 +127,16 @@ func (c *Client) CreateIndexes(ctx context.Context, collection string, models []
 
 // DropIndex drops an index
 func (c *Client) DropIndex(ctx context.Context, collection, name string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	_, err := c.Collection(collection).Indexes().DropOne(ctx, name)
 	return err
 }
 
 // ListIndexes lists all indexes
 func (c *Client) ListIndexes(ctx context.Context, collection string) ([]bson.M, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	cursor, err := c.Collection(collection).Indexes().List(ctx)
 	if err != nil {
 		return nil, err
@@ -127,10 +153,14 @@ func (c *Client) ListIndexes(ctx context.Context, collection string) ([]bson.M,
 
 // BulkWrite performs bulk write operations
 func (c *Client) BulkWrite(ctx context.Context, collection string, models []mongo.WriteModel) (*mongo.BulkWriteResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).BulkWrite(ctx, models)
 }
 
 // Distinct finds distinct values for a field
 func (c *Client) Distinct(ctx context.Context, collection, field string, filter interface{}) ([]interface{}, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	return c.Collection(collection).Distinct(ctx, field, filter)
 }
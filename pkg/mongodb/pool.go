@@ -0,0 +1,95 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	poolConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mongodb_pool_connections",
+			Help: "Connections of the MongoDB pool, by client and state (in_use, idle)",
+		},
+		[]string{"client", "state"},
+	)
+	poolWait = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mongodb_pool_wait_seconds",
+			Help:    "Time operations waited to check a connection out of the MongoDB pool",
+			Buckets: []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 5},
+		},
+		[]string{"client"},
+	)
+	poolCheckoutFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mongodb_pool_checkout_failures_total",
+			Help: "Connection checkouts that failed, e.g. timed out waiting for the MongoDB pool",
+		},
+		[]string{"client", "reason"},
+	)
+)
+
+// poolStats tracks the state of each pooled connection from the driver
+// events, so connections closed before becoming ready are not counted
+type poolStats struct {
+	mu    sync.Mutex
+	conns map[poolConn]string // State by connection
+	name  string
+}
+
+type poolConn struct {
+	address string
+	id      uint64
+}
+
+// poolMonitor keeps the pool metrics of a client from the driver events
+func poolMonitor(name string) *event.PoolMonitor {
+	stats := &poolStats{conns: make(map[poolConn]string), name: name}
+	wait := poolWait.WithLabelValues(name)
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			conn := poolConn{address: e.Address, id: e.ConnectionID}
+			switch e.Type {
+			case event.ConnectionReady, event.ConnectionReturned:
+				stats.set(conn, "idle")
+			case event.GetSucceeded:
+				wait.Observe(e.Duration.Seconds())
+				stats.set(conn, "in_use")
+			case event.ConnectionClosed:
+				stats.set(conn, "")
+			case event.GetFailed:
+				wait.Observe(e.Duration.Seconds())
+				poolCheckoutFailures.WithLabelValues(name, e.Reason).Inc()
+			}
+		},
+	}
+}
+
+// set moves a connection to state, "" once closed
+func (s *poolStats) set(conn poolConn, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if previous, ok := s.conns[conn]; ok {
+		poolConnections.WithLabelValues(s.name, previous).Dec()
+	}
+	if state == "" {
+		delete(s.conns, conn)
+		return
+	}
+	s.conns[conn] = state
+	poolConnections.WithLabelValues(s.name, state).Inc()
+}
+
+// withTimeout bounds an operation by the OperationTimeout of the client;
+// an earlier deadline of ctx still applies
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
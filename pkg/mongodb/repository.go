@@ -0,0 +1,382 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrVersionConflict is returned when a document changed since it was read
+var ErrVersionConflict = errors.New("mongodb: document version conflict")
+
+// Repository operations recorded in the revision history
+const (
+	OperationUpdate  = "update"
+	OperationReplace = "replace"
+	OperationDelete  = "delete"
+)
+
+// RepositoryConfig configures a Repository
+type RepositoryConfig struct {
+	Collection string
+
+	// SoftDelete makes Delete set DeletedAtField instead of removing the
+	// document; reads skip deleted documents unless Unscoped
+	SoftDelete     bool
+	DeletedAtField string // Default "deleted_at"
+
+	// Versioned keeps a counter in VersionField, starting at 1 and
+	// incremented on every write, for optimistic concurrency
+	Versioned    bool
+	VersionField string // Default "version"
+
+	// History stores the previous revision of every updated, replaced or
+	// deleted document in HistoryCollection
+	History           bool
+	HistoryCollection string // Default "<Collection>_history"
+}
+
+func (c *RepositoryConfig) setDefaults() {
+	if c.DeletedAtField == "" {
+		c.DeletedAtField = "deleted_at"
+	}
+	if c.VersionField == "" {
+		c.VersionField = "version"
+	}
+	if c.HistoryCollection == "" {
+		c.HistoryCollection = c.Collection + "_history"
+	}
+}
+
+// Revision is a previous state of a document, stored by repositories with
+// History
+type Revision struct {
+	DocumentID interface{} `bson:"document_id"`
+	Version    int64       `bson:"version,omitempty"`
+	Operation  string      `bson:"operation"`
+	Document   bson.Raw    `bson:"document"`
+	ChangedAt  time.Time   `bson:"changed_at"`
+}
+
+// Repository wraps a collection with optional soft delete, document
+// versioning and revision history:
+//
+//	users := mongodb.NewRepository(client, mongodb.RepositoryConfig{
+//		Collection: "users", SoftDelete: true, Versioned: true, History: true,
+//	})
+type Repository struct {
+	client   *Client
+	config   RepositoryConfig
+	unscoped bool
+}
+
+// NewRepository creates a repository on a collection of client
+func NewRepository(client *Client, config RepositoryConfig) *Repository {
+	config.setDefaults()
+	return &Repository{client: client, config: config}
+}
+
+// Collection returns the underlying collection
+func (r *Repository) Collection() *mongo.Collection {
+	return r.client.Collection(r.config.Collection)
+}
+
+// Unscoped returns a repository whose reads and writes include soft-deleted
+// documents
+func (r *Repository) Unscoped() *Repository {
+	u := *r
+	u.unscoped = true
+	return &u
+}
+
+// scope restricts filter to documents not soft-deleted
+func (r *Repository) scope(filter interface{}) interface{} {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	if !r.config.SoftDelete || r.unscoped {
+		return filter
+	}
+	return bson.M{"$and": bson.A{filter, bson.M{r.config.DeletedAtField: nil}}}
+}
+
+// Insert inserts a document, setting its version to 1 when Versioned
+func (r *Repository) Insert(ctx context.Context, document interface{}) (*mongo.InsertOneResult, error) {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	if !r.config.Versioned {
+		return r.Collection().InsertOne(ctx, document)
+	}
+	doc, err := toDoc(document)
+	if err != nil {
+		return nil, err
+	}
+	return r.Collection().InsertOne(ctx, setField(doc, r.config.VersionField, int64(1)))
+}
+
+// FindOne decodes the first document matching filter
+func (r *Repository) FindOne(ctx context.Context, filter interface{}, result interface{}) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	return r.Collection().FindOne(ctx, r.scope(filter)).Decode(result)
+}
+
+// FindByID decodes the document with id
+func (r *Repository) FindByID(ctx context.Context, id interface{}, result interface{}) error {
+	return r.FindOne(ctx, bson.M{"_id": id}, result)
+}
+
+// Find decodes every document matching filter
+func (r *Repository) Find(ctx context.Context, filter interface{}, results interface{}, opts ...*options.FindOptions) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.Collection().Find(ctx, r.scope(filter), opts...)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+// Count counts the documents matching filter
+func (r *Repository) Count(ctx context.Context, filter interface{}) (int64, error) {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	return r.Collection().CountDocuments(ctx, r.scope(filter))
+}
+
+// Update applies an update document such as bson.M{"$set": ...} to the
+// document with id, incrementing its version when Versioned. It returns
+// mongo.ErrNoDocuments when there is no such document.
+func (r *Repository) Update(ctx context.Context, id interface{}, update bson.M) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	return r.update(ctx, bson.M{"_id": id}, update, OperationUpdate)
+}
+
+// UpdateVersion is Update if the document is still at version, and returns
+// ErrVersionConflict when another write happened since it was read
+func (r *Repository) UpdateVersion(ctx context.Context, id interface{}, version int64, update bson.M) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	if !r.config.Versioned {
+		return fmt.Errorf("collection %s is not versioned", r.config.Collection)
+	}
+	return r.checkConflict(ctx, id, r.update(ctx, bson.M{"_id": id, r.config.VersionField: version}, update, OperationUpdate))
+}
+
+// Replace replaces the document with id, incrementing its version when
+// Versioned
+func (r *Repository) Replace(ctx context.Context, id interface{}, document interface{}) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	return r.replace(ctx, bson.M{"_id": id}, document, -1)
+}
+
+// ReplaceVersion is Replace if the document is still at version, and
+// returns ErrVersionConflict when another write happened since it was read
+func (r *Repository) ReplaceVersion(ctx context.Context, id interface{}, version int64, document interface{}) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	if !r.config.Versioned {
+		return fmt.Errorf("collection %s is not versioned", r.config.Collection)
+	}
+	err := r.replace(ctx, bson.M{"_id": id, r.config.VersionField: version}, document, version)
+	return r.checkConflict(ctx, id, err)
+}
+
+// Delete soft-deletes the document with id when SoftDelete is set, and
+// removes it otherwise
+func (r *Repository) Delete(ctx context.Context, id interface{}) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	if !r.config.SoftDelete || r.unscoped {
+		return r.ForceDelete(ctx, id)
+	}
+	return r.update(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{r.config.DeletedAtField: time.Now().UTC()}}, OperationDelete)
+}
+
+// Restore clears the deletion of a soft-deleted document
+func (r *Repository) Restore(ctx context.Context, id interface{}) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"_id": id, r.config.DeletedAtField: bson.M{"$ne": nil}}
+	res, err := r.Collection().UpdateOne(ctx, filter, r.withVersion(bson.M{"$unset": bson.M{r.config.DeletedAtField: ""}}))
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ForceDelete removes the document with id, even when SoftDelete is set
+func (r *Repository) ForceDelete(ctx context.Context, id interface{}) error {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	var before bson.Raw
+	err := r.Collection().FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&before)
+	if err != nil {
+		return err
+	}
+	return r.record(ctx, id, before, OperationDelete)
+}
+
+// History returns the previous revisions of the document with id, oldest
+// first
+func (r *Repository) History(ctx context.Context, id interface{}) ([]Revision, error) {
+	ctx, cancel := r.client.withTimeout(ctx)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.client.Collection(r.config.HistoryCollection).Find(ctx, bson.M{"document_id": id}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []Revision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *Repository) update(ctx context.Context, filter bson.M, update bson.M, operation string) error {
+	var before bson.Raw
+	err := r.Collection().FindOneAndUpdate(ctx, r.scope(filter), r.withVersion(update),
+		options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&before)
+	if err != nil {
+		return err
+	}
+	return r.record(ctx, filter["_id"], before, operation)
+}
+
+// replace replaces the matching document; version is the expected version,
+// or -1 to read it from the current document
+func (r *Repository) replace(ctx context.Context, filter bson.M, document interface{}, version int64) error {
+	doc, err := toDoc(document)
+	if err != nil {
+		return err
+	}
+	doc = removeField(doc, "_id")
+
+	if r.config.Versioned {
+		if version < 0 {
+			var current bson.Raw
+			if err := r.Collection().FindOne(ctx, r.scope(filter)).Decode(&current); err != nil {
+				return err
+			}
+			version = rawVersion(current, r.config.VersionField)
+			filter[r.config.VersionField] = version // Fails rather than overwrite a concurrent write
+		}
+		doc = setField(doc, r.config.VersionField, version+1)
+	}
+
+	var before bson.Raw
+	err = r.Collection().FindOneAndReplace(ctx, r.scope(filter), doc,
+		options.FindOneAndReplace().SetReturnDocument(options.Before)).Decode(&before)
+	if err != nil {
+		return err
+	}
+	return r.record(ctx, filter["_id"], before, OperationReplace)
+}
+
+// withVersion adds the version increment to an update document
+func (r *Repository) withVersion(update bson.M) bson.M {
+	if !r.config.Versioned {
+		return update
+	}
+	out := make(bson.M, len(update)+1)
+	for k, v := range update {
+		out[k] = v
+	}
+	inc := bson.M{r.config.VersionField: 1}
+	if existing, ok := update["$inc"].(bson.M); ok {
+		for k, v := range existing {
+			inc[k] = v
+		}
+	}
+	out["$inc"] = inc
+	return out
+}
+
+// checkConflict tells a version mismatch apart from a missing document
+func (r *Repository) checkConflict(ctx context.Context, id interface{}, err error) error {
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	count, countErr := r.Collection().CountDocuments(ctx, r.scope(bson.M{"_id": id}))
+	if countErr == nil && count > 0 {
+		return ErrVersionConflict
+	}
+	return err
+}
+
+// record stores the previous revision of a document when History is set
+func (r *Repository) record(ctx context.Context, id interface{}, before bson.Raw, operation string) error {
+	if !r.config.History {
+		return nil
+	}
+	_, err := r.client.Collection(r.config.HistoryCollection).InsertOne(ctx, Revision{
+		DocumentID: id,
+		Version:    rawVersion(before, r.config.VersionField),
+		Operation:  operation,
+		Document:   before,
+		ChangedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("document written but history not recorded: %w", err)
+	}
+	return nil
+}
+
+func rawVersion(doc bson.Raw, field string) int64 {
+	value, err := doc.LookupErr(field)
+	if err != nil {
+		return 0
+	}
+	if v, ok := value.AsInt64OK(); ok {
+		return v
+	}
+	return 0
+}
+
+// toDoc converts a struct or map to an ordered document
+func toDoc(document interface{}) (bson.D, error) {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func setField(doc bson.D, key string, value interface{}) bson.D {
+	for i := range doc {
+		if doc[i].Key == key {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, bson.E{Key: key, Value: value})
+}
+
+func removeField(doc bson.D, key string) bson.D {
+	out := doc[:0]
+	for _, e := range doc {
+		if e.Key != key {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,207 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TextSearchOptions configures a $text query, which needs a text index on
+// the collection
+type TextSearchOptions struct {
+	Language      string // Stemming language, e.g. "english"
+	CaseSensitive bool
+	Filter        bson.M // Additional conditions
+	Limit         int64
+	ScoreField    string // Receives the text score (default "score")
+}
+
+// TextSearch finds the documents matching query with a $text index, best
+// matches first
+func (c *Client) TextSearch(ctx context.Context, collection, query string, results interface{}, opts TextSearchOptions) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	if opts.ScoreField == "" {
+		opts.ScoreField = "score"
+	}
+
+	text := bson.M{"$search": query}
+	if opts.Language != "" {
+		text["$language"] = opts.Language
+	}
+	if opts.CaseSensitive {
+		text["$caseSensitive"] = true
+	}
+	filter := bson.M{"$text": text}
+	for k, v := range opts.Filter {
+		filter[k] = v
+	}
+
+	score := bson.M{opts.ScoreField: bson.M{"$meta": "textScore"}}
+	findOpts := options.Find().SetProjection(score).SetSort(score)
+	if opts.Limit > 0 {
+		findOpts.SetLimit(opts.Limit)
+	}
+	return c.Find(ctx, collection, filter, results, findOpts)
+}
+
+// SearchOptions configures an Atlas Search $search stage
+type SearchOptions struct {
+	Index      string   // Search index name (default "default")
+	Path       []string // Fields to search; all indexed fields when empty
+	MaxEdits   int      // Enables fuzzy matching with up to 2 edits per term
+	Filter     bson.M   // $match applied after the search
+	Skip       int64
+	Limit      int64
+	ScoreField string // Receives the search score when set
+}
+
+// SearchStage returns the $search stage of an Atlas Search text query,
+// for pipelines built by hand
+func SearchStage(query string, opts SearchOptions) bson.D {
+	index := opts.Index
+	if index == "" {
+		index = "default"
+	}
+
+	text := bson.M{"query": query}
+	if len(opts.Path) == 0 {
+		text["path"] = bson.M{"wildcard": "*"}
+	} else {
+		text["path"] = opts.Path
+	}
+	if opts.MaxEdits > 0 {
+		text["fuzzy"] = bson.M{"maxEdits": min(opts.MaxEdits, 2)}
+	}
+	return bson.D{{Key: "$search", Value: bson.M{"index": index, "text": text}}}
+}
+
+// Search runs an Atlas Search text query, best matches first
+func (c *Client) Search(ctx context.Context, collection, query string, results interface{}, opts SearchOptions) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	pipeline := mongo.Pipeline{SearchStage(query, opts)}
+	pipeline = append(pipeline, pageStages(opts.Filter, opts.Skip, opts.Limit)...)
+	if opts.ScoreField != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{opts.ScoreField: bson.M{"$meta": "searchScore"}}}})
+	}
+	return c.Aggregate(ctx, collection, pipeline, results)
+}
+
+// VectorSearchOptions configures a $vectorSearch stage
+type VectorSearchOptions struct {
+	Index         string // Vector search index name, required
+	Path          string // Field holding the embeddings, required
+	Limit         int64  // Number of nearest neighbours to return, k (default 10)
+	NumCandidates int64  // Candidates considered by approximate search (default 10 × Limit)
+	Exact         bool   // Exact nearest neighbour search instead of approximate
+	Filter        bson.M // Pre-filter on fields indexed as filter fields
+	ScoreField    string // Receives the similarity score (default "score")
+}
+
+// VectorSearchStage returns the $vectorSearch stage for vector, for
+// pipelines built by hand
+func VectorSearchStage(vector []float32, opts VectorSearchOptions) (bson.D, error) {
+	if opts.Index == "" || opts.Path == "" {
+		return nil, errors.New("vector search requires an index and a path")
+	}
+	if len(vector) == 0 {
+		return nil, errors.New("vector search requires a query vector")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+
+	stage := bson.M{
+		"index":       opts.Index,
+		"path":        opts.Path,
+		"queryVector": vector,
+		"limit":       opts.Limit,
+	}
+	if opts.Exact {
+		stage["exact"] = true
+	} else {
+		if opts.NumCandidates <= 0 {
+			opts.NumCandidates = opts.Limit * 10
+		}
+		stage["numCandidates"] = opts.NumCandidates
+	}
+	if len(opts.Filter) > 0 {
+		stage["filter"] = opts.Filter
+	}
+	return bson.D{{Key: "$vectorSearch", Value: stage}}, nil
+}
+
+// VectorSearch returns the documents whose embeddings are nearest to vector,
+// most similar first, e.g. to retrieve context for a RAG prompt. Extra
+// stages, such as a $project dropping the embeddings, run after the score is
+// added.
+func (c *Client) VectorSearch(ctx context.Context, collection string, vector []float32, results interface{}, opts VectorSearchOptions, stages ...bson.D) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	stage, err := VectorSearchStage(vector, opts)
+	if err != nil {
+		return err
+	}
+	scoreField := opts.ScoreField
+	if scoreField == "" {
+		scoreField = "score"
+	}
+
+	pipeline := mongo.Pipeline{
+		stage,
+		{{Key: "$addFields", Value: bson.M{scoreField: bson.M{"$meta": "vectorSearchScore"}}}},
+	}
+	pipeline = append(pipeline, stages...)
+	return c.Aggregate(ctx, collection, pipeline, results)
+}
+
+// VectorIndex describes an Atlas vector search index
+type VectorIndex struct {
+	Name       string
+	Path       string   // Field holding the embeddings
+	Dimensions int      // Embedding size, e.g. 1536
+	Similarity string   // "cosine" (default), "euclidean" or "dotProduct"
+	Filters    []string // Fields usable in VectorSearchOptions.Filter
+}
+
+// CreateVectorIndex creates an Atlas vector search index. The index builds
+// asynchronously and is queryable once Atlas reports it ready.
+func (c *Client) CreateVectorIndex(ctx context.Context, collection string, index VectorIndex) (string, error) {
+	similarity := index.Similarity
+	if similarity == "" {
+		similarity = "cosine"
+	}
+
+	fields := bson.A{bson.M{
+		"type":          "vector",
+		"path":          index.Path,
+		"numDimensions": index.Dimensions,
+		"similarity":    similarity,
+	}}
+	for _, path := range index.Filters {
+		fields = append(fields, bson.M{"type": "filter", "path": path})
+	}
+
+	return c.Collection(collection).SearchIndexes().CreateOne(ctx, mongo.SearchIndexModel{
+		Definition: bson.M{"fields": fields},
+		Options:    options.SearchIndexes().SetName(index.Name).SetType("vectorSearch"),
+	})
+}
+
+func pageStages(filter bson.M, skip, limit int64) []bson.D {
+	var stages []bson.D
+	if len(filter) > 0 {
+		stages = append(stages, bson.D{{Key: "$match", Value: filter}})
+	}
+	if skip > 0 {
+		stages = append(stages, bson.D{{Key: "$skip", Value: skip}})
+	}
+	if limit > 0 {
+		stages = append(stages, bson.D{{Key: "$limit", Value: limit}})
+	}
+	return stages
+}
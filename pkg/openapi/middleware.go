@@ -0,0 +1,83 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/polymatx/goframe/pkg/xlog"
+)
+
+const defaultMaxBodySize = 1 << 20
+
+// Config configures request validation
+type Config struct {
+	// BasePath prefixes the documented paths (default the path of the first
+	// server URL of the spec, e.g. /v1; "/" for none)
+	BasePath string
+
+	// RejectUndocumented answers requests to routes the spec does not
+	// document with 404, or 405 for undocumented methods; by default they
+	// pass unchecked
+	RejectUndocumented bool
+
+	MaxBodySize int64 // Largest body validated, larger ones get 413 (default 1MB)
+}
+
+// Middleware rejects requests violating the spec before they reach the
+// handlers: invalid parameters get 400 and invalid bodies 422, both with
+// an "errors" object naming each field like binding.FieldErrors, malformed
+// JSON 400 and undocumented content types 415.
+func (s *Spec) Middleware(config Config) func(http.Handler) http.Handler {
+	switch config.BasePath {
+	case "":
+		config.BasePath = s.basePath
+	case "/":
+		config.BasePath = ""
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultMaxBodySize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := s.validate(r, config.BasePath, config.MaxBodySize)
+			var invalid *RequestError
+			switch {
+			case err == nil:
+				next.ServeHTTP(w, r)
+			case errors.As(err, &invalid):
+				status := http.StatusUnprocessableEntity
+				if len(invalid.Params) > 0 {
+					status = http.StatusBadRequest
+				}
+				writeJSON(w, status, map[string]interface{}{"error": "Validation failed", "errors": invalid.Fields()})
+			case errors.Is(err, ErrNotFound), errors.Is(err, ErrMethodNotAllowed):
+				if !config.RejectUndocumented {
+					next.ServeHTTP(w, r)
+					return
+				}
+				status := http.StatusNotFound
+				if errors.Is(err, ErrMethodNotAllowed) {
+					status = http.StatusMethodNotAllowed
+				}
+				writeJSON(w, status, map[string]string{"error": "Route not documented"})
+			case errors.Is(err, ErrUnsupportedMediaType):
+				writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "Unsupported content type"})
+			case errors.Is(err, ErrBodyTooLarge):
+				writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "Request body too large"})
+			case errors.Is(err, ErrInvalidJSON):
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid JSON body"})
+			default:
+				xlog.GetWithError(r.Context(), err).Warn("request validation failed")
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+			}
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,227 @@
+// Package openapi validates requests against an OpenAPI 3 document, so
+// spec-first services need no hand-written validation structs:
+//
+//	spec, err := openapi.Load("openapi.yaml")
+//	a.Use(spec.Middleware(openapi.Config{}))
+//
+// Path, query, header and cookie parameters and JSON or form bodies are
+// checked against their schemas. Local $refs, allOf, anyOf and oneOf are
+// followed; discriminators and remote refs are not.
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/polymatx/goframe/pkg/binding"
+	"go.yaml.in/yaml/v3"
+)
+
+// Errors of Validate for requests the spec does not cover
+var (
+	ErrNotFound             = errors.New("route not documented")
+	ErrMethodNotAllowed     = errors.New("method not documented for the route")
+	ErrUnsupportedMediaType = errors.New("content type not documented for the route")
+	ErrBodyTooLarge         = errors.New("request body too large")
+	ErrInvalidJSON          = errors.New("invalid JSON body")
+)
+
+// RequestError lists the parts of a request that violate the spec
+type RequestError struct {
+	Params binding.FieldErrors // Named "<in>.<name>", e.g. "query.page"
+	Body   binding.FieldErrors // Named like binding errors, "body" for the whole body
+}
+
+// Error lists the invalid parameters, then the invalid body fields
+func (e *RequestError) Error() string {
+	var parts []string
+	for _, fields := range []binding.FieldErrors{e.Params, e.Body} {
+		if len(fields) > 0 {
+			parts = append(parts, strings.TrimPrefix(fields.Error(), "validation failed: "))
+		}
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Fields returns the parameter and body errors together
+func (e *RequestError) Fields() binding.FieldErrors {
+	fields := make(binding.FieldErrors, len(e.Params)+len(e.Body))
+	for name, message := range e.Params {
+		fields[name] = message
+	}
+	for name, message := range e.Body {
+		fields[name] = message
+	}
+	return fields
+}
+
+// Spec is a parsed OpenAPI 3 document
+type Spec struct {
+	root     map[string]interface{}
+	routes   []route
+	basePath string
+
+	patterns sync.Map // Compiled schema patterns by source
+}
+
+// route is a documented path compiled for matching
+type route struct {
+	path   string
+	re     *regexp.Regexp
+	params []string
+	item   map[string]interface{}
+}
+
+// Load reads an OpenAPI 3 document in YAML or JSON
+func Load(path string) (*Spec, error) {
+	content, err := os.ReadFile(path) // #nosec G304 -- the spec is chosen by the developer
+	if err != nil {
+		return nil, err
+	}
+	spec, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Parse parses an OpenAPI 3 document in YAML or JSON
+func Parse(content []byte) (*Spec, error) {
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, errors.New("not an OpenAPI 3 document")
+	}
+	paths := mapValue(root["paths"])
+	if len(paths) == 0 {
+		return nil, errors.New("OpenAPI document has no paths")
+	}
+
+	spec := &Spec{root: root}
+	for path, item := range paths {
+		re, params, err := compilePath(path)
+		if err != nil {
+			return nil, err
+		}
+		spec.routes = append(spec.routes, route{path: path, re: re, params: params, item: spec.resolve(mapValue(item))})
+	}
+	// Static paths first, so /users/me is not shadowed by /users/{id}
+	sort.Slice(spec.routes, func(i, j int) bool {
+		pi, pj := len(spec.routes[i].params), len(spec.routes[j].params)
+		if pi != pj {
+			return pi < pj
+		}
+		return spec.routes[i].path < spec.routes[j].path
+	})
+
+	// The path of the first server, e.g. /v1 for https://api.example.com/v1
+	for _, server := range sliceValue(root["servers"]) {
+		raw, _ := mapValue(server)["url"].(string)
+		if u, err := url.Parse(raw); err == nil && !strings.Contains(raw, "{") {
+			spec.basePath = strings.TrimSuffix(u.Path, "/")
+		}
+		break
+	}
+	return spec, nil
+}
+
+// compilePath turns a path template such as /users/{id} into a regexp
+// capturing its parameters
+func compilePath(path string) (*regexp.Regexp, []string, error) {
+	var pattern strings.Builder
+	var params []string
+	pattern.WriteString("^")
+	for rest := path; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, nil, fmt.Errorf("invalid path template %q", path)
+		}
+		pattern.WriteString(regexp.QuoteMeta(rest[:start]) + "([^/]+)")
+		params = append(params, rest[start+1:start+end])
+		rest = rest[start+end+1:]
+	}
+	pattern.WriteString("$")
+	re, err := regexp.Compile(pattern.String())
+	return re, params, err
+}
+
+// match returns the route of path and its path parameters
+func (s *Spec) match(path string) (*route, map[string]string) {
+	for i := range s.routes {
+		rt := &s.routes[i]
+		values := rt.re.FindStringSubmatch(path)
+		if values == nil {
+			continue
+		}
+		params := make(map[string]string, len(rt.params))
+		for j, name := range rt.params {
+			params[name] = values[j+1]
+		}
+		return rt, params
+	}
+	return nil, nil
+}
+
+// operation returns the operation of a route for an HTTP method; HEAD
+// falls back to GET
+func operation(item map[string]interface{}, method string) map[string]interface{} {
+	op := mapValue(item[strings.ToLower(method)])
+	if op == nil && method == http.MethodHead {
+		op = mapValue(item["get"])
+	}
+	return op
+}
+
+// resolve follows local $refs such as #/components/schemas/User
+func (s *Spec) resolve(node map[string]interface{}) map[string]interface{} {
+	for i := 0; i < 8; i++ {
+		ref, ok := node["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return node
+		}
+		var target interface{} = s.root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			target = mapValue(target)[part]
+		}
+		node = mapValue(target)
+	}
+	return node
+}
+
+// pattern returns the compiled pattern of a schema, or nil if it is invalid
+func (s *Spec) pattern(source string) *regexp.Regexp {
+	if re, ok := s.patterns.Load(source); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		return nil
+	}
+	s.patterns.Store(source, re)
+	return re
+}
+
+func mapValue(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func sliceValue(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
@@ -0,0 +1,231 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSpec = `
+openapi: 3.0.3
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users:
+    get:
+      parameters:
+        - name: page
+          in: query
+          schema: {type: integer, minimum: 1}
+        - name: status
+          in: query
+          schema: {type: string, enum: [active, banned]}
+        - name: tag
+          in: query
+          schema: {type: array, items: {type: string}, maxItems: 2}
+    post:
+      parameters:
+        - name: X-Api-Version
+          in: header
+          required: true
+          schema: {type: string, pattern: '^\d+$'}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/User'}
+          application/x-www-form-urlencoded:
+            schema: {$ref: '#/components/schemas/User'}
+  /users/me:
+    get: {}
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema: {type: string, format: uuid}
+    get: {}
+components:
+  schemas:
+    User:
+      type: object
+      additionalProperties: false
+      required: [id, email, name]
+      properties:
+        id: {type: string, readOnly: true}
+        email: {type: string, format: email}
+        name: {type: string, minLength: 2, maxLength: 50}
+        age: {type: integer, minimum: 0, exclusiveMaximum: true, maximum: 150}
+        nickname: {type: string, nullable: true}
+        roles:
+          type: array
+          uniqueItems: true
+          items: {type: string, enum: [admin, member]}
+        address:
+          type: object
+          required: [zip]
+          properties:
+            zip: {type: string, minLength: 5}
+        contact:
+          oneOf:
+            - {type: object, required: [phone], properties: {phone: {type: string}}}
+            - {type: object, required: [email], properties: {email: {type: string}}}
+`
+
+func TestMiddleware(t *testing.T) {
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := spec.Middleware(Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The handler still reads the body
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		errors      map[string]string
+	}{
+		{name: "valid query", method: "GET", path: "/v1/users?page=2&status=active&tag=a,b", status: 200},
+		{name: "integer param", method: "GET", path: "/v1/users?page=abc", status: 400, errors: map[string]string{"query.page": "must be an integer"}},
+		{name: "minimum", method: "GET", path: "/v1/users?page=0", status: 400, errors: map[string]string{"query.page": "must be at least 1"}},
+		{name: "enum", method: "GET", path: "/v1/users?status=gone", status: 400, errors: map[string]string{"query.status": "must be one of active, banned"}},
+		{name: "array param", method: "GET", path: "/v1/users?tag=a&tag=b&tag=c", status: 400, errors: map[string]string{"query.tag": "must contain at most 2 items"}},
+		{name: "static path first", method: "GET", path: "/v1/users/me", status: 200},
+		{name: "path param", method: "GET", path: "/v1/users/42", status: 400, errors: map[string]string{"path.id": "must be a valid uuid"}},
+		{name: "valid path param", method: "GET", path: "/v1/users/0b5e8d7a-3c1f-4e2a-9d6b-7f8e9a0b1c2d", status: 200},
+		{name: "undocumented passes", method: "GET", path: "/v1/orders", status: 200},
+		{name: "outside base path passes", method: "GET", path: "/users?page=abc", status: 200},
+		{
+			name: "valid body", method: "POST", path: "/v1/users", contentType: "application/json",
+			body:   `{"email":"a@example.com","name":"Ann","age":30,"nickname":null,"roles":["admin"],"contact":{"phone":"1"}}`,
+			status: 200,
+		},
+		{
+			name: "invalid body", method: "POST", path: "/v1/users", contentType: "application/json",
+			body:   `{"id":"1","email":"nope","name":"A","age":150,"roles":["admin","admin"],"address":{},"extra":1,"contact":{}}`,
+			status: 422,
+			errors: map[string]string{
+				"id":          "is read-only",
+				"email":       "must be a valid email",
+				"name":        "must contain at least 2 characters",
+				"age":         "must be less than 150",
+				"roles":       "must not contain duplicates",
+				"address.zip": "is required",
+				"extra":       "is not allowed",
+				"contact":     "must match exactly one of the allowed schemas",
+			},
+		},
+		{
+			name: "wrong types", method: "POST", path: "/v1/users", contentType: "application/json",
+			body:   `{"email":"a@example.com","name":["Ann"],"age":1.5,"roles":["owner"]}`,
+			status: 422,
+			errors: map[string]string{"name": "must be a string", "age": "must be an integer", "roles[0]": "must be one of admin, member"},
+		},
+		{name: "body required", method: "POST", path: "/v1/users", contentType: "application/json", status: 422, errors: map[string]string{"body": "is required"}},
+		{name: "malformed JSON", method: "POST", path: "/v1/users", contentType: "application/json", body: `{`, status: 400},
+		{name: "media type", method: "POST", path: "/v1/users", contentType: "text/plain", body: `hi`, status: 415},
+		{
+			name: "form body", method: "POST", path: "/v1/users", contentType: "application/x-www-form-urlencoded",
+			body: "email=a@example.com&name=Ann&age=x", status: 422, errors: map[string]string{"age": "must be an integer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.method == "POST" {
+				r.Header.Set("X-Api-Version", "1")
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == 200 {
+				if w.Body.String() != tt.body {
+					t.Errorf("handler read %q, want %q", w.Body, tt.body)
+				}
+				return
+			}
+			if tt.errors == nil {
+				return
+			}
+			var got struct{ Errors map[string]string }
+			_ = json.Unmarshal(w.Body.Bytes(), &got)
+			if len(got.Errors) != len(tt.errors) {
+				t.Errorf("errors = %v, want %v", got.Errors, tt.errors)
+			}
+			for field, message := range tt.errors {
+				if got.Errors[field] != message {
+					t.Errorf("%s: %q, want %q", field, got.Errors[field], message)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddleware_Headers(t *testing.T) {
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := spec.Middleware(Config{})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for version, status := range map[string]int{"": 400, "v2": 400, "2": 200} {
+		r := httptest.NewRequest("POST", "/v1/users", strings.NewReader(`{"email":"a@example.com","name":"Ann"}`))
+		r.Header.Set("Content-Type", "application/json; charset=utf-8")
+		if version != "" {
+			r.Header.Set("X-Api-Version", version)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != status {
+			t.Errorf("version %q: status = %d, want %d: %s", version, w.Code, status, w.Body)
+		}
+	}
+}
+
+func TestMiddleware_RejectUndocumented(t *testing.T) {
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := spec.Middleware(Config{BasePath: "/", RejectUndocumented: true})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for path, status := range map[string]int{"/users": 200, "/orders": 404, "/v1/users": 404} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != status {
+			t.Errorf("GET %s: status = %d, want %d", path, w.Code, status)
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("DELETE", "/users", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE /users: status = %d, want 405", w.Code)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, doc := range []string{`swagger: "2.0"`, `openapi: 3.0.0`, `{`, "openapi: 3.1.0\npaths: {'/a/{id': {get: {}}}"} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("expected an error for %q", doc)
+		}
+	}
+	// JSON documents parse too
+	if _, err := Parse([]byte(`{"openapi":"3.1.0","paths":{"/a":{"get":{}}}}`)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,612 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/polymatx/goframe/pkg/binding"
+)
+
+// maxDepth bounds schema recursion, e.g. through recursive $refs
+const maxDepth = 32
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Validate checks a request against its operation in the spec. It returns
+// a *RequestError for invalid parameters or bodies, ErrNotFound and
+// ErrMethodNotAllowed for undocumented routes, ErrUnsupportedMediaType,
+// ErrBodyTooLarge beyond 1MB and ErrInvalidJSON. The body is left for the
+// handler.
+func (s *Spec) Validate(r *http.Request) error {
+	return s.validate(r, s.basePath, defaultMaxBodySize)
+}
+
+func (s *Spec) validate(r *http.Request, basePath string, maxBody int64) error {
+	path, ok := strings.CutPrefix(r.URL.Path, basePath)
+	if !ok || (path != "" && path[0] != '/') {
+		return ErrNotFound
+	}
+	rt, pathParams := s.match(path)
+	if rt == nil {
+		return ErrNotFound
+	}
+	op := s.resolve(operation(rt.item, r.Method))
+	if op == nil {
+		return ErrMethodNotAllowed
+	}
+
+	e := &RequestError{Params: binding.FieldErrors{}, Body: binding.FieldErrors{}}
+	v := &validator{spec: s}
+	for _, param := range s.parameters(rt.item, op) {
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		field := in + "." + name
+		values, present := paramValues(r, in, name, pathParams)
+		if !present {
+			if required, _ := param["required"].(bool); required || in == "path" {
+				e.Params[field] = "is required"
+			}
+			continue
+		}
+		schema := s.resolve(mapValue(param["schema"]))
+		if hasType(schemaTypes(schema), "object") {
+			// Object parameters (deepObject, form styles) are not checked
+			continue
+		}
+		value, ok := convert(values, schema, s)
+		if !ok {
+			e.Params[field] = "must be " + describe(schemaTypes(schema))
+			continue
+		}
+		v.errs = e.Params
+		v.validate(schema, value, field, 0)
+	}
+
+	if body := s.resolve(mapValue(op["requestBody"])); body != nil {
+		if err := s.validateBody(r, body, maxBody, e.Body); err != nil {
+			return err
+		}
+	}
+
+	if len(e.Params) > 0 || len(e.Body) > 0 {
+		return e
+	}
+	return nil
+}
+
+// parameters returns the parameters of an operation and its path item;
+// those of the operation override those of the path item
+func (s *Spec) parameters(item, op map[string]interface{}) []map[string]interface{} {
+	var params []map[string]interface{}
+	index := make(map[string]int)
+	for _, list := range []interface{}{item["parameters"], op["parameters"]} {
+		for _, p := range sliceValue(list) {
+			param := s.resolve(mapValue(p))
+			name, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			key := in + " " + name
+			if in == "header" {
+				key = in + " " + http.CanonicalHeaderKey(name)
+			}
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// paramValues returns the raw values of a parameter in the request
+func paramValues(r *http.Request, in, name string, pathParams map[string]string) ([]string, bool) {
+	switch in {
+	case "path":
+		value, ok := pathParams[name]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		return []string{value}, ok
+	case "query":
+		values, ok := r.URL.Query()[name]
+		return values, ok
+	case "header":
+		values := r.Header.Values(name)
+		return values, len(values) > 0
+	case "cookie":
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return nil, false
+		}
+		return []string{cookie.Value}, true
+	}
+	return nil, false
+}
+
+// convert turns raw parameter or form values into the JSON value the
+// schema describes; arrays take repeated or comma-separated values
+func convert(values []string, schema map[string]interface{}, s *Spec) (interface{}, bool) {
+	types := schemaTypes(schema)
+	if hasType(types, "array") {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items := s.resolve(mapValue(schema["items"]))
+		array := make([]interface{}, len(values))
+		for i, raw := range values {
+			item, ok := convert([]string{raw}, items, s)
+			if !ok {
+				return nil, false
+			}
+			array[i] = item
+		}
+		return array, true
+	}
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	raw := values[0]
+	switch {
+	case hasType(types, "integer"):
+		if _, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return json.Number(raw), true
+		}
+	case hasType(types, "number"):
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw), true
+		}
+	case hasType(types, "boolean"):
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b, true
+		}
+	default:
+		return raw, true
+	}
+	if hasType(types, "string") {
+		return raw, true
+	}
+	return nil, false
+}
+
+// validateBody checks the body of a request against the documented media
+// types and their schemas
+func (s *Spec) validateBody(r *http.Request, body map[string]interface{}, maxBody int64, errs binding.FieldErrors) error {
+	var data []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		data, err = io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) > maxBody {
+			return ErrBodyTooLarge
+		}
+	}
+	if len(data) == 0 {
+		if required, _ := body["required"].(bool); required {
+			errs["body"] = "is required"
+		}
+		return nil
+	}
+
+	content := mapValue(body["content"])
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	media, ok := content[mediaType]
+	if !ok {
+		major, _, _ := strings.Cut(mediaType, "/")
+		if media, ok = content[major+"/*"]; !ok {
+			media, ok = content["*/*"]
+		}
+	}
+	if !ok && len(content) > 0 {
+		return ErrUnsupportedMediaType
+	}
+	schema := s.resolve(mapValue(mapValue(media)["schema"]))
+	if schema == nil {
+		return nil
+	}
+
+	var value interface{}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return ErrInvalidJSON
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			errs["body"] = "must be a valid form"
+			return nil
+		}
+		object := make(map[string]interface{}, len(form))
+		properties := mapValue(schema["properties"])
+		for name, values := range form {
+			property := s.resolve(mapValue(properties[name]))
+			if converted, ok := convert(values, property, s); ok {
+				object[name] = converted
+			} else {
+				errs[name] = "must be " + describe(schemaTypes(property))
+			}
+		}
+		value = object
+	default:
+		// Other media types are only checked against the documented ones
+		return nil
+	}
+
+	v := &validator{spec: s, errs: errs, request: true}
+	v.validate(schema, value, "", 0)
+	return nil
+}
+
+// validator checks values against schemas, collecting the first error of
+// each field
+type validator struct {
+	spec    *Spec
+	errs    binding.FieldErrors
+	request bool // readOnly properties are not required
+}
+
+func (v *validator) fail(name, message string) {
+	if name == "" {
+		name = "body"
+	}
+	if _, exists := v.errs[name]; !exists {
+		v.errs[name] = message
+	}
+}
+
+func (v *validator) validate(schema map[string]interface{}, value interface{}, name string, depth int) {
+	schema = v.spec.resolve(schema)
+	if len(schema) == 0 || depth > maxDepth {
+		return
+	}
+
+	for _, sub := range sliceValue(schema["allOf"]) {
+		v.validate(mapValue(sub), value, name, depth+1)
+	}
+	if anyOf := sliceValue(schema["anyOf"]); len(anyOf) > 0 && v.matches(anyOf, value, depth) == 0 {
+		v.fail(name, "must match at least one of the allowed schemas")
+	}
+	if oneOf := sliceValue(schema["oneOf"]); len(oneOf) > 0 && v.matches(oneOf, value, depth) != 1 {
+		v.fail(name, "must match exactly one of the allowed schemas")
+	}
+
+	types := schemaTypes(schema)
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); !nullable && len(types) > 0 && !hasType(types, "null") {
+			v.fail(name, "must not be null")
+		}
+		return
+	}
+	if len(types) > 0 && !typeMatches(types, value) {
+		v.fail(name, "must be "+describe(types))
+		return
+	}
+	if enum := sliceValue(schema["enum"]); len(enum) > 0 && !inEnum(enum, value) {
+		options := make([]string, len(enum))
+		for i, option := range enum {
+			options[i] = fmt.Sprint(option)
+		}
+		v.fail(name, "must be one of "+strings.Join(options, ", "))
+		return
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(schema, value, name)
+	case json.Number:
+		v.validateNumber(schema, value, name)
+	case []interface{}:
+		if n, ok := number(schema["minItems"]); ok && float64(len(value)) < n {
+			v.fail(name, fmt.Sprintf("must contain at least %v %s", n, plural("item", n)))
+		}
+		if n, ok := number(schema["maxItems"]); ok && float64(len(value)) > n {
+			v.fail(name, fmt.Sprintf("must contain at most %v %s", n, plural("item", n)))
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique && !uniqueItems(value) {
+			v.fail(name, "must not contain duplicates")
+		}
+		items := mapValue(schema["items"])
+		for i, item := range value {
+			v.validate(items, item, fmt.Sprintf("%s[%d]", name, i), depth+1)
+		}
+	case map[string]interface{}:
+		v.validateObject(schema, value, name, depth)
+	}
+}
+
+func (v *validator) validateString(schema map[string]interface{}, value, name string) {
+	length := float64(utf8.RuneCountInString(value))
+	if n, ok := number(schema["minLength"]); ok && length < n {
+		v.fail(name, fmt.Sprintf("must contain at least %v %s", n, plural("character", n)))
+		return
+	}
+	if n, ok := number(schema["maxLength"]); ok && length > n {
+		v.fail(name, fmt.Sprintf("must contain at most %v %s", n, plural("character", n)))
+		return
+	}
+	if source, ok := schema["pattern"].(string); ok {
+		if re := v.spec.pattern(source); re != nil && !re.MatchString(value) {
+			v.fail(name, "must match the pattern "+source)
+			return
+		}
+	}
+
+	format, _ := schema["format"].(string)
+	valid := true
+	switch format {
+	case "email":
+		address, err := mail.ParseAddress(value)
+		valid = err == nil && address.Address == value
+	case "uuid":
+		valid = uuidPattern.MatchString(value)
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		valid = err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		valid = err == nil
+	case "uri", "url":
+		u, err := url.ParseRequestURI(value)
+		valid = err == nil && u.Scheme != ""
+	case "ipv4":
+		ip := net.ParseIP(value)
+		valid = ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(value)
+		valid = ip != nil && ip.To4() == nil
+	}
+	if !valid {
+		v.fail(name, "must be a valid "+format)
+	}
+}
+
+func (v *validator) validateNumber(schema map[string]interface{}, value json.Number, name string) {
+	f, err := value.Float64()
+	if err != nil {
+		v.fail(name, "must be a number")
+		return
+	}
+	if limit, ok := number(schema["minimum"]); ok {
+		// OpenAPI 3.0 marks exclusive limits with a boolean
+		if exclusive, _ := schema["exclusiveMinimum"].(bool); exclusive && f <= limit {
+			v.fail(name, fmt.Sprintf("must be greater than %v", limit))
+		} else if f < limit {
+			v.fail(name, fmt.Sprintf("must be at least %v", limit))
+		}
+	}
+	if limit, ok := number(schema["maximum"]); ok {
+		if exclusive, _ := schema["exclusiveMaximum"].(bool); exclusive && f >= limit {
+			v.fail(name, fmt.Sprintf("must be less than %v", limit))
+		} else if f > limit {
+			v.fail(name, fmt.Sprintf("must be at most %v", limit))
+		}
+	}
+	// OpenAPI 3.1 gives exclusive limits as numbers
+	if limit, ok := number(schema["exclusiveMinimum"]); ok && f <= limit {
+		v.fail(name, fmt.Sprintf("must be greater than %v", limit))
+	}
+	if limit, ok := number(schema["exclusiveMaximum"]); ok && f >= limit {
+		v.fail(name, fmt.Sprintf("must be less than %v", limit))
+	}
+	if divisor, ok := number(schema["multipleOf"]); ok && divisor > 0 {
+		if q := f / divisor; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(name, fmt.Sprintf("must be a multiple of %v", divisor))
+		}
+	}
+
+	switch format, _ := schema["format"].(string); format {
+	case "int32":
+		if f < math.MinInt32 || f > math.MaxInt32 {
+			v.fail(name, "must be a 32-bit integer")
+		}
+	case "int64":
+		if _, err := value.Int64(); err != nil {
+			v.fail(name, "must be a 64-bit integer")
+		}
+	}
+}
+
+func (v *validator) validateObject(schema, value map[string]interface{}, name string, depth int) {
+	properties := mapValue(schema["properties"])
+	for _, item := range sliceValue(schema["required"]) {
+		property, _ := item.(string)
+		if _, ok := value[property]; ok {
+			continue
+		}
+		if readOnly, _ := v.spec.resolve(mapValue(properties[property]))["readOnly"].(bool); readOnly && v.request {
+			continue
+		}
+		v.fail(join(name, property), "is required")
+	}
+
+	additional := schema["additionalProperties"]
+	for property, item := range value {
+		field := join(name, property)
+		if propertySchema, ok := properties[property]; ok {
+			resolved := v.spec.resolve(mapValue(propertySchema))
+			if readOnly, _ := resolved["readOnly"].(bool); readOnly && v.request {
+				v.fail(field, "is read-only")
+				continue
+			}
+			v.validate(resolved, item, field, depth+1)
+			continue
+		}
+		switch additional := additional.(type) {
+		case bool:
+			if !additional {
+				v.fail(field, "is not allowed")
+			}
+		case map[string]interface{}:
+			v.validate(additional, item, field, depth+1)
+		}
+	}
+}
+
+// matches counts the schemas value is valid against
+func (v *validator) matches(schemas []interface{}, value interface{}, depth int) int {
+	n := 0
+	for _, schema := range schemas {
+		branch := &validator{spec: v.spec, errs: binding.FieldErrors{}, request: v.request}
+		branch.validate(mapValue(schema), value, "", depth+1)
+		if len(branch.errs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// schemaTypes returns the types of a schema: OpenAPI 3.1 allows a list
+func schemaTypes(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func hasType(types []string, name string) bool {
+	for _, t := range types {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+func typeMatches(types []string, value interface{}) bool {
+	for _, t := range types {
+		switch value := value.(type) {
+		case string:
+			if t == "string" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case json.Number:
+			if t == "number" {
+				return true
+			}
+			if f, err := value.Float64(); t == "integer" && err == nil && f == math.Trunc(f) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describe words the types of a schema for error messages
+func describe(types []string) string {
+	words := make([]string, 0, len(types))
+	for _, t := range types {
+		switch t {
+		case "integer", "object", "array":
+			words = append(words, "an "+t)
+		case "null":
+		default:
+			words = append(words, "a "+t)
+		}
+	}
+	if len(words) == 0 {
+		return "a valid value"
+	}
+	return strings.Join(words, " or ")
+}
+
+// inEnum compares a JSON value with the enum values of a spec, in which
+// numbers may be ints or floats
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, option := range enum {
+		if n, ok := value.(json.Number); ok {
+			f, _ := n.Float64()
+			if o, ok := number(option); ok && o == f {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(option, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueItems(items []interface{}) bool {
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		key, _ := json.Marshal(item)
+		if seen[string(key)] {
+			return false
+		}
+		seen[string(key)] = true
+	}
+	return true
+}
+
+// number returns a numeric spec value as a float64
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func plural(word string, n float64) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func join(name, field string) string {
+	if name == "" {
+		return field
+	}
+	return name + "." + field
+}
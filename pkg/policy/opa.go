@@ -0,0 +1,168 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/app"
+)
+
+// OPAConfig holds OPA client configuration
+type OPAConfig struct {
+	URL        string       // OPA base URL (default "http://localhost:8181")
+	Path       string       // Decision path, e.g. "httpapi/authz/allow"
+	Token      string       // Optional bearer token for OPA's API
+	HTTPClient *http.Client // Default client has a 2s timeout
+}
+
+// OPAClient queries an OPA server through its REST API
+type OPAClient struct {
+	config OPAConfig
+}
+
+// Policy is a Rego module stored in OPA
+type Policy struct {
+	ID  string `json:"id"`
+	Raw string `json:"raw"`
+}
+
+// NewOPAClient creates an OPA client
+func NewOPAClient(config OPAConfig) *OPAClient {
+	if config.URL == "" {
+		config.URL = "http://localhost:8181"
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	config.Path = strings.Trim(config.Path, "/")
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 2 * time.Second}
+	}
+	return &OPAClient{config: config}
+}
+
+// Enforce evaluates the decision path with the input. The decision must be a
+// boolean, or an object with a boolean "allow" field.
+func (c *OPAClient) Enforce(ctx context.Context, input Input) (bool, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return false, err
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/data/"+c.config.Path, "application/json", body, &resp); err != nil {
+		return false, err
+	}
+
+	if len(resp.Result) == 0 {
+		// Undefined decision
+		return false, nil
+	}
+
+	var allowed bool
+	if err := json.Unmarshal(resp.Result, &allowed); err == nil {
+		return allowed, nil
+	}
+
+	var decision struct {
+		Allow bool `json:"allow"`
+	}
+	if err := json.Unmarshal(resp.Result, &decision); err != nil {
+		return false, fmt.Errorf("unexpected OPA decision: %s", resp.Result)
+	}
+	return decision.Allow, nil
+}
+
+// Policies lists the policy modules loaded in OPA
+func (c *OPAClient) Policies(ctx context.Context) ([]Policy, error) {
+	var resp struct {
+		Result []Policy `json:"result"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/policies", "", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// PutPolicy creates or replaces a Rego policy module
+func (c *OPAClient) PutPolicy(ctx context.Context, id, rego string) error {
+	return c.do(ctx, http.MethodPut, "/v1/policies/"+url.PathEscape(id), "text/plain", []byte(rego), nil)
+}
+
+// DeletePolicy removes a policy module
+func (c *OPAClient) DeletePolicy(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/policies/"+url.PathEscape(id), "", nil, nil)
+}
+
+func (c *OPAClient) do(ctx context.Context, method, path, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.config.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("opa request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("opa returned status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// RegisterAdminRoutes mounts a policy admin API on the group:
+//
+//	GET    /policies       list policies
+//	PUT    /policies/{id}  create or replace a policy (Rego in the body)
+//	DELETE /policies/{id}  delete a policy
+//
+// The group should be protected, e.g. with auth.BearerAuth and Enforce.
+func RegisterAdminRoutes(g *app.RouteGroup, client *OPAClient) {
+	g.GET("/policies", app.Wrap(func(c *app.Context) error {
+		policies, err := client.Policies(c.Request.Context())
+		if err != nil {
+			return app.NewHTTPError(http.StatusBadGateway).WithInternal(err)
+		}
+		return c.JSON(http.StatusOK, policies)
+	}))
+
+	g.PUT("/policies/{id}", app.Wrap(func(c *app.Context) error {
+		rego, err := c.Body()
+		if err != nil {
+			return app.NewHTTPError(http.StatusBadRequest).WithInternal(err)
+		}
+		if err := client.PutPolicy(c.Request.Context(), c.Param("id"), string(rego)); err != nil {
+			return app.NewHTTPError(http.StatusBadGateway, "failed to store policy").WithInternal(err)
+		}
+		c.NoContent()
+		return nil
+	}))
+
+	g.DELETE("/policies/{id}", app.Wrap(func(c *app.Context) error {
+		if err := client.DeletePolicy(c.Request.Context(), c.Param("id")); err != nil {
+			return app.NewHTTPError(http.StatusBadGateway, "failed to delete policy").WithInternal(err)
+		}
+		c.NoContent()
+		return nil
+	}))
+}
@@ -0,0 +1,140 @@
+// Package policy enforces authorization decisions made by a policy engine.
+// It ships an Open Policy Agent (OPA) client for a sidecar deployment; other
+// engines can be plugged in by implementing Enforcer.
+package policy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// Input is the authorization query sent to the policy engine
+type Input struct {
+	Subject string                 `json:"subject"`
+	Role    string                 `json:"role,omitempty"`
+	Object  string                 `json:"object"`
+	Action  string                 `json:"action"`
+	Extra   map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Enforcer decides whether an input is allowed
+type Enforcer interface {
+	Enforce(ctx context.Context, input Input) (bool, error)
+}
+
+// EnforcerFunc adapts a function to Enforcer
+type EnforcerFunc func(ctx context.Context, input Input) (bool, error)
+
+// Enforce implements Enforcer
+func (f EnforcerFunc) Enforce(ctx context.Context, input Input) (bool, error) {
+	return f(ctx, input)
+}
+
+// Route metadata keys naming the object and action of a route, e.g.
+// app.Meta(policy.MetaObject, "order"), read by the default Options
+const (
+	MetaObject = "policy_object"
+	MetaAction = "policy_action"
+)
+
+// Options customizes how the middleware builds the Input
+type Options struct {
+	// Object resolves the protected object. Defaults to the MetaObject
+	// route metadata, then the route path template (e.g. "/orders/{id}"),
+	// falling back to the request path.
+	Object func(r *http.Request) string
+
+	// Action resolves the action. Defaults to the MetaAction route
+	// metadata, then the HTTP method.
+	Action func(r *http.Request) string
+
+	// Extra adds request attributes to the input
+	Extra func(r *http.Request) map[string]interface{}
+}
+
+// Enforce returns middleware that asks the enforcer for every request. The
+// subject and role are read from the JWT claims set by auth.BearerAuth, so it
+// must run after authentication. Requests without claims get 401, denied
+// requests 403, and engine failures 503.
+func Enforce(enforcer Enforcer, opts ...Options) func(http.Handler) http.Handler {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Object == nil {
+		o.Object = routeObject
+	}
+	if o.Action == nil {
+		o.Action = routeAction
+	}
+
+	d := middleware.Descriptor{Name: "policy.Enforce", NeedsClaims: true}
+	return middleware.Describe(d, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.GetClaims(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			input := Input{
+				Subject: claims.UserID,
+				Role:    claims.Role,
+				Object:  o.Object(r),
+				Action:  o.Action(r),
+			}
+			if o.Extra != nil {
+				input.Extra = o.Extra(r)
+			}
+
+			allowed, err := enforcer.Enforce(r.Context(), input)
+			if err != nil {
+				logrus.WithError(err).Error("Policy evaluation failed")
+				writeError(w, http.StatusServiceUnavailable, "Authorization unavailable")
+				return
+			}
+			if !allowed {
+				writeError(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+// routeObject is the default object: the one declared on the route, else
+// the route template, or the path of requests that matched no route
+func routeObject(r *http.Request) string {
+	if object := metaString(r, MetaObject); object != "" {
+		return object
+	}
+	if tmpl := middleware.RouteTemplate(r); tmpl != "" {
+		return tmpl
+	}
+	return r.URL.Path
+}
+
+// routeAction is the default action: the one declared on the route, else
+// the HTTP method
+func routeAction(r *http.Request) string {
+	if action := metaString(r, MetaAction); action != "" {
+		return action
+	}
+	return r.Method
+}
+
+func metaString(r *http.Request, key string) string {
+	s, _ := middleware.GetRouteMeta(r.Context()).Extra[key].(string)
+	return s
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(`{"error":"` + message + `"}`))
+}
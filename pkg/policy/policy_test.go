@@ -0,0 +1,173 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+func TestEnforce(t *testing.T) {
+	var got Input
+	enforcer := EnforcerFunc(func(ctx context.Context, input Input) (bool, error) {
+		got = input
+		switch input.Subject {
+		case "alice":
+			return true, nil
+		case "broken":
+			return false, errors.New("engine down")
+		}
+		return false, nil
+	})
+
+	r := mux.NewRouter()
+	r.Handle("/orders/{id}", Enforce(enforcer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))).Methods("DELETE")
+
+	tests := []struct {
+		name       string
+		claims     *auth.Claims
+		wantStatus int
+	}{
+		{"no claims", nil, http.StatusUnauthorized},
+		{"allowed", &auth.Claims{UserID: "alice", Role: "admin"}, http.StatusOK},
+		{"denied", &auth.Claims{UserID: "bob"}, http.StatusForbidden},
+		{"engine failure", &auth.Claims{UserID: "broken"}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/orders/42", nil)
+			if tt.claims != nil {
+				req = req.WithContext(auth.WithClaims(req.Context(), tt.claims))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+
+	if got.Object != "/orders/{id}" || got.Action != "DELETE" {
+		t.Errorf("expected object from route template and action from method, got %+v", got)
+	}
+}
+
+func TestEnforce_RouteMeta(t *testing.T) {
+	var got Input
+	enforcer := EnforcerFunc(func(ctx context.Context, input Input) (bool, error) {
+		got = input
+		return true, nil
+	})
+
+	meta := &middleware.RouteMeta{Extra: map[string]interface{}{MetaObject: "order", MetaAction: "cancel"}}
+	withMeta := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(middleware.WithRouteMeta(r.Context(), meta)))
+		})
+	}
+
+	r := mux.NewRouter()
+	r.Handle("/orders/{id}/cancel", withMeta(Enforce(enforcer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/42/cancel", nil)
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UserID: "alice"}))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.Object != "order" || got.Action != "cancel" {
+		t.Errorf("expected object and action from route metadata, got %+v", got)
+	}
+}
+
+func TestOPAClient_Enforce(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   bool
+	}{
+		{"boolean decision", `{"result": true}`, true},
+		{"object decision", `{"result": {"allow": true}}`, true},
+		{"denied", `{"result": false}`, false},
+		{"undefined", `{}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/data/httpapi/authz/allow" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				var body struct {
+					Input Input `json:"input"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				if body.Input.Subject != "alice" {
+					t.Errorf("expected input to be forwarded, got %+v", body.Input)
+				}
+				_, _ = io.WriteString(w, tt.result)
+			}))
+			defer srv.Close()
+
+			client := NewOPAClient(OPAConfig{URL: srv.URL, Path: "/httpapi/authz/allow"})
+			allowed, err := client.Enforce(context.Background(), Input{Subject: "alice"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, allowed)
+			}
+		})
+	}
+}
+
+func TestOPAClient_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"code":"invalid_parameter"}`)
+	}))
+	defer srv.Close()
+
+	client := NewOPAClient(OPAConfig{URL: srv.URL, Path: "authz/allow"})
+	if _, err := client.Enforce(context.Background(), Input{}); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+	if err := client.PutPolicy(context.Background(), "authz", "package authz"); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
+
+func TestOPAClient_PolicyID(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+	}))
+	defer srv.Close()
+
+	client := NewOPAClient(OPAConfig{URL: srv.URL})
+	if err := client.PutPolicy(context.Background(), "orders/v2?draft", "package orders"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeletePolicy(context.Background(), "orders/v2?draft"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/v1/policies/orders%2Fv2%3Fdraft"
+	for _, path := range paths {
+		if path != want {
+			t.Errorf("expected path %s, got %s", want, path)
+		}
+	}
+	if len(paths) != 2 {
+		t.Errorf("expected 2 requests, got %d", len(paths))
+	}
+}
@@ -25,16 +25,17 @@ func GetConnection(name string) (*Connection, error) {
 	return &Connection{name: name}, nil
 }
 
-// Publish publishes a message to queue
+// Publish publishes a message to queue and waits for the broker to confirm
+// it, for up to rabbit_operation_timeout (ErrPublishTimeout past it)
 func (c *Connection) Publish(ctx context.Context, queue string, body []byte) error {
-	rngLock.Lock()
-	r := rng[c.name]
-	cl := r.Value.(*chnlLock)
-	rng[c.name] = r.Next()
-	rngLock.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout())
+	defer cancel()
 
-	cl.lock.Lock()
-	defer cl.lock.Unlock()
+	cl, release, err := acquire(ctx, c.name)
+	if err != nil {
+		return err
+	}
+	defer release()
 
 	if cl.closed {
 		return fmt.Errorf("channel closed")
@@ -82,8 +83,7 @@ func (c *Connection) Publish(ctx context.Context, queue string, body []byte) err
 		return err
 	}
 
-	cl.wg.Wait()
-	return nil
+	return waitConfirm(ctx, cl)
 }
 
 // PublishJSON publishes JSON message
@@ -0,0 +1,135 @@
+package rabbit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/spf13/viper"
+)
+
+// ErrPublishTimeout is returned when no publish channel frees up, or the
+// broker does not confirm a message, within the operation timeout
+var ErrPublishTimeout = errors.New("rabbit publish timed out")
+
+var (
+	poolConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rabbit_pool_connections",
+			Help: "RabbitMQ connections, by connection name and state (open, closed)",
+		},
+		[]string{"connection", "state"},
+	)
+	poolChannels = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rabbit_pool_channels",
+			Help: "RabbitMQ publish channels, by connection name and state (in_use, idle)",
+		},
+		[]string{"connection", "state"},
+	)
+	poolWait = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "rabbit_pool_wait_seconds",
+			Help:    "Time publishers waited for a RabbitMQ publish channel",
+			Buckets: []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 5},
+		},
+		[]string{"connection"},
+	)
+)
+
+// operationTimeout bounds publishing, from rabbit_operation_timeout
+// (default 10s)
+func operationTimeout() time.Duration {
+	if d := viper.GetDuration("rabbit_operation_timeout"); d > 0 {
+		return d
+	}
+	return 10 * time.Second
+}
+
+// watchConnection counts conn as open until the broker or the client closes it
+func watchConnection(name string, conn *amqp.Connection) {
+	poolConnections.WithLabelValues(name, "open").Inc()
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		<-closed
+		poolConnections.WithLabelValues(name, "open").Dec()
+		poolConnections.WithLabelValues(name, "closed").Inc()
+	}()
+}
+
+// channelStats counts the publish channels of a connection in use
+type channelStats struct {
+	mu    sync.Mutex
+	total int
+	inUse int
+}
+
+var (
+	channelStatsLock   sync.Mutex
+	channelStatsByName = make(map[string]*channelStats)
+)
+
+// updateChannels adds to the publish channels of name and those in use
+func updateChannels(name string, total, inUse int) {
+	channelStatsLock.Lock()
+	stats, ok := channelStatsByName[name]
+	if !ok {
+		stats = &channelStats{}
+		channelStatsByName[name] = stats
+	}
+	channelStatsLock.Unlock()
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.total += total
+	stats.inUse += inUse
+	poolChannels.WithLabelValues(name, "in_use").Set(float64(stats.inUse))
+	poolChannels.WithLabelValues(name, "idle").Set(float64(stats.total - stats.inUse))
+}
+
+// acquire takes the next publish channel of name, waiting until ctx ends
+// for it to be free; release must be called once done
+func acquire(ctx context.Context, name string) (*chnlLock, func(), error) {
+	rngLock.Lock()
+	r, ok := rng[name]
+	if !ok {
+		rngLock.Unlock()
+		return nil, nil, errors.New("rabbitmq connection '" + name + "' not found")
+	}
+	rng[name] = r.Next()
+	cl := rng[name].Value.(*chnlLock)
+	rngLock.Unlock()
+
+	start := time.Now()
+	select {
+	case cl.lock <- struct{}{}:
+	case <-ctx.Done():
+		poolWait.WithLabelValues(name).Observe(time.Since(start).Seconds())
+		return nil, nil, ErrPublishTimeout
+	}
+	poolWait.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	updateChannels(name, 0, 1)
+	return cl, func() {
+		updateChannels(name, 0, -1)
+		<-cl.lock
+	}, nil
+}
+
+// waitConfirm waits for the broker to confirm the messages published on cl
+func waitConfirm(ctx context.Context, cl *chnlLock) error {
+	done := make(chan struct{})
+	go func() {
+		cl.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ErrPublishTimeout
+	}
+}
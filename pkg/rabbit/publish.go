@@ -1,6 +1,7 @@
 package rabbit
 
 import (
+	"context"
 	"errors"
 	"os"
 
@@ -10,13 +11,14 @@ import (
 )
 
 func Publish(in Job, cnt string) error {
-	var err error
-	rngLock.Lock()
-	rng[cnt] = rng[cnt].Next()
-	v := rng[cnt].Value.(*chnlLock)
-	rngLock.Unlock()
-	v.lock.Lock()
-	defer v.lock.Unlock()
+	// Wait for a free publish channel up to rabbit_operation_timeout
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout())
+	defer cancel()
+	v, release, err := acquire(ctx, cnt)
+	if err != nil {
+		return err
+	}
+	defer release()
 	if v.closed {
 		return errors.New("waiting for finalize, can not publish")
 	}
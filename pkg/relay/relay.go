@@ -0,0 +1,176 @@
+// Package relay forwards message bus topics to browsers. A relay subscribes
+// to bus topics and pushes each message to a WebSocket room, an SSE topic or
+// both, so backend events reach clients without glue code per project:
+//
+//	r := relay.New(relay.Config{
+//		Bus:    redis, // *cache.Manager or *mqtt.Client
+//		Hub:    hub,
+//		Broker: broker,
+//		Routes: []relay.Route{{Topic: "orders.*"}},
+//	})
+//	err := r.Start(ctx)
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/polymatx/goframe/pkg/sse"
+	"github.com/polymatx/goframe/pkg/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Bus is a message bus the relay subscribes to. *cache.Manager (Redis
+// pub/sub) and *mqtt.Client implement it.
+type Bus interface {
+	Subscribe(ctx context.Context, topic string, handler func(topic string, payload []byte) error) error
+}
+
+// Message is a bus message
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+// Route forwards the messages of a bus topic
+type Route struct {
+	Topic string // Bus topic, with the wildcards the bus supports
+
+	// Room returns the WebSocket room and SSE topic a message goes to, e.g.
+	// "user:"+id for messages addressed to one user. Defaults to the bus
+	// topic; "" drops the message.
+	Room func(m Message) string
+
+	// Allow reports whether the WebSocket connection of user may receive a
+	// message of its room. nil allows everyone in the room. SSE clients have
+	// no user to check, so a relay with a Broker refuses routes setting it.
+	Allow func(user string, m Message) bool
+
+	Event     string                          // SSE event name (default the bus topic)
+	Transform func(m Message) ([]byte, error) // Data sent to clients (default the payload)
+}
+
+// Config configures a relay. At least one of Hub and Broker is required.
+type Config struct {
+	Bus    Bus
+	Hub    *websocket.Hub
+	Broker *sse.Broker
+	Routes []Route
+}
+
+var messagesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "relay_messages_total",
+		Help: "Bus messages handled by the relay, by route and outcome",
+	},
+	[]string{"route", "outcome"},
+)
+
+// Relay forwards bus messages to WebSocket and SSE clients
+type Relay struct {
+	config Config
+}
+
+// New creates a relay
+func New(config Config) *Relay {
+	return &Relay{config: config}
+}
+
+// Start subscribes to the topics of all routes. Messages are forwarded
+// until ctx is done; MQTT subscriptions last until unsubscribed.
+func (r *Relay) Start(ctx context.Context) error {
+	if r.config.Bus == nil {
+		return errors.New("relay requires a bus")
+	}
+	if r.config.Hub == nil && r.config.Broker == nil {
+		return errors.New("relay requires a websocket hub or an SSE broker")
+	}
+	for _, route := range r.config.Routes {
+		if err := r.check(route); err != nil {
+			return err
+		}
+	}
+	for _, route := range r.config.Routes {
+		route := route
+		err := r.config.Bus.Subscribe(ctx, route.Topic, func(topic string, payload []byte) error {
+			return r.Forward(route, Message{Topic: topic, Payload: payload})
+		})
+		if err != nil {
+			return fmt.Errorf("relay failed to subscribe to %s: %w", route.Topic, err)
+		}
+	}
+	return nil
+}
+
+// Forward sends a message along route, for messages received some other
+// way, e.g. from a RabbitMQ consumer. Errors are returned for the caller
+// to log, as the buses do.
+func (r *Relay) Forward(route Route, m Message) error {
+	if err := r.check(route); err != nil {
+		messagesTotal.WithLabelValues(route.Topic, "error").Inc()
+		return err
+	}
+
+	room := m.Topic
+	if route.Room != nil {
+		room = route.Room(m)
+	}
+	if room == "" {
+		messagesTotal.WithLabelValues(route.Topic, "dropped").Inc()
+		return nil
+	}
+
+	data := m.Payload
+	if route.Transform != nil {
+		var err error
+		if data, err = route.Transform(m); err != nil {
+			messagesTotal.WithLabelValues(route.Topic, "error").Inc()
+			return fmt.Errorf("relay failed to transform message of %s: %w", m.Topic, err)
+		}
+	}
+
+	if r.config.Hub != nil {
+		if route.Allow == nil {
+			r.config.Hub.BroadcastRoom(room, data)
+		} else {
+			r.config.Hub.BroadcastFunc(data, func(c *websocket.Connection) bool {
+				return c.InRoom(room) && route.Allow(c.ID(), m)
+			})
+		}
+	}
+	if r.config.Broker != nil {
+		event := route.Event
+		if event == "" {
+			event = m.Topic
+		}
+		r.config.Broker.Publish(room, sse.Event{Event: event, Data: string(data)})
+	}
+	messagesTotal.WithLabelValues(route.Topic, "forwarded").Inc()
+	return nil
+}
+
+// check refuses a route whose Allow filter the SSE broker would bypass,
+// sending the messages to every client of the topic
+func (r *Relay) check(route Route) error {
+	if route.Allow != nil && r.config.Broker != nil {
+		return fmt.Errorf("relay route %s sets Allow, which SSE clients cannot be filtered by; use a relay without Broker", route.Topic)
+	}
+	return nil
+}
+
+// Envelope is a Transform wrapping the payload with its topic, so clients
+// of several rooms can tell messages apart:
+//
+//	{"topic":"orders.created","data":{"id":42}}
+//
+// Payloads that are not JSON are sent as a string.
+func Envelope(m Message) ([]byte, error) {
+	var data interface{} = string(m.Payload)
+	if json.Valid(m.Payload) {
+		data = json.RawMessage(m.Payload)
+	}
+	return json.Marshal(map[string]interface{}{"topic": m.Topic, "data": data})
+}
@@ -0,0 +1,220 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+	"github.com/polymatx/goframe/pkg/cache"
+	"github.com/polymatx/goframe/pkg/mqtt"
+	"github.com/polymatx/goframe/pkg/sse"
+	"github.com/polymatx/goframe/pkg/websocket"
+)
+
+var (
+	_ Bus = (*cache.Manager)(nil)
+	_ Bus = (*mqtt.Client)(nil)
+)
+
+// fakeBus delivers published messages to the handlers subscribed to the
+// exact topic
+type fakeBus struct {
+	mu       sync.Mutex
+	handlers map[string][]func(string, []byte) error
+	fail     error
+}
+
+func (b *fakeBus) Subscribe(_ context.Context, topic string, handler func(string, []byte) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail != nil {
+		return b.fail
+	}
+	b.handlers[topic] = append(b.handlers[topic], handler)
+	return nil
+}
+
+func (b *fakeBus) publish(topic, payload string) {
+	b.mu.Lock()
+	handlers := b.handlers[topic]
+	b.mu.Unlock()
+	for _, h := range handlers {
+		_ = h(topic, []byte(payload))
+	}
+}
+
+// dial connects a WebSocket client as user, joined to rooms
+func dial(t *testing.T, hub *websocket.Hub, user string, rooms ...string) *gorilla.Conn {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = hub.UpgradeRooms(w, r, user, rooms...)
+	}))
+	t.Cleanup(srv.Close)
+
+	want := hub.ConnectionCount() + 1
+	conn, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	for deadline := time.Now().Add(time.Second); hub.ConnectionCount() < want; {
+		if time.Now().After(deadline) {
+			t.Fatal("connection not registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return conn
+}
+
+// receive returns the next message of conn, or "" when none arrives soon
+func receive(conn *gorilla.Conn) string {
+	_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return ""
+	}
+	return string(msg)
+}
+
+func TestRelay_WebSocket(t *testing.T) {
+	hub := websocket.NewHub()
+	go hub.Run()
+	bus := &fakeBus{handlers: map[string][]func(string, []byte) error{}}
+
+	r := New(Config{Bus: bus, Hub: hub, Routes: []Route{
+		{Topic: "orders"},
+		{
+			Topic: "alerts",
+			Room:  func(m Message) string { return "ops" },
+			Allow: func(user string, m Message) bool { return user != "guest" },
+		},
+	}})
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	alice := dial(t, hub, "alice", "orders", "ops")
+	guest := dial(t, hub, "guest", "ops")
+
+	bus.publish("orders", "created")
+	if got := receive(alice); got != "created" {
+		t.Errorf("alice got %q, want created", got)
+	}
+	if got := receive(guest); got != "" {
+		t.Errorf("guest outside the room got %q", got)
+	}
+
+	bus.publish("alerts", "disk full")
+	if got := receive(alice); got != "disk full" {
+		t.Errorf("alice got %q, want disk full", got)
+	}
+	if got := receive(guest); got != "" {
+		t.Errorf("filtered guest got %q", got)
+	}
+}
+
+func TestRelay_SSE(t *testing.T) {
+	broker := sse.NewBroker(sse.Config{Heartbeat: -1})
+	defer broker.Close()
+	bus := &fakeBus{handlers: map[string][]func(string, []byte) error{}}
+
+	r := New(Config{Bus: bus, Broker: broker, Routes: []Route{{
+		Topic:     "orders",
+		Room:      func(m Message) string { return "user:" + strings.SplitN(string(m.Payload), "|", 2)[0] },
+		Event:     "order",
+		Transform: func(m Message) ([]byte, error) { return []byte(strings.SplitN(string(m.Payload), "|", 2)[1]), nil },
+	}}})
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	srv := httptest.NewServer(broker.Handler("user:alice"))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	for deadline := time.Now().Add(time.Second); broker.ClientCount() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("client not subscribed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	bus.publish("orders", "bob|for bob")
+	bus.publish("orders", "alice|for alice")
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	var got []string
+	timeout := time.After(time.Second)
+	for len(got) < 2 {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream ended after %v", got)
+			}
+			if strings.HasPrefix(line, "event:") || strings.HasPrefix(line, "data:") {
+				got = append(got, line)
+			}
+		case <-timeout:
+			t.Fatalf("timed out after %v", got)
+		}
+	}
+	if got[0] != "event: order" || got[1] != "data: for alice" {
+		t.Errorf("got %v, want the order event for alice", got)
+	}
+}
+
+func TestRelay_Start(t *testing.T) {
+	hub := websocket.NewHub()
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"no bus", Config{Hub: hub}},
+		{"no destination", Config{Bus: &fakeBus{}}},
+		{"subscribe fails", Config{Bus: &fakeBus{fail: errors.New("down")}, Hub: hub, Routes: []Route{{Topic: "a"}}}},
+		{"allow with broker", Config{Bus: &fakeBus{}, Hub: hub, Broker: sse.NewBroker(sse.Config{Heartbeat: -1}), Routes: []Route{{
+			Topic: "a",
+			Allow: func(string, Message) bool { return false },
+		}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := New(tt.config).Start(context.Background()); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    string
+	}{
+		{`{"id":42}`, `{"data":{"id":42},"topic":"orders"}`},
+		{`plain`, `{"data":"plain","topic":"orders"}`},
+	}
+	for _, tt := range tests {
+		got, err := Envelope(Message{Topic: "orders", Payload: []byte(tt.payload)})
+		if err != nil || string(got) != tt.want {
+			t.Errorf("Envelope(%s) = %s, %v; want %s", tt.payload, got, err, tt.want)
+		}
+	}
+}
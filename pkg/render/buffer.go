@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// bufferClasses are the capacities pooled buffers are grouped by. Larger
+// buffers are dropped, so a burst of large bodies is not held on to.
+var bufferClasses = [...]int{1 << 10, 8 << 10, 64 << 10, 512 << 10}
+
+var bufferPools [len(bufferClasses)]sync.Pool
+
+// jsonSize is a moving average of encoded JSON sizes, picking the class
+// new encodings start from
+var jsonSize atomic.Int64
+
+// getBuffer returns an empty buffer of at least hint bytes capacity when
+// hint is within the largest class
+func getBuffer(hint int) *bytes.Buffer {
+	class := 0
+	for class < len(bufferClasses)-1 && bufferClasses[class] < hint {
+		class++
+	}
+	if buf, ok := bufferPools[class].Get().(*bytes.Buffer); ok {
+		return buf
+	}
+	return bytes.NewBuffer(make([]byte, 0, bufferClasses[class]))
+}
+
+// putBuffer returns buf to the pool of the largest class it holds
+func putBuffer(buf *bytes.Buffer) {
+	capacity := buf.Cap()
+	if capacity < bufferClasses[0] || capacity > 2*bufferClasses[len(bufferClasses)-1] {
+		return
+	}
+	class := len(bufferClasses) - 1
+	for bufferClasses[class] > capacity {
+		class--
+	}
+	buf.Reset()
+	bufferPools[class].Put(buf)
+}
+
+// EncodeError is returned by WriteJSON when obj cannot be encoded, e.g. a
+// channel or func field or a MarshalJSON method failing or panicking.
+// Nothing has been written then.
+type EncodeError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *EncodeError) Error() string {
+	return "json encoding failed: " + e.Err.Error()
+}
+
+// Unwrap returns the encoder error
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// WriteJSON encodes obj with codec into a pooled buffer, then writes it
+// with code and Content-Length. Nothing is written when encoding fails, so
+// the caller can still send an error; panics of marshalers are returned as
+// an *EncodeError too. Callers set Content-Type.
+func WriteJSON(w http.ResponseWriter, code int, codec JSONCodec, obj interface{}) error {
+	return writeJSON(w, code, func(buf *bytes.Buffer) error {
+		return codec.Encode(buf, obj)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, encode func(*bytes.Buffer) error) error {
+	buf := getBuffer(int(jsonSize.Load()))
+	defer putBuffer(buf)
+	if err := safeEncode(buf, encode); err != nil {
+		return err
+	}
+	// Racing updates lose a sample at worst
+	avg := jsonSize.Load()
+	jsonSize.Store(avg + (int64(buf.Len())-avg)/16)
+
+	// With the length known, Compress passes bodies below its MinSize
+	// through without buffering them
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(code)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// safeEncode runs encode, turning its failures and panics into an
+// *EncodeError
+func safeEncode(buf *bytes.Buffer, encode func(*bytes.Buffer) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &EncodeError{Err: fmt.Errorf("panic: %v", recovered)}
+		}
+	}()
+	if err := encode(buf); err != nil {
+		return &EncodeError{Err: err}
+	}
+	return nil
+}
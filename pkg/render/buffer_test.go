@@ -0,0 +1,163 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBufferClasses(t *testing.T) {
+	tests := []struct {
+		hint    int
+		wantCap int
+	}{
+		{0, 1 << 10},
+		{1 << 10, 1 << 10},
+		{1<<10 + 1, 8 << 10},
+		{100 << 10, 512 << 10},
+		{4 << 20, 512 << 10},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.hint), func(t *testing.T) {
+			// Drain buffers left by other tests
+			for range 8 {
+				_ = getBuffer(tt.hint)
+			}
+			buf := getBuffer(tt.hint)
+			if buf.Cap() < tt.wantCap || buf.Len() != 0 {
+				t.Errorf("expected an empty buffer of %d bytes, got len %d cap %d", tt.wantCap, buf.Len(), buf.Cap())
+			}
+		})
+	}
+
+	// Buffers grown past every class are not pooled
+	huge := bytes.NewBuffer(make([]byte, 0, 4<<20))
+	putBuffer(huge)
+	for class := range bufferPools {
+		if buf, ok := bufferPools[class].Get().(*bytes.Buffer); ok && buf == huge {
+			t.Fatal("expected an oversized buffer to be dropped")
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	codec := DefaultJSONCodec()
+
+	rec := httptest.NewRecorder()
+	if err := WriteJSON(rec, http.StatusCreated, codec, person{Name: "john", Age: 30}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"john","age":30}` + "\n"
+	if rec.Code != http.StatusCreated || rec.Body.String() != want {
+		t.Errorf("unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Length"); got != fmt.Sprint(len(want)) {
+		t.Errorf("expected Content-Length %d, got %q", len(want), got)
+	}
+
+	// A failed encoding leaves the response untouched
+	rec = httptest.NewRecorder()
+	if err := WriteJSON(rec, http.StatusOK, codec, make(chan int)); err == nil {
+		t.Fatal("expected an encoding error")
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Length") != "" {
+		t.Errorf("expected nothing written, got %q", rec.Body.String())
+	}
+
+	// So does a panicking marshaler
+	rec = httptest.NewRecorder()
+	err := WriteJSON(rec, http.StatusOK, codec, map[string]interface{}{"v": panicMarshaler{}})
+	var encodeErr *EncodeError
+	if !errors.As(err, &encodeErr) {
+		t.Fatalf("expected an *EncodeError, got %v", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected nothing written, got %q", rec.Body.String())
+	}
+
+	// Pooled buffers do not leak content between responses
+	large := map[string]string{"data": strings.Repeat("x", 20<<10)}
+	_ = WriteJSON(httptest.NewRecorder(), http.StatusOK, codec, large)
+	rec = httptest.NewRecorder()
+	_ = WriteJSON(rec, http.StatusOK, codec, 1)
+	if rec.Body.String() != "1\n" {
+		t.Errorf("expected only the second body, got %q", rec.Body.String())
+	}
+}
+
+type panicMarshaler struct{}
+
+func (panicMarshaler) MarshalJSON() ([]byte, error) {
+	panic("boom")
+}
+
+// discardWriter is a ResponseWriter dropping the body, so benchmarks
+// measure encoding rather than a recorder's buffer
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+type benchItem struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Tags  []string `json:"tags"`
+}
+
+func benchPayloads() map[string]interface{} {
+	items := func(n int) []benchItem {
+		list := make([]benchItem, n)
+		for i := range list {
+			list[i] = benchItem{ID: i, Name: "user", Email: "user@example.com", Tags: []string{"a", "b"}}
+		}
+		return list
+	}
+	return map[string]interface{}{
+		"small":  map[string]string{"status": "ok"},
+		"medium": items(20),
+		"large":  items(2000),
+	}
+}
+
+func BenchmarkJSON(b *testing.B) {
+	for _, size := range []string{"small", "medium", "large"} {
+		payload := benchPayloads()[size]
+		b.Run(size+"/pooled", func(b *testing.B) {
+			w := &discardWriter{header: make(http.Header)}
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = JSON(w, http.StatusOK, payload)
+			}
+		})
+		// Encoding straight to the response, as before pooling
+		b.Run(size+"/direct", func(b *testing.B) {
+			w := &discardWriter{header: make(http.Header)}
+			b.ReportAllocs()
+			for b.Loop() {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(payload)
+			}
+		})
+	}
+}
+
+func BenchmarkJSON_Parallel(b *testing.B) {
+	payload := benchPayloads()["medium"]
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		w := &discardWriter{header: make(http.Header)}
+		for pb.Next() {
+			_ = JSON(w, http.StatusOK, payload)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// JSONCodec encodes and decodes JSON bodies. Implementations must be safe
+// for concurrent use.
+type JSONCodec interface {
+	// Encode writes v to w as JSON followed by a newline
+	Encode(w io.Writer, v interface{}) error
+	// EncodeIndent is Encode with each element on its own line, indented
+	// by indent
+	EncodeIndent(w io.Writer, v interface{}, indent string) error
+	// Decode reads the next JSON value from r into v
+	Decode(r io.Reader, v interface{}) error
+}
+
+// JSONOptions holds JSON encoding options. The zero value matches
+// encoding/json.
+type JSONOptions struct {
+	// DisableHTMLEscape writes <, > and & in strings as is instead of as
+	// \u003c, \u003e and \u0026
+	DisableHTMLEscape bool
+
+	// Indent indents every encoded value, e.g. "  " for readable responses
+	Indent string
+
+	// TimeFormat is the layout time.Time values are encoded and decoded
+	// with (default time.RFC3339Nano)
+	TimeFormat string
+
+	// OmitEmptyMaps omits empty and nil map fields of structs, as if they
+	// were tagged omitempty
+	OmitEmptyMaps bool
+
+	// FieldCase renames struct fields, tagged or not, e.g. to serve legacy
+	// models in the API's style. Decoding accepts both names.
+	FieldCase FieldCase
+}
+
+// FieldCase is a naming style of JSON object fields
+type FieldCase string
+
+// Field cases
+const (
+	SnakeCase FieldCase = "snake" // user_id, http_server
+	CamelCase FieldCase = "camel" // userId, httpServer
+)
+
+// Convert returns name in the case c. Words are split on underscores,
+// hyphens, spaces and case changes, keeping acronyms whole: "HTTPServer"
+// is "http_server" and "httpServer".
+func (c FieldCase) Convert(name string) string {
+	words := splitWords(name)
+	var b strings.Builder
+	b.Grow(len(name) + len(words))
+	for i, word := range words {
+		word = strings.ToLower(word)
+		switch c {
+		case SnakeCase:
+			if i > 0 {
+				b.WriteByte('_')
+			}
+		case CamelCase:
+			if i > 0 {
+				r, size := utf8.DecodeRuneInString(word)
+				b.WriteRune(unicode.ToUpper(r))
+				word = word[size:]
+			}
+		default:
+			return name
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// splitWords splits a Go, snake, kebab or camel case name into words
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '_' || r == '-' || r == ' ' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(r) {
+			continue
+		}
+		prev := runes[i-1]
+		// fooBar, foo2Bar, and the Server of HTTPServer
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// ErrJSONOption is returned by StdJSON for options encoding/json does not
+// support
+var ErrJSONOption = errors.New("JSON option not supported by encoding/json")
+
+// StdJSON returns a codec using encoding/json. TimeFormat, OmitEmptyMaps
+// and FieldCase need a codec such as the one of render/jsoniter.
+func StdJSON(opts JSONOptions) (JSONCodec, error) {
+	if opts.TimeFormat != "" {
+		return nil, fmt.Errorf("%w: TimeFormat", ErrJSONOption)
+	}
+	if opts.OmitEmptyMaps {
+		return nil, fmt.Errorf("%w: OmitEmptyMaps", ErrJSONOption)
+	}
+	if opts.FieldCase != "" {
+		return nil, fmt.Errorf("%w: FieldCase", ErrJSONOption)
+	}
+	return stdJSON{opts: opts}, nil
+}
+
+type stdJSON struct {
+	opts JSONOptions
+}
+
+func (c stdJSON) Encode(w io.Writer, v interface{}) error {
+	return c.EncodeIndent(w, v, c.opts.Indent)
+}
+
+func (c stdJSON) EncodeIndent(w io.Writer, v interface{}, indent string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(!c.opts.DisableHTMLEscape)
+	if indent != "" {
+		encoder.SetIndent("", indent)
+	}
+	return encoder.Encode(v)
+}
+
+func (c stdJSON) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+var defaultJSON atomic.Value // holds jsonCodecHolder
+
+// jsonCodecHolder keeps atomic.Value storing one concrete type
+type jsonCodecHolder struct {
+	codec JSONCodec
+}
+
+func init() {
+	defaultJSON.Store(jsonCodecHolder{codec: stdJSON{}})
+}
+
+// SetJSONCodec sets the codec of JSON, JSONIndent and, unless their App
+// configures one, app.Context; nil restores encoding/json
+func SetJSONCodec(codec JSONCodec) {
+	if codec == nil {
+		codec = stdJSON{}
+	}
+	defaultJSON.Store(jsonCodecHolder{codec: codec})
+}
+
+// DefaultJSONCodec returns the codec set with SetJSONCodec
+func DefaultJSONCodec() JSONCodec {
+	return defaultJSON.Load().(jsonCodecHolder).codec
+}
@@ -0,0 +1,146 @@
+// Package jsoniter provides a render.JSONCodec built on json-iterator, a
+// faster drop-in for encoding/json suited to high-throughput APIs
+package jsoniter
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/modern-go/reflect2"
+	"github.com/polymatx/goframe/pkg/render"
+)
+
+// New returns a codec with opts. Output matches encoding/json, apart from
+// the options set.
+func New(opts render.JSONOptions) render.JSONCodec {
+	api := jsoniter.Config{
+		EscapeHTML:             !opts.DisableHTMLEscape,
+		SortMapKeys:            true,
+		ValidateJsonRawMessage: true,
+	}.Froze()
+	api.RegisterExtension(&extension{timeFormat: opts.TimeFormat, omitEmptyMaps: opts.OmitEmptyMaps, fieldCase: opts.FieldCase})
+	return &codec{api: api, indent: opts.Indent}
+}
+
+type codec struct {
+	api    jsoniter.API
+	indent string
+}
+
+func (c *codec) Encode(w io.Writer, v interface{}) error {
+	return c.EncodeIndent(w, v, c.indent)
+}
+
+func (c *codec) EncodeIndent(w io.Writer, v interface{}, indent string) error {
+	if indent != "" {
+		encoder := c.api.NewEncoder(w)
+		encoder.SetIndent("", indent)
+		return encoder.Encode(v)
+	}
+	// Pooled streams spare an allocation per response
+	stream := c.api.BorrowStream(w)
+	defer c.api.ReturnStream(stream)
+	stream.WriteVal(v)
+	stream.WriteRaw("\n")
+	if stream.Error != nil {
+		return stream.Error
+	}
+	return stream.Flush()
+}
+
+func (c *codec) Decode(r io.Reader, v interface{}) error {
+	return c.api.NewDecoder(r).Decode(v)
+}
+
+var timeType = reflect2.TypeOf(time.Time{})
+
+// extension applies TimeFormat, OmitEmptyMaps and FieldCase
+type extension struct {
+	jsoniter.DummyExtension
+	timeFormat    string
+	omitEmptyMaps bool
+	fieldCase     render.FieldCase
+}
+
+// CreateEncoder encodes times without going through time.MarshalJSON,
+// which allocates, in the layout of encoding/json unless TimeFormat is set
+func (e *extension) CreateEncoder(typ reflect2.Type) jsoniter.ValEncoder {
+	if typ != timeType {
+		return nil
+	}
+	if e.timeFormat == "" {
+		return &timeCodec{layout: time.RFC3339Nano}
+	}
+	return &timeCodec{layout: e.timeFormat, escape: strings.ContainsAny(e.timeFormat, "\"\\<>&")}
+}
+
+func (e *extension) CreateDecoder(typ reflect2.Type) jsoniter.ValDecoder {
+	if e.timeFormat != "" && typ == timeType {
+		return &timeCodec{layout: e.timeFormat}
+	}
+	return nil
+}
+
+// UpdateStructDescriptor renames fields to FieldCase, and tags map fields
+// omitempty, which jsoniter reads from the field tag once extensions ran
+func (e *extension) UpdateStructDescriptor(desc *jsoniter.StructDescriptor) {
+	for _, binding := range desc.Fields {
+		if e.fieldCase != "" && len(binding.ToNames) > 0 {
+			name := e.fieldCase.Convert(binding.ToNames[0])
+			binding.ToNames = []string{name}
+			if len(binding.FromNames) == 0 || name != binding.FromNames[0] {
+				binding.FromNames = append([]string{name}, binding.FromNames...)
+			}
+		}
+		if e.omitEmptyMaps && binding.Field.Type().Kind() == reflect.Map {
+			binding.Field = omitEmptyField{binding.Field}
+		}
+	}
+}
+
+type omitEmptyField struct {
+	reflect2.StructField
+}
+
+func (f omitEmptyField) Tag() reflect.StructTag {
+	tag := f.StructField.Tag()
+	return reflect.StructTag(fmt.Sprintf("json:%q", tag.Get("json")+",omitempty"))
+}
+
+type timeCodec struct {
+	layout string
+	escape bool // Whether layout has characters JSON strings escape
+}
+
+func (c *timeCodec) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
+	if c.escape {
+		stream.WriteString((*time.Time)(ptr).Format(c.layout))
+		return
+	}
+	// Appended in place, sparing the string of Format
+	buf := append(stream.Buffer(), '"')
+	buf = (*time.Time)(ptr).AppendFormat(buf, c.layout)
+	stream.SetBuffer(append(buf, '"'))
+}
+
+// IsEmpty is false, as encoding/json never omits structs
+func (c *timeCodec) IsEmpty(ptr unsafe.Pointer) bool {
+	return false
+}
+
+func (c *timeCodec) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	if iter.ReadNil() {
+		return
+	}
+	t, err := time.Parse(c.layout, iter.ReadString())
+	if err != nil {
+		iter.ReportError("decode time.Time", err.Error())
+		return
+	}
+	*(*time.Time)(ptr) = t
+}
@@ -0,0 +1,147 @@
+package jsoniter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/render"
+)
+
+type event struct {
+	Name   string            `json:"name"`
+	At     time.Time         `json:"at"`
+	Labels map[string]string `json:"labels"`
+	Extra  map[string]int
+}
+
+func TestCodec(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		opts render.JSONOptions
+		v    interface{}
+	}{
+		{"default", render.JSONOptions{}, event{Name: "<a&b>", At: at, Labels: map[string]string{"b": "2", "a": "1"}}},
+		{"no HTML escape", render.JSONOptions{DisableHTMLEscape: true}, event{Name: "<a&b>", At: at}},
+		{"map", render.JSONOptions{}, map[string]interface{}{"z": 1, "a": []int{1, 2}}},
+		{"time zone", render.JSONOptions{}, []time.Time{time.Date(2024, 5, 1, 12, 30, 0, 1500, time.FixedZone("", 3600))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, want strings.Builder
+			if err := New(tt.opts).Encode(&got, tt.v); err != nil {
+				t.Fatal(err)
+			}
+			std, _ := render.StdJSON(tt.opts)
+			_ = std.Encode(&want, tt.v)
+			if got.String() != want.String() {
+				t.Errorf("expected encoding/json output %s, got %s", want.String(), got.String())
+			}
+		})
+	}
+}
+
+func TestCodec_Options(t *testing.T) {
+	codec := New(render.JSONOptions{TimeFormat: time.DateOnly, OmitEmptyMaps: true})
+	at := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	var buf strings.Builder
+	if err := codec.Encode(&buf, event{Name: "launch", At: at, Extra: map[string]int{"n": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"launch","at":"2024-05-01","Extra":{"n":1}}` + "\n"; buf.String() != want {
+		t.Errorf("expected %s, got %s", want, buf.String())
+	}
+
+	var decoded event
+	if err := codec.Decode(strings.NewReader(`{"at":"2024-05-01"}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.At.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the date to be parsed with TimeFormat, got %v", decoded.At)
+	}
+	if err := codec.Decode(strings.NewReader(`{"at":"yesterday"}`), &decoded); err == nil {
+		t.Error("expected an error for a time not in TimeFormat")
+	}
+
+	// Indented output stays valid JSON
+	buf.Reset()
+	if err := codec.EncodeIndent(&buf, event{Name: "x"}, "  "); err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid([]byte(buf.String())) || !strings.Contains(buf.String(), "\n  \"name\"") {
+		t.Errorf("unexpected indented output %s", buf.String())
+	}
+}
+
+func BenchmarkCodec(b *testing.B) {
+	payload := make([]event, 20)
+	for i := range payload {
+		payload[i] = event{Name: "launch", At: time.Now()}
+	}
+	std, _ := render.StdJSON(render.JSONOptions{})
+	for name, codec := range map[string]render.JSONCodec{"jsoniter": New(render.JSONOptions{}), "std": std} {
+		b.Run(name, func(b *testing.B) {
+			w := httptest.NewRecorder()
+			b.ReportAllocs()
+			for b.Loop() {
+				w.Body.Reset()
+				_ = render.WriteJSON(w, http.StatusOK, codec, payload)
+			}
+		})
+	}
+}
+
+func TestCodec_FieldCase(t *testing.T) {
+	type Audit struct {
+		CreatedBy string
+	}
+	type legacyUser struct {
+		Audit
+		UserID    int               `json:"UserID"`
+		FirstName string            `json:"first_name"`
+		HTTPRoles []string          `json:",omitempty"`
+		Settings  map[string]string `json:"Settings"`
+		Secret    string            `json:"-"`
+	}
+	user := legacyUser{Audit: Audit{CreatedBy: "admin"}, UserID: 7, FirstName: "Ada", Settings: map[string]string{"DarkMode": "on"}}
+
+	tests := []struct {
+		fieldCase render.FieldCase
+		want      string
+	}{
+		{render.SnakeCase, `{"created_by":"admin","user_id":7,"first_name":"Ada","settings":{"DarkMode":"on"}}`},
+		{render.CamelCase, `{"createdBy":"admin","userId":7,"firstName":"Ada","settings":{"DarkMode":"on"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.fieldCase), func(t *testing.T) {
+			codec := New(render.JSONOptions{FieldCase: tt.fieldCase})
+			var buf strings.Builder
+			if err := codec.Encode(&buf, user); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+
+			// Both the converted and the original names decode
+			var decoded legacyUser
+			if err := codec.Decode(strings.NewReader(tt.want), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.UserID != 7 || decoded.FirstName != "Ada" || decoded.CreatedBy != "admin" {
+				t.Errorf("unexpected decoded value %+v", decoded)
+			}
+			decoded = legacyUser{}
+			_ = codec.Decode(strings.NewReader(`{"UserID":8}`), &decoded)
+			if decoded.UserID != 8 {
+				t.Errorf("expected the tag name to decode, got %+v", decoded)
+			}
+		})
+	}
+}
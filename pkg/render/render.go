@@ -1,30 +1,30 @@
 package render
 
 import (
-	"encoding/json"
+	"bytes"
 	"encoding/xml"
 	"fmt"
 	"html/template"
 	"io"
+	"mime"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 )
 
 // JSON renders JSON response
 func JSON(w http.ResponseWriter, code int, obj interface{}) error {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(code)
-	return json.NewEncoder(w).Encode(obj)
+	return WriteJSON(w, code, DefaultJSONCodec(), obj)
 }
 
 // JSONIndent renders indented JSON response
 func JSONIndent(w http.ResponseWriter, code int, obj interface{}) error {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(code)
-
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(obj)
+	return writeJSON(w, code, func(buf *bytes.Buffer) error {
+		return DefaultJSONCodec().EncodeIndent(buf, obj, "  ")
+	})
 }
 
 // XML renders XML response
@@ -63,7 +63,10 @@ func HTMLString(w http.ResponseWriter, code int, html string) error {
 	return err
 }
 
-// File sends file for download
+// File sends file for download.
+//
+// Deprecated: use ServeAttachment, or app.Context.FileFromStorage for files
+// in a storage.Store; both support Range and conditional requests.
 func File(w http.ResponseWriter, filepath string) error {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -84,7 +87,9 @@ func File(w http.ResponseWriter, filepath string) error {
 	return err
 }
 
-// FileAttachment sends file with custom filename
+// FileAttachment sends file with custom filename.
+//
+// Deprecated: use ServeAttachment.
 func FileAttachment(w http.ResponseWriter, filepath, filename string) error {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -105,6 +110,59 @@ func FileAttachment(w http.ResponseWriter, filepath, filename string) error {
 	return err
 }
 
+// ServeFile serves a file inline with its content type, honoring Range,
+// If-Modified-Since, If-None-Match and If-Range, so media players can seek
+// and clients revalidate cheaply. Files are sent with sendfile where the
+// connection supports it.
+func ServeFile(w http.ResponseWriter, r *http.Request, path string) error {
+	return serveFile(w, r, path, "")
+}
+
+// ServeAttachment is ServeFile sent as a download named filename, e.g. for
+// resumable downloads of large exports
+func ServeAttachment(w http.ResponseWriter, r *http.Request, path, filename string) error {
+	return serveFile(w, r, path, filename)
+}
+
+func serveFile(w http.ResponseWriter, r *http.Request, path, attachment string) error {
+	file, err := os.Open(path) // #nosec G304 -- callers choose which files to serve
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	if attachment != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment}))
+	}
+	ServeContent(w, r, info.Name(), info.ModTime(), FileETag(info), file)
+	return nil
+}
+
+// ServeContent serves content, e.g. a blob from object storage, with the
+// same Range and conditional request handling as ServeFile. name selects
+// the content type by extension unless Content-Type is set; a zero modtime
+// or empty etag disables the matching validator.
+func ServeContent(w http.ResponseWriter, r *http.Request, name string, modtime time.Time, etag string, content io.ReadSeeker) {
+	if etag != "" && w.Header().Get("ETag") == "" {
+		w.Header().Set("ETag", etag)
+	}
+	http.ServeContent(w, r, name, modtime, content)
+}
+
+// FileETag returns a strong ETag for a file version, derived from its size
+// and modification time without reading it
+func FileETag(info os.FileInfo) string {
+	return `"` + strconv.FormatInt(info.Size(), 16) + "-" + strconv.FormatInt(info.ModTime().UnixNano(), 16) + `"`
+}
+
 // Data renders raw bytes
 func Data(w http.ResponseWriter, code int, contentType string, data []byte) error {
 	w.Header().Set("Content-Type", contentType)
@@ -125,7 +183,17 @@ func Redirect(w http.ResponseWriter, r *http.Request, code int, location string)
 
 // TemplateRenderer holds templates
 type TemplateRenderer struct {
-	templates *template.Template
+	base      *template.Template // Parsed templates, never executed so they can be cloned
+	templates *template.Template // Copy of base executed by Render
+}
+
+// newTemplateRenderer creates a renderer of the templates parsed in base
+func newTemplateRenderer(base *template.Template) (*TemplateRenderer, error) {
+	tr := &TemplateRenderer{base: base}
+	if err := tr.refresh(); err != nil {
+		return nil, err
+	}
+	return tr, nil
 }
 
 // NewTemplateRenderer creates a new template renderer
@@ -134,10 +202,17 @@ func NewTemplateRenderer(pattern string) (*TemplateRenderer, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newTemplateRenderer(tmpl)
+}
 
-	return &TemplateRenderer{
-		templates: tmpl,
-	}, nil
+// NewTemplateRendererFuncs creates a template renderer whose templates may
+// call funcs, e.g. i18n.TemplateFuncs; RenderFuncs replaces them per call
+func NewTemplateRendererFuncs(pattern string, funcs template.FuncMap) (*TemplateRenderer, error) {
+	tmpl, err := template.New("").Funcs(funcs).ParseGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return newTemplateRenderer(tmpl)
 }
 
 // Render renders a template by name
@@ -147,19 +222,47 @@ func (tr *TemplateRenderer) Render(w http.ResponseWriter, code int, name string,
 	return tr.templates.ExecuteTemplate(w, name, data)
 }
 
+// RenderFuncs renders a template by name with funcs replacing the functions
+// of the same name given to NewTemplateRendererFuncs, e.g. for the request
+// locale. It executes a copy of the parsed templates, so Render, later calls
+// and AddTemplate keep working.
+func (tr *TemplateRenderer) RenderFuncs(w http.ResponseWriter, code int, name string, data interface{}, funcs template.FuncMap) error {
+	tmpl, err := tr.base.Clone()
+	if err != nil {
+		return err
+	}
+	if funcs != nil {
+		tmpl.Funcs(funcs)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	_, err = buf.WriteTo(w)
+	return err
+}
+
 // AddTemplate adds a template file
 func (tr *TemplateRenderer) AddTemplate(files ...string) error {
-	tmpl, err := tr.templates.ParseFiles(files...)
-	if err != nil {
+	if _, err := tr.base.ParseFiles(files...); err != nil {
 		return err
 	}
-	tr.templates = tmpl
-	return nil
+	return tr.refresh()
 }
 
 // AddTemplateGlob adds templates by glob pattern
 func (tr *TemplateRenderer) AddTemplateGlob(pattern string) error {
-	tmpl, err := tr.templates.ParseGlob(pattern)
+	if _, err := tr.base.ParseGlob(pattern); err != nil {
+		return err
+	}
+	return tr.refresh()
+}
+
+// refresh replaces the templates executed by Render with a copy of base
+func (tr *TemplateRenderer) refresh() error {
+	tmpl, err := tr.base.Clone()
 	if err != nil {
 		return err
 	}
@@ -2,13 +2,16 @@ package render
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 type person struct {
@@ -291,6 +294,103 @@ func TestFileAttachment(t *testing.T) {
 	})
 }
 
+func TestServeFile(t *testing.T) {
+	content := "0123456789abcdef"
+	path := writeTempFile(t, t.TempDir(), "clip.txt", content)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := FileETag(info)
+
+	serve := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/clip.txt", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		if err := ServeFile(rec, req, path); err != nil {
+			t.Fatalf("ServeFile returned error: %v", err)
+		}
+		return rec
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{"full file", nil, http.StatusOK, content},
+		{"range", map[string]string{"Range": "bytes=4-7"}, http.StatusPartialContent, "4567"},
+		{"suffix range", map[string]string{"Range": "bytes=-3"}, http.StatusPartialContent, "def"},
+		{"unsatisfiable range", map[string]string{"Range": "bytes=100-"}, http.StatusRequestedRangeNotSatisfiable, ""},
+		{"if-none-match", map[string]string{"If-None-Match": etag}, http.StatusNotModified, ""},
+		{"stale if-none-match", map[string]string{"If-None-Match": `"old"`}, http.StatusOK, content},
+		{"if-modified-since", map[string]string{"If-Modified-Since": info.ModTime().Add(time.Second).UTC().Format(http.TimeFormat)}, http.StatusNotModified, ""},
+		{"if-range match", map[string]string{"Range": "bytes=0-1", "If-Range": etag}, http.StatusPartialContent, "01"},
+		{"if-range mismatch", map[string]string{"Range": "bytes=0-1", "If-Range": `"old"`}, http.StatusOK, content},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.headers)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+			if tt.status < 400 && rec.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", rec.Header().Get("ETag"), etag)
+			}
+		})
+	}
+
+	t.Run("content type and ranges", func(t *testing.T) {
+		rec := serve(nil)
+		if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if ar := rec.Header().Get("Accept-Ranges"); ar != "bytes" {
+			t.Errorf("Accept-Ranges = %q, want bytes", ar)
+		}
+	})
+
+	t.Run("directory returns error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := ServeFile(httptest.NewRecorder(), req, t.TempDir()); err == nil {
+			t.Error("expected error for a directory")
+		}
+	})
+}
+
+func TestServeAttachment(t *testing.T) {
+	path := writeTempFile(t, t.TempDir(), "internal.dat", "export")
+
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	req.Header.Set("Range", "bytes=2-")
+	rec := httptest.NewRecorder()
+	if err := ServeAttachment(rec, req, path, "monthly report.csv"); err != nil {
+		t.Fatalf("ServeAttachment returned error: %v", err)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="monthly report.csv"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "port" {
+		t.Errorf("got %d %q, want 206 \"port\"", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServeContent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/avatar.png", nil)
+	req.Header.Set("If-None-Match", `"v2"`)
+	rec := httptest.NewRecorder()
+	ServeContent(rec, req, "avatar.png", time.Time{}, `"v2"`, strings.NewReader("png"))
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304", rec.Code)
+	}
+}
+
 func TestData(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -418,6 +518,78 @@ func TestTemplateRenderer_Render(t *testing.T) {
 	})
 }
 
+func TestTemplateRenderer_RenderFuncs(t *testing.T) {
+	dir := t.TempDir()
+	writeTempFile(t, dir, "greet.html", "<p>{{greet .}}</p>")
+
+	funcs := func(greeting string) template.FuncMap {
+		return template.FuncMap{"greet": func(name string) string { return greeting + " " + name }}
+	}
+	tr, err := NewTemplateRendererFuncs(filepath.Join(dir, "*.html"), funcs("Hello"))
+	if err != nil {
+		t.Fatalf("NewTemplateRendererFuncs returned error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		funcs template.FuncMap
+		want  string
+	}{
+		{"parse-time funcs", nil, "<p>Hello john</p>"},
+		{"replaced funcs", funcs("Hallo"), "<p>Hallo john</p>"},
+		{"again after execution", funcs("Hola"), "<p>Hola john</p>"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		if err := tr.RenderFuncs(rec, http.StatusOK, "greet.html", "john", tt.funcs); err != nil {
+			t.Fatalf("%s: RenderFuncs returned error: %v", tt.name, err)
+		}
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.name, rec.Body.String(), tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	if err := tr.Render(rec, http.StatusOK, "greet.html", "jane"); err != nil || rec.Body.String() != "<p>Hello jane</p>" {
+		t.Errorf("Render = %q, %v", rec.Body.String(), err)
+	}
+	rec = httptest.NewRecorder()
+	if err := tr.RenderFuncs(rec, http.StatusOK, "missing.html", nil, nil); err == nil || rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("missing template wrote %d %q, error %v", rec.Code, rec.Body.String(), err)
+	}
+}
+
+func TestTemplateRenderer_RenderThenRenderFuncs(t *testing.T) {
+	dir := t.TempDir()
+	writeTempFile(t, dir, "greet.html", "<p>Hello {{.}}</p>")
+
+	tr, err := NewTemplateRenderer(filepath.Join(dir, "*.html"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := tr.Render(rec, http.StatusOK, "greet.html", "john"); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	if err := tr.RenderFuncs(rec, http.StatusOK, "greet.html", "jane", nil); err != nil {
+		t.Fatalf("RenderFuncs after Render returned error: %v", err)
+	}
+	if rec.Body.String() != "<p>Hello jane</p>" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "<p>Hello jane</p>")
+	}
+
+	extra := writeTempFile(t, t.TempDir(), "extra.html", "extra")
+	if err := tr.AddTemplate(extra); err != nil {
+		t.Fatalf("AddTemplate after Render returned error: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	if err := tr.Render(rec, http.StatusOK, "extra.html", nil); err != nil || rec.Body.String() != "extra" {
+		t.Errorf("Render of added template = %q, %v", rec.Body.String(), err)
+	}
+}
+
 func TestTemplateRenderer_AddTemplate(t *testing.T) {
 	dir := t.TempDir()
 	writeTempFile(t, dir, "base.html", "base")
@@ -491,3 +663,72 @@ func TestTemplateRenderer_AddTemplateGlob(t *testing.T) {
 		}
 	})
 }
+
+func TestStdJSON(t *testing.T) {
+	codec, err := StdJSON(JSONOptions{DisableHTMLEscape: true, Indent: "\t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if err := codec.Encode(&buf, map[string]string{"html": "<b>"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n\t\"html\": \"<b>\"\n}\n"; buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+
+	for _, opts := range []JSONOptions{{TimeFormat: time.DateOnly}, {OmitEmptyMaps: true}, {FieldCase: SnakeCase}} {
+		if _, err := StdJSON(opts); !errors.Is(err, ErrJSONOption) {
+			t.Errorf("expected ErrJSONOption for %+v, got %v", opts, err)
+		}
+	}
+}
+
+func TestSetJSONCodec(t *testing.T) {
+	codec, _ := StdJSON(JSONOptions{DisableHTMLEscape: true})
+	SetJSONCodec(codec)
+	t.Cleanup(func() { SetJSONCodec(nil) })
+
+	rec := httptest.NewRecorder()
+	if err := JSON(rec, http.StatusOK, "a&b"); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != "\"a&b\"\n" {
+		t.Errorf("expected the codec set to be used, got %q", got)
+	}
+
+	SetJSONCodec(nil)
+	rec = httptest.NewRecorder()
+	_ = JSON(rec, http.StatusOK, "a&b")
+	if got := rec.Body.String(); got != "\"a\\u0026b\"\n" {
+		t.Errorf("expected encoding/json after reset, got %q", got)
+	}
+}
+
+func TestFieldCase_Convert(t *testing.T) {
+	tests := []struct {
+		name, snake, camel string
+	}{
+		{"UserID", "user_id", "userId"},
+		{"HTTPServer", "http_server", "httpServer"},
+		{"userName", "user_name", "userName"},
+		{"created_at", "created_at", "createdAt"},
+		{"Address2Line", "address2_line", "address2Line"},
+		{"X-Request-ID", "x_request_id", "xRequestId"},
+		{"ID", "id", "id"},
+		{"Ünicode_Name", "ünicode_name", "ünicodeName"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SnakeCase.Convert(tt.name); got != tt.snake {
+				t.Errorf("snake: expected %q, got %q", tt.snake, got)
+			}
+			if got := CamelCase.Convert(tt.name); got != tt.camel {
+				t.Errorf("camel: expected %q, got %q", tt.camel, got)
+			}
+		})
+	}
+	if got := FieldCase("kebab").Convert("UserID"); got != "UserID" {
+		t.Errorf("expected unknown cases to keep the name, got %q", got)
+	}
+}
@@ -0,0 +1,45 @@
+// Package reqctx holds the request values set by the HTTP layer, such as the
+// request ID, the matched route and the span recorder, so packages below it
+// like database and cache can use them without importing pkg/middleware
+package reqctx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// GetRequestID returns the request ID of ctx, or "" if none was set
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RouteTemplate returns the path template of the route matching r, e.g.
+// "/orders/{id}", or "" when r is nil or matched no route
+func RouteTemplate(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return ""
+}
+
+// Route returns the path template of the route matched for the request of
+// ctx, or ""
+func Route(ctx context.Context) string {
+	// mux keeps the matched route in the request context
+	return RouteTemplate((&http.Request{}).WithContext(ctx))
+}
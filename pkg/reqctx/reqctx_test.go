@@ -0,0 +1,63 @@
+package reqctx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRequestID(t *testing.T) {
+	if id := GetRequestID(context.Background()); id != "" {
+		t.Errorf("GetRequestID() = %q, want empty", id)
+	}
+	if id := GetRequestID(WithRequestID(context.Background(), "req-1")); id != "req-1" {
+		t.Errorf("GetRequestID() = %q, want req-1", id)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	var tmpl, route string
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		tmpl, route = RouteTemplate(r), Route(r.Context())
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/42", nil))
+
+	if tmpl != "/orders/{id}" || route != "/orders/{id}" {
+		t.Errorf("RouteTemplate() = %q, Route() = %q, want /orders/{id}", tmpl, route)
+	}
+	if got := RouteTemplate(nil); got != "" {
+		t.Errorf("RouteTemplate(nil) = %q, want empty", got)
+	}
+	if got := Route(context.Background()); got != "" {
+		t.Errorf("Route() without a route = %q, want empty", got)
+	}
+}
+
+type spanLog []string
+
+func (l *spanLog) RecordSpan(kind, name string, start time.Time, err error) {
+	*l = append(*l, kind+" "+name)
+}
+
+func TestRecordSpan(t *testing.T) {
+	ctx := context.Background()
+	if RecordingSpans(ctx) {
+		t.Error("expected no recording without a recorder")
+	}
+	RecordSpan(ctx, SpanDB, "SELECT 1", time.Now(), nil)
+
+	var spans spanLog
+	ctx = WithSpanRecorder(ctx, &spans)
+	if !RecordingSpans(ctx) {
+		t.Error("expected recording with a recorder")
+	}
+	RecordSpan(ctx, SpanCache, "GET k", time.Now(), nil)
+	if len(spans) != 1 || spans[0] != "cache GET k" {
+		t.Errorf("recorded %v, want [cache GET k]", spans)
+	}
+}
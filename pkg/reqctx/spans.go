@@ -0,0 +1,42 @@
+package reqctx
+
+import (
+	"context"
+	"time"
+)
+
+// Span kinds recorded by the instrumentation of pkg/database, pkg/cache and
+// outgoing HTTP calls
+const (
+	SpanDB    = "db"
+	SpanCache = "cache"
+	SpanHTTP  = "http"
+)
+
+// SpanRecorder collects the operations made while serving a request, e.g.
+// the flight recorder of middleware.SlowRequests
+type SpanRecorder interface {
+	RecordSpan(kind, name string, start time.Time, err error)
+}
+
+type spanRecorderKey struct{}
+
+// WithSpanRecorder returns a context whose spans are recorded by rec
+func WithSpanRecorder(ctx context.Context, rec SpanRecorder) context.Context {
+	return context.WithValue(ctx, spanRecorderKey{}, rec)
+}
+
+// RecordingSpans reports whether spans recorded with ctx are kept, so
+// instrumentation can skip timing otherwise
+func RecordingSpans(ctx context.Context) bool {
+	_, ok := ctx.Value(spanRecorderKey{}).(SpanRecorder)
+	return ok
+}
+
+// RecordSpan records an operation that started at start and just ended. It
+// does nothing for contexts without a SpanRecorder.
+func RecordSpan(ctx context.Context, kind, name string, start time.Time, err error) {
+	if rec, ok := ctx.Value(spanRecorderKey{}).(SpanRecorder); ok {
+		rec.RecordSpan(kind, name, start, err)
+	}
+}
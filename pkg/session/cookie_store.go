@@ -0,0 +1,129 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxCookieSize is the size browsers reliably accept for a single cookie
+const maxCookieSize = 4096
+
+// ErrCookieTooLarge is returned when the encoded session exceeds 4KB
+var ErrCookieTooLarge = errors.New("session cookie too large")
+
+// CookieStore keeps the whole session in an AES-GCM encrypted cookie, so no
+// server-side storage is needed. Values must stay small.
+type CookieStore struct {
+	aead cipher.AEAD
+}
+
+type cookiePayload struct {
+	ID        string                 `json:"id"`
+	Values    map[string]interface{} `json:"v"`
+	ExpiresAt int64                  `json:"exp"`
+}
+
+// NewCookieStore creates a cookie store; key must be 16, 24 or 32 bytes
+func NewCookieStore(key []byte) (*CookieStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &CookieStore{aead: aead}, nil
+}
+
+// Load implements Store
+func (c *CookieStore) Load(r *http.Request, config Config) (*Session, error) {
+	cookie, err := r.Cookie(config.Name)
+	if err != nil || cookie.Value == "" {
+		return New(), nil
+	}
+
+	payload, err := c.decrypt(config.Name, cookie.Value)
+	if err != nil {
+		return New(), nil
+	}
+	if time.Now().Unix() > payload.ExpiresAt {
+		return New(), nil
+	}
+
+	if payload.Values == nil {
+		payload.Values = make(map[string]interface{})
+	}
+	return &Session{ID: payload.ID, Values: payload.Values}, nil
+}
+
+// Save implements Store
+func (c *CookieStore) Save(w http.ResponseWriter, r *http.Request, s *Session, config Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.destroyed {
+		http.SetCookie(w, config.cookie("", -1))
+		return nil
+	}
+
+	value, err := c.encrypt(config.Name, cookiePayload{
+		ID:        s.ID,
+		Values:    s.Values,
+		ExpiresAt: time.Now().Add(config.MaxAge).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	cookie := config.cookie(value, int(config.MaxAge.Seconds()))
+	if len(cookie.String()) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+// encrypt seals payload with the cookie name as additional data, so a value
+// issued under one cookie name is rejected under another
+func (c *CookieStore) encrypt(name string, payload cookiePayload) (string, error) {
+	plain, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plain, []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (c *CookieStore) decrypt(name, value string) (cookiePayload, error) {
+	var payload cookiePayload
+
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return payload, err
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return payload, errors.New("session cookie too short")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return payload, err
+	}
+
+	err = json.Unmarshal(plain, &payload)
+	return payload, err
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/cache"
+)
+
+// Backend is the key-value storage used by RedisStore. *cache.Manager
+// satisfies it.
+type Backend interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+}
+
+// RedisStore keeps session values in Redis and only the session ID in the
+// cookie. Sessions expire MaxAge after their last save.
+type RedisStore struct {
+	backend Backend
+	prefix  string
+}
+
+// NewRedisStore creates a store on a cache connection, e.g.
+// session.NewRedisStore(cache.MustGet("default"), "session:")
+func NewRedisStore(backend Backend, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "session:"
+	}
+	return &RedisStore{backend: backend, prefix: prefix}
+}
+
+// Load implements Store
+func (s *RedisStore) Load(r *http.Request, config Config) (*Session, error) {
+	cookie, err := r.Cookie(config.Name)
+	if err != nil || cookie.Value == "" {
+		return New(), nil
+	}
+
+	raw, err := s.backend.Get(r.Context(), s.prefix+cookie.Value)
+	if errors.Is(err, cache.ErrNotFound) || (err == nil && raw == "") {
+		// Expired or unknown IDs get a fresh ID to prevent fixation
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return New(), nil
+	}
+	return &Session{ID: cookie.Value, Values: values}, nil
+}
+
+// Save implements Store
+func (s *RedisStore) Save(w http.ResponseWriter, r *http.Request, sess *Session, config Config) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	ctx := r.Context()
+	if sess.destroyed {
+		http.SetCookie(w, config.cookie("", -1))
+		if sess.IsNew {
+			return nil
+		}
+		return s.backend.Del(ctx, s.prefix+sess.ID)
+	}
+
+	if sess.previousID != "" {
+		if err := s.backend.Del(ctx, s.prefix+sess.previousID); err != nil {
+			return err
+		}
+		sess.previousID = ""
+	}
+
+	data, err := json.Marshal(sess.Values)
+	if err != nil {
+		return err
+	}
+	if err := s.backend.Set(ctx, s.prefix+sess.ID, string(data), config.MaxAge); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, config.cookie(sess.ID, int(config.MaxAge.Seconds())))
+	return nil
+}
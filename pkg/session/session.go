@@ -0,0 +1,270 @@
+// Package session attaches a server-side or cookie-backed session to each
+// request. Values are stored as JSON, so numbers read back as float64.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const flashKey = "_flash"
+
+// ErrNoSession is returned by FromContext when the middleware is not installed
+var ErrNoSession = errors.New("no session in context")
+
+type contextKey struct{}
+
+// Store loads and persists sessions
+type Store interface {
+	// Load returns the request's session, or a new one when there is none
+	// or it cannot be read
+	Load(r *http.Request, config Config) (*Session, error)
+
+	// Save persists the session and writes its cookie
+	Save(w http.ResponseWriter, r *http.Request, s *Session, config Config) error
+}
+
+// Config holds session cookie configuration
+type Config struct {
+	Name     string        // Cookie name (default "goframe_session")
+	MaxAge   time.Duration // Session lifetime (default 24h)
+	Path     string        // Cookie path (default "/")
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite // Default Lax
+}
+
+func (c *Config) setDefaults() {
+	if c.Name == "" {
+		c.Name = "goframe_session"
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = 24 * time.Hour
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+}
+
+// cookie builds the session cookie; a negative maxAge deletes it
+func (c Config) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   maxAge,
+		Secure:   c.Secure,
+		HttpOnly: true,
+		SameSite: c.SameSite,
+	}
+}
+
+// Session is the per-request session
+type Session struct {
+	ID     string
+	Values map[string]interface{}
+	IsNew  bool
+
+	mu         sync.Mutex
+	previousID string
+	modified   bool
+	destroyed  bool
+}
+
+// New creates an empty session with a random ID
+func New() *Session {
+	return &Session{ID: newID(), Values: make(map[string]interface{}), IsNew: true}
+}
+
+// Get returns a value
+func (s *Session) Get(key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Values[key]
+}
+
+// GetString returns a string value, or "" if missing or not a string
+func (s *Session) GetString(key string) string {
+	v, _ := s.Get(key).(string)
+	return v
+}
+
+// Set stores a value
+func (s *Session) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Values[key] = value
+	s.modified = true
+}
+
+// Delete removes a value
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Values, key)
+	s.modified = true
+}
+
+// Flash adds a message that is removed once read with Flashes
+func (s *Session) Flash(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flashes, _ := s.Values[flashKey].(map[string]interface{})
+	if flashes == nil {
+		flashes = make(map[string]interface{})
+	}
+	list, _ := flashes[key].([]interface{})
+	flashes[key] = append(list, value)
+	s.Values[flashKey] = flashes
+	s.modified = true
+}
+
+// Flashes returns and clears the flash messages for key
+func (s *Session) Flashes(key string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flashes, _ := s.Values[flashKey].(map[string]interface{})
+	list, _ := flashes[key].([]interface{})
+	if len(list) == 0 {
+		return nil
+	}
+
+	delete(flashes, key)
+	if len(flashes) == 0 {
+		delete(s.Values, flashKey)
+	}
+	s.modified = true
+	return list
+}
+
+// Regenerate assigns a new ID, keeping the values. Call it after login to
+// prevent session fixation.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.previousID == "" && !s.IsNew {
+		s.previousID = s.ID
+	}
+	s.ID = newID()
+	s.modified = true
+}
+
+// Destroy clears the session and deletes its cookie
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Values = make(map[string]interface{})
+	s.destroyed = true
+	s.modified = true
+}
+
+// Middleware loads the session before the handler runs and saves it before
+// the response is written, when it was modified
+func Middleware(store Store, config Config) func(http.Handler) http.Handler {
+	config.setDefaults()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := store.Load(r, config)
+			if err != nil {
+				logrus.Warnf("Failed to load session, starting a new one: %v", err)
+				s = New()
+			}
+
+			sw := &sessionWriter{ResponseWriter: w, save: func() {
+				if err := saveIfModified(store, w, r, s, config); err != nil {
+					logrus.Errorf("Failed to save session: %v", err)
+				}
+			}}
+
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKey{}, s)))
+			sw.saveOnce()
+		})
+	}
+}
+
+// FromContext returns the request session
+func FromContext(ctx context.Context) (*Session, error) {
+	s, ok := ctx.Value(contextKey{}).(*Session)
+	if !ok {
+		return nil, ErrNoSession
+	}
+	return s, nil
+}
+
+// Get returns the request session, or a detached empty session when the
+// middleware is not installed
+func Get(r *http.Request) *Session {
+	if s, err := FromContext(r.Context()); err == nil {
+		return s
+	}
+	return New()
+}
+
+func saveIfModified(store Store, w http.ResponseWriter, r *http.Request, s *Session, config Config) error {
+	s.mu.Lock()
+	modified := s.modified
+	s.mu.Unlock()
+
+	if !modified {
+		return nil
+	}
+	return store.Save(w, r, s, config)
+}
+
+// sessionWriter saves the session right before the response headers are
+// sent, since the cookie cannot be set afterwards
+type sessionWriter struct {
+	http.ResponseWriter
+	save  func()
+	saved bool
+}
+
+func (w *sessionWriter) saveOnce() {
+	if !w.saved {
+		w.saved = true
+		w.save()
+	}
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	w.saveOnce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.saveOnce()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (w *sessionWriter) Flush() {
+	w.saveOnce()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap supports http.ResponseController
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func newID() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/cache"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logrus.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// memoryBackend is an in-process Backend behaving like cache.Manager
+type memoryBackend struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{data: make(map[string]string)}
+}
+
+func (b *memoryBackend) Get(ctx context.Context, key string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.data[key]
+	if !ok {
+		return "", cache.ErrNotFound
+	}
+	return v, nil
+}
+
+func (b *memoryBackend) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data[key] = value
+	return nil
+}
+
+func (b *memoryBackend) Del(ctx context.Context, keys ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, k := range keys {
+		delete(b.data, k)
+	}
+	return nil
+}
+
+func (b *memoryBackend) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.data)
+}
+
+// testApp exercises a session through login, flash, read and logout
+func testApp(t *testing.T, store Store) http.Handler {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		s := Get(r)
+		s.Regenerate()
+		s.Set("user", "alice")
+		s.Flash("notice", "Welcome back")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		s := Get(r)
+		var notices []string
+		for _, f := range s.Flashes("notice") {
+			notices = append(notices, f.(string))
+		}
+		_, _ = io.WriteString(w, s.GetString("user")+"|"+strings.Join(notices, ","))
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		Get(r).Destroy()
+	})
+
+	return Middleware(store, Config{})(mux)
+}
+
+// client replays cookies between requests like a browser
+type client struct {
+	handler http.Handler
+	cookies map[string]*http.Cookie
+}
+
+func (c *client) get(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range c.cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, req)
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(c.cookies, cookie.Name)
+		} else {
+			c.cookies[cookie.Name] = cookie
+		}
+	}
+	return w
+}
+
+func runLifecycle(t *testing.T, store Store) {
+	c := &client{handler: testApp(t, store), cookies: make(map[string]*http.Cookie)}
+
+	if body := c.get("/me").Body.String(); body != "|" {
+		t.Fatalf("expected empty session, got %q", body)
+	}
+	if len(c.cookies) != 0 {
+		t.Fatalf("expected no cookie for an unmodified session, got %v", c.cookies)
+	}
+
+	c.get("/login")
+	cookie, ok := c.cookies["goframe_session"]
+	if !ok {
+		t.Fatal("expected session cookie after login")
+	}
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("expected HttpOnly Lax cookie, got %+v", cookie)
+	}
+
+	if body := c.get("/me").Body.String(); body != "alice|Welcome back" {
+		t.Errorf("expected user and flash, got %q", body)
+	}
+	if body := c.get("/me").Body.String(); body != "alice|" {
+		t.Errorf("expected flash to be consumed, got %q", body)
+	}
+
+	c.get("/logout")
+	if _, ok := c.cookies["goframe_session"]; ok {
+		t.Error("expected cookie to be deleted on logout")
+	}
+	if body := c.get("/me").Body.String(); body != "|" {
+		t.Errorf("expected empty session after logout, got %q", body)
+	}
+}
+
+func TestCookieStore(t *testing.T) {
+	store, err := NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runLifecycle(t, store)
+
+	t.Run("tampered cookie starts a new session", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "goframe_session", Value: "tampered"})
+		s, err := store.Load(req, Config{Name: "goframe_session"})
+		if err != nil || !s.IsNew {
+			t.Errorf("expected new session, got %+v, %v", s, err)
+		}
+	})
+
+	t.Run("cookie is bound to its name", func(t *testing.T) {
+		issued := Config{Name: "remember_me"}
+		issued.setDefaults()
+		s := New()
+		s.Set("user", "alice")
+		w := httptest.NewRecorder()
+		if err := store.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s, issued); err != nil {
+			t.Fatal(err)
+		}
+		value := w.Result().Cookies()[0].Value
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "goframe_session", Value: value})
+		loaded, err := store.Load(req, Config{Name: "goframe_session"})
+		if err != nil || !loaded.IsNew {
+			t.Errorf("expected a cookie replayed under another name to be rejected, got %+v, %v", loaded, err)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		if _, err := NewCookieStore([]byte("short")); err == nil {
+			t.Error("expected error for invalid key size")
+		}
+	})
+
+	t.Run("oversized session", func(t *testing.T) {
+		config := Config{}
+		config.setDefaults()
+		s := New()
+		s.Set("blob", strings.Repeat("x", 5000))
+		err := store.Save(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), s, config)
+		if err != ErrCookieTooLarge {
+			t.Errorf("expected ErrCookieTooLarge, got %v", err)
+		}
+	})
+}
+
+func TestRedisStore(t *testing.T) {
+	backend := newMemoryBackend()
+	runLifecycle(t, NewRedisStore(backend, ""))
+
+	if n := backend.len(); n != 0 {
+		t.Errorf("expected sessions to be deleted after logout, %d left", n)
+	}
+
+	t.Run("regenerate removes the old session", func(t *testing.T) {
+		backend := newMemoryBackend()
+		c := &client{handler: testApp(t, NewRedisStore(backend, "")), cookies: make(map[string]*http.Cookie)}
+
+		c.get("/login")
+		first := c.cookies["goframe_session"].Value
+		c.get("/login")
+		second := c.cookies["goframe_session"].Value
+
+		if first == second {
+			t.Error("expected a new session ID after regenerate")
+		}
+		if _, err := backend.Get(context.Background(), "session:"+first); err != cache.ErrNotFound {
+			t.Errorf("expected old session to be deleted, got %v", err)
+		}
+		if backend.len() != 1 {
+			t.Errorf("expected one stored session, got %d", backend.len())
+		}
+	})
+
+	t.Run("unknown ID starts a new session", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "goframe_session", Value: "attacker-chosen"})
+		s, err := NewRedisStore(newMemoryBackend(), "").Load(req, Config{Name: "goframe_session"})
+		if err != nil || !s.IsNew || s.ID == "attacker-chosen" {
+			t.Errorf("expected new session with fresh ID, got %+v, %v", s, err)
+		}
+	})
+}
+
+func TestFromContext_NoMiddleware(t *testing.T) {
+	if _, err := FromContext(context.Background()); err != ErrNoSession {
+		t.Errorf("expected ErrNoSession, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package sharding
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultReplicas is the number of points per node on a Ring
+const DefaultReplicas = 160
+
+// Ring is a consistent hash ring with virtual nodes. Adding or removing a
+// node only moves the keys of that node. It is safe for concurrent use.
+type Ring struct {
+	replicas int
+
+	mu     sync.RWMutex
+	points []uint64 // Sorted
+	owners map[uint64]string
+	nodes  map[string]int // Node to weight
+}
+
+// NewRing creates a ring with replicas points per node (DefaultReplicas
+// when 0 or less)
+func NewRing(replicas int, nodes ...string) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &Ring{
+		replicas: replicas,
+		owners:   make(map[uint64]string),
+		nodes:    make(map[string]int),
+	}
+	r.Add(nodes...)
+	return r
+}
+
+// Add adds nodes with weight 1
+func (r *Ring) Add(nodes ...string) {
+	for _, node := range nodes {
+		r.AddWeighted(node, 1)
+	}
+}
+
+// AddWeighted adds a node receiving weight times the share of keys of a
+// weight 1 node; adding an existing node changes its weight
+func (r *Ring) AddWeighted(node string, weight int) {
+	if weight <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nodes[node] = weight
+	r.rebuild()
+}
+
+// Remove removes nodes from the ring
+func (r *Ring) Remove(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, node := range nodes {
+		delete(r.nodes, node)
+	}
+	r.rebuild()
+}
+
+// rebuild places every node again; points only depend on the node name, so
+// untouched nodes keep their keys
+func (r *Ring) rebuild() {
+	r.points = r.points[:0]
+	r.owners = make(map[uint64]string)
+	for node, weight := range r.nodes {
+		for i := 0; i < r.replicas*weight; i++ {
+			point := Hash(node + "#" + strconv.Itoa(i))
+			if owner, taken := r.owners[point]; taken && owner < node {
+				continue // Deterministic winner on the rare collision
+			}
+			if _, taken := r.owners[point]; !taken {
+				r.points = append(r.points, point)
+			}
+			r.owners[point] = node
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+// Get returns the node owning key, or "" on an empty ring
+func (r *Ring) Get(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return ""
+	}
+	return r.owners[r.points[r.search(Hash(key))]]
+}
+
+// GetN returns up to n distinct nodes for key, the owner first, e.g. to
+// place replicas
+func (r *Ring) GetN(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n > len(r.nodes) {
+		n = len(r.nodes)
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for i, start := 0, r.search(Hash(key)); len(nodes) < n && i < len(r.points); i++ {
+		node := r.owners[r.points[(start+i)%len(r.points)]]
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Nodes returns the nodes of the ring, sorted
+func (r *Ring) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// search returns the index of the first point at or after hash, wrapping
+func (r *Ring) search(hash uint64) int {
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		return 0
+	}
+	return i
+}
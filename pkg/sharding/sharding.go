@@ -0,0 +1,102 @@
+// Package sharding partitions keys across shards: jump hash for a fixed
+// number of numbered shards (orders_0..orders_7), and a consistent hash Ring
+// for named nodes that join and leave.
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/polymatx/goframe/pkg/database"
+)
+
+// Hash returns a well-mixed 64-bit hash of key: FNV-1a followed by the
+// SplitMix64 finalizer, so similar keys land far apart
+func Hash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Jump maps key to a bucket in [0, buckets) with Lamping and Veach's jump
+// consistent hash: growing from n to n+1 buckets moves only 1/(n+1) of the
+// keys, all of them to the new bucket
+func Jump(key uint64, buckets int) int {
+	if buckets <= 0 {
+		return -1
+	}
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// JumpString maps a string key to a bucket in [0, buckets)
+func JumpString(key string, buckets int) int {
+	return Jump(Hash(key), buckets)
+}
+
+// Shards routes keys to Count shards named Prefix_0 to Prefix_<Count-1>, e.g.
+// database connections, cache key prefixes or queue routing keys
+type Shards struct {
+	Prefix string
+	Count  int
+}
+
+// New creates a Shards router
+func New(prefix string, count int) Shards {
+	return Shards{Prefix: prefix, Count: count}
+}
+
+// Index returns the shard number of key
+func (s Shards) Index(key string) int {
+	return JumpString(key, s.Count)
+}
+
+// Name returns the shard name of key, e.g. orders_3
+func (s Shards) Name(key string) string {
+	return s.NameOf(s.Index(key))
+}
+
+// NameOf returns the name of shard i
+func (s Shards) NameOf(i int) string {
+	return fmt.Sprintf("%s_%d", s.Prefix, i)
+}
+
+// Names returns every shard name in order
+func (s Shards) Names() []string {
+	names := make([]string, s.Count)
+	for i := range names {
+		names[i] = s.NameOf(i)
+	}
+	return names
+}
+
+// DB returns the database connection registered under the shard name of key
+func (s Shards) DB(key string) (*database.Connection, error) {
+	return database.Get(s.Name(key))
+}
+
+// EachDB calls fn with every shard connection in order, e.g. to migrate them,
+// stopping at the first error
+func (s Shards) EachDB(fn func(name string, conn *database.Connection) error) error {
+	for _, name := range s.Names() {
+		conn, err := database.Get(name)
+		if err != nil {
+			return err
+		}
+		if err := fn(name, conn); err != nil {
+			return fmt.Errorf("shard %s: %w", name, err)
+		}
+	}
+	return nil
+}
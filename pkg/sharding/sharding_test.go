@@ -0,0 +1,183 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/database"
+)
+
+func TestJump(t *testing.T) {
+	for _, buckets := range []int{1, 2, 7, 8, 100} {
+		for key := uint64(0); key < 1000; key++ {
+			if b := Jump(key, buckets); b < 0 || b >= buckets {
+				t.Fatalf("Jump(%d, %d) = %d, out of range", key, buckets, b)
+			}
+		}
+	}
+	if Jump(1, 0) != -1 {
+		t.Error("expected -1 without buckets")
+	}
+}
+
+func TestJump_Growth(t *testing.T) {
+	// Growing the bucket count only moves keys to the new bucket
+	for n := 1; n < 16; n++ {
+		for i := 0; i < 2000; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			before, after := JumpString(key, n), JumpString(key, n+1)
+			if before != after && after != n {
+				t.Fatalf("%s moved from %d to %d when growing to %d buckets", key, before, after, n+1)
+			}
+		}
+	}
+}
+
+func TestJump_Balance(t *testing.T) {
+	const buckets, keys = 8, 80000
+	counts := make([]int, buckets)
+	for i := 0; i < keys; i++ {
+		counts[JumpString(fmt.Sprintf("user:%d", i), buckets)]++
+	}
+	for b, count := range counts {
+		if count < keys/buckets*9/10 || count > keys/buckets*11/10 {
+			t.Errorf("bucket %d has %d keys, expected about %d", b, count, keys/buckets)
+		}
+	}
+}
+
+func TestShards(t *testing.T) {
+	s := New("orders", 8)
+
+	if got := s.NameOf(3); got != "orders_3" {
+		t.Errorf("expected orders_3, got %s", got)
+	}
+	names := s.Names()
+	if len(names) != 8 || names[0] != "orders_0" || names[7] != "orders_7" {
+		t.Errorf("unexpected names %v", names)
+	}
+	if s.Name("customer-42") != s.NameOf(s.Index("customer-42")) {
+		t.Error("Name and Index disagree")
+	}
+	if s.Name("customer-42") != s.Name("customer-42") {
+		t.Error("expected a stable shard")
+	}
+}
+
+func TestShards_DB(t *testing.T) {
+	s := New("orders", 3)
+	dir := t.TempDir()
+	for _, name := range s.Names() {
+		if err := database.Register(database.Lite(name, filepath.Join(dir, name+".db"))); err != nil {
+			t.Fatalf("register %s: %v", name, err)
+		}
+	}
+	if err := database.Initialize(context.Background()); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	var migrated []string
+	err := s.EachDB(func(name string, conn *database.Connection) error {
+		migrated = append(migrated, name)
+		return conn.DB().Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT)").Error
+	})
+	if err != nil || len(migrated) != 3 {
+		t.Fatalf("EachDB: %v, %v", migrated, err)
+	}
+
+	conn, err := s.DB("customer-42")
+	if err != nil {
+		t.Fatalf("DB: %v", err)
+	}
+	if err := conn.DB().Exec("INSERT INTO orders (customer) VALUES (?)", "customer-42").Error; err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	for i, name := range s.Names() {
+		var count int64
+		database.MustGet(name).DB().Raw("SELECT COUNT(*) FROM orders").Scan(&count)
+		want := int64(0)
+		if i == s.Index("customer-42") {
+			want = 1
+		}
+		if count != want {
+			t.Errorf("%s has %d rows, expected %d", name, count, want)
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	empty := NewRing(0)
+	if empty.Get("key") != "" || empty.GetN("key", 2) != nil {
+		t.Error("expected no node on an empty ring")
+	}
+
+	r := NewRing(0, "cache-a", "cache-b", "cache-c")
+	counts := make(map[string]int)
+	owners := make(map[string]string)
+	for i := 0; i < 30000; i++ {
+		key := fmt.Sprintf("session:%d", i)
+		owners[key] = r.Get(key)
+		counts[owners[key]]++
+	}
+	for node, count := range counts {
+		if count < 7000 || count > 13000 {
+			t.Errorf("%s owns %d of 30000 keys", node, count)
+		}
+	}
+
+	// Adding a node only takes keys from the others
+	r.Add("cache-d")
+	moved := 0
+	for key, owner := range owners {
+		if now := r.Get(key); now != owner {
+			if now != "cache-d" {
+				t.Fatalf("%s moved from %s to %s", key, owner, now)
+			}
+			moved++
+		}
+	}
+	if moved < 5000 || moved > 10000 {
+		t.Errorf("expected about a quarter of the keys to move, got %d", moved)
+	}
+
+	// Removing it gives them back
+	r.Remove("cache-d")
+	for key, owner := range owners {
+		if now := r.Get(key); now != owner {
+			t.Fatalf("%s owned by %s after removing cache-d, expected %s", key, now, owner)
+		}
+	}
+}
+
+func TestRing_GetN(t *testing.T) {
+	r := NewRing(50, "a", "b", "c")
+
+	nodes := r.GetN("order:1", 2)
+	if len(nodes) != 2 || nodes[0] != r.Get("order:1") || nodes[0] == nodes[1] {
+		t.Errorf("unexpected replicas %v", nodes)
+	}
+	if got := r.GetN("order:1", 5); len(got) != 3 {
+		t.Errorf("expected every node, got %v", got)
+	}
+	if got := r.Nodes(); len(got) != 3 || got[0] != "a" {
+		t.Errorf("unexpected nodes %v", got)
+	}
+}
+
+func TestRing_Weight(t *testing.T) {
+	r := NewRing(0)
+	r.AddWeighted("small", 1)
+	r.AddWeighted("large", 3)
+
+	counts := make(map[string]int)
+	for i := 0; i < 40000; i++ {
+		counts[r.Get(fmt.Sprintf("k%d", i))]++
+	}
+	if ratio := float64(counts["large"]) / float64(counts["small"]); ratio < 2.3 || ratio > 3.9 {
+		t.Errorf("expected large to own about 3 times more keys, got %v", counts)
+	}
+}
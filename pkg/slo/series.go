@@ -0,0 +1,142 @@
+package slo
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// budgetSlots is the resolution of the error budget window
+const budgetSlots = 720
+
+// series counts the requests of an objective in two rings of time slots:
+// minutes for the burn rate windows and coarser slots covering the error
+// budget window
+type series struct {
+	objective Objective
+
+	mu     sync.Mutex
+	recent ring
+	budget ring
+	total  int64
+	badAvl int64
+	badLat int64
+}
+
+func newSeries(o Objective, longest time.Duration) *series {
+	return &series{
+		objective: o,
+		recent:    newRing(time.Minute, longest),
+		budget:    newRing(max(time.Minute, o.Window/budgetSlots), o.Window),
+	}
+}
+
+func (s *series) add(now time.Time, failed bool, d time.Duration) {
+	slow := s.objective.Latency > 0 && d > s.objective.Latency
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recent.add(now, failed, slow)
+	s.budget.add(now, failed, slow)
+	s.total++
+	if failed {
+		s.badAvl++
+	}
+	if slow {
+		s.badLat++
+	}
+}
+
+// events returns the good and bad requests of sli since the start
+func (s *series) events(sli string) (int64, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bad := s.badAvl
+	if sli == Latency {
+		bad = s.badLat
+	}
+	return s.total - bad, bad
+}
+
+// burnRate returns the error rate of sli over the last d relative to the
+// rate its objective allows; 1 spends the budget exactly over the window
+func (s *series) burnRate(now time.Time, sli string, d time.Duration) float64 {
+	s.mu.Lock()
+	total, bad := s.recent.sum(now, d, sli)
+	s.mu.Unlock()
+	if total == 0 {
+		return 0
+	}
+	return float64(bad) / float64(total) / errorBudget(s.objective.target(sli))
+}
+
+// budgetRemaining returns the fraction of the error budget of sli left in
+// the objective window, negative once overspent
+func (s *series) budgetRemaining(now time.Time, sli string) float64 {
+	s.mu.Lock()
+	total, bad := s.budget.sum(now, s.objective.Window, sli)
+	s.mu.Unlock()
+	if total == 0 {
+		return 1
+	}
+	return 1 - float64(bad)/float64(total)/errorBudget(s.objective.target(sli))
+}
+
+// errorBudget returns the fraction of requests target allows to be bad.
+// Targets are decimal fractions such as 0.999, so the float error of the
+// subtraction is rounded off.
+func errorBudget(target float64) float64 {
+	return math.Round((1-target)*1e12) / 1e12
+}
+
+type slot struct {
+	epoch                 int64 // Index of the slot since the Unix epoch
+	total, badAvl, badLat int64
+}
+
+// ring holds counts of consecutive time slots, reusing the slots that left
+// the window
+type ring struct {
+	width time.Duration
+	slots []slot
+}
+
+func newRing(width, span time.Duration) ring {
+	n := int((span + width - 1) / width)
+	return ring{width: width, slots: make([]slot, max(n, 1))}
+}
+
+func (r *ring) add(now time.Time, failed, slow bool) {
+	epoch := now.UnixNano() / int64(r.width)
+	sl := &r.slots[epoch%int64(len(r.slots))]
+	if sl.epoch != epoch {
+		*sl = slot{epoch: epoch}
+	}
+	sl.total++
+	if failed {
+		sl.badAvl++
+	}
+	if slow {
+		sl.badLat++
+	}
+}
+
+// sum returns the total and bad requests of sli in the slots overlapping
+// the last d
+func (r *ring) sum(now time.Time, d time.Duration, sli string) (int64, int64) {
+	epoch := now.UnixNano() / int64(r.width)
+	n := min(int64((d+r.width-1)/r.width), int64(len(r.slots)))
+	var total, bad int64
+	for e := epoch - n + 1; e <= epoch; e++ {
+		sl := r.slots[e%int64(len(r.slots))]
+		if sl.epoch != e {
+			continue
+		}
+		total += sl.total
+		if sli == Latency {
+			bad += sl.badLat
+		} else {
+			bad += sl.badAvl
+		}
+	}
+	return total, bad
+}
@@ -0,0 +1,463 @@
+// Package slo tracks service level objectives of HTTP routes. Routes declare
+// an availability and a latency objective; the tracker counts good and bad
+// requests against them, exports the remaining error budgets and burn rates
+// as Prometheus metrics, and notifies when a budget burns too fast:
+//
+//	tracker, err := slo.New(slo.Config{Notifier: notifier})
+//	a.Use(tracker.Middleware())
+//	api.With(slo.Declare(slo.Objective{Availability: 0.999, Latency: 300 * time.Millisecond})).
+//		GET("/orders/{id}", getOrder)
+package slo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// SLIs, the kinds of objective
+const (
+	Availability = "availability" // Requests answered without a 5xx status
+	Latency      = "latency"      // Requests answered within the latency objective
+)
+
+// metaKey is the route metadata key Declare stores objectives under
+const metaKey = "slo"
+
+// Objective is the service level objective of a route
+type Objective struct {
+	// Name identifies the objective in metrics and alerts (default the
+	// method and route template, e.g. "GET /orders/{id}")
+	Name string
+
+	// Availability is the target fraction of requests answered without a
+	// 5xx status, e.g. 0.999. Zero sets no availability objective.
+	Availability float64
+
+	// Latency, when set, is the duration requests must be answered within,
+	// and LatencyTarget the fraction that must be (default 0.99)
+	Latency       time.Duration
+	LatencyTarget float64
+
+	// Window is the period the error budget covers (default 30 days)
+	Window time.Duration
+}
+
+func (o Objective) validate() error {
+	if o.Availability == 0 && o.Latency == 0 {
+		return errors.New("slo: objective sets neither Availability nor Latency")
+	}
+	if o.Availability < 0 || o.Availability >= 1 {
+		return fmt.Errorf("slo: Availability %v is not in [0, 1)", o.Availability)
+	}
+	if o.Latency < 0 || o.LatencyTarget < 0 || o.LatencyTarget >= 1 {
+		return fmt.Errorf("slo: invalid latency objective %v at %v", o.Latency, o.LatencyTarget)
+	}
+	return nil
+}
+
+func (o Objective) withDefaults(name string) Objective {
+	if o.Name == "" {
+		o.Name = name
+	}
+	if o.Latency > 0 && o.LatencyTarget == 0 {
+		o.LatencyTarget = 0.99
+	}
+	if o.Window <= 0 {
+		o.Window = 30 * 24 * time.Hour
+	}
+	return o
+}
+
+// target returns the target of sli, or 0 if o sets no objective for it
+func (o Objective) target(sli string) float64 {
+	if sli == Availability {
+		return o.Availability
+	}
+	if o.Latency > 0 {
+		return o.LatencyTarget
+	}
+	return 0
+}
+
+// Declare returns a route option setting the objective of the route, for
+// app.RouteGroup.With and app.Route.With. It panics on an invalid
+// objective.
+func Declare(o Objective) func(meta *middleware.RouteMeta) {
+	if err := o.validate(); err != nil {
+		panic(err)
+	}
+	return func(meta *middleware.RouteMeta) {
+		if meta.Extra == nil {
+			meta.Extra = make(map[string]interface{})
+		}
+		meta.Extra[metaKey] = o
+	}
+}
+
+// BurnRateAlert fires when the error budget burns faster than Rate times
+// the sustainable rate over both Long and the more recent Short windows.
+// The short window makes the alert resolve soon after the burn stops.
+type BurnRateAlert struct {
+	Long     time.Duration
+	Short    time.Duration
+	Rate     float64
+	Severity string // e.g. "page" or "ticket"
+}
+
+// DefaultAlerts page when 2% of a 30 day budget is spent in an hour or 5%
+// in six hours
+var DefaultAlerts = []BurnRateAlert{
+	{Long: time.Hour, Short: 5 * time.Minute, Rate: 14.4, Severity: "page"},
+	{Long: 6 * time.Hour, Short: 30 * time.Minute, Rate: 6, Severity: "page"},
+}
+
+// Alert is a burn rate alert of an objective
+type Alert struct {
+	Objective       string        `json:"objective"`
+	SLI             string        `json:"sli"`
+	Severity        string        `json:"severity"`
+	Rate            float64       `json:"rate"`      // Alert threshold
+	BurnRate        float64       `json:"burn_rate"` // Over the long window
+	Long            time.Duration `json:"long"`
+	Short           time.Duration `json:"short"`
+	BudgetRemaining float64       `json:"budget_remaining"` // Fraction of the budget left in the window
+	Resolved        bool          `json:"resolved"`         // The burn rate fell back under the threshold
+	Time            time.Time     `json:"time"`
+}
+
+// Notifier delivers alerts, e.g. to a chat channel or an incident tool
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NotifierFunc adapts a function to Notifier
+type NotifierFunc func(ctx context.Context, alert Alert) error
+
+// Notify calls f
+func (f NotifierFunc) Notify(ctx context.Context, alert Alert) error {
+	return f(ctx, alert)
+}
+
+// Config holds SLO tracking configuration
+type Config struct {
+	// Objectives of routes, keyed by method and route template, e.g.
+	// "GET /orders/{id}", or by template alone for all methods. Objectives
+	// declared on routes take precedence.
+	Objectives map[string]Objective
+
+	Alerts   []BurnRateAlert // Default DefaultAlerts
+	Notifier Notifier        // Receives alerts; nil only exports metrics
+
+	// EvaluateInterval is how often burn rates are checked for alerts
+	// (default 1m)
+	EvaluateInterval time.Duration
+
+	// Registry the metrics are registered in (default the global
+	// Prometheus registry)
+	Registry *prometheus.Registry
+}
+
+// Tracker tracks the objectives of routes
+type Tracker struct {
+	config Config
+	now    func() time.Time
+
+	mu     sync.RWMutex
+	series map[string]*series // By objective name
+
+	firingMu sync.Mutex
+	firing   map[alertKey]bool
+
+	descs struct {
+		target, budget, burnRate, events *prometheus.Desc
+	}
+	windows []time.Duration // Burn rate windows exported
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+type alertKey struct {
+	objective, sli string
+	alert          int
+}
+
+// New returns a tracker exporting its metrics and, with a Notifier,
+// checking burn rates every EvaluateInterval until Close
+func New(config Config) (*Tracker, error) {
+	if len(config.Alerts) == 0 {
+		config.Alerts = DefaultAlerts
+	}
+	if config.EvaluateInterval <= 0 {
+		config.EvaluateInterval = time.Minute
+	}
+	for key, o := range config.Objectives {
+		if err := o.validate(); err != nil {
+			return nil, fmt.Errorf("%w (%s)", err, key)
+		}
+	}
+
+	t := &Tracker{
+		config: config,
+		now:    time.Now,
+		series: make(map[string]*series),
+		firing: make(map[alertKey]bool),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	labels := []string{"slo", "sli"}
+	t.descs.target = prometheus.NewDesc("slo_objective_ratio", "Target fraction of good requests", labels, nil)
+	t.descs.budget = prometheus.NewDesc("slo_error_budget_remaining_ratio",
+		"Fraction of the error budget left in the objective window", labels, nil)
+	t.descs.burnRate = prometheus.NewDesc("slo_burn_rate",
+		"Error budget burn rate over the window, 1 spends the budget exactly in the objective window",
+		append(labels, "window"), nil)
+	t.descs.events = prometheus.NewDesc("slo_requests_total", "Requests counted against the objective",
+		append(labels, "result"), nil)
+	seen := make(map[time.Duration]bool)
+	for _, a := range config.Alerts {
+		for _, d := range []time.Duration{a.Short, a.Long} {
+			if !seen[d] {
+				seen[d] = true
+				t.windows = append(t.windows, d)
+			}
+		}
+	}
+	sort.Slice(t.windows, func(i, j int) bool { return t.windows[i] < t.windows[j] })
+
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	if config.Registry != nil {
+		registerer = config.Registry
+	}
+	if err := registerer.Register(t); err != nil {
+		return nil, fmt.Errorf("slo: failed to register metrics: %w", err)
+	}
+
+	if config.Notifier != nil {
+		go t.loop()
+	} else {
+		close(t.done)
+	}
+	return t, nil
+}
+
+// Close stops checking burn rates
+func (t *Tracker) Close() {
+	t.once.Do(func() { close(t.stop) })
+	<-t.done
+}
+
+// Middleware counts the requests of routes with an objective. It runs
+// before routing, e.g. with App.Use, and reads objectives declared on the
+// matched route.
+func (t *Tracker) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			r, routed := middleware.TrackRouting(r)
+
+			next.ServeHTTP(rw, r)
+
+			if s := t.seriesFor(routed()); s != nil {
+				s.add(t.now(), rw.status >= 500, time.Since(start))
+			}
+		})
+	}
+}
+
+// Record counts a request against the objective named name, e.g. for work
+// not served over HTTP. Requests of unknown objectives are ignored.
+func (t *Tracker) Record(name string, failed bool, duration time.Duration) {
+	t.mu.RLock()
+	s := t.series[name]
+	t.mu.RUnlock()
+	if s == nil {
+		if o, ok := t.config.Objectives[name]; ok {
+			s = t.getSeries(o.withDefaults(name))
+		}
+	}
+	if s != nil {
+		s.add(t.now(), failed, duration)
+	}
+}
+
+// seriesFor returns the series of the objective of the routed request, or
+// nil for routes without one
+func (t *Tracker) seriesFor(r *http.Request) *series {
+	route := middleware.RouteTemplate(r)
+	if route == "" {
+		return nil
+	}
+	name := r.Method + " " + route
+	if o, ok := middleware.GetRouteMeta(r.Context()).Extra[metaKey].(Objective); ok {
+		return t.getSeries(o.withDefaults(name))
+	}
+	if o, ok := t.config.Objectives[name]; ok {
+		return t.getSeries(o.withDefaults(name))
+	}
+	if o, ok := t.config.Objectives[route]; ok {
+		return t.getSeries(o.withDefaults(route))
+	}
+	return nil
+}
+
+func (t *Tracker) getSeries(o Objective) *series {
+	t.mu.RLock()
+	s := t.series[o.Name]
+	t.mu.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s := t.series[o.Name]; s != nil {
+		return s
+	}
+	longest := time.Minute
+	for _, a := range t.config.Alerts {
+		longest = max(longest, a.Long, a.Short)
+	}
+	s = newSeries(o, longest)
+	t.series[o.Name] = s
+	return s
+}
+
+func (t *Tracker) loop() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.config.EvaluateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.evaluate(context.Background())
+		}
+	}
+}
+
+// evaluate notifies of the alerts that started or stopped firing
+func (t *Tracker) evaluate(ctx context.Context) {
+	now := t.now()
+	t.mu.RLock()
+	all := make([]*series, 0, len(t.series))
+	for _, s := range t.series {
+		all = append(all, s)
+	}
+	t.mu.RUnlock()
+
+	for _, s := range all {
+		for _, sli := range []string{Availability, Latency} {
+			if s.objective.target(sli) == 0 {
+				continue
+			}
+			for i, a := range t.config.Alerts {
+				burn := s.burnRate(now, sli, a.Long)
+				firing := burn >= a.Rate && s.burnRate(now, sli, a.Short) >= a.Rate
+
+				key := alertKey{s.objective.Name, sli, i}
+				t.firingMu.Lock()
+				changed := t.firing[key] != firing
+				t.firing[key] = firing
+				t.firingMu.Unlock()
+				if !changed {
+					continue
+				}
+
+				alert := Alert{
+					Objective:       s.objective.Name,
+					SLI:             sli,
+					Severity:        a.Severity,
+					Rate:            a.Rate,
+					BurnRate:        burn,
+					Long:            a.Long,
+					Short:           a.Short,
+					BudgetRemaining: s.budgetRemaining(now, sli),
+					Resolved:        !firing,
+					Time:            now,
+				}
+				if err := t.config.Notifier.Notify(ctx, alert); err != nil {
+					logrus.WithFields(logrus.Fields{
+						"slo": alert.Objective, "sli": sli, "severity": a.Severity,
+					}).WithError(err).Error("Failed to notify SLO burn rate alert")
+				}
+			}
+		}
+	}
+}
+
+// Describe implements prometheus.Collector
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.descs.target
+	ch <- t.descs.budget
+	ch <- t.descs.burnRate
+	ch <- t.descs.events
+}
+
+// Collect implements prometheus.Collector
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	now := t.now()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, s := range t.series {
+		for _, sli := range []string{Availability, Latency} {
+			target := s.objective.target(sli)
+			if target == 0 {
+				continue
+			}
+			name := s.objective.Name
+			ch <- prometheus.MustNewConstMetric(t.descs.target, prometheus.GaugeValue, target, name, sli)
+			ch <- prometheus.MustNewConstMetric(t.descs.budget, prometheus.GaugeValue, s.budgetRemaining(now, sli), name, sli)
+			for _, d := range t.windows {
+				ch <- prometheus.MustNewConstMetric(t.descs.burnRate, prometheus.GaugeValue,
+					s.burnRate(now, sli, d), name, sli, d.String())
+			}
+			good, bad := s.events(sli)
+			ch <- prometheus.MustNewConstMetric(t.descs.events, prometheus.CounterValue, float64(good), name, sli, "good")
+			ch <- prometheus.MustNewConstMetric(t.descs.events, prometheus.CounterValue, float64(bad), name, sli, "bad")
+		}
+	}
+}
+
+// statusWriter captures the response status
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap supports http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
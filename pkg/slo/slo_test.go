@@ -0,0 +1,179 @@
+package slo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// route registers h on router with meta as the app router does
+func route(router *mux.Router, path string, meta middleware.RouteMeta, h http.HandlerFunc) {
+	router.Handle(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(middleware.WithRouteMeta(r.Context(), &meta))
+		middleware.RecordRequest(r)
+		h(w, r)
+	}))
+}
+
+func TestTracker(t *testing.T) {
+	var mu sync.Mutex
+	var alerts []Alert
+	registry := prometheus.NewRegistry()
+	tracker, err := New(Config{
+		Objectives: map[string]Objective{"/health": {Availability: 0.9}},
+		Alerts:     []BurnRateAlert{{Long: time.Hour, Short: 5 * time.Minute, Rate: 5, Severity: "page"}},
+		Notifier: NotifierFunc(func(ctx context.Context, a Alert) error {
+			mu.Lock()
+			defer mu.Unlock()
+			alerts = append(alerts, a)
+			return nil
+		}),
+		EvaluateInterval: time.Hour,
+		Registry:         registry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.Close()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	var meta middleware.RouteMeta
+	Declare(Objective{Availability: 0.99})(&meta)
+	router := mux.NewRouter()
+	route(router, "/orders/{id}", meta, func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	route(router, "/health", middleware.RouteMeta{}, func(w http.ResponseWriter, r *http.Request) {})
+	route(router, "/untracked", middleware.RouteMeta{}, func(w http.ResponseWriter, r *http.Request) {})
+	handler := tracker.Middleware()(router)
+	serve := func(path string, n int) {
+		for i := 0; i < n; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+	}
+
+	// 10% errors burn a 99% objective 10 times faster than sustainable
+	serve("/orders/1", 90)
+	serve("/orders/fail", 10)
+	serve("/health", 10)
+	serve("/untracked", 10)
+
+	const name = "GET /orders/{id}"
+	expected := `
+		# HELP slo_requests_total Requests counted against the objective
+		# TYPE slo_requests_total counter
+		slo_requests_total{result="bad",sli="availability",slo="/health"} 0
+		slo_requests_total{result="good",sli="availability",slo="/health"} 10
+		slo_requests_total{result="bad",sli="availability",slo="GET /orders/{id}"} 10
+		slo_requests_total{result="good",sli="availability",slo="GET /orders/{id}"} 90
+	`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "slo_requests_total"); err != nil {
+		t.Error(err)
+	}
+	expected = `
+		# HELP slo_burn_rate Error budget burn rate over the window, 1 spends the budget exactly in the objective window
+		# TYPE slo_burn_rate gauge
+		slo_burn_rate{sli="availability",slo="/health",window="1h0m0s"} 0
+		slo_burn_rate{sli="availability",slo="/health",window="5m0s"} 0
+		slo_burn_rate{sli="availability",slo="GET /orders/{id}",window="1h0m0s"} 10
+		slo_burn_rate{sli="availability",slo="GET /orders/{id}",window="5m0s"} 10
+	`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "slo_burn_rate"); err != nil {
+		t.Error(err)
+	}
+
+	tracker.evaluate(context.Background())
+	tracker.evaluate(context.Background())
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %+v", alerts)
+	}
+	if a := alerts[0]; a.Objective != name || a.SLI != Availability || a.Resolved || a.BurnRate != 10 || a.Severity != "page" {
+		t.Errorf("unexpected alert %+v", a)
+	}
+	// 10 of the 1% of 100 requests allowed are spent
+	if a := alerts[0]; a.BudgetRemaining > -8.99 || a.BudgetRemaining < -9.01 {
+		t.Errorf("BudgetRemaining = %v, want -9", a.BudgetRemaining)
+	}
+
+	// Once errors stop, the short window clears first and resolves the alert
+	now = now.Add(10 * time.Minute)
+	serve("/orders/1", 100)
+	tracker.evaluate(context.Background())
+	if len(alerts) != 2 || !alerts[1].Resolved || alerts[1].BurnRate != 5 {
+		t.Errorf("expected resolved alert, got %+v", alerts)
+	}
+}
+
+func TestLatencyObjective(t *testing.T) {
+	tracker, err := New(Config{
+		Objectives: map[string]Objective{"search": {Latency: 100 * time.Millisecond, LatencyTarget: 0.9, Window: 24 * time.Hour}},
+		Registry:   prometheus.NewRegistry(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.Close()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	for i := 0; i < 8; i++ {
+		tracker.Record("search", false, 50*time.Millisecond)
+	}
+	tracker.Record("search", false, time.Second)
+	tracker.Record("search", true, time.Millisecond)
+	tracker.Record("unknown", true, time.Second)
+
+	s := tracker.series["search"]
+	if got := s.burnRate(now, Latency, time.Hour); got < 0.99 || got > 1.01 {
+		t.Errorf("latency burn rate = %v, want 1", got)
+	}
+	if got := s.objective.target(Availability); got != 0 {
+		t.Errorf("expected no availability objective, got %v", got)
+	}
+	if got := s.budgetRemaining(now, Latency); got > 0.01 || got < -0.01 {
+		t.Errorf("budget remaining = %v, want 0", got)
+	}
+
+	// Requests leave the budget window
+	now = now.Add(25 * time.Hour)
+	if got := s.budgetRemaining(now, Latency); got != 1 {
+		t.Errorf("budget remaining after the window = %v, want 1", got)
+	}
+	if len(tracker.series) != 1 {
+		t.Errorf("expected unknown objectives ignored, got %v", tracker.series)
+	}
+}
+
+func TestObjectiveValidation(t *testing.T) {
+	tests := []struct {
+		objective Objective
+		valid     bool
+	}{
+		{Objective{Availability: 0.999}, true},
+		{Objective{Latency: time.Second}, true},
+		{Objective{}, false},
+		{Objective{Availability: 1}, false},
+		{Objective{Latency: time.Second, LatencyTarget: 1.5}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.objective.validate(); (err == nil) != tt.valid {
+			t.Errorf("validate(%+v) = %v, want valid %v", tt.objective, err, tt.valid)
+		}
+	}
+
+	if _, err := New(Config{Objectives: map[string]Objective{"x": {}}, Registry: prometheus.NewRegistry()}); err == nil {
+		t.Error("expected New to reject an invalid objective")
+	}
+}
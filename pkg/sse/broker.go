@@ -0,0 +1,256 @@
+package sse
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config holds broker configuration
+type Config struct {
+	BufferSize  int           // Events buffered per client before it is dropped (default 64)
+	HistorySize int           // Events kept per topic for Last-Event-ID resume (default 100, negative disables)
+	Heartbeat   time.Duration // Interval of keep-alive comments (default 15s, negative disables)
+	Retry       time.Duration // Reconnection delay sent to clients on connect
+}
+
+type record struct {
+	seq   uint64
+	event Event
+}
+
+type client struct {
+	topics map[string]bool
+	send   chan record
+	done   chan struct{} // closed by the broker to drop the client
+}
+
+// Broker fans events out to the clients subscribed to their topic
+type Broker struct {
+	config  Config
+	mu      sync.RWMutex
+	seq     uint64
+	clients map[*client]struct{}
+	history map[string][]record
+	closed  bool
+}
+
+// NewBroker creates a broker
+func NewBroker(config Config) *Broker {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 64
+	}
+	if config.HistorySize == 0 {
+		config.HistorySize = 100
+	}
+	if config.Heartbeat == 0 {
+		config.Heartbeat = 15 * time.Second
+	}
+
+	return &Broker{
+		config:  config,
+		clients: make(map[*client]struct{}),
+		history: make(map[string][]record),
+	}
+}
+
+// Publish sends an event to every client subscribed to topic. Events without
+// an ID are numbered by the broker. Clients whose buffer is full are
+// disconnected; they resume from history when they reconnect.
+func (b *Broker) Publish(topic string, e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return e
+	}
+
+	b.seq++
+	if e.ID == "" {
+		e.ID = strconv.FormatUint(b.seq, 10)
+	}
+	rec := record{seq: b.seq, event: e}
+
+	if b.config.HistorySize > 0 {
+		h := append(b.history[topic], rec)
+		if len(h) > b.config.HistorySize {
+			h = h[len(h)-b.config.HistorySize:]
+		}
+		b.history[topic] = h
+	}
+
+	for c := range b.clients {
+		if !c.topics[topic] {
+			continue
+		}
+		select {
+		case c.send <- rec:
+		default:
+			b.drop(c)
+			logrus.Warnf("SSE client dropped: buffer full on topic %s", topic)
+		}
+	}
+
+	return e
+}
+
+// drop disconnects a client; b.mu must be held for writing
+func (b *Broker) drop(c *client) {
+	if _, ok := b.clients[c]; ok {
+		delete(b.clients, c)
+		close(c.done)
+	}
+}
+
+// Serve streams the events of topics to the client until it disconnects or
+// the broker is closed. Events published since the client's Last-Event-ID
+// are replayed first.
+func (b *Broker) Serve(w http.ResponseWriter, r *http.Request, topics ...string) error {
+	stream, err := NewStream(w, r)
+	if err != nil {
+		return err
+	}
+
+	c := &client{
+		topics: make(map[string]bool, len(topics)),
+		send:   make(chan record, b.config.BufferSize),
+		done:   make(chan struct{}),
+	}
+	for _, topic := range topics {
+		c.topics[topic] = true
+	}
+
+	// Register and collect the backlog under the same lock so no event is
+	// missed or delivered twice
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	backlog := b.since(stream.LastEventID(), topics)
+	b.clients[c] = struct{}{}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		b.drop(c)
+		b.mu.Unlock()
+	}()
+
+	if b.config.Retry > 0 {
+		if err := stream.SetRetry(b.config.Retry); err != nil {
+			return err
+		}
+	}
+
+	for _, rec := range backlog {
+		if err := stream.Send(rec.event); err != nil {
+			return err
+		}
+	}
+
+	var heartbeat <-chan time.Time
+	if b.config.Heartbeat > 0 {
+		ticker := time.NewTicker(b.config.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case rec := <-c.send:
+			if err := stream.Send(rec.event); err != nil {
+				return err
+			}
+		case <-heartbeat:
+			if err := stream.Comment("ping"); err != nil {
+				return err
+			}
+		case <-c.done:
+			return nil
+		case <-stream.Done():
+			return nil
+		}
+	}
+}
+
+// since returns the history of topics published after the event lastID, in
+// publish order; b.mu must be held
+func (b *Broker) since(lastID string, topics []string) []record {
+	if lastID == "" {
+		return nil
+	}
+
+	after, found := uint64(0), false
+	for _, h := range b.history {
+		for _, rec := range h {
+			if rec.event.ID == lastID {
+				after, found = rec.seq, true
+			}
+		}
+	}
+	if !found {
+		// Broker-assigned IDs are sequence numbers, so they still locate the
+		// position after the event itself has left the history
+		seq, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			return nil
+		}
+		after = seq
+	}
+
+	var backlog []record
+	for _, topic := range topics {
+		for _, rec := range b.history[topic] {
+			if rec.seq > after {
+				backlog = append(backlog, rec)
+			}
+		}
+	}
+	sort.Slice(backlog, func(i, j int) bool { return backlog[i].seq < backlog[j].seq })
+	return backlog
+}
+
+// Handler returns a handler streaming topics. Without topics, clients choose
+// them with "topic" query parameters; put authorization in front of such a
+// handler if topics are private.
+func (b *Broker) Handler(topics ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subscribed := topics
+		if len(subscribed) == 0 {
+			subscribed = r.URL.Query()["topic"]
+		}
+		if len(subscribed) == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"No topic"}`))
+			return
+		}
+		if err := b.Serve(w, r, subscribed...); errors.Is(err, ErrStreamingUnsupported) {
+			logrus.Errorf("SSE: %v", err)
+		}
+	})
+}
+
+// ClientCount returns the number of connected clients
+func (b *Broker) ClientCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.clients)
+}
+
+// Close disconnects every client and stops accepting new ones
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for c := range b.clients {
+		b.drop(c)
+	}
+}
@@ -0,0 +1,115 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrStreamingUnsupported is returned when the response writer cannot flush
+var ErrStreamingUnsupported = errors.New("sse: response writer does not support flushing")
+
+// Event is a server-sent event
+type Event struct {
+	ID    string        // Sent as the id field; clients echo it back in Last-Event-ID
+	Event string        // Event type; "message" when empty
+	Data  string        // Payload; multi-line data is split into several data fields
+	Retry time.Duration // Reconnection delay hint for the client
+}
+
+// WriteTo encodes the event in the text/event-stream format
+func (e Event) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + singleLine(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Field values other than data cannot span lines
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// Stream writes events to a single client
+type Stream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+	r  *http.Request
+}
+
+// NewStream starts an event stream on w: it sends the text/event-stream
+// headers and flushes them so the client sees the connection open
+func NewStream(w http.ResponseWriter, r *http.Request) (*Stream, error) {
+	rc := http.NewResponseController(w)
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		return nil, ErrStreamingUnsupported
+	}
+	// Streams are long-lived; the server WriteTimeout must not cut them off
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	return &Stream{w: w, rc: rc, r: r}, nil
+}
+
+// Send writes an event and flushes it to the client
+func (s *Stream) Send(e Event) error {
+	if _, err := e.WriteTo(s.w); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Comment writes a comment line, ignored by clients but keeping idle
+// connections open through proxies
+func (s *Stream) Comment(text string) error {
+	if _, err := io.WriteString(s.w, ": "+singleLine(text)+"\n\n"); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// SetRetry tells the client how long to wait before reconnecting
+func (s *Stream) SetRetry(d time.Duration) error {
+	if _, err := fmt.Fprintf(s.w, "retry: %d\n\n", d.Milliseconds()); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// LastEventID returns the ID of the last event the client received before
+// reconnecting, from the Last-Event-ID header or the lastEventId query
+// parameter (for clients that cannot set headers)
+func (s *Stream) LastEventID() string {
+	if id := s.r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return s.r.URL.Query().Get("lastEventId")
+}
+
+// Done is closed when the client disconnects
+func (s *Stream) Done() <-chan struct{} {
+	return s.r.Context().Done()
+}
@@ -0,0 +1,190 @@
+package sse
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logrus.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestEvent_WriteTo(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		want  string
+	}{
+		{"data only", Event{Data: "hello"}, "data: hello\n\n"},
+		{"all fields", Event{ID: "7", Event: "update", Data: "x", Retry: 2 * time.Second}, "id: 7\nevent: update\nretry: 2000\ndata: x\n\n"},
+		{"multi-line data", Event{Data: "a\nb\r\nc"}, "data: a\ndata: b\ndata: c\n\n"},
+		{"newlines stripped from fields", Event{ID: "1\n2", Event: "a\nb"}, "id: 12\nevent: ab\ndata: \n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if _, err := tt.event.WriteTo(&b); err != nil {
+				t.Fatalf("WriteTo: %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+// frame is one parsed block of an event stream
+type frame struct {
+	fields  map[string]string
+	comment string
+}
+
+func readFrame(t *testing.T, r *bufio.Reader) frame {
+	t.Helper()
+	f := frame{fields: map[string]string{}}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return f
+		}
+		if strings.HasPrefix(line, ": ") {
+			f.comment = strings.TrimPrefix(line, ": ")
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		f.fields[key] = value
+	}
+}
+
+func connect(t *testing.T, url, lastEventID string) (*bufio.Reader, func()) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	return bufio.NewReader(resp.Body), func() { _ = resp.Body.Close() }
+}
+
+func waitForClients(t *testing.T, b *Broker, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for b.ClientCount() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d clients, have %d", n, b.ClientCount())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBroker_PublishAndResume(t *testing.T) {
+	b := NewBroker(Config{Heartbeat: -1})
+	srv := httptest.NewServer(b.Handler())
+	defer srv.Close()
+
+	r, closeConn := connect(t, srv.URL+"?topic=news", "")
+	waitForClients(t, b, 1)
+
+	b.Publish("sports", Event{Data: "ignored"})
+	b.Publish("news", Event{Event: "headline", Data: "first"})
+	b.Publish("news", Event{Data: "second"})
+
+	first := readFrame(t, r)
+	if first.fields["id"] != "2" || first.fields["event"] != "headline" || first.fields["data"] != "first" {
+		t.Errorf("unexpected first event %v", first.fields)
+	}
+	if second := readFrame(t, r); second.fields["data"] != "second" {
+		t.Errorf("unexpected second event %v", second.fields)
+	}
+
+	closeConn()
+	waitForClients(t, b, 0)
+	b.Publish("news", Event{Data: "missed"})
+
+	// Reconnect after the first event: the rest is replayed in order
+	r, closeConn = connect(t, srv.URL+"?topic=news", first.fields["id"])
+	defer closeConn()
+	for _, want := range []string{"second", "missed"} {
+		if got := readFrame(t, r).fields["data"]; got != want {
+			t.Errorf("replayed %q, want %q", got, want)
+		}
+	}
+}
+
+func TestBroker_Heartbeat(t *testing.T) {
+	b := NewBroker(Config{Heartbeat: 10 * time.Millisecond, Retry: time.Second})
+	srv := httptest.NewServer(b.Handler("news"))
+	defer srv.Close()
+
+	r, closeConn := connect(t, srv.URL, "")
+	defer closeConn()
+
+	if retry := readFrame(t, r).fields["retry"]; retry != "1000" {
+		t.Errorf("expected retry 1000, got %q", retry)
+	}
+	if ping := readFrame(t, r); ping.comment != "ping" {
+		t.Errorf("expected ping comment, got %+v", ping)
+	}
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker(Config{Heartbeat: -1})
+	srv := httptest.NewServer(b.Handler("news"))
+	defer srv.Close()
+
+	r, closeConn := connect(t, srv.URL, "")
+	defer closeConn()
+	waitForClients(t, b, 1)
+
+	b.Close()
+	if _, err := r.ReadString('\n'); err != io.EOF {
+		t.Errorf("expected the stream to end, got %v", err)
+	}
+	if e := b.Publish("news", Event{Data: "late"}); e.ID != "" {
+		t.Errorf("expected publish after close to be ignored, got %+v", e)
+	}
+}
+
+func TestBroker_Handler_NoTopic(t *testing.T) {
+	b := NewBroker(Config{})
+	rec := httptest.NewRecorder()
+	b.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestBroker_HistorySize(t *testing.T) {
+	b := NewBroker(Config{HistorySize: 2})
+	for _, data := range []string{"a", "b", "c"} {
+		b.Publish("news", Event{Data: data})
+	}
+
+	b.mu.RLock()
+	backlog := b.since("1", []string{"news"})
+	b.mu.RUnlock()
+
+	if len(backlog) != 2 || backlog[0].event.Data != "b" || backlog[1].event.Data != "c" {
+		t.Errorf("unexpected backlog %+v", backlog)
+	}
+}
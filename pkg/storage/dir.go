@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Dir is a Store of the files below a local directory. Keys are slash
+// separated paths relative to it; keys escaping it, e.g. through ".." or
+// symlinks, are rejected.
+type Dir struct {
+	path string
+}
+
+// NewDir creates a Store of the files below dir
+func NewDir(dir string) *Dir {
+	return &Dir{path: dir}
+}
+
+// Stat implements Store
+func (d *Dir) Stat(ctx context.Context, key string) (Object, error) {
+	root, err := os.OpenRoot(d.path)
+	if err != nil {
+		return Object{}, err
+	}
+	defer root.Close()
+
+	info, err := root.Stat(dirName(key))
+	if err != nil {
+		return Object{}, dirError(err)
+	}
+	if info.IsDir() {
+		return Object{}, ErrNotFound
+	}
+	return fileObject(key, info), nil
+}
+
+// Open implements Store
+func (d *Dir) Open(ctx context.Context, key string) (io.ReadSeekCloser, Object, error) {
+	root, err := os.OpenRoot(d.path)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	defer root.Close()
+
+	file, err := root.Open(dirName(key))
+	if err != nil {
+		return nil, Object{}, dirError(err)
+	}
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = ErrNotFound
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, Object{}, err
+	}
+	return file, fileObject(key, info), nil
+}
+
+// Put writes the content of r to key, creating its directories
+func (d *Dir) Put(ctx context.Context, key string, r io.Reader) error {
+	root, err := os.OpenRoot(d.path)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	name := dirName(key)
+	if dir := filepath.Dir(name); dir != "." {
+		if err := root.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	file, err := root.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Delete removes key; missing keys are not an error
+func (d *Dir) Delete(ctx context.Context, key string) error {
+	root, err := os.OpenRoot(d.path)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	if err := root.Remove(dirName(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// cleanKey returns key without "..", "." and leading slashes
+func cleanKey(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
+
+// dirName turns a key into a path relative to the root
+func dirName(key string) string {
+	if key = cleanKey(key); key == "" {
+		return "."
+	}
+	return filepath.FromSlash(key)
+}
+
+func dirError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// fileObject returns the metadata of a file, with an ETag from its size
+// and modification time like render.FileETag
+func fileObject(key string, info fs.FileInfo) Object {
+	return Object{
+		Key:         cleanKey(key),
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ETag:        `"` + strconv.FormatInt(info.Size(), 16) + "-" + strconv.FormatInt(info.ModTime().UnixNano(), 16) + `"`,
+		ModTime:     info.ModTime(),
+	}
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	ctx := context.Background()
+	base := t.TempDir()
+	d := NewDir(filepath.Join(base, "files"))
+	if err := os.Mkdir(filepath.Join(base, "files"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "secret.txt"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Put(ctx, "reports/2024/q1.csv", strings.NewReader("a,b\n1,2\n")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	body, obj, err := d.Open(ctx, "/reports/2024/q1.csv")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	_ = body.Close()
+	if string(data) != "a,b\n1,2\n" || obj.Size != 8 || obj.ETag == "" || obj.ModTime.IsZero() {
+		t.Errorf("Open = %q, %+v", data, obj)
+	}
+	if !strings.HasPrefix(obj.ContentType, "text/csv") {
+		t.Errorf("ContentType = %q, want text/csv", obj.ContentType)
+	}
+	if stat, err := d.Stat(ctx, "reports/2024/q1.csv"); err != nil || stat != obj {
+		t.Errorf("Stat = %+v, %v, want %+v", stat, err, obj)
+	}
+
+	for _, key := range []string{"missing.txt", "reports", "../secret.txt", ""} {
+		if _, _, err := d.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) error = %v, want ErrNotFound", key, err)
+		}
+	}
+
+	if err := os.Symlink(filepath.Join(base, "secret.txt"), filepath.Join(base, "files", "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.Open(ctx, "link.txt"); err == nil {
+		t.Error("Open followed a symlink out of the directory")
+	}
+
+	if err := d.Delete(ctx, "reports/2024/q1.csv"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := d.Stat(ctx, "reports/2024/q1.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after Delete = %v, want ErrNotFound", err)
+	}
+	if err := d.Delete(ctx, "reports/2024/q1.csv"); err != nil {
+		t.Errorf("Delete of a missing key = %v", err)
+	}
+}
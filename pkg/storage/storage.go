@@ -0,0 +1,47 @@
+// Package storage abstracts the object stores files are served from, such
+// as a local directory or S3. Stores open objects as io.ReadSeekCloser, so
+// app.Context.FileFromStorage can answer Range requests from any backend;
+// stores that can presign URLs implement Signer to let clients download
+// directly.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned for keys without an object
+var ErrNotFound = errors.New("storage: object not found")
+
+// Object is the metadata of a stored object
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string    // Empty when unknown; detected from the key or content
+	ETag        string    // Version of the content, quoted or not
+	ModTime     time.Time // Zero when unknown
+}
+
+// Store reads objects by key, e.g. "avatars/42.png"
+type Store interface {
+	// Stat returns the metadata of key, ErrNotFound when there is none
+	Stat(ctx context.Context, key string) (Object, error)
+	// Open returns the content and metadata of key, ErrNotFound when there
+	// is none. Backends reading over the network should fetch lazily from
+	// the offset sought to, so a Range request downloads only its part.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, Object, error)
+}
+
+// SignOptions are the options of a signed URL
+type SignOptions struct {
+	Expires    time.Duration // How long the URL is valid
+	Attachment string        // Download filename for Content-Disposition, if any
+}
+
+// Signer is a Store handing out time-limited URLs to its objects, e.g. S3
+// presigned GET URLs
+type Signer interface {
+	SignedURL(ctx context.Context, key string, opts SignOptions) (string, error)
+}
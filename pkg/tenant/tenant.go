@@ -0,0 +1,173 @@
+// Package tenant resolves the tenant of each request, from the subdomain, a
+// header or a JWT claim, and stores it in the request context, where
+// database.TenantRouter and caches with tenant.KeyPrefix pick it up:
+//
+//	a.Use(tenant.Middleware(tenant.Config{
+//		Resolvers: []tenant.Resolver{tenant.FromSubdomain("example.com"), tenant.FromHeader("X-Tenant-ID")},
+//	}))
+//
+//	id := tenant.FromContext(r.Context())
+package tenant
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/middleware"
+	"github.com/polymatx/goframe/pkg/xlog"
+)
+
+// Header is the header the tenant is propagated to other services in
+const Header = "X-Tenant-ID"
+
+type tenantKey struct{}
+
+// Resolver returns the tenant of a request, or "" if it names none
+type Resolver func(r *http.Request) string
+
+// FromHeader resolves the tenant from a request header
+func FromHeader(name string) Resolver {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// FromSubdomain resolves the tenant from the label before domain in the
+// host, e.g. "acme" for acme.example.com and domain example.com
+func FromSubdomain(domain string) Resolver {
+	suffix := "." + strings.ToLower(strings.Trim(domain, "."))
+	return func(r *http.Request) string {
+		host := strings.ToLower(r.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		sub, ok := strings.CutSuffix(host, suffix)
+		if !ok || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+}
+
+// FromClaim resolves the tenant from a string claim of the JWT, e.g.
+// "tenant_id". The middleware must run after the authentication
+// middleware, as group middleware.
+func FromClaim(name string) Resolver {
+	return func(r *http.Request) string {
+		claims, ok := auth.GetClaims(r.Context())
+		if !ok {
+			return ""
+		}
+		tenant, _ := claims.Extra[name].(string)
+		return tenant
+	}
+}
+
+// Config holds tenant resolution configuration
+type Config struct {
+	// Resolvers are tried in order and the first tenant found is used
+	// (default FromHeader(Header)). List trusted sources, such as a claim,
+	// first, so a header cannot override them.
+	Resolvers []Resolver
+
+	// Required rejects requests without a tenant with 400
+	Required bool
+
+	// Validate reports whether a tenant exists and the request may act for
+	// it; unknown tenants get 404, and errors 503
+	Validate func(ctx context.Context, tenant string) (bool, error)
+}
+
+// Middleware resolves the tenant of requests and stores it in the request
+// context, the xlog fields and the headers propagated to other services.
+// Tenant IDs are at most 64 letters, digits, '-', '_' or '.', and requests
+// naming others get 400.
+func Middleware(config Config) func(http.Handler) http.Handler {
+	if len(config.Resolvers) == 0 {
+		config.Resolvers = []Resolver{FromHeader(Header)}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := ""
+			for _, resolve := range config.Resolvers {
+				if tenant = resolve(r); tenant != "" {
+					break
+				}
+			}
+
+			switch {
+			case tenant == "" && config.Required:
+				writeError(w, http.StatusBadRequest, "Tenant required")
+				return
+			case tenant == "":
+				next.ServeHTTP(w, r)
+				return
+			case !validID(tenant):
+				writeError(w, http.StatusBadRequest, "Invalid tenant")
+				return
+			}
+
+			if config.Validate != nil {
+				ok, err := config.Validate(r.Context(), tenant)
+				if err != nil {
+					xlog.GetWithError(r.Context(), err).Warn("tenant validation failed")
+					writeError(w, http.StatusServiceUnavailable, "Tenant unavailable")
+					return
+				}
+				if !ok {
+					writeError(w, http.StatusNotFound, "Unknown tenant")
+					return
+				}
+			}
+
+			ctx := xlog.SetField(WithID(r.Context(), tenant), "tenant", tenant)
+			ctx = middleware.WithPropagatedHeaders(ctx, http.Header{http.CanonicalHeaderKey(Header): {tenant}})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WithID returns a context carrying the tenant, e.g. for a background job
+// of a tenant
+func WithID(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// FromContext returns the tenant of ctx, or "" if none was resolved. It
+// is the default database.TenantConfig.Tenant.
+func FromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// KeyPrefix returns the cache key prefix of the tenant of ctx, or "" if
+// none was resolved, for cache.Config.KeyPrefix
+func KeyPrefix(ctx context.Context) string {
+	if tenant := FromContext(ctx); tenant != "" {
+		return "tenant:" + tenant + ":"
+	}
+	return ""
+}
+
+func validID(id string) bool {
+	if len(id) > 64 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"error":"` + message + `"}`))
+}
@@ -0,0 +1,82 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/polymatx/goframe/pkg/auth"
+	"github.com/polymatx/goframe/pkg/middleware"
+)
+
+func TestMiddleware(t *testing.T) {
+	var got string
+	var propagated http.Header
+	handler := func(config Config) http.Handler {
+		return Middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = FromContext(r.Context())
+			propagated = middleware.GetPropagatedHeaders(r.Context())
+		}))
+	}
+	resolvers := []Resolver{FromClaim("tenant_id"), FromSubdomain("example.com"), FromHeader("X-Tenant-ID")}
+
+	tests := []struct {
+		name     string
+		config   Config
+		host     string
+		header   string
+		claim    string
+		wantCode int
+		want     string
+	}{
+		{"header", Config{}, "api.test", "acme", "", http.StatusOK, "acme"},
+		{"subdomain", Config{Resolvers: resolvers}, "acme.example.com:8080", "", "", http.StatusOK, "acme"},
+		{"nested subdomain ignored", Config{Resolvers: resolvers}, "a.acme.example.com", "", "", http.StatusOK, ""},
+		{"claim wins over header", Config{Resolvers: resolvers}, "api.test", "globex", "acme", http.StatusOK, "acme"},
+		{"none", Config{}, "api.test", "", "", http.StatusOK, ""},
+		{"required", Config{Required: true}, "api.test", "", "", http.StatusBadRequest, ""},
+		{"invalid", Config{}, "api.test", "acme:*", "", http.StatusBadRequest, ""},
+		{"unknown", Config{Validate: func(ctx context.Context, tenant string) (bool, error) {
+			return tenant == "acme", nil
+		}}, "api.test", "globex", "", http.StatusNotFound, ""},
+		{"validation failure", Config{Validate: func(context.Context, string) (bool, error) {
+			return false, errors.New("db down")
+		}}, "api.test", "acme", "", http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, propagated = "", nil
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Host = tt.host
+			if tt.header != "" {
+				r.Header.Set("X-Tenant-ID", tt.header)
+			}
+			if tt.claim != "" {
+				r = r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{Extra: map[string]interface{}{"tenant_id": tt.claim}}))
+			}
+			w := httptest.NewRecorder()
+			handler(tt.config).ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body)
+			}
+			if got != tt.want {
+				t.Errorf("tenant = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && propagated.Get(Header) != tt.want {
+				t.Errorf("expected tenant propagated, got %v", propagated)
+			}
+		})
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	if p := KeyPrefix(context.Background()); p != "" {
+		t.Errorf("KeyPrefix without tenant = %q", p)
+	}
+	if p := KeyPrefix(WithID(context.Background(), "acme")); p != "tenant:acme:" {
+		t.Errorf("KeyPrefix = %q, want tenant:acme:", p)
+	}
+}
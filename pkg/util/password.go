@@ -0,0 +1,110 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrPasswordTooShort = errors.New("password is too short")
+	ErrPasswordTooLong  = errors.New("password is too long")
+	ErrPasswordTooWeak  = errors.New("password is too easy to guess")
+	ErrPasswordBreached = errors.New("password appears in a data breach")
+)
+
+// PasswordPolicy holds the requirements of new passwords
+type PasswordPolicy struct {
+	MinLength int // Minimum characters (default 8)
+	MaxLength int // Maximum bytes (default 72, the most bcrypt hashes)
+
+	// MinEntropy is the minimum estimated strength in bits, see
+	// PasswordEntropy; 0 skips the check. 50 rejects most short or
+	// repetitive passwords.
+	MinEntropy float64
+
+	// Breached reports whether the password is known from data breaches,
+	// e.g. through the Have I Been Pwned range API; nil skips the check.
+	// Its errors fail Validate.
+	Breached func(ctx context.Context, password string) (bool, error)
+}
+
+// Validate checks password against the policy, returning the first
+// requirement it fails: ErrPasswordTooShort, ErrPasswordTooLong,
+// ErrPasswordTooWeak or ErrPasswordBreached
+func (p PasswordPolicy) Validate(ctx context.Context, password string) error {
+	if p.MinLength == 0 {
+		p.MinLength = 8
+	}
+	if p.MaxLength == 0 {
+		p.MaxLength = 72
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > p.MaxLength {
+		return ErrPasswordTooLong
+	}
+	if p.MinEntropy > 0 && PasswordEntropy(password) < p.MinEntropy {
+		return ErrPasswordTooWeak
+	}
+	if p.Breached != nil {
+		breached, err := p.Breached(ctx, password)
+		if err != nil {
+			return fmt.Errorf("breached password check failed: %w", err)
+		}
+		if breached {
+			return ErrPasswordBreached
+		}
+	}
+	return nil
+}
+
+// PasswordEntropy estimates the strength of password in bits from the
+// character classes it uses (lower, upper, digits, symbols, other) and its
+// length, counting repeated characters at half weight. It is a rough
+// guide: dictionary words score as random letters, which the Breached
+// check of PasswordPolicy catches better.
+func PasswordEntropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	seen := make(map[rune]bool)
+	var length float64
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+		if seen[r] {
+			length += 0.5
+		} else {
+			length++
+			seen[r] = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	return length * math.Log2(float64(pool))
+}
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"regexp"
 	"strings"
@@ -617,3 +619,55 @@ func TestRemoveSpaces(t *testing.T) {
 		})
 	}
 }
+
+// ---------- password.go ----------
+
+func TestPasswordPolicy(t *testing.T) {
+	breached := func(ctx context.Context, password string) (bool, error) {
+		switch password {
+		case "correct horse battery staple":
+			return true, nil
+		case "lookup fails here":
+			return false, errors.New("timeout")
+		}
+		return false, nil
+	}
+	policy := PasswordPolicy{MinLength: 10, MinEntropy: 50, Breached: breached}
+
+	tests := []struct {
+		password string
+		want     error
+	}{
+		{"Xk9#mPq2$vLw", nil},
+		{"short1A!", ErrPasswordTooShort},
+		{"ünïcødé€€", ErrPasswordTooShort}, // 9 characters, more bytes
+		{strings.Repeat("Ab1!", 19), ErrPasswordTooLong},
+		{"aaaaaaaaaaaaaaaa", ErrPasswordTooWeak},
+		{"abcdefghij", ErrPasswordTooWeak},
+		{"correct horse battery staple", ErrPasswordBreached},
+	}
+	for _, tt := range tests {
+		if err := policy.Validate(context.Background(), tt.password); !errors.Is(err, tt.want) {
+			t.Errorf("Validate(%q) = %v, want %v", tt.password, err, tt.want)
+		}
+	}
+
+	if err := policy.Validate(context.Background(), "lookup fails here"); err == nil || errors.Is(err, ErrPasswordBreached) {
+		t.Errorf("Validate with a failing check = %v, want the check error", err)
+	}
+	if err := (PasswordPolicy{}).Validate(context.Background(), "12345678"); err != nil {
+		t.Errorf("default policy = %v, want only the length checked", err)
+	}
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	if got := PasswordEntropy(""); got != 0 {
+		t.Errorf("PasswordEntropy(\"\") = %v", got)
+	}
+	if a, b := PasswordEntropy("aaaaaaaa"), PasswordEntropy("abcdefgh"); a >= b {
+		t.Errorf("repeated %v >= distinct %v", a, b)
+	}
+	if a, b := PasswordEntropy("abcdefgh"), PasswordEntropy("abcdEFG1"); a >= b {
+		t.Errorf("one class %v >= three classes %v", a, b)
+	}
+}
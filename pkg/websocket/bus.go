@@ -0,0 +1,175 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Bus is a message bus carrying subscription events between instances.
+// *cache.Manager (Redis pub/sub) and *mqtt.Client implement it.
+type Bus interface {
+	Subscribe(ctx context.Context, topic string, handler func(topic string, payload []byte) error) error
+}
+
+// BusSubscriberConfig configures a BusSubscriber
+type BusSubscriberConfig struct {
+	Bus Bus
+
+	// Topic returns the bus topic of a subscription, e.g. "orders:"+id from
+	// its variables; an error rejects the subscription
+	Topic func(ctx context.Context, req GraphQLRequest) (string, error)
+
+	// Resolve turns a bus message into the result sent to a subscription,
+	// nil skipping it, e.g. to filter by the claims in ctx. By default the
+	// payload is sent as is and must be a JSON execution result such as
+	// {"data":{"order":{"status":"shipped"}}}.
+	Resolve func(ctx context.Context, req GraphQLRequest, payload []byte) (interface{}, error)
+
+	Buffer int // Results buffered per subscription before it is ended (default 16)
+}
+
+// BusSubscriber is a GraphQLSubscriber fanning out the events published on
+// a bus, so every instance behind a load balancer delivers them to its own
+// subscriptions. Each instance subscribes to a bus topic once, while it has
+// subscriptions to it, and hands each message to all of them:
+//
+//	subscriber := websocket.NewBusSubscriber(websocket.BusSubscriberConfig{
+//		Bus: cache.MustGet("default"),
+//		Topic: func(ctx context.Context, req websocket.GraphQLRequest) (string, error) {
+//			return "orders:" + fmt.Sprint(req.Variables["id"]), nil
+//		},
+//	})
+//	router.Handle("/graphql/ws", websocket.GraphQLHandler(websocket.GraphQLConfig{Subscriber: subscriber}))
+//
+//	// On any instance
+//	cache.MustGet("default").Publish(ctx, "orders:42", payload)
+type BusSubscriber struct {
+	config BusSubscriberConfig
+
+	mu     sync.Mutex
+	topics map[string]*busTopic
+}
+
+// busTopic is a bus subscription shared by the subscriptions to its topic
+type busTopic struct {
+	cancel context.CancelFunc
+	subs   map[*busSubscription]struct{}
+}
+
+type busSubscription struct {
+	ctx     context.Context
+	req     GraphQLRequest
+	results chan interface{}
+}
+
+// NewBusSubscriber creates a BusSubscriber
+func NewBusSubscriber(config BusSubscriberConfig) *BusSubscriber {
+	if config.Buffer <= 0 {
+		config.Buffer = 16
+	}
+	if config.Resolve == nil {
+		config.Resolve = func(ctx context.Context, req GraphQLRequest, payload []byte) (interface{}, error) {
+			if !json.Valid(payload) {
+				return nil, errors.New("bus payload is not JSON")
+			}
+			return json.RawMessage(payload), nil
+		}
+	}
+	return &BusSubscriber{config: config, topics: make(map[string]*busTopic)}
+}
+
+// Subscribe implements GraphQLSubscriber. The results end when ctx is
+// canceled, or when the subscription falls Buffer results behind.
+func (b *BusSubscriber) Subscribe(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error) {
+	topic, err := b.config.Topic(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &busSubscription{ctx: ctx, req: req, results: make(chan interface{}, b.config.Buffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t, ok := b.topics[topic]
+	if !ok {
+		// The bus subscription outlives the request of its first subscriber
+		busCtx, cancel := context.WithCancel(context.Background())
+		t = &busTopic{cancel: cancel, subs: make(map[*busSubscription]struct{})}
+		if err := b.config.Bus.Subscribe(busCtx, topic, func(_ string, payload []byte) error {
+			b.deliver(topic, t, payload)
+			return nil
+		}); err != nil {
+			cancel()
+			return nil, err
+		}
+		b.topics[topic] = t
+	}
+	t.subs[sub] = struct{}{}
+
+	context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(topic, t, sub, false)
+	})
+	return sub.results, nil
+}
+
+// deliver hands payload to the subscriptions of t
+func (b *BusSubscriber) deliver(topic string, t *busTopic, payload []byte) {
+	b.mu.Lock()
+	subs := make([]*busSubscription, 0, len(t.subs))
+	for sub := range t.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		result, err := b.config.Resolve(sub.ctx, sub.req, payload)
+		if err != nil {
+			logrus.WithError(err).WithField("topic", topic).Warn("GraphQL bus event dropped")
+			continue
+		}
+		if result == nil {
+			continue
+		}
+
+		b.mu.Lock()
+		if _, ok := t.subs[sub]; ok {
+			select {
+			case sub.results <- result:
+			default:
+				logrus.WithField("topic", topic).Warn("GraphQL subscription ended: too slow for bus events")
+				b.remove(topic, t, sub, true)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// remove ends sub, and the bus subscription of topic with its last
+// subscription. Closing the results completes a subscription still being
+// read. b.mu must be held.
+func (b *BusSubscriber) remove(topic string, t *busTopic, sub *busSubscription, closeResults bool) {
+	if _, ok := t.subs[sub]; !ok {
+		return
+	}
+	delete(t.subs, sub)
+	if closeResults {
+		close(sub.results)
+	}
+	if len(t.subs) == 0 {
+		t.cancel()
+		delete(b.topics, topic)
+	}
+}
+
+// Topics returns how many bus topics the instance is subscribed to
+func (b *BusSubscriber) Topics() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.topics)
+}
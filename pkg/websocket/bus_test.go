@@ -0,0 +1,108 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBus is a Bus delivering to the handlers of a topic in process
+type memoryBus struct {
+	mu       sync.Mutex
+	handlers map[string][]func(string, []byte) error
+	subs     int
+}
+
+func (m *memoryBus) Subscribe(ctx context.Context, topic string, handler func(topic string, payload []byte) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.handlers == nil {
+		m.handlers = make(map[string][]func(string, []byte) error)
+	}
+	m.subs++
+	i := len(m.handlers[topic])
+	m.handlers[topic] = append(m.handlers[topic], handler)
+	context.AfterFunc(ctx, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.handlers[topic][i] = nil
+	})
+	return nil
+}
+
+func (m *memoryBus) publish(topic, payload string) {
+	m.mu.Lock()
+	handlers := append([]func(string, []byte) error(nil), m.handlers[topic]...)
+	m.mu.Unlock()
+	for _, h := range handlers {
+		if h != nil {
+			_ = h(topic, []byte(payload))
+		}
+	}
+}
+
+func receive(t *testing.T, results <-chan interface{}) string {
+	t.Helper()
+	select {
+	case result, ok := <-results:
+		if !ok {
+			return "closed"
+		}
+		b, _ := json.Marshal(result)
+		return string(b)
+	case <-time.After(time.Second):
+		t.Fatal("no result")
+		return ""
+	}
+}
+
+func TestBusSubscriber(t *testing.T) {
+	bus := &memoryBus{}
+	subscriber := NewBusSubscriber(BusSubscriberConfig{
+		Bus: bus,
+		Topic: func(ctx context.Context, req GraphQLRequest) (string, error) {
+			return "orders:" + req.Variables["id"].(string), nil
+		},
+		Buffer: 1,
+	})
+	order := func(id string) GraphQLRequest {
+		return GraphQLRequest{Query: "subscription { order }", Variables: map[string]interface{}{"id": id}}
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	first, _ := subscriber.Subscribe(ctx1, order("42"))
+	second, _ := subscriber.Subscribe(ctx2, order("42"))
+	if bus.subs != 1 || subscriber.Topics() != 1 {
+		t.Fatalf("expected one bus subscription for the topic, got %d", bus.subs)
+	}
+
+	bus.publish("orders:42", `{"data":{"order":"shipped"}}`)
+	bus.publish("orders:7", `{"data":{"order":"lost"}}`)
+	for _, results := range []<-chan interface{}{first, second} {
+		if got := receive(t, results); got != `{"data":{"order":"shipped"}}` {
+			t.Errorf("unexpected result %s", got)
+		}
+	}
+
+	// The second subscription does not keep up and is ended
+	bus.publish("orders:42", `{"data":{"order":"delivered"}}`)
+	receive(t, first)
+	bus.publish("orders:42", `{"data":{"order":"returned"}}`)
+	if got := receive(t, first); got != `{"data":{"order":"returned"}}` {
+		t.Errorf("unexpected result %s", got)
+	}
+	receive(t, second)
+	if got := receive(t, second); got != "closed" {
+		t.Errorf("expected the slow subscription to be ended, got %s", got)
+	}
+
+	cancel2()
+	cancel1()
+	time.Sleep(10 * time.Millisecond)
+	if subscriber.Topics() != 0 {
+		t.Errorf("expected the bus subscription to end with the last subscription")
+	}
+}
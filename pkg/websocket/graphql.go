@@ -0,0 +1,319 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// GraphQLProtocol is the graphql-ws subprotocol name
+const GraphQLProtocol = "graphql-transport-ws"
+
+// graphql-ws message types
+const (
+	gqlConnectionInit = "connection_init"
+	gqlConnectionAck  = "connection_ack"
+	gqlPing           = "ping"
+	gqlPong           = "pong"
+	gqlSubscribe      = "subscribe"
+	gqlNext           = "next"
+	gqlError          = "error"
+	gqlComplete       = "complete"
+)
+
+// graphql-ws close codes
+const (
+	closeInvalidMessage      = 4400
+	closeUnauthorized        = 4401
+	closeForbidden           = 4403
+	closeInitTimeout         = 4408
+	closeSubscriberExists    = 4409
+	closeTooManyInitRequests = 4429
+)
+
+// ErrTooManySubscriptions is reported when a connection exceeds MaxSubscriptions
+var ErrTooManySubscriptions = errors.New("too many subscriptions")
+
+// GraphQLRequest is the payload of a subscribe message
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLSubscriber executes a subscription operation. Results are sent on the
+// returned channel until it is closed or ctx is canceled. With multiple
+// instances, source events from a shared broker, e.g. with BusSubscriber.
+type GraphQLSubscriber interface {
+	Subscribe(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error)
+}
+
+// GraphQLSubscriberFunc adapts a function to GraphQLSubscriber
+type GraphQLSubscriberFunc func(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error)
+
+// Subscribe implements GraphQLSubscriber
+func (f GraphQLSubscriberFunc) Subscribe(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error) {
+	return f(ctx, req)
+}
+
+// GraphQLConfig holds configuration for the GraphQL subscription handler
+type GraphQLConfig struct {
+	Subscriber GraphQLSubscriber
+
+	// OnConnect authenticates the connection_init payload. The returned
+	// context is used for every subscription on the connection, so it can
+	// carry auth claims. Returning an error closes the socket with 4403.
+	OnConnect func(ctx context.Context, r *http.Request, payload map[string]interface{}) (context.Context, error)
+
+	InitTimeout      time.Duration // Time allowed for connection_init (default 10s)
+	WriteTimeout     time.Duration // Time allowed for each message write (default 10s)
+	MaxSubscriptions int           // Concurrent subscriptions per connection (default 10)
+	Upgrader         UpgraderConfig
+}
+
+type gqlMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+// GraphQLHandler returns a handler serving GraphQL subscriptions over the
+// graphql-ws (graphql-transport-ws) protocol
+func GraphQLHandler(config GraphQLConfig) http.Handler {
+	if config.InitTimeout == 0 {
+		config.InitTimeout = 10 * time.Second
+	}
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = 10 * time.Second
+	}
+	if config.MaxSubscriptions == 0 {
+		config.MaxSubscriptions = 10
+	}
+	if config.Upgrader.CheckOrigin == nil {
+		config.Upgrader.CheckOrigin = defaultCheckOrigin
+	}
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  config.Upgrader.ReadBufferSize,
+		WriteBufferSize: config.Upgrader.WriteBufferSize,
+		CheckOrigin:     config.Upgrader.CheckOrigin,
+		Subprotocols:    []string{GraphQLProtocol},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		s := &gqlSession{
+			config: config,
+			conn:   conn,
+			subs:   make(map[string]*gqlSubscription),
+		}
+		s.serve(r)
+	})
+}
+
+type gqlSession struct {
+	config GraphQLConfig
+	conn   *websocket.Conn
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	subs    map[string]*gqlSubscription
+	wg      sync.WaitGroup
+}
+
+// gqlSubscription is a running subscription; a client may reuse its ID once
+// it completes, so entries are compared by pointer
+type gqlSubscription struct {
+	cancel context.CancelFunc
+}
+
+func (s *gqlSession) serve(r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer func() {
+		cancel()
+		s.wg.Wait()
+		_ = s.conn.Close()
+	}()
+
+	initTimer := time.AfterFunc(s.config.InitTimeout, func() {
+		s.close(closeInitTimeout, "Connection initialisation timeout")
+	})
+	defer initTimer.Stop()
+
+	acked := false
+	for {
+		var msg gqlMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logrus.Debugf("GraphQL websocket read error: %v", err)
+			}
+			return
+		}
+
+		switch msg.Type {
+		case gqlConnectionInit:
+			if acked {
+				s.close(closeTooManyInitRequests, "Too many initialisation requests")
+				return
+			}
+			initTimer.Stop()
+
+			var payload map[string]interface{}
+			if len(msg.Payload) > 0 {
+				_ = json.Unmarshal(msg.Payload, &payload)
+			}
+			if s.config.OnConnect != nil {
+				connCtx, err := s.config.OnConnect(ctx, r, payload)
+				if err != nil {
+					s.close(closeForbidden, "Forbidden")
+					return
+				}
+				if connCtx != nil {
+					ctx = connCtx
+				}
+			}
+
+			acked = true
+			s.write(gqlMessage{Type: gqlConnectionAck})
+
+		case gqlPing:
+			s.write(gqlMessage{Type: gqlPong})
+
+		case gqlPong:
+
+		case gqlSubscribe:
+			if !acked {
+				s.close(closeUnauthorized, "Unauthorized")
+				return
+			}
+			if msg.ID == "" {
+				s.close(closeInvalidMessage, "Invalid message")
+				return
+			}
+
+			var req GraphQLRequest
+			if err := json.Unmarshal(msg.Payload, &req); err != nil {
+				s.close(closeInvalidMessage, "Invalid message")
+				return
+			}
+			if !s.subscribe(ctx, msg.ID, req) {
+				return
+			}
+
+		case gqlComplete:
+			s.mu.Lock()
+			if sub, ok := s.subs[msg.ID]; ok {
+				sub.cancel()
+				delete(s.subs, msg.ID)
+			}
+			s.mu.Unlock()
+
+		default:
+			s.close(closeInvalidMessage, "Invalid message")
+			return
+		}
+	}
+}
+
+// subscribe starts a subscription and reports false if the connection was closed
+func (s *gqlSession) subscribe(ctx context.Context, id string, req GraphQLRequest) bool {
+	s.mu.Lock()
+	if _, exists := s.subs[id]; exists {
+		s.mu.Unlock()
+		s.close(closeSubscriberExists, "Subscriber for "+id+" already exists")
+		return false
+	}
+	if len(s.subs) >= s.config.MaxSubscriptions {
+		s.mu.Unlock()
+		s.writeErrors(id, ErrTooManySubscriptions)
+		return true
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	sub := &gqlSubscription{cancel: cancel}
+	s.subs[id] = sub
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.finish(id, sub)
+
+		results, err := s.config.Subscriber.Subscribe(subCtx, req)
+		if err != nil {
+			s.writeErrors(id, err)
+			return
+		}
+
+		for {
+			select {
+			case <-subCtx.Done():
+				return
+			case result, ok := <-results:
+				if !ok {
+					s.write(gqlMessage{ID: id, Type: gqlComplete})
+					return
+				}
+				payload, err := json.Marshal(result)
+				if err != nil {
+					s.writeErrors(id, err)
+					return
+				}
+				s.write(gqlMessage{ID: id, Type: gqlNext, Payload: payload})
+			}
+		}
+	}()
+
+	return true
+}
+
+// finish releases sub, leaving a newer subscription the client started
+// under the same ID after completing sub in place
+func (s *gqlSession) finish(id string, sub *gqlSubscription) {
+	sub.cancel()
+	s.mu.Lock()
+	if s.subs[id] == sub {
+		delete(s.subs, id)
+	}
+	s.mu.Unlock()
+}
+
+func (s *gqlSession) writeErrors(id string, err error) {
+	payload, _ := json.Marshal([]graphQLError{{Message: err.Error()}})
+	s.write(gqlMessage{ID: id, Type: gqlError, Payload: payload})
+}
+
+// write sends msg within WriteTimeout. A client that stops reading would
+// otherwise block every subscription of the connection on writeMu; on a
+// failed write the socket is closed, which ends the session.
+func (s *gqlSession) write(msg gqlMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.config.WriteTimeout))
+	if err := s.conn.WriteJSON(msg); err != nil {
+		logrus.Debugf("GraphQL websocket write error: %v", err)
+		_ = s.conn.Close()
+	}
+}
+
+func (s *gqlSession) close(code int, reason string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	_ = s.conn.Close()
+}
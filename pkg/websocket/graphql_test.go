@@ -0,0 +1,202 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func dialGraphQL(t *testing.T, config GraphQLConfig) *websocket.Conn {
+	t.Helper()
+
+	srv := httptest.NewServer(GraphQLHandler(config))
+	t.Cleanup(srv.Close)
+
+	dialer := websocket.Dialer{Subprotocols: []string{GraphQLProtocol}}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	if resp.Header.Get("Sec-WebSocket-Protocol") != GraphQLProtocol {
+		t.Errorf("expected subprotocol %s, got %q", GraphQLProtocol, resp.Header.Get("Sec-WebSocket-Protocol"))
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) gqlMessage {
+	t.Helper()
+	var msg gqlMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return msg
+}
+
+func TestGraphQLHandler_Subscription(t *testing.T) {
+	subscriber := GraphQLSubscriberFunc(func(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error) {
+		ch := make(chan interface{}, 2)
+		ch <- map[string]string{"user": ctx.Value(ctxUserKey{}).(string)}
+		ch <- map[string]string{"query": req.Query}
+		close(ch)
+		return ch, nil
+	})
+
+	conn := dialGraphQL(t, GraphQLConfig{
+		Subscriber: subscriber,
+		OnConnect: func(ctx context.Context, r *http.Request, payload map[string]interface{}) (context.Context, error) {
+			if payload["token"] != "secret" {
+				return nil, errors.New("bad token")
+			}
+			return context.WithValue(ctx, ctxUserKey{}, "alice"), nil
+		},
+	})
+
+	_ = conn.WriteJSON(map[string]interface{}{"type": "connection_init", "payload": map[string]string{"token": "secret"}})
+	if msg := readMessage(t, conn); msg.Type != gqlConnectionAck {
+		t.Fatalf("expected connection_ack, got %s", msg.Type)
+	}
+
+	_ = conn.WriteJSON(map[string]interface{}{"id": "1", "type": "subscribe", "payload": map[string]string{"query": "subscription { ping }"}})
+
+	first := readMessage(t, conn)
+	if first.Type != gqlNext || first.ID != "1" || !strings.Contains(string(first.Payload), "alice") {
+		t.Errorf("unexpected first message: %+v (%s)", first, first.Payload)
+	}
+	second := readMessage(t, conn)
+	if second.Type != gqlNext || !strings.Contains(string(second.Payload), "ping") {
+		t.Errorf("unexpected second message: %+v (%s)", second, second.Payload)
+	}
+	if done := readMessage(t, conn); done.Type != gqlComplete || done.ID != "1" {
+		t.Errorf("expected complete for 1, got %+v", done)
+	}
+}
+
+type ctxUserKey struct{}
+
+func TestGraphQLHandler_RejectsBadInit(t *testing.T) {
+	conn := dialGraphQL(t, GraphQLConfig{
+		Subscriber: GraphQLSubscriberFunc(func(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error) {
+			return nil, nil
+		}),
+		OnConnect: func(ctx context.Context, r *http.Request, payload map[string]interface{}) (context.Context, error) {
+			return nil, errors.New("denied")
+		},
+	})
+
+	_ = conn.WriteJSON(map[string]interface{}{"type": "connection_init"})
+	_, _, err := conn.ReadMessage()
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != closeForbidden {
+		t.Errorf("expected close code %d, got %v", closeForbidden, err)
+	}
+}
+
+func TestGraphQLHandler_SubscribeBeforeInit(t *testing.T) {
+	conn := dialGraphQL(t, GraphQLConfig{})
+
+	_ = conn.WriteJSON(map[string]interface{}{"id": "1", "type": "subscribe", "payload": map[string]string{"query": "{}"}})
+	_, _, err := conn.ReadMessage()
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != closeUnauthorized {
+		t.Errorf("expected close code %d, got %v", closeUnauthorized, err)
+	}
+}
+
+func TestGraphQLHandler_SubscriptionLimit(t *testing.T) {
+	block := GraphQLSubscriberFunc(func(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error) {
+		return make(chan interface{}), nil
+	})
+
+	conn := dialGraphQL(t, GraphQLConfig{Subscriber: block, MaxSubscriptions: 1})
+
+	_ = conn.WriteJSON(map[string]interface{}{"type": "connection_init"})
+	readMessage(t, conn)
+
+	_ = conn.WriteJSON(map[string]interface{}{"id": "1", "type": "subscribe", "payload": map[string]string{"query": "{}"}})
+	_ = conn.WriteJSON(map[string]interface{}{"id": "2", "type": "subscribe", "payload": map[string]string{"query": "{}"}})
+
+	msg := readMessage(t, conn)
+	if msg.Type != gqlError || msg.ID != "2" || !strings.Contains(string(msg.Payload), ErrTooManySubscriptions.Error()) {
+		t.Errorf("expected too many subscriptions error for 2, got %+v (%s)", msg, msg.Payload)
+	}
+}
+
+func TestGraphQLHandler_ReusedID(t *testing.T) {
+	release := make(chan struct{})
+	second := make(chan struct{})
+	var calls atomic.Int32
+	subscriber := GraphQLSubscriberFunc(func(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error) {
+		switch calls.Add(1) {
+		case 1:
+			<-release // Finishes after the client reused its ID
+			return nil, nil
+		case 2:
+			close(second)
+		}
+		return make(chan interface{}), nil
+	})
+
+	conn := dialGraphQL(t, GraphQLConfig{Subscriber: subscriber, MaxSubscriptions: 1})
+	_ = conn.WriteJSON(map[string]interface{}{"type": "connection_init"})
+	readMessage(t, conn)
+
+	subscribe := map[string]interface{}{"id": "1", "type": "subscribe", "payload": map[string]string{"query": "{}"}}
+	_ = conn.WriteJSON(subscribe)
+	_ = conn.WriteJSON(map[string]interface{}{"id": "1", "type": "complete"})
+	_ = conn.WriteJSON(subscribe)
+	<-second
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+
+	// The first subscription finishing must not drop the second
+	_ = conn.WriteJSON(map[string]interface{}{"id": "2", "type": "subscribe", "payload": map[string]string{"query": "{}"}})
+	msg := readMessage(t, conn)
+	if msg.Type != gqlError || msg.ID != "2" {
+		t.Errorf("expected too many subscriptions error for 2, got %+v (%s)", msg, msg.Payload)
+	}
+}
+
+func TestGraphQLHandler_StalledClient(t *testing.T) {
+	stopped := make(chan struct{})
+	subscriber := GraphQLSubscriberFunc(func(ctx context.Context, req GraphQLRequest) (<-chan interface{}, error) {
+		ch := make(chan interface{})
+		big := strings.Repeat("x", 64<<10)
+		go func() {
+			defer close(stopped)
+			for {
+				select {
+				case ch <- big:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch, nil
+	})
+
+	conn := dialGraphQL(t, GraphQLConfig{Subscriber: subscriber, WriteTimeout: 50 * time.Millisecond})
+	_ = conn.WriteJSON(map[string]interface{}{"type": "connection_init"})
+	if msg := readMessage(t, conn); msg.Type != gqlConnectionAck {
+		t.Fatalf("expected connection_ack, got %s", msg.Type)
+	}
+
+	// Subscribe, then stop reading
+	_ = conn.WriteJSON(map[string]interface{}{"id": "1", "type": "subscribe", "payload": map[string]string{"query": "subscription { feed }"}})
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a stalled client to end its subscriptions")
+	}
+}
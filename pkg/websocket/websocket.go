@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
@@ -50,10 +51,11 @@ func AllowAllOrigins(r *http.Request) bool {
 
 // Connection wraps websocket connection
 type Connection struct {
-	conn *websocket.Conn
-	send chan []byte
-	hub  *Hub
-	id   string
+	conn  *websocket.Conn
+	send  chan []byte
+	hub   *Hub
+	id    string
+	rooms map[string]bool // Guarded by hub.mu
 }
 
 // Hub maintains active connections
@@ -125,6 +127,75 @@ func (h *Hub) Broadcast(message []byte) {
 	h.broadcast <- message
 }
 
+// Join adds the connections of id to rooms
+func (h *Hub) Join(id string, rooms ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn := range h.connections {
+		if conn.id == id {
+			for _, room := range rooms {
+				conn.rooms[room] = true
+			}
+		}
+	}
+}
+
+// Leave removes the connections of id from rooms
+func (h *Hub) Leave(id string, rooms ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn := range h.connections {
+		if conn.id == id {
+			for _, room := range rooms {
+				delete(conn.rooms, room)
+			}
+		}
+	}
+}
+
+// BroadcastRoom sends message to the connections in room
+func (h *Hub) BroadcastRoom(room string, message []byte) {
+	h.BroadcastFunc(message, func(c *Connection) bool { return c.rooms[room] })
+}
+
+// BroadcastFunc sends message to the connections match selects. match runs
+// with the hub locked and must not call other hub methods. Connections too
+// slow to take the message are dropped, as with Broadcast.
+func (h *Hub) BroadcastFunc(message []byte, match func(c *Connection) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn := range h.connections {
+		if !match(conn) {
+			continue
+		}
+		select {
+		case conn.send <- message:
+		default:
+			close(conn.send)
+			delete(h.connections, conn)
+		}
+	}
+}
+
+// CloseAll closes every connection with a going-away close frame, e.g. from
+// an app.OnDrain hook during shutdown, and returns how many were closed.
+// Clients reconnect to another instance.
+func (h *Hub) CloseAll() int {
+	h.mu.RLock()
+	conns := make([]*Connection, 0, len(h.connections))
+	for conn := range h.connections {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, conn := range conns {
+		_ = conn.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		_ = conn.conn.Close()
+	}
+	return len(conns)
+}
+
 // ConnectionCount returns number of active connections
 func (h *Hub) ConnectionCount() int {
 	h.mu.RLock()
@@ -134,16 +205,25 @@ func (h *Hub) ConnectionCount() int {
 
 // Upgrade upgrades HTTP connection to WebSocket
 func (h *Hub) Upgrade(w http.ResponseWriter, r *http.Request, id string) error {
+	return h.UpgradeRooms(w, r, id)
+}
+
+// UpgradeRooms upgrades HTTP connection to WebSocket, joined to rooms
+func (h *Hub) UpgradeRooms(w http.ResponseWriter, r *http.Request, id string, rooms ...string) error {
 	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return err
 	}
 
 	connection := &Connection{
-		conn: conn,
-		send: make(chan []byte, 256),
-		hub:  h,
-		id:   id,
+		conn:  conn,
+		send:  make(chan []byte, 256),
+		hub:   h,
+		id:    id,
+		rooms: make(map[string]bool, len(rooms)),
+	}
+	for _, room := range rooms {
+		connection.rooms[room] = true
 	}
 
 	h.register <- connection
@@ -187,6 +267,17 @@ func (c *Connection) writePump() {
 	}
 }
 
+// ID returns the ID the connection was upgraded with
+func (c *Connection) ID() string {
+	return c.id
+}
+
+// InRoom reports whether the connection is in room. Call it from a
+// BroadcastFunc match function, which holds the hub lock.
+func (c *Connection) InRoom(room string) bool {
+	return c.rooms[room]
+}
+
 // Send sends message to this specific connection
 func (c *Connection) Send(message []byte) {
 	c.send <- message